@@ -0,0 +1,5 @@
+-- Chaos controls: artificial per-zone latency for transfer requests.
+
+ALTER TABLE zone_controls
+  ADD COLUMN IF NOT EXISTS inject_latency_ms INTEGER NOT NULL DEFAULT 0 CHECK (inject_latency_ms >= 0 AND inject_latency_ms <= 60000),
+  ADD COLUMN IF NOT EXISTS inject_jitter_ms INTEGER NOT NULL DEFAULT 0 CHECK (inject_jitter_ms >= 0 AND inject_jitter_ms <= 60000);
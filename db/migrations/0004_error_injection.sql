@@ -0,0 +1,4 @@
+-- Chaos controls: deterministic synthetic transfer failures per zone.
+
+ALTER TABLE zone_controls
+  ADD COLUMN IF NOT EXISTS error_rate_percent INTEGER NOT NULL DEFAULT 0 CHECK (error_rate_percent >= 0 AND error_rate_percent <= 100);
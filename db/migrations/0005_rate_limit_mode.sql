@@ -0,0 +1,13 @@
+-- Throttle mode selection: deterministic hash-percent (HASH) or token-bucket rate limit (RATE).
+
+ALTER TABLE zone_controls
+  ADD COLUMN IF NOT EXISTS throttle_mode TEXT NOT NULL DEFAULT 'HASH' CHECK (throttle_mode IN ('HASH','RATE')),
+  ADD COLUMN IF NOT EXISTS rate_limit_per_sec INTEGER NOT NULL DEFAULT 0 CHECK (rate_limit_per_sec >= 0),
+  ADD COLUMN IF NOT EXISTS rate_limit_burst INTEGER NOT NULL DEFAULT 0 CHECK (rate_limit_burst >= 0);
+
+-- Persisted token buckets so limits hold across replicas.
+CREATE TABLE IF NOT EXISTS zone_rate_buckets (
+  zone_id TEXT PRIMARY KEY REFERENCES zones(id) ON DELETE CASCADE,
+  tokens DOUBLE PRECISION NOT NULL,
+  refilled_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
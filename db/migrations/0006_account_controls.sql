@@ -0,0 +1,10 @@
+-- Per-account write controls for containing a single account without freezing its zone.
+-- No FK to accounts: operators may pre-emptively block an account that has not transacted yet.
+
+CREATE TABLE IF NOT EXISTS account_controls (
+  account_id TEXT PRIMARY KEY,
+  debits_blocked BOOLEAN NOT NULL DEFAULT FALSE,
+  credits_blocked BOOLEAN NOT NULL DEFAULT FALSE,
+  throttle INTEGER NOT NULL DEFAULT 100 CHECK (throttle >= 0 AND throttle <= 100),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,6 @@
+-- Zone lifecycle: zones can be created at runtime and retired (soft) once drained.
+-- Retired zones keep their row because transactions/incidents reference them.
+
+ALTER TABLE zones
+  ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  ADD COLUMN IF NOT EXISTS retired_at TIMESTAMPTZ NULL;
@@ -0,0 +1,16 @@
+-- Future-dated zone controls changes applied by a background scheduler.
+
+CREATE TABLE IF NOT EXISTS scheduled_control_changes (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  zone_id TEXT NOT NULL REFERENCES zones(id) ON DELETE CASCADE,
+  apply_at TIMESTAMPTZ NOT NULL,
+  controls JSONB NOT NULL,
+  actor TEXT NOT NULL,
+  reason TEXT NULL,
+  status TEXT NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING','APPLIED','CANCELLED','FAILED')),
+  fail_reason TEXT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  applied_at TIMESTAMPTZ NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_controls_due ON scheduled_control_changes(status, apply_at);
@@ -0,0 +1,21 @@
+-- Chaos scenarios: stored scripts of timed operator actions and their runs.
+
+CREATE TABLE IF NOT EXISTS sim_scenarios (
+  name TEXT PRIMARY KEY,
+  definition JSONB NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS scenario_runs (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  scenario_name TEXT NOT NULL REFERENCES sim_scenarios(name) ON DELETE CASCADE,
+  status TEXT NOT NULL DEFAULT 'RUNNING' CHECK (status IN ('RUNNING','COMPLETED','STOPPED','FAILED')),
+  steps_total INTEGER NOT NULL,
+  steps_done INTEGER NOT NULL DEFAULT 0,
+  log JSONB NOT NULL DEFAULT '[]'::jsonb,
+  started_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  finished_at TIMESTAMPTZ NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_scenario_runs_name_time ON scenario_runs(scenario_name, started_at DESC);
@@ -0,0 +1,12 @@
+-- Simulated network partitions: from_zone cannot reach to_zone (directional).
+
+CREATE TABLE IF NOT EXISTS zone_partitions (
+  from_zone TEXT NOT NULL REFERENCES zones(id) ON DELETE CASCADE,
+  to_zone TEXT NOT NULL REFERENCES zones(id) ON DELETE CASCADE,
+  mode TEXT NOT NULL DEFAULT 'SPOOL' CHECK (mode IN ('SPOOL','REJECT')),
+  actor TEXT NOT NULL,
+  reason TEXT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (from_zone, to_zone),
+  CHECK (from_zone <> to_zone)
+);
@@ -0,0 +1,10 @@
+-- Per-zone clock skew (simulated local clock offset) and recording order for skew reconciliation.
+
+ALTER TABLE zone_controls
+  ADD COLUMN IF NOT EXISTS clock_skew_ms BIGINT NOT NULL DEFAULT 0 CHECK (clock_skew_ms BETWEEN -86400000 AND 86400000);
+
+ALTER TABLE transactions
+  ADD COLUMN IF NOT EXISTS clock_skew_ms BIGINT NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS recorded_seq BIGSERIAL;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_recorded_seq ON transactions(recorded_seq);
@@ -0,0 +1,26 @@
+-- Named simulation runs. While a run is active, new transactions, spooled
+-- transfers and incidents are tagged with its id via the column default.
+
+CREATE TABLE IF NOT EXISTS sim_runs (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  name TEXT NOT NULL,
+  status TEXT NOT NULL DEFAULT 'RUNNING' CHECK (status IN ('RUNNING','STOPPED')),
+  actor TEXT NOT NULL,
+  reason TEXT NULL,
+  started_at TIMESTAMPTZ NOT NULL,
+  stopped_at TIMESTAMPTZ NULL
+);
+
+-- at most one active run
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sim_runs_active ON sim_runs((true)) WHERE status='RUNNING';
+
+CREATE OR REPLACE FUNCTION current_sim_run() RETURNS UUID
+  LANGUAGE sql STABLE AS $$ SELECT id FROM sim_runs WHERE status='RUNNING' LIMIT 1 $$;
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS run_id UUID NULL DEFAULT current_sim_run();
+ALTER TABLE spooled_transfers ADD COLUMN IF NOT EXISTS run_id UUID NULL DEFAULT current_sim_run();
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS run_id UUID NULL DEFAULT current_sim_run();
+
+CREATE INDEX IF NOT EXISTS idx_transactions_run ON transactions(run_id) WHERE run_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_spooled_transfers_run ON spooled_transfers(run_id) WHERE run_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_incidents_run ON incidents(run_id) WHERE run_id IS NOT NULL;
@@ -0,0 +1,8 @@
+-- Incidents change status after detection (ACK/ASSIGN/RESOLVE); updated_at lets
+-- list endpoints derive an ETag from count + max(updated_at).
+
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+UPDATE incidents SET updated_at = detected_at WHERE updated_at > detected_at;
+
+CREATE INDEX IF NOT EXISTS idx_incidents_updated_at ON incidents(updated_at);
+CREATE INDEX IF NOT EXISTS idx_incidents_zone_updated_at ON incidents(zone_id, updated_at);
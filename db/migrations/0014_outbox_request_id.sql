@@ -0,0 +1,4 @@
+-- X-Request-Id of the API call that produced an event. The outbox publisher sends
+-- it as a NATS header so consumer logs correlate with the originating request.
+
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS request_id TEXT NULL;
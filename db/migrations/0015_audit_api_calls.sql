@@ -0,0 +1,4 @@
+-- Every mutating HTTP call is audited (target_type 'http'); index the listing
+-- used for compliance review.
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_type_created ON audit_log(target_type, created_at DESC);
@@ -0,0 +1,4 @@
+-- W3C trace context (traceparent/tracestate) of the request that wrote the
+-- event; the publisher forwards it as NATS headers so consumers join the trace.
+
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS trace_context JSONB NULL;
@@ -0,0 +1,25 @@
+-- Notify 'zone_changed' with the zone id whenever a zone or its controls
+-- change, from any writer (API, scheduler, restore, the Rust service). The Go
+-- service caches zone status and controls for the transfer path and drops a
+-- zone's entry on each notification.
+
+CREATE OR REPLACE FUNCTION notify_zone_changed() RETURNS trigger AS $$
+BEGIN
+  IF TG_TABLE_NAME = 'zones' THEN
+    PERFORM pg_notify('zone_changed', COALESCE(NEW.id, OLD.id));
+  ELSE
+    PERFORM pg_notify('zone_changed', COALESCE(NEW.zone_id, OLD.zone_id));
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS zones_notify_changed ON zones;
+CREATE TRIGGER zones_notify_changed
+  AFTER INSERT OR UPDATE OR DELETE ON zones
+  FOR EACH ROW EXECUTE FUNCTION notify_zone_changed();
+
+DROP TRIGGER IF EXISTS zone_controls_notify_changed ON zone_controls;
+CREATE TRIGGER zone_controls_notify_changed
+  AFTER INSERT OR UPDATE OR DELETE ON zone_controls
+  FOR EACH ROW EXECUTE FUNCTION notify_zone_changed();
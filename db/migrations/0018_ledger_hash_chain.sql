@@ -0,0 +1,71 @@
+-- Tamper evidence: every transaction is linked into a per-zone hash chain,
+--   hash = sha256(prev_hash || ledger_chain_payload(txn))
+-- starting from 32 zero bytes. The trigger runs for every writer (Go, Rust,
+-- restore). ledger_chain_heads holds each zone's last link; its row lock
+-- orders a zone's transactions until commit. GET /v1/zones/{id}/ledger-proof
+-- recomputes the chain.
+
+CREATE TABLE IF NOT EXISTS ledger_chain (
+  txn_id UUID PRIMARY KEY REFERENCES transactions(id),
+  zone_id TEXT NOT NULL,
+  seq BIGINT NOT NULL CHECK (seq > 0),
+  prev_hash BYTEA NOT NULL,
+  hash BYTEA NOT NULL,
+  UNIQUE (zone_id, seq)
+);
+
+-- txn_id references transactions so a restore's TRUNCATE ... CASCADE resets it
+CREATE TABLE IF NOT EXISTS ledger_chain_heads (
+  zone_id TEXT PRIMARY KEY,
+  seq BIGINT NOT NULL DEFAULT 0,
+  hash BYTEA NOT NULL DEFAULT decode(repeat('00', 32), 'hex'),
+  txn_id UUID NULL REFERENCES transactions(id)
+);
+
+-- The canonical payload (v1): each field as "<byte length>:<value>", in this
+-- order; created_at is in Unix microseconds. The Go verifier builds the same
+-- bytes (ledger.chainPayload).
+CREATE OR REPLACE FUNCTION ledger_chain_payload(t transactions) RETURNS bytea
+  LANGUAGE sql IMMUTABLE AS $$
+  SELECT convert_to(string_agg(octet_length(f)::text || ':' || f, '' ORDER BY i), 'UTF8')
+  FROM unnest(ARRAY[
+    t.id::text, t.request_id, t.payload_hash, t.zone_id, t.from_account, t.to_account,
+    t.amount_units::text, (extract(epoch FROM t.created_at) * 1000000)::bigint::text
+  ]) WITH ORDINALITY AS u(f, i)
+$$;
+
+CREATE OR REPLACE FUNCTION ledger_chain_append() RETURNS trigger AS $$
+DECLARE
+  head ledger_chain_heads%ROWTYPE;
+  h bytea;
+BEGIN
+  INSERT INTO ledger_chain_heads(zone_id) VALUES (NEW.zone_id) ON CONFLICT DO NOTHING;
+  SELECT * INTO head FROM ledger_chain_heads WHERE zone_id = NEW.zone_id FOR UPDATE;
+  h := sha256(head.hash || ledger_chain_payload(NEW));
+  INSERT INTO ledger_chain(txn_id, zone_id, seq, prev_hash, hash) VALUES (NEW.id, NEW.zone_id, head.seq + 1, head.hash, h);
+  UPDATE ledger_chain_heads SET seq = head.seq + 1, hash = h, txn_id = NEW.id WHERE zone_id = NEW.zone_id;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- existing transactions are chained in recording order
+DO $$
+DECLARE
+  t transactions%ROWTYPE;
+  head ledger_chain_heads%ROWTYPE;
+  h bytea;
+BEGIN
+  FOR t IN SELECT * FROM transactions tx WHERE NOT EXISTS (SELECT 1 FROM ledger_chain c WHERE c.txn_id = tx.id) ORDER BY recorded_seq LOOP
+    INSERT INTO ledger_chain_heads(zone_id) VALUES (t.zone_id) ON CONFLICT DO NOTHING;
+    SELECT * INTO head FROM ledger_chain_heads WHERE zone_id = t.zone_id;
+    h := sha256(head.hash || ledger_chain_payload(t));
+    INSERT INTO ledger_chain(txn_id, zone_id, seq, prev_hash, hash) VALUES (t.id, t.zone_id, head.seq + 1, head.hash, h);
+    UPDATE ledger_chain_heads SET seq = head.seq + 1, hash = h, txn_id = t.id WHERE zone_id = t.zone_id;
+  END LOOP;
+END $$;
+
+-- AFTER, so rows skipped by INSERT ... ON CONFLICT DO NOTHING are not chained
+DROP TRIGGER IF EXISTS transactions_chain ON transactions;
+CREATE TRIGGER transactions_chain
+  AFTER INSERT ON transactions
+  FOR EACH ROW EXECUTE FUNCTION ledger_chain_append();
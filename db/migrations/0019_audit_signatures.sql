@@ -0,0 +1,9 @@
+-- Signed audit entries. The Go service signs each entry it writes when
+-- AUDIT_SIGNING_KEY is set; entries from other writers (and older ones) have
+-- no signature and are reported as unsigned by GET /v1/audit/verify.
+
+ALTER TABLE audit_log
+  ADD COLUMN IF NOT EXISTS signature BYTEA NULL,
+  ADD COLUMN IF NOT EXISTS sig_key_id TEXT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_created ON audit_log(created_at, id);
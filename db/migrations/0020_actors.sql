@@ -0,0 +1,25 @@
+-- Directory of the people, services and scenarios that act on the sim. With
+-- REQUIRE_KNOWN_ACTORS the Go service rejects status, controls and incident
+-- changes whose actor is not registered here (or is disabled).
+
+CREATE TABLE IF NOT EXISTS actors (
+  id TEXT PRIMARY KEY,
+  kind TEXT NOT NULL CHECK (kind IN ('HUMAN','SERVICE','SCENARIO')),
+  name TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  disabled_at TIMESTAMPTZ NULL
+);
+
+-- the Go service's own background writers
+INSERT INTO actors(id,kind,name) VALUES
+  ('system','SERVICE','Transfer path'),
+  ('scheduler','SERVICE','Control scheduler')
+ON CONFLICT (id) DO NOTHING;
+
+-- scenarios act as scenario:<name>; new uploads register themselves
+INSERT INTO actors(id,kind,name)
+SELECT 'scenario:' || name, 'SCENARIO', name FROM sim_scenarios
+ON CONFLICT (id) DO NOTHING;
+
+-- per-actor activity reports
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log(actor, created_at);
@@ -0,0 +1,24 @@
+-- Two-person rule: with TWO_PERSON_RULE the Go service records dangerous
+-- operator actions (zone DOWN, blocking writes, snapshot restore) here as
+-- PENDING, and performs them only when a second operator approves.
+
+CREATE TABLE IF NOT EXISTS approvals (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  kind TEXT NOT NULL CHECK (kind IN ('ZONE_STATUS','ZONE_CONTROLS','RESTORE')),
+  target_type TEXT NOT NULL,
+  target_id TEXT NOT NULL,
+  payload JSONB NOT NULL DEFAULT '{}'::jsonb,
+  body BYTEA NULL, -- the snapshot of a RESTORE, as uploaded
+  status TEXT NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING','APPROVED','REJECTED','FAILED')),
+  requested_by TEXT NOT NULL,
+  reason TEXT NULL,
+  decided_by TEXT NULL,
+  decision_reason TEXT NULL,
+  result JSONB NULL,
+  fail_reason TEXT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  expires_at TIMESTAMPTZ NOT NULL,
+  decided_at TIMESTAMPTZ NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_approvals_pending ON approvals(created_at) WHERE status='PENDING';
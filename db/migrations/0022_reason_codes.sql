@@ -0,0 +1,24 @@
+-- Reason codes: a managed catalog of why operators change zones. Status,
+-- controls and spool replay changes carry one (in the audit entry's
+-- details.reason_code) next to the free-text reason, so incident reviews can
+-- count actions by cause. REQUIRE_REASON_CODE makes the code mandatory.
+
+CREATE TABLE IF NOT EXISTS reason_codes (
+  code TEXT PRIMARY KEY,
+  description TEXT NOT NULL,
+  applies_to TEXT[] NOT NULL DEFAULT '{}', -- audit actions it may be used for; empty: all
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  retired_at TIMESTAMPTZ NULL
+);
+
+INSERT INTO reason_codes(code, description) VALUES
+  ('INCIDENT_RESPONSE', 'Containing or mitigating an active incident'),
+  ('RECOVERY', 'Restoring normal service after an incident'),
+  ('MAINTENANCE', 'Planned maintenance window'),
+  ('CAPACITY', 'Shedding or rebalancing load'),
+  ('DRILL', 'Game day or training exercise'),
+  ('SCENARIO', 'Step of a scripted chaos scenario'),
+  ('OTHER', 'None of the above; explain in the reason')
+ON CONFLICT (code) DO NOTHING;
+
+ALTER TABLE scheduled_control_changes ADD COLUMN IF NOT EXISTS reason_code TEXT NULL;
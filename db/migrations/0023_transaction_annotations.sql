@@ -0,0 +1,15 @@
+-- Investigator notes and tags on posted transactions. Kept apart from
+-- transactions.metadata, which is the transfer as submitted and never changes
+-- after posting. Append-only, like the audit log.
+
+CREATE TABLE IF NOT EXISTS transaction_annotations (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  txn_id UUID NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+  note TEXT NOT NULL DEFAULT '',
+  tags TEXT[] NOT NULL DEFAULT '{}',
+  actor TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_txn_annotations_txn ON transaction_annotations(txn_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_txn_annotations_tags ON transaction_annotations USING GIN (tags);
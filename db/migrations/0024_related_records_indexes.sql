@@ -0,0 +1,7 @@
+-- Lookups behind GET /v1/transactions/{id}/related: incidents and outbox
+-- events by transaction, and audit entries by target or by request id.
+
+CREATE INDEX IF NOT EXISTS idx_incidents_related_txn ON incidents(related_txn_id) WHERE related_txn_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_aggregate ON outbox_events(aggregate_type, aggregate_id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_target ON audit_log(target_type, target_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_log_request_id ON audit_log((details->>'request_id')) WHERE details ? 'request_id';
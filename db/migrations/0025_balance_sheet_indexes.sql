@@ -0,0 +1,5 @@
+-- GET /v1/zones/{id}/balance-sheet reads a zone's accounts' postings over a
+-- time range.
+
+CREATE INDEX IF NOT EXISTS idx_accounts_zone ON accounts(zone_id);
+CREATE INDEX IF NOT EXISTS idx_postings_account_time ON postings(account_id, created_at);
@@ -0,0 +1,34 @@
+-- The zones a transaction moved value between, for GET /v1/flows. A trigger
+-- fills them from the accounts' zones on insert, so every writer (the Go and
+-- Rust services, snapshot restore) records them; accounts created by a
+-- transfer live in its zone. Existing rows are backfilled the same way.
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS from_zone_id TEXT NULL;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS to_zone_id TEXT NULL;
+
+CREATE OR REPLACE FUNCTION transactions_set_zones() RETURNS trigger
+LANGUAGE plpgsql AS $$
+BEGIN
+  IF NEW.from_zone_id IS NULL THEN
+    NEW.from_zone_id := COALESCE((SELECT zone_id FROM accounts WHERE id = NEW.from_account), NEW.zone_id);
+  END IF;
+  IF NEW.to_zone_id IS NULL THEN
+    NEW.to_zone_id := COALESCE((SELECT zone_id FROM accounts WHERE id = NEW.to_account), NEW.zone_id);
+  END IF;
+  RETURN NEW;
+END $$;
+
+DROP TRIGGER IF EXISTS transactions_zones ON transactions;
+CREATE TRIGGER transactions_zones
+  BEFORE INSERT ON transactions
+  FOR EACH ROW EXECUTE FUNCTION transactions_set_zones();
+
+UPDATE transactions t SET
+  from_zone_id = COALESCE((SELECT zone_id FROM accounts WHERE id = t.from_account), t.zone_id),
+  to_zone_id = COALESCE((SELECT zone_id FROM accounts WHERE id = t.to_account), t.zone_id)
+WHERE t.from_zone_id IS NULL OR t.to_zone_id IS NULL;
+
+ALTER TABLE transactions ALTER COLUMN from_zone_id SET NOT NULL;
+ALTER TABLE transactions ALTER COLUMN to_zone_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_created_zones ON transactions(created_at) INCLUDE (from_zone_id, to_zone_id, amount_units);
@@ -0,0 +1,12 @@
+-- Free-form tags on accounts (merchant, consumer, treasury, ...) for
+-- segmenting simulated traffic. Stored lower-cased, one row per tag.
+
+CREATE TABLE IF NOT EXISTS account_tags (
+  account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+  tag TEXT NOT NULL,
+  actor TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (account_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_tags_tag ON account_tags(tag, account_id);
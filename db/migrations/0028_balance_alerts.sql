@@ -0,0 +1,15 @@
+-- Negative balance monitor state: one row per account or zone currently below
+-- its floor, pointing at the incident it opened. The row outlives an operator
+-- resolving the incident and is only removed once the balance recovers past
+-- the floor plus the hysteresis margin, so a balance hovering at the floor
+-- opens one incident rather than one per check.
+CREATE TABLE IF NOT EXISTS balance_alerts (
+  scope TEXT NOT NULL CHECK (scope IN ('account','zone')),
+  subject TEXT NOT NULL,
+  zone_id TEXT NOT NULL REFERENCES zones(id),
+  incident_id UUID NULL REFERENCES incidents(id) ON DELETE SET NULL,
+  balance_units BIGINT NOT NULL,
+  floor_units BIGINT NOT NULL,
+  opened_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (scope, subject)
+);
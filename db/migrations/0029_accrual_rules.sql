@@ -0,0 +1,24 @@
+-- Interest and decay accrual rules. Each run posts one transfer per matching
+-- account against the rule's sink account, then moves next_run_at on by one
+-- period, so a sim clock jump is caught up period by period.
+
+CREATE TABLE IF NOT EXISTS accrual_rules (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  zone_id TEXT NOT NULL REFERENCES zones(id),
+  kind TEXT NOT NULL CHECK (kind IN ('INTEREST','DECAY')),
+  rate_bps INTEGER NOT NULL CHECK (rate_bps > 0 AND rate_bps <= 10000),
+  period_seconds BIGINT NOT NULL CHECK (period_seconds > 0),
+  account_tag TEXT NULL,
+  min_balance_units BIGINT NOT NULL DEFAULT 1,
+  sink_account TEXT NOT NULL, -- no FK: a restore that resets accounts keeps the rules; runs recreate it
+  status TEXT NOT NULL DEFAULT 'ACTIVE' CHECK (status IN ('ACTIVE','DISABLED')),
+  runs BIGINT NOT NULL DEFAULT 0,
+  next_run_at TIMESTAMPTZ NOT NULL,
+  last_run_at TIMESTAMPTZ NULL,
+  last_run_units BIGINT NOT NULL DEFAULT 0,
+  actor TEXT NOT NULL,
+  reason TEXT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_accrual_rules_due ON accrual_rules(next_run_at) WHERE status = 'ACTIVE';
@@ -0,0 +1,28 @@
+-- Recurring transfer definitions. The control scheduler turns each due run
+-- into a regular transfer (request_id recurring-<id>-<run>), so zone gates,
+-- spooling and idempotency apply as for any client transfer.
+
+CREATE TABLE IF NOT EXISTS recurring_transfers (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  name TEXT NULL,
+  zone_id TEXT NOT NULL REFERENCES zones(id),
+  from_account TEXT NOT NULL,
+  to_account TEXT NOT NULL,
+  amount_units BIGINT NOT NULL CHECK (amount_units > 0),
+  cron TEXT NULL,
+  interval_seconds BIGINT NULL CHECK (interval_seconds > 0),
+  metadata JSONB NOT NULL DEFAULT '{}'::jsonb,
+  status TEXT NOT NULL DEFAULT 'ACTIVE' CHECK (status IN ('ACTIVE','PAUSED','CANCELLED')),
+  runs BIGINT NOT NULL DEFAULT 0,
+  next_run_at TIMESTAMPTZ NULL,
+  last_run_at TIMESTAMPTZ NULL,
+  last_outcome TEXT NULL,
+  last_error TEXT NULL,
+  actor TEXT NOT NULL,
+  reason TEXT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  CHECK ((cron IS NULL) <> (interval_seconds IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_recurring_transfers_due ON recurring_transfers(next_run_at) WHERE status = 'ACTIVE';
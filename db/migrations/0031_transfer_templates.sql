@@ -0,0 +1,15 @@
+-- Named transfer templates: a predefined transfer that demo drivers and
+-- scenarios fire by name, overriding fields per call.
+
+CREATE TABLE IF NOT EXISTS transfer_templates (
+  name TEXT PRIMARY KEY,
+  description TEXT NULL,
+  zone_id TEXT NOT NULL REFERENCES zones(id),
+  from_account TEXT NOT NULL,
+  to_account TEXT NOT NULL,
+  amount_units BIGINT NOT NULL CHECK (amount_units > 0),
+  metadata JSONB NOT NULL DEFAULT '{}'::jsonb,
+  actor TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,23 @@
+-- Two-phase transfers. A prepare passes the transfer gates and holds the
+-- amount against from_account until it is committed (posting a regular
+-- transaction with the prepare's request_id), aborted, or expires.
+
+CREATE TABLE IF NOT EXISTS transfer_prepares (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(), -- the prepare token
+  request_id TEXT NOT NULL UNIQUE,
+  payload_hash TEXT NOT NULL,
+  zone_id TEXT NOT NULL REFERENCES zones(id),
+  from_account TEXT NOT NULL,
+  to_account TEXT NOT NULL,
+  amount_units BIGINT NOT NULL CHECK (amount_units > 0),
+  metadata JSONB NOT NULL DEFAULT '{}'::jsonb,
+  status TEXT NOT NULL DEFAULT 'PREPARED' CHECK (status IN ('PREPARED','COMMITTED','ABORTED','EXPIRED')),
+  expires_at TIMESTAMPTZ NOT NULL,
+  txn_id UUID NULL,
+  abort_reason TEXT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  resolved_at TIMESTAMPTZ NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_transfer_prepares_pending ON transfer_prepares(expires_at) WHERE status = 'PREPARED';
+CREATE INDEX IF NOT EXISTS idx_transfer_prepares_held ON transfer_prepares(from_account) WHERE status = 'PREPARED';
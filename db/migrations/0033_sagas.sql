@@ -0,0 +1,42 @@
+-- Multi-zone transfer sagas. A saga debits from_account into the source
+-- zone's transit account, then credits to_account from the destination
+-- zone's transit account; when the credit cannot be made the debit is
+-- compensated. Each step is triggered by a SAGA_STEP_DUE outbox event that
+-- the saga consumer picks up from JetStream.
+
+CREATE TABLE IF NOT EXISTS sagas (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  request_id TEXT NOT NULL UNIQUE,
+  payload_hash TEXT NOT NULL,
+  from_zone TEXT NOT NULL REFERENCES zones(id),
+  to_zone TEXT NOT NULL REFERENCES zones(id),
+  from_account TEXT NOT NULL,
+  to_account TEXT NOT NULL,
+  amount_units BIGINT NOT NULL CHECK (amount_units > 0),
+  metadata JSONB NOT NULL DEFAULT '{}'::jsonb,
+  status TEXT NOT NULL DEFAULT 'RUNNING' CHECK (status IN ('RUNNING','COMPLETED','COMPENSATED','FAILED')),
+  step TEXT NULL CHECK (step IN ('DEBIT','CREDIT','COMPENSATE')), -- NULL once finished
+  step_started_at TIMESTAMPTZ NOT NULL,
+  step_timeout_seconds INTEGER NOT NULL CHECK (step_timeout_seconds > 0),
+  attempts INTEGER NOT NULL DEFAULT 0,
+  next_attempt_at TIMESTAMPTZ NULL,
+  last_error TEXT NULL,
+  created_at TIMESTAMPTZ NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_sagas_retry ON sagas(next_attempt_at) WHERE status = 'RUNNING';
+
+-- One row per step attempt, for GET /v1/sagas/{id}.
+CREATE TABLE IF NOT EXISTS saga_steps (
+  id BIGSERIAL PRIMARY KEY,
+  saga_id UUID NOT NULL REFERENCES sagas(id) ON DELETE CASCADE,
+  step TEXT NOT NULL,
+  attempt INTEGER NOT NULL,
+  outcome TEXT NOT NULL CHECK (outcome IN ('APPLIED','BLOCKED','REJECTED')),
+  transaction_id UUID NULL,
+  reason TEXT NULL,
+  at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_saga_steps_saga ON saga_steps(saga_id, id);
@@ -0,0 +1,39 @@
+-- Inter-zone settlement. A settlement run nets the cross-zone transactions
+-- not yet settled into one obligation per zone pair and posts each non-zero
+-- net as a transaction between the two zones' settlement accounts.
+
+CREATE TABLE IF NOT EXISTS settlement_runs (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  cutoff_at TIMESTAMPTZ NOT NULL, -- sim clock; transactions created up to here were eligible
+  transfers INTEGER NOT NULL DEFAULT 0,
+  gross_units BIGINT NOT NULL DEFAULT 0,
+  net_units BIGINT NOT NULL DEFAULT 0,
+  settlements INTEGER NOT NULL DEFAULT 0, -- settlement transactions posted
+  actor TEXT NOT NULL,
+  reason TEXT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_settlement_runs_created ON settlement_runs(created_at DESC);
+
+-- One row per zone pair a run settled. payer_zone owes payee_zone net_units:
+-- gross_units moved from payer to payee accounts and reverse_units back.
+CREATE TABLE IF NOT EXISTS settlement_obligations (
+  run_id UUID NOT NULL REFERENCES settlement_runs(id) ON DELETE CASCADE,
+  payer_zone TEXT NOT NULL,
+  payee_zone TEXT NOT NULL,
+  transfers INTEGER NOT NULL,
+  gross_units BIGINT NOT NULL,
+  reverse_units BIGINT NOT NULL,
+  net_units BIGINT NOT NULL CHECK (net_units >= 0),
+  transaction_id UUID NULL, -- NULL when the pair netted to zero
+  PRIMARY KEY (run_id, payer_zone, payee_zone)
+);
+
+-- Every transaction a run covered, including its own settlement
+-- transactions, so no transaction is settled twice. No foreign key to
+-- transactions: a restore truncates them and brings back the same ids.
+CREATE TABLE IF NOT EXISTS settlement_items (
+  txn_id UUID PRIMARY KEY,
+  run_id UUID NOT NULL REFERENCES settlement_runs(id) ON DELETE CASCADE
+);
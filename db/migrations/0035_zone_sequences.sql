@@ -0,0 +1,49 @@
+-- Per-zone transaction sequence numbers for gapless incremental sync
+-- (GET /v1/zones/{id}/transactions?after_seq=). A trigger allocates the next
+-- number of the transaction's zone on insert, so every writer records one.
+-- The zone's counter row stays locked until the inserting transaction ends:
+-- a rolled-back insert gives its number back, and a zone's numbers commit in
+-- order, so a reader that saw seq n has already seen every seq below it.
+
+CREATE TABLE IF NOT EXISTS zone_sequences (
+  zone_id TEXT PRIMARY KEY,
+  last_seq BIGINT NOT NULL
+);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS zone_seq BIGINT NULL;
+
+CREATE OR REPLACE FUNCTION transactions_set_zone_seq() RETURNS trigger
+LANGUAGE plpgsql AS $$
+BEGIN
+  -- restore brings the original numbers
+  IF NEW.zone_seq IS NOT NULL THEN
+    INSERT INTO zone_sequences(zone_id, last_seq) VALUES (NEW.zone_id, NEW.zone_seq)
+    ON CONFLICT (zone_id) DO UPDATE SET last_seq = GREATEST(zone_sequences.last_seq, EXCLUDED.last_seq);
+    RETURN NEW;
+  END IF;
+  INSERT INTO zone_sequences(zone_id, last_seq) VALUES (NEW.zone_id, 0) ON CONFLICT (zone_id) DO NOTHING;
+  PERFORM 1 FROM zone_sequences WHERE zone_id = NEW.zone_id FOR UPDATE;
+  -- a request_id recorded first (seen now that the lock is held) makes the
+  -- insert a no-op under ON CONFLICT DO NOTHING; do not spend a number on it
+  IF EXISTS (SELECT 1 FROM transactions WHERE request_id = NEW.request_id) THEN RETURN NEW; END IF;
+  UPDATE zone_sequences SET last_seq = last_seq + 1 WHERE zone_id = NEW.zone_id RETURNING last_seq INTO NEW.zone_seq;
+  RETURN NEW;
+END $$;
+
+DROP TRIGGER IF EXISTS transactions_zone_seq ON transactions;
+CREATE TRIGGER transactions_zone_seq
+  BEFORE INSERT ON transactions
+  FOR EACH ROW EXECUTE FUNCTION transactions_set_zone_seq();
+
+-- existing rows are numbered in the order they were recorded
+UPDATE transactions t SET zone_seq = n.seq
+FROM (SELECT id, row_number() OVER (PARTITION BY zone_id ORDER BY recorded_seq) AS seq FROM transactions) n
+WHERE t.id = n.id AND t.zone_seq IS NULL;
+
+INSERT INTO zone_sequences(zone_id, last_seq)
+SELECT zone_id, MAX(zone_seq) FROM transactions GROUP BY zone_id
+ON CONFLICT (zone_id) DO UPDATE SET last_seq = GREATEST(zone_sequences.last_seq, EXCLUDED.last_seq);
+
+ALTER TABLE transactions ALTER COLUMN zone_seq SET NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_zone_seq ON transactions(zone_id, zone_seq);
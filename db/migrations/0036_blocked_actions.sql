@@ -0,0 +1,8 @@
+-- Per-cause behaviour for blocked transfers: blocked_actions maps a cause
+-- (zone_down, writes_blocked, throttled, rate_limited) to SPOOL, REJECT or
+-- DELAY. Causes it does not name keep spool_enabled's zone-wide behaviour.
+-- DELAY holds the request in-process for blocked_delay_ms, then looks again.
+
+ALTER TABLE zone_controls
+  ADD COLUMN IF NOT EXISTS blocked_actions JSONB NOT NULL DEFAULT '{}'::jsonb,
+  ADD COLUMN IF NOT EXISTS blocked_delay_ms INT NOT NULL DEFAULT 0 CHECK (blocked_delay_ms BETWEEN 0 AND 10000);
@@ -0,0 +1,23 @@
+-- Gradual throttle ramps: a background controller raises a zone's hash
+-- throttle from from_percent to 100% in audited steps of step_percent,
+-- spread evenly over duration_seconds. One ramp runs per zone at a time.
+
+CREATE TABLE IF NOT EXISTS throttle_ramps (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  zone_id TEXT NOT NULL REFERENCES zones(id) ON DELETE CASCADE,
+  from_percent INT NOT NULL CHECK (from_percent BETWEEN 1 AND 99),
+  step_percent INT NOT NULL CHECK (step_percent BETWEEN 1 AND 99),
+  duration_seconds INT NOT NULL CHECK (duration_seconds > 0),
+  current_percent INT NOT NULL CHECK (current_percent BETWEEN 0 AND 100),
+  status TEXT NOT NULL DEFAULT 'RUNNING' CHECK (status IN ('RUNNING','COMPLETED','CANCELLED','FAILED')),
+  actor TEXT NOT NULL,
+  reason TEXT NULL,
+  fail_reason TEXT NULL,
+  started_at TIMESTAMPTZ NOT NULL,
+  next_step_at TIMESTAMPTZ NULL,
+  finished_at TIMESTAMPTZ NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_throttle_ramps_running ON throttle_ramps(zone_id) WHERE status='RUNNING';
+CREATE INDEX IF NOT EXISTS idx_throttle_ramps_due ON throttle_ramps(next_step_at) WHERE status='RUNNING';
+CREATE INDEX IF NOT EXISTS idx_throttle_ramps_zone ON throttle_ramps(zone_id, started_at DESC);
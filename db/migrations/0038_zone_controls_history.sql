@@ -0,0 +1,45 @@
+-- Versioned zone controls: every insert or update of a zone_controls row, from
+-- any writer (API, scheduler, ramps, restore, the Rust service), records the
+-- full resulting state as the zone's next version. Writers that know who made
+-- the change set the transaction-local ledger.controls_actor,
+-- ledger.controls_reason_code and ledger.controls_reason first; others leave
+-- them NULL.
+
+CREATE TABLE IF NOT EXISTS zone_controls_history (
+  zone_id TEXT NOT NULL REFERENCES zones(id) ON DELETE CASCADE,
+  version BIGINT NOT NULL,
+  controls JSONB NOT NULL,
+  actor TEXT NULL,
+  reason_code TEXT NULL,
+  reason TEXT NULL,
+  changed_at TIMESTAMPTZ NOT NULL,
+  PRIMARY KEY (zone_id, version)
+);
+
+CREATE INDEX IF NOT EXISTS idx_zone_controls_history_changed ON zone_controls_history(zone_id, changed_at);
+
+-- the version is allocated under the zone_controls row lock the write holds
+CREATE OR REPLACE FUNCTION record_zone_controls_version() RETURNS trigger
+LANGUAGE plpgsql AS $$
+BEGIN
+  INSERT INTO zone_controls_history(zone_id, version, controls, actor, reason_code, reason, changed_at)
+  SELECT NEW.zone_id,
+    COALESCE((SELECT MAX(version) FROM zone_controls_history WHERE zone_id = NEW.zone_id), 0) + 1,
+    to_jsonb(NEW) - 'zone_id' - 'updated_at',
+    NULLIF(current_setting('ledger.controls_actor', true), ''),
+    NULLIF(current_setting('ledger.controls_reason_code', true), ''),
+    NULLIF(current_setting('ledger.controls_reason', true), ''),
+    NEW.updated_at;
+  RETURN NULL;
+END $$;
+
+DROP TRIGGER IF EXISTS zone_controls_record_version ON zone_controls;
+CREATE TRIGGER zone_controls_record_version
+  AFTER INSERT OR UPDATE ON zone_controls
+  FOR EACH ROW EXECUTE FUNCTION record_zone_controls_version();
+
+-- the current state is each zone's first version
+INSERT INTO zone_controls_history(zone_id, version, controls, changed_at)
+SELECT c.zone_id, 1, to_jsonb(c) - 'zone_id' - 'updated_at', c.updated_at
+FROM zone_controls c
+ON CONFLICT DO NOTHING;
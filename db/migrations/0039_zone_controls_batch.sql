@@ -0,0 +1,5 @@
+-- Controls batches that block writes are held for approval like single changes.
+
+ALTER TABLE approvals DROP CONSTRAINT IF EXISTS approvals_kind_check;
+ALTER TABLE approvals ADD CONSTRAINT approvals_kind_check
+  CHECK (kind IN ('ZONE_STATUS','ZONE_CONTROLS','ZONE_CONTROLS_BATCH','RESTORE'));
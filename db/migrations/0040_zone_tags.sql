@@ -0,0 +1,6 @@
+-- Zone tags (region=EMEA, tier=critical) for selecting zones in sets.
+
+ALTER TABLE zones ADD COLUMN IF NOT EXISTS tags JSONB NOT NULL DEFAULT '{}';
+CREATE INDEX IF NOT EXISTS zones_tags_idx ON zones USING GIN (tags);
+ALTER TABLE zones DROP CONSTRAINT IF EXISTS zones_tags_object;
+ALTER TABLE zones ADD CONSTRAINT zones_tags_object CHECK (jsonb_typeof(tags) = 'object');
@@ -0,0 +1,14 @@
+-- Spool depth sampled per zone on an interval (sim clock), so reviews can
+-- chart how a backlog built up and drained. Old samples are pruned by the
+-- sampler.
+
+CREATE TABLE IF NOT EXISTS spool_depth_samples (
+  zone_id TEXT NOT NULL REFERENCES zones(id) ON DELETE CASCADE,
+  sampled_at TIMESTAMPTZ NOT NULL,
+  pending BIGINT NOT NULL,
+  failed BIGINT NOT NULL,
+  oldest_pending_at TIMESTAMPTZ NULL,
+  PRIMARY KEY (zone_id, sampled_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_spool_depth_samples_at ON spool_depth_samples(sampled_at);
@@ -0,0 +1,25 @@
+-- Spool replay as a background job: a worker replays a zone's pending spool
+-- in rate-limited batches until it is empty, the job's limit is reached or
+-- the job is cancelled. One job runs per zone at a time.
+
+CREATE TABLE IF NOT EXISTS replay_jobs (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  zone_id TEXT NOT NULL REFERENCES zones(id) ON DELETE CASCADE,
+  status TEXT NOT NULL DEFAULT 'RUNNING' CHECK (status IN ('RUNNING','COMPLETED','CANCELLED','FAILED')),
+  rate_per_sec INT NOT NULL CHECK (rate_per_sec BETWEEN 1 AND 500),
+  max_items INT NOT NULL DEFAULT 0 CHECK (max_items >= 0),
+  applied INT NOT NULL DEFAULT 0,
+  failed INT NOT NULL DEFAULT 0,
+  skipped INT NOT NULL DEFAULT 0,
+  remaining BIGINT NOT NULL DEFAULT 0,
+  actor TEXT NOT NULL,
+  reason_code TEXT NULL,
+  reason TEXT NULL,
+  fail_reason TEXT NULL,
+  created_at TIMESTAMPTZ NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL,
+  finished_at TIMESTAMPTZ NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_replay_jobs_running ON replay_jobs(zone_id) WHERE status='RUNNING';
+CREATE INDEX IF NOT EXISTS idx_replay_jobs_zone ON replay_jobs(zone_id, created_at DESC);
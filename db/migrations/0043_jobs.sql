@@ -0,0 +1,41 @@
+-- Background jobs: any replica's worker pool claims a due job under a short
+-- lease, runs one step of it and records the progress. Long-running features
+-- (spool replay, exports) are job kinds. Replay jobs move here from
+-- replay_jobs.
+
+CREATE TABLE IF NOT EXISTS jobs (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  kind TEXT NOT NULL,
+  status TEXT NOT NULL DEFAULT 'QUEUED' CHECK (status IN ('QUEUED','RUNNING','COMPLETED','CANCELLED','FAILED')),
+  target TEXT NULL, -- what the job works on, e.g. a zone id
+  dedupe_key TEXT NULL, -- at most one queued or running job per key
+  params JSONB NOT NULL DEFAULT '{}'::jsonb,
+  progress JSONB NULL,
+  result JSONB NULL,
+  error TEXT NULL,
+  steps INT NOT NULL DEFAULT 0,
+  actor TEXT NOT NULL,
+  reason TEXT NULL,
+  run_after TIMESTAMPTZ NOT NULL DEFAULT now(), -- wall clock
+  lease_until TIMESTAMPTZ NULL, -- wall clock; set while a worker runs a step
+  created_at TIMESTAMPTZ NOT NULL,
+  started_at TIMESTAMPTZ NULL,
+  updated_at TIMESTAMPTZ NOT NULL,
+  finished_at TIMESTAMPTZ NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_dedupe ON jobs(dedupe_key) WHERE status IN ('QUEUED','RUNNING');
+CREATE INDEX IF NOT EXISTS idx_jobs_due ON jobs(run_after) WHERE status IN ('QUEUED','RUNNING');
+CREATE INDEX IF NOT EXISTS idx_jobs_kind ON jobs(kind, target, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_jobs_finished ON jobs(finished_at) WHERE finished_at IS NOT NULL;
+
+INSERT INTO jobs(id, kind, status, target, dedupe_key, params, progress, error, actor, reason, created_at, started_at, updated_at, finished_at)
+SELECT id, 'REPLAY_SPOOL', status, zone_id,
+  CASE WHEN status='RUNNING' THEN 'replay-spool:' || zone_id END,
+  jsonb_strip_nulls(jsonb_build_object('zone_id', zone_id, 'rate_per_sec', rate_per_sec, 'max_items', max_items, 'reason_code', reason_code)),
+  jsonb_build_object('applied', applied, 'failed', failed, 'skipped', skipped, 'remaining', remaining),
+  fail_reason, actor, reason, created_at, created_at, updated_at, finished_at
+FROM replay_jobs
+ON CONFLICT (id) DO NOTHING;
+
+DROP TABLE IF EXISTS replay_jobs;
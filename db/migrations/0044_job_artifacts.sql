@@ -0,0 +1,23 @@
+-- Files produced by background jobs (exports), kept in the database in
+-- chunks so any replica can serve the download. An artifact's chunks are
+-- dropped when it expires; the row stays until its job is pruned so a late
+-- download can tell expired from missing.
+
+CREATE TABLE IF NOT EXISTS job_artifacts (
+  job_id UUID PRIMARY KEY REFERENCES jobs(id) ON DELETE CASCADE,
+  filename TEXT NOT NULL,
+  content_type TEXT NOT NULL,
+  bytes BIGINT NOT NULL DEFAULT 0,
+  complete BOOLEAN NOT NULL DEFAULT false,
+  created_at TIMESTAMPTZ NOT NULL,
+  expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_artifacts_expires ON job_artifacts(expires_at);
+
+CREATE TABLE IF NOT EXISTS job_artifact_chunks (
+  job_id UUID NOT NULL REFERENCES job_artifacts(job_id) ON DELETE CASCADE,
+  seq INT NOT NULL,
+  data BYTEA NOT NULL,
+  PRIMARY KEY (job_id, seq)
+);
@@ -0,0 +1,22 @@
+-- Per-minute rollups of transfers, moved amounts, spooled transfers and
+-- opened incidents per zone, written by the stats rollup worker so
+-- dashboards chart time series without scanning the raw tables.
+-- stats_rollup_state holds how far the worker has rolled up.
+
+CREATE TABLE IF NOT EXISTS stats_buckets (
+  metric TEXT NOT NULL,
+  zone_id TEXT NOT NULL REFERENCES zones(id) ON DELETE CASCADE,
+  bucket_start TIMESTAMPTZ NOT NULL, -- start of the minute
+  value BIGINT NOT NULL,
+  PRIMARY KEY (metric, bucket_start, zone_id)
+);
+
+CREATE TABLE IF NOT EXISTS stats_rollup_state (
+  id BOOLEAN PRIMARY KEY DEFAULT true CHECK (id),
+  rolled_up_to TIMESTAMPTZ NULL -- buckets before this are final, barring late rows
+);
+
+INSERT INTO stats_rollup_state(id) VALUES (true) ON CONFLICT DO NOTHING;
+
+CREATE INDEX IF NOT EXISTS idx_spooled_transfers_created ON spooled_transfers(created_at);
+CREATE INDEX IF NOT EXISTS idx_incidents_detected ON incidents(detected_at);
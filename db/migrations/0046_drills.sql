@@ -0,0 +1,24 @@
+-- Training drills run by a game master: scripted and ad hoc injects
+-- (synthetic incidents, fake fraud hits, zone flapping) against a set of
+-- trainees. The game master's audit entries carry details.drill_id and stay
+-- out of the trainees' audit views until the drill ends. One drill runs at a
+-- time.
+
+CREATE TABLE IF NOT EXISTS drills (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  name TEXT NOT NULL,
+  description TEXT NULL,
+  injects JSONB NOT NULL DEFAULT '[]'::jsonb, -- the script, plus ad hoc injects as they are made
+  trainees TEXT[] NOT NULL DEFAULT '{}', -- empty: anyone who is not a scenario
+  log JSONB NOT NULL DEFAULT '[]'::jsonb, -- one entry per executed inject
+  started_by TEXT NOT NULL,
+  started_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  ended_by TEXT NULL,
+  ended_at TIMESTAMPTZ NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_drills_running ON drills((true)) WHERE ended_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_drills_started ON drills(started_at DESC);
+
+-- hidden-entry lookups filter on details->>'drill_id'
+CREATE INDEX IF NOT EXISTS idx_audit_log_drill ON audit_log((details->>'drill_id')) WHERE details ? 'drill_id';
@@ -0,0 +1,175 @@
+-- Tenants: independent simulations (training cohorts) sharing a deployment.
+-- Every row of the simulation's tables carries tenant_id and row-level
+-- security scopes a session to the tenant in app.tenant. The Go service runs
+-- a tenant's queries as sim_tenant with app.tenant set (MULTI_TENANT=true).
+-- Sessions that set no tenant (the service's own role, migrations, the Rust
+-- service) are unscoped; app.tenant='*' is unscoped too but files each new
+-- row under the tenant of the row it belongs to (its zone, account,
+-- transaction, job or saga) instead of 'default'.
+--
+-- Zone, account and request ids stay unique across tenants. Reference data
+-- (actors, reason codes, scenarios), the messaging tables, the sim clock and
+-- settlement are shared. A table added later that belongs to a simulation
+-- needs the same column, policy and trigger.
+
+CREATE TABLE IF NOT EXISTS tenants (
+  id TEXT PRIMARY KEY,
+  name TEXT NOT NULL,
+  created_by TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+INSERT INTO tenants(id, name, created_by) VALUES ('default', 'Default', 'system') ON CONFLICT DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS tenant_api_keys (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  tenant_id TEXT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  key_hash TEXT NOT NULL UNIQUE, -- hex sha256 of the key; the key itself is shown once
+  prefix TEXT NOT NULL, -- the key's first characters, to tell keys apart
+  label TEXT NULL,
+  created_by TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  revoked_by TEXT NULL,
+  revoked_at TIMESTAMPTZ NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_tenant_api_keys_tenant ON tenant_api_keys(tenant_id, created_at);
+
+-- tenant_visible is the row filter: a session without a tenant, or with '*',
+-- sees every tenant.
+CREATE OR REPLACE FUNCTION tenant_visible(t TEXT) RETURNS BOOLEAN LANGUAGE sql STABLE AS $$
+  SELECT COALESCE(NULLIF(current_setting('app.tenant', true), ''), '*') IN ('*', t)
+$$;
+
+-- tenant_row files a new row under a tenant. The optional arguments name the
+-- parent row: the column holding its id, its table and the id's type. In a
+-- tenant's session the row takes the session's tenant and the parent must be
+-- visible to it; under '*' it takes the parent's tenant.
+CREATE OR REPLACE FUNCTION tenant_row() RETURNS trigger LANGUAGE plpgsql AS $$
+DECLARE
+  session_tenant TEXT := NULLIF(current_setting('app.tenant', true), '');
+  parent_id TEXT;
+  parent_tenant TEXT;
+BEGIN
+  IF session_tenant IS NULL THEN RETURN NEW; END IF;
+  IF TG_NARGS = 3 THEN
+    parent_id := to_jsonb(NEW)->>TG_ARGV[0];
+    IF parent_id IS NOT NULL THEN
+      EXECUTE format('SELECT tenant_id FROM %I WHERE id = $1::%s', TG_ARGV[1], TG_ARGV[2]) INTO parent_tenant USING parent_id;
+    END IF;
+  END IF;
+  IF session_tenant = '*' THEN
+    NEW.tenant_id := COALESCE(parent_tenant, NEW.tenant_id);
+    RETURN NEW;
+  END IF;
+  IF parent_id IS NOT NULL AND parent_tenant IS NULL THEN
+    RAISE EXCEPTION '% row % not found in tenant %', TG_ARGV[1], parent_id, session_tenant USING ERRCODE = 'foreign_key_violation';
+  END IF;
+  NEW.tenant_id := session_tenant;
+  RETURN NEW;
+END
+$$;
+
+-- audit entries belong to the tenant of their target
+CREATE OR REPLACE FUNCTION tenant_audit_row() RETURNS trigger LANGUAGE plpgsql AS $$
+DECLARE
+  session_tenant TEXT := NULLIF(current_setting('app.tenant', true), '');
+  parent_tenant TEXT;
+BEGIN
+  IF session_tenant IS NULL THEN RETURN NEW; END IF;
+  IF session_tenant <> '*' THEN
+    NEW.tenant_id := session_tenant;
+    RETURN NEW;
+  END IF;
+  CASE NEW.target_type
+    WHEN 'zone' THEN SELECT tenant_id INTO parent_tenant FROM zones WHERE id = NEW.target_id;
+    WHEN 'account' THEN SELECT tenant_id INTO parent_tenant FROM accounts WHERE id = NEW.target_id;
+    WHEN 'incident' THEN SELECT tenant_id INTO parent_tenant FROM incidents WHERE id::text = NEW.target_id;
+    WHEN 'transaction' THEN SELECT tenant_id INTO parent_tenant FROM transactions WHERE id::text = NEW.target_id;
+    ELSE NULL;
+  END CASE;
+  NEW.tenant_id := COALESCE(parent_tenant, NEW.tenant_id);
+  RETURN NEW;
+END
+$$;
+
+DO $$
+DECLARE
+  t RECORD;
+BEGIN
+  FOR t IN SELECT * FROM (VALUES
+    ('zones', NULL, NULL, NULL),
+    ('accounts', 'zone_id', 'zones', 'text'),
+    ('transactions', 'zone_id', 'zones', 'text'),
+    ('postings', 'txn_id', 'transactions', 'uuid'),
+    ('balances', 'account_id', 'accounts', 'text'),
+    ('incidents', 'zone_id', 'zones', 'text'),
+    ('zone_controls', 'zone_id', 'zones', 'text'),
+    ('spooled_transfers', 'zone_id', 'zones', 'text'),
+    ('zone_rate_buckets', 'zone_id', 'zones', 'text'),
+    ('account_controls', 'account_id', 'accounts', 'text'),
+    ('scheduled_control_changes', 'zone_id', 'zones', 'text'),
+    ('ledger_chain', 'txn_id', 'transactions', 'uuid'),
+    ('ledger_chain_heads', 'zone_id', 'zones', 'text'),
+    ('transaction_annotations', 'txn_id', 'transactions', 'uuid'),
+    ('account_tags', 'account_id', 'accounts', 'text'),
+    ('balance_alerts', 'zone_id', 'zones', 'text'),
+    ('accrual_rules', 'zone_id', 'zones', 'text'),
+    ('recurring_transfers', 'zone_id', 'zones', 'text'),
+    ('transfer_templates', 'zone_id', 'zones', 'text'),
+    ('transfer_prepares', 'zone_id', 'zones', 'text'),
+    ('sagas', NULL, NULL, NULL),
+    ('saga_steps', 'saga_id', 'sagas', 'uuid'),
+    ('zone_sequences', 'zone_id', 'zones', 'text'),
+    ('throttle_ramps', 'zone_id', 'zones', 'text'),
+    ('zone_controls_history', 'zone_id', 'zones', 'text'),
+    ('spool_depth_samples', 'zone_id', 'zones', 'text'),
+    ('replay_jobs', 'zone_id', 'zones', 'text'),
+    ('jobs', NULL, NULL, NULL),
+    ('job_artifacts', 'job_id', 'jobs', 'uuid'),
+    ('job_artifact_chunks', 'job_id', 'jobs', 'uuid'),
+    ('stats_buckets', 'zone_id', 'zones', 'text'),
+    ('approvals', NULL, NULL, NULL),
+    ('drills', NULL, NULL, NULL),
+    ('audit_log', NULL, NULL, NULL)
+  ) AS v(tbl, col, parent, typ) LOOP
+    EXECUTE format('ALTER TABLE %I ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT %L', t.tbl, 'default');
+    EXECUTE format('ALTER TABLE %I ENABLE ROW LEVEL SECURITY', t.tbl);
+    EXECUTE format('DROP POLICY IF EXISTS tenant_isolation ON %I', t.tbl);
+    EXECUTE format('CREATE POLICY tenant_isolation ON %I USING (tenant_visible(tenant_id)) WITH CHECK (tenant_visible(tenant_id))', t.tbl);
+    EXECUTE format('DROP TRIGGER IF EXISTS trg_tenant_row ON %I', t.tbl);
+    IF t.tbl = 'audit_log' THEN
+      EXECUTE 'CREATE TRIGGER trg_tenant_row BEFORE INSERT ON audit_log FOR EACH ROW EXECUTE FUNCTION tenant_audit_row()';
+    ELSIF t.col IS NULL THEN
+      EXECUTE format('CREATE TRIGGER trg_tenant_row BEFORE INSERT ON %I FOR EACH ROW EXECUTE FUNCTION tenant_row()', t.tbl);
+    ELSE
+      EXECUTE format('CREATE TRIGGER trg_tenant_row BEFORE INSERT ON %I FOR EACH ROW EXECUTE FUNCTION tenant_row(%L, %L, %L)', t.tbl, t.col, t.parent, t.typ);
+    END IF;
+  END LOOP;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_zones_tenant ON zones(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_accounts_tenant ON accounts(tenant_id);
+
+-- one running drill per tenant
+DROP INDEX IF EXISTS idx_drills_running;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_drills_running ON drills(tenant_id) WHERE ended_at IS NULL;
+
+-- The role a tenant's queries run as. It does not own the tables, so row-level
+-- security applies to it; the role running migrations must be able to SET ROLE
+-- to it.
+DO $$
+BEGIN
+  IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'sim_tenant') THEN
+    CREATE ROLE sim_tenant NOLOGIN;
+  END IF;
+  EXECUTE format('GRANT sim_tenant TO %I', current_user);
+END $$;
+
+GRANT USAGE ON SCHEMA public TO sim_tenant;
+GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public TO sim_tenant;
+GRANT USAGE, SELECT, UPDATE ON ALL SEQUENCES IN SCHEMA public TO sim_tenant;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT SELECT, INSERT, UPDATE, DELETE ON TABLES TO sim_tenant;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT USAGE, SELECT, UPDATE ON SEQUENCES TO sim_tenant;
+-- tenants and their keys are managed outside any tenant
+REVOKE ALL ON tenants, tenant_api_keys FROM sim_tenant;
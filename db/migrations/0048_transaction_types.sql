@@ -0,0 +1,22 @@
+-- Transaction types, so reports can tell organic traffic from the postings
+-- the ledger makes itself. Clients post TRANSFER (the default), FEE or
+-- REVERSAL; SETTLEMENT, ACCRUAL and SEED are posted only by the ledger. The
+-- catalog is ledger.TransactionTypes (GET /v1/transaction-types); rows
+-- written without a type, such as the Rust service's, are TRANSFER.
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS type TEXT NOT NULL DEFAULT 'TRANSFER';
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_type_check
+  CHECK (type IN ('TRANSFER','FEE','REVERSAL','SETTLEMENT','ACCRUAL','SEED'));
+CREATE INDEX IF NOT EXISTS idx_transactions_type ON transactions(type, created_at);
+
+-- spooled and prepared transfers are posted with their type
+ALTER TABLE spooled_transfers ADD COLUMN IF NOT EXISTS type TEXT NOT NULL DEFAULT 'TRANSFER';
+ALTER TABLE spooled_transfers DROP CONSTRAINT IF EXISTS spooled_transfers_type_check;
+ALTER TABLE spooled_transfers ADD CONSTRAINT spooled_transfers_type_check
+  CHECK (type IN ('TRANSFER','FEE','REVERSAL','SETTLEMENT','ACCRUAL','SEED'));
+
+ALTER TABLE transfer_prepares ADD COLUMN IF NOT EXISTS type TEXT NOT NULL DEFAULT 'TRANSFER';
+ALTER TABLE transfer_prepares DROP CONSTRAINT IF EXISTS transfer_prepares_type_check;
+ALTER TABLE transfer_prepares ADD CONSTRAINT transfer_prepares_type_check
+  CHECK (type IN ('TRANSFER','FEE','REVERSAL','SETTLEMENT','ACCRUAL','SEED'));
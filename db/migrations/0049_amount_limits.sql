@@ -0,0 +1,10 @@
+-- Amount limits per zone, to model regulatory caps: the smallest and largest
+-- transfer a client may make, and how much one account may send per sim-clock
+-- day (UTC). 0 is no limit. Limits are checked when a transfer is accepted,
+-- before it is spooled; an admin can override them per transfer, which is
+-- audited as OVERRIDE_AMOUNT_LIMIT.
+
+ALTER TABLE zone_controls
+  ADD COLUMN IF NOT EXISTS min_amount_units BIGINT NOT NULL DEFAULT 0 CHECK (min_amount_units >= 0),
+  ADD COLUMN IF NOT EXISTS max_amount_units BIGINT NOT NULL DEFAULT 0 CHECK (max_amount_units >= 0),
+  ADD COLUMN IF NOT EXISTS daily_account_limit_units BIGINT NOT NULL DEFAULT 0 CHECK (daily_account_limit_units >= 0);
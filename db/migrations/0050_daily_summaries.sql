@@ -0,0 +1,36 @@
+-- End of day. A zone's business day closes at day_cutover_minute past
+-- midnight UTC on the zone's (skewed) clock, 0 being midnight: with a
+-- cutover the day D runs from D-1 at the cutover to D at the cutover. The day
+-- closer totals each closed day into daily_summaries and emits DAY_CLOSED.
+
+ALTER TABLE zone_controls ADD COLUMN IF NOT EXISTS day_cutover_minute INT NOT NULL DEFAULT 0;
+ALTER TABLE zone_controls DROP CONSTRAINT IF EXISTS zone_controls_day_cutover_minute_check;
+ALTER TABLE zone_controls ADD CONSTRAINT zone_controls_day_cutover_minute_check
+  CHECK (day_cutover_minute >= 0 AND day_cutover_minute < 1440);
+
+CREATE TABLE IF NOT EXISTS daily_summaries (
+  zone_id TEXT NOT NULL REFERENCES zones(id) ON DELETE CASCADE,
+  business_date DATE NOT NULL,
+  opens_at TIMESTAMPTZ NOT NULL, -- the previous day's closes_at, so days do not overlap
+  closes_at TIMESTAMPTZ NOT NULL,
+  transfers BIGINT NOT NULL,
+  amount_units BIGINT NOT NULL,
+  cross_zone_transfers BIGINT NOT NULL,
+  cross_zone_amount_units BIGINT NOT NULL,
+  by_type JSONB NOT NULL DEFAULT '{}'::jsonb, -- type -> {transfers, amount_units}
+  active_accounts BIGINT NOT NULL,
+  spooled BIGINT NOT NULL,
+  incidents BIGINT NOT NULL,
+  closed_at TIMESTAMPTZ NOT NULL, -- sim clock
+  PRIMARY KEY (zone_id, business_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_zone_created ON transactions(zone_id, created_at);
+
+-- a zone's summaries are its tenant's (see 0047)
+ALTER TABLE daily_summaries ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE daily_summaries ENABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON daily_summaries;
+CREATE POLICY tenant_isolation ON daily_summaries USING (tenant_visible(tenant_id)) WITH CHECK (tenant_visible(tenant_id));
+DROP TRIGGER IF EXISTS trg_tenant_row ON daily_summaries;
+CREATE TRIGGER trg_tenant_row BEFORE INSERT ON daily_summaries FOR EACH ROW EXECUTE FUNCTION tenant_row('zone_id', 'zones', 'text');
@@ -0,0 +1,53 @@
+-- Balance change notifications. A subscription watches one account and
+-- fires on every change of its balance (CHANGE) or when the balance crosses
+-- threshold_units either way (CROSS). The balance notifier evaluates them
+-- against TRANSFER_POSTED events from JetStream: subscriptions with a
+-- webhook_url get a signed POST, and every subscription can be streamed over
+-- SSE. balance_deliveries records each webhook delivery, so a redelivered
+-- event is not posted twice to a webhook that already took it.
+
+CREATE TABLE IF NOT EXISTS balance_subscriptions (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+  trigger TEXT NOT NULL CHECK (trigger IN ('CHANGE','CROSS')),
+  threshold_units BIGINT NULL,
+  webhook_url TEXT NULL, -- NULL: SSE only
+  secret TEXT NULL, -- HMAC-SHA256 key of the webhook signature
+  created_by TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  CHECK ((trigger = 'CROSS') = (threshold_units IS NOT NULL)),
+  CHECK ((webhook_url IS NULL) = (secret IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_balance_subscriptions_account ON balance_subscriptions(account_id);
+
+CREATE TABLE IF NOT EXISTS balance_deliveries (
+  subscription_id UUID NOT NULL REFERENCES balance_subscriptions(id) ON DELETE CASCADE,
+  event_id UUID NOT NULL, -- the TRANSFER_POSTED event
+  attempts INT NOT NULL DEFAULT 0,
+  status_code INT NULL, -- of the last attempt; NULL when it got no response
+  error TEXT NULL, -- of the last attempt; NULL once delivered
+  delivered_at TIMESTAMPTZ NULL,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (subscription_id, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_balance_deliveries_updated ON balance_deliveries(subscription_id, updated_at DESC);
+
+-- an account's subscriptions are its tenant's (see 0047)
+DO $$
+DECLARE
+  t RECORD;
+BEGIN
+  FOR t IN SELECT * FROM (VALUES
+    ('balance_subscriptions', 'account_id', 'accounts', 'text'),
+    ('balance_deliveries', 'subscription_id', 'balance_subscriptions', 'uuid')
+  ) AS v(tbl, col, parent, typ) LOOP
+    EXECUTE format('ALTER TABLE %I ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT %L', t.tbl, 'default');
+    EXECUTE format('ALTER TABLE %I ENABLE ROW LEVEL SECURITY', t.tbl);
+    EXECUTE format('DROP POLICY IF EXISTS tenant_isolation ON %I', t.tbl);
+    EXECUTE format('CREATE POLICY tenant_isolation ON %I USING (tenant_visible(tenant_id)) WITH CHECK (tenant_visible(tenant_id))', t.tbl);
+    EXECUTE format('DROP TRIGGER IF EXISTS trg_tenant_row ON %I', t.tbl);
+    EXECUTE format('CREATE TRIGGER trg_tenant_row BEFORE INSERT ON %I FOR EACH ROW EXECUTE FUNCTION tenant_row(%L, %L, %L)', t.tbl, t.col, t.parent, t.typ);
+  END LOOP;
+END $$;
@@ -0,0 +1,8 @@
+-- JetStream stream sequence from the publish ack of each outbox event. When
+-- the publisher starts it reads the stream after the highest stored sequence
+-- and marks unpublished events it finds there as published, so an event
+-- acked just before a crash is not sent again after the duplicate window.
+
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS stream_seq BIGINT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_outbox_stream_seq ON outbox_events(stream_seq) WHERE stream_seq IS NOT NULL;
@@ -0,0 +1,58 @@
+-- Zone partitions and sim runs belong to a tenant too (see 0047). A tenant
+-- sees, cuts and heals only partitions from its own zones, and a run tags only
+-- its own tenant's transactions, spooled transfers and incidents.
+
+DO $$
+DECLARE
+  t RECORD;
+BEGIN
+  FOR t IN SELECT * FROM (VALUES
+    ('zone_partitions', 'from_zone', 'zones', 'text'),
+    ('sim_runs', NULL, NULL, NULL)
+  ) AS v(tbl, col, parent, typ) LOOP
+    EXECUTE format('ALTER TABLE %I ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT %L', t.tbl, 'default');
+    EXECUTE format('ALTER TABLE %I ENABLE ROW LEVEL SECURITY', t.tbl);
+    EXECUTE format('DROP POLICY IF EXISTS tenant_isolation ON %I', t.tbl);
+    EXECUTE format('CREATE POLICY tenant_isolation ON %I USING (tenant_visible(tenant_id)) WITH CHECK (tenant_visible(tenant_id))', t.tbl);
+    EXECUTE format('DROP TRIGGER IF EXISTS trg_tenant_row ON %I', t.tbl);
+    IF t.col IS NULL THEN
+      EXECUTE format('CREATE TRIGGER trg_tenant_row BEFORE INSERT ON %I FOR EACH ROW EXECUTE FUNCTION tenant_row()', t.tbl);
+    ELSE
+      EXECUTE format('CREATE TRIGGER trg_tenant_row BEFORE INSERT ON %I FOR EACH ROW EXECUTE FUNCTION tenant_row(%L, %L, %L)', t.tbl, t.col, t.parent, t.typ);
+    END IF;
+  END LOOP;
+END $$;
+
+-- partitions cut before this migration belong to their source zone's tenant
+UPDATE zone_partitions p SET tenant_id = z.tenant_id FROM zones z WHERE z.id = p.from_zone AND p.tenant_id <> z.tenant_id;
+
+-- one active run per tenant
+DROP INDEX IF EXISTS idx_sim_runs_active;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sim_runs_active ON sim_runs(tenant_id) WHERE status='RUNNING';
+
+-- A column default cannot see the row's tenant, which tenant_row sets from
+-- the parent row in unscoped sessions. Runs are tagged by a trigger instead;
+-- trg_tenant_sim_run sorts after trg_tenant_row, so it fires once tenant_id
+-- is final. A row inserted with a run_id keeps it.
+CREATE OR REPLACE FUNCTION current_sim_run(t TEXT) RETURNS UUID
+  LANGUAGE sql STABLE AS $$ SELECT id FROM sim_runs WHERE status='RUNNING' AND tenant_id=t LIMIT 1 $$;
+
+CREATE OR REPLACE FUNCTION tenant_sim_run() RETURNS trigger LANGUAGE plpgsql AS $$
+BEGIN
+  IF NEW.run_id IS NULL THEN NEW.run_id := current_sim_run(NEW.tenant_id); END IF;
+  RETURN NEW;
+END
+$$;
+
+DO $$
+DECLARE
+  tbl TEXT;
+BEGIN
+  FOREACH tbl IN ARRAY ARRAY['transactions', 'spooled_transfers', 'incidents'] LOOP
+    EXECUTE format('ALTER TABLE %I ALTER COLUMN run_id DROP DEFAULT', tbl);
+    EXECUTE format('DROP TRIGGER IF EXISTS trg_tenant_sim_run ON %I', tbl);
+    EXECUTE format('CREATE TRIGGER trg_tenant_sim_run BEFORE INSERT ON %I FOR EACH ROW EXECUTE FUNCTION tenant_sim_run()', tbl);
+  END LOOP;
+END $$;
+
+DROP FUNCTION IF EXISTS current_sim_run();
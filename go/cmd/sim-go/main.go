@@ -2,6 +2,7 @@ package main
 
 import (
   "context"
+  "flag"
   "log"
   "net/http"
   "os"
@@ -13,7 +14,12 @@ import (
 )
 
 func main() {
-  cfg := app.LoadConfigFromEnv()
+  configFile := flag.String("config", "", "YAML config file (default $CONFIG_FILE); env vars override it")
+  flag.Parse()
+  cfg, err := app.LoadConfig(*configFile)
+  if err != nil {
+    log.Fatalf("config: %v", err)
+  }
 
   ctx, cancel := context.WithCancel(context.Background())
   defer cancel()
@@ -29,21 +35,49 @@ func main() {
     ReadHeaderTimeout: 5 * time.Second,
   }
 
-  go func() {
-    <-a.Done()
-    _ = srv.Shutdown(context.Background())
-  }()
-
+  // On SIGTERM: drain writes and the outbox, stop gRPC and the background
+  // loops, let in-flight HTTP reads finish, then close connections.
+  stopped := make(chan struct{})
   go func() {
     sig := make(chan os.Signal, 1)
     signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
     <-sig
+    sctx, scancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+    defer scancel()
+    if err := a.Shutdown(sctx); err != nil {
+      log.Printf("shutdown: %v", err)
+    }
+    if err := srv.Shutdown(sctx); err != nil {
+      log.Printf("http shutdown: %v", err)
+    }
     cancel()
     a.Close()
+    close(stopped)
+  }()
+
+  if cfg.GRPCPort != "" {
+    go func() {
+      log.Printf("sim-go gRPC listening on :%s", cfg.GRPCPort)
+      if err := a.ServeGRPC(); err != nil {
+        log.Printf("grpc: %v", err)
+      }
+    }()
+  }
+
+  // SIGHUP re-reads the config file and environment and applies the tunables.
+  go func() {
+    hup := make(chan os.Signal, 1)
+    signal.Notify(hup, syscall.SIGHUP)
+    for range hup {
+      if _, err := a.Reload(ctx); err != nil {
+        log.Printf("config reload: %v", err)
+      }
+    }
   }()
 
   log.Printf("sim-go listening on :%s", cfg.Port)
   if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
     log.Fatalf("http: %v", err)
   }
+  <-stopped
 }
@@ -0,0 +1,93 @@
+package main
+
+import (
+  "fmt"
+  "net/url"
+  "text/tabwriter"
+
+  "github.com/spf13/cobra"
+
+  "time-ledger-sim/go/internal/ledger"
+  "time-ledger-sim/go/internal/web"
+)
+
+func newActorsCmd(c *client) *cobra.Command {
+  cmd := &cobra.Command{Use: "actors", Short: "Manage the actor directory and report actor activity"}
+
+  var kind string
+  var all bool
+  list := &cobra.Command{
+    Use: "list",
+    Short: "List registered actors",
+    Args: cobra.NoArgs,
+    RunE: func(cmd *cobra.Command, _ []string) error {
+      q := url.Values{}
+      if kind != "" { q.Set("kind", kind) }
+      if all { q.Set("all", "true") }
+      var out struct{ Actors []ledger.Actor `json:"actors"` }
+      if err := c.call(cmd.Context(), "GET", "/v1/actors?"+q.Encode(), nil, &out); err != nil { return err }
+      return c.print(out, func(w *tabwriter.Writer) {
+        fmt.Fprintln(w, "ID\tKIND\tNAME\tCREATED\tDISABLED")
+        for _, a := range out.Actors {
+          disabled := "-"
+          if a.DisabledAt != nil { disabled = ts(*a.DisabledAt) }
+          fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", a.ID, a.Kind, a.Name, ts(a.CreatedAt), disabled)
+        }
+      })
+    },
+  }
+  list.Flags().StringVar(&kind, "kind", "", "HUMAN, SERVICE or SCENARIO")
+  list.Flags().BoolVar(&all, "all", false, "include disabled actors")
+
+  var req web.RegisterActorRequest
+  register := &cobra.Command{
+    Use: "register ID",
+    Short: "Register an actor (admin)",
+    Args: cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      req.ID, req.Actor = args[0], c.actor
+      var a ledger.Actor
+      if err := c.call(cmd.Context(), "POST", "/v1/actors", req, &a); err != nil { return err }
+      return c.print(a, func(w *tabwriter.Writer) { fmt.Fprintf(w, "registered %s (%s)\n", a.ID, a.Kind) })
+    },
+  }
+  register.Flags().StringVar(&req.Kind, "kind", ledger.ActorKindHuman, "HUMAN, SERVICE or SCENARIO")
+  register.Flags().StringVar(&req.Name, "name", "", "display name (default: the id)")
+  register.Flags().StringVar(&req.Reason, "reason", "", "reason recorded in the audit log")
+
+  var reason string
+  disable := &cobra.Command{
+    Use: "disable ID",
+    Short: "Disable an actor (admin)",
+    Args: cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      q := url.Values{"actor": {c.actor}, "reason": {reason}}
+      var a ledger.Actor
+      if err := c.call(cmd.Context(), "DELETE", "/v1/actors/"+url.PathEscape(args[0])+"?"+q.Encode(), nil, &a); err != nil { return err }
+      return c.print(a, func(w *tabwriter.Writer) { fmt.Fprintf(w, "disabled %s\n", a.ID) })
+    },
+  }
+  disable.Flags().StringVar(&reason, "reason", "", "reason recorded in the audit log")
+
+  var since string
+  activity := &cobra.Command{
+    Use: "activity ID",
+    Short: "Show what an actor did, by action and target type",
+    Args: cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      q := url.Values{}
+      if since != "" { q.Set("since", since) }
+      var act ledger.ActorActivity
+      if err := c.call(cmd.Context(), "GET", "/v1/actors/"+url.PathEscape(args[0])+"/activity?"+q.Encode(), nil, &act); err != nil { return err }
+      return c.print(act, func(w *tabwriter.Writer) {
+        fmt.Fprintf(w, "%s: %d entries since %s\n", act.Actor.ID, act.Total, ts(act.Since))
+        fmt.Fprintln(w, "ACTION\tTARGET\tCOUNT\tLAST")
+        for _, a := range act.Actions { fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", a.Action, a.TargetType, a.Count, ts(a.LastAt)) }
+      })
+    },
+  }
+  activity.Flags().StringVar(&since, "since", "", "RFC 3339 time (default: 7 days ago)")
+
+  cmd.AddCommand(list, register, disable, activity)
+  return cmd
+}
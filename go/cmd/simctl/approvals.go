@@ -0,0 +1,59 @@
+package main
+
+import (
+  "fmt"
+  "net/url"
+  "text/tabwriter"
+
+  "github.com/spf13/cobra"
+
+  "time-ledger-sim/go/internal/ledger"
+  "time-ledger-sim/go/internal/web"
+)
+
+func newApprovalsCmd(c *client) *cobra.Command {
+  cmd := &cobra.Command{Use: "approvals", Short: "List, approve and reject actions held under the two-person rule"}
+
+  var status string
+  list := &cobra.Command{
+    Use: "list",
+    Short: "List approvals, newest first",
+    Args: cobra.NoArgs,
+    RunE: func(cmd *cobra.Command, _ []string) error {
+      q := url.Values{}
+      if status != "" { q.Set("status", status) }
+      var out struct{ Approvals []ledger.Approval `json:"approvals"` }
+      if err := c.call(cmd.Context(), "GET", "/v1/approvals?"+q.Encode(), nil, &out); err != nil { return err }
+      return c.print(out, func(w *tabwriter.Writer) {
+        fmt.Fprintln(w, "ID\tKIND\tTARGET\tSTATUS\tREQUESTED BY\tCREATED\tEXPIRES")
+        for _, a := range out.Approvals {
+          fmt.Fprintf(w, "%s\t%s\t%s:%s\t%s\t%s\t%s\t%s\n", a.ID, a.Kind, a.TargetType, a.TargetID, a.Status, a.RequestedBy, ts(a.CreatedAt), ts(a.ExpiresAt))
+        }
+      })
+    },
+  }
+  list.Flags().StringVar(&status, "status", "PENDING", "PENDING, APPROVED, REJECTED, FAILED or EXPIRED (empty: all)")
+
+  decide := func(verb string) *cobra.Command {
+    var reason string
+    d := &cobra.Command{
+      Use: verb + " ID",
+      Short: map[string]string{"approve": "Approve a pending action and perform it", "reject": "Reject or withdraw a pending action"}[verb],
+      Args: cobra.ExactArgs(1),
+      RunE: func(cmd *cobra.Command, args []string) error {
+        var a ledger.Approval
+        req := web.DecideApprovalRequest{Actor: c.actor, Reason: reason}
+        if err := c.call(cmd.Context(), "POST", "/v1/approvals/"+url.PathEscape(args[0])+"/"+verb, req, &a); err != nil { return err }
+        return c.print(a, func(w *tabwriter.Writer) {
+          fmt.Fprintf(w, "%s %s:%s -> %s\n", a.Kind, a.TargetType, a.TargetID, a.Status)
+          if a.FailReason != nil { fmt.Fprintf(w, "failed: %s\n", *a.FailReason) }
+        })
+      },
+    }
+    d.Flags().StringVar(&reason, "reason", "", "reason recorded in the audit log")
+    return d
+  }
+
+  cmd.AddCommand(list, decide("approve"), decide("reject"))
+  return cmd
+}
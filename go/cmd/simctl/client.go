@@ -0,0 +1,127 @@
+package main
+
+import (
+  "bytes"
+  "context"
+  "encoding/json"
+  "fmt"
+  "io"
+  "net/http"
+  "os"
+  "strings"
+  "text/tabwriter"
+  "time"
+
+  "time-ledger-sim/go/internal/ledger"
+)
+
+// client is the HTTP side of every command; flags fill it in before RunE.
+type client struct {
+  server string
+  adminKey string
+  tenantKey string
+  token string
+  actor string
+  json bool
+  http http.Client
+}
+
+// apiError is an RFC 7807 problem returned by the server.
+type apiError struct {
+  Status int `json:"status"`
+  Title string `json:"title"`
+  Detail string `json:"detail"`
+  Code string `json:"code"`
+  RequestID string `json:"request_id"`
+  body []byte // the whole response, for problems that carry more (e.g. a restore report)
+}
+
+func (e *apiError) Error() string {
+  msg := fmt.Sprintf("%d %s", e.Status, e.Title)
+  if e.Detail != "" { msg += ": " + e.Detail }
+  if e.RequestID != "" { msg += " (request " + e.RequestID + ")" }
+  return msg
+}
+
+// request sends body (JSON-encoded unless it is an io.Reader) and returns the
+// response; non-2xx statuses become an *apiError.
+func (c *client) request(ctx context.Context, method, path string, body any, header http.Header) (*http.Response, error) {
+  var rd io.Reader
+  switch b := body.(type) {
+  case nil:
+  case io.Reader:
+    rd = b
+  default:
+    buf, err := json.Marshal(b)
+    if err != nil { return nil, err }
+    rd = bytes.NewReader(buf)
+  }
+  req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(c.server, "/")+path, rd)
+  if err != nil { return nil, err }
+  if body != nil { req.Header.Set("Content-Type", "application/json") }
+  for k, v := range header { req.Header[k] = v }
+  if c.adminKey != "" { req.Header.Set("X-Admin-Key", c.adminKey) }
+  if c.tenantKey != "" { req.Header.Set("X-Tenant-Key", c.tenantKey) }
+  if c.token != "" { req.Header.Set("Authorization", "Bearer "+c.token) }
+
+  resp, err := c.http.Do(req)
+  if err != nil { return nil, err }
+  if resp.StatusCode < 300 || resp.StatusCode == http.StatusNotModified { return resp, nil }
+  defer resp.Body.Close()
+  raw, _ := io.ReadAll(resp.Body)
+  e := &apiError{body: raw}
+  if json.Unmarshal(raw, e) != nil || e.Title == "" { e.Title = strings.TrimSpace(string(raw)) }
+  e.Status = resp.StatusCode
+  return nil, e
+}
+
+// call sends a JSON request and decodes the JSON response into out (if non-nil).
+func (c *client) call(ctx context.Context, method, path string, body, out any) error {
+  resp, err := c.request(ctx, method, path, body, nil)
+  if err != nil { return err }
+  defer resp.Body.Close()
+  if out == nil { return nil }
+  return decode(resp.Body, out)
+}
+
+// callApprovable is call for actions the two-person rule can hold: a 202 is
+// decoded as the pending approval instead of into out.
+func (c *client) callApprovable(ctx context.Context, method, path string, body, out any) (*ledger.Approval, error) {
+  resp, err := c.request(ctx, method, path, body, nil)
+  if err != nil { return nil, err }
+  defer resp.Body.Close()
+  return c.decodeApprovable(resp, out)
+}
+
+func (c *client) decodeApprovable(resp *http.Response, out any) (*ledger.Approval, error) {
+  if resp.StatusCode != http.StatusAccepted { return nil, decode(resp.Body, out) }
+  var ap ledger.Approval
+  if err := decode(resp.Body, &ap); err != nil { return nil, err }
+  return &ap, nil
+}
+
+// printPending tells the operator that a second one must approve.
+func (c *client) printPending(ap *ledger.Approval) error {
+  return c.print(ap, func(w *tabwriter.Writer) {
+    fmt.Fprintf(w, "held for approval %s (expires %s); a second operator must run: simctl approvals approve %s\n", ap.ID, ts(ap.ExpiresAt), ap.ID)
+  })
+}
+
+func decode(r io.Reader, out any) error { return json.NewDecoder(r).Decode(out) }
+
+// print writes v as indented JSON with --json, else calls table.
+func (c *client) print(v any, table func(w *tabwriter.Writer)) error {
+  if c.json || table == nil {
+    enc := json.NewEncoder(os.Stdout)
+    enc.SetIndent("", "  ")
+    return enc.Encode(v)
+  }
+  w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+  table(w)
+  return w.Flush()
+}
+
+func ts(t time.Time) string {
+  if t.IsZero() { return "-" }
+  return t.Local().Format("2006-01-02 15:04:05")
+}
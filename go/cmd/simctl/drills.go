@@ -0,0 +1,90 @@
+package main
+
+import (
+  "fmt"
+  "net/url"
+  "os"
+  "path/filepath"
+  "text/tabwriter"
+
+  "github.com/spf13/cobra"
+
+  "time-ledger-sim/go/internal/ledger"
+  "time-ledger-sim/go/internal/web"
+)
+
+func newDrillsCmd(c *client) *cobra.Command {
+  cmd := &cobra.Command{Use: "drills", Short: "Run training drills as the game master"}
+
+  list := &cobra.Command{
+    Use: "list",
+    Short: "List drills, newest first",
+    Args: cobra.NoArgs,
+    RunE: func(cmd *cobra.Command, _ []string) error {
+      var out struct{ Drills []ledger.Drill `json:"drills"` }
+      if err := c.call(cmd.Context(), "GET", "/v1/sim/drills", nil, &out); err != nil { return err }
+      return c.print(out, func(w *tabwriter.Writer) {
+        fmt.Fprintln(w, "ID\tNAME\tRUNNING\tINJECTS\tSTARTED")
+        for _, d := range out.Drills { fmt.Fprintf(w, "%s\t%s\t%t\t%d/%d\t%s\n", d.ID, d.Name, d.Running, len(d.Log), len(d.Injects), ts(d.StartedAt)) }
+      })
+    },
+  }
+
+  start := &cobra.Command{
+    Use: "start FILE",
+    Short: "Start a drill from a script (JSON or YAML)",
+    Args: cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      body, err := os.ReadFile(args[0])
+      if err != nil { return err }
+      ext := filepath.Ext(args[0])
+      in, err := ledger.ParseDrill(body, ext == ".yaml" || ext == ".yml")
+      if err != nil { return err }
+      if in.Actor == "" { in.Actor = c.actor }
+      var d ledger.Drill
+      if err := c.call(cmd.Context(), "POST", "/v1/sim/drills", in, &d); err != nil { return err }
+      if c.json { return c.print(d, nil) }
+      fmt.Printf("started drill %s (%s, %d injects)\n", d.ID, d.Name, len(d.Injects))
+      return nil
+    },
+  }
+
+  end := &cobra.Command{
+    Use: "end ID",
+    Short: "End a drill and print its debrief",
+    Args: cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      var db ledger.DrillDebrief
+      if err := c.call(cmd.Context(), "POST", "/v1/sim/drills/"+url.PathEscape(args[0])+"/end", web.EndDrillRequest{Actor: c.actor}, &db); err != nil { return err }
+      return c.print(db, func(w *tabwriter.Writer) { debriefTable(w, &db) })
+    },
+  }
+
+  debrief := &cobra.Command{
+    Use: "debrief ID",
+    Short: "Compare the trainees' actions with the drill script",
+    Args: cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      var db ledger.DrillDebrief
+      if err := c.call(cmd.Context(), "GET", "/v1/sim/drills/"+url.PathEscape(args[0])+"/debrief", nil, &db); err != nil { return err }
+      return c.print(db, func(w *tabwriter.Writer) { debriefTable(w, &db) })
+    },
+  }
+
+  cmd.AddCommand(list, start, end, debrief)
+  return cmd
+}
+
+func debriefTable(w *tabwriter.Writer, db *ledger.DrillDebrief) {
+  score := "-"
+  if db.ScorePercent != nil { score = fmt.Sprintf("%d%%", *db.ScorePercent) }
+  fmt.Fprintf(w, "drill %s (%s): %d met, %d missed, score %s, %d unscripted actions\n", db.Drill.Name, db.Drill.ID, db.Met, db.Missed, score, db.Unscripted)
+  if !db.Final { fmt.Fprintln(w, "(still running; provisional)") }
+  fmt.Fprintln(w, "INJECT\tZONE\tEXPECTED\tMET\tBY\tRESPONSE")
+  for _, e := range db.Expectations {
+    by, resp := "", ""
+    if e.By != nil { by = e.By.Actor }
+    if e.ResponseSeconds != nil { resp = fmt.Sprintf("%.0fs", *e.ResponseSeconds) }
+    fmt.Fprintf(w, "%d %s\t%s\t%s\t%t\t%s\t%s\n", e.Inject, e.InjectAction, e.ZoneID, e.Expected, e.Met, by, resp)
+  }
+}
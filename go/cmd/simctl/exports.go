@@ -0,0 +1,56 @@
+package main
+
+import (
+  "fmt"
+  "text/tabwriter"
+  "time"
+
+  "github.com/spf13/cobra"
+
+  "time-ledger-sim/go/internal/ledger"
+  "time-ledger-sim/go/internal/web"
+)
+
+func newExportsCmd(c *client) *cobra.Command {
+  cmd := &cobra.Command{Use: "exports", Short: "Export datasets to downloadable files"}
+
+  var req web.StartExportRequest
+  var from, to string
+  start := &cobra.Command{
+    Use: "start DATASET",
+    Short: "Start exporting transactions, postings, incidents or audit; fetch the file with simctl jobs download",
+    Args: cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      req.Dataset, req.Actor = args[0], c.actor
+      for _, f := range []struct{ s string; dst **time.Time }{{from, &req.From}, {to, &req.To}} {
+        if f.s == "" { continue }
+        t, err := time.Parse(time.RFC3339, f.s)
+        if err != nil { return fmt.Errorf("want an RFC 3339 time, got %q", f.s) }
+        *f.dst = &t
+      }
+      var job ledger.Job
+      if err := c.call(cmd.Context(), "POST", "/v1/exports", req, &job); err != nil { return err }
+      return c.print(job, func(w *tabwriter.Writer) { printJobs(w, job) })
+    },
+  }
+  start.Flags().StringVar(&req.Format, "format", "csv", "csv, ndjson or parquet (transactions and postings)")
+  start.Flags().StringVar(&req.ZoneID, "zone", "", "only this zone (default: every zone)")
+  start.Flags().StringVar(&from, "from", "", "RFC 3339 time; rows at or after it")
+  start.Flags().StringVar(&to, "to", "", "RFC 3339 time; rows at or before it")
+  start.Flags().IntVar(&req.TTLHours, "ttl-hours", 0, "how long the file can be downloaded (0 = 24, max 168)")
+  start.Flags().StringVar(&req.Reason, "reason", "", "reason recorded in the audit log")
+
+  list := &cobra.Command{
+    Use: "list",
+    Short: "List export jobs, newest first",
+    Args: cobra.NoArgs,
+    RunE: func(cmd *cobra.Command, _ []string) error {
+      var out struct{ Exports []ledger.Job `json:"exports"` }
+      if err := c.call(cmd.Context(), "GET", "/v1/exports", nil, &out); err != nil { return err }
+      return c.print(out, func(w *tabwriter.Writer) { printJobs(w, out.Exports...) })
+    },
+  }
+
+  cmd.AddCommand(start, list)
+  return cmd
+}
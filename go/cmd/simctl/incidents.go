@@ -0,0 +1,129 @@
+package main
+
+import (
+  "context"
+  "fmt"
+  "io"
+  "net/http"
+  "net/url"
+  "os"
+  "text/tabwriter"
+  "time"
+
+  "github.com/spf13/cobra"
+
+  "time-ledger-sim/go/internal/ledger"
+)
+
+func newIncidentsCmd(c *client) *cobra.Command {
+  cmd := &cobra.Command{Use: "incidents", Short: "List, follow and export incidents"}
+
+  var zone string
+  var limit int
+  list := &cobra.Command{
+    Use: "list",
+    Short: "List recent incidents, newest first",
+    Args: cobra.NoArgs,
+    RunE: func(cmd *cobra.Command, _ []string) error {
+      inc, _, err := c.incidents(cmd.Context(), zone, limit, "")
+      if err != nil { return err }
+      return c.print(map[string]any{"incidents": inc}, func(w *tabwriter.Writer) {
+        incidentHeader(w)
+        for _, i := range inc { incidentRow(w, i) }
+      })
+    },
+  }
+
+  var interval time.Duration
+  tail := &cobra.Command{
+    Use: "tail",
+    Short: "Print new incidents as they are raised (Ctrl-C to stop)",
+    Args: cobra.NoArgs,
+    RunE: func(cmd *cobra.Command, _ []string) error {
+      seen := map[string]bool{}
+      etag := ""
+      first := true
+      w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+      if !c.json { incidentHeader(w); _ = w.Flush() }
+      for {
+        inc, tag, err := c.incidents(cmd.Context(), zone, limit, etag)
+        if err != nil { return err }
+        if tag != "" { etag = tag }
+        // the API lists newest first; print oldest first
+        for j := len(inc) - 1; j >= 0; j-- {
+          i := inc[j]
+          if seen[i.ID] { continue }
+          seen[i.ID] = true
+          if first && j >= 10 { continue } // start with the last few, like tail(1)
+          if c.json { _ = c.print(i, nil); continue }
+          incidentRow(w, i)
+        }
+        _ = w.Flush()
+        first = false
+        select {
+        case <-cmd.Context().Done():
+          return nil
+        case <-time.After(interval):
+        }
+      }
+    },
+  }
+  tail.Flags().DurationVar(&interval, "interval", 2*time.Second, "poll interval")
+
+  for _, sub := range []*cobra.Command{list, tail} {
+    sub.Flags().StringVar(&zone, "zone", "", "only this zone")
+    sub.Flags().IntVar(&limit, "limit", 100, "max incidents per request")
+  }
+  var format, out string
+  var lead time.Duration
+  export := &cobra.Command{
+    Use: "export ID",
+    Short: "Write an incident's postmortem bundle (Markdown or JSON)",
+    Args: cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      path := fmt.Sprintf("/v1/incidents/%s/export?format=%s&lead_minutes=%d", url.PathEscape(args[0]), url.QueryEscape(format), int(lead/time.Minute))
+      resp, err := c.request(cmd.Context(), "GET", path, nil, nil)
+      if err != nil { return err }
+      defer resp.Body.Close()
+      if out == "-" {
+        _, err = io.Copy(os.Stdout, resp.Body)
+        return err
+      }
+      f, err := os.Create(out)
+      if err != nil { return err }
+      n, err := io.Copy(f, resp.Body)
+      if cerr := f.Close(); err == nil { err = cerr }
+      if err != nil { return err }
+      fmt.Fprintf(os.Stderr, "wrote %s (%d bytes)\n", out, n)
+      return nil
+    },
+  }
+  export.Flags().StringVar(&format, "format", "markdown", "markdown or json")
+  export.Flags().DurationVar(&lead, "lead", 15*time.Minute, "how far before detection the window opens")
+  export.Flags().StringVarP(&out, "output", "o", "-", "file to write; - for stdout")
+
+  cmd.AddCommand(list, tail, export)
+  return cmd
+}
+
+// incidents fetches a page of incidents; with etag set an unchanged list
+// comes back as (nil, etag, nil).
+func (c *client) incidents(ctx context.Context, zone string, limit int, etag string) ([]ledger.Incident, string, error) {
+  path := fmt.Sprintf("/v1/incidents?limit=%d", limit)
+  if zone != "" { path = fmt.Sprintf("/v1/zones/%s/incidents?limit=%d", url.PathEscape(zone), limit) }
+  h := http.Header{}
+  if etag != "" { h.Set("If-None-Match", etag) }
+  resp, err := c.request(ctx, "GET", path, nil, h)
+  if err != nil { return nil, "", err }
+  defer resp.Body.Close()
+  if resp.StatusCode == http.StatusNotModified { return nil, etag, nil }
+  var out struct{ Incidents []ledger.Incident `json:"incidents"` }
+  if err := decode(resp.Body, &out); err != nil { return nil, "", err }
+  return out.Incidents, resp.Header.Get("ETag"), nil
+}
+
+func incidentHeader(w *tabwriter.Writer) { fmt.Fprintln(w, "DETECTED\tZONE\tSEVERITY\tSTATUS\tTITLE\tID") }
+
+func incidentRow(w *tabwriter.Writer, i ledger.Incident) {
+  fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", ts(i.DetectedAt), i.ZoneID, i.Severity, i.Status, i.Title, i.ID)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+  "fmt"
+  "io"
+  "mime"
+  "net/url"
+  "os"
+  "path/filepath"
+  "text/tabwriter"
+
+  "github.com/spf13/cobra"
+
+  "time-ledger-sim/go/internal/ledger"
+  "time-ledger-sim/go/internal/web"
+)
+
+func newJobsCmd(c *client) *cobra.Command {
+  cmd := &cobra.Command{Use: "jobs", Short: "Follow and cancel background jobs"}
+
+  var kind, status, target string
+  list := &cobra.Command{
+    Use: "list",
+    Short: "List jobs, newest first",
+    Args: cobra.NoArgs,
+    RunE: func(cmd *cobra.Command, _ []string) error {
+      q := url.Values{}
+      if kind != "" { q.Set("kind", kind) }
+      if status != "" { q.Set("status", status) }
+      if target != "" { q.Set("target", target) }
+      var out struct{ Jobs []ledger.Job `json:"jobs"` }
+      if err := c.call(cmd.Context(), "GET", "/v1/jobs?"+q.Encode(), nil, &out); err != nil { return err }
+      return c.print(out, func(w *tabwriter.Writer) { printJobs(w, out.Jobs...) })
+    },
+  }
+  list.Flags().StringVar(&kind, "kind", "", "job kind, e.g. REPLAY_SPOOL")
+  list.Flags().StringVar(&status, "status", "", "QUEUED, RUNNING, COMPLETED, CANCELLED or FAILED (empty: all)")
+  list.Flags().StringVar(&target, "target", "", "what the job works on, e.g. a zone id")
+
+  get := &cobra.Command{
+    Use: "get ID",
+    Short: "Show a job's status and progress",
+    Args: cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      var j ledger.Job
+      if err := c.call(cmd.Context(), "GET", "/v1/jobs/"+url.PathEscape(args[0]), nil, &j); err != nil { return err }
+      return c.print(j, func(w *tabwriter.Writer) { printJobs(w, j) })
+    },
+  }
+
+  var reason string
+  cancel := &cobra.Command{
+    Use: "cancel ID",
+    Short: "Cancel a queued or running job",
+    Args: cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      var j ledger.Job
+      req := web.CancelJobRequest{Actor: c.actor, Reason: reason}
+      if err := c.call(cmd.Context(), "POST", "/v1/jobs/"+url.PathEscape(args[0])+"/cancel", req, &j); err != nil { return err }
+      return c.print(j, func(w *tabwriter.Writer) { printJobs(w, j) })
+    },
+  }
+  cancel.Flags().StringVar(&reason, "reason", "", "reason recorded in the audit log")
+
+  var out string
+  download := &cobra.Command{
+    Use: "download ID",
+    Short: "Download the file a finished job produced (an export)",
+    Args: cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      resp, err := c.request(cmd.Context(), "GET", "/v1/jobs/"+url.PathEscape(args[0])+"/artifact", nil, nil)
+      if err != nil { return err }
+      defer resp.Body.Close()
+      if out == "-" {
+        _, err = io.Copy(os.Stdout, resp.Body)
+        return err
+      }
+      if out == "" {
+        _, params, _ := mime.ParseMediaType(resp.Header.Get("Content-Disposition"))
+        out = filepath.Base(params["filename"])
+        if out == "." || out == "/" { out = args[0] }
+      }
+      f, err := os.Create(out)
+      if err != nil { return err }
+      n, err := io.Copy(f, resp.Body)
+      if cerr := f.Close(); err == nil { err = cerr }
+      if err != nil { return err }
+      fmt.Fprintf(os.Stderr, "wrote %s (%d bytes)\n", out, n)
+      return nil
+    },
+  }
+  download.Flags().StringVarP(&out, "output", "o", "", "file to write (default: the server's file name; - for stdout)")
+
+  cmd.AddCommand(list, get, cancel, download)
+  return cmd
+}
+
+func printJobs(w *tabwriter.Writer, jobs ...ledger.Job) {
+  fmt.Fprintln(w, "ID\tKIND\tTARGET\tSTATUS\tCREATED\tPROGRESS")
+  for _, j := range jobs {
+    target := "-"
+    if j.Target != nil { target = *j.Target }
+    progress := string(j.Progress)
+    if j.Error != nil { progress = "error: " + *j.Error }
+    fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", j.ID, j.Kind, target, j.Status, ts(j.CreatedAt), progress)
+  }
+}
@@ -0,0 +1,52 @@
+// Command simctl is an operator CLI for sim-go's HTTP API: zones and
+// controls, spool replay, snapshots, incidents, scenarios, actors, approvals
+// and reason codes.
+//
+//   simctl zones list
+//   simctl zones status zone-eu DOWN --reason-code DRILL --reason "game day"
+//   simctl zones controls set zone-eu --spool-enabled --throttle 50
+//   simctl spool replay zone-eu
+//   simctl snapshot take -o snap.json && simctl snapshot restore snap.json --dry-run
+//   simctl incidents tail --zone zone-eu
+//   simctl scenarios run eu-outage --wait
+//   simctl actors register alice --kind HUMAN
+//   simctl approvals approve 3f2a... --reason "confirmed with on-call"
+package main
+
+import (
+  "context"
+  "os"
+  "os/signal"
+
+  "github.com/spf13/cobra"
+)
+
+func main() {
+  ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+  defer stop()
+  if err := newRootCmd().ExecuteContext(ctx); err != nil { os.Exit(1) }
+}
+
+func newRootCmd() *cobra.Command {
+  c := &client{}
+  root := &cobra.Command{
+    Use: "simctl",
+    Short: "Operate a time-ledger-sim server over its HTTP API",
+    SilenceUsage: true,
+  }
+  f := root.PersistentFlags()
+  f.StringVar(&c.server, "server", envOr("SIMCTL_SERVER", "http://localhost:8080"), "API base URL (SIMCTL_SERVER)")
+  f.StringVar(&c.adminKey, "admin-key", os.Getenv("ADMIN_KEY"), "X-Admin-Key for admin endpoints (ADMIN_KEY)")
+  f.StringVar(&c.tenantKey, "tenant-key", os.Getenv("SIMCTL_TENANT_KEY"), "X-Tenant-Key selecting the tenant on a multi-tenant server (SIMCTL_TENANT_KEY)")
+  f.StringVar(&c.token, "token", os.Getenv("SIMCTL_TOKEN"), "bearer token when the server uses OIDC (SIMCTL_TOKEN)")
+  f.StringVar(&c.actor, "actor", envOr("SIMCTL_ACTOR", envOr("USER", "simctl")), "actor recorded in the audit log (SIMCTL_ACTOR)")
+  f.BoolVar(&c.json, "json", false, "print raw JSON instead of tables")
+
+  root.AddCommand(newZonesCmd(c), newSpoolCmd(c), newSnapshotCmd(c), newIncidentsCmd(c), newScenariosCmd(c), newActorsCmd(c), newApprovalsCmd(c), newReasonCodesCmd(c), newJobsCmd(c), newExportsCmd(c), newDrillsCmd(c), newTenantsCmd(c))
+  return root
+}
+
+func envOr(key, def string) string {
+  if v := os.Getenv(key); v != "" { return v }
+  return def
+}
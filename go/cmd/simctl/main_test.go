@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"time-ledger-sim/go/internal/web"
+)
+
+func run(t *testing.T, srv *httptest.Server, args ...string) error {
+	t.Helper()
+	root := newRootCmd()
+	root.SetArgs(append([]string{"--server", srv.URL, "--admin-key", "k", "--actor", "ops", "--json"}, args...))
+	root.SetOut(new(strings.Builder))
+	root.SetErr(new(strings.Builder))
+	return root.Execute()
+}
+
+func TestControlsSetKeepsUnsetFields(t *testing.T) {
+	var got web.SetZoneControlsRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/zones/zone-eu/controls" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method == "POST" {
+			if r.Header.Get("X-Admin-Key") != "k" {
+				t.Errorf("admin key not sent")
+			}
+			_ = json.NewDecoder(r.Body).Decode(&got)
+		}
+		w.Write([]byte(`{"zone_id":"zone-eu","cross_zone_throttle":40,"spool_enabled":true,"throttle_mode":"HASH","blocked_actions":{"throttled":"DELAY"},"blocked_delay_ms":200}`))
+	}))
+	defer srv.Close()
+
+	if err := run(t, srv, "zones", "controls", "set", "zone-eu", "--writes-blocked", "--reason", "drill"); err != nil {
+		t.Fatal(err)
+	}
+	want := web.SetZoneControlsRequest{
+		WritesBlocked: true, CrossZoneThrottle: 40, SpoolEnabled: true, ThrottleMode: "HASH",
+		BlockedActions: map[string]string{"throttled": "DELAY"}, BlockedDelayMs: 200, Actor: "ops", Reason: "drill",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("sent %+v, want %+v", got, want)
+	}
+}
+
+func TestProblemBecomesError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(409)
+		w.Write([]byte(`{"status":409,"title":"Conflict","detail":"zone is DOWN","code":"zone_down","request_id":"r1"}`))
+	}))
+	defer srv.Close()
+
+	err := run(t, srv, "spool", "replay", "zone-eu")
+	if err == nil || err.Error() != "409 Conflict: zone is DOWN (request r1)" {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestStatusHeldForApproval(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"id":"ap-1","kind":"ZONE_STATUS","target_type":"zone","target_id":"zone-eu","status":"PENDING","requested_by":"ops"}`))
+	}))
+	defer srv.Close()
+
+	c := &client{server: srv.URL}
+	var out map[string]any
+	ap, err := c.callApprovable(context.Background(), "POST", "/v1/zones/zone-eu/status", web.SetZoneStatusRequest{Status: "DOWN", Actor: "ops"}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ap == nil || ap.ID != "ap-1" || out != nil {
+		t.Fatalf("approval = %+v, out = %v", ap, out)
+	}
+}
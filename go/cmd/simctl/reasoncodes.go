@@ -0,0 +1,100 @@
+package main
+
+import (
+  "fmt"
+  "net/url"
+  "strings"
+  "text/tabwriter"
+
+  "github.com/spf13/cobra"
+
+  "time-ledger-sim/go/internal/ledger"
+  "time-ledger-sim/go/internal/web"
+)
+
+func newReasonCodesCmd(c *client) *cobra.Command {
+  cmd := &cobra.Command{Use: "reason-codes", Short: "Manage the reason-code catalog and report actions by code"}
+
+  var action string
+  var all bool
+  list := &cobra.Command{
+    Use: "list",
+    Short: "List reason codes",
+    Args: cobra.NoArgs,
+    RunE: func(cmd *cobra.Command, _ []string) error {
+      q := url.Values{}
+      if action != "" { q.Set("action", action) }
+      if all { q.Set("all", "true") }
+      var out struct{ ReasonCodes []ledger.ReasonCode `json:"reason_codes"` }
+      if err := c.call(cmd.Context(), "GET", "/v1/reason-codes?"+q.Encode(), nil, &out); err != nil { return err }
+      return c.print(out, func(w *tabwriter.Writer) {
+        fmt.Fprintln(w, "CODE\tFOR\tDESCRIPTION\tRETIRED")
+        for _, rc := range out.ReasonCodes {
+          applies, retired := "*", "-"
+          if len(rc.AppliesTo) > 0 { applies = strings.Join(rc.AppliesTo, ",") }
+          if rc.RetiredAt != nil { retired = ts(*rc.RetiredAt) }
+          fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", rc.Code, applies, rc.Description, retired)
+        }
+      })
+    },
+  }
+  list.Flags().StringVar(&action, "action", "", "only codes usable for SET_ZONE_STATUS, SET_ZONE_CONTROLS or REPLAY_SPOOL")
+  list.Flags().BoolVar(&all, "all", false, "include retired codes")
+
+  var req web.RegisterReasonCodeRequest
+  add := &cobra.Command{
+    Use: "add CODE DESCRIPTION",
+    Short: "Add a reason code (admin)",
+    Args: cobra.ExactArgs(2),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      req.Code, req.Description, req.Actor = strings.ToUpper(args[0]), args[1], c.actor
+      var rc ledger.ReasonCode
+      if err := c.call(cmd.Context(), "POST", "/v1/reason-codes", req, &rc); err != nil { return err }
+      return c.print(rc, func(w *tabwriter.Writer) { fmt.Fprintf(w, "added %s\n", rc.Code) })
+    },
+  }
+  add.Flags().StringSliceVar(&req.AppliesTo, "for", nil, "actions it may be used for (default: all)")
+  add.Flags().StringVar(&req.Reason, "reason", "", "reason recorded in the audit log")
+
+  var reason string
+  retire := &cobra.Command{
+    Use: "retire CODE",
+    Short: "Retire a reason code (admin)",
+    Args: cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      q := url.Values{"actor": {c.actor}, "reason": {reason}}
+      var rc ledger.ReasonCode
+      if err := c.call(cmd.Context(), "DELETE", "/v1/reason-codes/"+url.PathEscape(args[0])+"?"+q.Encode(), nil, &rc); err != nil { return err }
+      return c.print(rc, func(w *tabwriter.Writer) { fmt.Fprintf(w, "retired %s\n", rc.Code) })
+    },
+  }
+  retire.Flags().StringVar(&reason, "reason", "", "reason recorded in the audit log")
+
+  var since, zone string
+  usage := &cobra.Command{
+    Use: "usage",
+    Short: "Count zone status, controls and replay actions by reason code",
+    Args: cobra.NoArgs,
+    RunE: func(cmd *cobra.Command, _ []string) error {
+      q := url.Values{}
+      if since != "" { q.Set("since", since) }
+      if zone != "" { q.Set("zone_id", zone) }
+      var u ledger.ReasonCodeUsage
+      if err := c.call(cmd.Context(), "GET", "/v1/reason-codes/usage?"+q.Encode(), nil, &u); err != nil { return err }
+      return c.print(u, func(w *tabwriter.Writer) {
+        fmt.Fprintf(w, "%d actions since %s, %d without a code\n", u.Total, ts(u.Since), u.Uncoded)
+        fmt.Fprintln(w, "CODE\tACTION\tCOUNT\tZONES\tLAST")
+        for _, rc := range u.Codes {
+          code := rc.Code
+          if code == "" { code = "-" }
+          fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\n", code, rc.Action, rc.Count, rc.Zones, ts(rc.LastAt))
+        }
+      })
+    },
+  }
+  usage.Flags().StringVar(&since, "since", "", "RFC 3339 time (default: 7 days ago)")
+  usage.Flags().StringVar(&zone, "zone", "", "only this zone")
+
+  cmd.AddCommand(list, add, retire, usage)
+  return cmd
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+  "bytes"
+  "fmt"
+  "net/http"
+  "net/url"
+  "os"
+  "path/filepath"
+  "text/tabwriter"
+  "time"
+
+  "github.com/spf13/cobra"
+
+  "time-ledger-sim/go/internal/ledger"
+)
+
+func newScenariosCmd(c *client) *cobra.Command {
+  cmd := &cobra.Command{Use: "scenarios", Short: "Upload, run and stop chaos scenarios"}
+
+  list := &cobra.Command{
+    Use: "list",
+    Short: "List scenarios",
+    Args: cobra.NoArgs,
+    RunE: func(cmd *cobra.Command, _ []string) error {
+      var out struct{ Scenarios []ledger.Scenario `json:"scenarios"` }
+      if err := c.call(cmd.Context(), "GET", "/v1/sim/scenarios", nil, &out); err != nil { return err }
+      return c.print(out, func(w *tabwriter.Writer) {
+        fmt.Fprintln(w, "NAME\tSTEPS\tDESCRIPTION")
+        for _, s := range out.Scenarios { fmt.Fprintf(w, "%s\t%d\t%s\n", s.Name, len(s.Steps), s.Description) }
+      })
+    },
+  }
+
+  upload := &cobra.Command{
+    Use: "upload FILE",
+    Short: "Upload a scenario definition (JSON or YAML)",
+    Args: cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      body, err := os.ReadFile(args[0])
+      if err != nil { return err }
+      h := http.Header{}
+      h.Set("Content-Type", "application/json")
+      if ext := filepath.Ext(args[0]); ext == ".yaml" || ext == ".yml" { h.Set("Content-Type", "application/yaml") }
+      resp, err := c.request(cmd.Context(), "POST", "/v1/sim/scenarios", bytes.NewReader(body), h)
+      if err != nil { return err }
+      defer resp.Body.Close()
+      var sc ledger.Scenario
+      if err := decode(resp.Body, &sc); err != nil { return err }
+      if c.json { return c.print(sc, nil) }
+      fmt.Printf("uploaded %s (%d steps)\n", sc.Name, len(sc.Steps))
+      return nil
+    },
+  }
+
+  status := &cobra.Command{
+    Use: "status NAME",
+    Short: "Show a scenario's latest run",
+    Args: cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      st, err := c.scenarioStatus(cmd, args[0])
+      if err != nil { return err }
+      return c.print(st, func(w *tabwriter.Writer) {
+        if st.LastRun == nil { fmt.Fprintf(w, "%s has not run\n", args[0]); return }
+        runTable(w, st.LastRun)
+      })
+    },
+  }
+
+  var wait bool
+  var interval time.Duration
+  run := &cobra.Command{
+    Use: "run NAME",
+    Short: "Start a scenario run",
+    Args: cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      var r ledger.ScenarioRun
+      if err := c.call(cmd.Context(), "POST", "/v1/sim/scenarios/"+url.PathEscape(args[0])+"/run", nil, &r); err != nil { return err }
+      if !wait { return c.print(r, func(w *tabwriter.Writer) { runTable(w, &r) }) }
+
+      done := r.StepsDone
+      fmt.Fprintf(os.Stderr, "%s started (run %s, %d steps)\n", r.ScenarioName, r.ID, r.StepsTotal)
+      for {
+        select {
+        case <-cmd.Context().Done():
+          return cmd.Context().Err()
+        case <-time.After(interval):
+        }
+        st, err := c.scenarioStatus(cmd, args[0])
+        if err != nil { return err }
+        if st.LastRun == nil || st.LastRun.ID != r.ID { continue }
+        if st.LastRun.StepsDone != done {
+          done = st.LastRun.StepsDone
+          fmt.Fprintf(os.Stderr, "step %d/%d\n", done, st.LastRun.StepsTotal)
+        }
+        if !st.Running {
+          return c.print(st.LastRun, func(w *tabwriter.Writer) { runTable(w, st.LastRun) })
+        }
+      }
+    },
+  }
+  run.Flags().BoolVar(&wait, "wait", false, "follow the run until it finishes")
+  run.Flags().DurationVar(&interval, "interval", time.Second, "poll interval with --wait")
+
+  stop := &cobra.Command{
+    Use: "stop NAME",
+    Short: "Stop a running scenario",
+    Args: cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      var r ledger.ScenarioRun
+      if err := c.call(cmd.Context(), "POST", "/v1/sim/scenarios/"+url.PathEscape(args[0])+"/stop", nil, &r); err != nil { return err }
+      return c.print(r, func(w *tabwriter.Writer) { runTable(w, &r) })
+    },
+  }
+
+  cmd.AddCommand(list, upload, status, run, stop)
+  return cmd
+}
+
+type scenarioStatus struct {
+  Scenario ledger.Scenario `json:"scenario"`
+  Running bool `json:"running"`
+  LastRun *ledger.ScenarioRun `json:"last_run"`
+}
+
+func (c *client) scenarioStatus(cmd *cobra.Command, name string) (*scenarioStatus, error) {
+  var st scenarioStatus
+  if err := c.call(cmd.Context(), "GET", "/v1/sim/scenarios/"+url.PathEscape(name), nil, &st); err != nil { return nil, err }
+  return &st, nil
+}
+
+func runTable(w *tabwriter.Writer, r *ledger.ScenarioRun) {
+  fmt.Fprintln(w, "SCENARIO\tRUN\tSTATUS\tSTEPS\tSTARTED\tFINISHED")
+  finished := "-"
+  if r.FinishedAt != nil { finished = ts(*r.FinishedAt) }
+  fmt.Fprintf(w, "%s\t%s\t%s\t%d/%d\t%s\t%s\n", r.ScenarioName, r.ID, r.Status, r.StepsDone, r.StepsTotal, ts(r.StartedAt), finished)
+}
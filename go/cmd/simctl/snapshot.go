@@ -0,0 +1,132 @@
+package main
+
+import (
+  "bytes"
+  "errors"
+  "fmt"
+  "io"
+  "net/http"
+  "net/url"
+  "os"
+  "sort"
+  "strings"
+  "text/tabwriter"
+
+  "github.com/spf13/cobra"
+
+  "time-ledger-sim/go/internal/ledger"
+)
+
+func newSnapshotCmd(c *client) *cobra.Command {
+  cmd := &cobra.Command{Use: "snapshot", Short: "Take and restore snapshots"}
+
+  var out, dest string
+  var full, ndjson bool
+  take := &cobra.Command{
+    Use: "take",
+    Short: "Take a snapshot to a file, stdout, or object storage (--dest)",
+    Args: cobra.NoArgs,
+    RunE: func(cmd *cobra.Command, _ []string) error {
+      q := url.Values{}
+      if full { q.Set("full", "true") }
+      if ndjson || strings.HasSuffix(out, ".ndjson") { q.Set("format", "ndjson") }
+      if dest != "" { q.Set("dest", dest) }
+      resp, err := c.request(cmd.Context(), "POST", "/v1/sim/snapshot?"+q.Encode(), nil, nil)
+      if err != nil { return err }
+      defer resp.Body.Close()
+
+      if dest != "" {
+        var loc struct{ Location string `json:"location"`; Bytes int64 `json:"bytes"` }
+        if err := decode(resp.Body, &loc); err != nil { return err }
+        fmt.Printf("wrote %s (%d bytes)\n", loc.Location, loc.Bytes)
+        return nil
+      }
+      if out == "" || out == "-" {
+        _, err = io.Copy(os.Stdout, resp.Body)
+        return err
+      }
+      f, err := os.Create(out)
+      if err != nil { return err }
+      n, err := io.Copy(f, resp.Body)
+      if cerr := f.Close(); err == nil { err = cerr }
+      if err != nil { return err }
+      fmt.Fprintf(os.Stderr, "wrote %s (%d bytes)\n", out, n)
+      return nil
+    },
+  }
+  take.Flags().StringVarP(&out, "output", "o", "", "file to write (default stdout; .ndjson selects NDJSON)")
+  take.Flags().StringVar(&dest, "dest", "", "s3://bucket/key to write to object storage instead")
+  take.Flags().BoolVar(&full, "full", false, "include transaction history")
+  take.Flags().BoolVar(&ndjson, "ndjson", false, "stream NDJSON")
+
+  var src, scope, reason string
+  var dryRun bool
+  restore := &cobra.Command{
+    Use: "restore [FILE|-]",
+    Short: "Restore a snapshot from a file, stdin, or object storage (--src)",
+    Args: cobra.MaximumNArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      if (len(args) == 0) == (src == "") { return errors.New("give a snapshot file (or - for stdin) or --src") }
+      q := url.Values{"actor": {c.actor}}
+      if reason != "" { q.Set("reason", reason) }
+      if dryRun { q.Set("dry_run", "true") }
+      if scope != "" { q.Set("scope", scope) }
+      var body io.Reader
+      h := http.Header{}
+      if src != "" {
+        q.Set("src", src)
+      } else {
+        body = os.Stdin
+        if args[0] != "-" {
+          f, err := os.Open(args[0])
+          if err != nil { return err }
+          defer f.Close()
+          body = f
+        }
+        h.Set("Content-Type", "application/json")
+        if strings.HasSuffix(args[0], ".ndjson") || ndjson { h.Set("Content-Type", "application/x-ndjson") }
+      }
+
+      var report ledger.RestoreReport
+      resp, err := c.request(cmd.Context(), "POST", "/v1/sim/restore?"+q.Encode(), body, h)
+      if err != nil {
+        // an invalid snapshot comes back as a problem carrying the report
+        var ae *apiError
+        var withReport struct{ Report *ledger.RestoreReport `json:"report"` }
+        if errors.As(err, &ae) && decode(bytes.NewReader(ae.body), &withReport) == nil && withReport.Report != nil {
+          _ = c.print(withReport.Report, func(w *tabwriter.Writer) { reportTable(w, withReport.Report) })
+        }
+        return err
+      }
+      defer resp.Body.Close()
+      ap, err := c.decodeApprovable(resp, &report)
+      if err != nil { return err }
+      if ap != nil { return c.printPending(ap) }
+      return c.print(report, func(w *tabwriter.Writer) { reportTable(w, &report) })
+    },
+  }
+  restore.Flags().StringVar(&src, "src", "", "s3://bucket/key to read from object storage")
+  restore.Flags().StringVar(&scope, "scope", "", "comma-separated parts to restore: zones,controls,balances,incidents,spool,audit")
+  restore.Flags().BoolVar(&dryRun, "dry-run", false, "validate only")
+  restore.Flags().StringVar(&reason, "reason", "", "reason recorded in the audit log")
+  restore.Flags().BoolVar(&ndjson, "ndjson", false, "the snapshot is NDJSON (implied by a .ndjson file name)")
+
+  cmd.AddCommand(take, restore)
+  return cmd
+}
+
+func reportTable(w *tabwriter.Writer, r *ledger.RestoreReport) {
+  fmt.Fprintf(w, "valid: %v  dry_run: %v  version: %s\n\n", r.Valid, r.DryRun, r.Version)
+  fmt.Fprintln(w, "SECTION\tINSERT\tSKIPPED\tADJUSTED\tERRORS")
+  names := make([]string, 0, len(r.Sections))
+  for n := range r.Sections { names = append(names, n) }
+  sort.Strings(names)
+  for _, n := range names {
+    s := r.Sections[n]
+    fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\n", n, s.Insert, s.Skipped, s.Adjusted, s.Errors)
+  }
+  if len(r.Issues) == 0 { return }
+  fmt.Fprintln(w, "\nSECTION\tINDEX\tOUTCOME\tREASON")
+  for _, i := range r.Issues { fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", i.Section, i.Index, i.Outcome, i.Reason) }
+  if r.IssuesOmitted > 0 { fmt.Fprintf(w, "(%d more issues omitted)\n", r.IssuesOmitted) }
+}
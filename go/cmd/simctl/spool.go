@@ -0,0 +1,69 @@
+package main
+
+import (
+  "fmt"
+  "net/url"
+  "text/tabwriter"
+
+  "github.com/spf13/cobra"
+
+  "time-ledger-sim/go/internal/ledger"
+  "time-ledger-sim/go/internal/web"
+)
+
+func newSpoolCmd(c *client) *cobra.Command {
+  cmd := &cobra.Command{Use: "spool", Short: "Inspect and replay a zone's spool"}
+
+  stats := &cobra.Command{
+    Use: "stats ZONE",
+    Short: "Show pending, applied and failed spool counts",
+    Args: cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      var s ledger.SpoolStats
+      if err := c.call(cmd.Context(), "GET", "/v1/zones/"+url.PathEscape(args[0])+"/spool", nil, &s); err != nil { return err }
+      return c.print(s, func(w *tabwriter.Writer) {
+        fmt.Fprintln(w, "ZONE\tPENDING\tAPPLIED\tFAILED")
+        fmt.Fprintf(w, "%s\t%d\t%d\t%d\n", s.ZoneID, s.Pending, s.Applied, s.Failed)
+      })
+    },
+  }
+
+  var req web.ReplaySpoolRequest
+  replay := &cobra.Command{
+    Use: "replay ZONE",
+    Short: "Replay pending spooled transfers",
+    Args: cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      req.Actor = c.actor
+      var res ledger.ReplayResult
+      if err := c.call(cmd.Context(), "POST", "/v1/zones/"+url.PathEscape(args[0])+"/spool/replay", req, &res); err != nil { return err }
+      return c.print(res, func(w *tabwriter.Writer) {
+        fmt.Fprintln(w, "ZONE\tAPPLIED\tFAILED\tSKIPPED")
+        fmt.Fprintf(w, "%s\t%d\t%d\t%d\n", res.ZoneID, res.Applied, res.Failed, res.Skipped)
+      })
+    },
+  }
+  replay.Flags().IntVar(&req.Limit, "limit", 0, "max entries to replay (0 = server default, max 500)")
+  replay.Flags().StringVar(&req.ReasonCode, "reason-code", "", "reason code from the catalog (simctl reason-codes list)")
+  replay.Flags().StringVar(&req.Reason, "reason", "", "reason recorded in the audit log")
+
+  var jobReq web.StartReplayJobRequest
+  drain := &cobra.Command{
+    Use: "drain ZONE",
+    Short: "Start a background replay job that drains the spool at a bounded rate",
+    Args: cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      jobReq.Actor = c.actor
+      var job ledger.Job
+      if err := c.call(cmd.Context(), "POST", "/v1/zones/"+url.PathEscape(args[0])+"/spool/replay-jobs", jobReq, &job); err != nil { return err }
+      return c.print(job, func(w *tabwriter.Writer) { printJobs(w, job) })
+    },
+  }
+  drain.Flags().IntVar(&jobReq.RatePerSec, "rate", 0, "entries per second (0 = server default 50, max 500)")
+  drain.Flags().IntVar(&jobReq.MaxItems, "max-items", 0, "stop after this many entries (0 = until the spool is empty)")
+  drain.Flags().StringVar(&jobReq.ReasonCode, "reason-code", "", "reason code from the catalog (simctl reason-codes list)")
+  drain.Flags().StringVar(&jobReq.Reason, "reason", "", "reason recorded in the audit log")
+
+  cmd.AddCommand(stats, replay, drain)
+  return cmd
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+  "fmt"
+  "net/url"
+  "text/tabwriter"
+
+  "github.com/spf13/cobra"
+
+  "time-ledger-sim/go/internal/ledger"
+  "time-ledger-sim/go/internal/web"
+)
+
+func newTenantsCmd(c *client) *cobra.Command {
+  cmd := &cobra.Command{Use: "tenants", Short: "Manage tenants and their API keys (admin)"}
+
+  list := &cobra.Command{
+    Use: "list",
+    Short: "List tenants",
+    Args: cobra.NoArgs,
+    RunE: func(cmd *cobra.Command, _ []string) error {
+      var out struct{ Tenants []ledger.Tenant `json:"tenants"` }
+      if err := c.call(cmd.Context(), "GET", "/v1/tenants", nil, &out); err != nil { return err }
+      return c.print(out, func(w *tabwriter.Writer) {
+        fmt.Fprintln(w, "ID\tNAME\tKEYS\tCREATED")
+        for _, t := range out.Tenants { fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", t.ID, t.Name, t.ActiveKeys, ts(t.CreatedAt)) }
+      })
+    },
+  }
+
+  var req web.CreateTenantRequest
+  create := &cobra.Command{
+    Use: "create ID",
+    Short: "Create a tenant",
+    Args: cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      req.ID, req.Actor = args[0], c.actor
+      var t ledger.Tenant
+      if err := c.call(cmd.Context(), "POST", "/v1/tenants", req, &t); err != nil { return err }
+      return c.print(t, func(w *tabwriter.Writer) { fmt.Fprintf(w, "created tenant %s\n", t.ID) })
+    },
+  }
+  create.Flags().StringVar(&req.Name, "name", "", "display name (default: the id)")
+  create.Flags().StringVar(&req.Reason, "reason", "", "reason recorded in the audit log")
+
+  cmd.AddCommand(list, create, newTenantKeysCmd(c))
+  return cmd
+}
+
+func newTenantKeysCmd(c *client) *cobra.Command {
+  cmd := &cobra.Command{Use: "keys", Short: "Issue, list and revoke a tenant's API keys"}
+
+  list := &cobra.Command{
+    Use: "list TENANT",
+    Short: "List a tenant's keys",
+    Args: cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      var out struct{ Keys []ledger.TenantKey `json:"keys"` }
+      if err := c.call(cmd.Context(), "GET", "/v1/tenants/"+url.PathEscape(args[0])+"/keys", nil, &out); err != nil { return err }
+      return c.print(out, func(w *tabwriter.Writer) {
+        fmt.Fprintln(w, "ID\tPREFIX\tLABEL\tCREATED\tREVOKED")
+        for _, k := range out.Keys {
+          revoked := "-"
+          if k.RevokedAt != nil { revoked = ts(*k.RevokedAt) }
+          fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", k.ID, k.Prefix, k.Label, ts(k.CreatedAt), revoked)
+        }
+      })
+    },
+  }
+
+  var req web.CreateTenantKeyRequest
+  create := &cobra.Command{
+    Use: "create TENANT",
+    Short: "Issue a key; it is printed once",
+    Args: cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      req.Actor = c.actor
+      var k ledger.NewTenantKey
+      if err := c.call(cmd.Context(), "POST", "/v1/tenants/"+url.PathEscape(args[0])+"/keys", req, &k); err != nil { return err }
+      return c.print(k, func(w *tabwriter.Writer) {
+        fmt.Fprintf(w, "key %s for tenant %s (keep it; it is not shown again):\n%s\n", k.ID, k.TenantID, k.Key)
+      })
+    },
+  }
+  create.Flags().StringVar(&req.Label, "label", "", "what the key is for, e.g. a cohort")
+  create.Flags().StringVar(&req.Reason, "reason", "", "reason recorded in the audit log")
+
+  var reason string
+  revoke := &cobra.Command{
+    Use: "revoke TENANT KEY_ID",
+    Short: "Revoke a key",
+    Args: cobra.ExactArgs(2),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      q := url.Values{"actor": {c.actor}, "reason": {reason}}
+      var k ledger.TenantKey
+      path := "/v1/tenants/" + url.PathEscape(args[0]) + "/keys/" + url.PathEscape(args[1]) + "?" + q.Encode()
+      if err := c.call(cmd.Context(), "DELETE", path, nil, &k); err != nil { return err }
+      return c.print(k, func(w *tabwriter.Writer) { fmt.Fprintf(w, "revoked %s (%s)\n", k.ID, k.Prefix) })
+    },
+  }
+  revoke.Flags().StringVar(&reason, "reason", "", "reason recorded in the audit log")
+
+  cmd.AddCommand(list, create, revoke)
+  return cmd
+}
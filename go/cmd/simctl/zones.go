@@ -0,0 +1,202 @@
+package main
+
+import (
+  "fmt"
+  "maps"
+  "net/url"
+  "strings"
+  "text/tabwriter"
+
+  "github.com/spf13/cobra"
+
+  "time-ledger-sim/go/internal/ledger"
+  "time-ledger-sim/go/internal/web"
+)
+
+func newZonesCmd(c *client) *cobra.Command {
+  cmd := &cobra.Command{Use: "zones", Short: "List zones and change their status and controls"}
+
+  list := &cobra.Command{
+    Use: "list",
+    Short: "List zones",
+    Args: cobra.NoArgs,
+    RunE: func(cmd *cobra.Command, _ []string) error {
+      var out struct{ Zones []ledger.Zone `json:"zones"` }
+      if err := c.call(cmd.Context(), "GET", "/v1/zones", nil, &out); err != nil { return err }
+      return c.print(out, func(w *tabwriter.Writer) {
+        fmt.Fprintln(w, "ZONE\tNAME\tSTATUS\tUPDATED")
+        for _, z := range out.Zones { fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", z.ID, z.Name, z.Status, ts(z.UpdatedAt)) }
+      })
+    },
+  }
+
+  var code, reason string
+  status := &cobra.Command{
+    Use: "status ZONE OK|DEGRADED|DOWN",
+    Short: "Set a zone's status",
+    Args: cobra.ExactArgs(2),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      req := web.SetZoneStatusRequest{Status: strings.ToUpper(args[1]), Actor: c.actor, ReasonCode: code, Reason: reason}
+      var out map[string]any
+      ap, err := c.callApprovable(cmd.Context(), "POST", "/v1/zones/"+url.PathEscape(args[0])+"/status", req, &out)
+      if err != nil { return err }
+      if ap != nil { return c.printPending(ap) }
+      if c.json { return c.print(out, nil) }
+      fmt.Printf("%s -> %s\n", args[0], req.Status)
+      return nil
+    },
+  }
+  status.Flags().StringVar(&code, "reason-code", "", "reason code from the catalog (simctl reason-codes list)")
+  status.Flags().StringVar(&reason, "reason", "", "reason recorded in the audit log")
+
+  cmd.AddCommand(list, status, newControlsCmd(c), newRecommendationsCmd(c))
+  return cmd
+}
+
+func newRecommendationsCmd(c *client) *cobra.Command {
+  cmd := &cobra.Command{Use: "recommendations", Aliases: []string{"recs"}, Short: "Show and apply the advisor's recommended actions"}
+
+  list := &cobra.Command{
+    Use: "list ZONE",
+    Short: "List the actions recommended for a zone now",
+    Args: cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      var out struct{ Recommendations []ledger.Recommendation `json:"recommendations"` }
+      if err := c.call(cmd.Context(), "GET", "/v1/zones/"+url.PathEscape(args[0])+"/recommendations", nil, &out); err != nil { return err }
+      return c.print(out, func(w *tabwriter.Writer) {
+        fmt.Fprintln(w, "ID\tSEVERITY\tACTION\tWHY")
+        for _, r := range out.Recommendations {
+          fmt.Fprintf(w, "%s\t%s\t%s %s\t%s\n", r.ID, r.Severity, r.Action.Method, r.Action.Path, r.Why)
+        }
+      })
+    },
+  }
+
+  var code, reason string
+  apply := &cobra.Command{
+    Use: "apply ZONE ID",
+    Short: "Apply a recommendation through its control API",
+    Args: cobra.ExactArgs(2),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      req := web.ApplyRecommendationRequest{Actor: c.actor, ReasonCode: code, Reason: reason}
+      var out ledger.AppliedRecommendation
+      path := "/v1/zones/" + url.PathEscape(args[0]) + "/recommendations/" + url.PathEscape(args[1]) + "/apply"
+      ap, err := c.callApprovable(cmd.Context(), "POST", path, req, &out)
+      if err != nil { return err }
+      if ap != nil { return c.printPending(ap) }
+      if c.json { return c.print(out, nil) }
+      fmt.Printf("applied %s: %s\n", args[1], out.Recommendation.Title)
+      return nil
+    },
+  }
+  apply.Flags().StringVar(&code, "reason-code", "", "reason code from the catalog (simctl reason-codes list)")
+  apply.Flags().StringVar(&reason, "reason", "", "reason recorded in the audit log (default: the recommendation's id)")
+
+  cmd.AddCommand(list, apply)
+  return cmd
+}
+
+func newControlsCmd(c *client) *cobra.Command {
+  cmd := &cobra.Command{Use: "controls", Short: "Show or change a zone's controls"}
+
+  get := &cobra.Command{
+    Use: "get ZONE",
+    Short: "Show a zone's controls",
+    Args: cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      var zc ledger.ZoneControls
+      if err := c.call(cmd.Context(), "GET", "/v1/zones/"+url.PathEscape(args[0])+"/controls", nil, &zc); err != nil { return err }
+      return c.print(zc, func(w *tabwriter.Writer) { controlsTable(w, zc) })
+    },
+  }
+
+  // set starts from the current controls so only the flags given change.
+  var in web.SetZoneControlsRequest
+  set := &cobra.Command{
+    Use: "set ZONE",
+    Short: "Change a zone's controls (unset flags keep their current value)",
+    Args: cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      path := "/v1/zones/" + url.PathEscape(args[0]) + "/controls"
+      var cur ledger.ZoneControls
+      if err := c.call(cmd.Context(), "GET", path, nil, &cur); err != nil { return err }
+      req := web.SetZoneControlsRequest{
+        WritesBlocked: cur.WritesBlocked, CrossZoneThrottle: cur.CrossZoneThrottle, SpoolEnabled: cur.SpoolEnabled,
+        InjectLatencyMs: cur.InjectLatencyMs, InjectJitterMs: cur.InjectJitterMs, ErrorRatePercent: cur.ErrorRatePercent,
+        ThrottleMode: cur.ThrottleMode, RateLimitPerSec: cur.RateLimitPerSec, RateLimitBurst: cur.RateLimitBurst,
+        ClockSkewMs: cur.ClockSkewMs, BlockedActions: cur.BlockedActions, BlockedDelayMs: cur.BlockedDelayMs,
+        MinAmountUnits: cur.MinAmountUnits, MaxAmountUnits: cur.MaxAmountUnits, DailyAccountLimitUnits: cur.DailyAccountLimitUnits,
+        DayCutoverMinute: cur.DayCutoverMinute,
+        Actor: c.actor, ReasonCode: in.ReasonCode, Reason: in.Reason,
+      }
+      fl := cmd.Flags()
+      if fl.Changed("writes-blocked") { req.WritesBlocked = in.WritesBlocked }
+      if fl.Changed("throttle") { req.CrossZoneThrottle = in.CrossZoneThrottle }
+      if fl.Changed("spool-enabled") { req.SpoolEnabled = in.SpoolEnabled }
+      if fl.Changed("latency-ms") { req.InjectLatencyMs = in.InjectLatencyMs }
+      if fl.Changed("jitter-ms") { req.InjectJitterMs = in.InjectJitterMs }
+      if fl.Changed("error-rate") { req.ErrorRatePercent = in.ErrorRatePercent }
+      if fl.Changed("throttle-mode") { req.ThrottleMode = strings.ToUpper(in.ThrottleMode) }
+      if fl.Changed("rate-limit") { req.RateLimitPerSec = in.RateLimitPerSec }
+      if fl.Changed("rate-burst") { req.RateLimitBurst = in.RateLimitBurst }
+      if fl.Changed("clock-skew-ms") { req.ClockSkewMs = in.ClockSkewMs }
+      if fl.Changed("blocked-action") {
+        // merged into the current actions; an empty action drops the cause
+        actions := maps.Clone(cur.BlockedActions)
+        if actions == nil { actions = map[string]string{} }
+        for cause, action := range in.BlockedActions {
+          if action == "" { delete(actions, cause); continue }
+          actions[cause] = strings.ToUpper(action)
+        }
+        req.BlockedActions = actions
+      }
+      if fl.Changed("blocked-delay-ms") { req.BlockedDelayMs = in.BlockedDelayMs }
+      if fl.Changed("min-amount") { req.MinAmountUnits = in.MinAmountUnits }
+      if fl.Changed("max-amount") { req.MaxAmountUnits = in.MaxAmountUnits }
+      if fl.Changed("daily-account-limit") { req.DailyAccountLimitUnits = in.DailyAccountLimitUnits }
+      if fl.Changed("day-cutover-minute") { req.DayCutoverMinute = in.DayCutoverMinute }
+
+      var zc ledger.ZoneControls
+      ap, err := c.callApprovable(cmd.Context(), "POST", path, req, &zc)
+      if err != nil { return err }
+      if ap != nil { return c.printPending(ap) }
+      return c.print(zc, func(w *tabwriter.Writer) { controlsTable(w, zc) })
+    },
+  }
+  f := set.Flags()
+  f.BoolVar(&in.WritesBlocked, "writes-blocked", false, "reject all writes")
+  f.IntVar(&in.CrossZoneThrottle, "throttle", 100, "percent of transfers admitted (0-100)")
+  f.BoolVar(&in.SpoolEnabled, "spool-enabled", false, "spool blocked transfers instead of rejecting them")
+  f.IntVar(&in.InjectLatencyMs, "latency-ms", 0, "injected latency")
+  f.IntVar(&in.InjectJitterMs, "jitter-ms", 0, "injected jitter")
+  f.IntVar(&in.ErrorRatePercent, "error-rate", 0, "percent of transfers failed with a synthetic 500")
+  f.StringVar(&in.ThrottleMode, "throttle-mode", "", "HASH or RATE")
+  f.IntVar(&in.RateLimitPerSec, "rate-limit", 0, "transfers per second in RATE mode")
+  f.IntVar(&in.RateLimitBurst, "rate-burst", 0, "token bucket burst in RATE mode")
+  f.Int64Var(&in.ClockSkewMs, "clock-skew-ms", 0, "offset applied to transaction timestamps")
+  f.StringToStringVar(&in.BlockedActions, "blocked-action", nil, "what a blocked transfer gets per cause (zone_down, writes_blocked, throttled, rate_limited): SPOOL, REJECT or DELAY, e.g. throttled=DELAY")
+  f.IntVar(&in.BlockedDelayMs, "blocked-delay-ms", 0, "how long DELAY holds a transfer before looking again")
+  f.Int64Var(&in.MinAmountUnits, "min-amount", 0, "smallest transfer amount in units (0: no limit)")
+  f.Int64Var(&in.MaxAmountUnits, "max-amount", 0, "largest transfer amount in units (0: no limit)")
+  f.Int64Var(&in.DailyAccountLimitUnits, "daily-account-limit", 0, "units one account may send per day (0: no limit)")
+  f.IntVar(&in.DayCutoverMinute, "day-cutover-minute", 0, "minute of the zone's day the business day closes (0: midnight)")
+  f.StringVar(&in.ReasonCode, "reason-code", "", "reason code from the catalog (simctl reason-codes list)")
+  f.StringVar(&in.Reason, "reason", "", "reason recorded in the audit log")
+
+  cmd.AddCommand(get, set)
+  return cmd
+}
+
+func controlsTable(w *tabwriter.Writer, zc ledger.ZoneControls) {
+  rows := [][2]any{
+    {"zone", zc.ZoneID}, {"writes_blocked", zc.WritesBlocked}, {"cross_zone_throttle", zc.CrossZoneThrottle},
+    {"spool_enabled", zc.SpoolEnabled}, {"inject_latency_ms", zc.InjectLatencyMs}, {"inject_jitter_ms", zc.InjectJitterMs},
+    {"error_rate_percent", zc.ErrorRatePercent}, {"throttle_mode", zc.ThrottleMode}, {"rate_limit_per_sec", zc.RateLimitPerSec},
+    {"rate_limit_burst", zc.RateLimitBurst}, {"clock_skew_ms", zc.ClockSkewMs},
+    {"blocked_actions", zc.BlockedActions}, {"blocked_delay_ms", zc.BlockedDelayMs},
+    {"min_amount_units", zc.MinAmountUnits}, {"max_amount_units", zc.MaxAmountUnits}, {"daily_account_limit_units", zc.DailyAccountLimitUnits},
+    {"day_cutover_minute", zc.DayCutoverMinute},
+    {"updated_at", ts(zc.UpdatedAt)},
+  }
+  for _, r := range rows { fmt.Fprintf(w, "%s\t%v\n", r[0], r[1]) }
+}
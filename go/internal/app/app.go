@@ -4,75 +4,241 @@ import (
   "context"
   "errors"
   "log/slog"
+  "net"
   "net/http"
   "os"
+  "sync"
+  "sync/atomic"
   "time"
 
   "github.com/go-chi/chi/v5"
+  "github.com/go-chi/chi/v5/middleware"
   "github.com/jackc/pgx/v5/pgxpool"
   "github.com/nats-io/nats.go"
+  "github.com/prometheus/client_golang/prometheus"
   "github.com/prometheus/client_golang/prometheus/promhttp"
+  "google.golang.org/grpc"
 
+  "time-ledger-sim/go/internal/auditsig"
+  "time-ledger-sim/go/internal/auth"
+  "time-ledger-sim/go/internal/dbwatch"
+  "time-ledger-sim/go/internal/grpcapi"
+  "time-ledger-sim/go/internal/leader"
   "time-ledger-sim/go/internal/ledger"
+  "time-ledger-sim/go/internal/logging"
   "time-ledger-sim/go/internal/messaging"
+  "time-ledger-sim/go/internal/metrics"
+  "time-ledger-sim/go/internal/objstore"
+  "time-ledger-sim/go/internal/store"
+  "time-ledger-sim/go/internal/tracing"
   "time-ledger-sim/go/internal/web"
 )
 
 type App struct {
-  cfg Config
+  cfg Config // as loaded at startup; tunables live in tun
+  tun atomic.Pointer[Tunables]
+  reloadMu sync.Mutex
+  level *slog.LevelVar
+  cors *web.CORS
+  watch *dbwatch.Watcher
   log *slog.Logger
+  store store.Store
   db  *pgxpool.Pool
+  natsSrv *messaging.EmbeddedServer // nil unless NATS_URL=embedded
   nc  *nats.Conn
   js  nats.JetStreamContext
 
   shutdownTracer func(context.Context) error
+  zoneGauges prometheus.Collector // unregistered on Close so another App can start in-process
+  alertGauges prometheus.Collector // likewise
 
   router http.Handler
+  grpc *grpc.Server // nil when GRPC_PORT=off
+  notReady atomic.Bool // last /readyz outcome, to log transitions only
+  msgReady atomic.Bool // JetStream stream in place and publisher running; writes are held until then
+  gate web.DrainGate
+  led *ledger.Ledger
+  pub *messaging.OutboxPublisher
+  pubLeader *leader.Elector // one outbox publisher across replicas
+  schedLeader *leader.Elector // one control scheduler across replicas
+  balMon *ledger.BalanceMonitor
+  balLeader *leader.Elector // one negative balance monitor across replicas
+  settler *ledger.SettlementRunner
+  settleLeader *leader.Elector // one settlement runner across replicas
+  spoolSampler *ledger.SpoolSampler
+  sampleLeader *leader.Elector // one spool depth sampler across replicas
+  statsRollup *ledger.StatsRollup
+  rollupLeader *leader.Elector // one stats rollup across replicas
+  dayCloser *ledger.DayCloser
+  dayLeader *leader.Elector // one day closer across replicas
+  jobs *ledger.JobRunner // runs on every replica; jobs are claimed under a lease
+  alerts *ledger.AlertUpdater // refreshes alertGauges on every replica
+  stopLoops context.CancelFunc
+  loops sync.WaitGroup // background loops, waited on by Shutdown
   done chan struct{}
 }
 
 func New(ctx context.Context, cfg Config) (*App, error) {
-  logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
+  level := new(slog.LevelVar)
+  level.Set(cfg.LogLevel)
+  logger := logging.New(os.Stdout, level)
   shutdown, err := initTracer(ctx, cfg.OtelEndpoint)
   if err != nil { return nil, err }
 
-  if cfg.DatabaseURL == "" { return nil, errors.New("DATABASE_URL required") }
-  db, err := pgxpool.New(ctx, cfg.DatabaseURL)
+  watch := dbwatch.New(cfg.SlowQuery, cfg.LongTx, logger)
+  // retry Postgres for up to STARTUP_TIMEOUT so the process can start before
+  // its database (compose ordering); the embedded one either starts or not
+  dbRetry := cfg.Startup
+  if cfg.Store.URL == store.Embedded { dbRetry.Timeout = 0 }
+  var st store.Store
+  err = retry(ctx, dbRetry, logger, "postgres", func(ctx context.Context) (err error) {
+    st, err = store.Open(ctx, cfg.Store, dbwatch.Chain(tracing.QueryTracer{}, watch))
+    return err
+  })
   if err != nil { return nil, err }
+  if cfg.Store.URL == store.Embedded { logger.Info("using embedded postgres", "migrations", cfg.Store.Migrations) }
+  db := st.Pool()
 
-  if err := db.Ping(ctx); err != nil { return nil, err }
-
-  if cfg.NatsURL == "" { return nil, errors.New("NATS_URL required") }
-  nc, err := nats.Connect(cfg.NatsURL, nats.MaxReconnects(-1), nats.ReconnectWait(500*time.Millisecond))
+  natsURL := cfg.NatsURL
+  var natsSrv *messaging.EmbeddedServer
+  if natsURL == "" { return nil, errors.New("NATS_URL required") }
+  if natsURL == messaging.EmbeddedURL {
+    natsSrv, err = messaging.StartEmbedded()
+    if err != nil { st.Close(); return nil, err }
+    natsURL = natsSrv.URL()
+    logger.Info("using embedded nats", "url", natsURL)
+  }
+  // NATS may come up later: the client keeps reconnecting in the background
+  // and runMessaging starts the publisher and consumer once JetStream answers
+  nc, err := nats.Connect(natsURL, nats.MaxReconnects(-1), nats.RetryOnFailedConnect(true),
+    nats.CustomReconnectDelay(cfg.Startup.delay))
   if err != nil { return nil, err }
   js, err := nc.JetStream()
   if err != nil { return nil, err }
 
-  if err := messaging.EnsureStreams(ctx, js); err != nil { return nil, err }
-
   led := ledger.New(db, logger)
-  pub := messaging.NewOutboxPublisher(db, js, logger)
-  fraud := messaging.NewFraudConsumer(db, js, logger)
+  if ro := st.ReadPool(); ro != db {
+    led.UseReadReplica(ro)
+    logger.Info("routing list and export queries to the read replica")
+  }
+  if cfg.SimSeed != 0 { led.Reseed(cfg.SimSeed) }
+  led.SetZoneCacheTTL(cfg.zoneCacheTTL())
+  led.SetTransferIsolation(cfg.transferIsolation())
+  led.SetRequireKnownActors(cfg.RequireKnownActors)
+  led.SetRequireReasonCode(cfg.RequireReasonCode)
+  led.SetStrictAccounts(cfg.StrictAccounts)
+  led.SetMetadataRedaction(cfg.metadataRedaction())
+  led.SetTwoPersonRule(cfg.TwoPersonRule, cfg.ApprovalTTL)
+  led.SetMultiTenant(cfg.Store.MultiTenant)
+  signer, err := auditsig.New(cfg.AuditSigning)
+  if err != nil { return nil, err }
+  if signer != nil {
+    led.SetAuditSigner(signer)
+    logger.Info("audit entries are signed", "alg", signer.Alg(), "key_id", signer.KeyID())
+  }
+  zoneGauges := metrics.NewZoneCollector(led.ZoneGauges)
+  if err := prometheus.Register(zoneGauges); err != nil { return nil, err }
+  alertGauges := metrics.NewAlertCollector()
+  if err := prometheus.Register(alertGauges); err != nil { return nil, err }
+  alerts := ledger.NewAlertUpdater(led, logger, alertGauges)
+  alerts.SetInterval(cfg.AlertMetricsInterval)
+  logger.Info("sim random seed", "seed", led.Seed())
+  eventCipher, err := messaging.NewCipher(cfg.EventEncryption)
+  if err != nil { return nil, err }
+  if eventCipher.KeyID() != "" { logger.Info("event payloads are encrypted", "alg", messaging.EncryptionAlg, "key_id", eventCipher.KeyID()) }
+  pub := messaging.NewOutboxPublisher(db, js, eventCipher, logger)
+  pub.SetTuning(cfg.outboxTuning())
+  fraud := messaging.NewFraudConsumer(db, js, eventCipher, logger)
+  sagas := messaging.NewSagaConsumer(led, js, eventCipher, logger)
+  balNotifier := messaging.NewBalanceNotifier(led, js, eventCipher, logger)
+  balHub := messaging.NewBalanceHub(eventCipher, logger)
+  sched := ledger.NewControlScheduler(led, logger)
+  balMon := ledger.NewBalanceMonitor(led, logger)
+  balMon.SetTuning(cfg.BalanceMonitorInterval, cfg.balanceThresholds())
+  settler := ledger.NewSettlementRunner(led, logger)
+  settler.SetInterval(cfg.SettlementInterval)
+  spoolSampler := ledger.NewSpoolSampler(led, logger)
+  spoolSampler.SetInterval(cfg.SpoolSampleInterval)
+  statsRollup := ledger.NewStatsRollup(led, logger)
+  statsRollup.SetInterval(cfg.StatsRollupInterval)
+  dayCloser := ledger.NewDayCloser(led, logger)
+  dayCloser.SetInterval(cfg.DayCloseInterval)
+  jobs := ledger.NewJobRunner(led, logger, cfg.JobWorkers)
+  jobs.SetRetention(cfg.JobRetention)
+  scenarios := ledger.NewScenarioRunner(led, logger)
 
   a := &App{
-    cfg: cfg, log: logger, db: db, nc: nc, js: js,
+    cfg: cfg, log: logger, store: st, db: db, natsSrv: natsSrv, nc: nc, js: js,
+    level: level, cors: web.NewCORS(cfg.CorsAllowOrigins), watch: watch,
     shutdownTracer: shutdown,
+    zoneGauges: zoneGauges,
+    alertGauges: alertGauges,
+    led: led,
+    pub: pub,
+    pubLeader: leader.New(db, "outbox-publisher", logger),
+    schedLeader: leader.New(db, "control-scheduler", logger),
+    balMon: balMon,
+    balLeader: leader.New(db, "balance-monitor", logger),
+    settler: settler,
+    settleLeader: leader.New(db, "settlement-runner", logger),
+    spoolSampler: spoolSampler,
+    sampleLeader: leader.New(db, "spool-sampler", logger),
+    statsRollup: statsRollup,
+    rollupLeader: leader.New(db, "stats-rollup", logger),
+    dayCloser: dayCloser,
+    dayLeader: leader.New(db, "day-closer", logger),
+    jobs: jobs,
+    alerts: alerts,
     done: make(chan struct{}),
   }
+  tun := cfg.Tunables
+  a.tun.Store(&tun)
 
   r := chi.NewRouter()
-  r.Use(web.CORSMiddleware(cfg.CorsAllowOrigins))
-  r.Get("/healthz", func(w http.ResponseWriter, r *http.Request){ w.WriteHeader(200); _, _ = w.Write([]byte("ok")) })
+  r.Use(web.RequestIDMiddleware) // X-Request-Id, echoed as request_id in logs and error bodies
+  r.Use(web.TraceMiddleware) // server span per request, continuing an incoming traceparent
+  r.Use(web.AccessLogMiddleware(logger))
+  r.Use(a.cors.Middleware)
+  r.Use(middleware.Compress(5, web.CompressibleTypes...)) // gzip/deflate per Accept-Encoding
+  r.Get("/livez", a.handleLivez)
+  r.Get("/readyz", a.handleReadyz)
   r.Handle("/metrics", promhttp.Handler())
 
-  api := web.NewAPI(cfg.AdminKey, led, logger)
-  api.RegisterRoutes(r)
+  store, err := objstore.New(cfg.S3)
+  if err != nil { return nil, err }
+
+  verifier, err := auth.New(ctx, cfg.OIDC)
+  if err != nil { return nil, err }
+  if verifier != nil { logger.Info("oidc auth enabled", "issuer", cfg.OIDC.Issuer) }
+
+  api := web.NewAPI(cfg.AdminKey, led, scenarios, store, a, a, balHub, messaging.NewStreamAdmin(js), watch, logger)
+  api.RegisterDocs(r)
+  r.Group(func(r chi.Router) {
+    r.Use(api.TenantMiddleware) // first, so the call's audit entry is the tenant's
+    r.Use(api.AuditMiddleware) // outside auth so rejected calls are recorded too
+    r.Use(a.gate.Middleware)
+    r.Use(web.HoldWrites(a.msgReady.Load))
+    r.Use(web.AuthMiddleware(verifier))
+    api.RegisterRoutes(r)
+  })
 
   a.router = r
+  if cfg.GRPCPort != "" { a.grpc = grpcapi.NewServer(led, verifier, grpcapi.WriteGate{Drain: &a.gate, Ready: a.msgReady.Load}, logger) }
 
-  // background loops
-  go pub.Run(ctx)
-  go fraud.Run(ctx)
+  // background loops; Shutdown stops them before closing connections
+  loopCtx, stopLoops := context.WithCancel(ctx)
+  a.stopLoops = stopLoops
+  a.loops.Go(func() { a.runMessaging(loopCtx, fraud, sagas, balNotifier, balHub) })
+  a.loops.Go(func() { a.schedLeader.Run(loopCtx, sched.Run) })
+  a.loops.Go(func() { a.balLeader.Run(loopCtx, balMon.Run) })
+  a.loops.Go(func() { a.settleLeader.Run(loopCtx, settler.Run) })
+  a.loops.Go(func() { a.sampleLeader.Run(loopCtx, spoolSampler.Run) })
+  a.loops.Go(func() { a.rollupLeader.Run(loopCtx, statsRollup.Run) })
+  a.loops.Go(func() { a.dayLeader.Run(loopCtx, dayCloser.Run) })
+  a.loops.Go(func() { jobs.Run(loopCtx) })
+  a.loops.Go(func() { alerts.Run(loopCtx) }) // every replica: rules aggregate across instances
+  a.loops.Go(func() { scenarios.Run(loopCtx) })
+  a.loops.Go(func() { ledger.NewZoneCacheListener(led, db, logger).Run(loopCtx) })
 
   return a, nil
 }
@@ -81,10 +247,124 @@ func (a *App) Router() http.Handler { return a.router }
 
 func (a *App) Done() <-chan struct{} { return a.done }
 
+// ServeGRPC listens on GRPC_PORT and blocks until the server stops. It returns
+// nil right away when gRPC is disabled.
+func (a *App) ServeGRPC() error {
+  if a.grpc == nil { return nil }
+  lis, err := net.Listen("tcp", ":"+a.cfg.GRPCPort)
+  if err != nil { return err }
+  return a.grpc.Serve(lis)
+}
+
+// Drain closes the write gate (new mutating requests get 503 and /readyz
+// fails), waits for in-flight writes and publishes the outbox backlog. The
+// process keeps serving reads; draining lasts until restart.
+func (a *App) Drain(ctx context.Context) (*web.DrainReport, error) {
+  start := time.Now()
+  if !a.gate.Closed() { a.log.InfoContext(ctx, "draining") }
+  a.gate.Close()
+  if err := a.gate.Wait(ctx); err != nil { return nil, err }
+  n, err := a.flushOutbox(ctx, a.pubLeader.IsLeader())
+  if err != nil { return nil, err }
+  backlog, err := messaging.OutboxBacklog(ctx, a.db)
+  if err != nil { return nil, err }
+  return &web.DrainReport{
+    Draining: true,
+    OutboxPublished: n,
+    OutboxBacklog: backlog,
+    DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+  }, nil
+}
+
+// Shutdown stops work in dependency order before Close: drain HTTP writes and
+// the outbox, let in-flight gRPC calls finish, stop the background loops (each
+// finishes its current batch; the consumer acks what it fetched), then publish
+// whatever those last batches wrote. It returns ctx's error if time runs out.
+func (a *App) Shutdown(ctx context.Context) error {
+  rep, err := a.Drain(ctx)
+  if err != nil { return err }
+  // stopping the loops gives up leadership; the final flush belongs to
+  // whoever was publishing when shutdown began
+  publishing := a.pubLeader.IsLeader()
+  if a.grpc != nil {
+    stopped := make(chan struct{})
+    go func() { a.grpc.GracefulStop(); close(stopped) }()
+    select {
+    case <-stopped:
+    case <-ctx.Done():
+      a.grpc.Stop()
+    }
+  }
+  a.stopLoops()
+  loopsDone := make(chan struct{})
+  go func() { a.loops.Wait(); close(loopsDone) }()
+  select {
+  case <-loopsDone:
+  case <-ctx.Done():
+    return ctx.Err()
+  }
+  n, err := a.flushOutbox(ctx, publishing)
+  if err != nil { return err }
+  a.log.Info("shutdown drained", "outbox_published", rep.OutboxPublished+n, "duration_ms", rep.DurationMs)
+  return nil
+}
+
+// runMessaging waits for JetStream (retrying with the startup backoff for as
+// long as it takes), then runs the fraud and saga consumers, the balance
+// notifier and hub and, while this replica leads, the outbox publisher until
+// ctx ends. Until then writes get 503 and /readyz reports degraded. Balance
+// streams end with it, ready or not.
+func (a *App) runMessaging(ctx context.Context, fraud *messaging.FraudConsumer, sagas *messaging.SagaConsumer,
+  balances *messaging.BalanceNotifier, hub *messaging.BalanceHub) {
+  defer hub.Stop()
+  forever := a.cfg.Startup
+  forever.Timeout = -1
+  err := retry(ctx, forever, a.log, "jetstream", a.ensureStreams)
+  if err != nil { return }
+  a.msgReady.Store(true)
+  a.log.Info("messaging ready")
+  var wg sync.WaitGroup
+  wg.Go(func() { a.pubLeader.Run(ctx, a.pub.Run) })
+  wg.Go(func() { fraud.Run(ctx) })
+  wg.Go(func() { sagas.Run(ctx) })
+  wg.Go(func() { balances.Run(ctx) })
+  wg.Go(func() { hub.Run(ctx, a.js) })
+  wg.Wait()
+}
+
+// ensureStreams creates or updates the EVENTS stream. A config the stream
+// cannot safely take is logged and the stream kept as it is: messaging works
+// either way, and giving up messages is an operator's call.
+func (a *App) ensureStreams(ctx context.Context) error {
+  changed, err := messaging.EnsureStreams(ctx, a.js, a.cfg.Stream)
+  if messaging.IsStreamConfigConflict(err) {
+    a.log.ErrorContext(ctx, "stream config not applied", "stream", messaging.StreamName, "err", err.Error())
+    return nil
+  }
+  if err != nil { return err }
+  if len(changed) > 0 { a.log.InfoContext(ctx, "stream config updated", "stream", messaging.StreamName, "changed", changed) }
+  return nil
+}
+
+// MessagingReady reports whether JetStream is set up and writes are accepted.
+func (a *App) MessagingReady() bool { return a.msgReady.Load() }
+
+// flushOutbox publishes the outbox backlog, or nothing while messaging is down
+// (events stay in the outbox for the next start) or another replica publishes.
+func (a *App) flushOutbox(ctx context.Context, leading bool) (int, error) {
+  if !a.msgReady.Load() || !leading { return 0, nil }
+  return a.pub.Flush(ctx)
+}
+
 func (a *App) Close() {
   defer close(a.done)
+  if a.stopLoops != nil { a.stopLoops() }
+  if a.grpc != nil { a.grpc.Stop() }
   if a.nc != nil { a.nc.Close() }
-  if a.db != nil { a.db.Close() }
+  if a.natsSrv != nil { a.natsSrv.Shutdown() }
+  if a.store != nil { a.store.Close() }
+  if a.zoneGauges != nil { prometheus.Unregister(a.zoneGauges) }
+  if a.alertGauges != nil { prometheus.Unregister(a.alertGauges) }
   if a.shutdownTracer != nil {
     _ = a.shutdownTracer(context.Background())
   }
@@ -1,26 +1,475 @@
 package app
 
-import "os"
+import (
+  "bytes"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "io"
+  "log/slog"
+  "net/url"
+  "os"
+  "reflect"
+  "strconv"
+  "strings"
+  "time"
 
+  "github.com/jackc/pgx/v5/pgxpool"
+  "go.yaml.in/yaml/v3"
+
+  "time-ledger-sim/go/internal/auditsig"
+  "time-ledger-sim/go/internal/auth"
+  "time-ledger-sim/go/internal/ledger"
+  "time-ledger-sim/go/internal/logging"
+  "time-ledger-sim/go/internal/messaging"
+  "time-ledger-sim/go/internal/objstore"
+  "time-ledger-sim/go/internal/store"
+)
+
+// Config is built from defaults, then the YAML file (CONFIG_FILE or -config)
+// if any, then environment variables, and validated before startup. YAML keys
+// are the yaml tags below; the env var for each setting is in its comment.
 type Config struct {
-  CorsAllowOrigins string
-  Port        string
-  DatabaseURL string
-  NatsURL     string
-  OtelEndpoint string
-  AdminKey    string
+  File string `yaml:"-"` // YAML file it was loaded from; re-read on reload
+  Tunables `yaml:",inline"`
+  Port        string `yaml:"port"` // PORT
+  GRPCPort    string `yaml:"grpc_port"` // GRPC_PORT; "" or "off" disables the gRPC server
+  Store store.Config `yaml:"database"` // DATABASE_URL, or "embedded" for an in-process Postgres
+  NatsURL     string `yaml:"nats_url"` // NATS_URL; "embedded" runs an in-process JetStream server
+  Stream messaging.StreamConfig `yaml:"nats_stream"` // NATS_STREAM_*; the EVENTS stream, created or updated to match at startup
+  OtelEndpoint string `yaml:"otel_endpoint"` // OTEL_EXPORTER_OTLP_ENDPOINT
+  AdminKey    string `yaml:"admin_key"` // ADMIN_KEY
+  SimSeed     uint64 `yaml:"sim_seed"` // SIM_SEED; 0 = derive from startup time
+  JobWorkers  int    `yaml:"job_workers"` // JOB_WORKERS; background job steps this replica runs at once
+  ShutdownTimeout time.Duration `yaml:"shutdown_timeout"` // SHUTDOWN_TIMEOUT; bound on draining and stopping before connections close
+  Startup StartupRetry `yaml:"startup"` // retrying Postgres and NATS at boot
+  S3 objstore.Config `yaml:"s3"` // snapshot storage; disabled when S3_ENDPOINT is unset
+  OIDC auth.Config `yaml:"oidc"` // bearer-token auth; disabled when OIDC_ISSUER is unset
+  AuditSigning auditsig.Config `yaml:"audit_signing"` // signs audit entries; disabled when no key is set
+  EventEncryption messaging.EncryptionConfig `yaml:"event_encryption"` // encrypts event payloads on NATS; disabled when no key is set
+}
+
+// StartupRetry bounds how long startup waits for dependencies. Postgres is
+// retried for up to Timeout, then startup fails; NATS is retried in the
+// background for as long as it takes, with writes held until it is up.
+type StartupRetry struct {
+  Timeout time.Duration `yaml:"timeout"` // STARTUP_TIMEOUT; 0 = fail on the first error
+  Backoff time.Duration `yaml:"backoff"` // STARTUP_BACKOFF, first wait, doubled after each attempt
+  BackoffMax time.Duration `yaml:"backoff_max"` // STARTUP_BACKOFF_MAX
+}
+
+// Tunables take effect without a restart, on SIGHUP or POST /v1/sim/config/reload.
+type Tunables struct {
+  LogLevel slog.Level `yaml:"log_level"` // LOG_LEVEL: debug, info (default), warn, error
+  CorsAllowOrigins string `yaml:"cors_allow_origins"` // CORS_ALLOW_ORIGINS, comma-separated; "*" allows any
+  OutboxMinInterval time.Duration `yaml:"outbox_min_interval"` // OUTBOX_MIN_INTERVAL, shortest wait between publisher polls, while events keep coming
+  OutboxInterval time.Duration `yaml:"outbox_interval"` // OUTBOX_INTERVAL, longest wait between publisher polls, reached while the outbox stays empty
+  OutboxBatch int `yaml:"outbox_batch"` // OUTBOX_BATCH_SIZE, fewest events per poll, when idle
+  OutboxMaxBatch int `yaml:"outbox_max_batch"` // OUTBOX_MAX_BATCH_SIZE, most events per poll, under load
+  OutboxReadyMax int64 `yaml:"outbox_ready_max"` // OUTBOX_READY_MAX_BACKLOG; /readyz fails above this many unpublished events; 0 disables
+  SlowQuery time.Duration `yaml:"db_slow_query"` // DB_SLOW_QUERY_MS; 0 disables
+  LongTx time.Duration `yaml:"db_long_tx"` // DB_LONG_TX_MS; 0 disables
+  ZoneCacheTTL time.Duration `yaml:"zone_cache_ttl"` // ZONE_CACHE_TTL; how long transfers reuse a zone's status and controls; 0 disables
+  ZoneCacheStrict bool `yaml:"zone_cache_strict"` // ZONE_CACHE_STRICT; read them in every transfer's transaction regardless of the TTL
+  TransferIsolation string `yaml:"transfer_isolation"` // TRANSFER_ISOLATION: read_committed (default), repeatable_read or serializable
+  TransferRetries int `yaml:"transfer_retries"` // TRANSFER_RETRIES after a serialization failure or deadlock
+  RequireKnownActors bool `yaml:"require_known_actors"` // REQUIRE_KNOWN_ACTORS; status, controls and incident changes must name a registered actor
+  RequireReasonCode bool `yaml:"require_reason_code"` // REQUIRE_REASON_CODE; status, controls and replay changes must carry a catalog reason code
+  StrictAccounts bool `yaml:"strict_accounts"` // STRICT_ACCOUNTS; transfers fail on unknown accounts instead of creating them
+  RedactMetadataKeys string `yaml:"redact_metadata_keys"` // REDACT_METADATA_KEYS, comma-separated key patterns masked in the change feed, snapshots and exports
+  TwoPersonRule bool `yaml:"two_person_rule"` // TWO_PERSON_RULE; zone DOWN, blocking writes and restores wait for a second operator's approval
+  ApprovalTTL time.Duration `yaml:"approval_ttl"` // APPROVAL_TTL; how long such an approval stays open
+  BalanceMonitorInterval time.Duration `yaml:"balance_monitor_interval"` // BALANCE_MONITOR_INTERVAL between negative balance checks; 0 disables
+  AccountBalanceFloor int64 `yaml:"account_balance_floor"` // ACCOUNT_BALANCE_FLOOR; an account below this many units opens a WARN incident
+  ZoneBalanceFloor int64 `yaml:"zone_balance_floor"` // ZONE_BALANCE_FLOOR; a zone whose accounts sum below this opens a CRITICAL incident
+  BalanceHysteresis int64 `yaml:"balance_hysteresis"` // BALANCE_HYSTERESIS; units above the floor a balance must recover before its incident clears
+  SettlementInterval time.Duration `yaml:"settlement_interval"` // SETTLEMENT_INTERVAL between inter-zone settlement runs; 0 disables
+  SpoolSampleInterval time.Duration `yaml:"spool_sample_interval"` // SPOOL_SAMPLE_INTERVAL between spool depth samples; 0 disables
+  JobRetention time.Duration `yaml:"job_retention"` // JOB_RETENTION; how long finished background jobs are kept; 0 keeps them
+  StatsRollupInterval time.Duration `yaml:"stats_rollup_interval"` // STATS_ROLLUP_INTERVAL between rollups of the per-minute stats buckets; 0 disables
+  DayCloseInterval time.Duration `yaml:"day_close_interval"` // DAY_CLOSE_INTERVAL between looks for business days to close; 0 disables
+  AlertMetricsInterval time.Duration `yaml:"alert_metrics_interval"` // ALERT_METRICS_INTERVAL between refreshes of the sim_* alerting metrics; 0 disables
+}
+
+// balanceThresholds are the negative balance monitor's floors.
+func (t Tunables) outboxTuning() messaging.OutboxTuning {
+  return messaging.OutboxTuning{MinInterval: t.OutboxMinInterval, MaxInterval: t.OutboxInterval, MinBatch: t.OutboxBatch, MaxBatch: t.OutboxMaxBatch}
+}
+
+func (t Tunables) balanceThresholds() ledger.BalanceThresholds {
+  return ledger.BalanceThresholds{AccountFloor: t.AccountBalanceFloor, ZoneFloor: t.ZoneBalanceFloor, Hysteresis: t.BalanceHysteresis}
+}
+
+// zoneCacheTTL is the TTL the ledger should use: 0 when strict.
+func (t Tunables) zoneCacheTTL() time.Duration {
+  if t.ZoneCacheStrict { return 0 }
+  return t.ZoneCacheTTL
+}
+
+// metadataRedaction is the validated redaction policy (nil when off).
+func (t Tunables) metadataRedaction() *ledger.RedactionPolicy {
+  p, _ := ledger.NewRedactionPolicy(t.RedactMetadataKeys)
+  return p
+}
+
+// transferIsolation is the validated isolation level and retry budget.
+func (t Tunables) transferIsolation() (ledger.Isolation, int) {
+  iso, _ := ledger.ParseIsolation(t.TransferIsolation)
+  return iso, t.TransferRetries
+}
+
+// MarshalJSON writes durations as strings ("250ms") for the config endpoint.
+func (t Tunables) MarshalJSON() ([]byte, error) {
+  return json.Marshal(map[string]any{
+    "log_level": t.LogLevel.String(),
+    "cors_allow_origins": t.CorsAllowOrigins,
+    "outbox_min_interval": t.OutboxMinInterval.String(),
+    "outbox_interval": t.OutboxInterval.String(),
+    "outbox_batch": t.OutboxBatch,
+    "outbox_max_batch": t.OutboxMaxBatch,
+    "outbox_ready_max": t.OutboxReadyMax,
+    "db_slow_query": t.SlowQuery.String(),
+    "db_long_tx": t.LongTx.String(),
+    "zone_cache_ttl": t.ZoneCacheTTL.String(),
+    "zone_cache_strict": t.ZoneCacheStrict,
+    "transfer_isolation": t.TransferIsolation,
+    "transfer_retries": t.TransferRetries,
+    "require_known_actors": t.RequireKnownActors,
+    "require_reason_code": t.RequireReasonCode,
+    "strict_accounts": t.StrictAccounts,
+    "redact_metadata_keys": t.RedactMetadataKeys,
+    "two_person_rule": t.TwoPersonRule,
+    "approval_ttl": t.ApprovalTTL.String(),
+    "balance_monitor_interval": t.BalanceMonitorInterval.String(),
+    "account_balance_floor": t.AccountBalanceFloor,
+    "zone_balance_floor": t.ZoneBalanceFloor,
+    "balance_hysteresis": t.BalanceHysteresis,
+    "settlement_interval": t.SettlementInterval.String(),
+    "spool_sample_interval": t.SpoolSampleInterval.String(),
+    "job_retention": t.JobRetention.String(),
+    "stats_rollup_interval": t.StatsRollupInterval.String(),
+    "day_close_interval": t.DayCloseInterval.String(),
+    "alert_metrics_interval": t.AlertMetricsInterval.String(),
+  })
 }
 
-func LoadConfigFromEnv() Config {
-  cfg := Config{
+func DefaultConfig() Config {
+  return Config{
+    Tunables: Tunables{
+      LogLevel: slog.LevelInfo,
+      CorsAllowOrigins: "http://localhost:5173,http://localhost:4173",
+      OutboxMinInterval: messaging.DefaultOutboxMinInterval,
+      OutboxInterval: messaging.DefaultOutboxInterval,
+      OutboxBatch: messaging.DefaultOutboxBatch,
+      OutboxMaxBatch: messaging.DefaultOutboxMaxBatch,
+      OutboxReadyMax: 10000,
+      SlowQuery: 250 * time.Millisecond,
+      LongTx: 2 * time.Second,
+      ZoneCacheTTL: time.Second,
+      TransferIsolation: string(ledger.IsolationReadCommitted),
+      TransferRetries: ledger.DefaultTxRetries,
+      ApprovalTTL: ledger.DefaultApprovalTTL,
+      BalanceMonitorInterval: 30 * time.Second,
+      AccountBalanceFloor: ledger.DefaultAccountBalanceFloor,
+      ZoneBalanceFloor: ledger.DefaultZoneBalanceFloor,
+      BalanceHysteresis: ledger.DefaultBalanceHysteresis,
+      SettlementInterval: time.Hour,
+      SpoolSampleInterval: 15 * time.Second,
+      JobRetention: ledger.DefaultJobRetention,
+      StatsRollupInterval: time.Minute,
+      DayCloseInterval: time.Minute,
+      AlertMetricsInterval: 15 * time.Second,
+    },
     Port: "8080",
-    DatabaseURL: os.Getenv("DATABASE_URL"),
-    NatsURL: os.Getenv("NATS_URL"),
-    OtelEndpoint: os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
-    AdminKey: os.Getenv("ADMIN_KEY"),
-    CorsAllowOrigins: os.Getenv("CORS_ALLOW_ORIGINS"),
-  }
-  if p := os.Getenv("PORT"); p != "" { cfg.Port = p }
-  if cfg.CorsAllowOrigins == "" { cfg.CorsAllowOrigins = "http://localhost:5173,http://localhost:4173" }
-  return cfg
+    GRPCPort: "9090",
+    JobWorkers: 2,
+    ShutdownTimeout: 30 * time.Second,
+    Startup: StartupRetry{Timeout: time.Minute, Backoff: 250 * time.Millisecond, BackoffMax: 5 * time.Second},
+    Store: store.Config{Migrations: "../db/migrations"},
+    Stream: messaging.DefaultStreamConfig(),
+    S3: objstore.Config{UseSSL: true},
+  }
+}
+
+// LoadConfig reads path (or CONFIG_FILE when path is empty; no file is fine),
+// applies environment overrides and validates the result.
+func LoadConfig(path string) (Config, error) { return loadConfig(path, os.Getenv) }
+
+func loadConfig(path string, getenv func(string) string) (Config, error) {
+  cfg := DefaultConfig()
+  if path == "" { path = getenv("CONFIG_FILE") }
+  cfg.File = path
+  if path != "" {
+    b, err := os.ReadFile(path)
+    if err != nil { return cfg, fmt.Errorf("config file: %w", err) }
+    dec := yaml.NewDecoder(bytes.NewReader(b))
+    dec.KnownFields(true) // a misspelt key is an error, not a silently ignored setting
+    if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) { return cfg, fmt.Errorf("config file %s: %w", path, err) }
+  }
+  if err := applyEnv(&cfg, getenv); err != nil { return cfg, err }
+  if cfg.GRPCPort == "off" { cfg.GRPCPort = "" }
+  return cfg, cfg.Validate()
+}
+
+// applyEnv overrides cfg with every env var that is set. Values that do not
+// parse are errors (all of them are reported) rather than falling back.
+func applyEnv(cfg *Config, getenv func(string) string) error {
+  var problems []string
+  set := func(name string, parse func(string) error) {
+    v := getenv(name)
+    if v == "" { return }
+    if err := parse(v); err != nil { problems = append(problems, fmt.Sprintf("%s=%q: %v", name, v, err)) }
+  }
+  str := func(dst *string) func(string) error { return func(v string) error { *dst = v; return nil } }
+  ms := func(dst *time.Duration) func(string) error {
+    return func(v string) error {
+      n, err := strconv.Atoi(v)
+      if err != nil { return errors.New("want milliseconds, e.g. 250") }
+      *dst = time.Duration(n) * time.Millisecond
+      return nil
+    }
+  }
+  dur := func(dst *time.Duration) func(string) error {
+    return func(v string) error {
+      d, err := time.ParseDuration(v)
+      if err != nil { return errors.New("want a duration, e.g. 500ms or 30s") }
+      *dst = d
+      return nil
+    }
+  }
+
+  set("LOG_LEVEL", func(v string) error {
+    var l slog.Level
+    if l.UnmarshalText([]byte(v)) != nil { return errors.New("want debug, info, warn or error") }
+    cfg.LogLevel = logging.ParseLevel(v, cfg.LogLevel)
+    return nil
+  })
+  set("CORS_ALLOW_ORIGINS", str(&cfg.CorsAllowOrigins))
+  set("OUTBOX_MIN_INTERVAL", dur(&cfg.OutboxMinInterval))
+  set("OUTBOX_INTERVAL", dur(&cfg.OutboxInterval))
+  set("OUTBOX_BATCH_SIZE", func(v string) (err error) { cfg.OutboxBatch, err = strconv.Atoi(v); return })
+  set("OUTBOX_MAX_BATCH_SIZE", func(v string) (err error) { cfg.OutboxMaxBatch, err = strconv.Atoi(v); return })
+  set("OUTBOX_READY_MAX_BACKLOG", func(v string) (err error) { cfg.OutboxReadyMax, err = strconv.ParseInt(v, 10, 64); return })
+  set("DB_SLOW_QUERY_MS", ms(&cfg.SlowQuery))
+  set("DB_LONG_TX_MS", ms(&cfg.LongTx))
+  set("ZONE_CACHE_TTL", dur(&cfg.ZoneCacheTTL))
+  set("ZONE_CACHE_STRICT", func(v string) (err error) { cfg.ZoneCacheStrict, err = strconv.ParseBool(v); return })
+  set("TRANSFER_ISOLATION", str(&cfg.TransferIsolation))
+  set("TRANSFER_RETRIES", func(v string) (err error) { cfg.TransferRetries, err = strconv.Atoi(v); return })
+  set("REQUIRE_KNOWN_ACTORS", func(v string) (err error) { cfg.RequireKnownActors, err = strconv.ParseBool(v); return })
+  set("REQUIRE_REASON_CODE", func(v string) (err error) { cfg.RequireReasonCode, err = strconv.ParseBool(v); return })
+  set("STRICT_ACCOUNTS", func(v string) (err error) { cfg.StrictAccounts, err = strconv.ParseBool(v); return })
+  set("REDACT_METADATA_KEYS", str(&cfg.RedactMetadataKeys))
+  set("TWO_PERSON_RULE", func(v string) (err error) { cfg.TwoPersonRule, err = strconv.ParseBool(v); return })
+  set("APPROVAL_TTL", dur(&cfg.ApprovalTTL))
+  set("BALANCE_MONITOR_INTERVAL", dur(&cfg.BalanceMonitorInterval))
+  set("ACCOUNT_BALANCE_FLOOR", func(v string) (err error) { cfg.AccountBalanceFloor, err = strconv.ParseInt(v, 10, 64); return })
+  set("ZONE_BALANCE_FLOOR", func(v string) (err error) { cfg.ZoneBalanceFloor, err = strconv.ParseInt(v, 10, 64); return })
+  set("BALANCE_HYSTERESIS", func(v string) (err error) { cfg.BalanceHysteresis, err = strconv.ParseInt(v, 10, 64); return })
+  set("SETTLEMENT_INTERVAL", dur(&cfg.SettlementInterval))
+  set("SPOOL_SAMPLE_INTERVAL", dur(&cfg.SpoolSampleInterval))
+  set("JOB_RETENTION", dur(&cfg.JobRetention))
+  set("STATS_ROLLUP_INTERVAL", dur(&cfg.StatsRollupInterval))
+  set("DAY_CLOSE_INTERVAL", dur(&cfg.DayCloseInterval))
+  set("ALERT_METRICS_INTERVAL", dur(&cfg.AlertMetricsInterval))
+
+  set("PORT", str(&cfg.Port))
+  set("GRPC_PORT", str(&cfg.GRPCPort))
+  set("DATABASE_URL", str(&cfg.Store.URL))
+  set("DATABASE_READ_URL", str(&cfg.Store.ReadURL))
+  set("EMBEDDED_PG_DIR", str(&cfg.Store.DataDir))
+  set("EMBEDDED_PG_PORT", func(v string) error {
+    n, err := strconv.ParseUint(v, 10, 16)
+    cfg.Store.Port = uint32(n)
+    return err
+  })
+  set("MIGRATIONS_DIR", str(&cfg.Store.Migrations))
+  set("MULTI_TENANT", func(v string) (err error) { cfg.Store.MultiTenant, err = strconv.ParseBool(v); return })
+  set("NATS_URL", str(&cfg.NatsURL))
+  set("NATS_STREAM_SUBJECTS", func(v string) error {
+    cfg.Stream.Subjects = nil
+    for _, subj := range strings.Split(v, ",") { cfg.Stream.Subjects = append(cfg.Stream.Subjects, strings.TrimSpace(subj)) }
+    return nil
+  })
+  set("NATS_STREAM_RETENTION", str(&cfg.Stream.Retention))
+  set("NATS_STREAM_STORAGE", str(&cfg.Stream.Storage))
+  set("NATS_STREAM_MAX_AGE", dur(&cfg.Stream.MaxAge))
+  set("NATS_STREAM_MAX_BYTES", func(v string) (err error) { cfg.Stream.MaxBytes, err = strconv.ParseInt(v, 10, 64); return })
+  set("NATS_STREAM_MAX_MSGS_PER_SUBJECT", func(v string) (err error) { cfg.Stream.MaxMsgsPerSubject, err = strconv.ParseInt(v, 10, 64); return })
+  set("NATS_STREAM_DUPLICATE_WINDOW", dur(&cfg.Stream.DuplicateWindow))
+  set("NATS_STREAM_REPLICAS", func(v string) (err error) { cfg.Stream.Replicas, err = strconv.Atoi(v); return })
+  set("NATS_STREAM_ALLOW_DATA_LOSS", func(v string) (err error) { cfg.Stream.AllowDataLoss, err = strconv.ParseBool(v); return })
+  set("OTEL_EXPORTER_OTLP_ENDPOINT", str(&cfg.OtelEndpoint))
+  set("ADMIN_KEY", str(&cfg.AdminKey))
+  set("SIM_SEED", func(v string) (err error) { cfg.SimSeed, err = strconv.ParseUint(v, 10, 64); return })
+  set("JOB_WORKERS", func(v string) (err error) { cfg.JobWorkers, err = strconv.Atoi(v); return })
+  set("SHUTDOWN_TIMEOUT", dur(&cfg.ShutdownTimeout))
+  set("STARTUP_TIMEOUT", dur(&cfg.Startup.Timeout))
+  set("STARTUP_BACKOFF", dur(&cfg.Startup.Backoff))
+  set("STARTUP_BACKOFF_MAX", dur(&cfg.Startup.BackoffMax))
+  set("S3_ENDPOINT", str(&cfg.S3.Endpoint))
+  set("S3_ACCESS_KEY_ID", str(&cfg.S3.AccessKeyID))
+  set("S3_SECRET_ACCESS_KEY", str(&cfg.S3.SecretAccessKey))
+  set("S3_REGION", str(&cfg.S3.Region))
+  set("S3_USE_SSL", func(v string) (err error) { cfg.S3.UseSSL, err = strconv.ParseBool(v); return })
+  set("S3_BUCKET", str(&cfg.S3.Bucket))
+  set("OIDC_ISSUER", str(&cfg.OIDC.Issuer))
+  set("OIDC_AUDIENCE", str(&cfg.OIDC.Audience))
+  set("OIDC_ACTOR_CLAIM", str(&cfg.OIDC.ActorClaim))
+  set("AUDIT_SIGNING_KEY", str(&cfg.AuditSigning.Key))
+  set("AUDIT_SIGNING_KEY_FILE", str(&cfg.AuditSigning.KeyFile))
+  set("AUDIT_SIGNING_KEY_ID", str(&cfg.AuditSigning.KeyID))
+  set("EVENT_ENCRYPTION_KEY", str(&cfg.EventEncryption.Key))
+  set("EVENT_ENCRYPTION_KEY_FILE", str(&cfg.EventEncryption.KeyFile))
+  set("EVENT_ENCRYPTION_PREVIOUS_KEYS", func(v string) error { cfg.EventEncryption.PreviousKeys = strings.Split(v, ","); return nil })
+
+  if len(problems) > 0 { return fmt.Errorf("invalid environment:\n  %s", strings.Join(problems, "\n  ")) }
+  return nil
+}
+
+// Validate reports every invalid setting at once, by YAML key and env var.
+func (c Config) Validate() error {
+  var problems []string
+  bad := func(key, env, format string, args ...any) {
+    problems = append(problems, fmt.Sprintf("%s (%s): %s", key, env, fmt.Sprintf(format, args...)))
+  }
+
+  switch c.Store.URL {
+  case "":
+    bad("database.url", "DATABASE_URL", "required: a postgres:// URL, or %q for an in-process Postgres", store.Embedded)
+  case store.Embedded:
+  default:
+    if _, err := pgxpool.ParseConfig(c.Store.URL); err != nil { bad("database.url", "DATABASE_URL", "%v", err) }
+  }
+  if c.Store.ReadURL != "" {
+    if c.Store.URL == store.Embedded {
+      bad("database.read_url", "DATABASE_READ_URL", "not supported with the embedded store")
+    } else if _, err := pgxpool.ParseConfig(c.Store.ReadURL); err != nil {
+      bad("database.read_url", "DATABASE_READ_URL", "%v", err)
+    }
+  }
+  if c.NatsURL == "" { bad("nats_url", "NATS_URL", "required: a nats:// URL, or %q for an in-process server", messaging.EmbeddedURL) }
+  streamProblems(c.Stream, bad)
+  if !validPort(c.Port) { bad("port", "PORT", "want a port number 1-65535, got %q", c.Port) }
+  if c.GRPCPort != "" && !validPort(c.GRPCPort) { bad("grpc_port", "GRPC_PORT", "want a port number 1-65535 or \"off\", got %q", c.GRPCPort) }
+  if c.GRPCPort != "" && c.GRPCPort == c.Port { bad("grpc_port", "GRPC_PORT", "must differ from port %s", c.Port) }
+  if c.JobWorkers < 1 || c.JobWorkers > 32 { bad("job_workers", "JOB_WORKERS", "want 1 to 32, got %d", c.JobWorkers) }
+  if c.ShutdownTimeout <= 0 { bad("shutdown_timeout", "SHUTDOWN_TIMEOUT", "must be positive, got %s", c.ShutdownTimeout) }
+  if c.Startup.Timeout < 0 { bad("startup.timeout", "STARTUP_TIMEOUT", "must not be negative (0 = no retry), got %s", c.Startup.Timeout) }
+  if c.Startup.Backoff <= 0 { bad("startup.backoff", "STARTUP_BACKOFF", "must be positive, got %s", c.Startup.Backoff) }
+  if c.Startup.BackoffMax < c.Startup.Backoff { bad("startup.backoff_max", "STARTUP_BACKOFF_MAX", "must be at least startup.backoff (%s), got %s", c.Startup.Backoff, c.Startup.BackoffMax) }
+  if c.S3.Endpoint != "" && strings.Contains(c.S3.Endpoint, "://") {
+    bad("s3.endpoint", "S3_ENDPOINT", "want host[:port] without a scheme (use_ssl selects https), got %q", c.S3.Endpoint)
+  }
+  if c.OIDC.Issuer != "" {
+    if u, err := url.Parse(c.OIDC.Issuer); err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+      bad("oidc.issuer", "OIDC_ISSUER", "want an absolute http(s) URL, got %q", c.OIDC.Issuer)
+    }
+  }
+  if _, err := auditsig.New(c.AuditSigning); err != nil { bad("audit_signing", "AUDIT_SIGNING_KEY", "%v", err) }
+  if _, err := messaging.NewCipher(c.EventEncryption); err != nil { bad("event_encryption", "EVENT_ENCRYPTION_KEY", "%v", err) }
+  for _, p := range c.Tunables.problems() { problems = append(problems, p) }
+
+  if len(problems) > 0 { return fmt.Errorf("invalid config:\n  %s", strings.Join(problems, "\n  ")) }
+  return nil
+}
+
+func (t Tunables) problems() []string {
+  var out []string
+  bad := func(key, env, format string, args ...any) {
+    out = append(out, fmt.Sprintf("%s (%s): %s", key, env, fmt.Sprintf(format, args...)))
+  }
+  for _, o := range strings.Split(t.CorsAllowOrigins, ",") {
+    o = strings.TrimSpace(o)
+    if o == "" || o == "*" { continue }
+    if u, err := url.Parse(o); err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") || (u.Path != "" && u.Path != "/") {
+      bad("cors_allow_origins", "CORS_ALLOW_ORIGINS", "want \"*\" or origins like https://ops.example.com, got %q", o)
+    }
+  }
+  if t.OutboxInterval < 10*time.Millisecond || t.OutboxInterval > time.Minute {
+    bad("outbox_interval", "OUTBOX_INTERVAL", "want 10ms to 1m, got %s", t.OutboxInterval)
+  }
+  if t.OutboxMinInterval < time.Millisecond || t.OutboxMinInterval > t.OutboxInterval {
+    bad("outbox_min_interval", "OUTBOX_MIN_INTERVAL", "want 1ms to outbox_interval (%s), got %s", t.OutboxInterval, t.OutboxMinInterval)
+  }
+  if t.OutboxBatch < 1 || t.OutboxBatch > 1000 { bad("outbox_batch", "OUTBOX_BATCH_SIZE", "want 1 to 1000, got %d", t.OutboxBatch) }
+  if t.OutboxMaxBatch < t.OutboxBatch || t.OutboxMaxBatch > 1000 {
+    bad("outbox_max_batch", "OUTBOX_MAX_BATCH_SIZE", "want outbox_batch (%d) to 1000, got %d", t.OutboxBatch, t.OutboxMaxBatch)
+  }
+  if t.OutboxReadyMax < 0 { bad("outbox_ready_max", "OUTBOX_READY_MAX_BACKLOG", "must not be negative (0 disables), got %d", t.OutboxReadyMax) }
+  if t.SlowQuery < 0 { bad("db_slow_query", "DB_SLOW_QUERY_MS", "must not be negative (0 disables), got %s", t.SlowQuery) }
+  if t.LongTx < 0 { bad("db_long_tx", "DB_LONG_TX_MS", "must not be negative (0 disables), got %s", t.LongTx) }
+  if t.ZoneCacheTTL < 0 || t.ZoneCacheTTL > time.Minute {
+    bad("zone_cache_ttl", "ZONE_CACHE_TTL", "want 0 (disabled) to 1m, got %s", t.ZoneCacheTTL)
+  }
+  if _, err := ledger.ParseIsolation(t.TransferIsolation); err != nil { bad("transfer_isolation", "TRANSFER_ISOLATION", "%v", err) }
+  if _, err := ledger.NewRedactionPolicy(t.RedactMetadataKeys); err != nil { bad("redact_metadata_keys", "REDACT_METADATA_KEYS", "%v", err) }
+  if t.TransferRetries < 0 || t.TransferRetries > 20 { bad("transfer_retries", "TRANSFER_RETRIES", "want 0 to 20, got %d", t.TransferRetries) }
+  if t.ApprovalTTL < time.Minute || t.ApprovalTTL > 7*24*time.Hour { bad("approval_ttl", "APPROVAL_TTL", "want 1m to 168h, got %s", t.ApprovalTTL) }
+  if t.BalanceMonitorInterval != 0 && (t.BalanceMonitorInterval < time.Second || t.BalanceMonitorInterval > time.Hour) {
+    bad("balance_monitor_interval", "BALANCE_MONITOR_INTERVAL", "want 0 (disabled) or 1s to 1h, got %s", t.BalanceMonitorInterval)
+  }
+  if t.BalanceHysteresis < 0 { bad("balance_hysteresis", "BALANCE_HYSTERESIS", "must not be negative, got %d", t.BalanceHysteresis) }
+  if t.SettlementInterval != 0 && (t.SettlementInterval < time.Minute || t.SettlementInterval > 7*24*time.Hour) {
+    bad("settlement_interval", "SETTLEMENT_INTERVAL", "want 0 (disabled) or 1m to 168h, got %s", t.SettlementInterval)
+  }
+  if t.SpoolSampleInterval != 0 && (t.SpoolSampleInterval < time.Second || t.SpoolSampleInterval > time.Hour) {
+    bad("spool_sample_interval", "SPOOL_SAMPLE_INTERVAL", "want 0 (disabled) or 1s to 1h, got %s", t.SpoolSampleInterval)
+  }
+  if t.JobRetention != 0 && (t.JobRetention < time.Hour || t.JobRetention > 90*24*time.Hour) {
+    bad("job_retention", "JOB_RETENTION", "want 0 (keep) or 1h to 2160h, got %s", t.JobRetention)
+  }
+  if t.StatsRollupInterval != 0 && (t.StatsRollupInterval < 10*time.Second || t.StatsRollupInterval > time.Hour) {
+    bad("stats_rollup_interval", "STATS_ROLLUP_INTERVAL", "want 0 (disabled) or 10s to 1h, got %s", t.StatsRollupInterval)
+  }
+  if t.DayCloseInterval != 0 && (t.DayCloseInterval < 10*time.Second || t.DayCloseInterval > time.Hour) {
+    bad("day_close_interval", "DAY_CLOSE_INTERVAL", "want 0 (disabled) or 10s to 1h, got %s", t.DayCloseInterval)
+  }
+  if t.AlertMetricsInterval != 0 && (t.AlertMetricsInterval < time.Second || t.AlertMetricsInterval > 10*time.Minute) {
+    bad("alert_metrics_interval", "ALERT_METRICS_INTERVAL", "want 0 (disabled) or 1s to 10m, got %s", t.AlertMetricsInterval)
+  }
+
+  return out
+}
+
+func streamProblems(s messaging.StreamConfig, bad func(key, env, format string, args ...any)) {
+  covered := false
+  for _, subj := range s.Subjects {
+    if subj == "" || strings.ContainsAny(subj, " \t") { bad("nats_stream.subjects", "NATS_STREAM_SUBJECTS", "want NATS subjects, got %q", subj) }
+    covered = covered || subj == messaging.EventSubjects || subj == ">"
+  }
+  if !covered { bad("nats_stream.subjects", "NATS_STREAM_SUBJECTS", "must include %s, which the outbox publishes on, got %v", messaging.EventSubjects, s.Subjects) }
+  if s.Retention != "limits" && s.Retention != "interest" {
+    bad("nats_stream.retention", "NATS_STREAM_RETENTION", "want limits or interest, got %q", s.Retention)
+  }
+  if s.Storage != "file" && s.Storage != "memory" { bad("nats_stream.storage", "NATS_STREAM_STORAGE", "want file or memory, got %q", s.Storage) }
+  if s.MaxAge != 0 && s.MaxAge < time.Minute { bad("nats_stream.max_age", "NATS_STREAM_MAX_AGE", "want 0 (unlimited) or at least 1m, got %s", s.MaxAge) }
+  if s.MaxBytes < 0 { bad("nats_stream.max_bytes", "NATS_STREAM_MAX_BYTES", "must not be negative (0 = unlimited), got %d", s.MaxBytes) }
+  if s.MaxMsgsPerSubject < 0 {
+    bad("nats_stream.max_msgs_per_subject", "NATS_STREAM_MAX_MSGS_PER_SUBJECT", "must not be negative (0 = unlimited), got %d", s.MaxMsgsPerSubject)
+  }
+  if s.DuplicateWindow < time.Second || (s.MaxAge != 0 && s.DuplicateWindow > s.MaxAge) {
+    bad("nats_stream.duplicate_window", "NATS_STREAM_DUPLICATE_WINDOW", "want 1s to max_age, got %s", s.DuplicateWindow)
+  }
+  if s.Replicas < 1 || s.Replicas > 5 { bad("nats_stream.replicas", "NATS_STREAM_REPLICAS", "want 1 to 5, got %d", s.Replicas) }
+}
+
+func validPort(s string) bool {
+  n, err := strconv.Atoi(s)
+  return err == nil && n > 0 && n < 65536
+}
+
+// changedKeys lists the YAML keys of the fields that differ between two
+// values of the same struct type, skipping embedded structs and untagged
+// fields. Nested structs are reported by their own key.
+func changedKeys(a, b any) []string {
+  va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+  out := []string{}
+  for i := 0; i < va.NumField(); i++ {
+    f := va.Type().Field(i)
+    key, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+    if f.Anonymous || key == "" || key == "-" { continue }
+    if !reflect.DeepEqual(va.Field(i).Interface(), vb.Field(i).Interface()) { out = append(out, key) }
+  }
+  return out
 }
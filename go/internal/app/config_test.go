@@ -0,0 +1,114 @@
+package app
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func env(vars map[string]string) func(string) string {
+	return func(k string) string { return vars[k] }
+}
+
+func writeFile(t *testing.T, body string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "sim.yaml")
+	if err := os.WriteFile(p, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestLoadConfigFileThenEnv(t *testing.T) {
+	p := writeFile(t, `
+log_level: debug
+port: 8081
+outbox_interval: 100ms
+outbox_batch: 20
+db_slow_query: 1s
+database:
+  url: embedded
+  port: 5499
+nats_url: nats://file:4222
+nats_stream:
+  max_age: 24h
+`)
+	cfg, err := loadConfig(p, env(map[string]string{
+		"NATS_URL": "nats://env:4222", "DB_SLOW_QUERY_MS": "500", "GRPC_PORT": "off", "NATS_STREAM_SUBJECTS": "events.>, audit.>",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.LogLevel != slog.LevelDebug || cfg.Port != "8081" || cfg.OutboxInterval != 100*time.Millisecond || cfg.OutboxBatch != 20 {
+		t.Fatalf("file values not applied: %+v", cfg)
+	}
+	if cfg.NatsURL != "nats://env:4222" || cfg.SlowQuery != 500*time.Millisecond || cfg.GRPCPort != "" {
+		t.Fatalf("env overrides not applied: %+v", cfg)
+	}
+	if cfg.Store.Port != 5499 || cfg.Store.Migrations != "../db/migrations" || cfg.LongTx != 2*time.Second {
+		t.Fatalf("defaults lost: %+v", cfg)
+	}
+	if s := cfg.Stream; s.MaxAge != 24*time.Hour || !reflect.DeepEqual(s.Subjects, []string{"events.>", "audit.>"}) || s.DuplicateWindow != 2*time.Minute {
+		t.Fatalf("stream config = %+v", s)
+	}
+}
+
+func TestLoadConfigRejectsUnknownKeys(t *testing.T) {
+	p := writeFile(t, "outbox_intervall: 1s\n")
+	_, err := loadConfig(p, env(nil))
+	if err == nil || !strings.Contains(err.Error(), "outbox_intervall") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestLoadConfigReportsEveryProblem(t *testing.T) {
+	_, err := loadConfig("", env(map[string]string{"DB_SLOW_QUERY_MS": "fast", "SHUTDOWN_TIMEOUT": "30"}))
+	if err == nil || !strings.Contains(err.Error(), `DB_SLOW_QUERY_MS="fast"`) || !strings.Contains(err.Error(), `SHUTDOWN_TIMEOUT="30"`) {
+		t.Fatalf("env err = %v", err)
+	}
+
+	_, err = loadConfig("", env(map[string]string{
+		"NATS_URL": "embedded", "PORT": "http", "OUTBOX_BATCH_SIZE": "0", "CORS_ALLOW_ORIGINS": "localhost:5173",
+		"TRANSFER_ISOLATION": "snapshot", "OUTBOX_MIN_INTERVAL": "2s", "NATS_STREAM_SUBJECTS": "audit.>", "NATS_STREAM_RETENTION": "workqueue",
+		"EVENT_ENCRYPTION_KEY": "c2hvcnQ=", "REDACT_METADATA_KEYS": "email,[card",
+	}))
+	for _, want := range []string{"database.url (DATABASE_URL): required", "port (PORT)", "outbox_batch (OUTBOX_BATCH_SIZE)", "outbox_min_interval (OUTBOX_MIN_INTERVAL)", `cors_allow_origins (CORS_ALLOW_ORIGINS)`, "transfer_isolation (TRANSFER_ISOLATION)",
+		"nats_stream.subjects (NATS_STREAM_SUBJECTS): must include events.>", "nats_stream.retention (NATS_STREAM_RETENTION)", "event_encryption (EVENT_ENCRYPTION_KEY)",
+		"redact_metadata_keys (REDACT_METADATA_KEYS)"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("missing %q in %v", want, err)
+		}
+	}
+}
+
+func TestChangedKeys(t *testing.T) {
+	a := DefaultConfig()
+	b := a
+	b.OutboxBatch = 10
+	b.LogLevel = slog.LevelWarn
+	b.Port = "9000"
+	b.Store.URL = "embedded"
+	if got := changedKeys(a.Tunables, b.Tunables); !reflect.DeepEqual(got, []string{"log_level", "outbox_batch"}) {
+		t.Fatalf("tunables changed = %v", got)
+	}
+	if got := changedKeys(a, b); !reflect.DeepEqual(got, []string{"port", "database"}) {
+		t.Fatalf("restart-only changed = %v", got)
+	}
+}
+
+func TestReadReplicaConfig(t *testing.T) {
+	cfg, err := loadConfig("", env(map[string]string{
+		"DATABASE_URL": "postgres://primary/db", "DATABASE_READ_URL": "postgres://replica/db", "NATS_URL": "embedded",
+	}))
+	if err != nil || cfg.Store.ReadURL != "postgres://replica/db" {
+		t.Fatalf("cfg = %+v, err = %v", cfg.Store, err)
+	}
+	_, err = loadConfig("", env(map[string]string{"DATABASE_URL": "embedded", "DATABASE_READ_URL": "postgres://replica/db", "NATS_URL": "embedded"}))
+	if err == nil || !strings.Contains(err.Error(), "database.read_url (DATABASE_READ_URL)") {
+		t.Fatalf("err = %v", err)
+	}
+}
@@ -0,0 +1,164 @@
+package app
+
+import (
+  "context"
+  "encoding/json"
+  "errors"
+  "net/http"
+  "sync"
+  "time"
+
+  "github.com/nats-io/nats.go"
+
+  "time-ledger-sim/go/internal/messaging"
+)
+
+// Probes for Kubernetes: /livez only says the process serves HTTP; /readyz checks
+// every dependency a request needs and reports each one, returning 503 when any
+// fails so the pod is taken out of the Service until it recovers.
+
+const readyCheckTimeout = 2 * time.Second
+
+type checkResult struct {
+  Status string `json:"status"` // ok | fail
+  LatencyMs float64 `json:"latency_ms"`
+  Error string `json:"error,omitempty"`
+  Details map[string]any `json:"details,omitempty"`
+}
+
+type readiness struct {
+  Status string `json:"status"` // ok | degraded (reads only, still 200) | fail
+  Checks map[string]checkResult `json:"checks"`
+}
+
+// readyCheck returns optional details; an error marks the component failed.
+type readyCheck func(ctx context.Context) (map[string]any, error)
+
+// runChecks runs the checks concurrently, each under its own timeout.
+func runChecks(ctx context.Context, checks map[string]readyCheck) readiness {
+  out := readiness{Status: "ok", Checks: make(map[string]checkResult, len(checks))}
+  var mu sync.Mutex
+  var wg sync.WaitGroup
+  for name, check := range checks {
+    wg.Add(1)
+    go func() {
+      defer wg.Done()
+      cctx, cancel := context.WithTimeout(ctx, readyCheckTimeout)
+      defer cancel()
+      start := time.Now()
+      details, err := check(cctx)
+      res := checkResult{Status: "ok", LatencyMs: float64(time.Since(start).Microseconds()) / 1000, Details: details}
+      if err != nil { res.Status, res.Error = "fail", err.Error() }
+      mu.Lock()
+      defer mu.Unlock()
+      out.Checks[name] = res
+      if err != nil { out.Status = "fail" }
+    }()
+  }
+  wg.Wait()
+  return out
+}
+
+func (a *App) readyChecks() map[string]readyCheck {
+  return map[string]readyCheck{
+    "drain": func(context.Context) (map[string]any, error) {
+      if a.gate.Closed() { return nil, errDraining }
+      return nil, nil
+    },
+    "db": func(ctx context.Context) (map[string]any, error) {
+      if err := a.db.Ping(ctx); err != nil { return nil, err }
+      st := a.db.Stat()
+      return map[string]any{"total_conns": st.TotalConns(), "idle_conns": st.IdleConns(), "max_conns": st.MaxConns()}, nil
+    },
+    "db_replica": func(ctx context.Context) (map[string]any, error) {
+      ro := a.store.ReadPool()
+      if ro == a.db { return map[string]any{"configured": false}, nil }
+      var lag *float64 // null when nothing has been replayed yet
+      err := ro.QueryRow(ctx, `SELECT EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp())::float8`).Scan(&lag)
+      if err != nil { return nil, err }
+      return map[string]any{"configured": true, "lag_seconds": lag}, nil
+    },
+    "messaging": func(context.Context) (map[string]any, error) {
+      if !a.msgReady.Load() { return map[string]any{"state": "starting", "writes": "held"}, errMessagingStarting }
+      return map[string]any{"state": "running"}, nil
+    },
+    "nats": func(context.Context) (map[string]any, error) {
+      details := map[string]any{"state": a.nc.Status().String()}
+      if !a.nc.IsConnected() { return details, nats.ErrConnectionClosed }
+      return details, nil
+    },
+    "jetstream": func(ctx context.Context) (map[string]any, error) {
+      info, err := a.js.StreamInfo(messaging.StreamName, nats.Context(ctx))
+      if err != nil { return map[string]any{"stream": messaging.StreamName}, err }
+      return map[string]any{"stream": messaging.StreamName, "messages": info.State.Msgs}, nil
+    },
+    // informational: a follower is as ready as the leader
+    "leader": func(context.Context) (map[string]any, error) {
+      return map[string]any{"outbox_publisher": a.pubLeader.IsLeader(), "control_scheduler": a.schedLeader.IsLeader(), "balance_monitor": a.balLeader.IsLeader(),
+        "settlement_runner": a.settleLeader.IsLeader(), "spool_sampler": a.sampleLeader.IsLeader(), "stats_rollup": a.rollupLeader.IsLeader(),
+        "day_closer": a.dayLeader.IsLeader()}, nil
+    },
+    "outbox": func(ctx context.Context) (map[string]any, error) {
+      n, err := messaging.OutboxBacklog(ctx, a.db)
+      if err != nil { return nil, err }
+      max := a.tunables().OutboxReadyMax
+      details := map[string]any{"backlog": n, "threshold": max}
+      if max > 0 && n > max { return details, errOutboxBacklog }
+      return details, nil
+    },
+  }
+}
+
+var (
+  errOutboxBacklog = errors.New("outbox backlog above threshold")
+  errDraining = errors.New("draining")
+  errMessagingStarting = errors.New("messaging not started; writes held")
+)
+
+// messagingChecks may fail while the process runs degraded: reads are served
+// and writes held until messaging comes up.
+var messagingChecks = map[string]bool{"messaging": true, "nats": true, "jetstream": true}
+
+// degrade turns a failed result into "degraded" when only messaging checks
+// failed and messaging never started, so load balancers keep routing reads.
+func degrade(res readiness, msgReady bool) readiness {
+  if res.Status != "fail" || msgReady { return res }
+  for name, c := range res.Checks {
+    if c.Status != "ok" && !messagingChecks[name] { return res }
+  }
+  res.Status = "degraded"
+  return res
+}
+
+func (a *App) handleLivez(w http.ResponseWriter, _ *http.Request) {
+  writeHealth(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (a *App) handleReadyz(w http.ResponseWriter, r *http.Request) {
+  res := degrade(runChecks(r.Context(), a.readyChecks()), a.msgReady.Load())
+  status := http.StatusOK
+  if res.Status == "fail" { status = http.StatusServiceUnavailable }
+  if was := a.notReady.Swap(status != http.StatusOK); was != (status != http.StatusOK) {
+    if was {
+      a.log.InfoContext(r.Context(), "ready")
+    } else {
+      a.log.WarnContext(r.Context(), "not ready", "checks", failedChecks(res))
+    }
+  }
+  writeHealth(w, status, res)
+}
+
+func failedChecks(res readiness) map[string]string {
+  out := map[string]string{}
+  for name, c := range res.Checks {
+    if c.Status != "ok" { out[name] = c.Error }
+  }
+  return out
+}
+
+func writeHealth(w http.ResponseWriter, status int, v any) {
+  w.Header().Set("content-type", "application/json")
+  w.Header().Set("Cache-Control", "no-store")
+  w.WriteHeader(status)
+  _ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,63 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRunChecks(t *testing.T) {
+	res := runChecks(context.Background(), map[string]readyCheck{
+		"db": func(context.Context) (map[string]any, error) { return map[string]any{"idle_conns": 2}, nil },
+		"outbox": func(context.Context) (map[string]any, error) {
+			return map[string]any{"backlog": 20000}, errOutboxBacklog
+		},
+	})
+	if res.Status != "fail" {
+		t.Fatalf("status = %q", res.Status)
+	}
+	if c := res.Checks["db"]; c.Status != "ok" || c.Details["idle_conns"] != 2 {
+		t.Fatalf("db = %+v", c)
+	}
+	if c := res.Checks["outbox"]; c.Status != "fail" || c.Error != errOutboxBacklog.Error() {
+		t.Fatalf("outbox = %+v", c)
+	}
+	if got := failedChecks(res); len(got) != 1 || got["outbox"] == "" {
+		t.Fatalf("failed = %v", got)
+	}
+}
+
+func TestRunChecksTimeout(t *testing.T) {
+	start := time.Now()
+	res := runChecks(context.Background(), map[string]readyCheck{
+		"nats": func(ctx context.Context) (map[string]any, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	})
+	if res.Status != "fail" || res.Checks["nats"].Error != context.DeadlineExceeded.Error() {
+		t.Fatalf("status = %q", res.Status)
+	}
+	if d := time.Since(start); d > readyCheckTimeout+time.Second {
+		t.Fatalf("took %s", d)
+	}
+}
+
+func TestDegradedWhileMessagingStarts(t *testing.T) {
+	res := runChecks(context.Background(), map[string]readyCheck{
+		"db":        func(context.Context) (map[string]any, error) { return nil, nil },
+		"messaging": func(context.Context) (map[string]any, error) { return nil, errMessagingStarting },
+		"nats":      func(context.Context) (map[string]any, error) { return nil, errors.New("not connected") },
+	})
+	if got := degrade(res, false).Status; got != "degraded" {
+		t.Fatalf("messaging down at start = %q", got)
+	}
+	if got := degrade(res, true).Status; got != "fail" {
+		t.Fatalf("messaging lost after start = %q", got)
+	}
+	res.Checks["db"] = checkResult{Status: "fail"}
+	if got := degrade(res, false).Status; got != "fail" {
+		t.Fatalf("db down = %q", got)
+	}
+}
@@ -9,9 +9,12 @@ import (
   "go.opentelemetry.io/otel/sdk/resource"
   sdktrace "go.opentelemetry.io/otel/sdk/trace"
   semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+
+  "time-ledger-sim/go/internal/tracing"
 )
 
 func initTracer(ctx context.Context, endpoint string) (func(context.Context) error, error) {
+  otel.SetTextMapPropagator(tracing.Propagator)
   if endpoint == "" {
     tp := sdktrace.NewTracerProvider()
     otel.SetTracerProvider(tp)
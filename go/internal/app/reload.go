@@ -0,0 +1,56 @@
+package app
+
+import (
+  "context"
+
+  "time-ledger-sim/go/internal/web"
+)
+
+func (a *App) tunables() Tunables { return *a.tun.Load() }
+
+// Tunables returns the settings currently in effect (for GET /v1/sim/config).
+func (a *App) Tunables() any { return a.tunables() }
+
+// Reload re-reads the config file and environment. If the result is valid its
+// tunables take effect at once; other changed settings are reported and only
+// apply after a restart. An invalid config changes nothing.
+func (a *App) Reload(ctx context.Context) (*web.ReloadReport, error) {
+  a.reloadMu.Lock()
+  defer a.reloadMu.Unlock()
+
+  next, err := LoadConfig(a.cfg.File)
+  if err != nil {
+    a.log.WarnContext(ctx, "config reload rejected", "file", a.cfg.File, "err", err.Error())
+    return nil, err
+  }
+  cur := a.tunables()
+  rep := &web.ReloadReport{
+    Changed: changedKeys(cur, next.Tunables),
+    RestartRequired: changedKeys(a.cfg, next),
+    Tunables: next.Tunables,
+  }
+
+  t := next.Tunables
+  a.level.Set(t.LogLevel)
+  a.cors.Set(t.CorsAllowOrigins)
+  a.pub.SetTuning(t.outboxTuning())
+  a.watch.SetThresholds(t.SlowQuery, t.LongTx)
+  a.led.SetZoneCacheTTL(t.zoneCacheTTL())
+  a.led.SetTransferIsolation(t.transferIsolation())
+  a.led.SetRequireKnownActors(t.RequireKnownActors)
+  a.led.SetRequireReasonCode(t.RequireReasonCode)
+  a.led.SetStrictAccounts(t.StrictAccounts)
+  a.led.SetMetadataRedaction(t.metadataRedaction())
+  a.led.SetTwoPersonRule(t.TwoPersonRule, t.ApprovalTTL)
+  a.balMon.SetTuning(t.BalanceMonitorInterval, t.balanceThresholds())
+  a.settler.SetInterval(t.SettlementInterval)
+  a.spoolSampler.SetInterval(t.SpoolSampleInterval)
+  a.jobs.SetRetention(t.JobRetention)
+  a.statsRollup.SetInterval(t.StatsRollupInterval)
+  a.dayCloser.SetInterval(t.DayCloseInterval)
+  a.alerts.SetInterval(t.AlertMetricsInterval)
+  a.tun.Store(&t)
+
+  a.log.InfoContext(ctx, "config reloaded", "file", a.cfg.File, "changed", rep.Changed, "restart_required", rep.RestartRequired)
+  return rep, nil
+}
@@ -0,0 +1,38 @@
+package app
+
+import (
+  "context"
+  "log/slog"
+  "time"
+)
+
+// retry calls fn until it succeeds, waiting r.Backoff after the first failure
+// and doubling up to r.BackoffMax. It gives up with the last error once
+// r.Timeout has passed (a zero Timeout allows one attempt, a negative one
+// retries until ctx is done).
+func retry(ctx context.Context, r StartupRetry, log *slog.Logger, what string, fn func(context.Context) error) error {
+  start := time.Now()
+  wait := r.Backoff
+  for attempt := 1; ; attempt++ {
+    err := fn(ctx)
+    if err == nil {
+      if attempt > 1 { log.InfoContext(ctx, what+" connected", "attempts", attempt, "waited_ms", time.Since(start).Milliseconds()) }
+      return nil
+    }
+    if r.Timeout >= 0 && time.Since(start)+wait > r.Timeout { return err }
+    log.WarnContext(ctx, what+" unavailable, retrying", "attempt", attempt, "retry_in", wait.String(), "err", err.Error())
+    select {
+    case <-ctx.Done():
+      return err
+    case <-time.After(wait):
+    }
+    wait = min(wait*2, r.BackoffMax)
+  }
+}
+
+// delay is the wait before reconnect attempt n (1-based) under r's backoff.
+func (r StartupRetry) delay(n int) time.Duration {
+  d := r.Backoff
+  for i := 1; i < n && d < r.BackoffMax; i++ { d *= 2 }
+  return min(d, r.BackoffMax)
+}
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	r := StartupRetry{Timeout: time.Second, Backoff: time.Millisecond, BackoffMax: 4 * time.Millisecond}
+	down := errors.New("connection refused")
+
+	calls := 0
+	err := retry(context.Background(), r, log, "postgres", func(context.Context) error {
+		if calls++; calls < 4 {
+			return down
+		}
+		return nil
+	})
+	if err != nil || calls != 4 {
+		t.Fatalf("err = %v after %d calls", err, calls)
+	}
+
+	calls = 0
+	r.Timeout = 0
+	if err := retry(context.Background(), r, log, "postgres", func(context.Context) error { calls++; return down }); err != down || calls != 1 {
+		t.Fatalf("no-retry: err = %v after %d calls", err, calls)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	r.Timeout = -1
+	if err := retry(ctx, r, log, "jetstream", func(context.Context) error { return down }); err != down {
+		t.Fatalf("until ctx done: err = %v", err)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	r := StartupRetry{Backoff: 250 * time.Millisecond, BackoffMax: time.Second}
+	for n, want := range map[int]time.Duration{1: 250 * time.Millisecond, 2: 500 * time.Millisecond, 3: time.Second, 10: time.Second} {
+		if got := r.delay(n); got != want {
+			t.Errorf("delay(%d) = %s, want %s", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// Package auditsig signs audit log entries so they can be trusted even where
+// operators can write to the database: an entry changed or inserted without
+// the key no longer verifies. Keys are HMAC-SHA256 secrets or Ed25519 private
+// keys, given inline or read from a file (a secret mounted by a KMS or secret
+// manager).
+package auditsig
+
+import (
+  "crypto/ed25519"
+  "crypto/hmac"
+  "crypto/sha256"
+  "encoding/base64"
+  "encoding/hex"
+  "errors"
+  "fmt"
+  "os"
+  "strings"
+)
+
+const (
+  AlgHMAC = "hmac-sha256"
+  AlgEd25519 = "ed25519"
+)
+
+type Config struct {
+  Key string `yaml:"key"` // AUDIT_SIGNING_KEY: "hmac-sha256:<base64 secret>" or "ed25519:<base64 seed or private key>"; signing is off when empty
+  KeyFile string `yaml:"key_file"` // AUDIT_SIGNING_KEY_FILE; holds the key in the same form, instead of Key
+  KeyID string `yaml:"key_id"` // AUDIT_SIGNING_KEY_ID stored with each signature; derived from the key when empty
+}
+
+// Enabled reports whether a key is configured.
+func (c Config) Enabled() bool { return c.Key != "" || c.KeyFile != "" }
+
+// Signer signs and verifies entries with one key.
+type Signer interface {
+  Alg() string
+  KeyID() string
+  Sign(payload []byte) []byte
+  Verify(payload, sig []byte) bool
+  // PublicKey is the base64 verification key for Ed25519, "" for HMAC.
+  PublicKey() string
+}
+
+// New parses the configured key. It returns nil (no error) when signing is off.
+func New(cfg Config) (Signer, error) {
+  if !cfg.Enabled() { return nil, nil }
+  if cfg.Key != "" && cfg.KeyFile != "" { return nil, errors.New("set either key or key_file, not both") }
+  spec := cfg.Key
+  if cfg.KeyFile != "" {
+    b, err := os.ReadFile(cfg.KeyFile)
+    if err != nil { return nil, err }
+    spec = strings.TrimSpace(string(b))
+  }
+  alg, enc, ok := strings.Cut(spec, ":")
+  if !ok { return nil, fmt.Errorf("want %s:<base64> or %s:<base64>", AlgHMAC, AlgEd25519) }
+  raw, err := base64.StdEncoding.DecodeString(enc)
+  if err != nil { return nil, fmt.Errorf("%s key: %v", alg, err) }
+
+  switch alg {
+  case AlgHMAC:
+    if len(raw) < 32 { return nil, fmt.Errorf("%s key: want at least 32 bytes, got %d", alg, len(raw)) }
+    return &hmacSigner{key: raw, id: keyID(cfg.KeyID, "hmac", raw)}, nil
+  case AlgEd25519:
+    var priv ed25519.PrivateKey
+    switch len(raw) {
+    case ed25519.SeedSize:
+      priv = ed25519.NewKeyFromSeed(raw)
+    case ed25519.PrivateKeySize:
+      priv = ed25519.PrivateKey(raw)
+      if !priv.Equal(ed25519.NewKeyFromSeed(priv.Seed())) { return nil, fmt.Errorf("%s key: public half does not match the seed", alg) }
+    default:
+      return nil, fmt.Errorf("%s key: want a %d-byte seed or %d-byte private key, got %d bytes", alg, ed25519.SeedSize, ed25519.PrivateKeySize, len(raw))
+    }
+    pub := priv.Public().(ed25519.PublicKey)
+    return &ed25519Signer{priv: priv, pub: pub, id: keyID(cfg.KeyID, "ed25519", pub)}, nil
+  }
+  return nil, fmt.Errorf("unknown algorithm %q: want %s or %s", alg, AlgHMAC, AlgEd25519)
+}
+
+// keyID is the configured id, or a fingerprint of the HMAC key or public key.
+func keyID(configured, prefix string, key []byte) string {
+  if configured != "" { return configured }
+  sum := sha256.Sum256(key)
+  return prefix + "-" + hex.EncodeToString(sum[:6])
+}
+
+type hmacSigner struct {
+  key []byte
+  id string
+}
+
+func (s *hmacSigner) Alg() string { return AlgHMAC }
+func (s *hmacSigner) KeyID() string { return s.id }
+func (s *hmacSigner) PublicKey() string { return "" }
+
+func (s *hmacSigner) Sign(payload []byte) []byte {
+  m := hmac.New(sha256.New, s.key)
+  m.Write(payload)
+  return m.Sum(nil)
+}
+
+func (s *hmacSigner) Verify(payload, sig []byte) bool { return hmac.Equal(s.Sign(payload), sig) }
+
+type ed25519Signer struct {
+  priv ed25519.PrivateKey
+  pub ed25519.PublicKey
+  id string
+}
+
+func (s *ed25519Signer) Alg() string { return AlgEd25519 }
+func (s *ed25519Signer) KeyID() string { return s.id }
+func (s *ed25519Signer) PublicKey() string { return base64.StdEncoding.EncodeToString(s.pub) }
+func (s *ed25519Signer) Sign(payload []byte) []byte { return ed25519.Sign(s.priv, payload) }
+func (s *ed25519Signer) Verify(payload, sig []byte) bool { return ed25519.Verify(s.pub, payload, sig) }
@@ -0,0 +1,64 @@
+package auditsig
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func key(alg string, n int) string {
+	return alg + ":" + base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", n)))
+}
+
+func TestSigners(t *testing.T) {
+	priv := ed25519.NewKeyFromSeed([]byte(strings.Repeat("s", ed25519.SeedSize)))
+	for _, spec := range []string{key(AlgHMAC, 32), key(AlgEd25519, 32), AlgEd25519 + ":" + base64.StdEncoding.EncodeToString(priv)} {
+		s, err := New(Config{Key: spec})
+		if err != nil {
+			t.Fatalf("%s: %v", spec, err)
+		}
+		sig := s.Sign([]byte("entry"))
+		if !s.Verify([]byte("entry"), sig) {
+			t.Errorf("%s: own signature does not verify", s.Alg())
+		}
+		if s.Verify([]byte("entrY"), sig) {
+			t.Errorf("%s: changed entry verifies", s.Alg())
+		}
+		if (s.PublicKey() == "") != (s.Alg() == AlgHMAC) {
+			t.Errorf("%s: public key %q", s.Alg(), s.PublicKey())
+		}
+		if !strings.HasPrefix(s.KeyID(), strings.Split(s.Alg(), "-")[0]+"-") {
+			t.Errorf("%s: derived key id %q", s.Alg(), s.KeyID())
+		}
+	}
+}
+
+func TestNewFromFileAndErrors(t *testing.T) {
+	if s, err := New(Config{}); s != nil || err != nil {
+		t.Fatalf("no key: %v, %v", s, err)
+	}
+	p := filepath.Join(t.TempDir(), "audit.key")
+	if err := os.WriteFile(p, []byte(key(AlgHMAC, 48)+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s, err := New(Config{KeyFile: p, KeyID: "2026-10"})
+	if err != nil || s.KeyID() != "2026-10" {
+		t.Fatalf("key file: %v, %v", s, err)
+	}
+
+	for _, cfg := range []Config{
+		{Key: key(AlgHMAC, 16)},
+		{Key: key(AlgEd25519, 10)},
+		{Key: key(AlgEd25519, 64)},
+		{Key: "rsa:AAAA"},
+		{Key: "hmac-sha256"},
+		{Key: key(AlgHMAC, 32), KeyFile: p},
+	} {
+		if _, err := New(cfg); err == nil {
+			t.Errorf("%+v: no error", cfg)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// Package auth validates OIDC bearer tokens and carries the caller's identity
+// in the request context.
+package auth
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "strings"
+
+  "github.com/coreos/go-oidc/v3/oidc"
+)
+
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+type Config struct {
+  Issuer string `yaml:"issuer"` // OIDC issuer URL; auth is disabled when empty
+  Audience string `yaml:"audience"` // expected aud (client id); not checked when empty
+  ActorClaim string `yaml:"actor_claim"` // claim used as the audit actor, default "email"
+}
+
+// Identity is the authenticated caller.
+type Identity struct {
+  Subject string `json:"sub"`
+  Actor string `json:"actor"`
+  Claims map[string]any `json:"claims"`
+}
+
+type Verifier struct {
+  v *oidc.IDTokenVerifier
+  actorClaim string
+}
+
+// New discovers the issuer's keys. It returns nil (no error) when no issuer is configured.
+func New(ctx context.Context, cfg Config) (*Verifier, error) {
+  if cfg.Issuer == "" { return nil, nil }
+  p, err := oidc.NewProvider(ctx, cfg.Issuer)
+  if err != nil { return nil, fmt.Errorf("oidc discovery %s: %w", cfg.Issuer, err) }
+  claim := cfg.ActorClaim
+  if claim == "" { claim = "email" }
+  return &Verifier{
+    v: p.Verifier(&oidc.Config{ClientID: cfg.Audience, SkipClientIDCheck: cfg.Audience == ""}),
+    actorClaim: claim,
+  }, nil
+}
+
+// Verify checks signature, issuer, audience and expiry of a raw bearer token.
+func (v *Verifier) Verify(ctx context.Context, raw string) (*Identity, error) {
+  tok, err := v.v.Verify(ctx, raw)
+  if err != nil { return nil, fmt.Errorf("%w: %v", ErrUnauthenticated, err) }
+  claims := map[string]any{}
+  if err := tok.Claims(&claims); err != nil { return nil, fmt.Errorf("%w: claims: %v", ErrUnauthenticated, err) }
+  return &Identity{Subject: tok.Subject, Actor: actorFromClaims(claims, v.actorClaim, tok.Subject), Claims: claims}, nil
+}
+
+// actorFromClaims picks the audit actor: the configured claim, then
+// preferred_username, then the subject.
+func actorFromClaims(claims map[string]any, claim, subject string) string {
+  for _, k := range []string{claim, "preferred_username"} {
+    if s, ok := claims[k].(string); ok && s != "" { return s }
+  }
+  return subject
+}
+
+// BearerToken extracts the token from an Authorization header value.
+func BearerToken(header string) (string, bool) {
+  scheme, tok, ok := strings.Cut(strings.TrimSpace(header), " ")
+  if !ok || !strings.EqualFold(scheme, "Bearer") { return "", false }
+  tok = strings.TrimSpace(tok)
+  return tok, tok != ""
+}
+
+type ctxKey struct{}
+
+func WithIdentity(ctx context.Context, id *Identity) context.Context {
+  return context.WithValue(ctx, ctxKey{}, id)
+}
+
+func FromContext(ctx context.Context) (*Identity, bool) {
+  id, ok := ctx.Value(ctxKey{}).(*Identity)
+  return id, ok && id != nil
+}
@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"context"
+	"testing"
+)
+
+func TestBearerToken(t *testing.T) {
+	cases := map[string]string{
+		"Bearer abc":   "abc",
+		"bearer  abc ": "abc",
+		"Basic abc":    "",
+		"Bearer":       "",
+		"":             "",
+	}
+	for in, want := range cases {
+		got, ok := BearerToken(in)
+		if got != want || ok != (want != "") {
+			t.Fatalf("%q: got %q %v", in, got, ok)
+		}
+	}
+}
+
+func TestActorFromClaims(t *testing.T) {
+	claims := map[string]any{"email": "ops@example.com", "preferred_username": "ops"}
+	if got := actorFromClaims(claims, "email", "sub-1"); got != "ops@example.com" {
+		t.Fatalf("got %q", got)
+	}
+	if got := actorFromClaims(claims, "upn", "sub-1"); got != "ops" {
+		t.Fatalf("expected preferred_username fallback, got %q", got)
+	}
+	if got := actorFromClaims(map[string]any{}, "email", "sub-1"); got != "sub-1" {
+		t.Fatalf("expected subject fallback, got %q", got)
+	}
+}
+
+func TestIdentityContext(t *testing.T) {
+	if _, ok := FromContext(context.Background()); ok {
+		t.Fatal("expected no identity")
+	}
+	ctx := WithIdentity(context.Background(), &Identity{Subject: "s", Actor: "a"})
+	id, ok := FromContext(ctx)
+	if !ok || id.Actor != "a" {
+		t.Fatalf("got %+v %v", id, ok)
+	}
+}
+
+func TestNewDisabled(t *testing.T) {
+	v, err := New(context.Background(), Config{})
+	if v != nil || err != nil {
+		t.Fatalf("expected nil verifier, got %v %v", v, err)
+	}
+}
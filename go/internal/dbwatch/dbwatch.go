@@ -0,0 +1,180 @@
+// Package dbwatch finds slow statements and long transactions from pgx's
+// tracing hooks. Each one is logged, counted in metrics and kept in a small
+// ring for the admin endpoint, so heavy multi-statement handlers show up
+// without turning on Postgres statement logging.
+package dbwatch
+
+import (
+  "context"
+  "log/slog"
+  "strings"
+  "sync"
+  "sync/atomic"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+
+  "time-ledger-sim/go/internal/logging"
+  "time-ledger-sim/go/internal/metrics"
+)
+
+const (
+  recentSize = 50
+  maxSQL = 300
+)
+
+// Event is one slow statement or long transaction.
+type Event struct {
+  At time.Time `json:"at"`
+  DurationMs float64 `json:"duration_ms"`
+  Operation string `json:"operation"` // first SQL keyword; TX for transactions
+  SQL string `json:"sql,omitempty"` // truncated; for transactions the first statement after BEGIN
+  Error string `json:"error,omitempty"`
+  RequestID string `json:"request_id,omitempty"`
+}
+
+// Watcher is a pgx.QueryTracer. A zero threshold disables that check.
+type Watcher struct {
+  slowQuery atomic.Int64 // time.Duration; changed by config reload
+  longTx atomic.Int64
+  log *slog.Logger
+
+  open sync.Map // *pgx.Conn -> *openTx
+
+  mu sync.Mutex
+  slow []Event
+  long []Event
+}
+
+type openTx struct {
+  start time.Time
+  first string
+}
+
+func New(slowQuery, longTx time.Duration, log *slog.Logger) *Watcher {
+  w := &Watcher{log: log}
+  w.SetThresholds(slowQuery, longTx)
+  return w
+}
+
+// SetThresholds changes the slow query and long transaction thresholds.
+func (w *Watcher) SetThresholds(slowQuery, longTx time.Duration) {
+  w.slowQuery.Store(int64(slowQuery))
+  w.longTx.Store(int64(longTx))
+}
+
+func (w *Watcher) Thresholds() (slowQuery, longTx time.Duration) {
+  return time.Duration(w.slowQuery.Load()), time.Duration(w.longTx.Load())
+}
+
+type startKey struct{}
+
+type queryStart struct {
+  at time.Time
+  sql string
+}
+
+func (w *Watcher) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+  sql := strings.TrimSpace(data.SQL)
+  switch op := operation(sql); {
+  case op == "BEGIN":
+    w.open.Store(conn, &openTx{start: time.Now()})
+  case op != "COMMIT" && op != "ROLLBACK":
+    if v, ok := w.open.Load(conn); ok && v.(*openTx).first == "" { v.(*openTx).first = truncate(sql) }
+  }
+  return context.WithValue(ctx, startKey{}, queryStart{at: time.Now(), sql: sql})
+}
+
+func (w *Watcher) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+  qs, ok := ctx.Value(startKey{}).(queryStart)
+  if !ok { return }
+  d := time.Since(qs.at)
+  op := operation(qs.sql)
+  metrics.DBQueryDuration.WithLabelValues(op).Observe(d.Seconds())
+
+  slowQuery, longTx := w.Thresholds()
+  if op == "COMMIT" || op == "ROLLBACK" {
+    if v, ok := w.open.LoadAndDelete(conn); ok {
+      tx := v.(*openTx)
+      if held := time.Since(tx.start); longTx > 0 && held >= longTx {
+        metrics.DBLongTransactions.Inc()
+        ev := w.event(ctx, held, "TX", tx.first, data.Err)
+        w.log.WarnContext(ctx, "long transaction", "duration_ms", ev.DurationMs, "ended_by", op, "first_sql", ev.SQL)
+        w.record(&w.long, ev)
+      }
+    }
+  }
+
+  if slowQuery > 0 && d >= slowQuery {
+    metrics.DBSlowQueries.WithLabelValues(op).Inc()
+    ev := w.event(ctx, d, op, truncate(qs.sql), data.Err)
+    w.log.WarnContext(ctx, "slow query", "duration_ms", ev.DurationMs, "operation", op, "sql", ev.SQL)
+    w.record(&w.slow, ev)
+  }
+}
+
+func (w *Watcher) event(ctx context.Context, d time.Duration, op, sql string, err error) Event {
+  ev := Event{
+    At: time.Now().UTC(),
+    DurationMs: float64(d.Microseconds()) / 1000,
+    Operation: op,
+    SQL: sql,
+    RequestID: logging.RequestID(ctx),
+  }
+  if err != nil { ev.Error = err.Error() }
+  return ev
+}
+
+func (w *Watcher) record(ring *[]Event, ev Event) {
+  w.mu.Lock()
+  defer w.mu.Unlock()
+  *ring = append(*ring, ev)
+  if len(*ring) > recentSize { *ring = (*ring)[len(*ring)-recentSize:] }
+}
+
+// Recent returns the latest slow statements and long transactions, newest first.
+func (w *Watcher) Recent() (slow, longTx []Event) {
+  w.mu.Lock()
+  defer w.mu.Unlock()
+  return newestFirst(w.slow), newestFirst(w.long)
+}
+
+func newestFirst(ring []Event) []Event {
+  out := make([]Event, len(ring))
+  for i, ev := range ring { out[len(ring)-1-i] = ev }
+  return out
+}
+
+// operation is the statement's leading keyword, from a fixed set so it is safe
+// as a metric label.
+func operation(sql string) string {
+  f := strings.Fields(strings.TrimLeft(sql, "( \t\n"))
+  if len(f) == 0 { return "OTHER" }
+  switch op := strings.ToUpper(f[0]); op {
+  case "SELECT", "INSERT", "UPDATE", "DELETE", "WITH", "BEGIN", "COMMIT", "ROLLBACK", "LOCK", "COPY", "NOTIFY", "LISTEN":
+    return op
+  case "END":
+    return "COMMIT"
+  }
+  return "OTHER"
+}
+
+func truncate(sql string) string {
+  sql = strings.Join(strings.Fields(sql), " ")
+  if len(sql) > maxSQL { sql = sql[:maxSQL] + "..." }
+  return sql
+}
+
+// Chain runs several pgx query tracers in order (ends in reverse order).
+func Chain(tracers ...pgx.QueryTracer) pgx.QueryTracer { return chain(tracers) }
+
+type chain []pgx.QueryTracer
+
+func (c chain) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+  for _, t := range c { ctx = t.TraceQueryStart(ctx, conn, data) }
+  return ctx
+}
+
+func (c chain) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+  for i := len(c) - 1; i >= 0; i-- { c[i].TraceQueryEnd(ctx, conn, data) }
+}
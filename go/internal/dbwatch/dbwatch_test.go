@@ -0,0 +1,57 @@
+package dbwatch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func run(w *Watcher, conn *pgx.Conn, sql string, d time.Duration, err error) {
+	ctx := w.TraceQueryStart(context.Background(), conn, pgx.TraceQueryStartData{SQL: sql})
+	time.Sleep(d)
+	w.TraceQueryEnd(ctx, conn, pgx.TraceQueryEndData{Err: err})
+}
+
+func TestSlowQueryAndLongTransaction(t *testing.T) {
+	var buf bytes.Buffer
+	w := New(5*time.Millisecond, 15*time.Millisecond, slog.New(slog.NewJSONHandler(&buf, nil)))
+	conn := new(pgx.Conn)
+
+	run(w, conn, "SELECT 1", 0, nil)
+	run(w, conn, "begin", 0, nil)
+	run(w, conn, "\n  UPDATE balances SET balance_units = balance_units + $1\n  WHERE account_id=$2", 10*time.Millisecond, errors.New("deadlock detected"))
+	run(w, conn, "SELECT 2", 10*time.Millisecond, nil)
+	run(w, conn, "rollback", 0, nil)
+
+	slow, longTx := w.Recent()
+	if len(slow) != 2 || slow[0].Operation != "SELECT" || slow[1].Operation != "UPDATE" || slow[1].Error != "deadlock detected" {
+		t.Fatalf("slow = %+v", slow)
+	}
+	if len(longTx) != 1 || longTx[0].Operation != "TX" || !strings.HasPrefix(longTx[0].SQL, "UPDATE balances SET") {
+		t.Fatalf("long tx = %+v", longTx)
+	}
+	if n := strings.Count(buf.String(), `"msg":"slow query"`); n != 2 {
+		t.Fatalf("logged %d slow queries: %s", n, buf.String())
+	}
+}
+
+func TestOperation(t *testing.T) {
+	for sql, want := range map[string]string{
+		"select 1":                           "SELECT",
+		"(SELECT a FROM t) UNION ALL":        "SELECT",
+		"begin isolation level serializable": "BEGIN",
+		"end":                                "COMMIT",
+		"VACUUM":                             "OTHER",
+		"":                                   "OTHER",
+	} {
+		if got := operation(sql); got != want {
+			t.Errorf("%q: got %q", sql, got)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package grpcapi
+
+import (
+  "context"
+  "time"
+
+  "google.golang.org/grpc/codes"
+  "google.golang.org/protobuf/types/known/timestamppb"
+
+  "time-ledger-sim/go/internal/grpcapi/simv1"
+  "time-ledger-sim/go/internal/ledger"
+)
+
+// --- zone controls ---
+
+func (s *Server) GetZoneControls(ctx context.Context, req *simv1.GetZoneControlsRequest) (*simv1.ZoneControls, error) {
+  if req.GetZoneId() == "" { return nil, invalid("missing zone_id") }
+  c, err := s.led.GetZoneControls(ctx, req.GetZoneId())
+  if err != nil { return nil, toStatus(err, codes.Internal) }
+  return zoneControlsPB(c), nil
+}
+
+func (s *Server) SetZoneControls(ctx context.Context, req *simv1.SetZoneControlsRequest) (*simv1.ZoneControls, error) {
+  actor := actorFor(ctx, req.GetActor())
+  if req.GetZoneId() == "" || actor == "" { return nil, invalid("missing fields") }
+  in := ledger.SetZoneControlsInput{
+    WritesBlocked: req.GetWritesBlocked(),
+    CrossZoneThrottle: int(req.GetCrossZoneThrottle()),
+    SpoolEnabled: req.GetSpoolEnabled(),
+    InjectLatencyMs: int(req.GetInjectLatencyMs()),
+    InjectJitterMs: int(req.GetInjectJitterMs()),
+    ErrorRatePercent: int(req.GetErrorRatePercent()),
+    ThrottleMode: req.GetThrottleMode(),
+    RateLimitPerSec: int(req.GetRateLimitPerSec()),
+    RateLimitBurst: int(req.GetRateLimitBurst()),
+    ClockSkewMs: req.GetClockSkewMs(),
+    Actor: actor,
+    ReasonCode: reasonCodeFor(ctx),
+    Reason: req.GetReason(),
+  }
+  // the proto has no blocked actions, amount limits or day cutover yet; keep
+  // the zone's rather than clear them
+  cur, err := s.led.GetZoneControls(ctx, req.GetZoneId())
+  if err != nil { return nil, toStatus(err, codes.Internal) }
+  in.BlockedActions, in.BlockedDelayMs = cur.BlockedActions, cur.BlockedDelayMs
+  in.MinAmountUnits, in.MaxAmountUnits, in.DailyAccountLimitUnits = cur.MinAmountUnits, cur.MaxAmountUnits, cur.DailyAccountLimitUnits
+  in.DayCutoverMinute = cur.DayCutoverMinute
+  if s.led.ZoneControlsNeedApproval(in) {
+    ap, err := s.led.RequestZoneControlsApproval(ctx, req.GetZoneId(), time.Time{}, in)
+    if err != nil { return nil, toStatus(err, codes.InvalidArgument) }
+    return nil, pendingApproval(ap)
+  }
+  c, err := s.led.SetZoneControls(ctx, req.GetZoneId(), in)
+  if err != nil { return nil, toStatus(err, codes.Internal) }
+  return zoneControlsPB(c), nil
+}
+
+func zoneControlsPB(c *ledger.ZoneControls) *simv1.ZoneControls {
+  return &simv1.ZoneControls{
+    ZoneId: c.ZoneID,
+    WritesBlocked: c.WritesBlocked,
+    CrossZoneThrottle: int32(c.CrossZoneThrottle),
+    SpoolEnabled: c.SpoolEnabled,
+    InjectLatencyMs: int32(c.InjectLatencyMs),
+    InjectJitterMs: int32(c.InjectJitterMs),
+    ErrorRatePercent: int32(c.ErrorRatePercent),
+    ThrottleMode: c.ThrottleMode,
+    RateLimitPerSec: int32(c.RateLimitPerSec),
+    RateLimitBurst: int32(c.RateLimitBurst),
+    ClockSkewMs: c.ClockSkewMs,
+    UpdatedAt: timestamppb.New(c.UpdatedAt),
+  }
+}
+
+// --- account controls ---
+
+func (s *Server) GetAccountControls(ctx context.Context, req *simv1.GetAccountControlsRequest) (*simv1.AccountControls, error) {
+  if req.GetAccountId() == "" { return nil, invalid("missing account_id") }
+  c, err := s.led.GetAccountControls(ctx, req.GetAccountId())
+  if err != nil { return nil, toStatus(err, codes.Internal) }
+  return accountControlsPB(c), nil
+}
+
+func (s *Server) SetAccountControls(ctx context.Context, req *simv1.SetAccountControlsRequest) (*simv1.AccountControls, error) {
+  actor := actorFor(ctx, req.GetActor())
+  if req.GetAccountId() == "" || actor == "" { return nil, invalid("missing fields") }
+  throttle := 100
+  if req.Throttle != nil { throttle = int(req.GetThrottle()) }
+  c, err := s.led.SetAccountControls(ctx, req.GetAccountId(), ledger.SetAccountControlsInput{
+    DebitsBlocked: req.GetDebitsBlocked(),
+    CreditsBlocked: req.GetCreditsBlocked(),
+    Throttle: throttle,
+    Actor: actor,
+    Reason: req.GetReason(),
+  })
+  if err != nil { return nil, toStatus(err, codes.InvalidArgument) }
+  return accountControlsPB(c), nil
+}
+
+func accountControlsPB(c *ledger.AccountControls) *simv1.AccountControls {
+  return &simv1.AccountControls{
+    AccountId: c.AccountID,
+    DebitsBlocked: c.DebitsBlocked,
+    CreditsBlocked: c.CreditsBlocked,
+    Throttle: int32(c.Throttle),
+    UpdatedAt: timestamppb.New(c.UpdatedAt),
+  }
+}
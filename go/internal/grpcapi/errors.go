@@ -0,0 +1,85 @@
+package grpcapi
+
+import (
+  "google.golang.org/grpc/codes"
+  "google.golang.org/grpc/status"
+
+  "time-ledger-sim/go/internal/ledger"
+)
+
+type codeMapping struct {
+  is func(error) bool
+  code codes.Code
+}
+
+// codeMappings follow the REST problem mappings (web/problem.go): 404 is NotFound,
+// 409 is AlreadyExists or FailedPrecondition, 503 is Unavailable, and so on.
+var codeMappings = []codeMapping{
+  {ledger.IsIdempotencyConflict, codes.AlreadyExists},
+  {ledger.IsZoneNotFound, codes.NotFound},
+  {ledger.IsTransactionNotFound, codes.NotFound},
+  {ledger.IsZoneExists, codes.AlreadyExists},
+  {ledger.IsZoneNotRetirable, codes.FailedPrecondition},
+  {ledger.IsZoneDown, codes.Unavailable},
+  {ledger.IsZoneBlocked, codes.Unavailable},
+  {ledger.IsAccountBlocked, codes.PermissionDenied},
+  {ledger.IsAmountBelowMinimum, codes.FailedPrecondition},
+  {ledger.IsAmountAboveMaximum, codes.FailedPrecondition},
+  {ledger.IsDailyLimitExceeded, codes.ResourceExhausted},
+  {ledger.IsAccountNotFound, codes.NotFound},
+  {ledger.IsAccountExists, codes.AlreadyExists},
+  {ledger.IsAccountTagNotFound, codes.NotFound},
+  {ledger.IsZoneTagNotFound, codes.NotFound},
+  {ledger.IsAccrualRuleNotFound, codes.NotFound},
+  {ledger.IsRecurringTransferNotFound, codes.NotFound},
+  {ledger.IsTemplateNotFound, codes.NotFound},
+  {ledger.IsPrepareNotFound, codes.NotFound},
+  {ledger.IsDayNotFound, codes.NotFound},
+  {ledger.IsBalanceSubscriptionNotFound, codes.NotFound},
+  {ledger.IsPrepareNotPending, codes.FailedPrecondition},
+  {ledger.IsPrepareExpired, codes.FailedPrecondition},
+  {ledger.IsSagaNotFound, codes.NotFound},
+  {ledger.IsSettlementRunNotFound, codes.NotFound},
+  {ledger.IsThrottleRampNotFound, codes.NotFound},
+  {ledger.IsThrottleRampRunning, codes.FailedPrecondition},
+  {ledger.IsJobNotFound, codes.NotFound},
+  {ledger.IsJobRunning, codes.FailedPrecondition},
+  {ledger.IsArtifactNotFound, codes.NotFound},
+  {ledger.IsArtifactNotReady, codes.FailedPrecondition},
+  {ledger.IsArtifactExpired, codes.NotFound},
+  {ledger.IsRecommendationNotFound, codes.NotFound},
+  {ledger.IsDrillNotFound, codes.NotFound},
+  {ledger.IsDrillRunning, codes.AlreadyExists},
+  {ledger.IsDrillEnded, codes.FailedPrecondition},
+  {ledger.IsTenantNotFound, codes.NotFound},
+  {ledger.IsTenantExists, codes.AlreadyExists},
+  {ledger.IsTenantKeyNotFound, codes.NotFound},
+  {ledger.IsInvalidTenantKey, codes.Unauthenticated},
+  {ledger.IsTenantScoped, codes.PermissionDenied},
+  {ledger.IsPartitioned, codes.Unavailable},
+  {ledger.IsRateLimited, codes.ResourceExhausted},
+  {ledger.IsInjectedFault, codes.Internal},
+  {ledger.IsSerializationFailure, codes.Aborted},
+  {ledger.IsUnknownActor, codes.InvalidArgument},
+  {ledger.IsInvalidReasonCode, codes.InvalidArgument},
+  {ledger.IsInvalidTransactionType, codes.InvalidArgument},
+  {ledger.IsApprovalNotFound, codes.NotFound},
+  {ledger.IsApprovalNotPending, codes.FailedPrecondition},
+  {ledger.IsSelfApproval, codes.PermissionDenied},
+}
+
+// toStatus maps err to a gRPC status; errors without a sentinel use fallback.
+func toStatus(err error, fallback codes.Code) error {
+  for _, m := range codeMappings {
+    if m.is(err) { return status.Error(m.code, err.Error()) }
+  }
+  return status.Error(fallback, err.Error())
+}
+
+// pendingApproval reports an action held under the two-person rule. The API
+// has no approval RPCs; a second operator approves over HTTP.
+func pendingApproval(ap *ledger.Approval) error {
+  return status.Errorf(codes.FailedPrecondition, "held for approval %s: a second operator must POST /v1/approvals/%s/approve", ap.ID, ap.ID)
+}
+
+func invalid(msg string) error { return status.Error(codes.InvalidArgument, msg) }
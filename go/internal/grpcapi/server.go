@@ -0,0 +1,157 @@
+// Package grpcapi serves the sim over gRPC (api/proto/timeledger/sim/v1) next to
+// the REST API. Handlers call the same ledger methods as the web package, so
+// validation, idempotency and audit behave the same on both transports.
+package grpcapi
+
+import (
+  "context"
+  "log/slog"
+  "strings"
+
+  "google.golang.org/grpc"
+  "google.golang.org/grpc/codes"
+  "google.golang.org/grpc/health"
+  healthpb "google.golang.org/grpc/health/grpc_health_v1"
+  "google.golang.org/grpc/metadata"
+  "google.golang.org/grpc/reflection"
+  "google.golang.org/grpc/status"
+
+  "time-ledger-sim/go/internal/auth"
+  "time-ledger-sim/go/internal/grpcapi/simv1"
+  "time-ledger-sim/go/internal/ledger"
+  "time-ledger-sim/go/internal/logging"
+  "time-ledger-sim/go/internal/store"
+)
+
+// Server implements the simv1 services over a ledger.
+type Server struct {
+  simv1.UnimplementedTransferServiceServer
+  simv1.UnimplementedZoneServiceServer
+  simv1.UnimplementedIncidentServiceServer
+  simv1.UnimplementedControlsServiceServer
+
+  led *ledger.Ledger
+  log *slog.Logger
+}
+
+// DrainGate counts mutating calls in flight and refuses them once closed
+// (web.DrainGate, shared with the REST API so a drain waits for both).
+type DrainGate interface {
+  Enter() bool
+  Leave()
+}
+
+// WriteGate holds mutating calls back as the REST API does: Drain while the
+// instance drains, Ready until messaging is connected. Either may be nil.
+type WriteGate struct {
+  Drain DrainGate
+  Ready func() bool
+}
+
+// NewServer builds a gRPC server with the sim services, the standard health
+// service and server reflection. verifier may be nil (auth disabled).
+func NewServer(led *ledger.Ledger, verifier *auth.Verifier, gate WriteGate, log *slog.Logger) *grpc.Server {
+  interceptors := []grpc.UnaryServerInterceptor{requestIDInterceptor}
+  if led != nil && led.MultiTenant() { interceptors = append(interceptors, tenantInterceptor(led)) }
+  interceptors = append(interceptors, writeGateInterceptor(gate), authInterceptor(verifier))
+  gs := grpc.NewServer(grpc.ChainUnaryInterceptor(interceptors...))
+  s := &Server{led: led, log: log}
+  simv1.RegisterTransferServiceServer(gs, s)
+  simv1.RegisterZoneServiceServer(gs, s)
+  simv1.RegisterIncidentServiceServer(gs, s)
+  simv1.RegisterControlsServiceServer(gs, s)
+
+  hs := health.NewServer()
+  for name := range gs.GetServiceInfo() {
+    hs.SetServingStatus(name, healthpb.HealthCheckResponse_SERVING)
+  }
+  healthpb.RegisterHealthServer(gs, hs)
+  reflection.Register(gs)
+  return gs
+}
+
+// requestIDInterceptor mirrors web.RequestIDMiddleware with x-request-id metadata.
+func requestIDInterceptor(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+  md, _ := metadata.FromIncomingContext(ctx)
+  var id string
+  if vals := md.Get(logging.Header); len(vals) > 0 { id = vals[0] }
+  if !logging.ValidRequestID(id) { id = logging.NewRequestID() }
+  _ = grpc.SetHeader(ctx, metadata.Pairs(logging.Header, id))
+  return handler(logging.WithRequestID(ctx, id), req)
+}
+
+// writeGateInterceptor mirrors web.DrainGate.Middleware and web.HoldWrites:
+// mutating calls fail with Unavailable while the instance drains or before
+// messaging is connected. Reads, health and reflection pass.
+func writeGateInterceptor(g WriteGate) grpc.UnaryServerInterceptor {
+  return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+    if !isWriteMethod(info.FullMethod) { return handler(ctx, req) }
+    if g.Drain != nil {
+      open := g.Drain.Enter()
+      defer g.Drain.Leave()
+      if !open { return nil, status.Error(codes.Unavailable, "instance is draining; retry against another instance") }
+    }
+    if g.Ready != nil && !g.Ready() {
+      return nil, status.Error(codes.Unavailable, "messaging is not connected yet; reads are served, writes are held")
+    }
+    return handler(ctx, req)
+  }
+}
+
+// isWriteMethod reports whether a sim RPC mutates state: every one except
+// the Get and List calls.
+func isWriteMethod(fullMethod string) bool {
+  if !strings.HasPrefix(fullMethod, "/timeledger.") { return false }
+  name := fullMethod[strings.LastIndexByte(fullMethod, '/')+1:]
+  return !strings.HasPrefix(name, "Get") && !strings.HasPrefix(name, "List")
+}
+
+// authInterceptor mirrors web.AuthMiddleware: with OIDC enabled every call needs a
+// bearer token in the "authorization" metadata, except health and reflection so
+// probes and grpcurl keep working.
+func authInterceptor(v *auth.Verifier) grpc.UnaryServerInterceptor {
+  return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+    if v == nil || !strings.HasPrefix(info.FullMethod, "/timeledger.") { return handler(ctx, req) }
+    md, _ := metadata.FromIncomingContext(ctx)
+    var header string
+    if vals := md.Get("authorization"); len(vals) > 0 { header = vals[0] }
+    raw, ok := auth.BearerToken(header)
+    if !ok { return nil, status.Error(codes.Unauthenticated, "missing bearer token") }
+    id, err := v.Verify(ctx, raw)
+    if err != nil { return nil, status.Error(codes.Unauthenticated, err.Error()) }
+    return handler(auth.WithIdentity(ctx, id), req)
+  }
+}
+
+// tenantInterceptor mirrors web.API.TenantMiddleware with x-tenant-key
+// metadata, when MULTI_TENANT is on: a call runs as the key's tenant, or the
+// default tenant without one.
+func tenantInterceptor(led *ledger.Ledger) grpc.UnaryServerInterceptor {
+  return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+    if !strings.HasPrefix(info.FullMethod, "/timeledger.") { return handler(ctx, req) }
+    tenant := store.DefaultTenant
+    md, _ := metadata.FromIncomingContext(ctx)
+    if vals := md.Get("x-tenant-key"); len(vals) > 0 {
+      t, err := led.TenantForKey(ctx, vals[0])
+      if err != nil { return nil, toStatus(err, codes.Unavailable) }
+      tenant = t
+    }
+    return handler(store.WithTenant(ctx, tenant), req)
+  }
+}
+
+// actorFor returns the authenticated caller when OIDC is enabled, otherwise the
+// actor the client supplied.
+func actorFor(ctx context.Context, supplied string) string {
+  if id, ok := auth.FromContext(ctx); ok { return id.Actor }
+  return supplied
+}
+
+// reasonCodeMD carries a reason code on RPCs whose messages have no field for it.
+const reasonCodeMD = "x-reason-code"
+
+func reasonCodeFor(ctx context.Context) string {
+  md, _ := metadata.FromIncomingContext(ctx)
+  if vals := md.Get(reasonCodeMD); len(vals) > 0 { return vals[0] }
+  return ""
+}
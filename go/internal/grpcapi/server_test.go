@@ -0,0 +1,156 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"time-ledger-sim/go/internal/grpcapi/simv1"
+	"time-ledger-sim/go/internal/ledger"
+	"time-ledger-sim/go/internal/ledger/ledgertest"
+	"time-ledger-sim/go/internal/util"
+	"time-ledger-sim/go/internal/web"
+)
+
+func TestToStatus(t *testing.T) {
+	cases := []struct {
+		err  error
+		want codes.Code
+	}{
+		{fmt.Errorf("wrap: %w", ledger.ErrIdempotencyConflict), codes.AlreadyExists},
+		{ledger.ErrZoneNotFound, codes.NotFound},
+		{ledger.ErrZoneBlocked, codes.Unavailable},
+		{ledger.ErrRateLimited, codes.ResourceExhausted},
+		{fmt.Errorf("boom"), codes.Internal},
+	}
+	for _, c := range cases {
+		if got := status.Code(toStatus(c.err, codes.Internal)); got != c.want {
+			t.Errorf("%v: got %v, want %v", c.err, got, c.want)
+		}
+	}
+}
+
+func TestServerHealthAndValidation(t *testing.T) {
+	lis := bufconn.Listen(1 << 20)
+	gs := NewServer(nil, nil, WriteGate{}, nil)
+	go func() { _ = gs.Serve(lis) }()
+	defer gs.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	ctx := context.Background()
+
+	hc := healthpb.NewHealthClient(conn)
+	for _, svc := range []string{"", simv1.TransferService_ServiceDesc.ServiceName} {
+		resp, err := hc.Check(ctx, &healthpb.HealthCheckRequest{Service: svc})
+		if err != nil || resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+			t.Fatalf("health %q: %v %v", svc, resp, err)
+		}
+	}
+
+	// Validation runs before the ledger is touched.
+	_, err = simv1.NewTransferServiceClient(conn).CreateTransfer(ctx, &simv1.CreateTransferRequest{RequestId: "r1"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestWriteGate(t *testing.T) {
+	var drain web.DrainGate
+	ready := false
+	intercept := writeGateInterceptor(WriteGate{Drain: &drain, Ready: func() bool { return ready }})
+	ok := func(context.Context, any) (any, error) { return "ok", nil }
+	call := func(method string, handler grpc.UnaryHandler) error {
+		_, err := intercept(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		return err
+	}
+	create := simv1.TransferService_CreateTransfer_FullMethodName
+	list := simv1.ZoneService_ListZones_FullMethodName
+
+	// without messaging, writes are held and reads pass
+	if err := call(create, ok); status.Code(err) != codes.Unavailable {
+		t.Fatalf("write before messaging: %v", err)
+	}
+	if err := call(list, ok); err != nil {
+		t.Fatalf("read before messaging: %v", err)
+	}
+	ready = true
+
+	// a write in flight holds the drain back
+	err := call(create, func(context.Context, any) (any, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		return nil, drain.Wait(ctx)
+	})
+	if err != context.DeadlineExceeded {
+		t.Fatalf("drain during a write: %v", err)
+	}
+
+	drain.Close()
+	if err := call(create, ok); status.Code(err) != codes.Unavailable {
+		t.Fatalf("write while draining: %v", err)
+	}
+	if err := call(list, ok); err != nil {
+		t.Fatalf("read while draining: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := drain.Wait(ctx); err != nil {
+		t.Fatalf("drain after writes finished: %v", err)
+	}
+}
+
+func TestCreateTransferTypeAndZoneSeq(t *testing.T) {
+	repo := ledgertest.NewMemRepo("zone-eu")
+	s := &Server{led: ledger.NewWithRepo(repo, nil)}
+	ctx := context.Background()
+	create := func(req, typ string) (*simv1.CreateTransferResponse, error) {
+		return s.CreateTransfer(ctx, &simv1.CreateTransferRequest{
+			RequestId: req, FromAccount: "a", ToAccount: "b", AmountUnits: 10, ZoneId: "zone-eu", Type: typ,
+		})
+	}
+
+	first, err := create("r1", "")
+	if err != nil || first.GetZoneSeq() != 1 {
+		t.Fatalf("first = %v, %v", first, err)
+	}
+	// the default type hashes like no type, as over REST
+	if again, err := create("r1", ledger.TxnTypeTransfer); err != nil || again.GetTransactionId() != first.GetTransactionId() || again.GetZoneSeq() != 1 {
+		t.Fatalf("retry with the default type = %v, %v", again, err)
+	}
+	fee, err := create("r2", ledger.TxnTypeFee)
+	if err != nil || fee.GetZoneSeq() != 2 {
+		t.Fatalf("fee = %v, %v", fee, err)
+	}
+	if _, err := create("r2", ""); status.Code(err) != codes.AlreadyExists {
+		t.Fatalf("retry without the type: %v", err)
+	}
+	if _, err := create("r3", "BOGUS"); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("unknown type: %v", err)
+	}
+
+	txns := repo.Transactions()
+	if len(txns) != 2 || txns[1].In.Type != ledger.TxnTypeFee {
+		t.Fatalf("transactions = %+v", txns)
+	}
+	restHash, err := util.HashCanonicalJSON(web.CreateTransferRequest{
+		RequestID: "r2", FromAccount: "a", ToAccount: "b", AmountUnits: 10, ZoneID: "zone-eu", Type: ledger.TxnTypeFee, Metadata: map[string]any{},
+	})
+	if err != nil || txns[1].In.PayloadHash != restHash {
+		t.Fatalf("payload hash %s, REST hashes %s (%v)", txns[1].In.PayloadHash, restHash, err)
+	}
+}
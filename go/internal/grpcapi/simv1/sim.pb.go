@@ -0,0 +1,2053 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: timeledger/sim/v1/sim.proto
+
+// gRPC surface of the Go sim. It mirrors the REST API (api/openapi.yaml) for
+// transfers, zones, incidents and controls and shares the same ledger layer, so
+// idempotency keys and audit entries behave identically over both transports.
+
+package simv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type CreateTransferRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     string                 `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	FromAccount   string                 `protobuf:"bytes,2,opt,name=from_account,json=fromAccount,proto3" json:"from_account,omitempty"`
+	ToAccount     string                 `protobuf:"bytes,3,opt,name=to_account,json=toAccount,proto3" json:"to_account,omitempty"`
+	AmountUnits   int64                  `protobuf:"varint,4,opt,name=amount_units,json=amountUnits,proto3" json:"amount_units,omitempty"`
+	ZoneId        string                 `protobuf:"bytes,5,opt,name=zone_id,json=zoneId,proto3" json:"zone_id,omitempty"`
+	Metadata      *structpb.Struct       `protobuf:"bytes,6,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	Type          string                 `protobuf:"bytes,7,opt,name=type,proto3" json:"type,omitempty"` // TRANSFER (default), FEE or REVERSAL; see GET /v1/transaction-types
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateTransferRequest) Reset() {
+	*x = CreateTransferRequest{}
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateTransferRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateTransferRequest) ProtoMessage() {}
+
+func (x *CreateTransferRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateTransferRequest.ProtoReflect.Descriptor instead.
+func (*CreateTransferRequest) Descriptor() ([]byte, []int) {
+	return file_timeledger_sim_v1_sim_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CreateTransferRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *CreateTransferRequest) GetFromAccount() string {
+	if x != nil {
+		return x.FromAccount
+	}
+	return ""
+}
+
+func (x *CreateTransferRequest) GetToAccount() string {
+	if x != nil {
+		return x.ToAccount
+	}
+	return ""
+}
+
+func (x *CreateTransferRequest) GetAmountUnits() int64 {
+	if x != nil {
+		return x.AmountUnits
+	}
+	return 0
+}
+
+func (x *CreateTransferRequest) GetZoneId() string {
+	if x != nil {
+		return x.ZoneId
+	}
+	return ""
+}
+
+func (x *CreateTransferRequest) GetMetadata() *structpb.Struct {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *CreateTransferRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+type CreateTransferResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"` // APPLIED | SPOOLED
+	RequestId     string                 `protobuf:"bytes,2,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	TransactionId string                 `protobuf:"bytes,3,opt,name=transaction_id,json=transactionId,proto3" json:"transaction_id,omitempty"` // set when APPLIED
+	SpoolId       string                 `protobuf:"bytes,4,opt,name=spool_id,json=spoolId,proto3" json:"spool_id,omitempty"`                   // set when SPOOLED
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	ZoneSeq       int64                  `protobuf:"varint,6,opt,name=zone_seq,json=zoneSeq,proto3" json:"zone_seq,omitempty"` // set when APPLIED: position in the zone's sequence
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateTransferResponse) Reset() {
+	*x = CreateTransferResponse{}
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateTransferResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateTransferResponse) ProtoMessage() {}
+
+func (x *CreateTransferResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateTransferResponse.ProtoReflect.Descriptor instead.
+func (*CreateTransferResponse) Descriptor() ([]byte, []int) {
+	return file_timeledger_sim_v1_sim_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CreateTransferResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *CreateTransferResponse) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *CreateTransferResponse) GetTransactionId() string {
+	if x != nil {
+		return x.TransactionId
+	}
+	return ""
+}
+
+func (x *CreateTransferResponse) GetSpoolId() string {
+	if x != nil {
+		return x.SpoolId
+	}
+	return ""
+}
+
+func (x *CreateTransferResponse) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *CreateTransferResponse) GetZoneSeq() int64 {
+	if x != nil {
+		return x.ZoneSeq
+	}
+	return 0
+}
+
+type GetTransactionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTransactionRequest) Reset() {
+	*x = GetTransactionRequest{}
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTransactionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTransactionRequest) ProtoMessage() {}
+
+func (x *GetTransactionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTransactionRequest.ProtoReflect.Descriptor instead.
+func (*GetTransactionRequest) Descriptor() ([]byte, []int) {
+	return file_timeledger_sim_v1_sim_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetTransactionRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type Transaction struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	RequestId     string                 `protobuf:"bytes,2,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	FromAccount   string                 `protobuf:"bytes,3,opt,name=from_account,json=fromAccount,proto3" json:"from_account,omitempty"`
+	ToAccount     string                 `protobuf:"bytes,4,opt,name=to_account,json=toAccount,proto3" json:"to_account,omitempty"`
+	AmountUnits   int64                  `protobuf:"varint,5,opt,name=amount_units,json=amountUnits,proto3" json:"amount_units,omitempty"`
+	ZoneId        string                 `protobuf:"bytes,6,opt,name=zone_id,json=zoneId,proto3" json:"zone_id,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	ZoneSeq       int64                  `protobuf:"varint,8,opt,name=zone_seq,json=zoneSeq,proto3" json:"zone_seq,omitempty"` // position in zone_id's sequence
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Transaction) Reset() {
+	*x = Transaction{}
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Transaction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Transaction) ProtoMessage() {}
+
+func (x *Transaction) ProtoReflect() protoreflect.Message {
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Transaction.ProtoReflect.Descriptor instead.
+func (*Transaction) Descriptor() ([]byte, []int) {
+	return file_timeledger_sim_v1_sim_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Transaction) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Transaction) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *Transaction) GetFromAccount() string {
+	if x != nil {
+		return x.FromAccount
+	}
+	return ""
+}
+
+func (x *Transaction) GetToAccount() string {
+	if x != nil {
+		return x.ToAccount
+	}
+	return ""
+}
+
+func (x *Transaction) GetAmountUnits() int64 {
+	if x != nil {
+		return x.AmountUnits
+	}
+	return 0
+}
+
+func (x *Transaction) GetZoneId() string {
+	if x != nil {
+		return x.ZoneId
+	}
+	return ""
+}
+
+func (x *Transaction) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Transaction) GetZoneSeq() int64 {
+	if x != nil {
+		return x.ZoneSeq
+	}
+	return 0
+}
+
+type Posting struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AccountId     string                 `protobuf:"bytes,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	Direction     string                 `protobuf:"bytes,2,opt,name=direction,proto3" json:"direction,omitempty"` // DEBIT | CREDIT
+	AmountUnits   int64                  `protobuf:"varint,3,opt,name=amount_units,json=amountUnits,proto3" json:"amount_units,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Posting) Reset() {
+	*x = Posting{}
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Posting) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Posting) ProtoMessage() {}
+
+func (x *Posting) ProtoReflect() protoreflect.Message {
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Posting.ProtoReflect.Descriptor instead.
+func (*Posting) Descriptor() ([]byte, []int) {
+	return file_timeledger_sim_v1_sim_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Posting) GetAccountId() string {
+	if x != nil {
+		return x.AccountId
+	}
+	return ""
+}
+
+func (x *Posting) GetDirection() string {
+	if x != nil {
+		return x.Direction
+	}
+	return ""
+}
+
+func (x *Posting) GetAmountUnits() int64 {
+	if x != nil {
+		return x.AmountUnits
+	}
+	return 0
+}
+
+type TransactionDetail struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Transaction   *Transaction           `protobuf:"bytes,1,opt,name=transaction,proto3" json:"transaction,omitempty"`
+	Metadata      *structpb.Struct       `protobuf:"bytes,2,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	Postings      []*Posting             `protobuf:"bytes,3,rep,name=postings,proto3" json:"postings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TransactionDetail) Reset() {
+	*x = TransactionDetail{}
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TransactionDetail) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransactionDetail) ProtoMessage() {}
+
+func (x *TransactionDetail) ProtoReflect() protoreflect.Message {
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransactionDetail.ProtoReflect.Descriptor instead.
+func (*TransactionDetail) Descriptor() ([]byte, []int) {
+	return file_timeledger_sim_v1_sim_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *TransactionDetail) GetTransaction() *Transaction {
+	if x != nil {
+		return x.Transaction
+	}
+	return nil
+}
+
+func (x *TransactionDetail) GetMetadata() *structpb.Struct {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *TransactionDetail) GetPostings() []*Posting {
+	if x != nil {
+		return x.Postings
+	}
+	return nil
+}
+
+type ListTransactionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Limit         int32                  `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"` // default 100
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTransactionsRequest) Reset() {
+	*x = ListTransactionsRequest{}
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTransactionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTransactionsRequest) ProtoMessage() {}
+
+func (x *ListTransactionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTransactionsRequest.ProtoReflect.Descriptor instead.
+func (*ListTransactionsRequest) Descriptor() ([]byte, []int) {
+	return file_timeledger_sim_v1_sim_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListTransactionsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type ListTransactionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Transactions  []*Transaction         `protobuf:"bytes,1,rep,name=transactions,proto3" json:"transactions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTransactionsResponse) Reset() {
+	*x = ListTransactionsResponse{}
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTransactionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTransactionsResponse) ProtoMessage() {}
+
+func (x *ListTransactionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTransactionsResponse.ProtoReflect.Descriptor instead.
+func (*ListTransactionsResponse) Descriptor() ([]byte, []int) {
+	return file_timeledger_sim_v1_sim_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ListTransactionsResponse) GetTransactions() []*Transaction {
+	if x != nil {
+		return x.Transactions
+	}
+	return nil
+}
+
+type Balance struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AccountId     string                 `protobuf:"bytes,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	BalanceUnits  int64                  `protobuf:"varint,2,opt,name=balance_units,json=balanceUnits,proto3" json:"balance_units,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Balance) Reset() {
+	*x = Balance{}
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Balance) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Balance) ProtoMessage() {}
+
+func (x *Balance) ProtoReflect() protoreflect.Message {
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Balance.ProtoReflect.Descriptor instead.
+func (*Balance) Descriptor() ([]byte, []int) {
+	return file_timeledger_sim_v1_sim_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Balance) GetAccountId() string {
+	if x != nil {
+		return x.AccountId
+	}
+	return ""
+}
+
+func (x *Balance) GetBalanceUnits() int64 {
+	if x != nil {
+		return x.BalanceUnits
+	}
+	return 0
+}
+
+func (x *Balance) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type ListBalancesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Limit         int32                  `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"` // default 100
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListBalancesRequest) Reset() {
+	*x = ListBalancesRequest{}
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListBalancesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListBalancesRequest) ProtoMessage() {}
+
+func (x *ListBalancesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListBalancesRequest.ProtoReflect.Descriptor instead.
+func (*ListBalancesRequest) Descriptor() ([]byte, []int) {
+	return file_timeledger_sim_v1_sim_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ListBalancesRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type ListBalancesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Balances      []*Balance             `protobuf:"bytes,1,rep,name=balances,proto3" json:"balances,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListBalancesResponse) Reset() {
+	*x = ListBalancesResponse{}
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListBalancesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListBalancesResponse) ProtoMessage() {}
+
+func (x *ListBalancesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListBalancesResponse.ProtoReflect.Descriptor instead.
+func (*ListBalancesResponse) Descriptor() ([]byte, []int) {
+	return file_timeledger_sim_v1_sim_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ListBalancesResponse) GetBalances() []*Balance {
+	if x != nil {
+		return x.Balances
+	}
+	return nil
+}
+
+type Zone struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"` // OK | DEGRADED | DOWN
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Zone) Reset() {
+	*x = Zone{}
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Zone) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Zone) ProtoMessage() {}
+
+func (x *Zone) ProtoReflect() protoreflect.Message {
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Zone.ProtoReflect.Descriptor instead.
+func (*Zone) Descriptor() ([]byte, []int) {
+	return file_timeledger_sim_v1_sim_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Zone) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Zone) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Zone) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Zone) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type ListZonesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListZonesRequest) Reset() {
+	*x = ListZonesRequest{}
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListZonesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListZonesRequest) ProtoMessage() {}
+
+func (x *ListZonesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListZonesRequest.ProtoReflect.Descriptor instead.
+func (*ListZonesRequest) Descriptor() ([]byte, []int) {
+	return file_timeledger_sim_v1_sim_proto_rawDescGZIP(), []int{12}
+}
+
+type ListZonesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Zones         []*Zone                `protobuf:"bytes,1,rep,name=zones,proto3" json:"zones,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListZonesResponse) Reset() {
+	*x = ListZonesResponse{}
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListZonesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListZonesResponse) ProtoMessage() {}
+
+func (x *ListZonesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListZonesResponse.ProtoReflect.Descriptor instead.
+func (*ListZonesResponse) Descriptor() ([]byte, []int) {
+	return file_timeledger_sim_v1_sim_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ListZonesResponse) GetZones() []*Zone {
+	if x != nil {
+		return x.Zones
+	}
+	return nil
+}
+
+type SetZoneStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ZoneId        string                 `protobuf:"bytes,1,opt,name=zone_id,json=zoneId,proto3" json:"zone_id,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	Actor         string                 `protobuf:"bytes,3,opt,name=actor,proto3" json:"actor,omitempty"` // ignored when the caller is authenticated
+	Reason        string                 `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetZoneStatusRequest) Reset() {
+	*x = SetZoneStatusRequest{}
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetZoneStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetZoneStatusRequest) ProtoMessage() {}
+
+func (x *SetZoneStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetZoneStatusRequest.ProtoReflect.Descriptor instead.
+func (*SetZoneStatusRequest) Descriptor() ([]byte, []int) {
+	return file_timeledger_sim_v1_sim_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *SetZoneStatusRequest) GetZoneId() string {
+	if x != nil {
+		return x.ZoneId
+	}
+	return ""
+}
+
+func (x *SetZoneStatusRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *SetZoneStatusRequest) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+func (x *SetZoneStatusRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type Incident struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	ZoneId        string                 `protobuf:"bytes,2,opt,name=zone_id,json=zoneId,proto3" json:"zone_id,omitempty"`
+	RelatedTxnId  string                 `protobuf:"bytes,3,opt,name=related_txn_id,json=relatedTxnId,proto3" json:"related_txn_id,omitempty"`
+	Severity      string                 `protobuf:"bytes,4,opt,name=severity,proto3" json:"severity,omitempty"` // INFO | WARN | CRITICAL
+	Status        string                 `protobuf:"bytes,5,opt,name=status,proto3" json:"status,omitempty"`     // OPEN | ACK | RESOLVED
+	Title         string                 `protobuf:"bytes,6,opt,name=title,proto3" json:"title,omitempty"`
+	Details       *structpb.Struct       `protobuf:"bytes,7,opt,name=details,proto3" json:"details,omitempty"`
+	DetectedAt    *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=detected_at,json=detectedAt,proto3" json:"detected_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Incident) Reset() {
+	*x = Incident{}
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Incident) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Incident) ProtoMessage() {}
+
+func (x *Incident) ProtoReflect() protoreflect.Message {
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Incident.ProtoReflect.Descriptor instead.
+func (*Incident) Descriptor() ([]byte, []int) {
+	return file_timeledger_sim_v1_sim_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *Incident) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Incident) GetZoneId() string {
+	if x != nil {
+		return x.ZoneId
+	}
+	return ""
+}
+
+func (x *Incident) GetRelatedTxnId() string {
+	if x != nil {
+		return x.RelatedTxnId
+	}
+	return ""
+}
+
+func (x *Incident) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+func (x *Incident) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Incident) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Incident) GetDetails() *structpb.Struct {
+	if x != nil {
+		return x.Details
+	}
+	return nil
+}
+
+func (x *Incident) GetDetectedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DetectedAt
+	}
+	return nil
+}
+
+type ListIncidentsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ZoneId        string                 `protobuf:"bytes,1,opt,name=zone_id,json=zoneId,proto3" json:"zone_id,omitempty"`
+	Limit         int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"` // default 500; ignored when zone_id is set
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListIncidentsRequest) Reset() {
+	*x = ListIncidentsRequest{}
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListIncidentsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListIncidentsRequest) ProtoMessage() {}
+
+func (x *ListIncidentsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListIncidentsRequest.ProtoReflect.Descriptor instead.
+func (*ListIncidentsRequest) Descriptor() ([]byte, []int) {
+	return file_timeledger_sim_v1_sim_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ListIncidentsRequest) GetZoneId() string {
+	if x != nil {
+		return x.ZoneId
+	}
+	return ""
+}
+
+func (x *ListIncidentsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type ListIncidentsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Incidents     []*Incident            `protobuf:"bytes,1,rep,name=incidents,proto3" json:"incidents,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListIncidentsResponse) Reset() {
+	*x = ListIncidentsResponse{}
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListIncidentsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListIncidentsResponse) ProtoMessage() {}
+
+func (x *ListIncidentsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListIncidentsResponse.ProtoReflect.Descriptor instead.
+func (*ListIncidentsResponse) Descriptor() ([]byte, []int) {
+	return file_timeledger_sim_v1_sim_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ListIncidentsResponse) GetIncidents() []*Incident {
+	if x != nil {
+		return x.Incidents
+	}
+	return nil
+}
+
+type GetIncidentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetIncidentRequest) Reset() {
+	*x = GetIncidentRequest{}
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetIncidentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetIncidentRequest) ProtoMessage() {}
+
+func (x *GetIncidentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetIncidentRequest.ProtoReflect.Descriptor instead.
+func (*GetIncidentRequest) Descriptor() ([]byte, []int) {
+	return file_timeledger_sim_v1_sim_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *GetIncidentRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ApplyIncidentActionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	IncidentId    string                 `protobuf:"bytes,1,opt,name=incident_id,json=incidentId,proto3" json:"incident_id,omitempty"`
+	Action        string                 `protobuf:"bytes,2,opt,name=action,proto3" json:"action,omitempty"` // ACK | ASSIGN | RESOLVE
+	Assignee      string                 `protobuf:"bytes,3,opt,name=assignee,proto3" json:"assignee,omitempty"`
+	Note          string                 `protobuf:"bytes,4,opt,name=note,proto3" json:"note,omitempty"`
+	Actor         string                 `protobuf:"bytes,5,opt,name=actor,proto3" json:"actor,omitempty"` // ignored when the caller is authenticated
+	Reason        string                 `protobuf:"bytes,6,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ApplyIncidentActionRequest) Reset() {
+	*x = ApplyIncidentActionRequest{}
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ApplyIncidentActionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ApplyIncidentActionRequest) ProtoMessage() {}
+
+func (x *ApplyIncidentActionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ApplyIncidentActionRequest.ProtoReflect.Descriptor instead.
+func (*ApplyIncidentActionRequest) Descriptor() ([]byte, []int) {
+	return file_timeledger_sim_v1_sim_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ApplyIncidentActionRequest) GetIncidentId() string {
+	if x != nil {
+		return x.IncidentId
+	}
+	return ""
+}
+
+func (x *ApplyIncidentActionRequest) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *ApplyIncidentActionRequest) GetAssignee() string {
+	if x != nil {
+		return x.Assignee
+	}
+	return ""
+}
+
+func (x *ApplyIncidentActionRequest) GetNote() string {
+	if x != nil {
+		return x.Note
+	}
+	return ""
+}
+
+func (x *ApplyIncidentActionRequest) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+func (x *ApplyIncidentActionRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type ZoneControls struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	ZoneId            string                 `protobuf:"bytes,1,opt,name=zone_id,json=zoneId,proto3" json:"zone_id,omitempty"`
+	WritesBlocked     bool                   `protobuf:"varint,2,opt,name=writes_blocked,json=writesBlocked,proto3" json:"writes_blocked,omitempty"`
+	CrossZoneThrottle int32                  `protobuf:"varint,3,opt,name=cross_zone_throttle,json=crossZoneThrottle,proto3" json:"cross_zone_throttle,omitempty"`
+	SpoolEnabled      bool                   `protobuf:"varint,4,opt,name=spool_enabled,json=spoolEnabled,proto3" json:"spool_enabled,omitempty"`
+	InjectLatencyMs   int32                  `protobuf:"varint,5,opt,name=inject_latency_ms,json=injectLatencyMs,proto3" json:"inject_latency_ms,omitempty"`
+	InjectJitterMs    int32                  `protobuf:"varint,6,opt,name=inject_jitter_ms,json=injectJitterMs,proto3" json:"inject_jitter_ms,omitempty"`
+	ErrorRatePercent  int32                  `protobuf:"varint,7,opt,name=error_rate_percent,json=errorRatePercent,proto3" json:"error_rate_percent,omitempty"`
+	ThrottleMode      string                 `protobuf:"bytes,8,opt,name=throttle_mode,json=throttleMode,proto3" json:"throttle_mode,omitempty"` // HASH | RATE
+	RateLimitPerSec   int32                  `protobuf:"varint,9,opt,name=rate_limit_per_sec,json=rateLimitPerSec,proto3" json:"rate_limit_per_sec,omitempty"`
+	RateLimitBurst    int32                  `protobuf:"varint,10,opt,name=rate_limit_burst,json=rateLimitBurst,proto3" json:"rate_limit_burst,omitempty"`
+	ClockSkewMs       int64                  `protobuf:"varint,11,opt,name=clock_skew_ms,json=clockSkewMs,proto3" json:"clock_skew_ms,omitempty"`
+	UpdatedAt         *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *ZoneControls) Reset() {
+	*x = ZoneControls{}
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ZoneControls) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ZoneControls) ProtoMessage() {}
+
+func (x *ZoneControls) ProtoReflect() protoreflect.Message {
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ZoneControls.ProtoReflect.Descriptor instead.
+func (*ZoneControls) Descriptor() ([]byte, []int) {
+	return file_timeledger_sim_v1_sim_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ZoneControls) GetZoneId() string {
+	if x != nil {
+		return x.ZoneId
+	}
+	return ""
+}
+
+func (x *ZoneControls) GetWritesBlocked() bool {
+	if x != nil {
+		return x.WritesBlocked
+	}
+	return false
+}
+
+func (x *ZoneControls) GetCrossZoneThrottle() int32 {
+	if x != nil {
+		return x.CrossZoneThrottle
+	}
+	return 0
+}
+
+func (x *ZoneControls) GetSpoolEnabled() bool {
+	if x != nil {
+		return x.SpoolEnabled
+	}
+	return false
+}
+
+func (x *ZoneControls) GetInjectLatencyMs() int32 {
+	if x != nil {
+		return x.InjectLatencyMs
+	}
+	return 0
+}
+
+func (x *ZoneControls) GetInjectJitterMs() int32 {
+	if x != nil {
+		return x.InjectJitterMs
+	}
+	return 0
+}
+
+func (x *ZoneControls) GetErrorRatePercent() int32 {
+	if x != nil {
+		return x.ErrorRatePercent
+	}
+	return 0
+}
+
+func (x *ZoneControls) GetThrottleMode() string {
+	if x != nil {
+		return x.ThrottleMode
+	}
+	return ""
+}
+
+func (x *ZoneControls) GetRateLimitPerSec() int32 {
+	if x != nil {
+		return x.RateLimitPerSec
+	}
+	return 0
+}
+
+func (x *ZoneControls) GetRateLimitBurst() int32 {
+	if x != nil {
+		return x.RateLimitBurst
+	}
+	return 0
+}
+
+func (x *ZoneControls) GetClockSkewMs() int64 {
+	if x != nil {
+		return x.ClockSkewMs
+	}
+	return 0
+}
+
+func (x *ZoneControls) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type GetZoneControlsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ZoneId        string                 `protobuf:"bytes,1,opt,name=zone_id,json=zoneId,proto3" json:"zone_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetZoneControlsRequest) Reset() {
+	*x = GetZoneControlsRequest{}
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetZoneControlsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetZoneControlsRequest) ProtoMessage() {}
+
+func (x *GetZoneControlsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetZoneControlsRequest.ProtoReflect.Descriptor instead.
+func (*GetZoneControlsRequest) Descriptor() ([]byte, []int) {
+	return file_timeledger_sim_v1_sim_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *GetZoneControlsRequest) GetZoneId() string {
+	if x != nil {
+		return x.ZoneId
+	}
+	return ""
+}
+
+// SetZoneControlsRequest replaces every control, like the REST endpoint.
+type SetZoneControlsRequest struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	ZoneId            string                 `protobuf:"bytes,1,opt,name=zone_id,json=zoneId,proto3" json:"zone_id,omitempty"`
+	WritesBlocked     bool                   `protobuf:"varint,2,opt,name=writes_blocked,json=writesBlocked,proto3" json:"writes_blocked,omitempty"`
+	CrossZoneThrottle int32                  `protobuf:"varint,3,opt,name=cross_zone_throttle,json=crossZoneThrottle,proto3" json:"cross_zone_throttle,omitempty"`
+	SpoolEnabled      bool                   `protobuf:"varint,4,opt,name=spool_enabled,json=spoolEnabled,proto3" json:"spool_enabled,omitempty"`
+	InjectLatencyMs   int32                  `protobuf:"varint,5,opt,name=inject_latency_ms,json=injectLatencyMs,proto3" json:"inject_latency_ms,omitempty"`
+	InjectJitterMs    int32                  `protobuf:"varint,6,opt,name=inject_jitter_ms,json=injectJitterMs,proto3" json:"inject_jitter_ms,omitempty"`
+	ErrorRatePercent  int32                  `protobuf:"varint,7,opt,name=error_rate_percent,json=errorRatePercent,proto3" json:"error_rate_percent,omitempty"`
+	ThrottleMode      string                 `protobuf:"bytes,8,opt,name=throttle_mode,json=throttleMode,proto3" json:"throttle_mode,omitempty"`
+	RateLimitPerSec   int32                  `protobuf:"varint,9,opt,name=rate_limit_per_sec,json=rateLimitPerSec,proto3" json:"rate_limit_per_sec,omitempty"`
+	RateLimitBurst    int32                  `protobuf:"varint,10,opt,name=rate_limit_burst,json=rateLimitBurst,proto3" json:"rate_limit_burst,omitempty"`
+	ClockSkewMs       int64                  `protobuf:"varint,11,opt,name=clock_skew_ms,json=clockSkewMs,proto3" json:"clock_skew_ms,omitempty"`
+	Actor             string                 `protobuf:"bytes,12,opt,name=actor,proto3" json:"actor,omitempty"` // ignored when the caller is authenticated
+	Reason            string                 `protobuf:"bytes,13,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *SetZoneControlsRequest) Reset() {
+	*x = SetZoneControlsRequest{}
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetZoneControlsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetZoneControlsRequest) ProtoMessage() {}
+
+func (x *SetZoneControlsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetZoneControlsRequest.ProtoReflect.Descriptor instead.
+func (*SetZoneControlsRequest) Descriptor() ([]byte, []int) {
+	return file_timeledger_sim_v1_sim_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *SetZoneControlsRequest) GetZoneId() string {
+	if x != nil {
+		return x.ZoneId
+	}
+	return ""
+}
+
+func (x *SetZoneControlsRequest) GetWritesBlocked() bool {
+	if x != nil {
+		return x.WritesBlocked
+	}
+	return false
+}
+
+func (x *SetZoneControlsRequest) GetCrossZoneThrottle() int32 {
+	if x != nil {
+		return x.CrossZoneThrottle
+	}
+	return 0
+}
+
+func (x *SetZoneControlsRequest) GetSpoolEnabled() bool {
+	if x != nil {
+		return x.SpoolEnabled
+	}
+	return false
+}
+
+func (x *SetZoneControlsRequest) GetInjectLatencyMs() int32 {
+	if x != nil {
+		return x.InjectLatencyMs
+	}
+	return 0
+}
+
+func (x *SetZoneControlsRequest) GetInjectJitterMs() int32 {
+	if x != nil {
+		return x.InjectJitterMs
+	}
+	return 0
+}
+
+func (x *SetZoneControlsRequest) GetErrorRatePercent() int32 {
+	if x != nil {
+		return x.ErrorRatePercent
+	}
+	return 0
+}
+
+func (x *SetZoneControlsRequest) GetThrottleMode() string {
+	if x != nil {
+		return x.ThrottleMode
+	}
+	return ""
+}
+
+func (x *SetZoneControlsRequest) GetRateLimitPerSec() int32 {
+	if x != nil {
+		return x.RateLimitPerSec
+	}
+	return 0
+}
+
+func (x *SetZoneControlsRequest) GetRateLimitBurst() int32 {
+	if x != nil {
+		return x.RateLimitBurst
+	}
+	return 0
+}
+
+func (x *SetZoneControlsRequest) GetClockSkewMs() int64 {
+	if x != nil {
+		return x.ClockSkewMs
+	}
+	return 0
+}
+
+func (x *SetZoneControlsRequest) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+func (x *SetZoneControlsRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type AccountControls struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	AccountId      string                 `protobuf:"bytes,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	DebitsBlocked  bool                   `protobuf:"varint,2,opt,name=debits_blocked,json=debitsBlocked,proto3" json:"debits_blocked,omitempty"`
+	CreditsBlocked bool                   `protobuf:"varint,3,opt,name=credits_blocked,json=creditsBlocked,proto3" json:"credits_blocked,omitempty"`
+	Throttle       int32                  `protobuf:"varint,4,opt,name=throttle,proto3" json:"throttle,omitempty"`
+	UpdatedAt      *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *AccountControls) Reset() {
+	*x = AccountControls{}
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AccountControls) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AccountControls) ProtoMessage() {}
+
+func (x *AccountControls) ProtoReflect() protoreflect.Message {
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AccountControls.ProtoReflect.Descriptor instead.
+func (*AccountControls) Descriptor() ([]byte, []int) {
+	return file_timeledger_sim_v1_sim_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *AccountControls) GetAccountId() string {
+	if x != nil {
+		return x.AccountId
+	}
+	return ""
+}
+
+func (x *AccountControls) GetDebitsBlocked() bool {
+	if x != nil {
+		return x.DebitsBlocked
+	}
+	return false
+}
+
+func (x *AccountControls) GetCreditsBlocked() bool {
+	if x != nil {
+		return x.CreditsBlocked
+	}
+	return false
+}
+
+func (x *AccountControls) GetThrottle() int32 {
+	if x != nil {
+		return x.Throttle
+	}
+	return 0
+}
+
+func (x *AccountControls) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type GetAccountControlsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AccountId     string                 `protobuf:"bytes,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAccountControlsRequest) Reset() {
+	*x = GetAccountControlsRequest{}
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAccountControlsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAccountControlsRequest) ProtoMessage() {}
+
+func (x *GetAccountControlsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAccountControlsRequest.ProtoReflect.Descriptor instead.
+func (*GetAccountControlsRequest) Descriptor() ([]byte, []int) {
+	return file_timeledger_sim_v1_sim_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *GetAccountControlsRequest) GetAccountId() string {
+	if x != nil {
+		return x.AccountId
+	}
+	return ""
+}
+
+type SetAccountControlsRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	AccountId      string                 `protobuf:"bytes,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	DebitsBlocked  bool                   `protobuf:"varint,2,opt,name=debits_blocked,json=debitsBlocked,proto3" json:"debits_blocked,omitempty"`
+	CreditsBlocked bool                   `protobuf:"varint,3,opt,name=credits_blocked,json=creditsBlocked,proto3" json:"credits_blocked,omitempty"`
+	Throttle       *int32                 `protobuf:"varint,4,opt,name=throttle,proto3,oneof" json:"throttle,omitempty"` // 0-100, defaults to 100 (no throttle)
+	Actor          string                 `protobuf:"bytes,5,opt,name=actor,proto3" json:"actor,omitempty"`              // ignored when the caller is authenticated
+	Reason         string                 `protobuf:"bytes,6,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *SetAccountControlsRequest) Reset() {
+	*x = SetAccountControlsRequest{}
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetAccountControlsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetAccountControlsRequest) ProtoMessage() {}
+
+func (x *SetAccountControlsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_timeledger_sim_v1_sim_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetAccountControlsRequest.ProtoReflect.Descriptor instead.
+func (*SetAccountControlsRequest) Descriptor() ([]byte, []int) {
+	return file_timeledger_sim_v1_sim_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *SetAccountControlsRequest) GetAccountId() string {
+	if x != nil {
+		return x.AccountId
+	}
+	return ""
+}
+
+func (x *SetAccountControlsRequest) GetDebitsBlocked() bool {
+	if x != nil {
+		return x.DebitsBlocked
+	}
+	return false
+}
+
+func (x *SetAccountControlsRequest) GetCreditsBlocked() bool {
+	if x != nil {
+		return x.CreditsBlocked
+	}
+	return false
+}
+
+func (x *SetAccountControlsRequest) GetThrottle() int32 {
+	if x != nil && x.Throttle != nil {
+		return *x.Throttle
+	}
+	return 0
+}
+
+func (x *SetAccountControlsRequest) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+func (x *SetAccountControlsRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+var File_timeledger_sim_v1_sim_proto protoreflect.FileDescriptor
+
+const file_timeledger_sim_v1_sim_proto_rawDesc = "" +
+	"\n" +
+	"\x1btimeledger/sim/v1/sim.proto\x12\x11timeledger.sim.v1\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xfd\x01\n" +
+	"\x15CreateTransferRequest\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\tR\trequestId\x12!\n" +
+	"\ffrom_account\x18\x02 \x01(\tR\vfromAccount\x12\x1d\n" +
+	"\n" +
+	"to_account\x18\x03 \x01(\tR\ttoAccount\x12!\n" +
+	"\famount_units\x18\x04 \x01(\x03R\vamountUnits\x12\x17\n" +
+	"\azone_id\x18\x05 \x01(\tR\x06zoneId\x123\n" +
+	"\bmetadata\x18\x06 \x01(\v2\x17.google.protobuf.StructR\bmetadata\x12\x12\n" +
+	"\x04type\x18\a \x01(\tR\x04type\"\xe7\x01\n" +
+	"\x16CreateTransferResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x02 \x01(\tR\trequestId\x12%\n" +
+	"\x0etransaction_id\x18\x03 \x01(\tR\rtransactionId\x12\x19\n" +
+	"\bspool_id\x18\x04 \x01(\tR\aspoolId\x129\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12\x19\n" +
+	"\bzone_seq\x18\x06 \x01(\x03R\azoneSeq\"'\n" +
+	"\x15GetTransactionRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x90\x02\n" +
+	"\vTransaction\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x02 \x01(\tR\trequestId\x12!\n" +
+	"\ffrom_account\x18\x03 \x01(\tR\vfromAccount\x12\x1d\n" +
+	"\n" +
+	"to_account\x18\x04 \x01(\tR\ttoAccount\x12!\n" +
+	"\famount_units\x18\x05 \x01(\x03R\vamountUnits\x12\x17\n" +
+	"\azone_id\x18\x06 \x01(\tR\x06zoneId\x129\n" +
+	"\n" +
+	"created_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12\x19\n" +
+	"\bzone_seq\x18\b \x01(\x03R\azoneSeq\"i\n" +
+	"\aPosting\x12\x1d\n" +
+	"\n" +
+	"account_id\x18\x01 \x01(\tR\taccountId\x12\x1c\n" +
+	"\tdirection\x18\x02 \x01(\tR\tdirection\x12!\n" +
+	"\famount_units\x18\x03 \x01(\x03R\vamountUnits\"\xc2\x01\n" +
+	"\x11TransactionDetail\x12@\n" +
+	"\vtransaction\x18\x01 \x01(\v2\x1e.timeledger.sim.v1.TransactionR\vtransaction\x123\n" +
+	"\bmetadata\x18\x02 \x01(\v2\x17.google.protobuf.StructR\bmetadata\x126\n" +
+	"\bpostings\x18\x03 \x03(\v2\x1a.timeledger.sim.v1.PostingR\bpostings\"/\n" +
+	"\x17ListTransactionsRequest\x12\x14\n" +
+	"\x05limit\x18\x01 \x01(\x05R\x05limit\"^\n" +
+	"\x18ListTransactionsResponse\x12B\n" +
+	"\ftransactions\x18\x01 \x03(\v2\x1e.timeledger.sim.v1.TransactionR\ftransactions\"\x88\x01\n" +
+	"\aBalance\x12\x1d\n" +
+	"\n" +
+	"account_id\x18\x01 \x01(\tR\taccountId\x12#\n" +
+	"\rbalance_units\x18\x02 \x01(\x03R\fbalanceUnits\x129\n" +
+	"\n" +
+	"updated_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"+\n" +
+	"\x13ListBalancesRequest\x12\x14\n" +
+	"\x05limit\x18\x01 \x01(\x05R\x05limit\"N\n" +
+	"\x14ListBalancesResponse\x126\n" +
+	"\bbalances\x18\x01 \x03(\v2\x1a.timeledger.sim.v1.BalanceR\bbalances\"}\n" +
+	"\x04Zone\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x129\n" +
+	"\n" +
+	"updated_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"\x12\n" +
+	"\x10ListZonesRequest\"B\n" +
+	"\x11ListZonesResponse\x12-\n" +
+	"\x05zones\x18\x01 \x03(\v2\x17.timeledger.sim.v1.ZoneR\x05zones\"u\n" +
+	"\x14SetZoneStatusRequest\x12\x17\n" +
+	"\azone_id\x18\x01 \x01(\tR\x06zoneId\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12\x14\n" +
+	"\x05actor\x18\x03 \x01(\tR\x05actor\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\"\x93\x02\n" +
+	"\bIncident\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\azone_id\x18\x02 \x01(\tR\x06zoneId\x12$\n" +
+	"\x0erelated_txn_id\x18\x03 \x01(\tR\frelatedTxnId\x12\x1a\n" +
+	"\bseverity\x18\x04 \x01(\tR\bseverity\x12\x16\n" +
+	"\x06status\x18\x05 \x01(\tR\x06status\x12\x14\n" +
+	"\x05title\x18\x06 \x01(\tR\x05title\x121\n" +
+	"\adetails\x18\a \x01(\v2\x17.google.protobuf.StructR\adetails\x12;\n" +
+	"\vdetected_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"detectedAt\"E\n" +
+	"\x14ListIncidentsRequest\x12\x17\n" +
+	"\azone_id\x18\x01 \x01(\tR\x06zoneId\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\"R\n" +
+	"\x15ListIncidentsResponse\x129\n" +
+	"\tincidents\x18\x01 \x03(\v2\x1b.timeledger.sim.v1.IncidentR\tincidents\"$\n" +
+	"\x12GetIncidentRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\xb3\x01\n" +
+	"\x1aApplyIncidentActionRequest\x12\x1f\n" +
+	"\vincident_id\x18\x01 \x01(\tR\n" +
+	"incidentId\x12\x16\n" +
+	"\x06action\x18\x02 \x01(\tR\x06action\x12\x1a\n" +
+	"\bassignee\x18\x03 \x01(\tR\bassignee\x12\x12\n" +
+	"\x04note\x18\x04 \x01(\tR\x04note\x12\x14\n" +
+	"\x05actor\x18\x05 \x01(\tR\x05actor\x12\x16\n" +
+	"\x06reason\x18\x06 \x01(\tR\x06reason\"\x82\x04\n" +
+	"\fZoneControls\x12\x17\n" +
+	"\azone_id\x18\x01 \x01(\tR\x06zoneId\x12%\n" +
+	"\x0ewrites_blocked\x18\x02 \x01(\bR\rwritesBlocked\x12.\n" +
+	"\x13cross_zone_throttle\x18\x03 \x01(\x05R\x11crossZoneThrottle\x12#\n" +
+	"\rspool_enabled\x18\x04 \x01(\bR\fspoolEnabled\x12*\n" +
+	"\x11inject_latency_ms\x18\x05 \x01(\x05R\x0finjectLatencyMs\x12(\n" +
+	"\x10inject_jitter_ms\x18\x06 \x01(\x05R\x0einjectJitterMs\x12,\n" +
+	"\x12error_rate_percent\x18\a \x01(\x05R\x10errorRatePercent\x12#\n" +
+	"\rthrottle_mode\x18\b \x01(\tR\fthrottleMode\x12+\n" +
+	"\x12rate_limit_per_sec\x18\t \x01(\x05R\x0frateLimitPerSec\x12(\n" +
+	"\x10rate_limit_burst\x18\n" +
+	" \x01(\x05R\x0erateLimitBurst\x12\"\n" +
+	"\rclock_skew_ms\x18\v \x01(\x03R\vclockSkewMs\x129\n" +
+	"\n" +
+	"updated_at\x18\f \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"1\n" +
+	"\x16GetZoneControlsRequest\x12\x17\n" +
+	"\azone_id\x18\x01 \x01(\tR\x06zoneId\"\xff\x03\n" +
+	"\x16SetZoneControlsRequest\x12\x17\n" +
+	"\azone_id\x18\x01 \x01(\tR\x06zoneId\x12%\n" +
+	"\x0ewrites_blocked\x18\x02 \x01(\bR\rwritesBlocked\x12.\n" +
+	"\x13cross_zone_throttle\x18\x03 \x01(\x05R\x11crossZoneThrottle\x12#\n" +
+	"\rspool_enabled\x18\x04 \x01(\bR\fspoolEnabled\x12*\n" +
+	"\x11inject_latency_ms\x18\x05 \x01(\x05R\x0finjectLatencyMs\x12(\n" +
+	"\x10inject_jitter_ms\x18\x06 \x01(\x05R\x0einjectJitterMs\x12,\n" +
+	"\x12error_rate_percent\x18\a \x01(\x05R\x10errorRatePercent\x12#\n" +
+	"\rthrottle_mode\x18\b \x01(\tR\fthrottleMode\x12+\n" +
+	"\x12rate_limit_per_sec\x18\t \x01(\x05R\x0frateLimitPerSec\x12(\n" +
+	"\x10rate_limit_burst\x18\n" +
+	" \x01(\x05R\x0erateLimitBurst\x12\"\n" +
+	"\rclock_skew_ms\x18\v \x01(\x03R\vclockSkewMs\x12\x14\n" +
+	"\x05actor\x18\f \x01(\tR\x05actor\x12\x16\n" +
+	"\x06reason\x18\r \x01(\tR\x06reason\"\xd7\x01\n" +
+	"\x0fAccountControls\x12\x1d\n" +
+	"\n" +
+	"account_id\x18\x01 \x01(\tR\taccountId\x12%\n" +
+	"\x0edebits_blocked\x18\x02 \x01(\bR\rdebitsBlocked\x12'\n" +
+	"\x0fcredits_blocked\x18\x03 \x01(\bR\x0ecreditsBlocked\x12\x1a\n" +
+	"\bthrottle\x18\x04 \x01(\x05R\bthrottle\x129\n" +
+	"\n" +
+	"updated_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\":\n" +
+	"\x19GetAccountControlsRequest\x12\x1d\n" +
+	"\n" +
+	"account_id\x18\x01 \x01(\tR\taccountId\"\xe6\x01\n" +
+	"\x19SetAccountControlsRequest\x12\x1d\n" +
+	"\n" +
+	"account_id\x18\x01 \x01(\tR\taccountId\x12%\n" +
+	"\x0edebits_blocked\x18\x02 \x01(\bR\rdebitsBlocked\x12'\n" +
+	"\x0fcredits_blocked\x18\x03 \x01(\bR\x0ecreditsBlocked\x12\x1f\n" +
+	"\bthrottle\x18\x04 \x01(\x05H\x00R\bthrottle\x88\x01\x01\x12\x14\n" +
+	"\x05actor\x18\x05 \x01(\tR\x05actor\x12\x16\n" +
+	"\x06reason\x18\x06 \x01(\tR\x06reasonB\v\n" +
+	"\t_throttle2\xa8\x03\n" +
+	"\x0fTransferService\x12e\n" +
+	"\x0eCreateTransfer\x12(.timeledger.sim.v1.CreateTransferRequest\x1a).timeledger.sim.v1.CreateTransferResponse\x12`\n" +
+	"\x0eGetTransaction\x12(.timeledger.sim.v1.GetTransactionRequest\x1a$.timeledger.sim.v1.TransactionDetail\x12k\n" +
+	"\x10ListTransactions\x12*.timeledger.sim.v1.ListTransactionsRequest\x1a+.timeledger.sim.v1.ListTransactionsResponse\x12_\n" +
+	"\fListBalances\x12&.timeledger.sim.v1.ListBalancesRequest\x1a'.timeledger.sim.v1.ListBalancesResponse2\xb8\x01\n" +
+	"\vZoneService\x12V\n" +
+	"\tListZones\x12#.timeledger.sim.v1.ListZonesRequest\x1a$.timeledger.sim.v1.ListZonesResponse\x12Q\n" +
+	"\rSetZoneStatus\x12'.timeledger.sim.v1.SetZoneStatusRequest\x1a\x17.timeledger.sim.v1.Zone2\xab\x02\n" +
+	"\x0fIncidentService\x12b\n" +
+	"\rListIncidents\x12'.timeledger.sim.v1.ListIncidentsRequest\x1a(.timeledger.sim.v1.ListIncidentsResponse\x12Q\n" +
+	"\vGetIncident\x12%.timeledger.sim.v1.GetIncidentRequest\x1a\x1b.timeledger.sim.v1.Incident\x12a\n" +
+	"\x13ApplyIncidentAction\x12-.timeledger.sim.v1.ApplyIncidentActionRequest\x1a\x1b.timeledger.sim.v1.Incident2\x9f\x03\n" +
+	"\x0fControlsService\x12]\n" +
+	"\x0fGetZoneControls\x12).timeledger.sim.v1.GetZoneControlsRequest\x1a\x1f.timeledger.sim.v1.ZoneControls\x12]\n" +
+	"\x0fSetZoneControls\x12).timeledger.sim.v1.SetZoneControlsRequest\x1a\x1f.timeledger.sim.v1.ZoneControls\x12f\n" +
+	"\x12GetAccountControls\x12,.timeledger.sim.v1.GetAccountControlsRequest\x1a\".timeledger.sim.v1.AccountControls\x12f\n" +
+	"\x12SetAccountControls\x12,.timeledger.sim.v1.SetAccountControlsRequest\x1a\".timeledger.sim.v1.AccountControlsB1Z/time-ledger-sim/go/internal/grpcapi/simv1;simv1b\x06proto3"
+
+var (
+	file_timeledger_sim_v1_sim_proto_rawDescOnce sync.Once
+	file_timeledger_sim_v1_sim_proto_rawDescData []byte
+)
+
+func file_timeledger_sim_v1_sim_proto_rawDescGZIP() []byte {
+	file_timeledger_sim_v1_sim_proto_rawDescOnce.Do(func() {
+		file_timeledger_sim_v1_sim_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_timeledger_sim_v1_sim_proto_rawDesc), len(file_timeledger_sim_v1_sim_proto_rawDesc)))
+	})
+	return file_timeledger_sim_v1_sim_proto_rawDescData
+}
+
+var file_timeledger_sim_v1_sim_proto_msgTypes = make([]protoimpl.MessageInfo, 26)
+var file_timeledger_sim_v1_sim_proto_goTypes = []any{
+	(*CreateTransferRequest)(nil),      // 0: timeledger.sim.v1.CreateTransferRequest
+	(*CreateTransferResponse)(nil),     // 1: timeledger.sim.v1.CreateTransferResponse
+	(*GetTransactionRequest)(nil),      // 2: timeledger.sim.v1.GetTransactionRequest
+	(*Transaction)(nil),                // 3: timeledger.sim.v1.Transaction
+	(*Posting)(nil),                    // 4: timeledger.sim.v1.Posting
+	(*TransactionDetail)(nil),          // 5: timeledger.sim.v1.TransactionDetail
+	(*ListTransactionsRequest)(nil),    // 6: timeledger.sim.v1.ListTransactionsRequest
+	(*ListTransactionsResponse)(nil),   // 7: timeledger.sim.v1.ListTransactionsResponse
+	(*Balance)(nil),                    // 8: timeledger.sim.v1.Balance
+	(*ListBalancesRequest)(nil),        // 9: timeledger.sim.v1.ListBalancesRequest
+	(*ListBalancesResponse)(nil),       // 10: timeledger.sim.v1.ListBalancesResponse
+	(*Zone)(nil),                       // 11: timeledger.sim.v1.Zone
+	(*ListZonesRequest)(nil),           // 12: timeledger.sim.v1.ListZonesRequest
+	(*ListZonesResponse)(nil),          // 13: timeledger.sim.v1.ListZonesResponse
+	(*SetZoneStatusRequest)(nil),       // 14: timeledger.sim.v1.SetZoneStatusRequest
+	(*Incident)(nil),                   // 15: timeledger.sim.v1.Incident
+	(*ListIncidentsRequest)(nil),       // 16: timeledger.sim.v1.ListIncidentsRequest
+	(*ListIncidentsResponse)(nil),      // 17: timeledger.sim.v1.ListIncidentsResponse
+	(*GetIncidentRequest)(nil),         // 18: timeledger.sim.v1.GetIncidentRequest
+	(*ApplyIncidentActionRequest)(nil), // 19: timeledger.sim.v1.ApplyIncidentActionRequest
+	(*ZoneControls)(nil),               // 20: timeledger.sim.v1.ZoneControls
+	(*GetZoneControlsRequest)(nil),     // 21: timeledger.sim.v1.GetZoneControlsRequest
+	(*SetZoneControlsRequest)(nil),     // 22: timeledger.sim.v1.SetZoneControlsRequest
+	(*AccountControls)(nil),            // 23: timeledger.sim.v1.AccountControls
+	(*GetAccountControlsRequest)(nil),  // 24: timeledger.sim.v1.GetAccountControlsRequest
+	(*SetAccountControlsRequest)(nil),  // 25: timeledger.sim.v1.SetAccountControlsRequest
+	(*structpb.Struct)(nil),            // 26: google.protobuf.Struct
+	(*timestamppb.Timestamp)(nil),      // 27: google.protobuf.Timestamp
+}
+var file_timeledger_sim_v1_sim_proto_depIdxs = []int32{
+	26, // 0: timeledger.sim.v1.CreateTransferRequest.metadata:type_name -> google.protobuf.Struct
+	27, // 1: timeledger.sim.v1.CreateTransferResponse.created_at:type_name -> google.protobuf.Timestamp
+	27, // 2: timeledger.sim.v1.Transaction.created_at:type_name -> google.protobuf.Timestamp
+	3,  // 3: timeledger.sim.v1.TransactionDetail.transaction:type_name -> timeledger.sim.v1.Transaction
+	26, // 4: timeledger.sim.v1.TransactionDetail.metadata:type_name -> google.protobuf.Struct
+	4,  // 5: timeledger.sim.v1.TransactionDetail.postings:type_name -> timeledger.sim.v1.Posting
+	3,  // 6: timeledger.sim.v1.ListTransactionsResponse.transactions:type_name -> timeledger.sim.v1.Transaction
+	27, // 7: timeledger.sim.v1.Balance.updated_at:type_name -> google.protobuf.Timestamp
+	8,  // 8: timeledger.sim.v1.ListBalancesResponse.balances:type_name -> timeledger.sim.v1.Balance
+	27, // 9: timeledger.sim.v1.Zone.updated_at:type_name -> google.protobuf.Timestamp
+	11, // 10: timeledger.sim.v1.ListZonesResponse.zones:type_name -> timeledger.sim.v1.Zone
+	26, // 11: timeledger.sim.v1.Incident.details:type_name -> google.protobuf.Struct
+	27, // 12: timeledger.sim.v1.Incident.detected_at:type_name -> google.protobuf.Timestamp
+	15, // 13: timeledger.sim.v1.ListIncidentsResponse.incidents:type_name -> timeledger.sim.v1.Incident
+	27, // 14: timeledger.sim.v1.ZoneControls.updated_at:type_name -> google.protobuf.Timestamp
+	27, // 15: timeledger.sim.v1.AccountControls.updated_at:type_name -> google.protobuf.Timestamp
+	0,  // 16: timeledger.sim.v1.TransferService.CreateTransfer:input_type -> timeledger.sim.v1.CreateTransferRequest
+	2,  // 17: timeledger.sim.v1.TransferService.GetTransaction:input_type -> timeledger.sim.v1.GetTransactionRequest
+	6,  // 18: timeledger.sim.v1.TransferService.ListTransactions:input_type -> timeledger.sim.v1.ListTransactionsRequest
+	9,  // 19: timeledger.sim.v1.TransferService.ListBalances:input_type -> timeledger.sim.v1.ListBalancesRequest
+	12, // 20: timeledger.sim.v1.ZoneService.ListZones:input_type -> timeledger.sim.v1.ListZonesRequest
+	14, // 21: timeledger.sim.v1.ZoneService.SetZoneStatus:input_type -> timeledger.sim.v1.SetZoneStatusRequest
+	16, // 22: timeledger.sim.v1.IncidentService.ListIncidents:input_type -> timeledger.sim.v1.ListIncidentsRequest
+	18, // 23: timeledger.sim.v1.IncidentService.GetIncident:input_type -> timeledger.sim.v1.GetIncidentRequest
+	19, // 24: timeledger.sim.v1.IncidentService.ApplyIncidentAction:input_type -> timeledger.sim.v1.ApplyIncidentActionRequest
+	21, // 25: timeledger.sim.v1.ControlsService.GetZoneControls:input_type -> timeledger.sim.v1.GetZoneControlsRequest
+	22, // 26: timeledger.sim.v1.ControlsService.SetZoneControls:input_type -> timeledger.sim.v1.SetZoneControlsRequest
+	24, // 27: timeledger.sim.v1.ControlsService.GetAccountControls:input_type -> timeledger.sim.v1.GetAccountControlsRequest
+	25, // 28: timeledger.sim.v1.ControlsService.SetAccountControls:input_type -> timeledger.sim.v1.SetAccountControlsRequest
+	1,  // 29: timeledger.sim.v1.TransferService.CreateTransfer:output_type -> timeledger.sim.v1.CreateTransferResponse
+	5,  // 30: timeledger.sim.v1.TransferService.GetTransaction:output_type -> timeledger.sim.v1.TransactionDetail
+	7,  // 31: timeledger.sim.v1.TransferService.ListTransactions:output_type -> timeledger.sim.v1.ListTransactionsResponse
+	10, // 32: timeledger.sim.v1.TransferService.ListBalances:output_type -> timeledger.sim.v1.ListBalancesResponse
+	13, // 33: timeledger.sim.v1.ZoneService.ListZones:output_type -> timeledger.sim.v1.ListZonesResponse
+	11, // 34: timeledger.sim.v1.ZoneService.SetZoneStatus:output_type -> timeledger.sim.v1.Zone
+	17, // 35: timeledger.sim.v1.IncidentService.ListIncidents:output_type -> timeledger.sim.v1.ListIncidentsResponse
+	15, // 36: timeledger.sim.v1.IncidentService.GetIncident:output_type -> timeledger.sim.v1.Incident
+	15, // 37: timeledger.sim.v1.IncidentService.ApplyIncidentAction:output_type -> timeledger.sim.v1.Incident
+	20, // 38: timeledger.sim.v1.ControlsService.GetZoneControls:output_type -> timeledger.sim.v1.ZoneControls
+	20, // 39: timeledger.sim.v1.ControlsService.SetZoneControls:output_type -> timeledger.sim.v1.ZoneControls
+	23, // 40: timeledger.sim.v1.ControlsService.GetAccountControls:output_type -> timeledger.sim.v1.AccountControls
+	23, // 41: timeledger.sim.v1.ControlsService.SetAccountControls:output_type -> timeledger.sim.v1.AccountControls
+	29, // [29:42] is the sub-list for method output_type
+	16, // [16:29] is the sub-list for method input_type
+	16, // [16:16] is the sub-list for extension type_name
+	16, // [16:16] is the sub-list for extension extendee
+	0,  // [0:16] is the sub-list for field type_name
+}
+
+func init() { file_timeledger_sim_v1_sim_proto_init() }
+func file_timeledger_sim_v1_sim_proto_init() {
+	if File_timeledger_sim_v1_sim_proto != nil {
+		return
+	}
+	file_timeledger_sim_v1_sim_proto_msgTypes[25].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_timeledger_sim_v1_sim_proto_rawDesc), len(file_timeledger_sim_v1_sim_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   26,
+			NumExtensions: 0,
+			NumServices:   4,
+		},
+		GoTypes:           file_timeledger_sim_v1_sim_proto_goTypes,
+		DependencyIndexes: file_timeledger_sim_v1_sim_proto_depIdxs,
+		MessageInfos:      file_timeledger_sim_v1_sim_proto_msgTypes,
+	}.Build()
+	File_timeledger_sim_v1_sim_proto = out.File
+	file_timeledger_sim_v1_sim_proto_goTypes = nil
+	file_timeledger_sim_v1_sim_proto_depIdxs = nil
+}
@@ -0,0 +1,101 @@
+package grpcapi
+
+import (
+  "context"
+
+  "google.golang.org/grpc/codes"
+  "google.golang.org/protobuf/types/known/structpb"
+  "google.golang.org/protobuf/types/known/timestamppb"
+
+  "time-ledger-sim/go/internal/grpcapi/simv1"
+  "time-ledger-sim/go/internal/ledger"
+)
+
+// --- zones ---
+
+func (s *Server) ListZones(ctx context.Context, _ *simv1.ListZonesRequest) (*simv1.ListZonesResponse, error) {
+  zones, err := s.led.ListZones(ctx, nil)
+  if err != nil { return nil, toStatus(err, codes.Internal) }
+  out := &simv1.ListZonesResponse{}
+  for _, z := range zones { out.Zones = append(out.Zones, zonePB(z)) }
+  return out, nil
+}
+
+func (s *Server) SetZoneStatus(ctx context.Context, req *simv1.SetZoneStatusRequest) (*simv1.Zone, error) {
+  actor := actorFor(ctx, req.GetActor())
+  if req.GetZoneId() == "" || req.GetStatus() == "" || actor == "" { return nil, invalid("missing fields") }
+  code := reasonCodeFor(ctx)
+  if s.led.ZoneStatusNeedsApproval(req.GetStatus()) {
+    ap, err := s.led.RequestZoneStatusApproval(ctx, req.GetZoneId(), req.GetStatus(), actor, code, req.GetReason())
+    if err != nil { return nil, toStatus(err, codes.Internal) }
+    return nil, pendingApproval(ap)
+  }
+  z, err := s.led.SetZoneStatus(ctx, req.GetZoneId(), req.GetStatus(), actor, code, req.GetReason())
+  if err != nil { return nil, toStatus(err, codes.Internal) }
+  return zonePB(*z), nil
+}
+
+func zonePB(z ledger.Zone) *simv1.Zone {
+  return &simv1.Zone{Id: z.ID, Name: z.Name, Status: z.Status, UpdatedAt: timestamppb.New(z.UpdatedAt)}
+}
+
+// --- incidents ---
+
+func (s *Server) ListIncidents(ctx context.Context, req *simv1.ListIncidentsRequest) (*simv1.ListIncidentsResponse, error) {
+  var rows []ledger.Incident
+  var err error
+  if req.GetZoneId() != "" {
+    rows, err = s.led.ListIncidentsByZone(ctx, req.GetZoneId())
+  } else {
+    rows, err = s.led.ListRecentIncidents(ctx, int(req.GetLimit()), nil)
+  }
+  if err != nil { return nil, toStatus(err, codes.Internal) }
+  out := &simv1.ListIncidentsResponse{}
+  for _, inc := range rows {
+    pb, err := incidentPB(inc)
+    if err != nil { return nil, toStatus(err, codes.Internal) }
+    out.Incidents = append(out.Incidents, pb)
+  }
+  return out, nil
+}
+
+func (s *Server) GetIncident(ctx context.Context, req *simv1.GetIncidentRequest) (*simv1.Incident, error) {
+  if req.GetId() == "" { return nil, invalid("missing id") }
+  inc, err := s.led.GetIncident(ctx, req.GetId())
+  if err != nil { return nil, toStatus(err, codes.NotFound) }
+  pb, err := incidentPB(*inc)
+  if err != nil { return nil, toStatus(err, codes.Internal) }
+  return pb, nil
+}
+
+func (s *Server) ApplyIncidentAction(ctx context.Context, req *simv1.ApplyIncidentActionRequest) (*simv1.Incident, error) {
+  actor := actorFor(ctx, req.GetActor())
+  if req.GetIncidentId() == "" || actor == "" || req.GetAction() == "" { return nil, invalid("missing fields") }
+  inc, err := s.led.ApplyIncidentAction(ctx, req.GetIncidentId(), ledger.IncidentAction{
+    Action: req.GetAction(),
+    Assignee: req.GetAssignee(),
+    Note: req.GetNote(),
+    Actor: actor,
+    Reason: req.GetReason(),
+  })
+  if err != nil { return nil, toStatus(err, codes.FailedPrecondition) }
+  pb, err := incidentPB(*inc)
+  if err != nil { return nil, toStatus(err, codes.Internal) }
+  return pb, nil
+}
+
+func incidentPB(inc ledger.Incident) (*simv1.Incident, error) {
+  details, err := structpb.NewStruct(inc.Details)
+  if err != nil { return nil, err }
+  out := &simv1.Incident{
+    Id: inc.ID,
+    ZoneId: inc.ZoneID,
+    Severity: inc.Severity,
+    Status: inc.Status,
+    Title: inc.Title,
+    Details: details,
+    DetectedAt: timestamppb.New(inc.DetectedAt),
+  }
+  if inc.RelatedTxnID != nil { out.RelatedTxnId = *inc.RelatedTxnID }
+  return out, nil
+}
@@ -0,0 +1,128 @@
+// Package leader elects one replica per role with Postgres session advisory
+// locks, so singleton loops (outbox publisher, control scheduler) run once
+// across a horizontally scaled deployment.
+//
+// The lock lives on a pooled connection held for as long as the replica
+// leads. If that connection breaks, Postgres drops the lock with the session
+// and this replica stops leading at its next health check, so a new leader
+// can overlap the old one by at most one check interval; the loops it guards
+// must tolerate that (outbox publishes are deduplicated by Nats-Msg-Id).
+package leader
+
+import (
+  "context"
+  "hash/fnv"
+  "log/slog"
+  "sync/atomic"
+  "time"
+
+  "github.com/jackc/pgx/v5/pgxpool"
+
+  "time-ledger-sim/go/internal/metrics"
+)
+
+// DefaultInterval is how often a follower retries the lock and a leader checks its session.
+const DefaultInterval = 2 * time.Second
+
+// Elector campaigns for one role.
+type Elector struct {
+  db *pgxpool.Pool
+  role string
+  key int64
+  interval time.Duration
+  log *slog.Logger
+  leader atomic.Bool
+}
+
+func New(db *pgxpool.Pool, role string, log *slog.Logger) *Elector {
+  return &Elector{db: db, role: role, key: LockKey(role), interval: DefaultInterval, log: log.With("role", role)}
+}
+
+// LockKey is the advisory lock key for a role: a hash of the name, namespaced
+// so it cannot collide with other advisory lock users by accident.
+func LockKey(role string) int64 {
+  h := fnv.New64a()
+  _, _ = h.Write([]byte("time-ledger-sim/leader/" + role))
+  return int64(h.Sum64())
+}
+
+// IsLeader reports whether this replica currently leads the role.
+func (e *Elector) IsLeader() bool { return e.leader.Load() }
+
+// Run campaigns until ctx is done. Each time this replica wins it runs lead
+// with a context that is cancelled when leadership is lost; it waits for lead
+// to return before releasing the lock.
+func (e *Elector) Run(ctx context.Context, lead func(ctx context.Context)) {
+  metrics.Leader.WithLabelValues(e.role).Set(0)
+  t := time.NewTicker(e.interval)
+  defer t.Stop()
+  for {
+    if conn := e.tryAcquire(ctx); conn != nil {
+      e.hold(ctx, conn, lead)
+    }
+    select {
+    case <-ctx.Done():
+      return
+    case <-t.C:
+    }
+  }
+}
+
+// tryAcquire returns the connection holding the lock, or nil.
+func (e *Elector) tryAcquire(ctx context.Context) *pgxpool.Conn {
+  conn, err := e.db.Acquire(ctx)
+  if err != nil { return nil }
+  var ok bool
+  if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, e.key).Scan(&ok); err != nil || !ok {
+    conn.Release()
+    return nil
+  }
+  return conn
+}
+
+func (e *Elector) hold(ctx context.Context, conn *pgxpool.Conn, lead func(ctx context.Context)) {
+  e.setLeader(true)
+  leadCtx, stop := context.WithCancel(ctx)
+  done := make(chan struct{})
+  go func() { defer close(done); lead(leadCtx) }()
+
+  t := time.NewTicker(e.interval)
+  defer t.Stop()
+  healthy := true
+  for healthy {
+    select {
+    case <-ctx.Done():
+      healthy = false
+    case <-done:
+      healthy = false
+    case <-t.C:
+      pctx, cancel := context.WithTimeout(ctx, e.interval)
+      if err := conn.Ping(pctx); err != nil {
+        e.log.Warn("leader session lost", "err", err.Error())
+        healthy = false
+      }
+      cancel()
+    }
+  }
+  stop()
+  <-done
+  e.setLeader(false)
+
+  // unlock explicitly when the session is fine; otherwise closing the
+  // connection ends the session, which releases the lock
+  uctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), e.interval)
+  defer cancel()
+  if _, err := conn.Exec(uctx, `SELECT pg_advisory_unlock($1)`, e.key); err != nil {
+    _ = conn.Conn().Close(uctx)
+  }
+  conn.Release()
+}
+
+func (e *Elector) setLeader(v bool) {
+  e.leader.Store(v)
+  event, g := "lost", 0.0
+  if v { event, g = "acquired", 1 }
+  metrics.Leader.WithLabelValues(e.role).Set(g)
+  metrics.LeaderChanges.WithLabelValues(e.role, event).Inc()
+  e.log.Info("leadership " + event)
+}
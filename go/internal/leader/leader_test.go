@@ -0,0 +1,81 @@
+package leader
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"time-ledger-sim/go/internal/store/storetest"
+)
+
+func TestLockKeyPerRole(t *testing.T) {
+	if LockKey("outbox-publisher") != LockKey("outbox-publisher") {
+		t.Fatal("lock key not stable")
+	}
+	if LockKey("outbox-publisher") == LockKey("control-scheduler") {
+		t.Fatal("roles share a lock key")
+	}
+}
+
+func TestOneLeaderAndFailover(t *testing.T) {
+	db := storetest.Open(t)
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var mu sync.Mutex
+	running := map[string]int{}
+	lead := func(name string) func(context.Context) {
+		return func(ctx context.Context) {
+			mu.Lock()
+			running[name]++
+			mu.Unlock()
+			<-ctx.Done()
+			mu.Lock()
+			running[name]--
+			mu.Unlock()
+		}
+	}
+
+	start := func(name string) (*Elector, context.CancelFunc, chan struct{}) {
+		e := New(db, "test", log)
+		e.interval = 20 * time.Millisecond
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() { defer close(done); e.Run(ctx, lead(name)) }()
+		return e, cancel, done
+	}
+	waitFor := func(what string, cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s", what)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	a, stopA, doneA := start("a")
+	waitFor("a to lead", a.IsLeader)
+	b, stopB, doneB := start("b")
+	defer func() { stopB(); <-doneB }()
+
+	time.Sleep(100 * time.Millisecond)
+	if b.IsLeader() {
+		t.Fatal("two leaders")
+	}
+
+	stopA()
+	<-doneA
+	if a.IsLeader() {
+		t.Fatal("a still leads after stopping")
+	}
+	waitFor("b to take over", b.IsLeader)
+	waitFor("b's loop to run", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return running["a"] == 0 && running["b"] == 1
+	})
+}
@@ -0,0 +1,170 @@
+package ledger
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+)
+
+var (
+  ErrAccountBlocked = errors.New("account blocked")
+  ErrAccountNotFound = errors.New("account not found")
+  ErrAccountExists = errors.New("account already exists")
+)
+
+func IsAccountBlocked(err error) bool { return errors.Is(err, ErrAccountBlocked) }
+func IsAccountNotFound(err error) bool { return errors.Is(err, ErrAccountNotFound) }
+func IsAccountExists(err error) bool { return errors.Is(err, ErrAccountExists) }
+
+type AccountControls struct {
+  AccountID string `json:"account_id"`
+  DebitsBlocked bool `json:"debits_blocked"`
+  CreditsBlocked bool `json:"credits_blocked"`
+  Throttle int `json:"throttle"`
+  UpdatedAt time.Time `json:"updated_at"`
+}
+
+type SetAccountControlsInput struct {
+  DebitsBlocked bool
+  CreditsBlocked bool
+  Throttle int
+  Actor string
+  Reason string
+}
+
+const accountControlsCols = `account_id, debits_blocked, credits_blocked, throttle, updated_at`
+
+func scanAccountControls(row pgx.Row) (*AccountControls, error) {
+  var c AccountControls
+  if err := row.Scan(&c.AccountID, &c.DebitsBlocked, &c.CreditsBlocked, &c.Throttle, &c.UpdatedAt); err != nil {
+    return nil, err
+  }
+  return &c, nil
+}
+
+// GetAccountControls returns the account's controls, or permissive defaults if none are set.
+func (l *Ledger) GetAccountControls(ctx context.Context, accountID string) (*AccountControls, error) {
+  c, err := scanAccountControls(l.db.QueryRow(ctx, `SELECT `+accountControlsCols+` FROM account_controls WHERE account_id=$1`, accountID))
+  if errors.Is(err, pgx.ErrNoRows) {
+    return &AccountControls{AccountID: accountID, Throttle: 100}, nil
+  }
+  return c, err
+}
+
+func (l *Ledger) SetAccountControls(ctx context.Context, accountID string, in SetAccountControlsInput) (*AccountControls, error) {
+  if in.Throttle < 0 || in.Throttle > 100 {
+    return nil, fmt.Errorf("invalid throttle")
+  }
+
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  if err := l.checkActors(ctx, tx, in.Actor); err != nil { return nil, err }
+
+  c, err := scanAccountControls(tx.QueryRow(ctx, `
+    INSERT INTO account_controls(account_id,debits_blocked,credits_blocked,throttle,updated_at)
+    VALUES($1,$2,$3,$4,now())
+    ON CONFLICT (account_id) DO UPDATE
+      SET debits_blocked=EXCLUDED.debits_blocked,
+          credits_blocked=EXCLUDED.credits_blocked,
+          throttle=EXCLUDED.throttle,
+          updated_at=now()
+    RETURNING `+accountControlsCols,
+    accountID, in.DebitsBlocked, in.CreditsBlocked, in.Throttle))
+  if err != nil { return nil, err }
+
+  err = l.audit(ctx, pgQueries{tx}, AuditRecord{
+    Actor: in.Actor, Action: "SET_ACCOUNT_CONTROLS", TargetType: "account", TargetID: accountID, Reason: in.Reason,
+    Details: map[string]any{"debits_blocked": in.DebitsBlocked, "credits_blocked": in.CreditsBlocked, "throttle": in.Throttle},
+  })
+  if err != nil { return nil, err }
+
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  return c, nil
+}
+
+// checkAccountControls enforces per-account controls for both legs of a transfer.
+// Account blocks always reject (never spool): spool replay bypasses gating and
+// would otherwise release the contained account's transfers.
+func (l *Ledger) checkAccountControls(ctx context.Context, q Queries, in CreateTransferInput) error {
+  controls, err := q.AccountControls(ctx, in.FromAccount, in.ToAccount)
+  if err != nil { return err }
+
+  for _, c := range controls {
+    if c.AccountID == in.FromAccount && c.DebitsBlocked {
+      return fmt.Errorf("%w: debits blocked for %s", ErrAccountBlocked, c.AccountID)
+    }
+    if c.AccountID == in.ToAccount && c.CreditsBlocked {
+      return fmt.Errorf("%w: credits blocked for %s", ErrAccountBlocked, c.AccountID)
+    }
+    if c.Throttle < 100 && (c.Throttle <= 0 || l.hashPercent(c.AccountID+":"+in.RequestID) >= c.Throttle) {
+      return fmt.Errorf("%w: throttled for %s", ErrAccountBlocked, c.AccountID)
+    }
+  }
+  return nil
+}
+
+// SetStrictAccounts stops transfers and spool replays from creating the
+// accounts they name: unknown accounts fail with ErrAccountNotFound and must
+// be created first (CreateAccount or POST /v1/sim/seed).
+func (l *Ledger) SetStrictAccounts(on bool) { l.strictAccounts.Store(on) }
+
+func (l *Ledger) StrictAccounts() bool { return l.strictAccounts.Load() }
+
+// checkAccountsExist returns ErrAccountNotFound for the first of the
+// transfer's accounts that does not exist, in strict mode.
+func (l *Ledger) checkAccountsExist(ctx context.Context, q Queries, in CreateTransferInput) error {
+  if !l.strictAccounts.Load() { return nil }
+  for _, id := range []string{in.FromAccount, in.ToAccount} {
+    zone, err := q.AccountZone(ctx, id)
+    if err != nil { return err }
+    if zone == "" { return fmt.Errorf("%w: %s", ErrAccountNotFound, id) }
+  }
+  return nil
+}
+
+type Account struct {
+  ID string `json:"id"`
+  ZoneID string `json:"zone_id"`
+  CreatedAt time.Time `json:"created_at"`
+}
+
+type CreateAccountInput struct {
+  AccountID string
+  ZoneID string
+  Actor string
+  Reason string
+}
+
+// CreateAccount opens an account in an active zone, audited as CREATE_ACCOUNT.
+func (l *Ledger) CreateAccount(ctx context.Context, in CreateAccountInput) (*Account, error) {
+  if in.AccountID == "" || len(in.AccountID) > 128 { return nil, fmt.Errorf("account_id must be 1-128 bytes") }
+
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  if _, err := (pgQueries{tx}).ZoneStatus(ctx, in.ZoneID); err != nil { return nil, err }
+  if err := l.checkActors(ctx, tx, in.Actor); err != nil { return nil, err }
+
+  a := Account{ID: in.AccountID, ZoneID: in.ZoneID}
+  err = tx.QueryRow(ctx, `
+    INSERT INTO accounts(id, zone_id) VALUES($1,$2) ON CONFLICT (id) DO NOTHING
+    RETURNING created_at
+  `, in.AccountID, in.ZoneID).Scan(&a.CreatedAt)
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrAccountExists }
+  if err != nil { return nil, err }
+
+  err = l.audit(ctx, pgQueries{tx}, AuditRecord{
+    Actor: in.Actor, Action: "CREATE_ACCOUNT", TargetType: "account", TargetID: in.AccountID, Reason: in.Reason,
+    Details: map[string]any{"zone_id": in.ZoneID},
+  })
+  if err != nil { return nil, err }
+
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  return &a, nil
+}
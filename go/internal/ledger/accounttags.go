@@ -0,0 +1,148 @@
+package ledger
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "strings"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+)
+
+var ErrAccountTagNotFound = errors.New("account tag not found")
+
+func IsAccountTagNotFound(err error) bool { return errors.Is(err, ErrAccountTagNotFound) }
+
+// AccountTags is an account's tags, sorted.
+type AccountTags struct {
+  AccountID string `json:"account_id"`
+  ZoneID string `json:"zone_id"`
+  Tags []string `json:"tags"`
+}
+
+// GetAccountTags returns an existing account's tags.
+func (l *Ledger) GetAccountTags(ctx context.Context, accountID string) (*AccountTags, error) {
+  return getAccountTags(ctx, l.db, accountID)
+}
+
+func getAccountTags(ctx context.Context, q querier, accountID string) (*AccountTags, error) {
+  t := AccountTags{AccountID: accountID}
+  err := q.QueryRow(ctx, `
+    SELECT a.zone_id, COALESCE((SELECT array_agg(tag ORDER BY tag) FROM account_tags WHERE account_id=a.id), '{}')
+    FROM accounts a WHERE a.id=$1
+  `, accountID).Scan(&t.ZoneID, &t.Tags)
+  if errors.Is(err, pgx.ErrNoRows) { return nil, fmt.Errorf("%w: %s", ErrAccountNotFound, accountID) }
+  if err != nil { return nil, err }
+  return &t, nil
+}
+
+type TagAccountInput struct {
+  Tags []string
+  Actor string
+  Reason string
+}
+
+// TagAccount adds tags to an account; tags it already has are kept. An
+// account carries at most maxTags tags.
+func (l *Ledger) TagAccount(ctx context.Context, accountID string, in TagAccountInput) (*AccountTags, error) {
+  tags, err := normalizeTags(in.Tags)
+  if err != nil { return nil, err }
+  if len(tags) == 0 { return nil, fmt.Errorf("tags required") }
+
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  if err := l.checkActors(ctx, tx, in.Actor); err != nil { return nil, err }
+  if _, err := tx.Exec(ctx, `SELECT 1 FROM accounts WHERE id=$1 FOR UPDATE`, accountID); err != nil { return nil, err }
+  if _, err := tx.Exec(ctx, `
+    INSERT INTO account_tags(account_id, tag, actor)
+    SELECT a.id, t, $3 FROM accounts a, unnest($2::text[]) t WHERE a.id=$1
+    ON CONFLICT DO NOTHING
+  `, accountID, tags, in.Actor); err != nil { return nil, err }
+  t, err := getAccountTags(ctx, tx, accountID)
+  if err != nil { return nil, err }
+  if len(t.Tags) > maxTags { return nil, fmt.Errorf("more than %d tags on %s", maxTags, accountID) }
+
+  err = l.audit(ctx, pgQueries{tx}, AuditRecord{
+    Actor: in.Actor, Action: "TAG_ACCOUNT", TargetType: "account", TargetID: accountID, Reason: in.Reason,
+    Details: map[string]any{"tags": tags},
+  })
+  if err != nil { return nil, err }
+
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  return t, nil
+}
+
+// UntagAccount removes one tag from an account.
+func (l *Ledger) UntagAccount(ctx context.Context, accountID, tag, actor, reason string) (*AccountTags, error) {
+  tag = strings.ToLower(strings.TrimSpace(tag))
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  if err := l.checkActors(ctx, tx, actor); err != nil { return nil, err }
+  ct, err := tx.Exec(ctx, `DELETE FROM account_tags WHERE account_id=$1 AND tag=$2`, accountID, tag)
+  if err != nil { return nil, err }
+  if ct.RowsAffected() == 0 { return nil, fmt.Errorf("%w: %s on %s", ErrAccountTagNotFound, tag, accountID) }
+  t, err := getAccountTags(ctx, tx, accountID)
+  if err != nil { return nil, err }
+
+  err = l.audit(ctx, pgQueries{tx}, AuditRecord{
+    Actor: actor, Action: "UNTAG_ACCOUNT", TargetType: "account", TargetID: accountID, Reason: reason,
+    Details: map[string]any{"tag": tag},
+  })
+  if err != nil { return nil, err }
+
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  return t, nil
+}
+
+// AccountTagStat aggregates the accounts carrying one tag. Sent and received
+// cover the window; a transfer between two accounts with the tag counts on
+// both sides.
+type AccountTagStat struct {
+  Tag string `json:"tag"`
+  Accounts int64 `json:"accounts"`
+  BalanceUnits int64 `json:"balance_units"` // now
+  Transfers int64 `json:"transfers"` // postings to the accounts in the window
+  SentUnits int64 `json:"sent_units"`
+  ReceivedUnits int64 `json:"received_units"`
+}
+
+type AccountTagStats struct {
+  Since time.Time `json:"since"`
+  Tags []AccountTagStat `json:"tags"`
+}
+
+// GetAccountTagStats aggregates balances and postings since since for every
+// tag, on the read replica.
+func (l *Ledger) GetAccountTagStats(ctx context.Context, since time.Time) (*AccountTagStats, error) {
+  st := AccountTagStats{Since: since, Tags: []AccountTagStat{}}
+  rows, err := l.ro.Query(ctx, `
+    SELECT g.tag, COUNT(*),
+      COALESCE(SUM(b.balance_units),0)::bigint,
+      COALESCE(SUM(p.n),0)::bigint,
+      COALESCE(SUM(p.sent),0)::bigint,
+      COALESCE(SUM(p.received),0)::bigint
+    FROM account_tags g
+    LEFT JOIN balances b ON b.account_id=g.account_id
+    LEFT JOIN LATERAL (
+      SELECT COUNT(*) AS n,
+        SUM(amount_units) FILTER (WHERE direction='DEBIT') AS sent,
+        SUM(amount_units) FILTER (WHERE direction='CREDIT') AS received
+      FROM postings WHERE account_id=g.account_id AND created_at >= $1
+    ) p ON true
+    GROUP BY g.tag
+    ORDER BY g.tag
+  `, since)
+  if err != nil { return nil, err }
+  defer rows.Close()
+  for rows.Next() {
+    var s AccountTagStat
+    if err := rows.Scan(&s.Tag, &s.Accounts, &s.BalanceUnits, &s.Transfers, &s.SentUnits, &s.ReceivedUnits); err != nil { return nil, err }
+    st.Tags = append(st.Tags, s)
+  }
+  return &st, rows.Err()
+}
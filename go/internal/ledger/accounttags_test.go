@@ -0,0 +1,76 @@
+package ledger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"time-ledger-sim/go/internal/store/storetest"
+)
+
+func TestAccountTags(t *testing.T) {
+	db := storetest.Open(t)
+	ctx := context.Background()
+	l := New(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	start := time.Now().Add(-time.Minute)
+
+	suffix := uuid.NewString()[:8]
+	merchant, consumer, cohort := "tag-m-"+suffix, "tag-c-"+suffix, "cohort-"+suffix
+	txn, _, err := l.CreateTransfer(ctx, CreateTransferInput{
+		RequestID: "tag-" + suffix, PayloadHash: "h", FromAccount: consumer, ToAccount: merchant, AmountUnits: 25, ZoneID: "zone-eu",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tags, err := l.TagAccount(ctx, merchant, TagAccountInput{Tags: []string{"Merchant", cohort, "merchant"}, Actor: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tags.Tags) != 2 || tags.ZoneID != "zone-eu" {
+		t.Fatalf("tags = %+v", tags)
+	}
+	if _, err := l.TagAccount(ctx, "tag-nobody-"+suffix, TagAccountInput{Tags: []string{cohort}, Actor: "test"}); !IsAccountNotFound(err) {
+		t.Fatalf("unknown account: err = %v", err)
+	}
+
+	page, err := l.ListBalances(ctx, BalanceFilter{AccountTag: cohort})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bals := page.Balances; len(bals) != 1 || bals[0].AccountID != merchant || bals[0].BalanceUnits != 25 {
+		t.Fatalf("balances = %+v", bals)
+	}
+	txns, err := l.ListTransactions(ctx, TransactionFilter{AccountTag: cohort})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txns) != 1 || txns[0].ID != txn.ID {
+		t.Fatalf("transactions = %+v", txns)
+	}
+
+	st, err := l.GetAccountTagStats(ctx, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	for _, s := range st.Tags {
+		if s.Tag == cohort {
+			found = s.Accounts == 1 && s.ReceivedUnits == 25 && s.SentUnits == 0 && s.Transfers == 1
+		}
+	}
+	if !found {
+		t.Fatalf("stats = %+v", st.Tags)
+	}
+
+	if _, err := l.UntagAccount(ctx, merchant, cohort, "test", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.UntagAccount(ctx, merchant, cohort, "test", ""); !IsAccountTagNotFound(err) {
+		t.Fatalf("second untag: err = %v", err)
+	}
+}
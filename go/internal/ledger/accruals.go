@@ -0,0 +1,274 @@
+package ledger
+
+import (
+  "context"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+
+  "time-ledger-sim/go/internal/util"
+)
+
+var ErrAccrualRuleNotFound = errors.New("accrual rule not found")
+
+func IsAccrualRuleNotFound(err error) bool { return errors.Is(err, ErrAccrualRuleNotFound) }
+
+// Accrual kinds: INTEREST pays accounts from the sink, DECAY moves part of
+// their balance to it.
+const (
+  AccrualInterest = "INTEREST"
+  AccrualDecay = "DECAY"
+)
+
+const (
+  minAccrualPeriod = time.Minute
+  maxAccrualPeriod = 366 * 24 * time.Hour
+)
+
+// accrualSinkAccount is a zone's default counterparty for accrual postings.
+func accrualSinkAccount(zoneID string) string { return zoneID + "-accrual" }
+
+type AccrualRule struct {
+  ID string `json:"id"`
+  ZoneID string `json:"zone_id"`
+  Kind string `json:"kind"`
+  RateBps int `json:"rate_bps"` // per period, in basis points of the balance
+  PeriodSeconds int64 `json:"period_seconds"`
+  AccountTag *string `json:"account_tag"`
+  MinBalanceUnits int64 `json:"min_balance_units"`
+  SinkAccount string `json:"sink_account"`
+  Status string `json:"status"` // ACTIVE|DISABLED
+  Runs int64 `json:"runs"`
+  NextRunAt time.Time `json:"next_run_at"`
+  LastRunAt *time.Time `json:"last_run_at"`
+  LastRunUnits int64 `json:"last_run_units"`
+  Actor string `json:"actor"`
+  Reason *string `json:"reason"`
+  CreatedAt time.Time `json:"created_at"`
+}
+
+const accrualRuleCols = `id::text, zone_id, kind, rate_bps, period_seconds, account_tag, min_balance_units, sink_account, status,
+  runs, next_run_at, last_run_at, last_run_units, actor, reason, created_at`
+
+func scanAccrualRule(row pgx.Row) (*AccrualRule, error) {
+  var r AccrualRule
+  err := row.Scan(&r.ID, &r.ZoneID, &r.Kind, &r.RateBps, &r.PeriodSeconds, &r.AccountTag, &r.MinBalanceUnits, &r.SinkAccount, &r.Status,
+    &r.Runs, &r.NextRunAt, &r.LastRunAt, &r.LastRunUnits, &r.Actor, &r.Reason, &r.CreatedAt)
+  if err != nil { return nil, err }
+  return &r, nil
+}
+
+type CreateAccrualRuleInput struct {
+  ZoneID string
+  Kind string
+  RateBps int
+  Period time.Duration
+  AccountTag string // only accounts with this tag; empty: every account in the zone
+  MinBalanceUnits int64 // accounts below this are skipped; at least 1, so negative balances never accrue
+  SinkAccount string // default <zone>-accrual
+  StartAt time.Time // first run on the sim clock; default one period from now
+  Actor string
+  Reason string
+}
+
+func (in *CreateAccrualRuleInput) normalize(now time.Time) error {
+  if in.Kind != AccrualInterest && in.Kind != AccrualDecay { return fmt.Errorf("kind must be INTEREST or DECAY") }
+  if in.RateBps < 1 || in.RateBps > 10000 { return fmt.Errorf("rate_bps must be 1-10000") }
+  if in.Period < minAccrualPeriod || in.Period > maxAccrualPeriod { return fmt.Errorf("period must be 1m to 8784h") }
+  if in.Period%time.Second != 0 { return fmt.Errorf("period must be whole seconds") }
+  if in.AccountTag != "" {
+    tags, err := normalizeTags([]string{in.AccountTag})
+    if err != nil { return err }
+    in.AccountTag = tags[0]
+  }
+  if in.MinBalanceUnits < 1 { in.MinBalanceUnits = 1 }
+  if in.SinkAccount == "" { in.SinkAccount = accrualSinkAccount(in.ZoneID) }
+  if len(in.SinkAccount) > 128 { return fmt.Errorf("sink_account must be 1-128 bytes") }
+  if in.StartAt.IsZero() { in.StartAt = now.Add(in.Period) }
+  return nil
+}
+
+// CreateAccrualRule adds a rule that posts interest or decay for a zone's
+// accounts every period, starting at StartAt. The sink account is created in
+// the zone if needed; one in another zone is rejected.
+func (l *Ledger) CreateAccrualRule(ctx context.Context, in CreateAccrualRuleInput) (*AccrualRule, error) {
+  if err := in.normalize(l.clock.Now()); err != nil { return nil, err }
+
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+  q := pgQueries{tx}
+
+  if _, err := q.ZoneStatus(ctx, in.ZoneID); err != nil { return nil, err }
+  if err := l.checkActors(ctx, tx, in.Actor); err != nil { return nil, err }
+  sinkZone, err := q.AccountZone(ctx, in.SinkAccount)
+  if err != nil { return nil, err }
+  if sinkZone != "" && sinkZone != in.ZoneID { return nil, fmt.Errorf("sink_account %s is in zone %s", in.SinkAccount, sinkZone) }
+  if err := q.EnsureAccount(ctx, in.SinkAccount, in.ZoneID); err != nil { return nil, err }
+
+  r, err := scanAccrualRule(tx.QueryRow(ctx, `
+    INSERT INTO accrual_rules(zone_id,kind,rate_bps,period_seconds,account_tag,min_balance_units,sink_account,next_run_at,actor,reason)
+    VALUES($1,$2,$3,$4,NULLIF($5,''),$6,$7,$8,$9,NULLIF($10,''))
+    RETURNING `+accrualRuleCols,
+    in.ZoneID, in.Kind, in.RateBps, int64(in.Period/time.Second), in.AccountTag, in.MinBalanceUnits, in.SinkAccount, in.StartAt, in.Actor, in.Reason))
+  if err != nil { return nil, err }
+
+  err = l.audit(ctx, q, AuditRecord{
+    Actor: in.Actor, Action: "CREATE_ACCRUAL_RULE", TargetType: "zone", TargetID: in.ZoneID, Reason: in.Reason,
+    Details: asDetails(r),
+  })
+  if err != nil { return nil, err }
+
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  return r, nil
+}
+
+// ListAccrualRules lists rules, optionally for one zone, active ones unless all.
+func (l *Ledger) ListAccrualRules(ctx context.Context, zoneID string, all bool) ([]AccrualRule, error) {
+  rows, err := l.db.Query(ctx, `
+    SELECT `+accrualRuleCols+`
+    FROM accrual_rules
+    WHERE ($1 = '' OR zone_id = $1) AND ($2 OR status = 'ACTIVE')
+    ORDER BY created_at, id
+    LIMIT 500
+  `, zoneID, all)
+  if err != nil { return nil, err }
+  defer rows.Close()
+
+  out := []AccrualRule{}
+  for rows.Next() {
+    r, err := scanAccrualRule(rows)
+    if err != nil { return nil, err }
+    out = append(out, *r)
+  }
+  return out, rows.Err()
+}
+
+func (l *Ledger) DisableAccrualRule(ctx context.Context, id, actor, reason string) (*AccrualRule, error) {
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  if err := l.checkActors(ctx, tx, actor); err != nil { return nil, err }
+
+  r, err := scanAccrualRule(tx.QueryRow(ctx, `
+    UPDATE accrual_rules SET status='DISABLED'
+    WHERE id::text=$1 AND status='ACTIVE'
+    RETURNING `+accrualRuleCols, id))
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrAccrualRuleNotFound }
+  if err != nil { return nil, err }
+
+  err = l.audit(ctx, pgQueries{tx}, AuditRecord{
+    Actor: actor, Action: "DISABLE_ACCRUAL_RULE", TargetType: "zone", TargetID: r.ZoneID, Reason: reason,
+    Details: map[string]any{"rule_id": r.ID},
+  })
+  if err != nil { return nil, err }
+
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  return r, nil
+}
+
+// ApplyDueAccruals runs up to limit due accrual periods, each in its own
+// transaction. A rule far behind the sim clock is caught up one period per
+// run, so interest compounds as it would have.
+func (l *Ledger) ApplyDueAccruals(ctx context.Context, limit int) (int, error) {
+  applied := 0
+  for i := 0; i < limit; i++ {
+    ok, err := l.applyNextDueAccrual(ctx)
+    if err != nil { return applied, err }
+    if !ok { break }
+    applied++
+  }
+  return applied, nil
+}
+
+// applyNextDueAccrual claims the most overdue rule (SKIP LOCKED, so replicas
+// never run a period twice) and posts one transfer per eligible account,
+// dated at the period's due time. Request IDs derive from the rule, run and
+// account, and zone controls are bypassed, as for the seed.
+func (l *Ledger) applyNextDueAccrual(ctx context.Context) (bool, error) {
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return false, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+  q := pgQueries{tx}
+
+  r, err := scanAccrualRule(tx.QueryRow(ctx, `
+    SELECT `+accrualRuleCols+`
+    FROM accrual_rules
+    WHERE status='ACTIVE' AND next_run_at <= $1
+    ORDER BY next_run_at ASC
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+  `, l.clock.Now()))
+  if errors.Is(err, pgx.ErrNoRows) { return false, nil }
+  if err != nil { return false, err }
+
+  run := r.Runs + 1
+  tag := ""
+  if r.AccountTag != nil { tag = *r.AccountTag }
+  rows, err := tx.Query(ctx, `
+    SELECT b.account_id, floor(b.balance_units::numeric * $3::integer / 10000)::bigint
+    FROM balances b
+    JOIN accounts a ON a.id = b.account_id
+    WHERE a.zone_id = $1 AND b.account_id <> $2 AND b.balance_units >= $4
+      AND ($5 = '' OR EXISTS (SELECT 1 FROM account_tags t WHERE t.account_id = b.account_id AND t.tag = $5))
+    ORDER BY b.account_id
+  `, r.ZoneID, r.SinkAccount, r.RateBps, r.MinBalanceUnits, tag)
+  if err != nil { return false, err }
+  type accrual struct { account string; units int64 }
+  var due []accrual
+  for rows.Next() {
+    var a accrual
+    if err := rows.Scan(&a.account, &a.units); err != nil { rows.Close(); return false, err }
+    if a.units > 0 { due = append(due, a) }
+  }
+  rows.Close()
+  if err := rows.Err(); err != nil { return false, err }
+
+  var accounts, units int64
+  if len(due) > 0 {
+    skewMs, err := zoneClockSkew(ctx, q, r.ZoneID)
+    if err != nil { return false, err }
+    if err := q.EnsureAccount(ctx, r.SinkAccount, r.ZoneID); err != nil { return false, err }
+    meta := map[string]any{"accrual_rule_id": r.ID, "accrual_kind": r.Kind, "accrual_run": run}
+    metaBytes, _ := json.Marshal(meta)
+    for _, a := range due {
+      t := CreateTransferInput{
+        RequestID: fmt.Sprintf("accrual-%s-%d-%s", r.ID, run, a.account),
+        FromAccount: a.account, ToAccount: r.SinkAccount,
+        AmountUnits: a.units, ZoneID: r.ZoneID, Type: TxnTypeAccrual, Metadata: meta, At: r.NextRunAt,
+      }
+      if r.Kind == AccrualInterest { t.FromAccount, t.ToAccount = r.SinkAccount, a.account }
+      t.PayloadHash, err = util.HashCanonicalJSON(map[string]any{
+        "request_id": t.RequestID, "from_account": t.FromAccount, "to_account": t.ToAccount,
+        "amount_units": t.AmountUnits, "zone_id": t.ZoneID, "metadata": t.Metadata,
+      })
+      if err != nil { return false, err }
+      _, err := l.applyTransfer(ctx, q, t, metaBytes, skewMs)
+      if errors.Is(err, ErrRequestExists) { continue }
+      if err != nil { return false, err }
+      accounts++
+      units += a.units
+    }
+  }
+
+  _, err = tx.Exec(ctx, `
+    UPDATE accrual_rules
+    SET runs=$2, last_run_at=next_run_at, last_run_units=$3, next_run_at = next_run_at + make_interval(secs => period_seconds)
+    WHERE id=$1::uuid
+  `, r.ID, run, units)
+  if err != nil { return false, err }
+
+  err = l.audit(ctx, q, AuditRecord{
+    Actor: "scheduler", Action: "APPLY_ACCRUAL", TargetType: "zone", TargetID: r.ZoneID,
+    Details: map[string]any{"rule_id": r.ID, "kind": r.Kind, "run": run, "due_at": r.NextRunAt, "accounts": accounts, "units": units},
+  })
+  if err != nil { return false, err }
+
+  if err := tx.Commit(ctx); err != nil { return false, err }
+  l.log.InfoContext(ctx, "accrual applied", "zone_id", r.ZoneID, "rule_id", r.ID, "kind", r.Kind, "run", run, "accounts", accounts, "units", units)
+  return true, nil
+}
@@ -0,0 +1,219 @@
+package ledger
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "regexp"
+  "strings"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+)
+
+var (
+  ErrActorNotFound = errors.New("actor not found")
+  ErrActorExists = errors.New("actor already exists")
+  ErrUnknownActor = errors.New("unknown actor")
+)
+
+func IsActorNotFound(err error) bool { return errors.Is(err, ErrActorNotFound) }
+func IsActorExists(err error) bool { return errors.Is(err, ErrActorExists) }
+func IsUnknownActor(err error) bool { return errors.Is(err, ErrUnknownActor) }
+
+const (
+  ActorKindHuman = "HUMAN"
+  ActorKindService = "SERVICE"
+  ActorKindScenario = "SCENARIO"
+)
+
+// actor ids are what audit entries record: user names, emails (OIDC), service
+// names, and scenario:<name>.
+var actorIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._@:+-]{0,127}$`)
+
+type Actor struct {
+  ID string `json:"id"`
+  Kind string `json:"kind"`
+  Name string `json:"name"`
+  CreatedAt time.Time `json:"created_at"`
+  DisabledAt *time.Time `json:"disabled_at"`
+}
+
+const actorCols = `id, kind, name, created_at, disabled_at`
+
+func scanActor(row pgx.Row) (*Actor, error) {
+  var a Actor
+  if err := row.Scan(&a.ID, &a.Kind, &a.Name, &a.CreatedAt, &a.DisabledAt); err != nil { return nil, err }
+  return &a, nil
+}
+
+// SetRequireKnownActors makes status, controls and incident changes fail with
+// ErrUnknownActor unless their actor is registered and not disabled.
+func (l *Ledger) SetRequireKnownActors(on bool) { l.knownActors.Store(on) }
+
+func (l *Ledger) RequireKnownActors() bool { return l.knownActors.Load() }
+
+// checkActors returns ErrUnknownActor for the first id that is not an active
+// actor, when enforcement is on. It reads through q so the check shares the
+// change's transaction.
+func (l *Ledger) checkActors(ctx context.Context, q querier, ids ...string) error {
+  if !l.knownActors.Load() { return nil }
+  for _, id := range ids {
+    var active bool
+    err := q.QueryRow(ctx, `SELECT disabled_at IS NULL FROM actors WHERE id=$1`, id).Scan(&active)
+    if errors.Is(err, pgx.ErrNoRows) { return fmt.Errorf("%w %q: register it with POST /v1/actors", ErrUnknownActor, id) }
+    if err != nil { return err }
+    if !active { return fmt.Errorf("%w %q: disabled", ErrUnknownActor, id) }
+  }
+  return nil
+}
+
+type RegisterActorInput struct {
+  ID string
+  Kind string
+  Name string
+  Actor string // who is registering it
+  Reason string
+}
+
+// RegisterActor adds an actor. A disabled actor with the same id is enabled
+// again (with the new kind and name) rather than rejected.
+func (l *Ledger) RegisterActor(ctx context.Context, in RegisterActorInput) (*Actor, error) {
+  if !actorIDPattern.MatchString(in.ID) { return nil, fmt.Errorf("invalid actor id") }
+  switch in.Kind {
+  case ActorKindHuman, ActorKindService:
+    if strings.HasPrefix(in.ID, "scenario:") { return nil, fmt.Errorf("scenario: ids are reserved for kind %s", ActorKindScenario) }
+  case ActorKindScenario:
+    if !strings.HasPrefix(in.ID, "scenario:") { return nil, fmt.Errorf("%s actor ids start with scenario:", ActorKindScenario) }
+  default:
+    return nil, fmt.Errorf("invalid kind")
+  }
+  if in.Name == "" { in.Name = in.ID }
+
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  a, err := scanActor(tx.QueryRow(ctx, `
+    INSERT INTO actors(id,kind,name) VALUES($1,$2,$3)
+    ON CONFLICT (id) DO UPDATE
+      SET kind=EXCLUDED.kind, name=EXCLUDED.name, disabled_at=NULL
+      WHERE actors.disabled_at IS NOT NULL
+    RETURNING `+actorCols, in.ID, in.Kind, in.Name))
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrActorExists }
+  if err != nil { return nil, err }
+
+  err = l.audit(ctx, pgQueries{tx}, AuditRecord{
+    Actor: in.Actor, Action: "REGISTER_ACTOR", TargetType: "actor", TargetID: in.ID, Reason: in.Reason,
+    Details: map[string]any{"kind": in.Kind, "name": in.Name},
+  })
+  if err != nil { return nil, err }
+
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  return a, nil
+}
+
+// DisableActor stops an actor from being accepted; its history stays attributed.
+func (l *Ledger) DisableActor(ctx context.Context, id, actor, reason string) (*Actor, error) {
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  a, err := scanActor(tx.QueryRow(ctx, `
+    UPDATE actors SET disabled_at=now() WHERE id=$1 AND disabled_at IS NULL
+    RETURNING `+actorCols, id))
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrActorNotFound }
+  if err != nil { return nil, err }
+
+  err = l.audit(ctx, pgQueries{tx}, AuditRecord{Actor: actor, Action: "DISABLE_ACTOR", TargetType: "actor", TargetID: id, Reason: reason})
+  if err != nil { return nil, err }
+
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  return a, nil
+}
+
+func (l *Ledger) GetActor(ctx context.Context, id string) (*Actor, error) {
+  a, err := scanActor(l.db.QueryRow(ctx, `SELECT `+actorCols+` FROM actors WHERE id=$1`, id))
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrActorNotFound }
+  return a, err
+}
+
+func (l *Ledger) ListActors(ctx context.Context, kind string, includeDisabled bool) ([]Actor, error) {
+  rows, err := l.ro.Query(ctx, `
+    SELECT `+actorCols+` FROM actors
+    WHERE ($1='' OR kind=$1) AND ($2 OR disabled_at IS NULL)
+    ORDER BY id
+  `, kind, includeDisabled)
+  if err != nil { return nil, err }
+  defer rows.Close()
+  out := []Actor{}
+  for rows.Next() {
+    a, err := scanActor(rows)
+    if err != nil { return nil, err }
+    out = append(out, *a)
+  }
+  return out, rows.Err()
+}
+
+// ActorActionCount is how often an actor took one action on one kind of target.
+type ActorActionCount struct {
+  Action string `json:"action"`
+  TargetType string `json:"target_type"`
+  Count int64 `json:"count"`
+  LastAt time.Time `json:"last_at"`
+}
+
+// ActorActivity summarises an actor's audit entries since a point in time.
+type ActorActivity struct {
+  Actor Actor `json:"actor"`
+  Since time.Time `json:"since"`
+  Total int64 `json:"total"`
+  LastAt *time.Time `json:"last_at"`
+  Actions []ActorActionCount `json:"actions"` // most frequent first
+  Targets []string `json:"targets"` // distinct target_type:target_id, up to maxActivityTargets
+}
+
+const maxActivityTargets = 100
+
+// GetActorActivity reports what a registered actor did since since, from the
+// audit log on the read replica. A running drill's entries are left out.
+func (l *Ledger) GetActorActivity(ctx context.Context, id string, since time.Time) (*ActorActivity, error) {
+  a, err := l.GetActor(ctx, id)
+  if err != nil { return nil, err }
+  act := ActorActivity{Actor: *a, Since: since, Actions: []ActorActionCount{}, Targets: []string{}}
+
+  rows, err := l.ro.Query(ctx, `
+    SELECT action, target_type, COUNT(*), MAX(created_at)
+    FROM audit_log
+    WHERE actor=$1 AND created_at >= $2 AND NOT `+drillHidden+`
+    GROUP BY action, target_type
+    ORDER BY COUNT(*) DESC, action, target_type
+  `, id, since)
+  if err != nil { return nil, err }
+  defer rows.Close()
+  for rows.Next() {
+    var c ActorActionCount
+    if err := rows.Scan(&c.Action, &c.TargetType, &c.Count, &c.LastAt); err != nil { return nil, err }
+    act.Total += c.Count
+    if act.LastAt == nil || c.LastAt.After(*act.LastAt) { last := c.LastAt; act.LastAt = &last }
+    act.Actions = append(act.Actions, c)
+  }
+  if err := rows.Err(); err != nil { return nil, err }
+
+  rows, err = l.ro.Query(ctx, `
+    SELECT target_type || ':' || target_id
+    FROM audit_log
+    WHERE actor=$1 AND created_at >= $2 AND NOT `+drillHidden+`
+    GROUP BY target_type, target_id
+    ORDER BY MAX(created_at) DESC
+    LIMIT $3
+  `, id, since, maxActivityTargets)
+  if err != nil { return nil, err }
+  defer rows.Close()
+  for rows.Next() {
+    var t string
+    if err := rows.Scan(&t); err != nil { return nil, err }
+    act.Targets = append(act.Targets, t)
+  }
+  return &act, rows.Err()
+}
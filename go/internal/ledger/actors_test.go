@@ -0,0 +1,68 @@
+package ledger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"time-ledger-sim/go/internal/store/storetest"
+)
+
+func TestRequireKnownActors(t *testing.T) {
+	db := storetest.Open(t)
+	ctx := context.Background()
+	l := New(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	t.Cleanup(func() { _, _ = db.Exec(ctx, `DELETE FROM actors WHERE id LIKE 'actors-test-%'`) })
+
+	// off by default: any actor is recorded as given
+	if _, err := l.SetZoneStatus(ctx, "zone-eu", "OK", "actors-test-nobody", "", "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	l.SetRequireKnownActors(true)
+	defer l.SetRequireKnownActors(false)
+	if _, err := l.SetZoneStatus(ctx, "zone-eu", "OK", "actors-test-nobody", "", "test"); !IsUnknownActor(err) {
+		t.Fatalf("unregistered actor: err = %v", err)
+	}
+
+	if _, err := l.RegisterActor(ctx, RegisterActorInput{ID: "actors-test-alice", Kind: ActorKindHuman, Actor: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.RegisterActor(ctx, RegisterActorInput{ID: "actors-test-alice", Kind: ActorKindHuman, Actor: "test"}); !IsActorExists(err) {
+		t.Fatalf("second registration: err = %v", err)
+	}
+	if _, err := l.SetZoneStatus(ctx, "zone-eu", "OK", "actors-test-alice", "", "test"); err != nil {
+		t.Fatalf("registered actor: %v", err)
+	}
+
+	act, err := l.GetActorActivity(ctx, "actors-test-alice", time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if act.Total != 1 || act.Actions[0].Action != "SET_ZONE_STATUS" || act.Targets[0] != "zone:zone-eu" {
+		t.Fatalf("activity = %+v", act)
+	}
+
+	if _, err := l.DisableActor(ctx, "actors-test-alice", "test", "left"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.SetZoneStatus(ctx, "zone-eu", "OK", "actors-test-alice", "", "test"); !IsUnknownActor(err) {
+		t.Fatalf("disabled actor: err = %v", err)
+	}
+}
+
+func TestRegisterActorValidation(t *testing.T) {
+	l := NewWithRepo(nil, nil)
+	for _, in := range []RegisterActorInput{
+		{ID: "bad id", Kind: ActorKindHuman},
+		{ID: "alice", Kind: "ROBOT"},
+		{ID: "scenario:eu-outage", Kind: ActorKindHuman},
+		{ID: "eu-outage", Kind: ActorKindScenario},
+	} {
+		if _, err := l.RegisterActor(context.Background(), in); err == nil {
+			t.Errorf("%+v: no error", in)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package ledger
+
+import (
+  "context"
+  "log/slog"
+  "sync/atomic"
+  "time"
+
+  "time-ledger-sim/go/internal/metrics"
+)
+
+// AlertState reads what the alerting metrics report: each active zone's
+// status, pending spool and open incidents, and the outbox backlog.
+func (l *Ledger) AlertState(ctx context.Context) (metrics.AlertState, error) {
+  s := metrics.AlertState{Zones: []metrics.AlertZone{}}
+  rows, err := l.ro.Query(ctx, `
+    SELECT z.id, z.status,
+      (SELECT COUNT(*) FROM spooled_transfers s WHERE s.zone_id=z.id AND s.status='PENDING'),
+      COUNT(i.id) FILTER (WHERE i.severity='INFO'),
+      COUNT(i.id) FILTER (WHERE i.severity='WARN'),
+      COUNT(i.id) FILTER (WHERE i.severity='CRITICAL')
+    FROM zones z
+    LEFT JOIN incidents i ON i.zone_id=z.id AND i.status<>'RESOLVED'
+    WHERE z.retired_at IS NULL
+    GROUP BY z.id, z.status
+    ORDER BY z.id
+  `)
+  if err != nil { return s, err }
+  defer rows.Close()
+  for rows.Next() {
+    var z metrics.AlertZone
+    var info, warn, crit int64
+    if err := rows.Scan(&z.Zone, &z.Status, &z.SpoolPending, &info, &warn, &crit); err != nil { return s, err }
+    z.OpenIncidents = map[string]int64{"INFO": info, "WARN": warn, "CRITICAL": crit}
+    s.Zones = append(s.Zones, z)
+  }
+  if err := rows.Err(); err != nil { return s, err }
+
+  err = l.ro.QueryRow(ctx, `
+    SELECT COUNT(*), COALESCE(EXTRACT(EPOCH FROM now() - MIN(created_at)), 0)::float8
+    FROM outbox_events WHERE published_at IS NULL
+  `).Scan(&s.OutboxBacklog, &s.OutboxOldestSeconds)
+  return s, err
+}
+
+// AlertUpdater refreshes a metrics.AlertCollector from AlertState on an
+// interval of wall time, so alert rules see the sim in real time whatever
+// the clock speed. The interval can change while it runs; 0 pauses it.
+type AlertUpdater struct {
+  led *Ledger
+  log *slog.Logger
+  gauges *metrics.AlertCollector
+  interval atomic.Int64
+}
+
+// alertUpdaterIdle is how often a paused updater looks for a new interval.
+const alertUpdaterIdle = 5 * time.Second
+
+func NewAlertUpdater(led *Ledger, log *slog.Logger, gauges *metrics.AlertCollector) *AlertUpdater {
+  u := &AlertUpdater{led: led, log: log, gauges: gauges}
+  u.SetInterval(15 * time.Second)
+  return u
+}
+
+func (u *AlertUpdater) SetInterval(d time.Duration) { u.interval.Store(int64(d)) }
+
+// Run updates the gauges at once, then on every tick. A failed read keeps
+// the last state; its timestamp goes stale.
+func (u *AlertUpdater) Run(ctx context.Context) {
+  next := func() time.Duration {
+    if iv := time.Duration(u.interval.Load()); iv > 0 { return iv }
+    return alertUpdaterIdle
+  }
+  timer := time.NewTimer(0)
+  defer timer.Stop()
+  for {
+    select {
+    case <-ctx.Done():
+      return
+    case <-timer.C:
+      timer.Reset(next())
+      if u.interval.Load() <= 0 { continue }
+      s, err := u.led.AlertState(ctx)
+      if err != nil {
+        if ctx.Err() == nil { u.log.Warn("alert metrics update failed", "err", err.Error()) }
+        continue
+      }
+      u.gauges.Set(s, time.Now())
+    }
+  }
+}
@@ -0,0 +1,125 @@
+package ledger
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "regexp"
+  "slices"
+  "strings"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+)
+
+var ErrTransactionNotFound = errors.New("transaction not found")
+
+func IsTransactionNotFound(err error) bool { return errors.Is(err, ErrTransactionNotFound) }
+
+// Limits on one annotation; maxTags also caps the tags on an account.
+const (
+  maxAnnotationNote = 4000
+  maxTags = 20
+)
+
+// tags are stored lower-cased, e.g. fraud-review, case:1234
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._:-]{0,63}$`)
+
+// Annotation is an investigator's note and tags on a posted transaction.
+type Annotation struct {
+  ID string `json:"id"`
+  TxnID string `json:"txn_id"`
+  Note string `json:"note"`
+  Tags []string `json:"tags"`
+  Actor string `json:"actor"`
+  CreatedAt time.Time `json:"created_at"`
+}
+
+const annotationCols = `id::text, txn_id::text, note, tags, actor, created_at`
+
+func scanAnnotation(row pgx.Row) (*Annotation, error) {
+  var a Annotation
+  if err := row.Scan(&a.ID, &a.TxnID, &a.Note, &a.Tags, &a.Actor, &a.CreatedAt); err != nil { return nil, err }
+  if a.Tags == nil { a.Tags = []string{} }
+  return &a, nil
+}
+
+type AnnotateInput struct {
+  Note string
+  Tags []string
+  Actor string
+}
+
+// normalize lower-cases and de-duplicates tags and checks the limits.
+func (in *AnnotateInput) normalize() error {
+  in.Note = strings.TrimSpace(in.Note)
+  if len(in.Note) > maxAnnotationNote { return fmt.Errorf("note longer than %d bytes", maxAnnotationNote) }
+  tags, err := normalizeTags(in.Tags)
+  if err != nil { return err }
+  if in.Note == "" && len(tags) == 0 { return fmt.Errorf("note or tags required") }
+  in.Tags = tags
+  return nil
+}
+
+// normalizeTags lower-cases and de-duplicates tags, as annotations and
+// account tags store them.
+func normalizeTags(in []string) ([]string, error) {
+  tags := []string{}
+  for _, t := range in {
+    t = strings.ToLower(strings.TrimSpace(t))
+    if !tagPattern.MatchString(t) { return nil, fmt.Errorf("invalid tag %q", t) }
+    if !slices.Contains(tags, t) { tags = append(tags, t) }
+  }
+  if len(tags) > maxTags { return nil, fmt.Errorf("more than %d tags", maxTags) }
+  return tags, nil
+}
+
+// AnnotateTransaction attaches a note and tags to a posted transaction. The
+// transaction itself is not changed; annotations are only ever added.
+func (l *Ledger) AnnotateTransaction(ctx context.Context, txnID string, in AnnotateInput) (*Annotation, error) {
+  if err := in.normalize(); err != nil { return nil, err }
+
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  if err := l.checkActors(ctx, tx, in.Actor); err != nil { return nil, err }
+
+  a, err := scanAnnotation(tx.QueryRow(ctx, `
+    INSERT INTO transaction_annotations(txn_id,note,tags,actor)
+    SELECT id,$2,$3,$4 FROM transactions WHERE id::text=$1
+    RETURNING `+annotationCols, txnID, in.Note, in.Tags, in.Actor))
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrTransactionNotFound }
+  if err != nil { return nil, err }
+
+  err = l.audit(ctx, pgQueries{tx}, AuditRecord{
+    Actor: in.Actor, Action: "ANNOTATE_TRANSACTION", TargetType: "transaction", TargetID: a.TxnID,
+    Details: map[string]any{"annotation_id": a.ID, "tags": a.Tags},
+  })
+  if err != nil { return nil, err }
+
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  return a, nil
+}
+
+// ListAnnotations returns a transaction's annotations, oldest first.
+func (l *Ledger) ListAnnotations(ctx context.Context, txnID string) ([]Annotation, error) {
+  return l.listAnnotations(ctx, l.ro, txnID)
+}
+
+func (l *Ledger) listAnnotations(ctx context.Context, q querier, txnID string) ([]Annotation, error) {
+  rows, err := q.Query(ctx, `
+    SELECT `+annotationCols+` FROM transaction_annotations
+    WHERE txn_id::text=$1
+    ORDER BY created_at, id
+  `, txnID)
+  if err != nil { return nil, err }
+  defer rows.Close()
+  out := []Annotation{}
+  for rows.Next() {
+    a, err := scanAnnotation(rows)
+    if err != nil { return nil, err }
+    out = append(out, *a)
+  }
+  return out, rows.Err()
+}
@@ -0,0 +1,76 @@
+package ledger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"time-ledger-sim/go/internal/store/storetest"
+)
+
+func TestAnnotateInputNormalize(t *testing.T) {
+	in := AnnotateInput{Note: "  chargeback  ", Tags: []string{"Fraud-Review", "fraud-review", "case:1234"}}
+	if err := in.normalize(); err != nil {
+		t.Fatal(err)
+	}
+	if in.Note != "chargeback" || strings.Join(in.Tags, ",") != "fraud-review,case:1234" {
+		t.Fatalf("normalized = %+v", in)
+	}
+
+	for _, bad := range []AnnotateInput{
+		{},
+		{Note: "   "},
+		{Tags: []string{"has space"}},
+		{Tags: []string{"-leading"}},
+		{Note: strings.Repeat("x", maxAnnotationNote+1)},
+	} {
+		if err := bad.normalize(); err == nil {
+			t.Errorf("%+v: no error", bad)
+		}
+	}
+}
+
+func TestAnnotateTransaction(t *testing.T) {
+	db := storetest.Open(t)
+	ctx := context.Background()
+	l := New(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	txn, _, err := l.CreateTransfer(ctx, CreateTransferInput{
+		RequestID: "annotate-test-" + uuid.NewString(), PayloadHash: "h", FromAccount: "acct-a", ToAccount: "acct-b", AmountUnits: 1, ZoneID: "zone-eu",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	txnID := txn.ID
+
+	if _, err := l.AnnotateTransaction(ctx, "00000000-0000-0000-0000-000000000000", AnnotateInput{Note: "x", Actor: "test"}); !IsTransactionNotFound(err) {
+		t.Fatalf("unknown transaction: err = %v", err)
+	}
+	if _, err := l.AnnotateTransaction(ctx, txnID, AnnotateInput{Note: "looks like a mule", Tags: []string{"fraud-review"}, Actor: "test"}); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := l.GetTransaction(ctx, txnID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Annotations) != 1 || d.Annotations[0].Note != "looks like a mule" {
+		t.Fatalf("annotations = %+v", d.Annotations)
+	}
+
+	rows, err := l.ListTransactions(ctx, TransactionFilter{Limit: 500, Tag: "FRAUD-REVIEW"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	for _, r := range rows {
+		found = found || r.ID == txnID
+	}
+	if !found {
+		t.Fatalf("%s not found by tag", txnID)
+	}
+}
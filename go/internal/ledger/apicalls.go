@@ -0,0 +1,48 @@
+package ledger
+
+import (
+  "context"
+)
+
+// API call audit: every mutating HTTP request is recorded in audit_log
+// (action API_CALL, target_type http) whatever its outcome, independently of the
+// entries handlers write for changes that succeed.
+
+const AuditActionAPICall = "API_CALL"
+
+// APICall describes one mutating request.
+type APICall struct {
+  Method string `json:"method"`
+  Route string `json:"route"` // chi pattern, e.g. /v1/zones/{zone_id}/controls
+  Path string `json:"path"`
+  Actor string `json:"-"`
+  KeyID string `json:"key_id,omitempty"` // which credential was presented, never the secret
+  PayloadHash string `json:"payload_hash"` // sha256 of the request body
+  PayloadBytes int64 `json:"payload_bytes"`
+  PayloadTruncated bool `json:"payload_truncated,omitempty"` // hash covers only the first bytes
+  Status int `json:"status"`
+  DurationMs float64 `json:"duration_ms"`
+  RequestID string `json:"request_id,omitempty"`
+}
+
+func (l *Ledger) RecordAPICall(ctx context.Context, c APICall) error {
+  actor := c.Actor
+  if actor == "" { actor = "anonymous" }
+  return l.audit(ctx, pgQueries{l.db}, AuditRecord{
+    Actor: actor, Action: AuditActionAPICall, TargetType: "http", TargetID: c.Method + " " + c.Route, Details: asDetails(c),
+  })
+}
+
+// ListAPICalls returns recorded API calls, newest first.
+func (l *Ledger) ListAPICalls(ctx context.Context, limit int) ([]AuditEntry, error) {
+  if limit <= 0 || limit > 1000 { limit = 100 }
+  rows, err := l.ro.Query(ctx, `
+    SELECT id::text, actor, action, target_type, target_id, reason, details, created_at
+    FROM audit_log
+    WHERE target_type='http' AND action=$1
+    ORDER BY created_at DESC
+    LIMIT $2
+  `, AuditActionAPICall, limit)
+  if err != nil { return nil, err }
+  return scanAuditEntries(rows)
+}
@@ -0,0 +1,311 @@
+package ledger
+
+import (
+  "bytes"
+  "context"
+  "crypto/sha256"
+  "encoding/hex"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+)
+
+var (
+  ErrApprovalNotFound = errors.New("approval not found")
+  ErrApprovalNotPending = errors.New("approval is not pending")
+  ErrSelfApproval = errors.New("an approval must come from a second operator")
+)
+
+func IsApprovalNotFound(err error) bool { return errors.Is(err, ErrApprovalNotFound) }
+func IsApprovalNotPending(err error) bool { return errors.Is(err, ErrApprovalNotPending) }
+func IsSelfApproval(err error) bool { return errors.Is(err, ErrSelfApproval) }
+
+// Kinds of action held for approval under the two-person rule.
+const (
+  ApprovalZoneStatus = "ZONE_STATUS" // setting a zone DOWN
+  ApprovalZoneControls = "ZONE_CONTROLS" // controls (set now or scheduled) that block writes
+  ApprovalZoneControlsBatch = "ZONE_CONTROLS_BATCH" // a controls batch that blocks writes
+  ApprovalRestore = "RESTORE" // a snapshot restore
+)
+
+// DefaultApprovalTTL is how long a pending approval can be approved.
+const DefaultApprovalTTL = time.Hour
+
+type approvalPolicy struct {
+  on bool
+  ttl time.Duration
+}
+
+// SetTwoPersonRule turns the two-person rule on or off. While on, the API
+// holds dangerous actions as pending approvals that expire after ttl.
+func (l *Ledger) SetTwoPersonRule(on bool, ttl time.Duration) {
+  if ttl <= 0 { ttl = DefaultApprovalTTL }
+  l.approvals.Store(&approvalPolicy{on: on, ttl: ttl})
+}
+
+func (l *Ledger) TwoPersonRule() bool { return l.approvals.Load().on }
+
+// ZoneStatusNeedsApproval reports whether setting status must be approved.
+func (l *Ledger) ZoneStatusNeedsApproval(status string) bool { return l.TwoPersonRule() && status == "DOWN" }
+
+// ZoneControlsNeedApproval reports whether applying in must be approved.
+func (l *Ledger) ZoneControlsNeedApproval(in SetZoneControlsInput) bool { return l.TwoPersonRule() && in.WritesBlocked }
+
+type Approval struct {
+  ID string `json:"id"`
+  Kind string `json:"kind"`
+  TargetType string `json:"target_type"`
+  TargetID string `json:"target_id"`
+  Payload map[string]any `json:"payload"`
+  Status string `json:"status"` // PENDING|APPROVED|REJECTED|FAILED|EXPIRED
+  RequestedBy string `json:"requested_by"`
+  Reason *string `json:"reason"`
+  DecidedBy *string `json:"decided_by"`
+  DecisionReason *string `json:"decision_reason"`
+  Result map[string]any `json:"result,omitempty"` // what the approved action returned
+  FailReason *string `json:"fail_reason"`
+  CreatedAt time.Time `json:"created_at"`
+  ExpiresAt time.Time `json:"expires_at"`
+  DecidedAt *time.Time `json:"decided_at"`
+}
+
+// approvalStatus reads a pending approval past its expiry as EXPIRED.
+const approvalStatus = `CASE WHEN status='PENDING' AND expires_at <= now() THEN 'EXPIRED' ELSE status END`
+
+const approvalCols = `id::text, kind, target_type, target_id, payload, ` + approvalStatus + `,
+  requested_by, reason, decided_by, decision_reason, result, fail_reason, created_at, expires_at, decided_at`
+
+func scanApproval(row pgx.Row) (*Approval, error) {
+  var a Approval
+  var payload, result []byte
+  err := row.Scan(&a.ID, &a.Kind, &a.TargetType, &a.TargetID, &payload, &a.Status, &a.RequestedBy, &a.Reason,
+    &a.DecidedBy, &a.DecisionReason, &result, &a.FailReason, &a.CreatedAt, &a.ExpiresAt, &a.DecidedAt)
+  if err != nil { return nil, err }
+  _ = json.Unmarshal(payload, &a.Payload)
+  if result != nil { _ = json.Unmarshal(result, &a.Result) }
+  return &a, nil
+}
+
+type approvalRequest struct {
+  kind, targetType, targetID string
+  payload any
+  body []byte
+  actor, reason string
+  reasonFor, reasonCode string // the action the held change performs, and its code
+}
+
+func (l *Ledger) requestApproval(ctx context.Context, in approvalRequest) (*Approval, error) {
+  payload, err := json.Marshal(in.payload)
+  if err != nil { return nil, err }
+
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  if err := l.checkActors(ctx, tx, in.actor); err != nil { return nil, err }
+  if in.reasonFor != "" {
+    if err := l.checkReasonCode(ctx, tx, in.reasonFor, in.reasonCode); err != nil { return nil, err }
+  }
+
+  a, err := scanApproval(tx.QueryRow(ctx, `
+    INSERT INTO approvals(kind,target_type,target_id,payload,body,requested_by,reason,expires_at)
+    VALUES($1,$2,$3,$4::jsonb,$5,$6,NULLIF($7,''),now()+make_interval(secs => $8))
+    RETURNING `+approvalCols,
+    in.kind, in.targetType, in.targetID, string(payload), in.body, in.actor, in.reason, l.approvals.Load().ttl.Seconds()))
+  if err != nil { return nil, err }
+
+  err = l.audit(ctx, pgQueries{tx}, AuditRecord{
+    Actor: in.actor, Action: "APPROVAL_REQUESTED", TargetType: in.targetType, TargetID: in.targetID, Reason: in.reason, ReasonCode: in.reasonCode,
+    Details: map[string]any{"approval_id": a.ID, "kind": in.kind, "payload": json.RawMessage(payload)},
+  })
+  if err != nil { return nil, err }
+
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  return a, nil
+}
+
+// RequestZoneStatusApproval holds a zone status change for a second operator.
+func (l *Ledger) RequestZoneStatusApproval(ctx context.Context, zoneID, status, actor, reasonCode, reason string) (*Approval, error) {
+  if _, err := l.repo.ZoneStatus(ctx, zoneID); err != nil { return nil, err }
+  return l.requestApproval(ctx, approvalRequest{
+    kind: ApprovalZoneStatus, targetType: "zone", targetID: zoneID,
+    payload: map[string]any{"status": status, "reason_code": reasonCode}, actor: actor, reason: reason,
+    reasonFor: ReasonForZoneStatus, reasonCode: reasonCode,
+  })
+}
+
+// RequestZoneControlsApproval holds a controls change for a second operator.
+// With a non-zero applyAt the approved change is scheduled rather than applied.
+func (l *Ledger) RequestZoneControlsApproval(ctx context.Context, zoneID string, applyAt time.Time, in SetZoneControlsInput) (*Approval, error) {
+  if in.ThrottleMode == "" { in.ThrottleMode = ThrottleModeHash }
+  if err := in.validate(); err != nil { return nil, err }
+  if !applyAt.IsZero() && !applyAt.After(l.clock.Now()) { return nil, fmt.Errorf("apply_at must be in the future") }
+  if _, err := l.repo.ZoneStatus(ctx, zoneID); err != nil { return nil, err }
+  payload := map[string]any{"controls": in, "reason_code": in.ReasonCode}
+  if !applyAt.IsZero() { payload["apply_at"] = applyAt }
+  return l.requestApproval(ctx, approvalRequest{
+    kind: ApprovalZoneControls, targetType: "zone", targetID: zoneID,
+    payload: payload, actor: in.Actor, reason: in.Reason,
+    reasonFor: ReasonForZoneControls, reasonCode: in.ReasonCode,
+  })
+}
+
+// RequestRestoreApproval validates a snapshot with a dry run and, if it would
+// restore, stores it for a second operator. The dry-run report is returned
+// with ErrBadSnapshot like a failed restore.
+func (l *Ledger) RequestRestoreApproval(ctx context.Context, snapshot []byte, ndjson bool, opts RestoreOptions, actor, reason string) (*Approval, *RestoreReport, error) {
+  opts.DryRun = true
+  report, err := l.restoreBytes(ctx, snapshot, ndjson, opts)
+  if err != nil { return nil, report, err }
+  sum := sha256.Sum256(snapshot)
+  format := "json"
+  if ndjson { format = "ndjson" }
+  a, err := l.requestApproval(ctx, approvalRequest{
+    kind: ApprovalRestore, targetType: "sim", targetID: "restore",
+    payload: map[string]any{"format": format, "scope": opts.Scope, "bytes": len(snapshot), "sha256": hex.EncodeToString(sum[:]), "dry_run": report},
+    body: snapshot, actor: actor, reason: reason,
+  })
+  return a, report, err
+}
+
+func (l *Ledger) restoreBytes(ctx context.Context, snapshot []byte, ndjson bool, opts RestoreOptions) (*RestoreReport, error) {
+  if ndjson { return l.RestoreNDJSON(ctx, bytes.NewReader(snapshot), opts) }
+  var snap map[string]any
+  if err := json.Unmarshal(snapshot, &snap); err != nil { return nil, fmt.Errorf("%w: %v", ErrBadSnapshot, err) }
+  return l.Restore(ctx, snap, opts)
+}
+
+func (l *Ledger) GetApproval(ctx context.Context, id string) (*Approval, error) {
+  a, err := scanApproval(l.db.QueryRow(ctx, `SELECT `+approvalCols+` FROM approvals WHERE id::text=$1`, id))
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrApprovalNotFound }
+  return a, err
+}
+
+// ListApprovals lists approvals newest first; status filters (EXPIRED included).
+func (l *Ledger) ListApprovals(ctx context.Context, status string, limit int) ([]Approval, error) {
+  if limit <= 0 || limit > 500 { limit = 100 }
+  rows, err := l.ro.Query(ctx, `
+    SELECT `+approvalCols+` FROM approvals
+    WHERE $1='' OR `+approvalStatus+`=$1
+    ORDER BY created_at DESC
+    LIMIT $2
+  `, status, limit)
+  if err != nil { return nil, err }
+  defer rows.Close()
+  out := []Approval{}
+  for rows.Next() {
+    a, err := scanApproval(rows)
+    if err != nil { return nil, err }
+    out = append(out, *a)
+  }
+  return out, rows.Err()
+}
+
+// decideApproval moves a pending, unexpired approval to status. Only one
+// decision can win, so an approved action runs once.
+func (l *Ledger) decideApproval(ctx context.Context, id, status, actor, reason string) (*Approval, []byte, error) {
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  if err := l.checkActors(ctx, tx, actor); err != nil { return nil, nil, err }
+
+  var requestedBy string
+  var pending bool
+  err = tx.QueryRow(ctx, `SELECT requested_by, status='PENDING' AND expires_at > now() FROM approvals WHERE id::text=$1 FOR UPDATE`, id).Scan(&requestedBy, &pending)
+  if errors.Is(err, pgx.ErrNoRows) { return nil, nil, ErrApprovalNotFound }
+  if err != nil { return nil, nil, err }
+  if !pending { return nil, nil, ErrApprovalNotPending }
+  if status == "APPROVED" && actor == requestedBy { return nil, nil, ErrSelfApproval }
+
+  var body []byte
+  a, err := scanApproval(tx.QueryRow(ctx, `
+    UPDATE approvals SET status=$2, decided_by=$3, decision_reason=NULLIF($4,''), decided_at=now(), body=CASE WHEN $2='APPROVED' THEN body END
+    WHERE id::text=$1
+    RETURNING `+approvalCols, id, status, actor, reason))
+  if err != nil { return nil, nil, err }
+  if status == "APPROVED" {
+    if err := tx.QueryRow(ctx, `SELECT body FROM approvals WHERE id::text=$1`, id).Scan(&body); err != nil { return nil, nil, err }
+  }
+
+  err = l.audit(ctx, pgQueries{tx}, AuditRecord{
+    Actor: actor, Action: "APPROVAL_" + status, TargetType: a.TargetType, TargetID: a.TargetID, Reason: reason,
+    Details: map[string]any{"approval_id": a.ID, "kind": a.Kind, "requested_by": a.RequestedBy},
+  })
+  if err != nil { return nil, nil, err }
+
+  if err := tx.Commit(ctx); err != nil { return nil, nil, err }
+  return a, body, nil
+}
+
+// ApproveApproval records a second operator's approval and performs the
+// action as the operator who requested it. If the action fails the approval
+// ends FAILED with the error; either way the approval is returned.
+func (l *Ledger) ApproveApproval(ctx context.Context, id, actor, reason string) (*Approval, error) {
+  a, body, err := l.decideApproval(ctx, id, "APPROVED", actor, reason)
+  if err != nil { return nil, err }
+
+  result, runErr := l.runApproved(ctx, a, body)
+  if runErr != nil {
+    _, err = l.db.Exec(ctx, `UPDATE approvals SET status='FAILED', fail_reason=$2, body=NULL WHERE id::text=$1`, id, runErr.Error())
+    if err != nil { return nil, err }
+    _ = l.audit(ctx, pgQueries{l.db}, AuditRecord{
+      Actor: "system", Action: "APPROVAL_FAILED", TargetType: a.TargetType, TargetID: a.TargetID, Reason: runErr.Error(),
+      Details: map[string]any{"approval_id": a.ID, "kind": a.Kind},
+    })
+    return l.GetApproval(ctx, id)
+  }
+  b, _ := json.Marshal(result)
+  if _, err := l.db.Exec(ctx, `UPDATE approvals SET result=$2::jsonb, body=NULL WHERE id::text=$1`, id, string(b)); err != nil { return nil, err }
+  return l.GetApproval(ctx, id)
+}
+
+// RejectApproval declines a pending approval; the requester may withdraw it this way too.
+func (l *Ledger) RejectApproval(ctx context.Context, id, actor, reason string) (*Approval, error) {
+  a, _, err := l.decideApproval(ctx, id, "REJECTED", actor, reason)
+  return a, err
+}
+
+func (l *Ledger) runApproved(ctx context.Context, a *Approval, body []byte) (any, error) {
+  reason := ""
+  if a.Reason != nil { reason = *a.Reason }
+  code, _ := a.Payload["reason_code"].(string)
+  switch a.Kind {
+  case ApprovalZoneStatus:
+    status, _ := a.Payload["status"].(string)
+    return l.SetZoneStatus(ctx, a.TargetID, status, a.RequestedBy, code, reason)
+  case ApprovalZoneControls:
+    var p struct {
+      Controls SetZoneControlsInput `json:"controls"`
+      ApplyAt *time.Time `json:"apply_at"`
+    }
+    b, _ := json.Marshal(a.Payload)
+    if err := json.Unmarshal(b, &p); err != nil { return nil, err }
+    p.Controls.Actor, p.Controls.ReasonCode, p.Controls.Reason = a.RequestedBy, code, reason
+    if p.ApplyAt != nil { return l.ScheduleZoneControls(ctx, a.TargetID, *p.ApplyAt, p.Controls) }
+    return l.SetZoneControls(ctx, a.TargetID, p.Controls)
+  case ApprovalZoneControlsBatch:
+    var p struct {
+      Batch BatchZoneControlsInput `json:"batch"`
+    }
+    b, _ := json.Marshal(a.Payload)
+    if err := json.Unmarshal(b, &p); err != nil { return nil, err }
+    p.Batch.Actor, p.Batch.ReasonCode, p.Batch.Reason = a.RequestedBy, code, reason
+    return l.BatchSetZoneControls(ctx, p.Batch)
+  case ApprovalRestore:
+    var p struct {
+      Format string `json:"format"`
+      Scope []string `json:"scope"`
+      SHA256 string `json:"sha256"`
+    }
+    b, _ := json.Marshal(a.Payload)
+    if err := json.Unmarshal(b, &p); err != nil { return nil, err }
+    sum := sha256.Sum256(body)
+    if hex.EncodeToString(sum[:]) != p.SHA256 { return nil, fmt.Errorf("stored snapshot does not match the approved sha256") }
+    return l.restoreBytes(ctx, body, p.Format == "ndjson", RestoreOptions{Scope: p.Scope})
+  }
+  return nil, fmt.Errorf("unknown approval kind %q", a.Kind)
+}
@@ -0,0 +1,62 @@
+package ledger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"time-ledger-sim/go/internal/store/storetest"
+)
+
+func TestTwoPersonRule(t *testing.T) {
+	db := storetest.Open(t)
+	ctx := context.Background()
+	l := New(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	l.SetTwoPersonRule(true, time.Hour)
+	t.Cleanup(func() { _, _ = l.SetZoneStatus(ctx, "zone-eu", "OK", "test", "", "cleanup") })
+
+	if !l.ZoneStatusNeedsApproval("DOWN") || l.ZoneStatusNeedsApproval("DEGRADED") {
+		t.Fatal("only DOWN needs approval")
+	}
+	ap, err := l.RequestZoneStatusApproval(ctx, "zone-eu", "DOWN", "alice", "", "drill")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ap.Status != "PENDING" {
+		t.Fatalf("status = %s", ap.Status)
+	}
+	if st, _ := l.repo.ZoneStatus(ctx, "zone-eu"); st == "DOWN" {
+		t.Fatal("zone went DOWN before approval")
+	}
+
+	if _, err := l.ApproveApproval(ctx, ap.ID, "alice", ""); !IsSelfApproval(err) {
+		t.Fatalf("self approval: err = %v", err)
+	}
+	got, err := l.ApproveApproval(ctx, ap.ID, "bob", "confirmed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != "APPROVED" || got.Result["status"] != "DOWN" {
+		t.Fatalf("approved = %+v", got)
+	}
+	if st, _ := l.repo.ZoneStatus(ctx, "zone-eu"); st != "DOWN" {
+		t.Fatalf("zone status after approval = %s", st)
+	}
+	if _, err := l.ApproveApproval(ctx, ap.ID, "carol", ""); !IsApprovalNotPending(err) {
+		t.Fatalf("second approval: err = %v", err)
+	}
+
+	// a rejected change never happens
+	ap, err = l.RequestZoneControlsApproval(ctx, "zone-eu", time.Time{}, SetZoneControlsInput{WritesBlocked: true, CrossZoneThrottle: 100, Actor: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.RejectApproval(ctx, ap.ID, "bob", "not now"); err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := l.GetZoneControls(ctx, "zone-eu"); c.WritesBlocked {
+		t.Fatal("rejected controls were applied")
+	}
+}
@@ -0,0 +1,128 @@
+package ledger
+
+import (
+  "context"
+  "errors"
+  "io"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+)
+
+var (
+  ErrArtifactNotFound = errors.New("job has no artifact")
+  ErrArtifactNotReady = errors.New("job has not finished writing its artifact")
+  ErrArtifactExpired = errors.New("artifact expired")
+)
+
+func IsArtifactNotFound(err error) bool { return errors.Is(err, ErrArtifactNotFound) }
+func IsArtifactNotReady(err error) bool { return errors.Is(err, ErrArtifactNotReady) }
+func IsArtifactExpired(err error) bool { return errors.Is(err, ErrArtifactExpired) }
+
+// Artifact is a file a job produced, downloadable until ExpiresAt (sim
+// clock). It is stored in the database in chunks.
+type Artifact struct {
+  JobID string `json:"job_id"`
+  Filename string `json:"filename"`
+  ContentType string `json:"content_type"`
+  Bytes int64 `json:"bytes"`
+  CreatedAt time.Time `json:"created_at"`
+  ExpiresAt time.Time `json:"expires_at"`
+}
+
+const artifactChunkSize = 1 << 20
+
+// artifactWriter writes a job's artifact a chunk at a time; Close marks it
+// complete. Nothing is downloadable before that.
+type artifactWriter struct {
+  l *Ledger
+  ctx context.Context
+  art Artifact
+  seq int
+  buf []byte
+}
+
+// createArtifact starts the job's artifact, replacing what an earlier
+// attempt left behind.
+func (l *Ledger) createArtifact(ctx context.Context, jobID, filename, contentType string, ttl time.Duration) (*artifactWriter, error) {
+  now := l.clock.Now()
+  art := Artifact{JobID: jobID, Filename: filename, ContentType: contentType, CreatedAt: now, ExpiresAt: now.Add(ttl)}
+  if _, err := l.db.Exec(ctx, `DELETE FROM job_artifacts WHERE job_id=$1::uuid`, jobID); err != nil { return nil, err }
+  _, err := l.db.Exec(ctx, `
+    INSERT INTO job_artifacts(job_id,filename,content_type,created_at,expires_at) VALUES($1::uuid,$2,$3,$4,$5)
+  `, jobID, filename, contentType, art.CreatedAt, art.ExpiresAt)
+  if err != nil { return nil, err }
+  return &artifactWriter{l: l, ctx: ctx, art: art, buf: make([]byte, 0, artifactChunkSize)}, nil
+}
+
+func (w *artifactWriter) Write(p []byte) (int, error) {
+  n := len(p)
+  for len(p) > 0 {
+    k := min(len(p), artifactChunkSize-len(w.buf))
+    w.buf = append(w.buf, p[:k]...)
+    p = p[k:]
+    if len(w.buf) == artifactChunkSize {
+      if err := w.flush(); err != nil { return n - len(p), err }
+    }
+  }
+  return n, nil
+}
+
+func (w *artifactWriter) flush() error {
+  if len(w.buf) == 0 { return nil }
+  _, err := w.l.db.Exec(w.ctx, `INSERT INTO job_artifact_chunks(job_id,seq,data) VALUES($1::uuid,$2,$3)`, w.art.JobID, w.seq, w.buf)
+  if err != nil { return err }
+  w.seq++
+  w.art.Bytes += int64(len(w.buf))
+  w.buf = w.buf[:0]
+  return nil
+}
+
+// Close writes the last chunk and makes the artifact downloadable.
+func (w *artifactWriter) Close() (*Artifact, error) {
+  if err := w.flush(); err != nil { return nil, err }
+  _, err := w.l.db.Exec(w.ctx, `UPDATE job_artifacts SET complete=true, bytes=$2 WHERE job_id=$1::uuid`, w.art.JobID, w.art.Bytes)
+  if err != nil { return nil, err }
+  return &w.art, nil
+}
+
+// GetArtifact returns the job's artifact if it can be downloaded.
+func (l *Ledger) GetArtifact(ctx context.Context, jobID string) (*Artifact, error) {
+  var status string
+  var art Artifact
+  var filename, contentType *string
+  var bytes *int64
+  var complete *bool
+  var createdAt, expiresAt *time.Time
+  err := l.db.QueryRow(ctx, `
+    SELECT j.id::text, j.status, a.filename, a.content_type, a.bytes, a.complete, a.created_at, a.expires_at
+    FROM jobs j LEFT JOIN job_artifacts a ON a.job_id=j.id
+    WHERE j.id::text=$1
+  `, jobID).Scan(&art.JobID, &status, &filename, &contentType, &bytes, &complete, &createdAt, &expiresAt)
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrJobNotFound }
+  if err != nil { return nil, err }
+  switch {
+  case status == JobQueued || status == JobRunning:
+    return nil, ErrArtifactNotReady
+  case filename == nil || !*complete:
+    return nil, ErrArtifactNotFound
+  case !l.clock.Now().Before(*expiresAt):
+    return nil, ErrArtifactExpired
+  }
+  art.Filename, art.ContentType, art.Bytes, art.CreatedAt, art.ExpiresAt = *filename, *contentType, *bytes, *createdAt, *expiresAt
+  return &art, nil
+}
+
+// WriteArtifact copies the job's artifact to w. Check it with GetArtifact
+// first.
+func (l *Ledger) WriteArtifact(ctx context.Context, w io.Writer, jobID string) error {
+  rows, err := l.db.Query(ctx, `SELECT data FROM job_artifact_chunks WHERE job_id::text=$1 ORDER BY seq`, jobID)
+  if err != nil { return err }
+  defer rows.Close()
+  for rows.Next() {
+    var data []byte
+    if err := rows.Scan(&data); err != nil { return err }
+    if _, err := w.Write(data); err != nil { return err }
+  }
+  return rows.Err()
+}
@@ -0,0 +1,159 @@
+package ledger
+
+import (
+  "context"
+  "encoding/json"
+  "errors"
+  "io"
+  "strconv"
+  "time"
+
+  "github.com/google/uuid"
+  "go.opentelemetry.io/otel/attribute"
+  "go.opentelemetry.io/otel/trace"
+
+  "time-ledger-sim/go/internal/auditsig"
+  "time-ledger-sim/go/internal/tracing"
+)
+
+var ErrAuditSigningOff = errors.New("audit signing is not configured")
+
+func IsAuditSigningOff(err error) bool { return errors.Is(err, ErrAuditSigningOff) }
+
+// SetAuditSigner makes the ledger sign every audit entry it writes (nil: unsigned).
+func (l *Ledger) SetAuditSigner(s auditsig.Signer) { l.signer = s }
+
+// audit writes an audit entry through q. Its id and time are chosen here so
+// they are covered by the signature. The request's trace id goes into the
+// details, and an event naming the entry onto its span, so either leads to
+// the other.
+func (l *Ledger) audit(ctx context.Context, q Queries, a AuditRecord) error {
+  if a.Details == nil { a.Details = map[string]any{} }
+  if a.ReasonCode != "" { a.Details["reason_code"] = a.ReasonCode }
+  if id, ok := ctx.Value(drillKey{}).(string); ok { a.Details["drill_id"] = id }
+  if id := tracing.TraceID(ctx); id != "" { a.Details["trace_id"] = id }
+  a.ID = uuid.NewString()
+  a.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+  if l.signer != nil {
+    details, err := json.Marshal(a.Details)
+    if err != nil { return err }
+    payload, err := auditPayload(a.ID, a.CreatedAt, a.Actor, a.Action, a.TargetType, a.TargetID, a.Reason, details)
+    if err != nil { return err }
+    a.Signature, a.KeyID = l.signer.Sign(payload), l.signer.KeyID()
+  }
+  if err := q.InsertAudit(ctx, a); err != nil { return err }
+  trace.SpanFromContext(ctx).AddEvent("audit", trace.WithAttributes(attribute.String("audit.id", a.ID), attribute.String("audit.action", a.Action)))
+  return nil
+}
+
+// auditPayload is the signed form of an entry. details is canonicalised
+// (decoded and re-encoded with sorted keys) because jsonb does not keep the
+// bytes it was given; a NULL reason signs as "".
+func auditPayload(id string, createdAt time.Time, actor, action, targetType, targetID, reason string, details []byte) ([]byte, error) {
+  var v any
+  if err := json.Unmarshal(details, &v); err != nil { return nil, err }
+  canon, err := json.Marshal(v)
+  if err != nil { return nil, err }
+  return lengthPrefixed("audit/v1", id, strconv.FormatInt(createdAt.UnixMicro(), 10), actor, action, targetType, targetID, reason, string(canon)), nil
+}
+
+// SignedAuditEntry is an audit entry with its signature, as exported.
+// Payload is the exact signed bytes, so the signature can be checked with the
+// public key alone.
+type SignedAuditEntry struct {
+  AuditEntry
+  KeyID string `json:"key_id,omitempty"`
+  Signature []byte `json:"signature,omitempty"` // base64
+  Payload []byte `json:"payload"` // base64
+}
+
+// AuditVerification counts audit entries by signature status.
+type AuditVerification struct {
+  Alg string `json:"alg"`
+  KeyID string `json:"key_id"`
+  Since *time.Time `json:"since,omitempty"`
+  Checked int64 `json:"checked"`
+  Valid int64 `json:"valid"`
+  Invalid int64 `json:"invalid"` // signed with this key, but changed since
+  Unsigned int64 `json:"unsigned"` // written without signing (older entries, other services)
+  OtherKey int64 `json:"other_key"` // signed with a key this instance does not hold
+  InvalidIDs []string `json:"invalid_ids"` // the first maxInvalidIDs
+}
+
+const maxInvalidIDs = 100
+
+// VerifyAudit checks the signature of every audit entry created at or after
+// since (all when zero), reading the primary.
+func (l *Ledger) VerifyAudit(ctx context.Context, since time.Time) (*AuditVerification, error) {
+  if l.signer == nil { return nil, ErrAuditSigningOff }
+  v := AuditVerification{Alg: l.signer.Alg(), KeyID: l.signer.KeyID(), InvalidIDs: []string{}}
+  if !since.IsZero() { v.Since = &since }
+  err := l.scanSignedAudit(ctx, l.db, since, func(e SignedAuditEntry) error {
+    v.Checked++
+    switch {
+    case e.Signature == nil:
+      v.Unsigned++
+    case e.KeyID != l.signer.KeyID():
+      v.OtherKey++
+    case l.signer.Verify(e.Payload, e.Signature):
+      v.Valid++
+    default:
+      v.Invalid++
+      if len(v.InvalidIDs) < maxInvalidIDs { v.InvalidIDs = append(v.InvalidIDs, e.ID) }
+    }
+    return nil
+  })
+  if err != nil { return nil, err }
+  return &v, nil
+}
+
+// WriteAuditExport streams the audit trail from since as NDJSON: a header
+// line with the algorithm, key id and (Ed25519) public key, then one
+// SignedAuditEntry per line, oldest first.
+func (l *Ledger) WriteAuditExport(ctx context.Context, w io.Writer, since time.Time) error {
+  enc := json.NewEncoder(w)
+  header := map[string]any{"type": "header", "payload_format": "audit/v1", "exported_at": time.Now().UTC()}
+  if l.signer != nil {
+    header["alg"], header["key_id"] = l.signer.Alg(), l.signer.KeyID()
+    if pk := l.signer.PublicKey(); pk != "" { header["public_key"] = pk }
+  }
+  if err := enc.Encode(header); err != nil { return err }
+  return l.scanSignedAudit(ctx, l.ro, since, func(e SignedAuditEntry) error {
+    return enc.Encode(struct {
+      Type string `json:"type"`
+      SignedAuditEntry
+    }{"entry", e})
+  })
+}
+
+func (l *Ledger) scanSignedAudit(ctx context.Context, db querier, since time.Time, fn func(SignedAuditEntry) error) error {
+  rows, err := db.Query(ctx, `
+    SELECT id::text, actor, action, target_type, target_id, reason, details, created_at, signature, sig_key_id
+    FROM audit_log
+    WHERE created_at >= $1
+    ORDER BY created_at, id
+  `, since)
+  if err != nil { return err }
+  defer rows.Close()
+  for rows.Next() {
+    var e SignedAuditEntry
+    var details []byte
+    var keyID *string
+    if err := rows.Scan(&e.ID, &e.Actor, &e.Action, &e.TargetType, &e.TargetID, &e.Reason, &details, &e.CreatedAt, &e.Signature, &keyID); err != nil { return err }
+    if keyID != nil { e.KeyID = *keyID }
+    _ = json.Unmarshal(details, &e.Details)
+    reason := ""
+    if e.Reason != nil { reason = *e.Reason }
+    if e.Payload, err = auditPayload(e.ID, e.CreatedAt, e.Actor, e.Action, e.TargetType, e.TargetID, reason, details); err != nil { return err }
+    if err := fn(e); err != nil { return err }
+  }
+  return rows.Err()
+}
+
+// asDetails converts a struct to audit details by its JSON encoding.
+func asDetails(v any) map[string]any {
+  b, _ := json.Marshal(v)
+  m := map[string]any{}
+  _ = json.Unmarshal(b, &m)
+  return m
+}
@@ -0,0 +1,105 @@
+package ledger
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"time-ledger-sim/go/internal/auditsig"
+)
+
+// auditSink keeps the audit records written through it.
+type auditSink struct {
+	Queries
+	records []AuditRecord
+}
+
+func (a *auditSink) InsertAudit(ctx context.Context, r AuditRecord) error {
+	a.records = append(a.records, r)
+	return nil
+}
+
+func TestAuditSignature(t *testing.T) {
+	signer, err := auditsig.New(auditsig.Config{Key: "ed25519:" + base64.StdEncoding.EncodeToString([]byte(strings.Repeat("s", 32)))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := NewWithRepo(nil, nil)
+	q := &auditSink{}
+	if err := l.audit(context.Background(), q, AuditRecord{Actor: "ops", Action: "SET_ZONE_STATUS", TargetType: "zone", TargetID: "zone-eu"}); err != nil {
+		t.Fatal(err)
+	}
+	if q.records[0].Signature != nil {
+		t.Fatal("signed without a signer")
+	}
+
+	l.SetAuditSigner(signer)
+	rec := AuditRecord{
+		Actor: "ops", Action: "SET_ZONE_STATUS", TargetType: "zone", TargetID: "zone-eu",
+		Details: map[string]any{"status": "DOWN", "limit": 50, "nested": map[string]any{"b": 1, "a": "<x>"}},
+	}
+	if err := l.audit(context.Background(), q, rec); err != nil {
+		t.Fatal(err)
+	}
+	got := q.records[1]
+	if got.ID == "" || got.CreatedAt.IsZero() || got.KeyID != signer.KeyID() {
+		t.Fatalf("record = %+v", got)
+	}
+
+	// details as jsonb hands them back: reordered keys and different spacing
+	stored := []byte(`{"limit": 50, "nested": {"a": "<x>", "b": 1}, "status": "DOWN"}`)
+	payload, err := auditPayload(got.ID, got.CreatedAt, "ops", "SET_ZONE_STATUS", "zone", "zone-eu", "", stored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !signer.Verify(payload, got.Signature) {
+		t.Fatal("signature does not verify against the stored form")
+	}
+
+	edited := []byte(`{"limit": 50, "nested": {"a": "<x>", "b": 1}, "status": "OK"}`)
+	payload, _ = auditPayload(got.ID, got.CreatedAt, "ops", "SET_ZONE_STATUS", "zone", "zone-eu", "", edited)
+	if signer.Verify(payload, got.Signature) {
+		t.Fatal("edited details still verify")
+	}
+	payload, _ = auditPayload(got.ID, got.CreatedAt, "someone-else", "SET_ZONE_STATUS", "zone", "zone-eu", "", stored)
+	if signer.Verify(payload, got.Signature) {
+		t.Fatal("changed actor still verifies")
+	}
+}
+
+func TestAuditRecordsTraceID(t *testing.T) {
+	l := NewWithRepo(nil, nil)
+	q := &auditSink{}
+	if err := l.audit(context.Background(), q, AuditRecord{Actor: "ops", Action: "SET_ZONE_STATUS", TargetType: "zone", TargetID: "zone-eu"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := q.records[0].Details["trace_id"]; ok {
+		t.Fatalf("trace_id without a trace: %v", q.records[0].Details)
+	}
+
+	rec := tracetest.NewSpanRecorder()
+	ctx, span := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)).Tracer("test").Start(context.Background(), "PUT /v1/zones/{zone_id}/status")
+	if err := l.audit(ctx, q, AuditRecord{Actor: "ops", Action: "SET_ZONE_STATUS", TargetType: "zone", TargetID: "zone-eu"}); err != nil {
+		t.Fatal(err)
+	}
+	span.End()
+	got := q.records[1]
+	if got.Details["trace_id"] != span.SpanContext().TraceID().String() {
+		t.Fatalf("details = %v, want trace %s", got.Details, span.SpanContext().TraceID())
+	}
+	var auditID string
+	for _, ev := range rec.Ended()[0].Events() {
+		for _, kv := range ev.Attributes {
+			if ev.Name == "audit" && kv.Key == "audit.id" {
+				auditID = kv.Value.AsString()
+			}
+		}
+	}
+	if auditID != got.ID {
+		t.Errorf("span audit event id = %q, want %q", auditID, got.ID)
+	}
+}
@@ -0,0 +1,196 @@
+package ledger
+
+import (
+  "context"
+  "log/slog"
+  "sync/atomic"
+  "time"
+)
+
+// Default negative balance thresholds, in units (1 unit = 1 second).
+const (
+  DefaultAccountBalanceFloor = -86400 // a day in debt
+  DefaultZoneBalanceFloor = 0
+  DefaultBalanceHysteresis = 3600
+)
+
+// BalanceThresholds drive the negative balance monitor. An account below
+// AccountFloor opens a WARN incident and a zone whose accounts sum below
+// ZoneFloor a CRITICAL one; either clears only once the balance is back at
+// floor + Hysteresis, so a balance hovering at the floor does not flap.
+type BalanceThresholds struct {
+  AccountFloor int64 `json:"account_floor_units"`
+  ZoneFloor int64 `json:"zone_floor_units"`
+  Hysteresis int64 `json:"hysteresis_units"`
+}
+
+// BalanceCheckResult counts what one monitor pass changed.
+type BalanceCheckResult struct {
+  AccountsOpened int `json:"accounts_opened"`
+  AccountsCleared int `json:"accounts_cleared"`
+  ZonesOpened int `json:"zones_opened"`
+  ZonesCleared int `json:"zones_cleared"`
+}
+
+func (r BalanceCheckResult) changed() bool {
+  return r.AccountsOpened+r.AccountsCleared+r.ZonesOpened+r.ZonesCleared > 0
+}
+
+// maxBalanceAlertsPerCheck bounds the incidents one pass opens per scope; the
+// rest are picked up by the next pass, worst balances first.
+const maxBalanceAlertsPerCheck = 100
+
+// balanceAlertScope holds the SQL that differs between account and zone alerts:
+// the current balance per subject and the incident each breach opens.
+type balanceAlertScope struct {
+  scope, severity, title string
+  balances string // subject, zone_id, balance_units per subject
+}
+
+var balanceAlertScopes = []balanceAlertScope{
+  {
+    scope: "account", severity: "WARN", title: "Account balance below floor",
+    balances: `SELECT a.id AS subject, a.zone_id, COALESCE(b.balance_units, 0) AS balance_units
+      FROM accounts a LEFT JOIN balances b ON b.account_id = a.id`,
+  },
+  {
+    scope: "zone", severity: "CRITICAL", title: "Zone aggregate balance below floor",
+    balances: `SELECT z.id AS subject, z.id AS zone_id, COALESCE(SUM(b.balance_units), 0)::bigint AS balance_units
+      FROM zones z
+      LEFT JOIN accounts a ON a.zone_id = z.id
+      LEFT JOIN balances b ON b.account_id = a.id
+      WHERE z.retired_at IS NULL
+      GROUP BY z.id`,
+  },
+}
+
+// CheckNegativeBalances opens incidents for accounts and zones that dropped
+// below their floor since the last pass and resolves the ones that recovered
+// past floor + hysteresis, in one transaction on the primary.
+func (l *Ledger) CheckNegativeBalances(ctx context.Context, th BalanceThresholds) (*BalanceCheckResult, error) {
+  tx, err := l.db.Begin(ctx)
+  if err != nil { return nil, err }
+  defer func(){ _ = tx.Rollback(ctx) }()
+
+  var res BalanceCheckResult
+  for _, s := range balanceAlertScopes {
+    floor := th.AccountFloor
+    opened, cleared := &res.AccountsOpened, &res.AccountsCleared
+    if s.scope == "zone" { floor, opened, cleared = th.ZoneFloor, &res.ZonesOpened, &res.ZonesCleared }
+
+    // clear first so a subject that recovered and fell again within one
+    // interval keeps its incident instead of getting a second one
+    var n int
+    err := tx.QueryRow(ctx, `
+      WITH cur AS (`+s.balances+`),
+      cleared AS (
+        DELETE FROM balance_alerts x
+        WHERE x.scope = $1
+          AND COALESCE((SELECT cur.balance_units FROM cur WHERE cur.subject = x.subject), 0) >= $2
+        RETURNING x.incident_id, x.subject
+      ),
+      resolved AS (
+        UPDATE incidents i
+        SET status = 'RESOLVED', updated_at = now(),
+          details = i.details || jsonb_build_object('resolved_by', 'balance-monitor',
+            'recovered_units', COALESCE((SELECT cur.balance_units FROM cur WHERE cur.subject = c.subject), 0))
+        FROM cleared c
+        WHERE i.id = c.incident_id AND i.status <> 'RESOLVED'
+      )
+      SELECT COUNT(*) FROM cleared
+    `, s.scope, floor+th.Hysteresis).Scan(&n)
+    if err != nil { return nil, err }
+    *cleared = n
+
+    rows, err := tx.Query(ctx, `
+      WITH cur AS (`+s.balances+`)
+      SELECT cur.subject, cur.zone_id, cur.balance_units
+      FROM cur
+      WHERE cur.balance_units < $2
+        AND NOT EXISTS (SELECT 1 FROM balance_alerts x WHERE x.scope = $1 AND x.subject = cur.subject)
+      ORDER BY cur.balance_units, cur.subject
+      LIMIT $3
+    `, s.scope, floor, maxBalanceAlertsPerCheck)
+    if err != nil { return nil, err }
+    type breach struct { subject, zoneID string; balance int64 }
+    var breaches []breach
+    for rows.Next() {
+      var b breach
+      if err := rows.Scan(&b.subject, &b.zoneID, &b.balance); err != nil { rows.Close(); return nil, err }
+      breaches = append(breaches, b)
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil { return nil, err }
+
+    for _, b := range breaches {
+      _, err := tx.Exec(ctx, `
+        WITH inc AS (
+          INSERT INTO incidents(zone_id,severity,title,details)
+          VALUES($3,$4,$5, jsonb_build_object('monitor','negative_balance','scope',$1::text,'subject',$2::text,'balance_units',$6::bigint,'floor_units',$7::bigint))
+          RETURNING id
+        )
+        INSERT INTO balance_alerts(scope,subject,zone_id,incident_id,balance_units,floor_units)
+        SELECT $1, $2, $3, id, $6, $7 FROM inc
+      `, s.scope, b.subject, b.zoneID, s.severity, s.title, b.balance, floor)
+      if err != nil { return nil, err }
+      l.log.WarnContext(ctx, "balance below floor", "scope", s.scope, "subject", b.subject, "zone_id", b.zoneID, "balance_units", b.balance, "floor_units", floor)
+    }
+    *opened = len(breaches)
+  }
+
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  return &res, nil
+}
+
+// BalanceMonitor runs CheckNegativeBalances on an interval. Interval and
+// thresholds can change while it runs; an interval of 0 pauses it.
+type BalanceMonitor struct {
+  led *Ledger
+  log *slog.Logger
+  interval atomic.Int64
+  thresholds atomic.Pointer[BalanceThresholds]
+}
+
+// balanceMonitorIdle is how often a paused monitor looks for a new interval.
+const balanceMonitorIdle = 5 * time.Second
+
+func NewBalanceMonitor(led *Ledger, log *slog.Logger) *BalanceMonitor {
+  m := &BalanceMonitor{led: led, log: log}
+  m.SetTuning(30*time.Second, BalanceThresholds{
+    AccountFloor: DefaultAccountBalanceFloor, ZoneFloor: DefaultZoneBalanceFloor, Hysteresis: DefaultBalanceHysteresis,
+  })
+  return m
+}
+
+func (m *BalanceMonitor) SetTuning(interval time.Duration, th BalanceThresholds) {
+  m.interval.Store(int64(interval))
+  m.thresholds.Store(&th)
+}
+
+func (m *BalanceMonitor) Run(ctx context.Context) {
+  next := func() time.Duration {
+    iv := time.Duration(m.interval.Load())
+    if iv <= 0 { return balanceMonitorIdle }
+    return m.led.scaledInterval(iv)
+  }
+  timer := time.NewTimer(next())
+  defer timer.Stop()
+  for {
+    select {
+    case <-ctx.Done():
+      return
+    case <-timer.C:
+      timer.Reset(next())
+      if m.interval.Load() <= 0 { continue }
+      res, err := m.led.CheckNegativeBalances(context.WithoutCancel(ctx), *m.thresholds.Load())
+      if err != nil {
+        m.log.Warn("balance monitor check failed", "err", err.Error())
+        continue
+      }
+      if res.changed() {
+        m.log.Info("balance monitor updated incidents", "accounts_opened", res.AccountsOpened, "accounts_cleared", res.AccountsCleared,
+          "zones_opened", res.ZonesOpened, "zones_cleared", res.ZonesCleared)
+      }
+    }
+  }
+}
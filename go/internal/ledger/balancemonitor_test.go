@@ -0,0 +1,96 @@
+package ledger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"time-ledger-sim/go/internal/store/storetest"
+)
+
+func TestCheckNegativeBalances(t *testing.T) {
+	db := storetest.Open(t)
+	ctx := context.Background()
+	l := New(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	// floors far below anything other tests leave behind, so only this test's
+	// account and zone breach them
+	const floor = -(int64(1) << 50)
+	th := BalanceThresholds{AccountFloor: floor, ZoneFloor: floor, Hysteresis: 1000}
+
+	suffix := strings.ReplaceAll(uuid.NewString()[:8], "-", "")
+	payer, payee := "zone-neg-a-"+suffix, "zone-neg-b-"+suffix
+	for _, z := range []string{payer, payee} {
+		if _, err := l.CreateZone(ctx, CreateZoneInput{ID: z, Name: z, Actor: "test"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	from, to := "neg-from-"+suffix, "neg-to-"+suffix
+	if _, err := l.CreateAccount(ctx, CreateAccountInput{AccountID: to, ZoneID: payee, Actor: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	transfer := func(src, dst string, amount int64) {
+		t.Helper()
+		_, _, err := l.CreateTransfer(ctx, CreateTransferInput{
+			RequestID: uuid.NewString(), PayloadHash: "h", FromAccount: src, ToAccount: dst, AmountUnits: amount, ZoneID: payer,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// open alerts for the account and its zone: incident id and status, "" when none
+	alert := func(scope, subject string) (string, string) {
+		t.Helper()
+		var id, status string
+		_ = db.QueryRow(ctx, `SELECT i.id::text, i.status FROM balance_alerts x JOIN incidents i ON i.id = x.incident_id
+			WHERE x.scope=$1 AND x.subject=$2`, scope, subject).Scan(&id, &status)
+		return id, status
+	}
+	check := func() {
+		t.Helper()
+		if _, err := l.CheckNegativeBalances(ctx, th); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	transfer(from, to, 1<<51)
+	check()
+	accountInc, _ := alert("account", from)
+	zoneInc, _ := alert("zone", payer)
+	if accountInc == "" || zoneInc == "" {
+		t.Fatalf("no alerts opened: account %q zone %q", accountInc, zoneInc)
+	}
+	var severity string
+	if err := db.QueryRow(ctx, `SELECT severity FROM incidents WHERE id=$1`, zoneInc).Scan(&severity); err != nil || severity != "CRITICAL" {
+		t.Fatalf("zone incident severity = %q (%v)", severity, err)
+	}
+	if id, _ := alert("zone", payee); id != "" {
+		t.Fatalf("receiving zone alerted: %s", id)
+	}
+
+	// back above the floor but inside the hysteresis band: nothing changes
+	transfer(to, from, (1<<51)-(1<<50)+500)
+	check()
+	if id, status := alert("account", from); id != accountInc || status != "OPEN" {
+		t.Fatalf("inside the band: incident %q status %q", id, status)
+	}
+
+	// past floor + hysteresis: the alert clears and the incident is resolved
+	transfer(to, from, 600)
+	check()
+	if id, _ := alert("account", from); id != "" {
+		t.Fatalf("alert not cleared: %s", id)
+	}
+	var status string
+	if err := db.QueryRow(ctx, `SELECT status FROM incidents WHERE id=$1`, accountInc).Scan(&status); err != nil || status != "RESOLVED" {
+		t.Fatalf("account incident status = %q (%v)", status, err)
+	}
+	var opened int
+	if err := db.QueryRow(ctx, `SELECT COUNT(*) FROM incidents WHERE details->>'monitor'='negative_balance' AND details->>'subject'=$1`, from).Scan(&opened); err != nil || opened != 1 {
+		t.Fatalf("incidents opened for the account = %d (%v)", opened, err)
+	}
+}
@@ -0,0 +1,107 @@
+package ledger
+
+import (
+  "context"
+  "encoding/base64"
+  "fmt"
+  "strconv"
+  "strings"
+  "time"
+)
+
+// Balance list orders.
+const (
+  BalanceOrderUpdated = "updated" // most recently changed first
+  BalanceOrderAccount = "account" // by account id
+)
+
+// BalanceFilter narrows ListBalances; zero values do not filter.
+type BalanceFilter struct {
+  Limit int // page size, 1-500, default 100
+  AccountTag string // accounts carrying this tag
+  ZoneID string
+  Order string // BalanceOrderUpdated (default) or BalanceOrderAccount
+  Cursor string // NextCursor of the previous page
+  AsOf time.Time // zero: current balances
+}
+
+type BalancePage struct {
+  Balances []BalanceRow `json:"balances"`
+  NextCursor string `json:"next_cursor,omitempty"` // "" on the last page
+}
+
+// ListBalances pages through balances with a keyset cursor, so pages stay
+// consistent while transfers post. With AsOf it rebuilds each balance from
+// the postings up to that instant (sim clock) instead of reading the
+// projection; accounts without postings by then are left out.
+func (l *Ledger) ListBalances(ctx context.Context, f BalanceFilter) (*BalancePage, error) {
+  if f.Limit <= 0 || f.Limit > 500 { f.Limit = 100 }
+  if f.Order == "" { f.Order = BalanceOrderUpdated }
+  if f.Order != BalanceOrderUpdated && f.Order != BalanceOrderAccount { return nil, fmt.Errorf("order must be %s or %s", BalanceOrderUpdated, BalanceOrderAccount) }
+  afterAt, afterID, err := decodeBalanceCursor(f.Cursor, f.Order)
+  if err != nil { return nil, err }
+
+  args := []any{f.Limit + 1, strings.ToLower(f.AccountTag), f.ZoneID}
+  arg := func(v any) string { args = append(args, v); return "$" + strconv.Itoa(len(args)) }
+
+  src := `SELECT account_id, balance_units, updated_at FROM balances`
+  if !f.AsOf.IsZero() {
+    src = `
+      SELECT account_id, SUM(CASE WHEN direction='CREDIT' THEN amount_units ELSE -amount_units END)::bigint, MAX(created_at)
+      FROM postings WHERE created_at <= ` + arg(f.AsOf) + ` GROUP BY account_id`
+  }
+  keyset, order := "", `b.updated_at DESC, b.account_id DESC`
+  if f.Order == BalanceOrderAccount { order = `b.account_id` }
+  switch {
+  case afterID == "":
+  case f.Order == BalanceOrderAccount:
+    keyset = `AND b.account_id > ` + arg(afterID)
+  default:
+    keyset = `AND (b.updated_at, b.account_id) < (` + arg(afterAt) + `, ` + arg(afterID) + `)`
+  }
+
+  rows, err := l.ro.Query(ctx, `
+    SELECT b.account_id, a.zone_id, b.balance_units, b.updated_at
+    FROM (`+src+`) b(account_id, balance_units, updated_at)
+    JOIN accounts a ON a.id=b.account_id
+    WHERE ($2='' OR EXISTS (SELECT 1 FROM account_tags g WHERE g.account_id=b.account_id AND g.tag=$2))
+      AND ($3='' OR a.zone_id=$3)
+      `+keyset+`
+    ORDER BY `+order+`
+    LIMIT $1
+  `, args...)
+  if err != nil { return nil, err }
+  defer rows.Close()
+
+  page := BalancePage{Balances: []BalanceRow{}}
+  for rows.Next() {
+    var b BalanceRow
+    if err := rows.Scan(&b.AccountID, &b.ZoneID, &b.BalanceUnits, &b.UpdatedAt); err != nil { return nil, err }
+    page.Balances = append(page.Balances, b)
+  }
+  if err := rows.Err(); err != nil { return nil, err }
+  if len(page.Balances) > f.Limit {
+    page.Balances = page.Balances[:f.Limit]
+    page.NextCursor = encodeBalanceCursor(page.Balances[f.Limit-1], f.Order)
+  }
+  return &page, nil
+}
+
+// A balance cursor is the last row's sort key: "<updated_at unix ns>|<account>"
+// for BalanceOrderUpdated or "|<account>", base64url-encoded.
+func encodeBalanceCursor(b BalanceRow, order string) string {
+  key := "|" + b.AccountID
+  if order == BalanceOrderUpdated { key = strconv.FormatInt(b.UpdatedAt.UnixNano(), 10) + key }
+  return base64.RawURLEncoding.EncodeToString([]byte(key))
+}
+
+func decodeBalanceCursor(cursor, order string) (time.Time, string, error) {
+  if cursor == "" { return time.Time{}, "", nil }
+  raw, err := base64.RawURLEncoding.DecodeString(cursor)
+  at, id, ok := strings.Cut(string(raw), "|")
+  if err != nil || !ok || id == "" || (at == "") != (order == BalanceOrderAccount) { return time.Time{}, "", fmt.Errorf("invalid cursor for order %s", order) }
+  if at == "" { return time.Time{}, id, nil }
+  ns, err := strconv.ParseInt(at, 10, 64)
+  if err != nil { return time.Time{}, "", fmt.Errorf("invalid cursor for order %s", order) }
+  return time.Unix(0, ns).UTC(), id, nil
+}
@@ -0,0 +1,87 @@
+package ledger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"time-ledger-sim/go/internal/store/storetest"
+)
+
+func TestBalanceCursor(t *testing.T) {
+	b := BalanceRow{AccountID: "acct|1", UpdatedAt: time.Date(2026, 1, 2, 3, 4, 5, 123456000, time.UTC)}
+	at, id, err := decodeBalanceCursor(encodeBalanceCursor(b, BalanceOrderUpdated), BalanceOrderUpdated)
+	if err != nil || id != b.AccountID || !at.Equal(b.UpdatedAt) {
+		t.Fatalf("updated cursor = %v %q %v", at, id, err)
+	}
+	if _, id, err := decodeBalanceCursor(encodeBalanceCursor(b, BalanceOrderAccount), BalanceOrderAccount); err != nil || id != b.AccountID {
+		t.Fatalf("account cursor = %q %v", id, err)
+	}
+	for _, c := range []string{"not base64!", encodeBalanceCursor(b, BalanceOrderAccount)} {
+		if _, _, err := decodeBalanceCursor(c, BalanceOrderUpdated); err == nil {
+			t.Errorf("%q: no error", c)
+		}
+	}
+}
+
+func TestListBalancesPagesAndAsOf(t *testing.T) {
+	db := storetest.Open(t)
+	ctx := context.Background()
+	l := New(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	suffix := uuid.NewString()[:8]
+	cohort := "page-" + suffix
+	src, a, b := "page-src-"+suffix, "page-a-"+suffix, "page-b-"+suffix
+	now := l.clock.Now()
+	for i, tr := range []struct {
+		to string
+		at time.Time
+	}{{a, now.Add(-time.Hour)}, {b, now}} {
+		_, _, err := l.CreateTransfer(ctx, CreateTransferInput{
+			RequestID: "page-" + uuid.NewString(), PayloadHash: "h", FromAccount: src, ToAccount: tr.to, AmountUnits: int64(10 * (i + 1)), ZoneID: "zone-eu", At: tr.at,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, acct := range []string{src, a, b} {
+		if _, err := l.TagAccount(ctx, acct, TagAccountInput{Tags: []string{cohort}, Actor: "test"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var seen []string
+	f := BalanceFilter{Limit: 2, AccountTag: cohort, Order: BalanceOrderAccount}
+	for {
+		page, err := l.ListBalances(ctx, f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, r := range page.Balances {
+			seen = append(seen, r.AccountID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		f.Cursor = page.NextCursor
+	}
+	if len(seen) != 3 || seen[0] != a || seen[1] != b || seen[2] != src {
+		t.Fatalf("pages = %v", seen)
+	}
+
+	page, err := l.ListBalances(ctx, BalanceFilter{AccountTag: cohort, AsOf: now.Add(-30 * time.Minute)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]int64{}
+	for _, r := range page.Balances {
+		got[r.AccountID] = r.BalanceUnits
+	}
+	if len(got) != 2 || got[a] != 10 || got[src] != -10 {
+		t.Fatalf("as_of balances = %v", got)
+	}
+}
@@ -0,0 +1,88 @@
+package ledger
+
+import (
+  "context"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+)
+
+// BalanceSheetAccount is one account's movement within a balance sheet.
+type BalanceSheetAccount struct {
+  AccountID string `json:"account_id"`
+  CreditsUnits int64 `json:"credits_units"`
+  DebitsUnits int64 `json:"debits_units"`
+  NetUnits int64 `json:"net_units"` // credits - debits
+  Postings int64 `json:"postings"`
+}
+
+// BalanceSheet totals the postings to a zone's accounts over [From, To).
+// Transfers inside the zone add equally to credits and debits, so NetUnits
+// equals CrossZoneInUnits - CrossZoneOutUnits.
+type BalanceSheet struct {
+  ZoneID string `json:"zone_id"`
+  From time.Time `json:"from"`
+  To time.Time `json:"to"`
+  CreditsUnits int64 `json:"credits_units"`
+  DebitsUnits int64 `json:"debits_units"`
+  NetUnits int64 `json:"net_units"`
+  Postings int64 `json:"postings"`
+  Accounts int64 `json:"accounts"` // with postings in the range
+  CrossZoneInUnits int64 `json:"cross_zone_in_units"` // credited from accounts in other zones
+  CrossZoneOutUnits int64 `json:"cross_zone_out_units"` // debited to accounts in other zones
+  TopAccounts []BalanceSheetAccount `json:"top_accounts"` // largest |net| first
+}
+
+const maxBalanceSheetTop = 100
+
+// DefaultBalanceSheetRange is the range reported when from is not given.
+const DefaultBalanceSheetRange = 24 * time.Hour
+
+// GetBalanceSheet aggregates the zone's postings between from and to in one
+// query on the read replica. A zero to is the sim clock's now and a zero from
+// is DefaultBalanceSheetRange before to. Retired zones keep their history, so
+// they are reported too.
+func (l *Ledger) GetBalanceSheet(ctx context.Context, zoneID string, from, to time.Time, top int) (*BalanceSheet, error) {
+  if to.IsZero() { to = l.clock.Now() }
+  if from.IsZero() { from = to.Add(-DefaultBalanceSheetRange) }
+  if !from.Before(to) { return nil, fmt.Errorf("from must be before to") }
+  if top <= 0 || top > maxBalanceSheetTop { top = 10 }
+  bs := BalanceSheet{ZoneID: zoneID, From: from, To: to}
+  var topJSON []byte
+  err := l.ro.QueryRow(ctx, `
+    WITH p AS (
+      SELECT p.account_id, p.direction, p.amount_units, c.zone_id <> $1 AS cross_zone
+      FROM accounts a
+      JOIN postings p ON p.account_id = a.id
+      JOIN transactions t ON t.id = p.txn_id
+      JOIN accounts c ON c.id = CASE WHEN p.direction='DEBIT' THEN t.to_account ELSE t.from_account END
+      WHERE a.zone_id=$1 AND p.created_at >= $2 AND p.created_at < $3
+    ), acct AS (
+      SELECT account_id,
+        COALESCE(SUM(amount_units) FILTER (WHERE direction='CREDIT'),0)::bigint AS credits,
+        COALESCE(SUM(amount_units) FILTER (WHERE direction='DEBIT'),0)::bigint AS debits,
+        COUNT(*) AS postings
+      FROM p GROUP BY account_id
+    )
+    SELECT
+      COALESCE((SELECT SUM(credits) FROM acct),0)::bigint,
+      COALESCE((SELECT SUM(debits) FROM acct),0)::bigint,
+      COALESCE((SELECT SUM(postings) FROM acct),0)::bigint,
+      (SELECT COUNT(*) FROM acct),
+      COALESCE((SELECT SUM(amount_units) FROM p WHERE cross_zone AND direction='CREDIT'),0)::bigint,
+      COALESCE((SELECT SUM(amount_units) FROM p WHERE cross_zone AND direction='DEBIT'),0)::bigint,
+      COALESCE((
+        SELECT jsonb_agg(jsonb_build_object('account_id',account_id,'credits_units',credits,'debits_units',debits,'net_units',credits-debits,'postings',postings) ORDER BY abs(credits-debits) DESC, account_id)
+        FROM (SELECT * FROM acct ORDER BY abs(credits-debits) DESC, account_id LIMIT $4) x
+      ), '[]'::jsonb)
+    FROM zones z WHERE z.id=$1
+  `, zoneID, from, to, top).Scan(&bs.CreditsUnits, &bs.DebitsUnits, &bs.Postings, &bs.Accounts, &bs.CrossZoneInUnits, &bs.CrossZoneOutUnits, &topJSON)
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrZoneNotFound }
+  if err != nil { return nil, err }
+  bs.NetUnits = bs.CreditsUnits - bs.DebitsUnits
+  if err := json.Unmarshal(topJSON, &bs.TopAccounts); err != nil { return nil, err }
+  return &bs, nil
+}
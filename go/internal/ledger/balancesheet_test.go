@@ -0,0 +1,56 @@
+package ledger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"time-ledger-sim/go/internal/store/storetest"
+)
+
+func TestGetBalanceSheet(t *testing.T) {
+	db := storetest.Open(t)
+	ctx := context.Background()
+	l := New(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	from := l.clock.Now().Add(-time.Second)
+
+	suffix := uuid.NewString()[:8]
+	eu1, eu2, na := "bs-eu1-"+suffix, "bs-eu2-"+suffix, "bs-na-"+suffix
+	transfer := func(zone, fromAcct, toAcct string, units int64) {
+		t.Helper()
+		_, _, err := l.CreateTransfer(ctx, CreateTransferInput{
+			RequestID: "bs-" + uuid.NewString(), PayloadHash: "h", FromAccount: fromAcct, ToAccount: toAcct, AmountUnits: units, ZoneID: zone,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	transfer("zone-na", na, "bs-na-sink-"+suffix, 1) // creates na in zone-na
+	transfer("zone-eu", eu1, eu2, 100)               // inside zone-eu
+	transfer("zone-eu", eu2, na, 30)                 // out to zone-na
+
+	bs, err := l.GetBalanceSheet(ctx, "zone-eu", from, l.clock.Now().Add(time.Second), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var acct1 *BalanceSheetAccount
+	for i := range bs.TopAccounts {
+		if bs.TopAccounts[i].AccountID == eu1 {
+			acct1 = &bs.TopAccounts[i]
+		}
+	}
+	if acct1 == nil || acct1.NetUnits != -100 || acct1.Postings != 1 {
+		t.Fatalf("eu1 = %+v", acct1)
+	}
+	if bs.CrossZoneOutUnits < 30 || bs.NetUnits != bs.CrossZoneInUnits-bs.CrossZoneOutUnits {
+		t.Fatalf("sheet = %+v", bs)
+	}
+
+	if _, err := l.GetBalanceSheet(ctx, "zone-nowhere", time.Time{}, time.Time{}, 0); !IsZoneNotFound(err) {
+		t.Fatalf("unknown zone: err = %v", err)
+	}
+}
@@ -0,0 +1,265 @@
+package ledger
+
+import (
+  "context"
+  "crypto/rand"
+  "encoding/base64"
+  "errors"
+  "fmt"
+  "net/url"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+)
+
+var ErrBalanceSubscriptionNotFound = errors.New("balance subscription not found")
+
+func IsBalanceSubscriptionNotFound(err error) bool { return errors.Is(err, ErrBalanceSubscriptionNotFound) }
+
+// Balance subscription triggers: CHANGE fires on every transfer that moves
+// the account's balance, CROSS when the balance crosses ThresholdUnits in
+// either direction.
+const (
+  BalanceTriggerChange = "CHANGE"
+  BalanceTriggerCross = "CROSS"
+)
+
+// webhookSecretPrefix starts every webhook signing secret.
+const webhookSecretPrefix = "whsec_"
+
+// BalanceSubscription watches one account's balance (migration 0051). The
+// balance notifier POSTs its notifications to WebhookURL, signed with the
+// subscription's secret; every subscription can also be streamed over SSE.
+type BalanceSubscription struct {
+  ID string `json:"id"`
+  AccountID string `json:"account_id"`
+  Trigger string `json:"trigger"`
+  ThresholdUnits *int64 `json:"threshold_units"`
+  WebhookURL *string `json:"webhook_url"`
+  CreatedBy string `json:"created_by"`
+  CreatedAt time.Time `json:"created_at"`
+}
+
+// NewBalanceSubscription is a created subscription with its webhook secret,
+// which is only ever returned here.
+type NewBalanceSubscription struct {
+  BalanceSubscription
+  Secret string `json:"secret,omitempty"`
+}
+
+// BalanceWebhook is a subscription with the secret its deliveries are
+// signed with.
+type BalanceWebhook struct {
+  BalanceSubscription
+  Secret string
+}
+
+// BalanceChange is what one posted transfer did to one account's balance.
+type BalanceChange struct {
+  EventID string
+  TransactionID string
+  ZoneID string
+  AccountID string
+  BalanceUnits int64 // after the transfer
+  DeltaUnits int64
+  At string
+}
+
+// Matches reports whether the change fires the subscription. A balance that
+// lands on the threshold has crossed it going up; one that leaves it going
+// down has crossed it too.
+func (s *BalanceSubscription) Matches(c BalanceChange) bool {
+  if c.AccountID != s.AccountID || c.DeltaUnits == 0 { return false }
+  if s.Trigger != BalanceTriggerCross { return true }
+  if s.ThresholdUnits == nil { return false }
+  t := *s.ThresholdUnits
+  return (c.BalanceUnits-c.DeltaUnits < t) != (c.BalanceUnits < t)
+}
+
+// BalanceNotification is the body of a webhook delivery and of an SSE event.
+type BalanceNotification struct {
+  EventID string `json:"event_id"`
+  SubscriptionID string `json:"subscription_id"`
+  AccountID string `json:"account_id"`
+  Trigger string `json:"trigger"`
+  ThresholdUnits *int64 `json:"threshold_units,omitempty"`
+  TransactionID string `json:"transaction_id"`
+  ZoneID string `json:"zone_id"`
+  BalanceUnits int64 `json:"balance_units"`
+  PreviousUnits int64 `json:"previous_units"`
+  DeltaUnits int64 `json:"delta_units"`
+  At string `json:"at"`
+}
+
+// Notification is the subscription's notification of c.
+func (s *BalanceSubscription) Notification(c BalanceChange) BalanceNotification {
+  return BalanceNotification{
+    EventID: c.EventID, SubscriptionID: s.ID, AccountID: s.AccountID, Trigger: s.Trigger, ThresholdUnits: s.ThresholdUnits,
+    TransactionID: c.TransactionID, ZoneID: c.ZoneID,
+    BalanceUnits: c.BalanceUnits, PreviousUnits: c.BalanceUnits - c.DeltaUnits, DeltaUnits: c.DeltaUnits, At: c.At,
+  }
+}
+
+const balanceSubscriptionCols = `id::text, account_id, trigger, threshold_units, webhook_url, created_by, created_at`
+
+func scanBalanceSubscription(row pgx.Row) (*BalanceSubscription, error) {
+  var s BalanceSubscription
+  if err := row.Scan(&s.ID, &s.AccountID, &s.Trigger, &s.ThresholdUnits, &s.WebhookURL, &s.CreatedBy, &s.CreatedAt); err != nil { return nil, err }
+  return &s, nil
+}
+
+type CreateBalanceSubscriptionInput struct {
+  AccountID string
+  Trigger string // default CHANGE
+  ThresholdUnits *int64 // required for CROSS, rejected for CHANGE
+  WebhookURL string // absolute http(s) URL; empty: SSE only
+  Actor string
+  Reason string
+}
+
+func (in *CreateBalanceSubscriptionInput) normalize() error {
+  if in.Trigger == "" { in.Trigger = BalanceTriggerChange }
+  switch in.Trigger {
+  case BalanceTriggerChange:
+    if in.ThresholdUnits != nil { return fmt.Errorf("threshold_units only applies to CROSS") }
+  case BalanceTriggerCross:
+    if in.ThresholdUnits == nil { return fmt.Errorf("threshold_units required for CROSS") }
+  default:
+    return fmt.Errorf("trigger must be CHANGE or CROSS")
+  }
+  if in.WebhookURL != "" {
+    u, err := url.Parse(in.WebhookURL)
+    if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+      return fmt.Errorf("webhook_url must be an absolute http or https URL")
+    }
+    if len(in.WebhookURL) > 2048 { return fmt.Errorf("webhook_url must be at most 2048 bytes") }
+  }
+  return nil
+}
+
+// CreateBalanceSubscription subscribes to an existing account's balance. A
+// subscription with a webhook gets a signing secret, returned in the result
+// only.
+func (l *Ledger) CreateBalanceSubscription(ctx context.Context, in CreateBalanceSubscriptionInput) (*NewBalanceSubscription, error) {
+  if err := in.normalize(); err != nil { return nil, err }
+  var secret string
+  if in.WebhookURL != "" {
+    b := make([]byte, 24)
+    if _, err := rand.Read(b); err != nil { return nil, err }
+    secret = webhookSecretPrefix + base64.RawURLEncoding.EncodeToString(b)
+  }
+
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  if err := l.checkActors(ctx, tx, in.Actor); err != nil { return nil, err }
+  s, err := scanBalanceSubscription(tx.QueryRow(ctx, `
+    INSERT INTO balance_subscriptions(account_id, trigger, threshold_units, webhook_url, secret, created_by)
+    SELECT id, $2, $3, NULLIF($4,''), NULLIF($5,''), $6 FROM accounts WHERE id=$1
+    RETURNING `+balanceSubscriptionCols, in.AccountID, in.Trigger, in.ThresholdUnits, in.WebhookURL, secret, in.Actor))
+  if errors.Is(err, pgx.ErrNoRows) { return nil, fmt.Errorf("%w: %s", ErrAccountNotFound, in.AccountID) }
+  if err != nil { return nil, err }
+
+  err = l.audit(ctx, pgQueries{tx}, AuditRecord{
+    Actor: in.Actor, Action: "CREATE_BALANCE_SUBSCRIPTION", TargetType: "account", TargetID: in.AccountID, Reason: in.Reason,
+    Details: map[string]any{"subscription_id": s.ID, "trigger": s.Trigger, "threshold_units": s.ThresholdUnits, "webhook_url": s.WebhookURL},
+  })
+  if err != nil { return nil, err }
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  return &NewBalanceSubscription{BalanceSubscription: *s, Secret: secret}, nil
+}
+
+// ListBalanceSubscriptions lists subscriptions, optionally one account's,
+// newest first.
+func (l *Ledger) ListBalanceSubscriptions(ctx context.Context, accountID string) ([]BalanceSubscription, error) {
+  rows, err := l.db.Query(ctx, `
+    SELECT `+balanceSubscriptionCols+`
+    FROM balance_subscriptions
+    WHERE $1 = '' OR account_id = $1
+    ORDER BY created_at DESC, id
+    LIMIT 500
+  `, accountID)
+  if err != nil { return nil, err }
+  defer rows.Close()
+  out := []BalanceSubscription{}
+  for rows.Next() {
+    s, err := scanBalanceSubscription(rows)
+    if err != nil { return nil, err }
+    out = append(out, *s)
+  }
+  return out, rows.Err()
+}
+
+func (l *Ledger) GetBalanceSubscription(ctx context.Context, id string) (*BalanceSubscription, error) {
+  s, err := scanBalanceSubscription(l.db.QueryRow(ctx, `SELECT `+balanceSubscriptionCols+` FROM balance_subscriptions WHERE id::text=$1`, id))
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrBalanceSubscriptionNotFound }
+  return s, err
+}
+
+// DeleteBalanceSubscription removes a subscription and its delivery records.
+// Its SSE streams end at their next keepalive.
+func (l *Ledger) DeleteBalanceSubscription(ctx context.Context, id, actor, reason string) (*BalanceSubscription, error) {
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  if err := l.checkActors(ctx, tx, actor); err != nil { return nil, err }
+  s, err := scanBalanceSubscription(tx.QueryRow(ctx, `DELETE FROM balance_subscriptions WHERE id::text=$1 RETURNING `+balanceSubscriptionCols, id))
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrBalanceSubscriptionNotFound }
+  if err != nil { return nil, err }
+
+  err = l.audit(ctx, pgQueries{tx}, AuditRecord{
+    Actor: actor, Action: "DELETE_BALANCE_SUBSCRIPTION", TargetType: "account", TargetID: s.AccountID, Reason: reason,
+    Details: map[string]any{"subscription_id": s.ID},
+  })
+  if err != nil { return nil, err }
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  return s, nil
+}
+
+// BalanceWebhooks returns the webhook subscriptions of the accounts, for the
+// balance notifier.
+func (l *Ledger) BalanceWebhooks(ctx context.Context, accountIDs []string) ([]BalanceWebhook, error) {
+  rows, err := l.db.Query(ctx, `
+    SELECT `+balanceSubscriptionCols+`, secret
+    FROM balance_subscriptions
+    WHERE account_id = ANY($1) AND webhook_url IS NOT NULL
+    ORDER BY created_at, id
+  `, accountIDs)
+  if err != nil { return nil, err }
+  defer rows.Close()
+  var out []BalanceWebhook
+  for rows.Next() {
+    var w BalanceWebhook
+    s := &w.BalanceSubscription
+    if err := rows.Scan(&s.ID, &s.AccountID, &s.Trigger, &s.ThresholdUnits, &s.WebhookURL, &s.CreatedBy, &s.CreatedAt, &w.Secret); err != nil { return nil, err }
+    out = append(out, w)
+  }
+  return out, rows.Err()
+}
+
+// BalanceDelivered reports whether the event was delivered to the
+// subscription's webhook already.
+func (l *Ledger) BalanceDelivered(ctx context.Context, subscriptionID, eventID string) (bool, error) {
+  var delivered bool
+  err := l.db.QueryRow(ctx, `
+    SELECT EXISTS (SELECT 1 FROM balance_deliveries WHERE subscription_id=$1::uuid AND event_id=$2::uuid AND delivered_at IS NOT NULL)
+  `, subscriptionID, eventID).Scan(&delivered)
+  return delivered, err
+}
+
+// RecordBalanceDelivery records a webhook delivery attempt: delivered when
+// deliveryErr is empty. statusCode 0 means there was no response. A
+// subscription deleted meanwhile records nothing.
+func (l *Ledger) RecordBalanceDelivery(ctx context.Context, subscriptionID, eventID string, statusCode int, deliveryErr string) error {
+  _, err := l.db.Exec(ctx, `
+    INSERT INTO balance_deliveries(subscription_id, event_id, attempts, status_code, error, delivered_at)
+    SELECT id, $2::uuid, 1, NULLIF($3,0), NULLIF($4,''), CASE WHEN $4='' THEN now() END
+    FROM balance_subscriptions WHERE id=$1::uuid
+    ON CONFLICT (subscription_id, event_id) DO UPDATE
+      SET attempts = balance_deliveries.attempts + 1, status_code = EXCLUDED.status_code, error = EXCLUDED.error,
+          delivered_at = EXCLUDED.delivered_at, updated_at = now()
+  `, subscriptionID, eventID, statusCode, deliveryErr)
+  return err
+}
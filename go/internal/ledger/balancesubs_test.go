@@ -0,0 +1,122 @@
+package ledger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"time-ledger-sim/go/internal/store/storetest"
+)
+
+func TestBalanceSubscriptionMatches(t *testing.T) {
+	threshold := int64(100)
+	change := &BalanceSubscription{AccountID: "a", Trigger: BalanceTriggerChange}
+	cross := &BalanceSubscription{AccountID: "a", Trigger: BalanceTriggerCross, ThresholdUnits: &threshold}
+	for _, tc := range []struct {
+		name           string
+		sub            *BalanceSubscription
+		account        string
+		balance, delta int64
+		want           bool
+	}{
+		{"change", change, "a", 5, 5, true},
+		{"other account", change, "b", 5, 5, false},
+		{"no change", change, "a", 5, 0, false},
+		{"up through", cross, "a", 120, 30, true},
+		{"up onto", cross, "a", 100, 10, true},
+		{"down off", cross, "a", 99, -1, true},
+		{"down through", cross, "a", 50, -60, true},
+		{"stays above", cross, "a", 150, 10, false},
+		{"stays below", cross, "a", 99, 9, false},
+		{"up from onto", cross, "a", 110, 10, false},
+	} {
+		got := tc.sub.Matches(BalanceChange{AccountID: tc.account, BalanceUnits: tc.balance, DeltaUnits: tc.delta})
+		if got != tc.want {
+			t.Errorf("%s: matches = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+	n := cross.Notification(BalanceChange{EventID: "e", AccountID: "a", BalanceUnits: 120, DeltaUnits: 30})
+	if n.PreviousUnits != 90 || n.BalanceUnits != 120 || *n.ThresholdUnits != 100 {
+		t.Errorf("notification = %+v", n)
+	}
+}
+
+func TestBalanceSubscriptions(t *testing.T) {
+	db := storetest.Open(t)
+	ctx := context.Background()
+	l := New(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	zone := "zone-bsub-" + uuid.NewString()[:8]
+	if _, err := l.CreateZone(ctx, CreateZoneInput{ID: zone, Name: zone, Actor: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	account := zone + "-a"
+	if _, err := l.CreateAccount(ctx, CreateAccountInput{AccountID: account, ZoneID: zone, Actor: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	threshold := int64(50)
+	for name, in := range map[string]CreateBalanceSubscriptionInput{
+		"unknown trigger":    {AccountID: account, Trigger: "DROP"},
+		"cross no threshold": {AccountID: account, Trigger: BalanceTriggerCross},
+		"change threshold":   {AccountID: account, ThresholdUnits: &threshold},
+		"relative webhook":   {AccountID: account, WebhookURL: "/hook"},
+		"non-http webhook":   {AccountID: account, WebhookURL: "ftp://example.com/hook"},
+	} {
+		in.Actor = "test"
+		if _, err := l.CreateBalanceSubscription(ctx, in); err == nil {
+			t.Errorf("%s: created", name)
+		}
+	}
+	if _, err := l.CreateBalanceSubscription(ctx, CreateBalanceSubscriptionInput{AccountID: zone + "-none", Actor: "test"}); !IsAccountNotFound(err) {
+		t.Errorf("unknown account: err = %v", err)
+	}
+
+	sse, err := l.CreateBalanceSubscription(ctx, CreateBalanceSubscriptionInput{AccountID: account, Actor: "test"})
+	if err != nil || sse.Trigger != BalanceTriggerChange || sse.Secret != "" || sse.WebhookURL != nil {
+		t.Fatalf("sse subscription = %+v, %v", sse, err)
+	}
+	hook, err := l.CreateBalanceSubscription(ctx, CreateBalanceSubscriptionInput{
+		AccountID: account, Trigger: BalanceTriggerCross, ThresholdUnits: &threshold, WebhookURL: "https://example.com/hook", Actor: "test",
+	})
+	if err != nil || len(hook.Secret) <= len(webhookSecretPrefix) {
+		t.Fatalf("webhook subscription = %+v, %v", hook, err)
+	}
+	if subs, err := l.ListBalanceSubscriptions(ctx, account); err != nil || len(subs) != 2 {
+		t.Fatalf("list = %+v, %v", subs, err)
+	}
+	hooks, err := l.BalanceWebhooks(ctx, []string{account})
+	if err != nil || len(hooks) != 1 || hooks[0].ID != hook.ID || hooks[0].Secret != hook.Secret {
+		t.Fatalf("webhooks = %+v, %v", hooks, err)
+	}
+
+	event := uuid.NewString()
+	if err := l.RecordBalanceDelivery(ctx, hook.ID, event, 500, "webhook returned 500"); err != nil {
+		t.Fatal(err)
+	}
+	if done, err := l.BalanceDelivered(ctx, hook.ID, event); err != nil || done {
+		t.Fatalf("delivered after a failure = %v, %v", done, err)
+	}
+	if err := l.RecordBalanceDelivery(ctx, hook.ID, event, 204, ""); err != nil {
+		t.Fatal(err)
+	}
+	var attempts int
+	if err := db.QueryRow(ctx, `SELECT attempts FROM balance_deliveries WHERE subscription_id=$1::uuid`, hook.ID).Scan(&attempts); err != nil || attempts != 2 {
+		t.Errorf("attempts = %d, %v", attempts, err)
+	}
+	if done, err := l.BalanceDelivered(ctx, hook.ID, event); err != nil || !done {
+		t.Fatalf("delivered = %v, %v", done, err)
+	}
+
+	if _, err := l.DeleteBalanceSubscription(ctx, hook.ID, "test", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.GetBalanceSubscription(ctx, hook.ID); !IsBalanceSubscriptionNotFound(err) {
+		t.Errorf("deleted: err = %v", err)
+	}
+	if _, err := l.DeleteBalanceSubscription(ctx, hook.ID, "test", ""); !IsBalanceSubscriptionNotFound(err) {
+		t.Errorf("deleted twice: err = %v", err)
+	}
+}
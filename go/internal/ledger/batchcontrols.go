@@ -0,0 +1,191 @@
+package ledger
+
+import (
+  "context"
+  "fmt"
+  "slices"
+  "strings"
+
+  "github.com/google/uuid"
+  "github.com/jackc/pgx/v5"
+)
+
+// ZoneControlsPatch is a controls change for many zones: the fields it sets
+// replace the zone's, the rest stay as each zone has them. BlockedActions,
+// when set, replaces the whole map.
+type ZoneControlsPatch struct {
+  WritesBlocked *bool `json:"writes_blocked,omitempty"`
+  CrossZoneThrottle *int `json:"cross_zone_throttle,omitempty"`
+  SpoolEnabled *bool `json:"spool_enabled,omitempty"`
+  InjectLatencyMs *int `json:"inject_latency_ms,omitempty"`
+  InjectJitterMs *int `json:"inject_jitter_ms,omitempty"`
+  ErrorRatePercent *int `json:"error_rate_percent,omitempty"`
+  ThrottleMode *string `json:"throttle_mode,omitempty"`
+  RateLimitPerSec *int `json:"rate_limit_per_sec,omitempty"`
+  RateLimitBurst *int `json:"rate_limit_burst,omitempty"`
+  ClockSkewMs *int64 `json:"clock_skew_ms,omitempty"`
+  BlockedActions map[string]string `json:"blocked_actions,omitempty"`
+  BlockedDelayMs *int `json:"blocked_delay_ms,omitempty"`
+  MinAmountUnits *int64 `json:"min_amount_units,omitempty"`
+  MaxAmountUnits *int64 `json:"max_amount_units,omitempty"`
+  DailyAccountLimitUnits *int64 `json:"daily_account_limit_units,omitempty"`
+  DayCutoverMinute *int `json:"day_cutover_minute,omitempty"`
+}
+
+func (p ZoneControlsPatch) empty() bool {
+  return p.WritesBlocked == nil && p.CrossZoneThrottle == nil && p.SpoolEnabled == nil && p.InjectLatencyMs == nil &&
+    p.InjectJitterMs == nil && p.ErrorRatePercent == nil && p.ThrottleMode == nil && p.RateLimitPerSec == nil &&
+    p.RateLimitBurst == nil && p.ClockSkewMs == nil && p.BlockedActions == nil && p.BlockedDelayMs == nil &&
+    p.MinAmountUnits == nil && p.MaxAmountUnits == nil && p.DailyAccountLimitUnits == nil && p.DayCutoverMinute == nil
+}
+
+func (p ZoneControlsPatch) apply(in *SetZoneControlsInput) {
+  set := func(dst *int, v *int) { if v != nil { *dst = *v } }
+  if p.WritesBlocked != nil { in.WritesBlocked = *p.WritesBlocked }
+  set(&in.CrossZoneThrottle, p.CrossZoneThrottle)
+  if p.SpoolEnabled != nil { in.SpoolEnabled = *p.SpoolEnabled }
+  set(&in.InjectLatencyMs, p.InjectLatencyMs)
+  set(&in.InjectJitterMs, p.InjectJitterMs)
+  set(&in.ErrorRatePercent, p.ErrorRatePercent)
+  if p.ThrottleMode != nil { in.ThrottleMode = *p.ThrottleMode }
+  set(&in.RateLimitPerSec, p.RateLimitPerSec)
+  set(&in.RateLimitBurst, p.RateLimitBurst)
+  if p.ClockSkewMs != nil { in.ClockSkewMs = *p.ClockSkewMs }
+  if p.BlockedActions != nil { in.BlockedActions = p.BlockedActions }
+  set(&in.BlockedDelayMs, p.BlockedDelayMs)
+  set64 := func(dst *int64, v *int64) { if v != nil { *dst = *v } }
+  set64(&in.MinAmountUnits, p.MinAmountUnits)
+  set64(&in.MaxAmountUnits, p.MaxAmountUnits)
+  set64(&in.DailyAccountLimitUnits, p.DailyAccountLimitUnits)
+  set(&in.DayCutoverMinute, p.DayCutoverMinute)
+}
+
+// BatchZoneControlsInput selects zones by ID, by tags (zones carrying all of
+// them) or all of them (retired zones never) and the change to make to each.
+type BatchZoneControlsInput struct {
+  ZoneIDs []string `json:"zone_ids,omitempty"`
+  Tags map[string]string `json:"tags,omitempty"`
+  All bool `json:"all,omitempty"`
+  Patch ZoneControlsPatch `json:"controls"`
+  Actor string `json:"-"`
+  ReasonCode string `json:"-"`
+  Reason string `json:"-"`
+}
+
+func (in *BatchZoneControlsInput) validate() error {
+  selectors := 0
+  for _, set := range []bool{len(in.ZoneIDs) > 0, len(in.Tags) > 0, in.All} {
+    if set { selectors++ }
+  }
+  if selectors != 1 { return fmt.Errorf("give exactly one of zone_ids, tags or all") }
+  if len(in.Tags) > 0 {
+    tags, err := normalizeZoneTags(in.Tags)
+    if err != nil { return err }
+    in.Tags = tags
+  }
+  if in.Patch.empty() { return fmt.Errorf("controls must change at least one field") }
+  return nil
+}
+
+type BatchZoneControlsResult struct {
+  BatchID string `json:"batch_id"`
+  Zones []ZoneControls `json:"zones"`
+}
+
+// ZoneControlsBatchNeedsApproval reports whether the batch must be approved:
+// like a single change, when it blocks writes.
+func (l *Ledger) ZoneControlsBatchNeedsApproval(in BatchZoneControlsInput) bool {
+  return l.TwoPersonRule() && in.Patch.WritesBlocked != nil && *in.Patch.WritesBlocked
+}
+
+// BatchSetZoneControls applies the patch to every selected zone in one
+// transaction: all zones change or none does. Each zone's change is audited
+// as usual, and a BATCH_SET_ZONE_CONTROLS entry sums up the batch.
+func (l *Ledger) BatchSetZoneControls(ctx context.Context, in BatchZoneControlsInput) (*BatchZoneControlsResult, error) {
+  if err := in.validate(); err != nil { return nil, err }
+
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  if err := l.checkReasonCode(ctx, tx, ReasonForZoneControls, in.ReasonCode); err != nil { return nil, err }
+  zones, err := batchZones(ctx, tx, in)
+  if err != nil { return nil, err }
+
+  // lock every row first, in zone order, so concurrent batches cannot deadlock
+  if _, err := tx.Exec(ctx, `INSERT INTO zone_controls(zone_id) SELECT unnest($1::text[]) ON CONFLICT DO NOTHING`, zones); err != nil { return nil, err }
+  rows, err := tx.Query(ctx, `SELECT `+zoneControlsCols+` FROM zone_controls WHERE zone_id = ANY($1) ORDER BY zone_id FOR UPDATE`, zones)
+  if err != nil { return nil, err }
+  current := []ZoneControls{}
+  for rows.Next() {
+    c, err := scanZoneControls(rows)
+    if err != nil { rows.Close(); return nil, err }
+    current = append(current, *c)
+  }
+  rows.Close()
+  if err := rows.Err(); err != nil { return nil, err }
+
+  res := &BatchZoneControlsResult{BatchID: uuid.NewString(), Zones: []ZoneControls{}}
+  for _, c := range current {
+    set := c.input()
+    in.Patch.apply(&set)
+    set.Actor, set.ReasonCode, set.Reason = in.Actor, in.ReasonCode, in.Reason
+    updated, err := l.setZoneControlsTx(ctx, tx, c.ZoneID, set)
+    if err != nil { return nil, fmt.Errorf("%s: %w", c.ZoneID, err) }
+    res.Zones = append(res.Zones, *updated)
+  }
+
+  err = l.audit(ctx, pgQueries{tx}, AuditRecord{
+    Actor: in.Actor, Action: "BATCH_SET_ZONE_CONTROLS", TargetType: "zones", TargetID: res.BatchID, Reason: in.Reason, ReasonCode: in.ReasonCode,
+    Details: map[string]any{"batch_id": res.BatchID, "zone_ids": zones, "tags": in.Tags, "all": in.All, "controls": asDetails(in.Patch)},
+  })
+  if err != nil { return nil, err }
+
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  for _, z := range zones { l.zones.invalidate(z) }
+  return res, nil
+}
+
+// batchZones resolves the batch's selector to active zone IDs in order. A
+// named zone that does not exist or is retired fails the batch, and so does a
+// selector matching no zone.
+func batchZones(ctx context.Context, tx pgx.Tx, in BatchZoneControlsInput) ([]string, error) {
+  rows, err := tx.Query(ctx, `
+    SELECT id FROM zones
+    WHERE retired_at IS NULL AND ($1 OR id = ANY($2) OR ($3::jsonb <> '{}' AND tags @> $3::jsonb))
+    ORDER BY id
+  `, in.All, in.ZoneIDs, tagSet(in.Tags))
+  if err != nil { return nil, err }
+  defer rows.Close()
+  zones := []string{}
+  for rows.Next() {
+    var id string
+    if err := rows.Scan(&id); err != nil { return nil, err }
+    zones = append(zones, id)
+  }
+  if err := rows.Err(); err != nil { return nil, err }
+
+  missing := []string{}
+  for _, id := range in.ZoneIDs {
+    if !slices.Contains(zones, id) && !slices.Contains(missing, id) { missing = append(missing, id) }
+  }
+  if len(missing) > 0 { return nil, fmt.Errorf("%w: %s", ErrZoneNotFound, strings.Join(missing, ", ")) }
+  if len(zones) == 0 && len(in.Tags) > 0 { return nil, fmt.Errorf("%w: no active zone has tags %s", ErrZoneNotFound, FormatTagSelector(in.Tags)) }
+  if len(zones) == 0 { return nil, fmt.Errorf("%w: no active zones", ErrZoneNotFound) }
+  return zones, nil
+}
+
+// RequestZoneControlsBatchApproval holds a batch for a second operator.
+func (l *Ledger) RequestZoneControlsBatchApproval(ctx context.Context, in BatchZoneControlsInput) (*Approval, error) {
+  if err := in.validate(); err != nil { return nil, err }
+  target := "all"
+  switch {
+  case len(in.ZoneIDs) > 0: target = strings.Join(in.ZoneIDs, ",")
+  case len(in.Tags) > 0: target = FormatTagSelector(in.Tags)
+  }
+  return l.requestApproval(ctx, approvalRequest{
+    kind: ApprovalZoneControlsBatch, targetType: "zones", targetID: target,
+    payload: map[string]any{"batch": in, "reason_code": in.ReasonCode}, actor: in.Actor, reason: in.Reason,
+    reasonFor: ReasonForZoneControls, reasonCode: in.ReasonCode,
+  })
+}
@@ -0,0 +1,84 @@
+package ledger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"time-ledger-sim/go/internal/store/storetest"
+)
+
+func TestZoneControlsPatch(t *testing.T) {
+	if !(ZoneControlsPatch{}).empty() {
+		t.Fatal("zero patch not empty")
+	}
+	off, throttle := false, 30
+	p := ZoneControlsPatch{SpoolEnabled: &off, CrossZoneThrottle: &throttle}
+	in := SetZoneControlsInput{WritesBlocked: true, SpoolEnabled: true, CrossZoneThrottle: 100, ThrottleMode: "HASH", BlockedDelayMs: 250}
+	p.apply(&in)
+	want := SetZoneControlsInput{WritesBlocked: true, SpoolEnabled: false, CrossZoneThrottle: 30, ThrottleMode: "HASH", BlockedDelayMs: 250}
+	if p.empty() || in.WritesBlocked != want.WritesBlocked || in.SpoolEnabled != want.SpoolEnabled ||
+		in.CrossZoneThrottle != want.CrossZoneThrottle || in.ThrottleMode != want.ThrottleMode || in.BlockedDelayMs != want.BlockedDelayMs {
+		t.Fatalf("patched = %+v, want %+v", in, want)
+	}
+
+	for _, bad := range []BatchZoneControlsInput{
+		{Patch: p},
+		{ZoneIDs: []string{"zone-a"}, All: true, Patch: p},
+		{ZoneIDs: []string{"zone-a"}},
+	} {
+		if err := bad.validate(); err == nil {
+			t.Errorf("%+v validated", bad)
+		}
+	}
+}
+
+func TestBatchSetZoneControls(t *testing.T) {
+	db := storetest.Open(t)
+	ctx := context.Background()
+	l := New(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	prefix := "zone-batch-" + uuid.NewString()[:8]
+	a, b := prefix+"-a", prefix+"-b"
+	for _, z := range []string{a, b} {
+		if _, err := l.CreateZone(ctx, CreateZoneInput{ID: z, Name: z, Actor: "test"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := l.SetZoneControls(ctx, b, SetZoneControlsInput{CrossZoneThrottle: 60, InjectLatencyMs: 20, Actor: "test"}); err != nil {
+		t.Fatal(err)
+	}
+
+	spool := true
+	res, err := l.BatchSetZoneControls(ctx, BatchZoneControlsInput{
+		ZoneIDs: []string{b, a}, Patch: ZoneControlsPatch{SpoolEnabled: &spool}, Actor: "test", Reason: "region drill",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.BatchID == "" || len(res.Zones) != 2 || res.Zones[0].ZoneID != a || res.Zones[1].ZoneID != b {
+		t.Fatalf("batch = %+v", res)
+	}
+	got, err := l.GetZoneControls(ctx, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.SpoolEnabled || got.CrossZoneThrottle != 60 || got.InjectLatencyMs != 20 {
+		t.Fatalf("%s controls = %+v, want spooling with the rest kept", b, got)
+	}
+
+	// one unknown zone fails the whole batch
+	throttle := 10
+	_, err = l.BatchSetZoneControls(ctx, BatchZoneControlsInput{
+		ZoneIDs: []string{a, "zone-nowhere"}, Patch: ZoneControlsPatch{CrossZoneThrottle: &throttle}, Actor: "test",
+	})
+	if !IsZoneNotFound(err) {
+		t.Fatalf("unknown zone err = %v", err)
+	}
+	if got, err := l.GetZoneControls(ctx, a); err != nil || got.CrossZoneThrottle != 100 {
+		t.Fatalf("%s controls after a failed batch = %+v, %v", a, got, err)
+	}
+}
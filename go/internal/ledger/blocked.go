@@ -0,0 +1,64 @@
+package ledger
+
+import (
+  "fmt"
+  "strings"
+  "time"
+)
+
+// What a zone does with a transfer its gates block, per cause (the
+// blocked_actions zone control).
+const (
+  BlockedActionSpool = "SPOOL"
+  BlockedActionReject = "REJECT"
+  // BlockedActionDelay holds the request in-process for blocked_delay_ms and
+  // then looks again. A hash throttle lets a delayed transfer through (the
+  // wait is its price); any other cause must have cleared meanwhile.
+  BlockedActionDelay = "DELAY"
+)
+
+// BlockedCauses are the blocked_actions keys: the blocked reasons of
+// createTransferTx, in snake case. Partitions have their own mode.
+var BlockedCauses = []string{"zone_down", "writes_blocked", "throttled", "rate_limited"}
+
+func blockedCause(reason string) string { return strings.ReplaceAll(reason, " ", "_") }
+
+// blockedOutcome is what happens to a transfer blocked for reason: SPOOL,
+// DELAY, or REJECT with the error to return. A cause without an action keeps
+// the zone-wide behaviour (spool when spool_enabled, reject otherwise), and so
+// does a DELAY once the transfer has been delayed. An explicit REJECT of a
+// throttle cause is ErrRateLimited (429) rather than ErrZoneBlocked.
+func (c *ZoneControls) blockedOutcome(status, reason string, delayed bool) (string, error) {
+  action := c.BlockedActions[blockedCause(reason)]
+  if action == BlockedActionDelay && (delayed || c.BlockedDelayMs <= 0) { action = "" }
+  switch action {
+  case BlockedActionSpool, BlockedActionDelay:
+    return action, nil
+  case BlockedActionReject:
+    if reason == "throttled" { return action, fmt.Errorf("%w: %s", ErrRateLimited, reason) }
+  case "":
+    if c.SpoolEnabled { return BlockedActionSpool, nil }
+  }
+  switch {
+  case status == "DOWN":
+    return BlockedActionReject, ErrZoneDown
+  case reason == "rate limited":
+    return BlockedActionReject, ErrRateLimited
+  }
+  return BlockedActionReject, fmt.Errorf("%w: %s", ErrZoneBlocked, reason)
+}
+
+// delaysThrottle reports whether a hash-throttled transfer is delayed rather
+// than spooled or rejected.
+func (c *ZoneControls) delaysThrottle() bool {
+  return c.BlockedActions["throttled"] == BlockedActionDelay && c.BlockedDelayMs > 0
+}
+
+// transferDelay rolls back a blocked transfer's transaction and asks
+// createTransfer to wait and try it again.
+type transferDelay struct {
+  wait time.Duration
+  reason string
+}
+
+func (d transferDelay) Error() string { return "transfer delayed: " + d.reason }
@@ -0,0 +1,93 @@
+package ledger
+
+import (
+  "context"
+  "encoding/base64"
+  "encoding/json"
+  "fmt"
+)
+
+// ChangeOpInsert is the only change transactions see: they are append-only.
+const ChangeOpInsert = "INSERT"
+
+// TransactionChange is one entry of the transaction change feed. Cursor is
+// the feed position right after it, so a consumer can resume from any entry.
+type TransactionChange struct {
+  Op string `json:"op"`
+  Cursor string `json:"cursor"`
+  ZoneID string `json:"zone_id"`
+  ZoneSeq int64 `json:"zone_seq"`
+  Transaction TransactionRow `json:"transaction"`
+  Metadata map[string]any `json:"metadata"`
+}
+
+type TransactionChangePage struct {
+  Changes []TransactionChange `json:"changes"`
+  NextCursor string `json:"next_cursor"` // the last change's cursor; the request's when there were none
+}
+
+// A change feed cursor is the last zone_seq seen per zone, as base64url JSON
+// ({"zone-eu": 42}). Zones it does not name start from the beginning.
+func encodeChangeCursor(pos map[string]int64) string {
+  b, _ := json.Marshal(pos)
+  return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeChangeCursor(cursor string) (map[string]int64, error) {
+  pos := map[string]int64{}
+  if cursor == "" { return pos, nil }
+  raw, err := base64.RawURLEncoding.DecodeString(cursor)
+  if err == nil { err = json.Unmarshal(raw, &pos) }
+  if err != nil { return nil, fmt.Errorf("invalid cursor") }
+  return pos, nil
+}
+
+// ListTransactionChanges returns up to limit transactions recorded after the
+// cursor. Each zone's transactions come in zone_seq order, which is commit
+// order (see ListZoneTransactions), so the cursor never passes a transaction
+// that commits later. Zones are interleaved by when their transactions were
+// recorded: a row's sort key is the highest recorded_seq among its zone's
+// rows up to it, which never goes down within a zone, so any prefix of the
+// page is a prefix of every zone's sequence.
+func (l *Ledger) ListTransactionChanges(ctx context.Context, cursor string, limit int) (*TransactionChangePage, error) {
+  if limit <= 0 || limit > 1000 { limit = 100 }
+  pos, err := decodeChangeCursor(cursor)
+  if err != nil { return nil, err }
+  posJSON, _ := json.Marshal(pos)
+
+  rows, err := l.ro.Query(ctx, `
+    WITH next AS (
+      SELECT t.*, MAX(t.recorded_seq) OVER (PARTITION BY t.zone_id ORDER BY t.zone_seq) AS merge_key
+      FROM zones z
+      CROSS JOIN LATERAL (
+        SELECT * FROM transactions t
+        WHERE t.zone_id = z.id AND t.zone_seq > COALESCE(($1::jsonb ->> z.id)::bigint, 0)
+        ORDER BY t.zone_seq
+        LIMIT $2
+      ) t
+    )
+    SELECT `+transactionRowCols+`, metadata
+    FROM next
+    ORDER BY merge_key, zone_id, zone_seq
+    LIMIT $2
+  `, string(posJSON), limit)
+  if err != nil { return nil, err }
+  defer rows.Close()
+
+  redact := l.redaction.Load()
+  page := TransactionChangePage{Changes: []TransactionChange{}}
+  for rows.Next() {
+    var meta []byte
+    t, err := scanTransactionRow(rows, &meta)
+    if err != nil { return nil, err }
+    pos[t.ZoneID] = t.ZoneSeq
+    c := TransactionChange{Op: ChangeOpInsert, Cursor: encodeChangeCursor(pos), ZoneID: t.ZoneID, ZoneSeq: t.ZoneSeq, Transaction: *t}
+    _ = json.Unmarshal(meta, &c.Metadata)
+    c.Metadata = redact.Redact(c.Metadata)
+    page.Changes = append(page.Changes, c)
+  }
+  if err := rows.Err(); err != nil { return nil, err }
+  page.NextCursor = cursor
+  if n := len(page.Changes); n > 0 { page.NextCursor = page.Changes[n-1].Cursor }
+  return &page, nil
+}
@@ -0,0 +1,94 @@
+package ledger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"time-ledger-sim/go/internal/store/storetest"
+)
+
+func TestChangeCursor(t *testing.T) {
+	pos, err := decodeChangeCursor(encodeChangeCursor(map[string]int64{"zone-eu": 42, "zone-na": 7}))
+	if err != nil || pos["zone-eu"] != 42 || pos["zone-na"] != 7 {
+		t.Fatalf("round trip = %v, %v", pos, err)
+	}
+	if pos, err := decodeChangeCursor(""); err != nil || len(pos) != 0 {
+		t.Fatalf("empty cursor = %v, %v", pos, err)
+	}
+	if _, err := decodeChangeCursor("not a cursor"); err == nil {
+		t.Fatal("want an invalid cursor error")
+	}
+}
+
+func TestListTransactionChanges(t *testing.T) {
+	db := storetest.Open(t)
+	ctx := context.Background()
+	l := New(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	// start at the current head of every zone, so only this test's changes follow
+	pos := map[string]int64{}
+	rows, err := db.Query(ctx, `SELECT zone_id, last_seq FROM zone_sequences`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+		var z string
+		var n int64
+		if err := rows.Scan(&z, &n); err != nil {
+			t.Fatal(err)
+		}
+		pos[z] = n
+	}
+	rows.Close()
+	cursor := encodeChangeCursor(pos)
+
+	suffix := uuid.NewString()[:8]
+	za, zb := "zone-cdca-"+suffix, "zone-cdcb-"+suffix
+	for _, z := range []string{za, zb} {
+		if _, err := l.CreateZone(ctx, CreateZoneInput{ID: z, Name: z, Actor: "test"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := map[string]bool{}
+	for _, z := range []string{za, zb, za} {
+		txn, _, err := l.CreateTransfer(ctx, CreateTransferInput{
+			RequestID: "cdc-" + uuid.NewString(), PayloadHash: "h", FromAccount: z + "-a", ToAccount: z + "-b", AmountUnits: 1, ZoneID: z,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want[txn.ID] = true
+	}
+
+	seen := map[string]int64{}
+	for page := 0; ; page++ {
+		p, err := l.ListTransactionChanges(ctx, cursor, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(p.Changes) == 0 {
+			if p.NextCursor != cursor {
+				t.Fatalf("drained feed moved the cursor")
+			}
+			break
+		}
+		for _, c := range p.Changes {
+			if last, ok := seen[c.ZoneID]; c.Op != ChangeOpInsert || (ok && c.ZoneSeq != last+1) {
+				t.Fatalf("change %+v after zone_seq %d", c, seen[c.ZoneID])
+			}
+			seen[c.ZoneID] = c.ZoneSeq
+			delete(want, c.Transaction.ID)
+		}
+		cursor = p.NextCursor
+		if page > 50 {
+			t.Fatal("feed does not drain")
+		}
+	}
+	if len(want) != 0 || seen[za] != 2 || seen[zb] != 1 {
+		t.Fatalf("missing %v, last seqs %v", want, seen)
+	}
+}
@@ -0,0 +1,51 @@
+package ledger
+
+import (
+  "context"
+  "errors"
+  "time"
+)
+
+var ErrInjectedFault = errors.New("injected fault")
+
+func IsInjectedFault(err error) bool { return errors.Is(err, ErrInjectedFault) }
+
+// applyChaos runs the zone's chaos controls ahead of the transfer transaction:
+// it sleeps for inject_latency_ms plus a uniform random jitter in
+// [0, inject_jitter_ms], then fails a deterministic error_rate_percent of
+// request IDs with ErrInjectedFault. Sleeping returns early with the context
+// error if the caller gives up first (client timeout drills).
+func (l *Ledger) applyChaos(ctx context.Context, zoneID, requestID string) error {
+  c, err := l.repo.FindZoneControls(ctx, zoneID)
+  if err != nil || c == nil { return err }
+
+  if d := injectedDelay(c.InjectLatencyMs, c.InjectJitterMs, l.rand.IntN); d > 0 {
+    t := time.NewTimer(d)
+    defer t.Stop()
+    select {
+    case <-ctx.Done():
+      return ctx.Err()
+    case <-t.C:
+    }
+  }
+
+  if l.injectedFailure(requestID, c.ErrorRatePercent) {
+    return ErrInjectedFault
+  }
+  return nil
+}
+
+// injectedDelay computes base + jitter; intn is injectable for tests.
+func injectedDelay(latencyMs, jitterMs int, intn func(int) int) time.Duration {
+  ms := latencyMs
+  if jitterMs > 0 { ms += intn(jitterMs + 1) }
+  return time.Duration(ms) * time.Millisecond
+}
+
+// injectedFailure is deterministic per request ID, like the throttle, but uses
+// a salted key so the failing subset is independent of the throttled subset.
+func (l *Ledger) injectedFailure(requestID string, errorRate int) bool {
+  if errorRate <= 0 { return false }
+  if errorRate >= 100 { return true }
+  return l.hashPercent("error:"+requestID) < errorRate
+}
@@ -0,0 +1,52 @@
+package ledger
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestInjectedDelay_NoLatency(t *testing.T) {
+	if d := injectedDelay(0, 0, func(int) int { return 0 }); d != 0 {
+		t.Fatalf("expected 0, got %v", d)
+	}
+}
+
+func TestInjectedDelay_BasePlusJitter(t *testing.T) {
+	gotN := -1
+	d := injectedDelay(100, 50, func(n int) int { gotN = n; return 25 })
+	if gotN != 51 {
+		t.Fatalf("expected jitter range 51, got %d", gotN)
+	}
+	if d != 125*time.Millisecond {
+		t.Fatalf("expected 125ms, got %v", d)
+	}
+}
+
+func TestInjectedFailure_Bounds(t *testing.T) {
+	l := &Ledger{}
+	if l.injectedFailure("req-0001", 0) {
+		t.Fatal("0% should never fail")
+	}
+	if !l.injectedFailure("req-0001", 100) {
+		t.Fatal("100% should always fail")
+	}
+}
+
+func TestInjectedFailure_DeterministicFraction(t *testing.T) {
+	l := &Ledger{}
+	failed := 0
+	for i := 0; i < 10000; i++ {
+		id := fmt.Sprintf("req-%05d", i)
+		a := l.injectedFailure(id, 20)
+		if a != l.injectedFailure(id, 20) {
+			t.Fatalf("expected deterministic result for %s", id)
+		}
+		if a {
+			failed++
+		}
+	}
+	if failed < 1500 || failed > 2500 {
+		t.Fatalf("expected roughly 20%% failures, got %d/10000", failed)
+	}
+}
@@ -0,0 +1,235 @@
+package ledger
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "strconv"
+  "sync"
+  "time"
+)
+
+// Clock is the simulation's notion of "now". Everything that stamps
+// simulated time (transaction created_at, rate limiting, schedulers,
+// scenarios) reads it instead of time.Now so runs can be frozen, advanced,
+// and replayed deterministically.
+type Clock interface {
+  Now() time.Time
+}
+
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time { return time.Now() }
+
+// VirtualClock tracks real time from an anchor at a configurable rate:
+// virtual = virtAnchor + (real - realAnchor) * rate. Rate 0 means frozen.
+type VirtualClock struct {
+  mu sync.Mutex
+  real func() time.Time
+  realAnchor time.Time
+  virtAnchor time.Time
+  rate float64
+}
+
+func NewVirtualClock() *VirtualClock {
+  return newVirtualClock(time.Now)
+}
+
+func newVirtualClock(real func() time.Time) *VirtualClock {
+  now := real()
+  return &VirtualClock{real: real, realAnchor: now, virtAnchor: now, rate: 1}
+}
+
+func (c *VirtualClock) Now() time.Time {
+  c.mu.Lock()
+  defer c.mu.Unlock()
+  return c.nowLocked()
+}
+
+func (c *VirtualClock) nowLocked() time.Time {
+  elapsed := c.real().Sub(c.realAnchor)
+  return c.virtAnchor.Add(time.Duration(float64(elapsed) * c.rate))
+}
+
+// reanchor pins the current virtual time so rate changes apply from now on.
+func (c *VirtualClock) reanchor(virt time.Time) {
+  c.realAnchor = c.real()
+  c.virtAnchor = virt
+}
+
+// Freeze stops virtual time at the current instant, or at `at` if non-zero.
+func (c *VirtualClock) Freeze(at time.Time) {
+  c.mu.Lock()
+  defer c.mu.Unlock()
+  if at.IsZero() { at = c.nowLocked() }
+  c.reanchor(at)
+  c.rate = 0
+}
+
+// Resume lets virtual time flow again at real-time speed from where it is.
+func (c *VirtualClock) Resume() {
+  c.mu.Lock()
+  defer c.mu.Unlock()
+  c.reanchor(c.nowLocked())
+  c.rate = 1
+}
+
+// Advance jumps virtual time forward by d (works frozen or running).
+func (c *VirtualClock) Advance(d time.Duration) {
+  c.mu.Lock()
+  defer c.mu.Unlock()
+  c.reanchor(c.nowLocked().Add(d))
+}
+
+// SetRate runs virtual time at rate x real time from the current instant.
+func (c *VirtualClock) SetRate(rate float64) {
+  c.mu.Lock()
+  defer c.mu.Unlock()
+  c.reanchor(c.nowLocked())
+  c.rate = rate
+}
+
+func (c *VirtualClock) Rate() float64 {
+  c.mu.Lock()
+  defer c.mu.Unlock()
+  return c.rate
+}
+
+// Reset returns to real time.
+func (c *VirtualClock) Reset() {
+  c.mu.Lock()
+  defer c.mu.Unlock()
+  c.reanchor(c.real())
+  c.rate = 1
+}
+
+type ClockState struct {
+  Now time.Time `json:"now"`
+  RealNow time.Time `json:"real_now"`
+  Frozen bool `json:"frozen"`
+  Rate float64 `json:"rate"`
+  Offset string `json:"offset"`
+}
+
+func (c *VirtualClock) State() ClockState {
+  c.mu.Lock()
+  defer c.mu.Unlock()
+  now := c.nowLocked()
+  real := c.real()
+  return ClockState{
+    Now: now.UTC(),
+    RealNow: real.UTC(),
+    Frozen: c.rate == 0,
+    Rate: c.rate,
+    Offset: now.Sub(real).Round(time.Millisecond).String(),
+  }
+}
+
+// waitUntil blocks until clock reaches t or ctx is done. It polls so that
+// freezing, advancing, or speeding up the clock is picked up promptly.
+func waitUntil(ctx context.Context, clock Clock, t time.Time) error {
+  const poll = 50 * time.Millisecond
+  for {
+    if !clock.Now().Before(t) { return nil }
+    timer := time.NewTimer(poll)
+    select {
+    case <-ctx.Done():
+      timer.Stop()
+      return ctx.Err()
+    case <-timer.C:
+    }
+  }
+}
+
+var ErrClockNotVirtual = errors.New("clock is not virtual")
+
+func IsClockNotVirtual(err error) bool { return errors.Is(err, ErrClockNotVirtual) }
+
+const (
+  ClockOpFreeze = "FREEZE"
+  ClockOpResume = "RESUME"
+  ClockOpAdvance = "ADVANCE"
+  ClockOpReset = "RESET"
+  ClockOpRate = "RATE"
+)
+
+const maxClockRate = 3600 // one virtual hour per real second
+
+type ClockAdjustment struct {
+  Op string
+  At time.Time // FREEZE: optional instant to freeze at
+  By time.Duration // ADVANCE
+  Rate float64 // RATE
+  Actor string
+  Reason string
+}
+
+func (l *Ledger) virtualClock() (*VirtualClock, error) {
+  vc, ok := l.clock.(*VirtualClock)
+  if !ok { return nil, ErrClockNotVirtual }
+  return vc, nil
+}
+
+type SimClockState struct {
+  ClockState
+  Seed uint64 `json:"seed"`
+}
+
+func (l *Ledger) ClockState() (*SimClockState, error) {
+  vc, err := l.virtualClock()
+  if err != nil { return nil, err }
+  return &SimClockState{ClockState: vc.State(), Seed: l.Seed()}, nil
+}
+
+// AdjustClock freezes, resumes, advances, or resets virtual time, audited as a sim action.
+func (l *Ledger) AdjustClock(ctx context.Context, in ClockAdjustment) (*SimClockState, error) {
+  vc, err := l.virtualClock()
+  if err != nil { return nil, err }
+  switch in.Op {
+  case ClockOpFreeze:
+    vc.Freeze(in.At)
+  case ClockOpResume:
+    vc.Resume()
+  case ClockOpAdvance:
+    if in.By <= 0 { return nil, fmt.Errorf("advance duration must be positive") }
+    vc.Advance(in.By)
+  case ClockOpReset:
+    vc.Reset()
+  case ClockOpRate:
+    if in.Rate <= 0 || in.Rate > maxClockRate { return nil, fmt.Errorf("rate must be in (0, %d]", maxClockRate) }
+    vc.SetRate(in.Rate)
+  default:
+    return nil, fmt.Errorf("invalid clock op")
+  }
+  st := vc.State()
+  _ = l.audit(ctx, pgQueries{l.db}, AuditRecord{
+    Actor: in.Actor, Action: "CLOCK_" + in.Op, TargetType: "sim", TargetID: "clock", Reason: in.Reason,
+    Details: map[string]any{"virtual_now": st.Now, "rate": st.Rate, "advance": in.By.String()},
+  })
+  return &SimClockState{ClockState: st, Seed: l.Seed()}, nil
+}
+
+// clockRate is how many virtual seconds pass per real second (1 on a non-virtual clock).
+func (l *Ledger) clockRate() float64 {
+  if vc, ok := l.clock.(*VirtualClock); ok { return vc.Rate() }
+  return 1
+}
+
+// scaledInterval shrinks a real-time polling interval when the clock is
+// accelerated, so background loops keep the same virtual-time granularity.
+func (l *Ledger) scaledInterval(d time.Duration) time.Duration {
+  const floor = 50 * time.Millisecond
+  rate := l.clockRate()
+  if rate > 1 { d = time.Duration(float64(d) / rate) }
+  if d < floor { d = floor }
+  return d
+}
+
+// ReseedRandom resets the sim-wide random source, audited as a sim action.
+func (l *Ledger) ReseedRandom(ctx context.Context, seed uint64, actor, reason string) {
+  l.Reseed(seed)
+  _ = l.audit(ctx, pgQueries{l.db}, AuditRecord{
+    Actor: actor, Action: "RESEED_RANDOM", TargetType: "sim", TargetID: "random", Reason: reason,
+    Details: map[string]any{"seed": strconv.FormatUint(seed, 10)},
+  })
+}
@@ -0,0 +1,82 @@
+package ledger
+
+import (
+	"testing"
+	"time"
+)
+
+type fakeReal struct{ t time.Time }
+
+func (f *fakeReal) now() time.Time { return f.t }
+
+func TestVirtualClock_FreezeAndAdvance(t *testing.T) {
+	real := &fakeReal{t: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c := newVirtualClock(real.now)
+
+	c.Freeze(time.Time{})
+	frozenAt := c.Now()
+	real.t = real.t.Add(time.Hour)
+	if !c.Now().Equal(frozenAt) {
+		t.Fatalf("frozen clock moved: %v -> %v", frozenAt, c.Now())
+	}
+
+	c.Advance(30 * time.Minute)
+	if got := c.Now().Sub(frozenAt); got != 30*time.Minute {
+		t.Fatalf("expected +30m, got %v", got)
+	}
+
+	c.Resume()
+	real.t = real.t.Add(time.Minute)
+	if got := c.Now().Sub(frozenAt); got != 31*time.Minute {
+		t.Fatalf("expected +31m after resume, got %v", got)
+	}
+
+	c.Reset()
+	if !c.Now().Equal(real.t) {
+		t.Fatalf("reset should return to real time")
+	}
+}
+
+func TestSimRand_Deterministic(t *testing.T) {
+	a, b := newSimRand(42), newSimRand(42)
+	for i := 0; i < 100; i++ {
+		if a.IntN(1000) != b.IntN(1000) {
+			t.Fatal("same seed should produce same sequence")
+		}
+	}
+}
+
+func TestVirtualClock_SetRateAccelerates(t *testing.T) {
+	real := &fakeReal{t: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c := newVirtualClock(real.now)
+	start := c.Now()
+
+	c.SetRate(60)
+	real.t = real.t.Add(10 * time.Second)
+	if got := c.Now().Sub(start); got != 10*time.Minute {
+		t.Fatalf("expected 10m of virtual time at 60x, got %v", got)
+	}
+
+	c.SetRate(1)
+	real.t = real.t.Add(10 * time.Second)
+	if got := c.Now().Sub(start); got != 10*time.Minute+10*time.Second {
+		t.Fatalf("expected rate change to apply from the switch point, got %v", got)
+	}
+}
+
+func TestScaledInterval(t *testing.T) {
+	real := &fakeReal{t: time.Now()}
+	vc := newVirtualClock(real.now)
+	l := &Ledger{clock: vc}
+	if got := l.scaledInterval(time.Second); got != time.Second {
+		t.Fatalf("expected 1s at 1x, got %v", got)
+	}
+	vc.SetRate(10)
+	if got := l.scaledInterval(time.Second); got != 100*time.Millisecond {
+		t.Fatalf("expected 100ms at 10x, got %v", got)
+	}
+	vc.SetRate(1000)
+	if got := l.scaledInterval(time.Second); got != 50*time.Millisecond {
+		t.Fatalf("expected 50ms floor, got %v", got)
+	}
+}
@@ -0,0 +1,55 @@
+package ledger
+
+import (
+  "context"
+  "encoding/json"
+  "errors"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+)
+
+// ZoneControlsChange is one version of a zone's controls: the full state
+// after a change, and who made it. Actor is nil for writers that do not say
+// (the default row, restores, the Rust service).
+type ZoneControlsChange struct {
+  Version int64 `json:"version"`
+  ChangedAt time.Time `json:"changed_at"`
+  Actor *string `json:"actor"`
+  ReasonCode *string `json:"reason_code"`
+  Reason *string `json:"reason"`
+  Controls ZoneControls `json:"controls"`
+}
+
+// ListZoneControlsHistory returns the zone's control versions after
+// afterVersion, oldest first, optionally only those changed at or after since.
+// Replaying them in order rebuilds every state the zone went through.
+func (l *Ledger) ListZoneControlsHistory(ctx context.Context, zoneID string, afterVersion int64, since time.Time, limit int) ([]ZoneControlsChange, error) {
+  if limit <= 0 || limit > 1000 { limit = 100 }
+  var exists bool
+  if err := l.ro.QueryRow(ctx, `SELECT true FROM zones WHERE id=$1`, zoneID).Scan(&exists); err != nil {
+    if errors.Is(err, pgx.ErrNoRows) { return nil, ErrZoneNotFound }
+    return nil, err
+  }
+
+  rows, err := l.ro.Query(ctx, `
+    SELECT version, changed_at, actor, reason_code, reason, controls
+    FROM zone_controls_history
+    WHERE zone_id=$1 AND version > $2 AND changed_at >= $3
+    ORDER BY version
+    LIMIT $4
+  `, zoneID, afterVersion, since, limit)
+  if err != nil { return nil, err }
+  defer rows.Close()
+
+  out := []ZoneControlsChange{}
+  for rows.Next() {
+    var c ZoneControlsChange
+    var controls []byte
+    if err := rows.Scan(&c.Version, &c.ChangedAt, &c.Actor, &c.ReasonCode, &c.Reason, &controls); err != nil { return nil, err }
+    if err := json.Unmarshal(controls, &c.Controls); err != nil { return nil, err }
+    c.Controls.ZoneID, c.Controls.UpdatedAt = zoneID, c.ChangedAt
+    out = append(out, c)
+  }
+  return out, rows.Err()
+}
@@ -0,0 +1,58 @@
+package ledger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"time-ledger-sim/go/internal/store/storetest"
+)
+
+func TestZoneControlsHistory(t *testing.T) {
+	db := storetest.Open(t)
+	ctx := context.Background()
+	l := New(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	// creating the zone creates its default controls: version 1
+	zone := "zone-hist-" + uuid.NewString()[:8]
+	if _, err := l.CreateZone(ctx, CreateZoneInput{ID: zone, Name: zone, Actor: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		actor    string
+		throttle int
+	}{{"alice", 40}, {"bob", 80}} {
+		in := SetZoneControlsInput{CrossZoneThrottle: c.throttle, SpoolEnabled: true, Actor: c.actor, Reason: "drill"}
+		if _, err := l.SetZoneControls(ctx, zone, in); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h, err := l.ListZoneControlsHistory(ctx, zone, 0, time.Time{}, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h) != 3 {
+		t.Fatalf("history = %+v, want the default and two changes", h)
+	}
+	if h[0].Version != 1 || h[0].Actor != nil || h[0].Controls.CrossZoneThrottle != 100 {
+		t.Fatalf("first version = %+v", h[0])
+	}
+	last := h[2]
+	if last.Version != 3 || last.Actor == nil || *last.Actor != "bob" || last.Reason == nil || *last.Reason != "drill" ||
+		last.Controls.CrossZoneThrottle != 80 || !last.Controls.SpoolEnabled || last.Controls.ZoneID != zone {
+		t.Fatalf("last version = %+v", last)
+	}
+
+	page, err := l.ListZoneControlsHistory(ctx, zone, 2, time.Time{}, 100)
+	if err != nil || len(page) != 1 || page[0].Version != 3 {
+		t.Fatalf("after version 2 = %+v, %v", page, err)
+	}
+	if _, err := l.ListZoneControlsHistory(ctx, "zone-nowhere", 0, time.Time{}, 10); !IsZoneNotFound(err) {
+		t.Fatalf("unknown zone err = %v", err)
+	}
+}
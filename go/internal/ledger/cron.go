@@ -0,0 +1,107 @@
+package ledger
+
+import (
+  "fmt"
+  "strconv"
+  "strings"
+  "time"
+)
+
+// cronSchedule is a parsed five-field cron expression (minute hour
+// day-of-month month day-of-week), evaluated in UTC. Fields take *, numbers,
+// a-b ranges, comma lists and /n steps; day-of-week 0 and 7 are Sunday. As in
+// classic cron, when both day fields are restricted a day matching either runs.
+type cronSchedule struct {
+  minute, hour, dom, month, dow uint64 // bit i set: value i allowed
+  domAny, dowAny bool
+}
+
+var cronFields = []struct {
+  name string
+  min, max int
+}{
+  {"minute", 0, 59}, {"hour", 0, 23}, {"day of month", 1, 31}, {"month", 1, 12}, {"day of week", 0, 7},
+}
+
+func parseCron(expr string) (*cronSchedule, error) {
+  fields := strings.Fields(expr)
+  if len(fields) != len(cronFields) { return nil, fmt.Errorf("cron %q: want 5 fields (minute hour day-of-month month day-of-week)", expr) }
+  var bits [5]uint64
+  for i, f := range fields {
+    b, err := parseCronField(f, cronFields[i].min, cronFields[i].max)
+    if err != nil { return nil, fmt.Errorf("cron %q: %s: %w", expr, cronFields[i].name, err) }
+    bits[i] = b
+  }
+  if bits[4]&(1<<7) != 0 { bits[4] |= 1 } // 7 is Sunday too
+  return &cronSchedule{
+    minute: bits[0], hour: bits[1], dom: bits[2], month: bits[3], dow: bits[4],
+    domAny: fields[2] == "*", dowAny: fields[4] == "*",
+  }, nil
+}
+
+func parseCronField(f string, min, max int) (uint64, error) {
+  var out uint64
+  for _, part := range strings.Split(f, ",") {
+    rng, stepStr, hasStep := strings.Cut(part, "/")
+    step := 1
+    if hasStep {
+      n, err := strconv.Atoi(stepStr)
+      if err != nil || n < 1 { return 0, fmt.Errorf("bad step %q", stepStr) }
+      step = n
+    }
+    lo, hi := min, max
+    switch {
+    case rng == "*":
+    case strings.Contains(rng, "-"):
+      a, b, _ := strings.Cut(rng, "-")
+      var err1, err2 error
+      lo, err1 = strconv.Atoi(a)
+      hi, err2 = strconv.Atoi(b)
+      if err1 != nil || err2 != nil || lo > hi { return 0, fmt.Errorf("bad range %q", rng) }
+    default:
+      n, err := strconv.Atoi(rng)
+      if err != nil { return 0, fmt.Errorf("bad value %q", rng) }
+      lo, hi = n, n
+      if hasStep { hi = max }
+    }
+    if lo < min || hi > max { return 0, fmt.Errorf("%q out of range %d-%d", part, min, max) }
+    for v := lo; v <= hi; v += step { out |= 1 << v }
+  }
+  return out, nil
+}
+
+func (c *cronSchedule) dayMatches(t time.Time) bool {
+  dom := c.dom&(1<<t.Day()) != 0
+  dow := c.dow&(1<<int(t.Weekday())) != 0
+  switch {
+  case c.domAny && c.dowAny:
+    return true
+  case c.domAny:
+    return dow
+  case c.dowAny:
+    return dom
+  }
+  return dom || dow
+}
+
+// next returns the first minute strictly after t that the schedule matches,
+// or the zero time if there is none within five years (e.g. "0 0 30 2 *").
+func (c *cronSchedule) next(t time.Time) time.Time {
+  t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+  limit := t.AddDate(5, 0, 0)
+  for t.Before(limit) {
+    switch {
+    case c.month&(1<<int(t.Month())) == 0:
+      t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+    case !c.dayMatches(t):
+      t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+    case c.hour&(1<<t.Hour()) == 0:
+      t = t.Truncate(time.Hour).Add(time.Hour)
+    case c.minute&(1<<t.Minute()) == 0:
+      t = t.Add(time.Minute)
+    default:
+      return t
+    }
+  }
+  return time.Time{}
+}
@@ -0,0 +1,271 @@
+package ledger
+
+import (
+  "context"
+  "encoding/json"
+  "errors"
+  "log/slog"
+  "sync/atomic"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+
+  "time-ledger-sim/go/internal/logging"
+  "time-ledger-sim/go/internal/tracing"
+)
+
+// ErrDayNotFound is a business day that has not begun on the zone's clock.
+var ErrDayNotFound = errors.New("business day not found")
+
+func IsDayNotFound(err error) bool { return errors.Is(err, ErrDayNotFound) }
+
+const (
+  minutesPerDay = 24 * 60
+  dayLength = 24 * time.Hour
+  // maxDayBackfill bounds how far back the first close of a zone goes.
+  maxDayBackfill = 90 * dayLength
+  dateLayout = "2006-01-02"
+)
+
+// DayTypeTotals is a transaction type's share of a business day.
+type DayTypeTotals struct {
+  Transfers int64 `json:"transfers"`
+  AmountUnits int64 `json:"amount_units"`
+}
+
+// DailySummary is a zone's totals over a business day (migration 0050). A
+// day that has not closed yet is totalled on request, with Closed false.
+type DailySummary struct {
+  ZoneID string `json:"zone_id"`
+  Date string `json:"date"` // YYYY-MM-DD
+  OpensAt time.Time `json:"opens_at"`
+  ClosesAt time.Time `json:"closes_at"`
+  Closed bool `json:"closed"`
+  ClosedAt *time.Time `json:"closed_at"`
+  Transfers int64 `json:"transfers"`
+  AmountUnits int64 `json:"amount_units"`
+  CrossZoneTransfers int64 `json:"cross_zone_transfers"`
+  CrossZoneAmountUnits int64 `json:"cross_zone_amount_units"`
+  ByType map[string]DayTypeTotals `json:"by_type"`
+  ActiveAccounts int64 `json:"active_accounts"` // accounts on either side of a transfer
+  Spooled int64 `json:"spooled"`
+  Incidents int64 `json:"incidents"`
+}
+
+// businessDay is the business day zone time t falls in. With cutover 0 the
+// day D is D itself; otherwise it runs from D-1 at the cutover to D at the
+// cutover, so a transfer after the cutover counts towards the next day.
+func businessDay(t time.Time, cutoverMinute int) time.Time {
+  t = t.UTC()
+  d := t.Truncate(dayLength)
+  if cutoverMinute > 0 && !t.Before(d.Add(time.Duration(cutoverMinute)*time.Minute)) { d = d.Add(dayLength) }
+  return d
+}
+
+// dayBounds is [opens, closes) of the business day d under the cutover.
+func dayBounds(d time.Time, cutoverMinute int) (time.Time, time.Time) {
+  closes := d.Add(dayLength)
+  if cutoverMinute > 0 { closes = d.Add(time.Duration(cutoverMinute) * time.Minute) }
+  return closes.Add(-dayLength), closes
+}
+
+// dayTotals totals the zone's transactions, spooled transfers and incidents
+// stamped in [s.OpensAt, s.ClosesAt) into s.
+func dayTotals(ctx context.Context, db querier, s *DailySummary) error {
+  return db.QueryRow(ctx, `
+    WITH t AS (
+      SELECT type, from_account, to_account, amount_units, from_zone_id <> to_zone_id AS cross_zone
+      FROM transactions WHERE zone_id=$1 AND created_at >= $2 AND created_at < $3
+    )
+    SELECT
+      (SELECT COUNT(*) FROM t),
+      (SELECT COALESCE(SUM(amount_units), 0)::bigint FROM t),
+      (SELECT COUNT(*) FROM t WHERE cross_zone),
+      (SELECT COALESCE(SUM(amount_units), 0)::bigint FROM t WHERE cross_zone),
+      (SELECT COALESCE(jsonb_object_agg(type, jsonb_build_object('transfers', n, 'amount_units', s)), '{}'::jsonb)
+       FROM (SELECT type, COUNT(*) AS n, SUM(amount_units) AS s FROM t GROUP BY type) g),
+      (SELECT COUNT(*) FROM (SELECT from_account FROM t UNION SELECT to_account FROM t) a),
+      (SELECT COUNT(*) FROM spooled_transfers WHERE zone_id=$1 AND created_at >= $2 AND created_at < $3),
+      (SELECT COUNT(*) FROM incidents WHERE zone_id=$1 AND detected_at >= $2 AND detected_at < $3)
+  `, s.ZoneID, s.OpensAt, s.ClosesAt).Scan(&s.Transfers, &s.AmountUnits, &s.CrossZoneTransfers, &s.CrossZoneAmountUnits,
+    &s.ByType, &s.ActiveAccounts, &s.Spooled, &s.Incidents)
+}
+
+const dailySummaryCols = `zone_id, business_date, opens_at, closes_at, closed_at, transfers, amount_units, cross_zone_transfers, cross_zone_amount_units, by_type, active_accounts, spooled, incidents`
+
+func scanDailySummary(row pgx.Row) (*DailySummary, error) {
+  var s DailySummary
+  var date time.Time
+  err := row.Scan(&s.ZoneID, &date, &s.OpensAt, &s.ClosesAt, &s.ClosedAt, &s.Transfers, &s.AmountUnits, &s.CrossZoneTransfers, &s.CrossZoneAmountUnits,
+    &s.ByType, &s.ActiveAccounts, &s.Spooled, &s.Incidents)
+  if err != nil { return nil, err }
+  s.Date, s.Closed = date.Format(dateLayout), true
+  s.OpensAt, s.ClosesAt = s.OpensAt.UTC(), s.ClosesAt.UTC()
+  return &s, nil
+}
+
+// GetBusinessDay returns the zone's summary of the business day date: the
+// stored one once the day has closed, otherwise its totals so far. A day
+// that has not begun on the zone's clock is ErrDayNotFound.
+func (l *Ledger) GetBusinessDay(ctx context.Context, zoneID string, date time.Time) (*DailySummary, error) {
+  d := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+  s, err := scanDailySummary(l.ro.QueryRow(ctx, `SELECT `+dailySummaryCols+` FROM daily_summaries WHERE zone_id=$1 AND business_date=$2`, zoneID, d))
+  if err == nil { return s, nil }
+  if !errors.Is(err, pgx.ErrNoRows) { return nil, err }
+
+  var skewMs int64
+  var cutover int
+  var prevCloses *time.Time
+  err = l.ro.QueryRow(ctx, `
+    SELECT COALESCE(c.clock_skew_ms, 0), COALESCE(c.day_cutover_minute, 0),
+      (SELECT closes_at FROM daily_summaries WHERE zone_id=z.id AND business_date=$2::date - 1)
+    FROM zones z LEFT JOIN zone_controls c ON c.zone_id=z.id
+    WHERE z.id=$1
+  `, zoneID, d).Scan(&skewMs, &cutover, &prevCloses)
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrZoneNotFound }
+  if err != nil { return nil, err }
+
+  s = &DailySummary{ZoneID: zoneID, Date: d.Format(dateLayout)}
+  s.OpensAt, s.ClosesAt = dayBounds(d, cutover)
+  if prevCloses != nil { s.OpensAt = prevCloses.UTC() }
+  if zoneTime(l.clock.Now(), skewMs).Before(s.OpensAt) { return nil, ErrDayNotFound }
+  if err := dayTotals(ctx, l.ro, s); err != nil { return nil, err }
+  return s, nil
+}
+
+// CloseDays closes every business day that has ended on its zone's clock,
+// oldest first: it stores the day's totals and emits DAY_CLOSED, in one
+// transaction per day. A zone's first close starts at the day of its first
+// transaction, at most maxDayBackfill ago. It returns the days closed.
+func (l *Ledger) CloseDays(ctx context.Context) (int, error) {
+  type zoneDays struct {
+    id string
+    skewMs int64
+    cutover int
+    last *time.Time // last closed business_date
+    lastCloses *time.Time
+    first *time.Time // first transaction
+  }
+  rows, err := l.db.Query(ctx, `
+    SELECT z.id, COALESCE(c.clock_skew_ms, 0), COALESCE(c.day_cutover_minute, 0), d.business_date, d.closes_at,
+      CASE WHEN d.business_date IS NULL THEN (SELECT MIN(created_at) FROM transactions t WHERE t.zone_id=z.id) END
+    FROM zones z
+    LEFT JOIN zone_controls c ON c.zone_id=z.id
+    LEFT JOIN LATERAL (
+      SELECT business_date, closes_at FROM daily_summaries WHERE zone_id=z.id ORDER BY business_date DESC LIMIT 1
+    ) d ON true
+    WHERE z.retired_at IS NULL
+    ORDER BY z.id
+  `)
+  if err != nil { return 0, err }
+  var zones []zoneDays
+  for rows.Next() {
+    var z zoneDays
+    if err := rows.Scan(&z.id, &z.skewMs, &z.cutover, &z.last, &z.lastCloses, &z.first); err != nil { rows.Close(); return 0, err }
+    zones = append(zones, z)
+  }
+  rows.Close()
+  if err := rows.Err(); err != nil { return 0, err }
+
+  closed := 0
+  for _, z := range zones {
+    now := zoneTime(l.clock.Now(), z.skewMs)
+    var next time.Time
+    opens := z.lastCloses
+    switch {
+    case z.last != nil:
+      next = z.last.UTC().Add(dayLength)
+    case z.first != nil:
+      next = businessDay(*z.first, z.cutover)
+    default:
+      // nothing to backfill: start with the day that closed last
+      next = businessDay(now, z.cutover).Add(-dayLength)
+    }
+    if oldest := businessDay(now.Add(-maxDayBackfill), z.cutover); next.Before(oldest) { next, opens = oldest, nil }
+    for {
+      o, c := dayBounds(next, z.cutover)
+      if now.Before(c) { break }
+      if opens != nil { o = *opens }
+      s := &DailySummary{ZoneID: z.id, Date: next.Format(dateLayout), OpensAt: o.UTC(), ClosesAt: c}
+      if err := l.closeDay(ctx, s); err != nil { return closed, err }
+      closed++
+      opens, next = &c, next.Add(dayLength)
+    }
+  }
+  return closed, nil
+}
+
+// closeDay totals and stores a business day and emits its DAY_CLOSED event.
+// A day another pass closed first is left alone.
+func (l *Ledger) closeDay(ctx context.Context, s *DailySummary) error {
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  if err := dayTotals(ctx, tx, s); err != nil { return err }
+  at := l.clock.Now()
+  s.Closed, s.ClosedAt = true, &at
+  byType, _ := json.Marshal(s.ByType)
+  tag, err := tx.Exec(ctx, `
+    INSERT INTO daily_summaries(zone_id, business_date, opens_at, closes_at, closed_at, transfers, amount_units,
+      cross_zone_transfers, cross_zone_amount_units, by_type, active_accounts, spooled, incidents)
+    VALUES($1,$2::date,$3,$4,$5,$6,$7,$8,$9,$10::jsonb,$11,$12,$13)
+    ON CONFLICT (zone_id, business_date) DO NOTHING
+  `, s.ZoneID, s.Date, s.OpensAt, s.ClosesAt, at, s.Transfers, s.AmountUnits, s.CrossZoneTransfers, s.CrossZoneAmountUnits,
+    string(byType), s.ActiveAccounts, s.Spooled, s.Incidents)
+  if err != nil { return err }
+  if tag.RowsAffected() == 0 { return nil }
+
+  payload := asDetails(s)
+  payload["event_id"] = "generated_by_db"
+  pb, _ := json.Marshal(payload)
+  err = pgQueries{tx}.InsertOutbox(ctx, OutboxEvent{
+    EventType: "DAY_CLOSED", AggregateType: "zone", AggregateID: s.ZoneID, Payload: pb,
+    RequestID: logging.RequestID(ctx), TraceContext: tracing.Carrier(ctx),
+  })
+  if err != nil { return err }
+  if err := tx.Commit(ctx); err != nil { return err }
+  l.log.InfoContext(ctx, "business day closed", "zone_id", s.ZoneID, "date", s.Date, "transfers", s.Transfers, "amount_units", s.AmountUnits)
+  return nil
+}
+
+// DayCloser runs CloseDays on an interval. The interval can change while it
+// runs; 0 pauses it.
+type DayCloser struct {
+  led *Ledger
+  log *slog.Logger
+  interval atomic.Int64
+}
+
+// dayCloserIdle is how often a paused closer looks for a new interval.
+const dayCloserIdle = 5 * time.Second
+
+func NewDayCloser(led *Ledger, log *slog.Logger) *DayCloser {
+  c := &DayCloser{led: led, log: log}
+  c.SetInterval(time.Minute)
+  return c
+}
+
+func (c *DayCloser) SetInterval(d time.Duration) { c.interval.Store(int64(d)) }
+
+func (c *DayCloser) Run(ctx context.Context) {
+  next := func() time.Duration {
+    iv := time.Duration(c.interval.Load())
+    if iv <= 0 { return dayCloserIdle }
+    return c.led.scaledInterval(iv)
+  }
+  timer := time.NewTimer(next())
+  defer timer.Stop()
+  for {
+    select {
+    case <-ctx.Done():
+      return
+    case <-timer.C:
+      timer.Reset(next())
+      if c.interval.Load() <= 0 { continue }
+      if _, err := c.led.CloseDays(context.WithoutCancel(ctx)); err != nil {
+        c.log.Warn("day close failed", "err", err.Error())
+      }
+    }
+  }
+}
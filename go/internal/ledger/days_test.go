@@ -0,0 +1,108 @@
+package ledger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"time-ledger-sim/go/internal/store/storetest"
+)
+
+func TestBusinessDay(t *testing.T) {
+	at := func(s string) time.Time {
+		t.Helper()
+		v, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	for _, tc := range []struct {
+		t       string
+		cutover int
+		day     string
+		opens   string
+	}{
+		{"2026-03-10T00:00:00Z", 0, "2026-03-10", "2026-03-10T00:00:00Z"},
+		{"2026-03-10T23:59:59Z", 0, "2026-03-10", "2026-03-10T00:00:00Z"},
+		{"2026-03-10T16:59:00Z", 17 * 60, "2026-03-10", "2026-03-09T17:00:00Z"},
+		{"2026-03-10T17:00:00Z", 17 * 60, "2026-03-11", "2026-03-10T17:00:00Z"},
+		{"2026-03-10T09:30:00+09:00", 60, "2026-03-10", "2026-03-09T01:00:00Z"},
+	} {
+		d := businessDay(at(tc.t), tc.cutover)
+		opens, closes := dayBounds(d, tc.cutover)
+		if d.Format(dateLayout) != tc.day || !opens.Equal(at(tc.opens)) || closes.Sub(opens) != 24*time.Hour {
+			t.Errorf("%s at cutover %d: day %s [%s, %s), want %s from %s", tc.t, tc.cutover, d.Format(dateLayout), opens, closes, tc.day, tc.opens)
+		}
+	}
+}
+
+func TestCloseDays(t *testing.T) {
+	db := storetest.Open(t)
+	ctx := context.Background()
+	l := New(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	clock := NewVirtualClock()
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	clock.Freeze(today.Add(10 * time.Hour))
+	l.SetClock(clock)
+
+	zone := "zone-eod-" + uuid.NewString()[:8]
+	if _, err := l.CreateZone(ctx, CreateZoneInput{ID: zone, Name: zone, Actor: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.SetZoneControls(ctx, zone, SetZoneControlsInput{CrossZoneThrottle: 100, DayCutoverMinute: 17 * 60, Actor: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	transfer := func(units int64) {
+		t.Helper()
+		_, _, err := l.CreateTransfer(ctx, CreateTransferInput{
+			RequestID: "eod-" + uuid.NewString(), PayloadHash: "h", FromAccount: zone + "-a", ToAccount: zone + "-b", AmountUnits: units, ZoneID: zone,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	transfer(2)
+	transfer(3)
+	if s, err := l.GetBusinessDay(ctx, zone, today); err != nil || s.Closed || s.Transfers != 2 {
+		t.Fatalf("open day = %+v, %v", s, err)
+	}
+
+	clock.Advance(8 * time.Hour) // past the 17:00 cutover
+	transfer(5)
+	for range 2 { // the second pass finds nothing new to close
+		if _, err := l.CloseDays(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, err := l.GetBusinessDay(ctx, zone, today)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Closed || s.Transfers != 2 || s.AmountUnits != 5 || s.ActiveAccounts != 2 || s.ByType[TxnTypeTransfer].Transfers != 2 {
+		t.Errorf("closed day = %+v", s)
+	}
+	if !s.OpensAt.Equal(today.Add(-7*time.Hour)) || !s.ClosesAt.Equal(today.Add(17*time.Hour)) {
+		t.Errorf("closed day runs [%s, %s)", s.OpensAt, s.ClosesAt)
+	}
+	var events int
+	if err := db.QueryRow(ctx, `SELECT COUNT(*) FROM outbox_events WHERE event_type='DAY_CLOSED' AND aggregate_id=$1`, zone).Scan(&events); err != nil || events != 1 {
+		t.Errorf("DAY_CLOSED events = %d (%v), want 1", events, err)
+	}
+
+	next, err := l.GetBusinessDay(ctx, zone, today.Add(24*time.Hour))
+	if err != nil || next.Closed || next.Transfers != 1 || !next.OpensAt.Equal(s.ClosesAt) {
+		t.Errorf("next day = %+v, %v", next, err)
+	}
+	if _, err := l.GetBusinessDay(ctx, zone, today.Add(48*time.Hour)); !IsDayNotFound(err) {
+		t.Errorf("day not begun: err = %v", err)
+	}
+	if _, err := l.GetBusinessDay(ctx, "zone-none-"+uuid.NewString()[:8], today); !IsZoneNotFound(err) {
+		t.Errorf("unknown zone: err = %v", err)
+	}
+}
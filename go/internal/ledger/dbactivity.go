@@ -0,0 +1,41 @@
+package ledger
+
+import (
+  "context"
+  "time"
+)
+
+// OpenTransaction is a transaction currently open in this database, from
+// pg_stat_activity.
+type OpenTransaction struct {
+  PID int32 `json:"pid"`
+  State string `json:"state"`
+  Application string `json:"application"`
+  XactStart time.Time `json:"xact_start"`
+  AgeMs float64 `json:"age_ms"`
+  WaitEvent *string `json:"wait_event"`
+  Query string `json:"query"` // current or last statement, truncated
+}
+
+// OpenTransactions lists transactions open for at least minAge, oldest first.
+// Other sessions' queries are only visible to superusers or pg_read_all_stats.
+func (l *Ledger) OpenTransactions(ctx context.Context, minAge time.Duration) ([]OpenTransaction, error) {
+  rows, err := l.db.Query(ctx, `
+    SELECT pid, COALESCE(state,''), application_name, xact_start,
+      EXTRACT(EPOCH FROM now()-xact_start)*1000, wait_event, left(query, 300)
+    FROM pg_stat_activity
+    WHERE datname=current_database() AND xact_start IS NOT NULL AND pid<>pg_backend_pid()
+      AND now()-xact_start >= make_interval(secs => $1)
+    ORDER BY xact_start
+    LIMIT 50
+  `, minAge.Seconds())
+  if err != nil { return nil, err }
+  defer rows.Close()
+  out := []OpenTransaction{}
+  for rows.Next() {
+    var t OpenTransaction
+    if err := rows.Scan(&t.PID, &t.State, &t.Application, &t.XactStart, &t.AgeMs, &t.WaitEvent, &t.Query); err != nil { return nil, err }
+    out = append(out, t)
+  }
+  return out, rows.Err()
+}
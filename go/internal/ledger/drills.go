@@ -0,0 +1,598 @@
+package ledger
+
+import (
+  "context"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "regexp"
+  "slices"
+  "sort"
+  "time"
+
+  "github.com/google/uuid"
+  "github.com/jackc/pgx/v5"
+  "github.com/jackc/pgx/v5/pgconn"
+
+  "time-ledger-sim/go/internal/tracing"
+)
+
+var (
+  ErrDrillNotFound = errors.New("drill not found")
+  ErrDrillRunning = errors.New("a drill is already running")
+  ErrDrillEnded = errors.New("drill has ended")
+)
+
+func IsDrillNotFound(err error) bool { return errors.Is(err, ErrDrillNotFound) }
+func IsDrillRunning(err error) bool { return errors.Is(err, ErrDrillRunning) }
+func IsDrillEnded(err error) bool { return errors.Is(err, ErrDrillEnded) }
+
+const (
+  DrillInjectIncident = "incident" // a synthetic incident
+  DrillInjectFraudHit = "fraud_hit" // an incident as the fraud consumer raises it
+  DrillInjectFlapZone = "flap_zone" // the zone goes DOWN and back, repeatedly
+)
+
+// GameMasterActor makes a drill's injects. Its audit entries carry the
+// drill's id and are hidden from the audit views until the drill ends.
+const GameMasterActor = "scenario:gamemaster"
+
+const (
+  maxDrillInjects = 200
+  maxDrillFlaps = 50
+  defaultDrillFlaps = 3
+  defaultDrillFlapPeriod = 30 * time.Second
+  defaultDrillFlapReason = "health checks failing"
+  maxDrillTraineeActions = 1000
+)
+
+var auditActionPattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]{1,63}$`)
+
+// drillKey carries the id of the drill the game master is acting for.
+type drillKey struct{}
+
+// withDrill marks the audit entries written under ctx as the drill's.
+func withDrill(ctx context.Context, id string) context.Context { return context.WithValue(ctx, drillKey{}, id) }
+
+// drillHidden matches audit_log rows of a drill that is still running, which
+// trainee-facing queries leave out.
+const drillHidden = `(details ? 'drill_id' AND details->>'drill_id' IN (SELECT id::text FROM drills WHERE ended_at IS NULL))`
+
+// DrillExpectation is what a trainee should do after an inject: an audited
+// action on the inject's zone or one of its incidents.
+type DrillExpectation struct {
+  Action string `json:"action"` // audit action, e.g. INCIDENT_ACK, SET_ZONE_CONTROLS
+  // Within is the deadline after the inject, in wall time since trainees
+  // respond in real time; 0 means by the end of the drill.
+  Within ScenarioOffset `json:"within,omitempty"`
+}
+
+// DrillInject is one step of a drill script. At is the offset from the
+// drill's start on the sim clock; ad hoc injects run at once and ignore it.
+type DrillInject struct {
+  At ScenarioOffset `json:"at"`
+  Action string `json:"action"` // incident|fraud_hit|flap_zone
+  ZoneID string `json:"zone_id"`
+  Severity string `json:"severity,omitempty"` // incident: INFO|WARN|CRITICAL, default WARN
+  Title string `json:"title,omitempty"` // incident
+  Details map[string]any `json:"details,omitempty"` // incident: what trainees see
+  AmountUnits int64 `json:"amount_units,omitempty"` // fraud_hit
+  TransactionID string `json:"transaction_id,omitempty"` // fraud_hit: the flagged transaction, optional
+  Flaps int `json:"flaps,omitempty"` // flap_zone: DOWN-and-back cycles, default 3
+  Period ScenarioOffset `json:"period,omitempty"` // flap_zone: time in each state, default 30s
+  Reason string `json:"reason,omitempty"` // flap_zone: recorded on the DOWN incidents
+  Expect *DrillExpectation `json:"expect,omitempty"`
+}
+
+// normalize fills the defaults and validates the inject.
+func (in *DrillInject) normalize() error {
+  if in.At < 0 { return fmt.Errorf("negative offset") }
+  if in.ZoneID == "" { return fmt.Errorf("zone_id required") }
+  switch in.Action {
+  case DrillInjectIncident:
+    if in.Severity == "" { in.Severity = "WARN" }
+    if in.Severity != "INFO" && in.Severity != "WARN" && in.Severity != "CRITICAL" { return fmt.Errorf("invalid severity") }
+    if in.Title == "" { return fmt.Errorf("title required") }
+  case DrillInjectFraudHit:
+    if in.AmountUnits <= 0 { return fmt.Errorf("amount_units must be positive") }
+    if in.TransactionID != "" {
+      if _, err := uuid.Parse(in.TransactionID); err != nil { return fmt.Errorf("invalid transaction_id") }
+    }
+  case DrillInjectFlapZone:
+    if in.Flaps == 0 { in.Flaps = defaultDrillFlaps }
+    if in.Flaps < 1 || in.Flaps > maxDrillFlaps { return fmt.Errorf("flaps must be 1-%d", maxDrillFlaps) }
+    if in.Period == 0 { in.Period = ScenarioOffset(defaultDrillFlapPeriod) }
+    if time.Duration(in.Period) < time.Second { return fmt.Errorf("period must be at least 1s") }
+    if in.Reason == "" { in.Reason = defaultDrillFlapReason }
+  default:
+    return fmt.Errorf("unknown action %q", in.Action)
+  }
+  if in.Expect != nil {
+    if !auditActionPattern.MatchString(in.Expect.Action) { return fmt.Errorf("expect.action must be an audit action such as INCIDENT_ACK") }
+    if in.Expect.Within < 0 { return fmt.Errorf("expect.within must not be negative") }
+  }
+  return nil
+}
+
+// StartDrillInput is a drill script and who it trains.
+type StartDrillInput struct {
+  Name string `json:"name"`
+  Description string `json:"description,omitempty"`
+  Trainees []string `json:"trainees,omitempty"` // actor ids; empty: anyone who is not a scenario
+  Injects []DrillInject `json:"injects"`
+  Actor string `json:"actor"`
+}
+
+// ParseDrill decodes a JSON or YAML drill script and validates it. Injects
+// are returned sorted by offset.
+func ParseDrill(body []byte, isYAML bool) (*StartDrillInput, error) {
+  if isYAML {
+    b, err := yamlToJSON(body)
+    if err != nil { return nil, err }
+    body = b
+  }
+  var in StartDrillInput
+  if err := json.Unmarshal(body, &in); err != nil { return nil, fmt.Errorf("bad drill: %w", err) }
+  if err := in.validate(); err != nil { return nil, err }
+  return &in, nil
+}
+
+func (in *StartDrillInput) validate() error {
+  if !scenarioNamePattern.MatchString(in.Name) { return fmt.Errorf("invalid drill name") }
+  if len(in.Injects) > maxDrillInjects { return fmt.Errorf("at most %d injects", maxDrillInjects) }
+  for i := range in.Injects {
+    if err := in.Injects[i].normalize(); err != nil { return fmt.Errorf("injects[%d]: %w", i, err) }
+  }
+  sort.SliceStable(in.Injects, func(i, j int) bool { return in.Injects[i].At < in.Injects[j].At })
+  return nil
+}
+
+// DrillLogEntry records an executed inject.
+type DrillLogEntry struct {
+  Inject int `json:"inject"` // index into the drill's injects
+  Action string `json:"action"`
+  ZoneID string `json:"zone_id"`
+  ExecutedAt time.Time `json:"executed_at"`
+  OK bool `json:"ok"`
+  Error string `json:"error,omitempty"`
+  IncidentID string `json:"incident_id,omitempty"`
+  Note string `json:"note,omitempty"`
+}
+
+type Drill struct {
+  ID string `json:"id"`
+  Name string `json:"name"`
+  Description *string `json:"description"`
+  Injects []DrillInject `json:"injects"`
+  Trainees []string `json:"trainees"`
+  Log []DrillLogEntry `json:"log"`
+  Running bool `json:"running"`
+  StartedBy string `json:"started_by"`
+  StartedAt time.Time `json:"started_at"`
+  EndedBy *string `json:"ended_by"`
+  EndedAt *time.Time `json:"ended_at"`
+}
+
+const drillCols = `id::text, name, description, injects, trainees, log, started_by, started_at, ended_by, ended_at`
+
+func scanDrill(row pgx.Row) (*Drill, error) {
+  var d Drill
+  var injects, log []byte
+  if err := row.Scan(&d.ID, &d.Name, &d.Description, &injects, &d.Trainees, &log, &d.StartedBy, &d.StartedAt, &d.EndedBy, &d.EndedAt); err != nil {
+    return nil, err
+  }
+  d.Injects, d.Log = []DrillInject{}, []DrillLogEntry{}
+  _ = json.Unmarshal(injects, &d.Injects)
+  _ = json.Unmarshal(log, &d.Log)
+  if d.Trainees == nil { d.Trainees = []string{} }
+  d.Running = d.EndedAt == nil
+  return &d, nil
+}
+
+// StartDrill records a new drill and registers the game master actor. Only
+// one drill runs at a time. The ScenarioRunner executes the script.
+func (l *Ledger) StartDrill(ctx context.Context, in StartDrillInput) (*Drill, error) {
+  if in.Actor == "" { return nil, fmt.Errorf("actor required") }
+  if err := in.validate(); err != nil { return nil, err }
+  zones := map[string]bool{}
+  for _, inj := range in.Injects { zones[inj.ZoneID] = true }
+  for z := range zones {
+    if _, err := l.repo.ZoneStatus(ctx, z); err != nil { return nil, err }
+  }
+
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+  if err := l.checkActors(ctx, tx, in.Actor); err != nil { return nil, err }
+  _, err = tx.Exec(ctx, `INSERT INTO actors(id,kind,name) VALUES($1,$2,'gamemaster') ON CONFLICT (id) DO NOTHING`, GameMasterActor, ActorKindScenario)
+  if err != nil { return nil, err }
+  injects, _ := json.Marshal(in.Injects)
+  trainees := in.Trainees
+  if trainees == nil { trainees = []string{} }
+  d, err := scanDrill(tx.QueryRow(ctx, `
+    INSERT INTO drills(name,description,injects,trainees,started_by) VALUES($1,NULLIF($2,''),$3::jsonb,$4,$5)
+    RETURNING `+drillCols, in.Name, in.Description, string(injects), trainees, in.Actor))
+  var pgErr *pgconn.PgError
+  if errors.As(err, &pgErr) && pgErr.Code == "23505" { return nil, ErrDrillRunning }
+  if err != nil { return nil, err }
+  err = l.audit(withDrill(ctx, d.ID), pgQueries{tx}, AuditRecord{
+    Actor: in.Actor, Action: "START_DRILL", TargetType: "drill", TargetID: d.ID,
+    Details: map[string]any{"name": in.Name, "injects": len(in.Injects), "trainees": trainees},
+  })
+  if err != nil { return nil, err }
+  return d, tx.Commit(ctx)
+}
+
+func (l *Ledger) GetDrill(ctx context.Context, id string) (*Drill, error) {
+  d, err := scanDrill(l.db.QueryRow(ctx, `SELECT `+drillCols+` FROM drills WHERE id::text=$1`, id))
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrDrillNotFound }
+  return d, err
+}
+
+func (l *Ledger) ListDrills(ctx context.Context, limit int) ([]Drill, error) {
+  if limit <= 0 || limit > 200 { limit = 50 }
+  rows, err := l.ro.Query(ctx, `SELECT `+drillCols+` FROM drills ORDER BY started_at DESC LIMIT $1`, limit)
+  if err != nil { return nil, err }
+  defer rows.Close()
+  out := []Drill{}
+  for rows.Next() {
+    d, err := scanDrill(rows)
+    if err != nil { return nil, err }
+    out = append(out, *d)
+  }
+  return out, rows.Err()
+}
+
+// EndDrill ends a running drill, which reveals its audit entries.
+func (l *Ledger) EndDrill(ctx context.Context, id, actor string) (*Drill, error) {
+  if actor == "" { return nil, fmt.Errorf("actor required") }
+  d, err := scanDrill(l.db.QueryRow(ctx, `
+    UPDATE drills SET ended_by=$2, ended_at=now() WHERE id::text=$1 AND ended_at IS NULL
+    RETURNING `+drillCols, id, actor))
+  if errors.Is(err, pgx.ErrNoRows) {
+    if _, err := l.GetDrill(ctx, id); err != nil { return nil, err }
+    return nil, ErrDrillEnded
+  }
+  if err != nil { return nil, err }
+  _ = l.audit(ctx, pgQueries{l.db}, AuditRecord{
+    Actor: actor, Action: "END_DRILL", TargetType: "drill", TargetID: d.ID, Details: map[string]any{"drill_id": d.ID},
+  })
+  return d, nil
+}
+
+// addDrillInject appends an ad hoc inject to a running drill and returns
+// its index.
+func (l *Ledger) addDrillInject(ctx context.Context, id string, in DrillInject) (int, error) {
+  if _, err := l.repo.ZoneStatus(ctx, in.ZoneID); err != nil { return 0, err }
+  b, _ := json.Marshal(in)
+  var n int
+  err := l.db.QueryRow(ctx, `
+    UPDATE drills SET injects=injects || jsonb_build_array($2::jsonb) WHERE id::text=$1 AND ended_at IS NULL
+    RETURNING jsonb_array_length(injects)
+  `, id, string(b)).Scan(&n)
+  if errors.Is(err, pgx.ErrNoRows) {
+    if _, err := l.GetDrill(ctx, id); err != nil { return 0, err }
+    return 0, ErrDrillEnded
+  }
+  return n - 1, err
+}
+
+func (l *Ledger) drillRunning(ctx context.Context, id string) (bool, error) {
+  var running bool
+  err := l.db.QueryRow(ctx, `SELECT ended_at IS NULL FROM drills WHERE id::text=$1`, id).Scan(&running)
+  if errors.Is(err, pgx.ErrNoRows) { return false, ErrDrillNotFound }
+  return running, err
+}
+
+func (l *Ledger) recordDrillInject(ctx context.Context, id string, e DrillLogEntry) error {
+  b, _ := json.Marshal(e)
+  _, err := l.db.Exec(ctx, `UPDATE drills SET log=log || jsonb_build_array($2::jsonb) WHERE id::text=$1`, id, string(b))
+  return err
+}
+
+// raiseDrillIncident inserts an incident or fake fraud hit, audited as the
+// game master's DRILL_INJECT. Trainees see an ordinary incident.
+func (l *Ledger) raiseDrillIncident(ctx context.Context, drillID string, i int, in DrillInject) (string, error) {
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return "", err }
+  defer func() { _ = tx.Rollback(ctx) }()
+  var id string
+  if in.Action == DrillInjectFraudHit {
+    // the same row the fraud consumer writes for a large transfer
+    err = tx.QueryRow(ctx, `
+      INSERT INTO incidents(zone_id, related_txn_id, severity, title, details)
+      VALUES($1, NULLIF($2,'')::uuid, 'WARN', 'Large time transfer', jsonb_build_object('amount_units',$3::bigint,'rule','large_transfer') || `+incidentTrace(4)+`)
+      RETURNING id::text
+    `, in.ZoneID, in.TransactionID, in.AmountUnits, tracing.TraceID(ctx)).Scan(&id)
+  } else {
+    details := in.Details
+    if details == nil { details = map[string]any{} }
+    b, _ := json.Marshal(details)
+    err = tx.QueryRow(ctx, `
+      INSERT INTO incidents(zone_id,severity,title,details) VALUES($1,$2,$3,$4::jsonb || `+incidentTrace(5)+`) RETURNING id::text
+    `, in.ZoneID, in.Severity, in.Title, string(b), tracing.TraceID(ctx)).Scan(&id)
+  }
+  if err != nil { return "", err }
+  err = l.audit(withDrill(ctx, drillID), pgQueries{tx}, AuditRecord{
+    Actor: GameMasterActor, Action: "DRILL_INJECT", TargetType: "incident", TargetID: id,
+    Details: map[string]any{"inject": i, "kind": in.Action},
+  })
+  if err != nil { return "", err }
+  return id, tx.Commit(ctx)
+}
+
+// executeDrillInject runs one inject and logs it. A flap_zone inject blocks
+// until its last flap or until ctx is done.
+func (l *Ledger) executeDrillInject(ctx context.Context, drillID string, i int, in DrillInject) error {
+  entry := DrillLogEntry{Inject: i, Action: in.Action, ZoneID: in.ZoneID, ExecutedAt: time.Now().UTC(), OK: true}
+  bg := context.WithoutCancel(ctx)
+  if in.Action != DrillInjectFlapZone {
+    id, err := l.raiseDrillIncident(ctx, drillID, i, in)
+    if err != nil { entry.OK, entry.Error = false, err.Error() }
+    entry.IncidentID = id
+    return errors.Join(err, l.recordDrillInject(bg, drillID, entry))
+  }
+
+  orig, err := l.repo.ZoneStatus(ctx, in.ZoneID)
+  if err != nil {
+    entry.OK, entry.Error = false, err.Error()
+    return errors.Join(err, l.recordDrillInject(bg, drillID, entry))
+  }
+  up := orig
+  if up == "DOWN" { up = "OK" }
+  states := []string{}
+  for k := 0; k < in.Flaps; k++ { states = append(states, "DOWN", up) }
+  if orig != up { states = append(states, orig) }
+  period := time.Duration(in.Period)
+  entry.Note = fmt.Sprintf("%d flaps, %s each way", in.Flaps, period)
+  cur := orig
+  for n, st := range states {
+    if n > 0 {
+      err := waitUntil(ctx, l.clock, l.clock.Now().Add(period))
+      running := err == nil
+      if running { running, err = l.drillRunning(ctx, drillID) }
+      if !running {
+        // the drill ended mid-flap: leave the zone as it was found
+        if cur != orig { _, err = l.SetZoneStatus(withDrill(bg, drillID), in.ZoneID, orig, GameMasterActor, ScenarioReasonCode, in.Reason) }
+        return err
+      }
+    }
+    if _, err := l.SetZoneStatus(withDrill(ctx, drillID), in.ZoneID, st, GameMasterActor, ScenarioReasonCode, in.Reason); err != nil {
+      e := entry
+      e.ExecutedAt, e.Note, e.OK, e.Error = time.Now().UTC(), "setting "+st, false, err.Error()
+      return errors.Join(err, l.recordDrillInject(bg, drillID, e))
+    }
+    cur = st
+    if n == 0 {
+      if err := l.recordDrillInject(bg, drillID, entry); err != nil { return err }
+    }
+  }
+  return nil
+}
+
+// DrillExpectationResult compares one expectation of the script with what
+// the trainees did.
+type DrillExpectationResult struct {
+  Inject int `json:"inject"`
+  InjectAction string `json:"inject_action"`
+  ZoneID string `json:"zone_id"`
+  InjectedAt *time.Time `json:"injected_at"` // nil: the inject never ran
+  Expected string `json:"expected"`
+  Deadline *time.Time `json:"deadline"` // nil: by the end of the drill
+  Met bool `json:"met"`
+  By *AuditEntry `json:"by"` // the trainee action that met it
+  ResponseSeconds *float64 `json:"response_seconds"`
+}
+
+// DrillTraineeSummary is one trainee's part in a drill.
+type DrillTraineeSummary struct {
+  Actor string `json:"actor"`
+  Actions int `json:"actions"`
+  Met int `json:"met"` // expectations their actions met
+  FirstActionAt *time.Time `json:"first_action_at"`
+}
+
+// DrillDebrief compares the trainees' audited actions on the drill's zones
+// with the script's expectations. Before the drill ends it is provisional.
+type DrillDebrief struct {
+  Drill Drill `json:"drill"`
+  Final bool `json:"final"`
+  Expectations []DrillExpectationResult `json:"expectations"`
+  Met int `json:"met"`
+  Missed int `json:"missed"`
+  ScorePercent *int `json:"score_percent"` // nil: the script expects nothing
+  Trainees []DrillTraineeSummary `json:"trainees"`
+  // TraineeActions are oldest first, up to maxDrillTraineeActions; Unscripted
+  // counts those that met no expectation.
+  TraineeActions []AuditEntry `json:"trainee_actions"`
+  TraineeActionsTruncated bool `json:"trainee_actions_truncated"`
+  Unscripted int `json:"unscripted"`
+  GeneratedAt time.Time `json:"generated_at"`
+}
+
+// DrillDebrief builds the drill's debrief. An expectation is met by the
+// first unused trainee action with the expected audit action on the inject's
+// zone, or an incident in it, between the inject and its deadline.
+func (l *Ledger) DrillDebrief(ctx context.Context, id string) (*DrillDebrief, error) {
+  d, err := l.GetDrill(ctx, id)
+  if err != nil { return nil, err }
+  db := &DrillDebrief{Drill: *d, Final: !d.Running, Expectations: []DrillExpectationResult{}, Trainees: []DrillTraineeSummary{}, GeneratedAt: time.Now().UTC()}
+  end := db.GeneratedAt
+  if d.EndedAt != nil { end = *d.EndedAt }
+
+  zones := []string{}
+  for _, in := range d.Injects {
+    if !slices.Contains(zones, in.ZoneID) { zones = append(zones, in.ZoneID) }
+  }
+  rows, err := l.ro.Query(ctx, `
+    SELECT a.id::text, a.actor, a.action, a.target_type, a.target_id, a.reason, a.details, a.created_at, COALESCE(i.zone_id, a.target_id)
+    FROM audit_log a
+    LEFT JOIN incidents i ON a.target_type='incident' AND i.id::text=a.target_id
+    WHERE a.created_at BETWEEN $1 AND $2
+      AND NOT (a.details ? 'drill_id')
+      AND a.actor NOT LIKE 'scenario:%'
+      AND (cardinality($3::text[])=0 OR a.actor=ANY($3))
+      AND ((a.target_type='zone' AND a.target_id=ANY($4)) OR i.zone_id=ANY($4))
+    ORDER BY a.created_at, a.id
+    LIMIT $5
+  `, d.StartedAt, end, d.Trainees, zones, maxDrillTraineeActions+1)
+  if err != nil { return nil, err }
+  defer rows.Close()
+  db.TraineeActions = []AuditEntry{}
+  actionZones := []string{}
+  for rows.Next() {
+    var e AuditEntry
+    var details []byte
+    var zone string
+    if err := rows.Scan(&e.ID, &e.Actor, &e.Action, &e.TargetType, &e.TargetID, &e.Reason, &details, &e.CreatedAt, &zone); err != nil { return nil, err }
+    _ = json.Unmarshal(details, &e.Details)
+    db.TraineeActions = append(db.TraineeActions, e)
+    actionZones = append(actionZones, zone)
+  }
+  if err := rows.Err(); err != nil { return nil, err }
+  if len(db.TraineeActions) > maxDrillTraineeActions {
+    db.TraineeActions, db.TraineeActionsTruncated = db.TraineeActions[:maxDrillTraineeActions], true
+  }
+
+  injectedAt := map[int]time.Time{}
+  for _, e := range d.Log {
+    if _, ok := injectedAt[e.Inject]; !ok && e.OK { injectedAt[e.Inject] = e.ExecutedAt }
+  }
+  for i, in := range d.Injects {
+    if in.Expect == nil { continue }
+    r := DrillExpectationResult{Inject: i, InjectAction: in.Action, ZoneID: in.ZoneID, Expected: in.Expect.Action}
+    if at, ok := injectedAt[i]; ok {
+      r.InjectedAt = &at
+      if in.Expect.Within > 0 { dl := at.Add(time.Duration(in.Expect.Within)); r.Deadline = &dl }
+    }
+    db.Expectations = append(db.Expectations, r)
+  }
+  // earliest inject first, so a response goes to the inject it answers
+  sort.SliceStable(db.Expectations, func(i, j int) bool {
+    a, b := db.Expectations[i].InjectedAt, db.Expectations[j].InjectedAt
+    if a == nil || b == nil { return b == nil && a != nil }
+    return a.Before(*b)
+  })
+
+  used := make([]bool, len(db.TraineeActions))
+  metBy := map[string]int{}
+  for k := range db.Expectations {
+    r := &db.Expectations[k]
+    if r.InjectedAt == nil { continue }
+    for j, e := range db.TraineeActions {
+      if used[j] || e.Action != r.Expected || actionZones[j] != r.ZoneID || e.CreatedAt.Before(*r.InjectedAt) { continue }
+      if r.Deadline != nil && e.CreatedAt.After(*r.Deadline) { continue }
+      used[j], r.Met = true, true
+      by := e
+      secs := e.CreatedAt.Sub(*r.InjectedAt).Seconds()
+      r.By, r.ResponseSeconds = &by, &secs
+      metBy[e.Actor]++
+      break
+    }
+  }
+  sort.SliceStable(db.Expectations, func(i, j int) bool { return db.Expectations[i].Inject < db.Expectations[j].Inject })
+  for _, r := range db.Expectations {
+    if r.Met { db.Met++ } else { db.Missed++ }
+  }
+  if n := len(db.Expectations); n > 0 { score := db.Met * 100 / n; db.ScorePercent = &score }
+
+  byActor := map[string]int{}
+  for j, e := range db.TraineeActions {
+    if !used[j] { db.Unscripted++ }
+    k, ok := byActor[e.Actor]
+    if !ok {
+      at := e.CreatedAt
+      k = len(db.Trainees)
+      byActor[e.Actor] = k
+      db.Trainees = append(db.Trainees, DrillTraineeSummary{Actor: e.Actor, Met: metBy[e.Actor], FirstActionAt: &at})
+    }
+    db.Trainees[k].Actions++
+  }
+  for _, t := range d.Trainees {
+    if _, ok := byActor[t]; !ok { db.Trainees = append(db.Trainees, DrillTraineeSummary{Actor: t}) }
+  }
+  return db, nil
+}
+
+// drillRun is what a replica runs for a drill: its script and ad hoc flaps,
+// all stopped when the drill ends.
+type drillRun struct {
+  ctx context.Context
+  cancel context.CancelFunc
+}
+
+// drillContext returns the context the drill's injects run under on this
+// replica, creating it for a drill started elsewhere or before a restart.
+func (s *ScenarioRunner) drillContext(id string) context.Context {
+  s.mu.Lock()
+  defer s.mu.Unlock()
+  if r, ok := s.drills[id]; ok { return r.ctx }
+  ctx, cancel := context.WithCancel(s.base)
+  s.drills[id] = drillRun{ctx: ctx, cancel: cancel}
+  return ctx
+}
+
+// StartDrill starts a drill and runs its script on the sim clock.
+func (s *ScenarioRunner) StartDrill(ctx context.Context, in StartDrillInput) (*Drill, error) {
+  d, err := s.led.StartDrill(ctx, in)
+  if err != nil { return nil, err }
+  go s.runDrillScript(s.drillContext(d.ID), d)
+  return d, nil
+}
+
+// runDrillScript executes the injects at their offsets. Flapping runs
+// alongside, so it does not hold up the injects after it.
+func (s *ScenarioRunner) runDrillScript(ctx context.Context, d *Drill) {
+  clock := s.led.clock
+  start := clock.Now()
+  for i, in := range d.Injects {
+    if err := waitUntil(ctx, clock, start.Add(time.Duration(in.At))); err != nil { return }
+    if running, err := s.led.drillRunning(ctx, d.ID); err != nil || !running {
+      if err == nil { s.forgetDrill(d.ID) }
+      return
+    }
+    if in.Action == DrillInjectFlapZone {
+      go s.runDrillInject(ctx, d.ID, i, in)
+      continue
+    }
+    s.runDrillInject(ctx, d.ID, i, in)
+  }
+}
+
+func (s *ScenarioRunner) runDrillInject(ctx context.Context, id string, i int, in DrillInject) {
+  if err := s.led.executeDrillInject(ctx, id, i, in); err != nil && ctx.Err() == nil {
+    s.log.Warn("drill inject failed", "drill_id", id, "inject", i, "action", in.Action, "err", err.Error())
+  }
+}
+
+// Inject adds an ad hoc inject to a running drill and executes it now. An
+// incident or fraud hit is raised before it returns; flapping runs in the
+// background until the drill ends.
+func (s *ScenarioRunner) Inject(ctx context.Context, id string, in DrillInject) (*Drill, error) {
+  in.At = 0
+  if err := in.normalize(); err != nil { return nil, err }
+  i, err := s.led.addDrillInject(ctx, id, in)
+  if err != nil { return nil, err }
+  if in.Action == DrillInjectFlapZone {
+    go s.runDrillInject(s.drillContext(id), id, i, in)
+  } else if err := s.led.executeDrillInject(ctx, id, i, in); err != nil {
+    return nil, err
+  }
+  return s.led.GetDrill(ctx, id)
+}
+
+// EndDrill ends the drill, stopping its script and flapping on this replica,
+// and returns its debrief. Another replica's injects stop at their next step,
+// when they find the drill ended.
+func (s *ScenarioRunner) EndDrill(ctx context.Context, id, actor string) (*DrillDebrief, error) {
+  if _, err := s.led.EndDrill(ctx, id, actor); err != nil { return nil, err }
+  s.forgetDrill(id)
+  return s.led.DrillDebrief(ctx, id)
+}
+
+// forgetDrill stops what this replica runs for the drill.
+func (s *ScenarioRunner) forgetDrill(id string) {
+  s.mu.Lock()
+  defer s.mu.Unlock()
+  if r, ok := s.drills[id]; ok { r.cancel(); delete(s.drills, id) }
+}
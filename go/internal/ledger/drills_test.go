@@ -0,0 +1,203 @@
+package ledger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"time-ledger-sim/go/internal/store/storetest"
+)
+
+func TestParseDrill_DefaultsAndOrder(t *testing.T) {
+	body := []byte(`
+name: eu-night-shift
+trainees: [alice]
+injects:
+  - at: 2m
+    action: flap_zone
+    zone_id: zone-eu
+  - at: 10s
+    action: incident
+    zone_id: zone-eu
+    title: Replication lag
+    expect: {action: INCIDENT_ACK, within: 5m}
+`)
+	in, err := ParseDrill(body, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inc, flap := in.Injects[0], in.Injects[1]
+	if inc.Action != DrillInjectIncident || inc.Severity != "WARN" || time.Duration(inc.Expect.Within) != 5*time.Minute {
+		t.Errorf("incident inject = %+v", inc)
+	}
+	if flap.Flaps != defaultDrillFlaps || time.Duration(flap.Period) != defaultDrillFlapPeriod || flap.Reason == "" {
+		t.Errorf("flap inject = %+v", flap)
+	}
+}
+
+func TestParseDrill_RejectsInvalid(t *testing.T) {
+	cases := map[string]string{
+		"bad name":       `{"name":"Bad Name","injects":[]}`,
+		"unknown action": `{"name":"x","injects":[{"at":"1s","action":"explode","zone_id":"zone-eu"}]}`,
+		"no zone":        `{"name":"x","injects":[{"at":"1s","action":"incident","title":"t"}]}`,
+		"no title":       `{"name":"x","injects":[{"at":"1s","action":"incident","zone_id":"zone-eu"}]}`,
+		"bad severity":   `{"name":"x","injects":[{"at":"1s","action":"incident","zone_id":"zone-eu","title":"t","severity":"BAD"}]}`,
+		"no amount":      `{"name":"x","injects":[{"at":"1s","action":"fraud_hit","zone_id":"zone-eu"}]}`,
+		"bad txn":        `{"name":"x","injects":[{"at":"1s","action":"fraud_hit","zone_id":"zone-eu","amount_units":5,"transaction_id":"nope"}]}`,
+		"too many flaps": `{"name":"x","injects":[{"at":"1s","action":"flap_zone","zone_id":"zone-eu","flaps":51}]}`,
+		"short period":   `{"name":"x","injects":[{"at":"1s","action":"flap_zone","zone_id":"zone-eu","period":"100ms"}]}`,
+		"bad expect":     `{"name":"x","injects":[{"at":"1s","action":"incident","zone_id":"zone-eu","title":"t","expect":{"action":"ack"}}]}`,
+	}
+	for name, body := range cases {
+		if _, err := ParseDrill([]byte(body), false); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestDrillHidesInjectsAndDebriefs(t *testing.T) {
+	db := storetest.Open(t)
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	l := New(db, log)
+	runner := NewScenarioRunner(l, log)
+
+	zone := "zone-drill-" + uuid.NewString()[:8]
+	if _, err := l.CreateZone(ctx, CreateZoneInput{ID: zone, Name: zone, Actor: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	// end a drill left running by an earlier test
+	if _, err := db.Exec(ctx, `UPDATE drills SET ended_at=now(), ended_by='test' WHERE ended_at IS NULL`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.RegisterActor(ctx, RegisterActorInput{ID: GameMasterActor, Kind: ActorKindScenario, Actor: "test"}); err != nil && !IsActorExists(err) {
+		t.Fatal(err)
+	}
+	since := l.clock.Now().Add(-time.Minute)
+	trainee := "trainee-" + uuid.NewString()[:8]
+	d, err := runner.StartDrill(ctx, StartDrillInput{
+		Name: "night-shift", Actor: "gm", Trainees: []string{trainee},
+		Injects: []DrillInject{
+			{Action: DrillInjectIncident, ZoneID: zone, Title: "Replication lag", Severity: "CRITICAL", Expect: &DrillExpectation{Action: "INCIDENT_ACK"}},
+			{Action: DrillInjectFraudHit, ZoneID: zone, AmountUnits: 5000, Expect: &DrillExpectation{Action: "INCIDENT_RESOLVE"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runner.StartDrill(ctx, StartDrillInput{Name: "second", Actor: "gm"}); !IsDrillRunning(err) {
+		t.Errorf("second drill: err = %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if d, err = l.GetDrill(ctx, d.ID); err != nil {
+			t.Fatal(err)
+		}
+		if len(d.Log) == 2 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if len(d.Log) != 2 || !d.Log[0].OK || d.Log[0].IncidentID == "" {
+		t.Fatalf("inject log = %+v", d.Log)
+	}
+
+	injected := func() int {
+		t.Helper()
+		entries, err := l.ListAuditForZone(ctx, zone, 500)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := 0
+		for _, e := range entries {
+			if e.Action == "DRILL_INJECT" {
+				n++
+			}
+		}
+		return n
+	}
+	if n := injected(); n != 0 {
+		t.Errorf("trainees see %d inject entries while the drill runs", n)
+	}
+	// nor do they see them in the game master's activity or the reason code
+	// usage of the drill's zone changes
+	flapCode := "DRILL_FLAP_" + strings.ToUpper(uuid.NewString()[:8])
+	err = l.audit(withDrill(ctx, d.ID), pgQueries{l.db}, AuditRecord{
+		Actor: GameMasterActor, Action: "SET_ZONE_STATUS", TargetType: "zone", TargetID: zone, ReasonCode: flapCode,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	activity := func() (injects int64, targets int) {
+		t.Helper()
+		a, err := l.GetActorActivity(ctx, GameMasterActor, since)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range a.Actions {
+			if c.Action == "DRILL_INJECT" {
+				injects += c.Count
+			}
+		}
+		for _, tg := range a.Targets {
+			if tg == "incident:"+d.Log[0].IncidentID {
+				targets++
+			}
+		}
+		return injects, targets
+	}
+	flaps := func() int64 {
+		t.Helper()
+		u, err := l.GetReasonCodeUsage(ctx, since, zone)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range u.Codes {
+			if c.Code == flapCode {
+				return c.Count
+			}
+		}
+		return 0
+	}
+	if n, tg := activity(); n != 0 || tg != 0 {
+		t.Errorf("game master activity shows %d injects and %d inject targets while the drill runs", n, tg)
+	}
+	if n := flaps(); n != 0 {
+		t.Errorf("reason code usage counts %d drill zone changes while the drill runs", n)
+	}
+
+	if _, err := l.ApplyIncidentAction(ctx, d.Log[0].IncidentID, IncidentAction{Action: "ACK", Actor: trainee}); err != nil {
+		t.Fatal(err)
+	}
+	db2, err := runner.EndDrill(ctx, d.ID, "gm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !db2.Final || db2.Met != 1 || db2.Missed != 1 || db2.ScorePercent == nil || *db2.ScorePercent != 50 {
+		t.Errorf("debrief: final=%t met=%d missed=%d score=%v", db2.Final, db2.Met, db2.Missed, db2.ScorePercent)
+	}
+	if e := db2.Expectations[0]; !e.Met || e.By == nil || e.By.Actor != trainee || e.ResponseSeconds == nil {
+		t.Errorf("ack expectation = %+v", e)
+	}
+	if len(db2.Trainees) != 1 || db2.Trainees[0].Actions != 1 || db2.Trainees[0].Met != 1 {
+		t.Errorf("trainees = %+v", db2.Trainees)
+	}
+	if n := injected(); n != 2 {
+		t.Errorf("after the drill trainees see %d inject entries, want 2", n)
+	}
+	if n, tg := activity(); n != 2 || tg != 1 {
+		t.Errorf("after the drill the game master activity shows %d injects and %d inject targets, want 2 and 1", n, tg)
+	}
+	if n := flaps(); n != 1 {
+		t.Errorf("after the drill reason code usage counts %d drill zone changes, want 1", n)
+	}
+	if _, err := runner.EndDrill(ctx, d.ID, "gm"); !IsDrillEnded(err) {
+		t.Errorf("ending twice: err = %v", err)
+	}
+}
@@ -0,0 +1,175 @@
+package ledger
+
+import (
+  "context"
+  "errors"
+  "fmt"
+)
+
+// TransferEstimate is what CreateTransfer would do with a transfer right now.
+type TransferEstimate struct {
+  Outcome string `json:"outcome"` // APPLIED, SPOOLED or REJECTED
+  Reason string `json:"reason,omitempty"` // why it would be spooled or rejected
+  RequestID string `json:"request_id"`
+  ZoneID string `json:"zone_id"`
+  ZoneStatus string `json:"zone_status,omitempty"`
+  // DelayMs is how long CreateTransfer would hold the transfer first (a DELAY
+  // blocked action); Outcome is what follows the delay, assuming any block
+  // other than a hash throttle is still in place.
+  DelayMs int `json:"delay_ms,omitempty"`
+  // Replay is set when request_id was already used: CreateTransfer would
+  // return the recorded transaction or spool entry instead of a new one.
+  Replay bool `json:"replay"`
+  TransactionID *string `json:"transaction_id,omitempty"`
+  SpoolID *string `json:"spool_id,omitempty"`
+  Balances []BalanceImpact `json:"balances"`
+  Err error `json:"-"` // the error CreateTransfer would return when REJECTED
+}
+
+// BalanceImpact is an account's balance now and after the transfer applies
+// (for a spooled transfer, once the spool is replayed). HeldUnits is what the
+// account's pending prepares hold; it is not taken off either balance.
+type BalanceImpact struct {
+  AccountID string `json:"account_id"`
+  BalanceUnits int64 `json:"balance_units"`
+  AfterUnits int64 `json:"after_units"`
+  HeldUnits int64 `json:"held_units"`
+}
+
+const (
+  EstimateApplied = "APPLIED"
+  EstimateSpooled = "SPOOLED"
+  EstimateRejected = "REJECTED"
+)
+
+// errEstimateDone rolls back the estimate's transaction: reading zone controls
+// and the rate bucket may create their rows, and an estimate writes nothing.
+var errEstimateDone = errors.New("estimate done")
+
+// EstimateTransfer runs the transfer path's checks (zone status and controls,
+// throttles, account controls, strict accounts, partitions, rate limit,
+// idempotency) without recording anything or taking a rate limit token.
+// Hash throttles depend on the request ID, so the estimate holds for a
+// transfer sent with the same one. Chaos faults are random and not estimated.
+// Errors other than a rejection (unknown zone or transaction type, database)
+// are returned as is.
+func (l *Ledger) EstimateTransfer(ctx context.Context, in CreateTransferInput) (*TransferEstimate, error) {
+  if err := checkTransactionType(in.Type); err != nil { return nil, err }
+  var est *TransferEstimate
+  err := l.repo.InTx(ctx, func(q Queries) error {
+    var err error
+    est, err = l.checkTransfer(ctx, q, in, false)
+    if err != nil { return err }
+    return errEstimateDone
+  })
+  if !errors.Is(err, errEstimateDone) { return nil, err }
+  return est, nil
+}
+
+// checkTransfer decides the transfer as createTransferTx would, without
+// recording it. With takeToken a rate limited zone's token is taken in q's
+// transaction, as for a transfer; otherwise it is only looked at.
+func (l *Ledger) checkTransfer(ctx context.Context, q Queries, in CreateTransferInput, takeToken bool) (*TransferEstimate, error) {
+  status, controls, err := l.zoneState(ctx, q, in.ZoneID)
+  if err != nil { return nil, err }
+  est := &TransferEstimate{Outcome: EstimateApplied, RequestID: in.RequestID, ZoneID: in.ZoneID, ZoneStatus: status}
+
+  for _, id := range []string{in.FromAccount, in.ToAccount} {
+    b, err := q.Balance(ctx, id)
+    if err != nil { return nil, err }
+    held, err := q.HeldUnits(ctx, id, in.RequestID, l.clock.Now())
+    if err != nil { return nil, err }
+    est.Balances = append(est.Balances, BalanceImpact{AccountID: id, BalanceUnits: b, AfterUnits: b, HeldUnits: held})
+  }
+  reject := func(err error) (*TransferEstimate, error) {
+    est.Outcome, est.Reason, est.Err = EstimateRejected, err.Error(), err
+    return est, nil
+  }
+  done := func(outcome, reason string) (*TransferEstimate, error) {
+    est.Outcome, est.Reason = outcome, reason
+    est.Balances[0].AfterUnits -= in.AmountUnits
+    est.Balances[1].AfterUnits += in.AmountUnits
+    return est, nil
+  }
+
+  // same order of checks as createTransferTx
+  blockedReason, throttleDelayed := "", false
+  if status == "DOWN" {
+    blockedReason = "zone down"
+  } else if controls.WritesBlocked {
+    blockedReason = "writes blocked"
+  } else if controls.ThrottleMode != ThrottleModeRate {
+    thr := controls.CrossZoneThrottle
+    if thr < 100 && (thr <= 0 || l.hashPercent(in.RequestID) >= thr) { blockedReason = "throttled" }
+    if blockedReason == "throttled" && controls.delaysThrottle() { blockedReason, throttleDelayed = "", true }
+  }
+
+  txn, spoolID, found, err := l.recordedRequest(ctx, q, in)
+  if IsIdempotencyConflict(err) { return reject(err) }
+  if err != nil { return nil, err }
+  if found {
+    est.Replay, est.SpoolID = true, spoolID
+    if txn != nil {
+      est.TransactionID = &txn.ID
+      est.Outcome, est.Reason = EstimateApplied, "already applied"
+    } else {
+      est.Outcome, est.Reason = EstimateSpooled, "already spooled"
+    }
+    return est, nil // the balances already include an applied replay
+  }
+  if throttleDelayed { est.DelayMs = controls.BlockedDelayMs }
+
+  if err := l.checkAccountsExist(ctx, q, in); err != nil {
+    if IsAccountNotFound(err) { return reject(err) }
+    return nil, err
+  }
+  if err := l.checkAccountControls(ctx, q, in); err != nil {
+    if IsAccountBlocked(err) { return reject(err) }
+    return nil, err
+  }
+  if err := l.checkAmountLimits(ctx, q, in, controls); err != nil {
+    if !isAmountLimit(err) { return nil, err }
+    if in.LimitOverride == nil { return reject(err) }
+  }
+
+  if blockedReason == "" {
+    p, err := l.partitionForTransfer(ctx, q, in.ZoneID, in.ToAccount)
+    if err != nil { return nil, err }
+    if p != nil {
+      if p.Mode == PartitionModeReject { return reject(fmt.Errorf("%w: %s -> %s", ErrPartitioned, p.FromZone, p.ToZone)) }
+      return done(EstimateSpooled, p.blockedReason())
+    }
+  }
+
+  if blockedReason == "" && controls.ThrottleMode == ThrottleModeRate {
+    var ok bool
+    if takeToken {
+      ok, err = l.takeRateToken(ctx, q, in.ZoneID, controls.RateLimitPerSec, controls.RateLimitBurst)
+    } else {
+      ok, err = l.peekRateToken(ctx, q, in.ZoneID, controls.RateLimitPerSec, controls.RateLimitBurst)
+    }
+    if err != nil { return nil, err }
+    if !ok { blockedReason = "rate limited" }
+  }
+
+  if blockedReason != "" {
+    action, rejectErr := controls.blockedOutcome(status, blockedReason, false)
+    if action == BlockedActionDelay {
+      est.DelayMs = controls.BlockedDelayMs
+      action, rejectErr = controls.blockedOutcome(status, blockedReason, true)
+    }
+    if action == BlockedActionSpool { return done(EstimateSpooled, blockedReason) }
+    return reject(rejectErr)
+  }
+  return done(EstimateApplied, "")
+}
+
+// peekRateToken reports whether the zone's bucket has a token, without
+// taking it. q's transaction must be rolled back.
+func (l *Ledger) peekRateToken(ctx context.Context, q Queries, zoneID string, ratePerSec, burst int) (bool, error) {
+  burst = effectiveBurst(ratePerSec, burst)
+  if ratePerSec <= 0 || burst <= 0 { return false, nil }
+  tokens, refilledAt, err := q.LockRateBucket(ctx, zoneID, float64(burst), l.clock.Now())
+  if err != nil { return false, err }
+  return refillTokens(tokens, l.clock.Now().Sub(refilledAt), ratePerSec, burst) >= 1, nil
+}
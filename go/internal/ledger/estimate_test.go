@@ -0,0 +1,87 @@
+package ledger_test
+
+import (
+	"context"
+	"testing"
+
+	"time-ledger-sim/go/internal/ledger"
+)
+
+func TestEstimateTransfer(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("applied, nothing recorded", func(t *testing.T) {
+		led, repo := newLedger(t, "zone-eu")
+		est, err := led.EstimateTransfer(ctx, transfer("req-1", 25))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if est.Outcome != ledger.EstimateApplied || est.Replay {
+			t.Fatalf("estimate = %+v", est)
+		}
+		if b := est.Balances; b[0].AfterUnits != -25 || b[1].AfterUnits != 25 {
+			t.Fatalf("balances = %+v", b)
+		}
+		if len(repo.Transactions()) != 0 || len(repo.Outbox()) != 0 {
+			t.Fatal("estimate recorded a transfer")
+		}
+
+		if _, _, err := led.CreateTransfer(ctx, transfer("req-1", 25)); err != nil {
+			t.Fatal(err)
+		}
+		est, err = led.EstimateTransfer(ctx, transfer("req-1", 25))
+		if err != nil || !est.Replay || est.TransactionID == nil || est.Balances[0].BalanceUnits != -25 {
+			t.Fatalf("estimate of a used request id = %+v, %v", est, err)
+		}
+		conflict := transfer("req-1", 26)
+		conflict.PayloadHash = "hash-changed"
+		if est, _ := led.EstimateTransfer(ctx, conflict); est.Outcome != ledger.EstimateRejected || !ledger.IsIdempotencyConflict(est.Err) {
+			t.Fatalf("estimate of a changed payload = %+v", est)
+		}
+	})
+
+	t.Run("spooled or rejected by zone gates", func(t *testing.T) {
+		led, repo := newLedger(t, "zone-eu")
+		repo.SetZoneControls(ledger.ZoneControls{ZoneID: "zone-eu", WritesBlocked: true, CrossZoneThrottle: 100, SpoolEnabled: true})
+		est, err := led.EstimateTransfer(ctx, transfer("req-1", 10))
+		if err != nil || est.Outcome != ledger.EstimateSpooled || est.Reason != "writes blocked" || est.Balances[1].AfterUnits != 10 {
+			t.Fatalf("estimate = %+v, %v", est, err)
+		}
+		if len(repo.Spool()) != 0 {
+			t.Fatal("estimate spooled the transfer")
+		}
+
+		repo.SetZoneStatus("zone-eu", "DOWN")
+		repo.SetZoneControls(ledger.ZoneControls{ZoneID: "zone-eu", CrossZoneThrottle: 100})
+		est, err = led.EstimateTransfer(ctx, transfer("req-1", 10))
+		if err != nil || est.Outcome != ledger.EstimateRejected || !ledger.IsZoneDown(est.Err) || est.Balances[1].AfterUnits != 0 {
+			t.Fatalf("estimate in a down zone = %+v, %v", est, err)
+		}
+	})
+
+	t.Run("rate limit token is not taken", func(t *testing.T) {
+		led, repo := newLedger(t, "zone-eu")
+		repo.SetZoneControls(ledger.ZoneControls{ZoneID: "zone-eu", CrossZoneThrottle: 100, ThrottleMode: ledger.ThrottleModeRate, RateLimitPerSec: 1, RateLimitBurst: 1})
+		for range 2 {
+			if est, err := led.EstimateTransfer(ctx, transfer("req-1", 10)); err != nil || est.Outcome != ledger.EstimateApplied {
+				t.Fatalf("estimate = %+v, %v", est, err)
+			}
+		}
+		if _, _, err := led.CreateTransfer(ctx, transfer("req-1", 10)); err != nil {
+			t.Fatal(err)
+		}
+		est, err := led.EstimateTransfer(ctx, transfer("req-2", 10))
+		if err != nil || est.Outcome != ledger.EstimateRejected || !ledger.IsRateLimited(est.Err) {
+			t.Fatalf("estimate with an empty bucket = %+v, %v", est, err)
+		}
+	})
+
+	t.Run("unknown zone is an error", func(t *testing.T) {
+		led, _ := newLedger(t, "zone-eu")
+		in := transfer("req-1", 10)
+		in.ZoneID = "zone-xx"
+		if _, err := led.EstimateTransfer(ctx, in); !ledger.IsZoneNotFound(err) {
+			t.Fatalf("err = %v", err)
+		}
+	})
+}
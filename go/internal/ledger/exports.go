@@ -0,0 +1,260 @@
+package ledger
+
+import (
+  "context"
+  "encoding/csv"
+  "encoding/json"
+  "fmt"
+  "io"
+  "strconv"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+)
+
+// JobKindExport writes a dataset to a downloadable artifact in one step.
+const JobKindExport = "EXPORT"
+
+// Export datasets.
+const (
+  ExportTransactions = "transactions"
+  ExportPostings = "postings"
+  ExportIncidents = "incidents"
+  ExportAudit = "audit"
+)
+
+// Export formats.
+const (
+  ExportCSV = "csv"
+  ExportNDJSON = "ndjson"
+  ExportParquet = "parquet" // typed and zstd-compressed; see parquetWriters
+)
+
+const (
+  DefaultExportTTL = 24 * time.Hour
+  maxExportTTL = 7 * 24 * time.Hour
+)
+
+// exportDatasets are the queries behind each dataset. $1 is a zone id or
+// "", $2 and $3 an inclusive time range whose ends may be NULL. The column
+// names are the CSV header and the NDJSON keys.
+var exportDatasets = map[string]string{
+  ExportTransactions: `
+    SELECT id::text AS id, request_id, from_account, to_account, amount_units, zone_id, from_zone_id, to_zone_id, zone_seq, metadata, created_at
+    FROM transactions
+    WHERE ($1='' OR from_zone_id=$1 OR to_zone_id=$1)
+      AND ($2::timestamptz IS NULL OR created_at >= $2) AND ($3::timestamptz IS NULL OR created_at <= $3)
+    ORDER BY created_at, id`,
+  ExportPostings: `
+    SELECT p.id::text AS id, p.txn_id::text AS txn_id, p.account_id, a.zone_id, p.direction, p.amount_units, p.created_at
+    FROM postings p JOIN accounts a ON a.id=p.account_id
+    WHERE ($1='' OR a.zone_id=$1)
+      AND ($2::timestamptz IS NULL OR p.created_at >= $2) AND ($3::timestamptz IS NULL OR p.created_at <= $3)
+    ORDER BY p.created_at, p.id`,
+  ExportIncidents: `
+    SELECT id::text AS id, zone_id, related_txn_id::text AS related_txn_id, severity, status, title, details, detected_at, updated_at
+    FROM incidents
+    WHERE ($1='' OR zone_id=$1)
+      AND ($2::timestamptz IS NULL OR detected_at >= $2) AND ($3::timestamptz IS NULL OR detected_at <= $3)
+    ORDER BY detected_at, id`,
+  ExportAudit: `
+    SELECT id::text AS id, actor, action, target_type, target_id, reason, details, created_at
+    FROM audit_log
+    WHERE ($1='' OR (target_type='zone' AND target_id=$1))
+      AND ($2::timestamptz IS NULL OR created_at >= $2) AND ($3::timestamptz IS NULL OR created_at <= $3)
+    ORDER BY created_at, id`,
+}
+
+var exportContentTypes = map[string]string{
+  ExportCSV: "text/csv",
+  ExportNDJSON: "application/x-ndjson",
+  ExportParquet: "application/vnd.apache.parquet",
+}
+
+// ExportParams are an EXPORT job's params.
+type ExportParams struct {
+  Dataset string `json:"dataset"`
+  Format string `json:"format"`
+  ZoneID string `json:"zone_id,omitempty"`
+  From *time.Time `json:"from,omitempty"`
+  To *time.Time `json:"to,omitempty"`
+  TTLSeconds int64 `json:"ttl_seconds"`
+}
+
+// ExportResult is a finished EXPORT job's result.
+type ExportResult struct {
+  Rows int64 `json:"rows"`
+  Artifact
+}
+
+type StartExportInput struct {
+  Dataset string
+  Format string // "" means csv
+  ZoneID string // "": every zone
+  From *time.Time
+  To *time.Time
+  TTL time.Duration // how long the file can be downloaded; 0 means 24h
+  Actor string
+  Reason string
+}
+
+// StartExport queues an export of a dataset. The finished job's artifact
+// is the file.
+func (l *Ledger) StartExport(ctx context.Context, in StartExportInput) (*Job, error) {
+  if in.Format == "" { in.Format = ExportCSV }
+  if in.TTL == 0 { in.TTL = DefaultExportTTL }
+  if _, ok := exportDatasets[in.Dataset]; !ok { return nil, fmt.Errorf("unknown dataset %q", in.Dataset) }
+  if _, ok := exportContentTypes[in.Format]; !ok { return nil, fmt.Errorf("unknown export format %q", in.Format) }
+  if _, ok := parquetWriters[in.Dataset]; in.Format == ExportParquet && !ok {
+    return nil, fmt.Errorf("parquet export covers transactions and postings, not %s", in.Dataset)
+  }
+  if in.TTL < time.Minute || in.TTL > maxExportTTL { return nil, fmt.Errorf("ttl must be between 1m and %s", maxExportTTL) }
+  if in.From != nil && in.To != nil && in.To.Before(*in.From) { return nil, fmt.Errorf("to must not be before from") }
+
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  if err := l.checkActors(ctx, tx, in.Actor); err != nil { return nil, err }
+  if in.ZoneID != "" {
+    if _, err := (pgQueries{tx}).ZoneStatus(ctx, in.ZoneID); err != nil { return nil, err }
+  }
+  p := ExportParams{Dataset: in.Dataset, Format: in.Format, ZoneID: in.ZoneID, From: in.From, To: in.To, TTLSeconds: int64(in.TTL / time.Second)}
+  j, err := l.enqueueJob(ctx, tx, jobSpec{Kind: JobKindExport, Target: in.ZoneID, Params: p, Actor: in.Actor, Reason: in.Reason})
+  if err != nil { return nil, err }
+
+  err = l.audit(ctx, pgQueries{tx}, AuditRecord{
+    Actor: in.Actor, Action: "START_EXPORT", TargetType: "job", TargetID: j.ID, Reason: in.Reason,
+    Details: map[string]any{"dataset": in.Dataset, "format": in.Format, "zone_id": in.ZoneID, "from": in.From, "to": in.To},
+  })
+  if err != nil { return nil, err }
+
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  return j, nil
+}
+
+// ListExports returns export jobs, newest first.
+func (l *Ledger) ListExports(ctx context.Context, limit int) ([]Job, error) {
+  return l.ListJobs(ctx, JobFilter{Kind: JobKindExport, Limit: limit})
+}
+
+// exportStep streams the dataset from the read replica into the job's
+// artifact. A step that is interrupted starts the file over when retried.
+func (l *Ledger) exportStep(ctx context.Context, j *Job) (JobStep, error) {
+  var p ExportParams
+  if err := json.Unmarshal(j.Params, &p); err != nil { return JobStep{}, err }
+  query, ok := exportDatasets[p.Dataset]
+  if !ok { return JobStep{}, fmt.Errorf("unknown dataset %q", p.Dataset) }
+
+  filename := fmt.Sprintf("%s-%s.%s", p.Dataset, j.ID[:8], p.Format)
+  aw, err := l.createArtifact(ctx, j.ID, filename, exportContentTypes[p.Format], time.Duration(p.TTLSeconds)*time.Second)
+  if err != nil { return JobStep{}, err }
+
+  rows, err := l.ro.Query(ctx, query, p.ZoneID, p.From, p.To)
+  if err != nil { return JobStep{}, err }
+  defer rows.Close()
+  var n int64
+  if p.Format == ExportParquet {
+    write, ok := parquetWriters[p.Dataset]
+    if !ok { return JobStep{}, fmt.Errorf("no parquet schema for %s", p.Dataset) }
+    if n, err = write(aw, rows, l.redaction.Load()); err != nil { return JobStep{}, err }
+  } else if n, err = writeExportRows(p.Format, aw, rows, l.redaction.Load()); err != nil {
+    return JobStep{}, err
+  }
+  art, err := aw.Close()
+  if err != nil { return JobStep{}, err }
+
+  return JobStep{Progress: map[string]int64{"rows": n}, Done: true, Result: ExportResult{Rows: n, Artifact: *art}}, nil
+}
+
+// writeExportRows writes rows as CSV or NDJSON, taking the column names
+// from the query. A metadata column is redacted by redact.
+func writeExportRows(format string, w io.Writer, rows pgx.Rows, redact *RedactionPolicy) (int64, error) {
+  cols := make([]string, len(rows.FieldDescriptions()))
+  for i, f := range rows.FieldDescriptions() { cols[i] = f.Name }
+  enc, err := newExportEncoder(format, w, cols)
+  if err != nil { return 0, err }
+  var n int64
+  for rows.Next() {
+    vals, err := rows.Values()
+    if err != nil { return n, err }
+    for i, c := range cols {
+      if c == "metadata" { vals[i] = redact.RedactValue(vals[i]) }
+    }
+    if err := enc.row(vals); err != nil { return n, err }
+    n++
+  }
+  if err := rows.Err(); err != nil { return n, err }
+  return n, enc.close()
+}
+
+type exportEncoder interface {
+  row(vals []any) error
+  close() error
+}
+
+func newExportEncoder(format string, w io.Writer, cols []string) (exportEncoder, error) {
+  switch format {
+  case ExportCSV:
+    cw := csv.NewWriter(w)
+    if err := cw.Write(cols); err != nil { return nil, err }
+    return &csvExport{w: cw, rec: make([]string, len(cols))}, nil
+  case ExportNDJSON:
+    return &ndjsonExport{enc: json.NewEncoder(w), cols: cols}, nil
+  }
+  return nil, fmt.Errorf("unknown export format %q", format)
+}
+
+type csvExport struct {
+  w *csv.Writer
+  rec []string
+}
+
+func (e *csvExport) row(vals []any) error {
+  for i, v := range vals {
+    s, err := csvCell(v)
+    if err != nil { return err }
+    e.rec[i] = s
+  }
+  return e.w.Write(e.rec)
+}
+
+func (e *csvExport) close() error {
+  e.w.Flush()
+  return e.w.Error()
+}
+
+// csvCell formats a column value: times as RFC 3339 UTC, JSON columns as
+// JSON, NULL as empty.
+func csvCell(v any) (string, error) {
+  switch v := v.(type) {
+  case nil:
+    return "", nil
+  case string:
+    return v, nil
+  case int64:
+    return strconv.FormatInt(v, 10), nil
+  case int32:
+    return strconv.FormatInt(int64(v), 10), nil
+  case bool:
+    return strconv.FormatBool(v), nil
+  case time.Time:
+    return v.UTC().Format(time.RFC3339Nano), nil
+  default:
+    b, err := json.Marshal(v)
+    return string(b), err
+  }
+}
+
+type ndjsonExport struct {
+  enc *json.Encoder
+  cols []string
+}
+
+func (e *ndjsonExport) row(vals []any) error {
+  m := make(map[string]any, len(vals))
+  for i, v := range vals { m[e.cols[i]] = v }
+  return e.enc.Encode(m)
+}
+
+func (e *ndjsonExport) close() error { return nil }
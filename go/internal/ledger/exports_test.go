@@ -0,0 +1,134 @@
+package ledger
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/parquet-go/parquet-go"
+
+	"time-ledger-sim/go/internal/store/storetest"
+)
+
+func TestCSVCell(t *testing.T) {
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.FixedZone("x", 3600))
+	for _, tc := range []struct {
+		in   any
+		want string
+	}{
+		{nil, ""},
+		{"a,b", "a,b"},
+		{int64(-5), "-5"},
+		{true, "true"},
+		{at, "2026-03-01T11:00:00Z"},
+		{map[string]any{"k": "v"}, `{"k":"v"}`},
+	} {
+		if got, err := csvCell(tc.in); err != nil || got != tc.want {
+			t.Errorf("csvCell(%v) = %q, %v; want %q", tc.in, got, err, tc.want)
+		}
+	}
+}
+
+func TestExportJob(t *testing.T) {
+	db := storetest.Open(t)
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	l := New(db, log)
+	runner := NewJobRunner(l, log, 1)
+
+	zone := "zone-ex-" + uuid.NewString()[:8]
+	if _, err := l.CreateZone(ctx, CreateZoneInput{ID: zone, Name: zone, Actor: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	for i := range 3 {
+		_, _, err := l.CreateTransfer(ctx, CreateTransferInput{
+			RequestID: "ex-" + uuid.NewString(), PayloadHash: "h", FromAccount: zone + "-a", ToAccount: zone + "-b", AmountUnits: int64(i + 1), ZoneID: zone,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := l.StartExport(ctx, StartExportInput{Dataset: "balances", Actor: "test"}); err == nil {
+		t.Fatal("unknown dataset accepted")
+	}
+	csvJob, err := l.StartExport(ctx, StartExportInput{Dataset: ExportTransactions, ZoneID: zone, Actor: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.GetArtifact(ctx, csvJob.ID); !IsArtifactNotReady(err) {
+		t.Fatalf("artifact before the run err = %v", err)
+	}
+	ndJob, err := l.StartExport(ctx, StartExportInput{Dataset: ExportPostings, Format: ExportNDJSON, ZoneID: zone, TTL: time.Hour, Actor: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pqJob, err := l.StartExport(ctx, StartExportInput{Dataset: ExportTransactions, Format: ExportParquet, ZoneID: zone, Actor: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.StartExport(ctx, StartExportInput{Dataset: ExportAudit, Format: ExportParquet, Actor: "test"}); err == nil {
+		t.Fatal("parquet audit export accepted")
+	}
+	if _, err := runner.RunDue(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	j, err := l.GetJob(ctx, csvJob.ID)
+	if err != nil || j.Status != JobCompleted {
+		t.Fatalf("csv job = %+v, %v", j, err)
+	}
+	var res ExportResult
+	if err := json.Unmarshal(j.Result, &res); err != nil || res.Rows != 3 || res.ContentType != "text/csv" {
+		t.Fatalf("result = %s, %v", j.Result, err)
+	}
+	art, err := l.GetArtifact(ctx, csvJob.ID)
+	if err != nil || art.Bytes != res.Bytes {
+		t.Fatalf("artifact = %+v, %v", art, err)
+	}
+	var buf bytes.Buffer
+	if err := l.WriteArtifact(ctx, &buf, csvJob.ID); err != nil {
+		t.Fatal(err)
+	}
+	recs, err := csv.NewReader(&buf).ReadAll()
+	if err != nil || len(recs) != 4 || recs[0][0] != "id" || recs[0][4] != "amount_units" {
+		t.Fatalf("csv = %v, %v", recs, err)
+	}
+
+	buf.Reset()
+	if err := l.WriteArtifact(ctx, &buf, ndJob.ID); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	var first map[string]any
+	if len(lines) != 6 || json.Unmarshal([]byte(lines[0]), &first) != nil || first["zone_id"] != zone {
+		t.Fatalf("ndjson = %q", buf.String())
+	}
+
+	buf.Reset()
+	if err := l.WriteArtifact(ctx, &buf, pqJob.ID); err != nil {
+		t.Fatal(err)
+	}
+	txns, err := parquet.Read[transactionRow](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil || len(txns) != 3 || txns[0].ZoneID != zone || txns[0].CreatedAt.IsZero() || txns[0].Metadata == "" {
+		t.Fatalf("parquet rows = %+v, %v", txns, err)
+	}
+
+	// expired: the contents go, the artifact answers expired
+	if _, err := db.Exec(ctx, `UPDATE job_artifacts SET expires_at=created_at WHERE job_id=$1::uuid`, ndJob.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.PruneJobs(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.GetArtifact(ctx, ndJob.ID); !IsArtifactExpired(err) {
+		t.Fatalf("expired artifact err = %v", err)
+	}
+}
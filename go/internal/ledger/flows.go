@@ -0,0 +1,83 @@
+package ledger
+
+import (
+  "context"
+  "slices"
+  "time"
+)
+
+// MaxFlowWindow bounds GET /v1/flows; flows are read from the database, so
+// unlike zone stats they are not limited to the in-process window.
+const MaxFlowWindow = 24 * time.Hour
+
+// FlowMatrix is the value moved between zones over a window. Rows are the
+// paying zone and columns the receiving one, both in Zones order; the
+// diagonal is value moved inside a zone.
+type FlowMatrix struct {
+  From time.Time `json:"from"`
+  To time.Time `json:"to"`
+  WindowSeconds int `json:"window_seconds"`
+  Zones []string `json:"zones"`
+  AmountUnits [][]int64 `json:"amount_units"` // [from][to]
+  Transfers [][]int64 `json:"transfers"` // [from][to]
+  TotalUnits int64 `json:"total_units"`
+  CrossZoneUnits int64 `json:"cross_zone_units"` // off the diagonal
+}
+
+// GetFlowMatrix sums transactions over the last window on the sim clock by
+// the zones of their two accounts, on the read replica. Zones lists the active
+// zones, plus retired ones that still had flows in the window.
+func (l *Ledger) GetFlowMatrix(ctx context.Context, window time.Duration) (*FlowMatrix, error) {
+  to := l.clock.Now()
+  m := FlowMatrix{From: to.Add(-window), To: to, WindowSeconds: int(window.Seconds()), Zones: []string{}}
+
+  type flow struct{ from, to string; units, n int64 }
+  var flows []flow
+  rows, err := l.ro.Query(ctx, `
+    SELECT from_zone_id, to_zone_id, SUM(amount_units)::bigint, COUNT(*)
+    FROM transactions
+    WHERE created_at >= $1 AND created_at < $2
+    GROUP BY 1, 2
+  `, m.From, m.To)
+  if err != nil { return nil, err }
+  for rows.Next() {
+    var f flow
+    if err := rows.Scan(&f.from, &f.to, &f.units, &f.n); err != nil { rows.Close(); return nil, err }
+    flows = append(flows, f)
+  }
+  rows.Close()
+  if err := rows.Err(); err != nil { return nil, err }
+
+  rows, err = l.ro.Query(ctx, `SELECT id FROM zones WHERE retired_at IS NULL ORDER BY id`)
+  if err != nil { return nil, err }
+  for rows.Next() {
+    var id string
+    if err := rows.Scan(&id); err != nil { rows.Close(); return nil, err }
+    m.Zones = append(m.Zones, id)
+  }
+  rows.Close()
+  if err := rows.Err(); err != nil { return nil, err }
+
+  for _, f := range flows {
+    for _, z := range []string{f.from, f.to} {
+      if !slices.Contains(m.Zones, z) { m.Zones = append(m.Zones, z) }
+    }
+  }
+  slices.Sort(m.Zones)
+
+  m.AmountUnits, m.Transfers = squareMatrix(len(m.Zones)), squareMatrix(len(m.Zones))
+  for _, f := range flows {
+    i, j := slices.Index(m.Zones, f.from), slices.Index(m.Zones, f.to)
+    m.AmountUnits[i][j] += f.units
+    m.Transfers[i][j] += f.n
+    m.TotalUnits += f.units
+    if i != j { m.CrossZoneUnits += f.units }
+  }
+  return &m, nil
+}
+
+func squareMatrix(n int) [][]int64 {
+  out := make([][]int64, n)
+  for i := range out { out[i] = make([]int64, n) }
+  return out
+}
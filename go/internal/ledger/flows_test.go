@@ -0,0 +1,107 @@
+package ledger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"time-ledger-sim/go/internal/store/storetest"
+)
+
+func TestGetFlowMatrix(t *testing.T) {
+	db := storetest.Open(t)
+	ctx := context.Background()
+	l := New(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	before, err := l.GetFlowMatrix(ctx, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	suffix := uuid.NewString()[:8]
+	eu, na := "flow-eu-"+suffix, "flow-na-"+suffix
+	transfer := func(zone, from, to string, units int64) *Transaction {
+		t.Helper()
+		txn, _, err := l.CreateTransfer(ctx, CreateTransferInput{
+			RequestID: "flow-" + uuid.NewString(), PayloadHash: "h", FromAccount: from, ToAccount: to, AmountUnits: units, ZoneID: zone,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return txn
+	}
+	transfer("zone-na", na, "flow-na-sink-"+suffix, 1) // creates na in zone-na
+	txn := transfer("zone-eu", eu, na, 40)
+
+	d, err := l.GetTransaction(ctx, txn.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.FromZoneID != "zone-eu" || d.ToZoneID != "zone-na" {
+		t.Fatalf("zones = %s -> %s", d.FromZoneID, d.ToZoneID)
+	}
+
+	after, err := l.GetFlowMatrix(ctx, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cell := func(m *FlowMatrix, from, to string) int64 {
+		i, j := slices.Index(m.Zones, from), slices.Index(m.Zones, to)
+		if i < 0 || j < 0 {
+			t.Fatalf("zones %v missing %s or %s", m.Zones, from, to)
+		}
+		return m.AmountUnits[i][j]
+	}
+	if got := cell(after, "zone-eu", "zone-na") - cell(before, "zone-eu", "zone-na"); got != 40 {
+		t.Fatalf("eu -> na moved %d, want 40", got)
+	}
+	if after.CrossZoneUnits-before.CrossZoneUnits != 40 {
+		t.Fatalf("cross-zone = %d, was %d", after.CrossZoneUnits, before.CrossZoneUnits)
+	}
+}
+
+func TestListZoneTransactions(t *testing.T) {
+	db := storetest.Open(t)
+	ctx := context.Background()
+	l := New(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	zone := "zone-seq-" + uuid.NewString()[:8]
+	if _, err := l.CreateZone(ctx, CreateZoneInput{ID: zone, Name: zone, Actor: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for i := 0; i < 3; i++ {
+		txn, _, err := l.CreateTransfer(ctx, CreateTransferInput{
+			RequestID: "seq-" + uuid.NewString(), PayloadHash: "h", FromAccount: zone + "-a", ToAccount: zone + "-b", AmountUnits: 1, ZoneID: zone,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if txn.ZoneSeq != int64(i+1) {
+			t.Fatalf("transfer %d zone_seq = %d", i, txn.ZoneSeq)
+		}
+		ids = append(ids, txn.ID)
+	}
+
+	page, err := l.ListZoneTransactions(ctx, zone, 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Transactions) != 2 || page.Transactions[0].ID != ids[0] || page.LastSeq != 2 || page.HeadSeq != 3 {
+		t.Fatalf("first page = %+v", page)
+	}
+	page, err = l.ListZoneTransactions(ctx, zone, page.LastSeq, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Transactions) != 1 || page.Transactions[0].ID != ids[2] || page.LastSeq != 3 {
+		t.Fatalf("second page = %+v", page)
+	}
+	if _, err := l.ListZoneTransactions(ctx, "zone-missing-"+uuid.NewString()[:8], 0, 10); !IsZoneNotFound(err) {
+		t.Fatalf("err = %v, want zone not found", err)
+	}
+}
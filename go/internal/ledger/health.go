@@ -0,0 +1,122 @@
+package ledger
+
+import (
+  "context"
+  "errors"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+
+  "time-ledger-sim/go/internal/metrics"
+)
+
+const healthThroughputWindow = 5 * time.Minute
+
+type ZoneHealth struct {
+  Zone Zone `json:"zone"`
+  Controls ZoneControls `json:"controls"`
+  SpoolPending int64 `json:"spool_pending"`
+  OpenIncidents map[string]int64 `json:"open_incidents"` // by severity
+  Throughput ZoneThroughput `json:"throughput"`
+  LastAudit *AuditSummary `json:"last_audit"`
+}
+
+type ZoneThroughput struct {
+  WindowSeconds int `json:"window_seconds"`
+  Transfers int64 `json:"transfers"`
+  AmountUnits int64 `json:"amount_units"`
+}
+
+type AuditSummary struct {
+  Actor string `json:"actor"`
+  Action string `json:"action"`
+  CreatedAt time.Time `json:"created_at"`
+}
+
+// GetZoneHealth aggregates everything the ops dashboard shows for one zone in a single statement.
+func (l *Ledger) GetZoneHealth(ctx context.Context, zoneID string) (*ZoneHealth, error) {
+  h := ZoneHealth{
+    OpenIncidents: map[string]int64{},
+    Throughput: ZoneThroughput{WindowSeconds: int(healthThroughputWindow.Seconds())},
+  }
+  c := &h.Controls
+  var info, warn, crit int64
+  var auditActor, auditAction *string
+  var auditAt *time.Time
+
+  err := l.db.QueryRow(ctx, `
+    SELECT z.id, z.name, z.status, z.updated_at,
+      c.zone_id, c.writes_blocked, c.cross_zone_throttle, c.spool_enabled, c.inject_latency_ms, c.inject_jitter_ms,
+      c.error_rate_percent, c.throttle_mode, c.rate_limit_per_sec, c.rate_limit_burst, c.clock_skew_ms,
+      c.blocked_actions, c.blocked_delay_ms, c.min_amount_units, c.max_amount_units, c.daily_account_limit_units, c.day_cutover_minute, c.updated_at,
+      (SELECT COUNT(*) FROM spooled_transfers s WHERE s.zone_id=z.id AND s.status='PENDING'),
+      i.info, i.warn, i.crit,
+      t.cnt, t.amt,
+      a.actor, a.action, a.created_at
+    FROM zones z
+    JOIN zone_controls c ON c.zone_id=z.id
+    CROSS JOIN LATERAL (
+      SELECT COUNT(*) FILTER (WHERE severity='INFO') AS info,
+             COUNT(*) FILTER (WHERE severity='WARN') AS warn,
+             COUNT(*) FILTER (WHERE severity='CRITICAL') AS crit
+      FROM incidents WHERE zone_id=z.id AND status<>'RESOLVED'
+    ) i
+    CROSS JOIN LATERAL (
+      SELECT COUNT(*) AS cnt, COALESCE(SUM(amount_units),0)::bigint AS amt
+      FROM transactions WHERE zone_id=z.id AND created_at >= $2
+    ) t
+    LEFT JOIN LATERAL (
+      SELECT actor, action, created_at FROM audit_log
+      WHERE target_type='zone' AND target_id=z.id AND NOT `+drillHidden+`
+      ORDER BY created_at DESC LIMIT 1
+    ) a ON true
+    WHERE z.id=$1 AND z.retired_at IS NULL
+  `, zoneID, l.clock.Now().Add(-healthThroughputWindow)).Scan(
+    &h.Zone.ID, &h.Zone.Name, &h.Zone.Status, &h.Zone.UpdatedAt,
+    &c.ZoneID, &c.WritesBlocked, &c.CrossZoneThrottle, &c.SpoolEnabled, &c.InjectLatencyMs, &c.InjectJitterMs,
+    &c.ErrorRatePercent, &c.ThrottleMode, &c.RateLimitPerSec, &c.RateLimitBurst, &c.ClockSkewMs,
+    &c.BlockedActions, &c.BlockedDelayMs, &c.MinAmountUnits, &c.MaxAmountUnits, &c.DailyAccountLimitUnits, &c.DayCutoverMinute, &c.UpdatedAt,
+    &h.SpoolPending,
+    &info, &warn, &crit,
+    &h.Throughput.Transfers, &h.Throughput.AmountUnits,
+    &auditActor, &auditAction, &auditAt,
+  )
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrZoneNotFound }
+  if err != nil { return nil, err }
+
+  h.OpenIncidents["INFO"] = info
+  h.OpenIncidents["WARN"] = warn
+  h.OpenIncidents["CRITICAL"] = crit
+  if auditAction != nil {
+    h.LastAudit = &AuditSummary{Actor: *auditActor, Action: *auditAction, CreatedAt: *auditAt}
+  }
+  return &h, nil
+}
+
+// ZoneGauges reads spool depth and open incidents for every active zone, for
+// the metrics.ZoneCollector.
+func (l *Ledger) ZoneGauges(ctx context.Context) ([]metrics.ZoneGauge, error) {
+  rows, err := l.db.Query(ctx, `
+    SELECT z.id,
+      (SELECT COUNT(*) FROM spooled_transfers s WHERE s.zone_id=z.id AND s.status='PENDING'),
+      COUNT(*) FILTER (WHERE i.severity='INFO'),
+      COUNT(*) FILTER (WHERE i.severity='WARN'),
+      COUNT(*) FILTER (WHERE i.severity='CRITICAL')
+    FROM zones z
+    LEFT JOIN incidents i ON i.zone_id=z.id AND i.status<>'RESOLVED'
+    WHERE z.retired_at IS NULL
+    GROUP BY z.id
+    ORDER BY z.id
+  `)
+  if err != nil { return nil, err }
+  defer rows.Close()
+  out := []metrics.ZoneGauge{}
+  for rows.Next() {
+    var g metrics.ZoneGauge
+    var info, warn, crit int64
+    if err := rows.Scan(&g.Zone, &g.SpoolDepth, &info, &warn, &crit); err != nil { return nil, err }
+    g.OpenIncidents = map[string]int64{"INFO": info, "WARN": warn, "CRITICAL": crit}
+    out = append(out, g)
+  }
+  return out, rows.Err()
+}
@@ -0,0 +1,105 @@
+package ledger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"time-ledger-sim/go/internal/store/storetest"
+)
+
+func TestGetZoneHealth(t *testing.T) {
+	db := storetest.Open(t)
+	ctx := context.Background()
+	l := New(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	clock := NewVirtualClock()
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	l.SetClock(clock)
+
+	prefix := "zone-health-" + uuid.NewString()[:8]
+	zone, retired := prefix+"-a", prefix+"-b"
+	for _, id := range []string{zone, retired} {
+		if _, err := l.CreateZone(ctx, CreateZoneInput{ID: id, Name: id, Actor: "ops"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	transfer := func(amount int64) {
+		t.Helper()
+		req := uuid.NewString()
+		in := CreateTransferInput{RequestID: req, PayloadHash: "h-" + req, FromAccount: zone + "-x", ToAccount: zone + "-y", AmountUnits: amount, ZoneID: zone, Metadata: map[string]any{}}
+		if _, _, err := l.CreateTransfer(ctx, in); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// one transfer before the throughput window, two inside it
+	clock.Freeze(now.Add(-2 * healthThroughputWindow))
+	transfer(7)
+	clock.Freeze(now)
+	transfer(5)
+	transfer(3)
+
+	// a down zone with spooling on: an open CRITICAL incident and a pending spool entry
+	if _, err := l.SetZoneControls(ctx, zone, SetZoneControlsInput{CrossZoneThrottle: 100, SpoolEnabled: true, Actor: "ops"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.SetZoneStatus(ctx, zone, "DOWN", "ops", "", "outage"); err != nil {
+		t.Fatal(err)
+	}
+	transfer(11)
+
+	h, err := l.GetZoneHealth(ctx, zone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Zone.ID != zone || h.Zone.Status != "DOWN" || !h.Controls.SpoolEnabled {
+		t.Fatalf("zone = %+v, controls = %+v", h.Zone, h.Controls)
+	}
+	if h.SpoolPending != 1 {
+		t.Fatalf("spool pending = %d, want 1", h.SpoolPending)
+	}
+	if h.OpenIncidents["CRITICAL"] != 1 || h.OpenIncidents["WARN"] != 0 || h.OpenIncidents["INFO"] != 0 {
+		t.Fatalf("open incidents = %v", h.OpenIncidents)
+	}
+	if h.Throughput.WindowSeconds != 300 || h.Throughput.Transfers != 2 || h.Throughput.AmountUnits != 8 {
+		t.Fatalf("throughput = %+v", h.Throughput)
+	}
+	if h.LastAudit == nil || h.LastAudit.Action != "SPOOL_TRANSFER" || h.LastAudit.Actor != "system" {
+		t.Fatalf("last audit = %+v", h.LastAudit)
+	}
+
+	// resolved incidents are not counted; the zone change is the last zone audit
+	if _, err := l.SetZoneStatus(ctx, zone, "OK", "ops", "", "recovered"); err != nil {
+		t.Fatal(err)
+	}
+	incidents, err := l.ListIncidentsByZone(ctx, zone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, inc := range incidents {
+		if _, err := l.ApplyIncidentAction(ctx, inc.ID, IncidentAction{Action: "RESOLVE", Actor: "ops"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h, err = l.GetZoneHealth(ctx, zone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.OpenIncidents["CRITICAL"] != 0 || h.LastAudit == nil || h.LastAudit.Action != "SET_ZONE_STATUS" || h.LastAudit.Actor != "ops" {
+		t.Fatalf("after recovery: incidents %v, last audit %+v", h.OpenIncidents, h.LastAudit)
+	}
+
+	if _, err := l.GetZoneHealth(ctx, prefix+"-nowhere"); !IsZoneNotFound(err) {
+		t.Fatalf("unknown zone: err = %v", err)
+	}
+	if err := l.RetireZone(ctx, retired, RetireZoneInput{Actor: "ops"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.GetZoneHealth(ctx, retired); !IsZoneNotFound(err) {
+		t.Fatalf("retired zone: err = %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package ledger
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "math/rand/v2"
+  "time"
+
+  "github.com/jackc/pgx/v5/pgconn"
+
+  "time-ledger-sim/go/internal/metrics"
+)
+
+// Isolation is the transaction isolation level transfers are applied at.
+type Isolation string
+
+const (
+  IsolationReadCommitted Isolation = "read_committed"
+  IsolationRepeatableRead Isolation = "repeatable_read"
+  IsolationSerializable Isolation = "serializable"
+)
+
+// ParseIsolation accepts the config spelling of an isolation level.
+func ParseIsolation(s string) (Isolation, error) {
+  switch iso := Isolation(s); iso {
+  case IsolationReadCommitted, IsolationRepeatableRead, IsolationSerializable:
+    return iso, nil
+  }
+  return "", fmt.Errorf("want read_committed, repeatable_read or serializable, got %q", s)
+}
+
+// DefaultTxRetries is how often a transfer transaction is retried after a
+// serialization failure or deadlock before the error is returned.
+const DefaultTxRetries = 5
+
+// txPolicy is the isolation and retry budget of transfer transactions.
+type txPolicy struct {
+  iso Isolation
+  retries int
+}
+
+// SetTransferIsolation sets the isolation level transfers and spool replays
+// run at and how many times one is retried when Postgres aborts it with a
+// serialization failure or deadlock. Read committed (the default) relies on
+// row locks and ON CONFLICT and rarely retries; serializable also rules out
+// anomalies between concurrent transfers, at the cost of retries under
+// contention (timeledger_tx_retries_total, timeledger_tx_attempts).
+func (l *Ledger) SetTransferIsolation(iso Isolation, retries int) {
+  if retries < 0 { retries = 0 }
+  l.tx.Store(&txPolicy{iso: iso, retries: retries})
+}
+
+// TransferIsolation returns the level set by SetTransferIsolation.
+func (l *Ledger) TransferIsolation() Isolation { return l.tx.Load().iso }
+
+// inTransferTx runs fn in a transaction at the configured isolation level,
+// running it again from the start while Postgres reports a serialization
+// failure or deadlock and retries remain. fn must not have effects outside
+// the transaction that a rerun would duplicate.
+func (l *Ledger) inTransferTx(ctx context.Context, fn func(q Queries) error) error {
+  p := l.tx.Load()
+  for attempt := 1; ; attempt++ {
+    err := l.repo.InTxIso(ctx, p.iso, fn)
+    if !IsSerializationFailure(err) || attempt > p.retries {
+      metrics.TxAttempts.WithLabelValues(string(p.iso)).Observe(float64(attempt))
+      if err != nil && IsSerializationFailure(err) {
+        metrics.TxRetries.WithLabelValues(string(p.iso), "exhausted").Inc()
+      }
+      return err
+    }
+    metrics.TxRetries.WithLabelValues(string(p.iso), "retried").Inc()
+    if l.log != nil { l.log.DebugContext(ctx, "retrying transfer transaction", "isolation", p.iso, "attempt", attempt, "err", err.Error()) }
+    if err := sleepCtx(ctx, retryBackoff(attempt)); err != nil { return err }
+  }
+}
+
+// retryBackoff is a short jittered wait so the transactions that collided do
+// not collide again: up to 2ms, 4ms, 8ms ... capped at 100ms.
+func retryBackoff(attempt int) time.Duration {
+  ceil := min(time.Millisecond<<attempt, 100*time.Millisecond)
+  return time.Duration(rand.Int64N(int64(ceil)) + 1)
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+  t := time.NewTimer(d)
+  defer t.Stop()
+  select {
+  case <-ctx.Done():
+    return ctx.Err()
+  case <-t.C:
+    return nil
+  }
+}
+
+// IsSerializationFailure reports whether err is a Postgres serialization
+// failure (40001) or deadlock (40P01), which succeed when retried.
+func IsSerializationFailure(err error) bool {
+  var pe *pgconn.PgError
+  return errors.As(err, &pe) && (pe.Code == "40001" || pe.Code == "40P01")
+}
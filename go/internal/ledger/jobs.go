@@ -0,0 +1,417 @@
+package ledger
+
+import (
+  "context"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "log/slog"
+  "slices"
+  "sync"
+  "sync/atomic"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+  "github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+  ErrJobNotFound = errors.New("job not found")
+  ErrJobRunning = errors.New("an equivalent job is already queued or running")
+)
+
+func IsJobNotFound(err error) bool { return errors.Is(err, ErrJobNotFound) }
+func IsJobRunning(err error) bool { return errors.Is(err, ErrJobRunning) }
+
+const (
+  JobQueued = "QUEUED"
+  JobRunning = "RUNNING"
+  JobCompleted = "COMPLETED"
+  JobCancelled = "CANCELLED"
+  JobFailed = "FAILED"
+)
+
+// DefaultJobRetention is how long finished jobs are kept on the sim clock.
+const DefaultJobRetention = 7 * 24 * time.Hour
+
+// Job is a unit of background work run by a JobRunner in steps: a replay
+// job replays one batch per step, an export writes its file in one. Params
+// are fixed at enqueue; Progress is whatever the kind's handler recorded
+// after its last step and Result what it returned when done.
+type Job struct {
+  ID string `json:"id"`
+  Kind string `json:"kind"`
+  Status string `json:"status"` // QUEUED, RUNNING, COMPLETED, CANCELLED or FAILED
+  Target *string `json:"target"` // what the job works on, e.g. a zone id
+  Params json.RawMessage `json:"params"`
+  Progress json.RawMessage `json:"progress"`
+  Result json.RawMessage `json:"result"`
+  Error *string `json:"error"`
+  Steps int `json:"steps"`
+  Actor string `json:"actor"`
+  Reason *string `json:"reason"`
+  CreatedAt time.Time `json:"created_at"`
+  StartedAt *time.Time `json:"started_at"`
+  UpdatedAt time.Time `json:"updated_at"`
+  FinishedAt *time.Time `json:"finished_at"`
+}
+
+// Finished reports whether the job has reached a final status.
+func (j *Job) Finished() bool { return j.Status != JobQueued && j.Status != JobRunning }
+
+const jobCols = `id::text, kind, status, target, params, progress, result, error, steps, actor, reason, created_at, started_at, updated_at, finished_at`
+
+func scanJob(row pgx.Row) (*Job, error) {
+  var j Job
+  var params, progress, result []byte
+  if err := row.Scan(&j.ID, &j.Kind, &j.Status, &j.Target, &params, &progress, &result, &j.Error, &j.Steps,
+    &j.Actor, &j.Reason, &j.CreatedAt, &j.StartedAt, &j.UpdatedAt, &j.FinishedAt); err != nil {
+    return nil, err
+  }
+  j.Params, j.Progress, j.Result = params, progress, result
+  return &j, nil
+}
+
+// jobSpec is a job to enqueue. A job with a DedupeKey fails with
+// ErrJobRunning while another job with that key is queued or running.
+type jobSpec struct {
+  Kind string
+  Target string
+  DedupeKey string
+  Params any
+  Progress any
+  Actor string
+  Reason string
+}
+
+// enqueueJob queues a job in the caller's transaction, so it only exists if
+// the caller's audit does too.
+func (l *Ledger) enqueueJob(ctx context.Context, tx pgx.Tx, s jobSpec) (*Job, error) {
+  params, err := json.Marshal(s.Params)
+  if err != nil { return nil, err }
+  var progress []byte
+  if s.Progress != nil {
+    if progress, err = json.Marshal(s.Progress); err != nil { return nil, err }
+  }
+  now := l.clock.Now()
+  j, err := scanJob(tx.QueryRow(ctx, `
+    INSERT INTO jobs(kind,target,dedupe_key,params,progress,actor,reason,created_at,updated_at)
+    VALUES($1,NULLIF($2,''),NULLIF($3,''),$4::jsonb,$5::jsonb,$6,NULLIF($7,''),$8,$8)
+    RETURNING `+jobCols,
+    s.Kind, s.Target, s.DedupeKey, params, progress, s.Actor, s.Reason, now))
+  var pgErr *pgconn.PgError
+  if errors.As(err, &pgErr) && pgErr.Code == "23505" { return nil, ErrJobRunning }
+  return j, err
+}
+
+type EnqueueJobInput struct {
+  Kind string
+  Target string
+  DedupeKey string // "": no limit on concurrent jobs
+  Params any
+  Actor string
+  Reason string
+}
+
+// EnqueueJob queues a job of a kind registered outside the ledger (see
+// JobRunner.Register) and audits it as START_JOB.
+func (l *Ledger) EnqueueJob(ctx context.Context, in EnqueueJobInput) (*Job, error) {
+  if in.Kind == "" { return nil, fmt.Errorf("job kind is required") }
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  if err := l.checkActors(ctx, tx, in.Actor); err != nil { return nil, err }
+  j, err := l.enqueueJob(ctx, tx, jobSpec{Kind: in.Kind, Target: in.Target, DedupeKey: in.DedupeKey, Params: in.Params, Actor: in.Actor, Reason: in.Reason})
+  if err != nil { return nil, err }
+
+  err = l.audit(ctx, pgQueries{tx}, AuditRecord{
+    Actor: in.Actor, Action: "START_JOB", TargetType: "job", TargetID: j.ID, Reason: in.Reason,
+    Details: map[string]any{"kind": j.Kind, "target": in.Target, "params": j.Params},
+  })
+  if err != nil { return nil, err }
+
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  return j, nil
+}
+
+func (l *Ledger) GetJob(ctx context.Context, id string) (*Job, error) {
+  j, err := scanJob(l.db.QueryRow(ctx, `SELECT `+jobCols+` FROM jobs WHERE id::text=$1`, id))
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrJobNotFound }
+  return j, err
+}
+
+// JobFilter narrows ListJobs; empty fields match everything.
+type JobFilter struct {
+  Kind string
+  Target string
+  Status string
+  Limit int
+}
+
+// ListJobs returns jobs newest first.
+func (l *Ledger) ListJobs(ctx context.Context, f JobFilter) ([]Job, error) {
+  if f.Limit <= 0 || f.Limit > 500 { f.Limit = 50 }
+  rows, err := l.db.Query(ctx, `
+    SELECT `+jobCols+` FROM jobs
+    WHERE ($1='' OR kind=$1) AND ($2='' OR target=$2) AND ($3='' OR status=$3)
+    ORDER BY created_at DESC, id
+    LIMIT $4
+  `, f.Kind, f.Target, f.Status, f.Limit)
+  if err != nil { return nil, err }
+  defer rows.Close()
+
+  out := []Job{}
+  for rows.Next() {
+    j, err := scanJob(rows)
+    if err != nil { return nil, err }
+    out = append(out, *j)
+  }
+  return out, rows.Err()
+}
+
+// CancelJob stops a queued or running job. A step already under way is
+// interrupted; whatever it finished is still recorded in the progress.
+func (l *Ledger) CancelJob(ctx context.Context, id, actor, reason string) (*Job, error) {
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  if err := l.checkActors(ctx, tx, actor); err != nil { return nil, err }
+
+  now := l.clock.Now()
+  j, err := scanJob(tx.QueryRow(ctx, `
+    UPDATE jobs SET status='CANCELLED', updated_at=$2, finished_at=$2
+    WHERE id::text=$1 AND status IN ('QUEUED','RUNNING')
+    RETURNING `+jobCols, id, now))
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrJobNotFound }
+  if err != nil { return nil, err }
+
+  err = l.audit(ctx, pgQueries{tx}, AuditRecord{
+    Actor: actor, Action: "CANCEL_JOB", TargetType: "job", TargetID: j.ID, Reason: reason,
+    Details: map[string]any{"kind": j.Kind, "target": j.Target, "progress": j.Progress},
+  })
+  if err != nil { return nil, err }
+
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  return j, nil
+}
+
+// PruneJobs deletes jobs that finished more than keep ago on the sim clock
+// (keep <= 0 keeps them) and the contents of expired artifacts.
+func (l *Ledger) PruneJobs(ctx context.Context, keep time.Duration) (int64, error) {
+  now := l.clock.Now()
+  _, err := l.db.Exec(ctx, `
+    DELETE FROM job_artifact_chunks c USING job_artifacts a
+    WHERE a.job_id=c.job_id AND a.expires_at <= $1
+  `, now)
+  if err != nil || keep <= 0 { return 0, err }
+  tag, err := l.db.Exec(ctx, `DELETE FROM jobs WHERE finished_at < $1`, now.Add(-keep))
+  if err != nil { return 0, err }
+  return tag.RowsAffected(), nil
+}
+
+// JobStep is what a handler reports after a step.
+type JobStep struct {
+  Progress any // recorded as the job's progress; nil keeps the previous one
+  Done bool
+  Result any // recorded when Done
+  After time.Duration // wall time before the next step
+}
+
+// JobHandler runs one step of a job. Jobs that take several steps resume
+// from j.Progress. An error fails the job; a handler that wants to retry
+// returns a step that is not done instead. ctx is cancelled when the job is
+// cancelled or the runner stops.
+type JobHandler func(ctx context.Context, j *Job) (JobStep, error)
+
+const (
+  jobPoll = time.Second
+  jobLease = 30 * time.Second
+  jobPruneEvery = time.Minute
+)
+
+// JobRunner is a pool of workers that run due job steps. Every replica runs
+// one: a worker claims a job by taking a lease on it, which it renews while
+// the step runs, so a job whose worker died is picked up again once its
+// lease expires. Timing is wall time, like the rates jobs are given.
+type JobRunner struct {
+  led *Ledger
+  log *slog.Logger
+  workers int
+  handlers map[string]JobHandler
+  retention atomic.Int64
+}
+
+// NewJobRunner returns a runner with the ledger's own job kinds registered.
+func NewJobRunner(led *Ledger, log *slog.Logger, workers int) *JobRunner {
+  r := &JobRunner{led: led, log: log, workers: max(workers, 1), handlers: map[string]JobHandler{}}
+  r.SetRetention(DefaultJobRetention)
+  r.Register(JobKindReplaySpool, led.replaySpoolStep)
+  r.Register(JobKindExport, led.exportStep)
+  return r
+}
+
+// Register adds a job kind. Call it before Run.
+func (r *JobRunner) Register(kind string, h JobHandler) { r.handlers[kind] = h }
+
+// SetRetention sets how long finished jobs are kept; 0 keeps them.
+func (r *JobRunner) SetRetention(d time.Duration) { r.retention.Store(int64(d)) }
+
+func (r *JobRunner) Run(ctx context.Context) {
+  var wg sync.WaitGroup
+  for range r.workers {
+    wg.Go(func() { r.work(ctx) })
+  }
+  ticker := time.NewTicker(jobPruneEvery)
+  defer ticker.Stop()
+  for {
+    select {
+    case <-ctx.Done():
+      wg.Wait()
+      return
+    case <-ticker.C:
+      n, err := r.led.PruneJobs(context.WithoutCancel(ctx), time.Duration(r.retention.Load()))
+      if err != nil {
+        r.log.Warn("job prune failed", "err", err.Error())
+      } else if n > 0 {
+        r.log.Info("pruned finished jobs", "deleted", n)
+      }
+    }
+  }
+}
+
+func (r *JobRunner) work(ctx context.Context) {
+  for ctx.Err() == nil {
+    ran, err := r.runStep(ctx)
+    if err != nil { r.log.Warn("job step failed", "err", err.Error()) }
+    if ran && err == nil { continue }
+    select {
+    case <-ctx.Done():
+    case <-time.After(jobPoll):
+    }
+  }
+}
+
+// RunDue runs steps until no job is due and returns how many it ran.
+func (r *JobRunner) RunDue(ctx context.Context) (int, error) {
+  n := 0
+  for {
+    ran, err := r.runStep(ctx)
+    if err != nil || !ran { return n, err }
+    n++
+  }
+}
+
+// runStep claims the next due job of a registered kind and runs one step.
+func (r *JobRunner) runStep(ctx context.Context) (bool, error) {
+  kinds := make([]string, 0, len(r.handlers))
+  for k := range r.handlers { kinds = append(kinds, k) }
+  slices.Sort(kinds)
+
+  db := r.led.db
+  j, err := scanJob(db.QueryRow(ctx, `
+    UPDATE jobs SET status='RUNNING', lease_until=now() + $1 * interval '1 millisecond',
+      started_at=COALESCE(started_at,$2), updated_at=$2
+    WHERE id=(
+      SELECT id FROM jobs
+      WHERE status IN ('QUEUED','RUNNING') AND run_after <= now() AND (lease_until IS NULL OR lease_until < now())
+        AND kind = ANY($3)
+      ORDER BY run_after, created_at
+      LIMIT 1
+      FOR UPDATE SKIP LOCKED
+    )
+    RETURNING `+jobCols, jobLease.Milliseconds(), r.led.clock.Now(), kinds))
+  if errors.Is(err, pgx.ErrNoRows) { return false, nil }
+  if err != nil { return false, err }
+
+  jobCtx, err := r.led.jobContext(ctx, j.ID)
+  if err != nil { return false, err }
+  stepCtx, cancel := context.WithCancel(jobCtx)
+  renewed := make(chan struct{})
+  go func() {
+    defer close(renewed)
+    r.renewLease(stepCtx, cancel, j.ID)
+  }()
+  step, stepErr := r.handlers[j.Kind](stepCtx, j)
+  cancel()
+  <-renewed
+
+  // stopping: hand the job back for another worker to resume
+  if ctx.Err() != nil {
+    _, err := db.Exec(context.WithoutCancel(ctx), `UPDATE jobs SET lease_until=NULL WHERE id=$1::uuid`, j.ID)
+    return true, err
+  }
+  return true, r.finishStep(context.WithoutCancel(ctx), j, step, stepErr)
+}
+
+// renewLease extends the lease while the step runs and cancels the step
+// once the job is no longer running (it was cancelled or pruned).
+func (r *JobRunner) renewLease(ctx context.Context, cancel context.CancelFunc, id string) {
+  ticker := time.NewTicker(jobLease / 3)
+  defer ticker.Stop()
+  for {
+    select {
+    case <-ctx.Done():
+      return
+    case <-ticker.C:
+      tag, err := r.led.db.Exec(ctx, `UPDATE jobs SET lease_until=now() + $2 * interval '1 millisecond' WHERE id=$1::uuid AND status='RUNNING'`,
+        id, jobLease.Milliseconds())
+      if err == nil && tag.RowsAffected() == 0 { cancel(); return }
+    }
+  }
+}
+
+// finishStep records a step's progress and, when the job is over, its final
+// status. A job cancelled meanwhile keeps that status but still gets the
+// step's progress.
+func (r *JobRunner) finishStep(ctx context.Context, j *Job, step JobStep, stepErr error) error {
+  status, errText := JobRunning, ""
+  switch {
+  case stepErr != nil:
+    status, errText = JobFailed, stepErr.Error()
+  case step.Done:
+    status = JobCompleted
+  }
+  var progress, result []byte
+  var err error
+  if step.Progress != nil {
+    if progress, err = json.Marshal(step.Progress); err != nil { return err }
+  }
+  if step.Done && step.Result != nil {
+    if result, err = json.Marshal(step.Result); err != nil { return err }
+  }
+
+  tx, err := r.led.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  now := r.led.clock.Now()
+  done, err := scanJob(tx.QueryRow(ctx, `
+    UPDATE jobs SET progress=COALESCE($2::jsonb, progress), steps=steps+1, lease_until=NULL,
+      run_after=now() + $3 * interval '1 millisecond',
+      status=CASE WHEN status='RUNNING' THEN $4::text ELSE status END,
+      result=CASE WHEN status='RUNNING' THEN $5::jsonb ELSE result END,
+      error=CASE WHEN status='RUNNING' THEN NULLIF($6,'') ELSE error END,
+      finished_at=CASE WHEN status='RUNNING' AND $4::text<>'RUNNING' THEN $7 ELSE finished_at END,
+      updated_at=$7
+    WHERE id=$1::uuid
+    RETURNING `+jobCols, j.ID, progress, step.After.Milliseconds(), status, result, errText, now))
+  if errors.Is(err, pgx.ErrNoRows) { return nil } // pruned meanwhile
+  if err != nil { return err }
+
+  if status != JobRunning && done.Status == status {
+    err = r.led.audit(ctx, pgQueries{tx}, AuditRecord{
+      Actor: "job-worker", Action: "FINISH_JOB", TargetType: "job", TargetID: j.ID, Reason: errText,
+      Details: map[string]any{"kind": j.Kind, "target": j.Target, "status": status, "steps": done.Steps,
+        "progress": done.Progress, "started_by": j.Actor},
+    })
+    if err != nil { return err }
+  }
+
+  if err := tx.Commit(ctx); err != nil { return err }
+  if status != JobRunning {
+    r.log.InfoContext(ctx, "job finished", "job_id", j.ID, "kind", j.Kind, "status", done.Status, "steps", done.Steps)
+  }
+  return nil
+}
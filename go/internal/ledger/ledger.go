@@ -6,26 +6,81 @@ import (
   "errors"
   "fmt"
   "hash/fnv"
+  "strconv"
+  "strings"
+  "sync/atomic"
   "time"
 
+  "github.com/google/uuid"
   "github.com/jackc/pgx/v5"
   "github.com/jackc/pgx/v5/pgxpool"
+  "go.opentelemetry.io/otel/attribute"
+  "go.opentelemetry.io/otel/trace"
   "log/slog"
+
+  "time-ledger-sim/go/internal/auditsig"
+  "time-ledger-sim/go/internal/logging"
+  "time-ledger-sim/go/internal/metrics"
+  "time-ledger-sim/go/internal/tracing"
 )
 
+// Ledger splits its SQL between commands and queries: writes, and reads that
+// must see them (gating, single-row lookups, ETags of lists served from the
+// primary), use db; list, search and export queries use ro, a read replica
+// when one is configured, so dashboards do not contend with transfers.
 type Ledger struct {
-  db *pgxpool.Pool
+  db *pgxpool.Pool // primary; nil with NewWithRepo
+  ro *pgxpool.Pool // queries; db unless UseReadReplica
+  repo Repo
   log *slog.Logger
+  clock Clock
+  rand *simRand
+  zones *zoneCache
+  tx atomic.Pointer[txPolicy]
+  signer auditsig.Signer // nil: audit entries are not signed
+  knownActors atomic.Bool // reject changes by actors missing from the directory
+  reasonCodes atomic.Bool // reject zone changes without a reason code
+  strictAccounts atomic.Bool // transfers never create accounts
+  multiTenant atomic.Bool // the store scopes store.WithTenant contexts
+  approvals atomic.Pointer[approvalPolicy]
+  redaction atomic.Pointer[RedactionPolicy] // metadata leaving the ledger; nil: unredacted
 }
 
+// New returns a Ledger on a real-time virtual clock with a time-derived seed;
+// use SetClock/Reseed for deterministic runs.
 func New(db *pgxpool.Pool, log *slog.Logger) *Ledger {
-  return &Ledger{db: db, log: log}
+  l := NewWithRepo(NewPgRepo(db), log)
+  l.db, l.ro = db, db
+  return l
+}
+
+// UseReadReplica sends list, search and export queries to ro. Their results
+// may lag the primary by the replication delay.
+func (l *Ledger) UseReadReplica(ro *pgxpool.Pool) { l.ro = ro }
+
+// NewWithRepo returns a Ledger whose transfer and spool-replay paths run on
+// repo. Without a pool only those paths (and the controls reads they share)
+// work; the rest of the API needs New.
+func NewWithRepo(repo Repo, log *slog.Logger) *Ledger {
+  l := &Ledger{repo: repo, log: log, clock: NewVirtualClock(), rand: newSimRand(uint64(time.Now().UnixNano())), zones: newZoneCache()}
+  l.SetTransferIsolation(IsolationReadCommitted, DefaultTxRetries)
+  l.SetTwoPersonRule(false, DefaultApprovalTTL)
+  return l
 }
 
+func (l *Ledger) SetClock(c Clock) { l.clock = c }
+
+func (l *Ledger) Now() time.Time { return l.clock.Now() }
+
+func (l *Ledger) Reseed(seed uint64) { l.rand.reseed(seed) }
+
+func (l *Ledger) Seed() uint64 { return l.rand.Seed() }
+
 type Zone struct {
   ID string `json:"id"`
   Name string `json:"name"`
   Status string `json:"status"`
+  Tags map[string]string `json:"tags"`
   UpdatedAt time.Time `json:"updated_at"`
 }
 
@@ -33,6 +88,7 @@ type Transaction struct {
   ID string
   RequestID string
   CreatedAt time.Time
+  ZoneSeq int64 // position in the zone's sequence
 }
 
 type CreateTransferInput struct {
@@ -42,11 +98,21 @@ type CreateTransferInput struct {
   ToAccount string
   AmountUnits int64
   ZoneID string
+  Type string // transaction type; "" is TRANSFER
   Metadata map[string]any
+  // EventContext is merged into the TRANSFER_POSTED payload (e.g. why it was spooled).
+  EventContext map[string]any
+  // At backdates the transfer (data seeding); zero means the sim clock's now.
+  At time.Time
+  // LimitOverride lets the transfer past the zone's amount limits.
+  LimitOverride *LimitOverride
 }
 
 var (
   ErrIdempotencyConflict = errors.New("idempotency conflict")
+  // ErrRequestExists is what Queries inserts return when another transaction
+  // recorded the request_id first; the ledger answers it as a replay.
+  ErrRequestExists = errors.New("request_id already recorded")
   ErrZoneDown = errors.New("zone down")
   ErrZoneBlocked = errors.New("zone blocked")
 )
@@ -55,46 +121,90 @@ func IsIdempotencyConflict(err error) bool { return errors.Is(err, ErrIdempotenc
 func IsZoneDown(err error) bool { return errors.Is(err, ErrZoneDown) }
 func IsZoneBlocked(err error) bool { return errors.Is(err, ErrZoneBlocked) }
 
-func (l *Ledger) ListZones(ctx context.Context) ([]Zone, error) {
-  rows, err := l.db.Query(ctx, `SELECT id,name,status,updated_at FROM zones ORDER BY id`)
+// ListZones lists the active zones carrying all the given tags (any zone
+// when tags is empty).
+func (l *Ledger) ListZones(ctx context.Context, tags map[string]string) ([]Zone, error) {
+  rows, err := l.db.Query(ctx, `SELECT id,name,status,tags,updated_at FROM zones WHERE retired_at IS NULL AND tags @> $1 ORDER BY id`, tagSet(tags))
   if err != nil { return nil, err }
   defer rows.Close()
   out := []Zone{}
   for rows.Next() {
     var z Zone
-    if err := rows.Scan(&z.ID, &z.Name, &z.Status, &z.UpdatedAt); err != nil { return nil, err }
+    if err := rows.Scan(&z.ID, &z.Name, &z.Status, &z.Tags, &z.UpdatedAt); err != nil { return nil, err }
     out = append(out, z)
   }
   return out, rows.Err()
 }
 
-func (l *Ledger) getZoneStatusTx(ctx context.Context, tx pgx.Tx, zoneID string) (string, error) {
-  var status string
-  err := tx.QueryRow(ctx, `SELECT status FROM zones WHERE id=$1`, zoneID).Scan(&status)
-  if err != nil { return "", err }
-  return status, nil
+func (l *Ledger) CreateTransfer(ctx context.Context, in CreateTransferInput) (*Transaction, *string, error) {
+  start := time.Now()
+  ctx, span := tracing.Start(ctx, "ledger.CreateTransfer", trace.WithAttributes(
+    attribute.String("zone_id", in.ZoneID),
+    attribute.String("transfer.request_id", in.RequestID),
+    attribute.Int64("amount_units", in.AmountUnits),
+  ))
+  txn, spoolID, err := l.createTransfer(ctx, in)
+  outcome := transferOutcome(spoolID, err)
+  span.SetAttributes(attribute.String("outcome", outcome))
+  if txn != nil { span.SetAttributes(attribute.String("txn_id", txn.ID)) }
+  if spoolID != nil { span.SetAttributes(attribute.String("spool_id", *spoolID)) }
+  tracing.End(span, err)
+
+  zone := in.ZoneID
+  if IsZoneNotFound(err) { zone = "unknown" } // no series per made-up zone id
+  metrics.ObserveTransfer(zone, outcome, time.Since(start))
+  return txn, spoolID, err
 }
 
-func (l *Ledger) ensureAccount(ctx context.Context, tx pgx.Tx, accountID, zoneID string) error {
-  // Insert if missing
-  _, err := tx.Exec(ctx, `INSERT INTO accounts(id, zone_id) VALUES($1,$2) ON CONFLICT (id) DO NOTHING`, accountID, zoneID)
-  return err
+// transferOutcome classifies a CreateTransfer result for metrics.
+func transferOutcome(spoolID *string, err error) string {
+  switch {
+  case err == nil && spoolID != nil:
+    return metrics.OutcomeSpooled
+  case err == nil:
+    return metrics.OutcomeApplied
+  case IsZoneDown(err), IsZoneBlocked(err), IsRateLimited(err), IsPartitioned(err), IsAccountBlocked(err), IsAccountNotFound(err), IsIdempotencyConflict(err), IsZoneNotFound(err), isAmountLimit(err):
+    return metrics.OutcomeRejected
+  }
+  return metrics.OutcomeError
 }
 
-func (l *Ledger) CreateTransfer(ctx context.Context, in CreateTransferInput) (*Transaction, *string, error) {
+func (l *Ledger) createTransfer(ctx context.Context, in CreateTransferInput) (*Transaction, *string, error) {
+  if err := checkTransactionType(in.Type); err != nil { return nil, nil, err }
   // serialize metadata
   metaBytes, err := json.Marshal(in.Metadata)
   if err != nil { return nil, nil, err }
 
-  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
-  if err != nil { return nil, nil, err }
-  defer func() { _ = tx.Rollback(ctx) }()
+  // chaos: latency + error injection happen before the DB transaction so they never hold locks
+  if err := l.applyChaos(ctx, in.ZoneID, in.RequestID); err != nil {
+    if IsInjectedFault(err) { l.logTransfer(ctx, slog.LevelWarn, "transfer failed by injected fault", in) }
+    return nil, nil, err
+  }
 
-  // zone gate + controls
-  status, err := l.getZoneStatusTx(ctx, tx, in.ZoneID)
+  var txn *Transaction
+  var spoolID *string
+  for delayed := false; ; delayed = true {
+    err = l.inTransferTx(ctx, func(q Queries) error {
+      var err error
+      txn, spoolID, err = l.createTransferTx(ctx, q, in, metaBytes, delayed)
+      return err
+    })
+    // a DELAY waits outside the transaction, then the gates are checked again
+    var d transferDelay
+    if delayed || !errors.As(err, &d) { break }
+    l.logTransfer(ctx, slog.LevelInfo, "transfer delayed", in, "reason", d.reason, "delay_ms", d.wait.Milliseconds())
+    if err := sleepCtx(ctx, d.wait); err != nil { return nil, nil, err }
+  }
   if err != nil { return nil, nil, err }
+  return txn, spoolID, nil
+}
 
-  controls, err := l.getZoneControlsTx(ctx, tx, in.ZoneID)
+// createTransferTx decides and records a transfer inside the transaction; its
+// log lines are written before the commit. delayed is set on the attempt
+// after a DELAY.
+func (l *Ledger) createTransferTx(ctx context.Context, q Queries, in CreateTransferInput, metaBytes []byte, delayed bool) (*Transaction, *string, error) {
+  // zone gate + controls
+  status, controls, err := l.zoneState(ctx, q, in.ZoneID)
   if err != nil { return nil, nil, err }
 
   blockedReason := ""
@@ -102,7 +212,7 @@ func (l *Ledger) CreateTransfer(ctx context.Context, in CreateTransferInput) (*T
     blockedReason = "zone down"
   } else if controls.WritesBlocked {
     blockedReason = "writes blocked"
-  } else {
+  } else if controls.ThrottleMode != ThrottleModeRate {
     // deterministic throttle (good for demos + reproducibility)
     thr := controls.CrossZoneThrottle
     if thr < 100 {
@@ -114,68 +224,134 @@ func (l *Ledger) CreateTransfer(ctx context.Context, in CreateTransferInput) (*T
         }
       }
     }
+    // the delay was the throttle's price
+    if blockedReason == "throttled" && delayed && controls.delaysThrottle() { blockedReason = "" }
   }
 
-  // idempotency check (applies to both applied and spooled cases)
-  var existingID string
-  var existingHash string
-  var createdAt time.Time
-  err = tx.QueryRow(ctx, `SELECT id::text,payload_hash,created_at FROM transactions WHERE request_id=$1`, in.RequestID).
-    Scan(&existingID, &existingHash, &createdAt)
-  if err == nil {
-    if existingHash != in.PayloadHash {
-      return nil, nil, ErrIdempotencyConflict
-    }
-    _ = tx.Commit(ctx)
-    return &Transaction{ID: existingID, RequestID: in.RequestID, CreatedAt: createdAt}, nil, nil
+  // idempotency fast path (applies to both applied and spooled cases). It is
+  // only an optimisation: a concurrent request with the same request_id can
+  // pass it too, and the inserts below settle that race.
+  if txn, spoolID, found, err := l.recordedRequest(ctx, q, in); found || err != nil { return txn, spoolID, err }
+
+  if err := l.checkAccountsExist(ctx, q, in); err != nil {
+    l.logTransfer(ctx, slog.LevelInfo, "transfer rejected", in, "reason", err.Error())
+    return nil, nil, err
   }
-  if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+
+  // per-account containment
+  if err := l.checkAccountControls(ctx, q, in); err != nil {
+    if IsAccountBlocked(err) { l.logTransfer(ctx, slog.LevelInfo, "transfer blocked", in, "reason", err.Error()) }
     return nil, nil, err
   }
 
-  // idempotency check for previously spooled transfer
-  var existingSpoolID string
-  var existingSpoolHash string
-  err = tx.QueryRow(ctx, `SELECT id::text,payload_hash FROM spooled_transfers WHERE request_id=$1`, in.RequestID).
-    Scan(&existingSpoolID, &existingSpoolHash)
-  if err == nil {
-    if existingSpoolHash != in.PayloadHash {
-      return nil, nil, ErrIdempotencyConflict
+  // regulatory amount limits, unless an admin overrides them
+  if err := l.checkAmountLimits(ctx, q, in, controls); err != nil {
+    if !isAmountLimit(err) { return nil, nil, err }
+    if in.LimitOverride == nil {
+      l.logTransfer(ctx, slog.LevelInfo, "transfer rejected", in, "reason", err.Error())
+      return nil, nil, err
     }
-    _ = tx.Commit(ctx)
-    return nil, &existingSpoolID, nil
+    if err := l.overrideAmountLimit(ctx, q, in, err); err != nil { return nil, nil, err }
   }
-  if err != nil && !errors.Is(err, pgx.ErrNoRows) {
-    return nil, nil, err
+
+  // simulated network partition towards the destination account's zone
+  if blockedReason == "" {
+    p, err := l.partitionForTransfer(ctx, q, in.ZoneID, in.ToAccount)
+    if err != nil { return nil, nil, err }
+    if p != nil {
+      if p.Mode == PartitionModeReject {
+        l.logTransfer(ctx, slog.LevelInfo, "transfer blocked", in, "reason", "partitioned", "to_zone", p.ToZone)
+        return nil, nil, fmt.Errorf("%w: %s -> %s", ErrPartitioned, p.FromZone, p.ToZone)
+      }
+      spoolID, err := l.spoolTransfer(ctx, q, in, metaBytes, p.blockedReason())
+      if errors.Is(err, ErrRequestExists) { return l.lostRequestRace(ctx, q, in) }
+      if err != nil { return nil, nil, err }
+      l.logTransfer(ctx, slog.LevelInfo, "transfer spooled", in, "reason", p.blockedReason(), "spool_id", spoolID)
+      return nil, &spoolID, nil
+    }
   }
 
-  // blocked? -> spool if enabled
+  // true rate limit: only consume a token for requests that would actually apply
+  if blockedReason == "" && controls.ThrottleMode == ThrottleModeRate {
+    ok, err := l.takeRateToken(ctx, q, in.ZoneID, controls.RateLimitPerSec, controls.RateLimitBurst)
+    if err != nil { return nil, nil, err }
+    if !ok { blockedReason = "rate limited" }
+  }
+
+  // blocked? -> spool, delay or reject, as the zone's controls say for the cause
   if blockedReason != "" {
-    if controls.SpoolEnabled {
-      spoolID, err := l.spoolTransferTx(ctx, tx, in, metaBytes, blockedReason)
+    action, rejectErr := controls.blockedOutcome(status, blockedReason, delayed)
+    switch action {
+    case BlockedActionSpool:
+      spoolID, err := l.spoolTransfer(ctx, q, in, metaBytes, blockedReason)
+      if errors.Is(err, ErrRequestExists) { return l.lostRequestRace(ctx, q, in) }
       if err != nil { return nil, nil, err }
-      if err := tx.Commit(ctx); err != nil { return nil, nil, err }
+      l.logTransfer(ctx, slog.LevelInfo, "transfer spooled", in, "reason", blockedReason, "spool_id", spoolID)
       return nil, &spoolID, nil
+    case BlockedActionDelay:
+      return nil, nil, transferDelay{wait: time.Duration(controls.BlockedDelayMs) * time.Millisecond, reason: blockedReason}
     }
-    // no spooling
-    if status == "DOWN" {
-      return nil, nil, ErrZoneDown
+    l.logTransfer(ctx, slog.LevelInfo, "transfer blocked", in, "reason", blockedReason)
+    return nil, nil, rejectErr
+  }
+
+  // accounts are created on first use (simulation simplification: all accounts
+  // live in the initiating zone); controls were read above, so reuse their skew
+  txn, err := l.applyTransfer(ctx, q, in, metaBytes, controls.ClockSkewMs)
+  if errors.Is(err, ErrRequestExists) { return l.lostRequestRace(ctx, q, in) }
+  if err != nil { return nil, nil, err }
+  l.logTransfer(ctx, slog.LevelDebug, "transfer applied", in, "txn_id", txn.ID, "zone_seq", txn.ZoneSeq, "amount_units", in.AmountUnits)
+  return txn, nil, nil
+}
+
+// recordedRequest looks up an earlier transaction or spool entry for the
+// request: found with the result to replay, or ErrIdempotencyConflict when
+// the payload differs.
+func (l *Ledger) recordedRequest(ctx context.Context, q Queries, in CreateTransferInput) (*Transaction, *string, bool, error) {
+  existing, existingHash, err := q.FindTransaction(ctx, in.RequestID)
+  if err != nil { return nil, nil, false, err }
+  if existing != nil {
+    if existingHash != in.PayloadHash {
+      l.logTransfer(ctx, slog.LevelWarn, "idempotency conflict", in, "txn_id", existing.ID)
+      return nil, nil, true, ErrIdempotencyConflict
     }
-    return nil, nil, ErrZoneBlocked
+    l.logTransfer(ctx, slog.LevelInfo, "idempotent hit", in, "txn_id", existing.ID)
+    return existing, nil, true, nil
   }
 
-  // ensure accounts exist (simulation simplification: all accounts live in initiating zone)
-  if err := l.ensureAccount(ctx, tx, in.FromAccount, in.ZoneID); err != nil { return nil, nil, err }
-  if err := l.ensureAccount(ctx, tx, in.ToAccount, in.ZoneID); err != nil { return nil, nil, err }
+  spoolID, spoolHash, err := q.FindSpooled(ctx, in.RequestID)
+  if err != nil { return nil, nil, false, err }
+  if spoolID != "" {
+    if spoolHash != in.PayloadHash {
+      l.logTransfer(ctx, slog.LevelWarn, "idempotency conflict", in, "spool_id", spoolID)
+      return nil, nil, true, ErrIdempotencyConflict
+    }
+    l.logTransfer(ctx, slog.LevelInfo, "idempotent hit", in, "spool_id", spoolID)
+    return nil, &spoolID, true, nil
+  }
+  return nil, nil, false, nil
+}
 
-  txnID, createdAt, err := l.applyTransferTx(ctx, tx, in, metaBytes)
-  if err != nil { return nil, nil, err }
+// lostRequestRace answers a request whose insert found its request_id taken by
+// a concurrent one. ON CONFLICT waited for that transaction to commit, so its
+// row is visible now and the request replays it (or conflicts with it).
+func (l *Ledger) lostRequestRace(ctx context.Context, q Queries, in CreateTransferInput) (*Transaction, *string, error) {
+  txn, spoolID, found, err := l.recordedRequest(ctx, q, in)
+  if err == nil && !found { err = ErrRequestExists }
+  return txn, spoolID, err
+}
 
-  if err := tx.Commit(ctx); err != nil { return nil, nil, err }
-  return &Transaction{ID: txnID, RequestID: in.RequestID, CreatedAt: createdAt}, nil, nil
+// logTransfer logs a transfer decision with the fields incident retrospectives
+// filter on. The API call's X-Request-Id is added from ctx as request_id; the
+// transfer's own idempotency key is transfer_request_id.
+func (l *Ledger) logTransfer(ctx context.Context, level slog.Level, msg string, in CreateTransferInput, attrs ...any) {
+  if l.log == nil { return }
+  l.log.Log(ctx, level, msg, append([]any{"zone_id", in.ZoneID, "transfer_request_id", in.RequestID}, attrs...)...)
 }
 
-func (l *Ledger) SetZoneStatus(ctx context.Context, zoneID, status, actor, reason string) (*Zone, error) {
+// SetZoneStatus changes a zone's status. reasonCode is from the reason-code
+// catalog ("" unless codes are required); reason is free text.
+func (l *Ledger) SetZoneStatus(ctx context.Context, zoneID, status, actor, reasonCode, reason string) (*Zone, error) {
   if status != "OK" && status != "DEGRADED" && status != "DOWN" {
     return nil, fmt.Errorf("invalid status")
   }
@@ -183,30 +359,41 @@ func (l *Ledger) SetZoneStatus(ctx context.Context, zoneID, status, actor, reaso
   if err != nil { return nil, err }
   defer func(){ _ = tx.Rollback(ctx) }()
 
+  if err := l.checkActors(ctx, tx, actor); err != nil { return nil, err }
+  if err := l.checkReasonCode(ctx, tx, ReasonForZoneStatus, reasonCode); err != nil { return nil, err }
+
   var z Zone
   err = tx.QueryRow(ctx, `
-    UPDATE zones SET status=$2, updated_at=now() WHERE id=$1
-    RETURNING id,name,status,updated_at
-  `, zoneID, status).Scan(&z.ID, &z.Name, &z.Status, &z.UpdatedAt)
+    UPDATE zones SET status=$2, updated_at=now() WHERE id=$1 AND retired_at IS NULL
+    RETURNING id,name,status,tags,updated_at
+  `, zoneID, status).Scan(&z.ID, &z.Name, &z.Status, &z.Tags, &z.UpdatedAt)
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrZoneNotFound }
   if err != nil { return nil, err }
 
-  _, err = tx.Exec(ctx, `
-    INSERT INTO audit_log(actor,action,target_type,target_id,reason,details)
-    VALUES($1,'SET_ZONE_STATUS','zone',$2,$3, jsonb_build_object('status',$4))
-  `, actor, zoneID, reason, status)
+  err = l.audit(ctx, pgQueries{tx}, AuditRecord{
+    Actor: actor, Action: "SET_ZONE_STATUS", TargetType: "zone", TargetID: zoneID, Reason: reason, ReasonCode: reasonCode,
+    Details: map[string]any{"status": status},
+  })
   if err != nil { return nil, err }
 
   if status == "DOWN" {
     _, _ = tx.Exec(ctx, `
       INSERT INTO incidents(zone_id,severity,title,details)
-      VALUES($1,'CRITICAL','Zone marked DOWN', jsonb_build_object('reason',$2,'actor',$3))
-    `, zoneID, reason, actor)
+      VALUES($1,'CRITICAL','Zone marked DOWN', jsonb_build_object('reason',$2,'actor',$3,'reason_code',NULLIF($4,'')) || `+incidentTrace(5)+`)
+    `, zoneID, reason, actor, reasonCode, tracing.TraceID(ctx))
   }
 
   if err := tx.Commit(ctx); err != nil { return nil, err }
+  l.zones.invalidate(zoneID) // other replicas hear the trigger's NOTIFY
   return &z, nil
 }
 
+// incidentTrace is SQL for the trace_id part of a new incident's details:
+// the text parameter $n, or nothing when it is "".
+func incidentTrace(n int) string {
+  return `jsonb_strip_nulls(jsonb_build_object('trace_id',NULLIF($` + strconv.Itoa(n) + `::text,'')))`
+}
+
 type Incident struct {
   ID string `json:"id"`
   ZoneID string `json:"zone_id"`
@@ -219,14 +406,17 @@ type Incident struct {
 }
 
 
-func (l *Ledger) ListRecentIncidents(ctx context.Context, limit int) ([]Incident, error) {
+// ListRecentIncidents lists the newest incidents, only those of zones
+// carrying all the given tags when tags is set.
+func (l *Ledger) ListRecentIncidents(ctx context.Context, limit int, tags map[string]string) ([]Incident, error) {
   if limit <= 0 || limit > 2000 { limit = 500 }
-  rows, err := l.db.Query(ctx, `
+  rows, err := l.ro.Query(ctx, `
     SELECT id::text, zone_id, related_txn_id::text, severity, status, title, details, detected_at
     FROM incidents
+    WHERE $2::jsonb = '{}' OR zone_id IN (SELECT id FROM zones WHERE tags @> $2::jsonb)
     ORDER BY detected_at DESC
     LIMIT $1
-  `, limit)
+  `, limit, tagSet(tags))
   if err != nil { return nil, err }
   defer rows.Close()
 
@@ -244,7 +434,7 @@ func (l *Ledger) ListRecentIncidents(ctx context.Context, limit int) ([]Incident
 }
 
 func (l *Ledger) ListIncidentsByZone(ctx context.Context, zoneID string) ([]Incident, error) {
-  rows, err := l.db.Query(ctx, `
+  rows, err := l.ro.Query(ctx, `
     SELECT id::text, zone_id, related_txn_id::text, severity, status, title, details, detected_at
     FROM incidents WHERE zone_id=$1 ORDER BY detected_at DESC LIMIT 200
   `, zoneID)
@@ -278,350 +468,11 @@ func (l *Ledger) GetIncident(ctx context.Context, id string) (*Incident, error)
   return &inc, nil
 }
 
-func (l *Ledger) Snapshot(ctx context.Context) (map[string]any, error) {
-  snap := map[string]any{
-    "version": "v2",
-    "created_at": time.Now().UTC().Format(time.RFC3339Nano),
-    "note": "Restore resets transaction history; balances/incidents/controls/spool/audit are restored.",
-  }
-
-  zones, err := l.ListZones(ctx)
-  if err != nil { return nil, err }
-  snap["zones"] = zones
-
-  // zone controls
-  rows, err := l.db.Query(ctx, `SELECT zone_id, writes_blocked, cross_zone_throttle, spool_enabled, updated_at FROM zone_controls ORDER BY zone_id`)
-  if err != nil { return nil, err }
-  defer rows.Close()
-  ctrls := []map[string]any{}
-  for rows.Next() {
-    var zid string
-    var wb bool
-    var thr int
-    var sp bool
-    var ua time.Time
-    if err := rows.Scan(&zid, &wb, &thr, &sp, &ua); err != nil { return nil, err }
-    ctrls = append(ctrls, map[string]any{
-      "zone_id": zid,
-      "writes_blocked": wb,
-      "cross_zone_throttle": thr,
-      "spool_enabled": sp,
-      "updated_at": ua.UTC().Format(time.RFC3339Nano),
-    })
-  }
-  snap["zone_controls"] = ctrls
-
-  // accounts + balances (joined)
-  abRows, err := l.db.Query(ctx, `
-    SELECT a.id, a.zone_id, COALESCE(b.balance_units,0) as balance_units
-    FROM accounts a
-    LEFT JOIN balances b ON b.account_id=a.id
-    ORDER BY a.id
-    LIMIT 20000
-  `)
-  if err != nil { return nil, err }
-  defer abRows.Close()
-  accts := []map[string]any{}
-  for abRows.Next() {
-    var id, zid string
-    var bal int64
-    if err := abRows.Scan(&id, &zid, &bal); err != nil { return nil, err }
-    accts = append(accts, map[string]any{"id": id, "zone_id": zid, "balance_units": bal})
-  }
-  snap["accounts"] = accts
-
-  // incidents
-  incRows, err := l.db.Query(ctx, `
-    SELECT id::text, zone_id, related_txn_id::text, severity, status, title, details, detected_at
-    FROM incidents
-    ORDER BY detected_at DESC
-    LIMIT 5000
-  `)
-  if err != nil { return nil, err }
-  defer incRows.Close()
-  incs := []map[string]any{}
-  for incRows.Next() {
-    var id, zid, sev, st, title string
-    var related *string
-    var detailsBytes []byte
-    var dt time.Time
-    if err := incRows.Scan(&id, &zid, &related, &sev, &st, &title, &detailsBytes, &dt); err != nil { return nil, err }
-    var d any
-    _ = json.Unmarshal(detailsBytes, &d)
-    m := map[string]any{
-      "id": id,
-      "zone_id": zid,
-      "related_txn_id": related,
-      "severity": sev,
-      "status": st,
-      "title": title,
-      "details": d,
-      "detected_at": dt.UTC().Format(time.RFC3339Nano),
-    }
-    incs = append(incs, m)
-  }
-  snap["incidents"] = incs
-
-  // spool (cap)
-  spRows, err := l.db.Query(ctx, `
-    SELECT id::text, request_id, payload_hash, from_account, to_account, amount_units, zone_id, metadata, status, fail_reason, created_at, updated_at, applied_at
-    FROM spooled_transfers
-    ORDER BY created_at DESC
-    LIMIT 5000
-  `)
-  if err != nil { return nil, err }
-  defer spRows.Close()
-  spools := []map[string]any{}
-  for spRows.Next() {
-    var id, req, ph, from, to, zid, st string
-    var amt int64
-    var meta []byte
-    var fail *string
-    var ca, ua time.Time
-    var aa *time.Time
-    if err := spRows.Scan(&id, &req, &ph, &from, &to, &amt, &zid, &meta, &st, &fail, &ca, &ua, &aa); err != nil { return nil, err }
-    var m any
-    _ = json.Unmarshal(meta, &m)
-    item := map[string]any{
-      "id": id,
-      "request_id": req,
-      "payload_hash": ph,
-      "from_account": from,
-      "to_account": to,
-      "amount_units": amt,
-      "zone_id": zid,
-      "metadata": m,
-      "status": st,
-      "fail_reason": fail,
-      "created_at": ca.UTC().Format(time.RFC3339Nano),
-      "updated_at": ua.UTC().Format(time.RFC3339Nano),
-      "applied_at": nil,
-    }
-    if aa != nil { item["applied_at"] = aa.UTC().Format(time.RFC3339Nano) }
-    spools = append(spools, item)
-  }
-  snap["spooled_transfers"] = spools
-
-  // audit tail
-  aRows, err := l.db.Query(ctx, `
-    SELECT id::text, actor, action, target_type, target_id, reason, details, created_at
-    FROM audit_log
-    ORDER BY created_at DESC
-    LIMIT 2000
-  `)
-  if err != nil { return nil, err }
-  defer aRows.Close()
-  audits := []map[string]any{}
-  for aRows.Next() {
-    var id, actor, action, tt, tid string
-    var reason *string
-    var details []byte
-    var ca time.Time
-    if err := aRows.Scan(&id, &actor, &action, &tt, &tid, &reason, &details, &ca); err != nil { return nil, err }
-    var d any
-    _ = json.Unmarshal(details, &d)
-    audits = append(audits, map[string]any{
-      "id": id,
-      "actor": actor,
-      "action": action,
-      "target_type": tt,
-      "target_id": tid,
-      "reason": reason,
-      "details": d,
-      "created_at": ca.UTC().Format(time.RFC3339Nano),
-    })
-  }
-  snap["audit_log"] = audits
-
-  return snap, nil
-}
-
-func (l *Ledger) Restore(ctx context.Context, snap map[string]any) error {
-  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
-  if err != nil { return err }
-  defer func(){ _ = tx.Rollback(ctx) }()
-
-  // Hard reset mutable state for a consistent restore.
-  _, _ = tx.Exec(ctx, `TRUNCATE TABLE postings RESTART IDENTITY CASCADE`)
-  _, _ = tx.Exec(ctx, `TRUNCATE TABLE transactions RESTART IDENTITY CASCADE`)
-  _, _ = tx.Exec(ctx, `TRUNCATE TABLE balances RESTART IDENTITY CASCADE`)
-  _, _ = tx.Exec(ctx, `TRUNCATE TABLE accounts RESTART IDENTITY CASCADE`)
-  _, _ = tx.Exec(ctx, `TRUNCATE TABLE incidents RESTART IDENTITY CASCADE`)
-  _, _ = tx.Exec(ctx, `TRUNCATE TABLE outbox_events RESTART IDENTITY CASCADE`)
-  _, _ = tx.Exec(ctx, `TRUNCATE TABLE inbox_events RESTART IDENTITY CASCADE`)
-  _, _ = tx.Exec(ctx, `TRUNCATE TABLE audit_log RESTART IDENTITY CASCADE`)
-  _, _ = tx.Exec(ctx, `TRUNCATE TABLE spooled_transfers RESTART IDENTITY CASCADE`)
-  _, _ = tx.Exec(ctx, `TRUNCATE TABLE zone_controls RESTART IDENTITY CASCADE`)
-
-  // zones: update statuses only
-  if zs, ok := snap["zones"].([]any); ok {
-    for _, it := range zs {
-      m, _ := it.(map[string]any)
-      id, _ := m["id"].(string)
-      status, _ := m["status"].(string)
-      if id != "" && (status=="OK"||status=="DEGRADED"||status=="DOWN") {
-        _, _ = tx.Exec(ctx, `UPDATE zones SET status=$2, updated_at=now() WHERE id=$1`, id, status)
-      }
-    }
-  }
-
-  // zone controls
-  if cs, ok := snap["zone_controls"].([]any); ok {
-    for _, it := range cs {
-      m, _ := it.(map[string]any)
-      zid, _ := m["zone_id"].(string)
-      if zid == "" { continue }
-      wb, _ := m["writes_blocked"].(bool)
-      thrF, _ := m["cross_zone_throttle"].(float64)
-      thr := int(thrF)
-      sp, _ := m["spool_enabled"].(bool)
-      _, _ = tx.Exec(ctx, `
-        INSERT INTO zone_controls(zone_id,writes_blocked,cross_zone_throttle,spool_enabled,updated_at)
-        VALUES($1,$2,$3,$4,now())
-        ON CONFLICT (zone_id) DO UPDATE
-          SET writes_blocked=EXCLUDED.writes_blocked,
-              cross_zone_throttle=EXCLUDED.cross_zone_throttle,
-              spool_enabled=EXCLUDED.spool_enabled,
-              updated_at=now()
-      `, zid, wb, thr, sp)
-    }
-  } else {
-    // seed defaults if absent
-    _, _ = tx.Exec(ctx, `INSERT INTO zone_controls(zone_id) SELECT id FROM zones ON CONFLICT DO NOTHING`)
-  }
-
-  // accounts + balances
-  if acs, ok := snap["accounts"].([]any); ok {
-    for _, it := range acs {
-      m, _ := it.(map[string]any)
-      id, _ := m["id"].(string)
-      zid, _ := m["zone_id"].(string)
-      if id == "" { continue }
-      if zid == "" { zid = "zone-eu" }
-      _, _ = tx.Exec(ctx, `INSERT INTO accounts(id, zone_id) VALUES($1,$2) ON CONFLICT DO NOTHING`, id, zid)
-
-      balF, _ := m["balance_units"].(float64)
-      bal := int64(balF)
-      _, _ = tx.Exec(ctx, `INSERT INTO balances(account_id,balance_units,updated_at) VALUES($1,$2,now()) ON CONFLICT (account_id) DO UPDATE SET balance_units=EXCLUDED.balance_units, updated_at=now()`, id, bal)
-    }
-  }
-
-  // incidents
-  if ins, ok := snap["incidents"].([]any); ok {
-    for _, it := range ins {
-      m, _ := it.(map[string]any)
-      zid, _ := m["zone_id"].(string)
-      sev, _ := m["severity"].(string)
-      st, _ := m["status"].(string)
-      title, _ := m["title"].(string)
-      relAny := m["related_txn_id"]
-      var rel *string
-      if relAny != nil {
-        if rs, ok := relAny.(string); ok && rs != "" { rel = &rs }
-      }
-      details := m["details"]
-      if zid=="" || title=="" { continue }
-      if sev=="" { sev="INFO" }
-      if st=="" { st="OPEN" }
-      b, _ := json.Marshal(details)
-      if rel != nil {
-        _, _ = tx.Exec(ctx, `INSERT INTO incidents(zone_id,related_txn_id,severity,status,title,details) VALUES($1,$2::uuid,$3,$4,$5,$6::jsonb)`,
-          zid, *rel, sev, st, title, string(b))
-      } else {
-        _, _ = tx.Exec(ctx, `INSERT INTO incidents(zone_id,severity,status,title,details) VALUES($1,$2,$3,$4,$5::jsonb)`,
-          zid, sev, st, title, string(b))
-      }
-    }
-  }
-
-  // spooled transfers
-  if sp, ok := snap["spooled_transfers"].([]any); ok {
-    for _, it := range sp {
-      m, _ := it.(map[string]any)
-      req, _ := m["request_id"].(string)
-      if req == "" { continue }
-      ph, _ := m["payload_hash"].(string)
-      from, _ := m["from_account"].(string)
-      to, _ := m["to_account"].(string)
-      zid, _ := m["zone_id"].(string)
-      amtF, _ := m["amount_units"].(float64)
-      amt := int64(amtF)
-      st, _ := m["status"].(string)
-      if st == "" { st = "PENDING" }
-      failAny := m["fail_reason"]
-      var fail *string
-      if fs, ok := failAny.(string); ok && fs != "" { fail = &fs }
-      meta := m["metadata"]
-      mb, _ := json.Marshal(meta)
-
-      if fail != nil {
-        _, _ = tx.Exec(ctx, `
-          INSERT INTO spooled_transfers(request_id,payload_hash,from_account,to_account,amount_units,zone_id,metadata,status,fail_reason,updated_at)
-          VALUES($1,$2,$3,$4,$5,$6,$7::jsonb,$8,$9,now())
-          ON CONFLICT (request_id) DO NOTHING
-        `, req, ph, from, to, amt, zid, string(mb), st, *fail)
-      } else {
-        _, _ = tx.Exec(ctx, `
-          INSERT INTO spooled_transfers(request_id,payload_hash,from_account,to_account,amount_units,zone_id,metadata,status,updated_at)
-          VALUES($1,$2,$3,$4,$5,$6,$7::jsonb,$8,now())
-          ON CONFLICT (request_id) DO NOTHING
-        `, req, ph, from, to, amt, zid, string(mb), st)
-      }
-    }
-  }
-
-  // audit tail
-  if al, ok := snap["audit_log"].([]any); ok {
-    for _, it := range al {
-      m, _ := it.(map[string]any)
-      actor, _ := m["actor"].(string)
-      action, _ := m["action"].(string)
-      tt, _ := m["target_type"].(string)
-      tid, _ := m["target_id"].(string)
-      if actor=="" || action=="" || tt=="" || tid=="" { continue }
-      reasonAny := m["reason"]
-      var reason *string
-      if rs, ok := reasonAny.(string); ok && rs != "" { reason = &rs }
-      details := m["details"]
-      db, _ := json.Marshal(details)
-      if reason != nil {
-        _, _ = tx.Exec(ctx, `INSERT INTO audit_log(actor,action,target_type,target_id,reason,details,created_at) VALUES($1,$2,$3,$4,$5,$6::jsonb,now())`,
-          actor, action, tt, tid, *reason, string(db))
-      } else {
-        _, _ = tx.Exec(ctx, `INSERT INTO audit_log(actor,action,target_type,target_id,details,created_at) VALUES($1,$2,$3,$4,$5::jsonb,now())`,
-          actor, action, tt, tid, string(db))
-      }
-    }
-  }
-
-  return tx.Commit(ctx)
-}
-
-
 type BalanceRow struct {
   AccountID string    `json:"account_id"`
+  ZoneID string       `json:"zone_id"`
   BalanceUnits int64  `json:"balance_units"`
-  UpdatedAt time.Time `json:"updated_at"`
-}
-
-func (l *Ledger) ListBalances(ctx context.Context, limit int) ([]BalanceRow, error) {
-  if limit <= 0 || limit > 500 { limit = 100 }
-  rows, err := l.db.Query(ctx, `
-    SELECT account_id, balance_units, updated_at
-    FROM balances
-    ORDER BY updated_at DESC
-    LIMIT $1
-  `, limit)
-  if err != nil { return nil, err }
-  defer rows.Close()
-
-  out := []BalanceRow{}
-  for rows.Next() {
-    var b BalanceRow
-    if err := rows.Scan(&b.AccountID, &b.BalanceUnits, &b.UpdatedAt); err != nil { return nil, err }
-    out = append(out, b)
-  }
-  return out, nil
+  UpdatedAt time.Time `json:"updated_at"` // with as_of: the account's last posting by then
 }
 
 type TransactionRow struct {
@@ -631,9 +482,23 @@ type TransactionRow struct {
   ToAccount string `json:"to_account"`
   AmountUnits int64 `json:"amount_units"`
   ZoneID string `json:"zone_id"`
+  Type string `json:"type"`
+  FromZoneID string `json:"from_zone_id"` // zones of the two accounts
+  ToZoneID string `json:"to_zone_id"`
+  ZoneSeq int64 `json:"zone_seq"` // position in zone_id's sequence
   CreatedAt time.Time `json:"created_at"`
 }
 
+const transactionRowCols = `id::text, request_id, from_account, to_account, amount_units, zone_id, type, from_zone_id, to_zone_id, zone_seq, created_at`
+
+// scanTransactionRow scans transactionRowCols, then extra.
+func scanTransactionRow(row pgx.Row, extra ...any) (*TransactionRow, error) {
+  var t TransactionRow
+  dest := append([]any{&t.ID, &t.RequestID, &t.FromAccount, &t.ToAccount, &t.AmountUnits, &t.ZoneID, &t.Type, &t.FromZoneID, &t.ToZoneID, &t.ZoneSeq, &t.CreatedAt}, extra...)
+  if err := row.Scan(dest...); err != nil { return nil, err }
+  return &t, nil
+}
+
 type PostingRow struct {
   AccountID string `json:"account_id"`
   Direction string `json:"direction"`
@@ -644,37 +509,92 @@ type TransactionDetail struct {
   TransactionRow
   Metadata map[string]any `json:"metadata"`
   Postings []PostingRow `json:"postings"`
+  Annotations []Annotation `json:"annotations"`
 }
 
-func (l *Ledger) ListTransactions(ctx context.Context, limit int) ([]TransactionRow, error) {
-  if limit <= 0 || limit > 500 { limit = 100 }
-  rows, err := l.db.Query(ctx, `
-    SELECT id::text, request_id, from_account, to_account, amount_units, zone_id, created_at
-    FROM transactions
+// TransactionFilter narrows ListTransactions; zero values do not filter.
+type TransactionFilter struct {
+  Limit int // 1-500, default 100
+  Tag string // carrying an annotation with this tag
+  AccountTag string // from or to an account carrying this tag
+  Type string // of this transaction type
+}
+
+// ListTransactions lists transactions newest first.
+func (l *Ledger) ListTransactions(ctx context.Context, f TransactionFilter) ([]TransactionRow, error) {
+  if f.Limit <= 0 || f.Limit > 500 { f.Limit = 100 }
+  if f.Type != "" && !knownTransactionType(f.Type) { return nil, fmt.Errorf("%w %q", ErrInvalidTransactionType, f.Type) }
+  rows, err := l.ro.Query(ctx, `
+    SELECT `+transactionRowCols+`
+    FROM transactions t
+    WHERE ($2='' OR EXISTS (SELECT 1 FROM transaction_annotations a WHERE a.txn_id=t.id AND a.tags @> ARRAY[$2]))
+      AND ($3='' OR EXISTS (SELECT 1 FROM account_tags g WHERE g.account_id IN (t.from_account, t.to_account) AND g.tag=$3))
+      AND ($4='' OR t.type=$4)
     ORDER BY created_at DESC
     LIMIT $1
-  `, limit)
+  `, f.Limit, strings.ToLower(f.Tag), strings.ToLower(f.AccountTag), f.Type)
   if err != nil { return nil, err }
   defer rows.Close()
 
   out := []TransactionRow{}
   for rows.Next() {
-    var t TransactionRow
-    if err := rows.Scan(&t.ID, &t.RequestID, &t.FromAccount, &t.ToAccount, &t.AmountUnits, &t.ZoneID, &t.CreatedAt); err != nil { return nil, err }
-    out = append(out, t)
+    t, err := scanTransactionRow(rows)
+    if err != nil { return nil, err }
+    out = append(out, *t)
   }
   return out, nil
 }
 
+// ZoneTransactionPage is a page of a zone's transactions in sequence order.
+type ZoneTransactionPage struct {
+  ZoneID string `json:"zone_id"`
+  Transactions []TransactionRow `json:"transactions"`
+  LastSeq int64 `json:"last_seq"` // after_seq for the next page
+  HeadSeq int64 `json:"head_seq"` // the zone's latest committed seq
+}
+
+// ListZoneTransactions returns up to limit transactions of the zone with a
+// zone_seq above afterSeq, in sequence order. Numbers are allocated under a
+// per-zone lock held until commit, so a page never skips a transaction that
+// commits later: paging on LastSeq until it reaches HeadSeq syncs the zone
+// without gaps.
+func (l *Ledger) ListZoneTransactions(ctx context.Context, zoneID string, afterSeq int64, limit int) (*ZoneTransactionPage, error) {
+  if limit <= 0 || limit > 1000 { limit = 100 }
+  page := ZoneTransactionPage{ZoneID: zoneID, Transactions: []TransactionRow{}, LastSeq: afterSeq}
+  err := l.ro.QueryRow(ctx, `
+    SELECT COALESCE((SELECT last_seq FROM zone_sequences s WHERE s.zone_id=z.id), 0) FROM zones z WHERE z.id=$1
+  `, zoneID).Scan(&page.HeadSeq)
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrZoneNotFound }
+  if err != nil { return nil, err }
+
+  rows, err := l.ro.Query(ctx, `
+    SELECT `+transactionRowCols+` FROM transactions WHERE zone_id=$1 AND zone_seq > $2 ORDER BY zone_seq LIMIT $3
+  `, zoneID, afterSeq, limit)
+  if err != nil { return nil, err }
+  defer rows.Close()
+  for rows.Next() {
+    t, err := scanTransactionRow(rows)
+    if err != nil { return nil, err }
+    page.Transactions = append(page.Transactions, *t)
+    page.LastSeq = t.ZoneSeq
+  }
+  if err := rows.Err(); err != nil { return nil, err }
+  // the head was read first; a page can run past it
+  if page.LastSeq > page.HeadSeq { page.HeadSeq = page.LastSeq }
+  return &page, nil
+}
+
 func (l *Ledger) GetTransaction(ctx context.Context, id string) (*TransactionDetail, error) {
   var t TransactionDetail
   var metaBytes []byte
-  err := l.db.QueryRow(ctx, `
-    SELECT id::text, request_id, from_account, to_account, amount_units, zone_id, created_at, metadata
+  row, err := scanTransactionRow(l.db.QueryRow(ctx, `
+    SELECT `+transactionRowCols+`, metadata
     FROM transactions
     WHERE id::text = $1
-  `, id).Scan(&t.ID, &t.RequestID, &t.FromAccount, &t.ToAccount, &t.AmountUnits, &t.ZoneID, &t.CreatedAt, &metaBytes)
+  `, id), &metaBytes)
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrTransactionNotFound }
   if err != nil { return nil, err }
+  t.TransactionRow = *row
   _ = json.Unmarshal(metaBytes, &t.Metadata)
 
   rows, err := l.db.Query(ctx, `
@@ -693,6 +613,7 @@ func (l *Ledger) GetTransaction(ctx context.Context, id string) (*TransactionDet
     posts = append(posts, p)
   }
   t.Postings = posts
+  if t.Annotations, err = l.listAnnotations(ctx, l.db, id); err != nil { return nil, err }
   return &t, nil
 }
 
@@ -705,107 +626,88 @@ func (l *Ledger) hashPercent(s string) int {
   return int(h.Sum32() % 100)
 }
 
-func (l *Ledger) getZoneControlsTx(ctx context.Context, tx pgx.Tx, zoneID string) (*ZoneControls, error) {
-  // ensure row exists
-  _, _ = tx.Exec(ctx, `INSERT INTO zone_controls(zone_id) VALUES($1) ON CONFLICT DO NOTHING`, zoneID)
-  var c ZoneControls
-  err := tx.QueryRow(ctx, `
-    SELECT zone_id, writes_blocked, cross_zone_throttle, spool_enabled, updated_at
-    FROM zone_controls
-    WHERE zone_id=$1
-  `, zoneID).Scan(&c.ZoneID, &c.WritesBlocked, &c.CrossZoneThrottle, &c.SpoolEnabled, &c.UpdatedAt)
-  if err != nil {
-    return nil, err
-  }
-  return &c, nil
-}
-
-func (l *Ledger) spoolTransferTx(ctx context.Context, tx pgx.Tx, in CreateTransferInput, metaBytes []byte, failReason string) (string, error) {
-  // idempotency within spool table
-  var existingID string
-  var existingHash string
-  err := tx.QueryRow(ctx, `SELECT id::text, payload_hash FROM spooled_transfers WHERE request_id=$1`, in.RequestID).
-    Scan(&existingID, &existingHash)
-  if err == nil {
-    if existingHash != in.PayloadHash {
-      return "", ErrIdempotencyConflict
-    }
-    return existingID, nil
-  }
-  if err != nil && !errors.Is(err, pgx.ErrNoRows) {
-    return "", err
-  }
-
-  var id string
-  err = tx.QueryRow(ctx, `
-    INSERT INTO spooled_transfers(request_id,payload_hash,from_account,to_account,amount_units,zone_id,metadata,status,fail_reason,updated_at)
-    VALUES($1,$2,$3,$4,$5,$6,$7::jsonb,'PENDING',$8,now())
-    RETURNING id::text
-  `, in.RequestID, in.PayloadHash, in.FromAccount, in.ToAccount, in.AmountUnits, in.ZoneID, string(metaBytes), failReason).Scan(&id)
+func (l *Ledger) spoolTransfer(ctx context.Context, q Queries, in CreateTransferInput, metaBytes []byte, failReason string) (string, error) {
+  // the caller checked for an earlier entry; a concurrent one makes this
+  // insert return ErrRequestExists
+  id, err := q.InsertSpooled(ctx, in, metaBytes, failReason, l.clock.Now())
+  if err != nil { return "", err }
+  err = l.spoolEvent(ctx, q, "TRANSFER_SPOOLED", SpooledTransfer{
+    ID: id, RequestID: in.RequestID, FromAccount: in.FromAccount, ToAccount: in.ToAccount, AmountUnits: in.AmountUnits, ZoneID: in.ZoneID,
+    Type: transactionType(in), FailReason: failReason,
+  }, nil)
   if err != nil { return "", err }
 
-  _, _ = tx.Exec(ctx, `
-    INSERT INTO audit_log(actor,action,target_type,target_id,reason,details)
-    VALUES('system','SPOOL_TRANSFER','zone',$1,$2, jsonb_build_object('request_id',$3,'spool_id',$4))
-  `, in.ZoneID, failReason, in.RequestID, id)
+  _ = l.audit(ctx, q, AuditRecord{
+    Actor: "system", Action: "SPOOL_TRANSFER", TargetType: "zone", TargetID: in.ZoneID, Reason: failReason,
+    Details: map[string]any{"request_id": in.RequestID, "spool_id": id},
+  })
 
   return id, nil
 }
 
-func (l *Ledger) applyTransferTx(ctx context.Context, tx pgx.Tx, in CreateTransferInput, metaBytes []byte) (string, time.Time, error) {
-  var txnID string
-  var createdAt time.Time
-  err := tx.QueryRow(ctx, `
-    INSERT INTO transactions(request_id,payload_hash,from_account,to_account,amount_units,zone_id,metadata)
-    VALUES($1,$2,$3,$4,$5,$6,$7::jsonb)
-    RETURNING id::text, created_at
-  `, in.RequestID, in.PayloadHash, in.FromAccount, in.ToAccount, in.AmountUnits, in.ZoneID, string(metaBytes)).Scan(&txnID, &createdAt)
-  if err != nil { return "", time.Time{}, err }
-
-  // postings
-  _, err = tx.Exec(ctx, `
-    INSERT INTO postings(txn_id,account_id,direction,amount_units)
-    VALUES($1::uuid,$2,'DEBIT',$3),
-          ($1::uuid,$4,'CREDIT',$3)
-  `, txnID, in.FromAccount, in.AmountUnits, in.ToAccount)
-  if err != nil { return "", time.Time{}, err }
-
-  // balance projection (allow negative; this is a sim)
-  _, err = tx.Exec(ctx, `
-    INSERT INTO balances(account_id,balance_units,updated_at)
-    VALUES($1,$2,now())
-    ON CONFLICT (account_id) DO UPDATE
-      SET balance_units = balances.balance_units + EXCLUDED.balance_units,
-          updated_at = now()
-  `, in.FromAccount, -in.AmountUnits)
-  if err != nil { return "", time.Time{}, err }
-
-  _, err = tx.Exec(ctx, `
-    INSERT INTO balances(account_id,balance_units,updated_at)
-    VALUES($1,$2,now())
-    ON CONFLICT (account_id) DO UPDATE
-      SET balance_units = balances.balance_units + EXCLUDED.balance_units,
-          updated_at = now()
-  `, in.ToAccount, in.AmountUnits)
-  if err != nil { return "", time.Time{}, err }
-
-  // transactional outbox event => JetStream => fraud consumer
+// spoolEvent writes a spool lifecycle outbox event (TRANSFER_SPOOLED,
+// SPOOL_ITEM_APPLIED or SPOOL_ITEM_FAILED) so consumers can follow the
+// backlog drain; extra adds event-specific fields to the payload.
+func (l *Ledger) spoolEvent(ctx context.Context, q Queries, eventType string, s SpooledTransfer, extra map[string]any) error {
+  payload := map[string]any{
+    "event_id": "generated_by_db",
+    "spool_id": s.ID,
+    "request_id": s.RequestID,
+    "zone_id": s.ZoneID,
+    "from_account": s.FromAccount,
+    "to_account": s.ToAccount,
+    "amount_units": s.AmountUnits,
+    "type": s.Type,
+    "spool_reason": s.FailReason,
+    "at": l.clock.Now().UTC().Format(time.RFC3339Nano),
+  }
+  for k, v := range extra { payload[k] = v }
+  pb, _ := json.Marshal(payload)
+  return q.InsertOutbox(ctx, OutboxEvent{
+    EventType: eventType, AggregateType: "spool", AggregateID: s.ID, Payload: pb,
+    RequestID: logging.RequestID(ctx), TraceContext: tracing.Carrier(ctx),
+  })
+}
+
+// applyTransfer records the accounts, the transaction, its two postings, the
+// balance projection and the TRANSFER_POSTED outbox event in one
+// Queries.PostTransfer call. Timestamps come from the zone's (possibly
+// skewed) local clock, skewMs.
+func (l *Ledger) applyTransfer(ctx context.Context, q Queries, in CreateTransferInput, metaBytes []byte, skewMs int64) (*Transaction, error) {
+  at := l.clock.Now()
+  if !in.At.IsZero() { at = in.At }
+  // Postgres keeps microseconds; truncate so the event and response carry the stored value
+  createdAt := zoneTime(at, skewMs).Truncate(time.Microsecond)
+  txnID := uuid.NewString()
+
+  // transactional outbox event => JetStream => fraud consumer and balance
+  // notifier. Accounts unknown so far are created in the transfer's zone, so
+  // they never add to_zone_id. PostTransfer adds zone_seq and both balances.
   payload := map[string]any{
     "event_id": "generated_by_db",
     "transaction_id": txnID,
     "zone_id": in.ZoneID,
+    "from_account": in.FromAccount,
+    "to_account": in.ToAccount,
     "amount_units": in.AmountUnits,
+    "type": transactionType(in),
     "created_at": createdAt.UTC().Format(time.RFC3339Nano),
   }
+  toZone, err := q.AccountZone(ctx, in.ToAccount)
+  if err != nil { return nil, err }
+  if toZone != "" && toZone != in.ZoneID { payload["to_zone_id"] = toZone }
+  for k, v := range in.EventContext { payload[k] = v }
   pb, _ := json.Marshal(payload)
 
-  _, err = tx.Exec(ctx, `
-    INSERT INTO outbox_events(event_type,aggregate_type,aggregate_id,payload)
-    VALUES('TRANSFER_POSTED','transaction',$1,$2::jsonb)
-  `, txnID, string(pb))
-  if err != nil { return "", time.Time{}, err }
-
-  return txnID, createdAt, nil
+  seq, err := q.PostTransfer(ctx, PostedTransfer{
+    ID: txnID, In: in, Metadata: metaBytes, CreatedAt: createdAt, ClockSkewMs: skewMs,
+    Event: OutboxEvent{
+      EventType: "TRANSFER_POSTED", AggregateType: "transaction", AggregateID: txnID, Payload: pb,
+      RequestID: logging.RequestID(ctx), TraceContext: tracing.Carrier(ctx),
+    },
+  })
+  if err != nil { return nil, err }
+  return &Transaction{ID: txnID, RequestID: in.RequestID, CreatedAt: createdAt, ZoneSeq: seq}, nil
 }
 
 // ApplyTransferBypass applies a transfer without zone gating (used for spool replay).
@@ -814,33 +716,33 @@ func (l *Ledger) ApplyTransferBypass(ctx context.Context, in CreateTransferInput
   metaBytes, err := json.Marshal(in.Metadata)
   if err != nil { return nil, err }
 
-  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
-  if err != nil { return nil, err }
-  defer func() { _ = tx.Rollback(ctx) }()
-
-  // idempotency
-  var existingID string
-  var existingHash string
-  var createdAt time.Time
-  err = tx.QueryRow(ctx, `SELECT id::text,payload_hash,created_at FROM transactions WHERE request_id=$1`, in.RequestID).
-    Scan(&existingID, &existingHash, &createdAt)
-  if err == nil {
-    if existingHash != in.PayloadHash {
-      return nil, ErrIdempotencyConflict
+  var txn *Transaction
+  err = l.inTransferTx(ctx, func(q Queries) error {
+    // idempotency
+    existing, err := recordedTransaction(ctx, q, in)
+    if existing != nil || err != nil { txn = existing; return err }
+    if err := l.checkAccountsExist(ctx, q, in); err != nil { return err }
+
+    skewMs, err := zoneClockSkew(ctx, q, in.ZoneID)
+    if err != nil { return err }
+    txn, err = l.applyTransfer(ctx, q, in, metaBytes, skewMs)
+    if errors.Is(err, ErrRequestExists) {
+      // a concurrent replay of the same entry won the insert and has committed
+      txn, err = recordedTransaction(ctx, q, in)
+      if txn == nil && err == nil { err = ErrRequestExists }
+      return err
     }
-    _ = tx.Commit(ctx)
-    return &Transaction{ID: existingID, RequestID: in.RequestID, CreatedAt: createdAt}, nil
-  }
-  if err != nil && !errors.Is(err, pgx.ErrNoRows) {
-    return nil, err
-  }
-
-  if err := l.ensureAccount(ctx, tx, in.FromAccount, in.ZoneID); err != nil { return nil, err }
-  if err := l.ensureAccount(ctx, tx, in.ToAccount, in.ZoneID); err != nil { return nil, err }
-
-  txnID, createdAt, err := l.applyTransferTx(ctx, tx, in, metaBytes)
+    return err
+  })
   if err != nil { return nil, err }
+  return txn, nil
+}
 
-  if err := tx.Commit(ctx); err != nil { return nil, err }
-  return &Transaction{ID: txnID, RequestID: in.RequestID, CreatedAt: createdAt}, nil
+// recordedTransaction returns the transaction already recorded for the
+// request, nil if there is none, or ErrIdempotencyConflict.
+func recordedTransaction(ctx context.Context, q Queries, in CreateTransferInput) (*Transaction, error) {
+  existing, existingHash, err := q.FindTransaction(ctx, in.RequestID)
+  if err != nil || existing == nil { return nil, err }
+  if existingHash != in.PayloadHash { return nil, ErrIdempotencyConflict }
+  return existing, nil
 }
@@ -0,0 +1,428 @@
+// Package ledgertest provides an in-memory ledger.Repo for unit tests of the
+// transfer, spool-replay and handler paths without Postgres.
+package ledgertest
+
+import (
+  "context"
+  "encoding/json"
+  "fmt"
+  "maps"
+  "slices"
+  "sync"
+  "sync/atomic"
+  "time"
+
+  "github.com/jackc/pgx/v5/pgconn"
+
+  "time-ledger-sim/go/internal/ledger"
+)
+
+// MemRepo is an in-memory ledger.Repo. InTx works on a copy and applies its
+// writes on success, so a failed transfer leaves no trace; a transaction
+// started inside another commits on its own, as it does on Postgres. It does
+// not model row locks or concurrent isolation; use FailCommits to exercise
+// serialization-failure retries.
+type MemRepo struct {
+  memQueries
+  mu sync.Mutex
+  st *state
+  seq atomic.Int64
+  failCommits int
+  isolations []ledger.Isolation
+}
+
+var _ ledger.Repo = (*MemRepo)(nil)
+
+// Txn is a recorded transaction.
+type Txn struct {
+  ID string
+  In ledger.CreateTransferInput
+  Metadata []byte
+  CreatedAt time.Time
+  ClockSkewMs int64
+  ZoneSeq int64
+}
+
+// Posting is one leg of a recorded transaction.
+type Posting struct {
+  TxnID string
+  AccountID string
+  Direction string
+  AmountUnits int64
+}
+
+// Spooled is a spool entry with its status.
+type Spooled struct {
+  ledger.SpooledTransfer
+  Status string // PENDING, APPLIED or FAILED
+  CreatedAt time.Time
+}
+
+type zone struct {
+  status string
+  retired bool
+}
+
+type bucket struct {
+  tokens float64
+  at time.Time
+}
+
+type state struct {
+  zones map[string]zone
+  zoneControls map[string]ledger.ZoneControls
+  accountControls map[string]ledger.AccountControls
+  accounts map[string]string // id -> zone
+  partitions map[[2]string]ledger.Partition
+  buckets map[string]bucket
+  txns []Txn
+  postings []Posting
+  balances map[string]int64
+  outbox []ledger.OutboxEvent
+  audit []ledger.AuditRecord
+  spool []Spooled
+}
+
+// NewMemRepo returns an empty repo with the given zones, all OK.
+func NewMemRepo(zoneIDs ...string) *MemRepo {
+  r := &MemRepo{st: &state{
+    zones: map[string]zone{},
+    zoneControls: map[string]ledger.ZoneControls{},
+    accountControls: map[string]ledger.AccountControls{},
+    accounts: map[string]string{},
+    partitions: map[[2]string]ledger.Partition{},
+    buckets: map[string]bucket{},
+    balances: map[string]int64{},
+  }}
+  for _, id := range zoneIDs { r.st.zones[id] = zone{status: "OK"} }
+  r.memQueries = memQueries{r: r, st: r.st, mu: &r.mu}
+  return r
+}
+
+func (s *state) clone() *state {
+  return &state{
+    zones: maps.Clone(s.zones),
+    zoneControls: maps.Clone(s.zoneControls),
+    accountControls: maps.Clone(s.accountControls),
+    accounts: maps.Clone(s.accounts),
+    partitions: maps.Clone(s.partitions),
+    buckets: maps.Clone(s.buckets),
+    txns: slices.Clone(s.txns),
+    postings: slices.Clone(s.postings),
+    balances: maps.Clone(s.balances),
+    outbox: slices.Clone(s.outbox),
+    audit: slices.Clone(s.audit),
+    spool: slices.Clone(s.spool),
+  }
+}
+
+func (r *MemRepo) InTx(ctx context.Context, fn func(q ledger.Queries) error) error {
+  return r.InTxIso(ctx, ledger.IsolationReadCommitted, fn)
+}
+
+// InTxIso records iso (see Isolations) and otherwise behaves like InTx.
+func (r *MemRepo) InTxIso(ctx context.Context, iso ledger.Isolation, fn func(q ledger.Queries) error) error {
+  r.mu.Lock()
+  r.isolations = append(r.isolations, iso)
+  tx := memQueries{r: r, st: r.st.clone(), ops: new([]func(*state))}
+  r.mu.Unlock()
+  if err := fn(tx); err != nil { return err }
+  r.mu.Lock()
+  defer r.mu.Unlock()
+  if r.failCommits > 0 {
+    r.failCommits--
+    return &pgconn.PgError{Code: "40001", Message: "could not serialize access due to read/write dependencies among transactions"}
+  }
+  for _, op := range *tx.ops { op(r.st) }
+  return nil
+}
+
+func (r *MemRepo) newID(prefix string) string { return fmt.Sprintf("%s-%d", prefix, r.seq.Add(1)) }
+
+// --- setup ---
+
+// FailCommits makes the next n transactions fail to commit with a
+// serialization failure (SQLSTATE 40001), discarding their writes.
+func (r *MemRepo) FailCommits(n int) { r.mu.Lock(); defer r.mu.Unlock(); r.failCommits = n }
+
+// SetZoneStatus sets a zone's status (OK, DEGRADED, DOWN), adding the zone if needed.
+func (r *MemRepo) SetZoneStatus(zoneID, status string) {
+  r.mu.Lock()
+  defer r.mu.Unlock()
+  z := r.st.zones[zoneID]
+  z.status = status
+  r.st.zones[zoneID] = z
+}
+
+// RetireZone marks a zone retired; it then reads as unknown.
+func (r *MemRepo) RetireZone(zoneID string) {
+  r.mu.Lock()
+  defer r.mu.Unlock()
+  z := r.st.zones[zoneID]
+  z.retired = true
+  r.st.zones[zoneID] = z
+}
+
+// SetZoneControls replaces a zone's controls.
+func (r *MemRepo) SetZoneControls(c ledger.ZoneControls) {
+  r.mu.Lock()
+  defer r.mu.Unlock()
+  if c.ThrottleMode == "" { c.ThrottleMode = ledger.ThrottleModeHash }
+  r.st.zoneControls[c.ZoneID] = c
+}
+
+func (r *MemRepo) SetAccountControls(c ledger.AccountControls) {
+  r.mu.Lock()
+  defer r.mu.Unlock()
+  r.st.accountControls[c.AccountID] = c
+}
+
+// AddAccount places an account in a zone.
+func (r *MemRepo) AddAccount(accountID, zoneID string) {
+  r.mu.Lock()
+  defer r.mu.Unlock()
+  r.st.accounts[accountID] = zoneID
+}
+
+// AddPartition cuts from -> to.
+func (r *MemRepo) AddPartition(from, to, mode string) {
+  r.mu.Lock()
+  defer r.mu.Unlock()
+  r.st.partitions[[2]string{from, to}] = ledger.Partition{FromZone: from, ToZone: to, Mode: mode, Actor: "test", CreatedAt: time.Now()}
+}
+
+// HealPartition removes the from -> to partition.
+func (r *MemRepo) HealPartition(from, to string) {
+  r.mu.Lock()
+  defer r.mu.Unlock()
+  delete(r.st.partitions, [2]string{from, to})
+}
+
+// --- inspection ---
+
+func (r *MemRepo) Transactions() []Txn { r.mu.Lock(); defer r.mu.Unlock(); return slices.Clone(r.st.txns) }
+func (r *MemRepo) Postings() []Posting { r.mu.Lock(); defer r.mu.Unlock(); return slices.Clone(r.st.postings) }
+func (r *MemRepo) Balances() map[string]int64 { r.mu.Lock(); defer r.mu.Unlock(); return maps.Clone(r.st.balances) }
+func (r *MemRepo) Outbox() []ledger.OutboxEvent { r.mu.Lock(); defer r.mu.Unlock(); return slices.Clone(r.st.outbox) }
+func (r *MemRepo) Audit() []ledger.AuditRecord { r.mu.Lock(); defer r.mu.Unlock(); return slices.Clone(r.st.audit) }
+func (r *MemRepo) Spool() []Spooled { r.mu.Lock(); defer r.mu.Unlock(); return slices.Clone(r.st.spool) }
+// Isolations lists the isolation level of every transaction begun, in order.
+func (r *MemRepo) Isolations() []ledger.Isolation { r.mu.Lock(); defer r.mu.Unlock(); return slices.Clone(r.isolations) }
+
+// memQueries reads and writes st. Outside a transaction mu guards st; inside
+// one st is the transaction's copy and writes are also queued in ops.
+type memQueries struct {
+  r *MemRepo
+  st *state
+  mu *sync.Mutex // nil inside InTx
+  ops *[]func(*state) // nil outside InTx
+}
+
+func (q memQueries) lock() func() {
+  if q.mu == nil { return func() {} }
+  q.mu.Lock()
+  return q.mu.Unlock
+}
+
+// write applies op now and, inside a transaction, again on commit.
+func (q memQueries) write(op func(*state)) {
+  op(q.st)
+  if q.ops != nil { *q.ops = append(*q.ops, op) }
+}
+
+func (q memQueries) ZoneStatus(ctx context.Context, zoneID string) (string, error) {
+  defer q.lock()()
+  z, ok := q.st.zones[zoneID]
+  if !ok || z.retired { return "", ledger.ErrZoneNotFound }
+  return z.status, nil
+}
+
+func (q memQueries) ZoneControls(ctx context.Context, zoneID string) (*ledger.ZoneControls, error) {
+  defer q.lock()()
+  c, ok := q.st.zoneControls[zoneID]
+  if !ok {
+    if _, known := q.st.zones[zoneID]; !known { return nil, fmt.Errorf("zone_controls: unknown zone %s", zoneID) }
+    c = ledger.ZoneControls{ZoneID: zoneID, CrossZoneThrottle: 100, ThrottleMode: ledger.ThrottleModeHash, UpdatedAt: time.Now()}
+    q.write(func(s *state) { s.zoneControls[zoneID] = c })
+  }
+  return &c, nil
+}
+
+func (q memQueries) FindZoneControls(ctx context.Context, zoneID string) (*ledger.ZoneControls, error) {
+  defer q.lock()()
+  c, ok := q.st.zoneControls[zoneID]
+  if !ok { return nil, nil }
+  return &c, nil
+}
+
+func (q memQueries) AccountControls(ctx context.Context, accountIDs ...string) ([]ledger.AccountControls, error) {
+  defer q.lock()()
+  var out []ledger.AccountControls
+  for _, id := range accountIDs {
+    if c, ok := q.st.accountControls[id]; ok { out = append(out, c) }
+  }
+  return out, nil
+}
+
+func (q memQueries) AccountZone(ctx context.Context, accountID string) (string, error) {
+  defer q.lock()()
+  return q.st.accounts[accountID], nil
+}
+
+func (q memQueries) Balance(ctx context.Context, accountID string) (int64, error) {
+  defer q.lock()()
+  return q.st.balances[accountID], nil
+}
+
+func (q memQueries) DebitedSince(ctx context.Context, accountID string, since time.Time) (int64, error) {
+  defer q.lock()()
+  var n int64
+  for _, t := range q.st.txns {
+    if t.In.FromAccount == accountID && !t.CreatedAt.Before(since) { n += t.In.AmountUnits }
+  }
+  return n, nil
+}
+
+// HeldUnits is always 0: prepares are not modelled.
+func (q memQueries) HeldUnits(ctx context.Context, accountID, exceptRequest string, at time.Time) (int64, error) {
+  return 0, nil
+}
+
+func (q memQueries) Partition(ctx context.Context, fromZone, toZone string) (*ledger.Partition, error) {
+  defer q.lock()()
+  p, ok := q.st.partitions[[2]string{fromZone, toZone}]
+  if !ok { return nil, nil }
+  return &p, nil
+}
+
+func (q memQueries) LockRateBucket(ctx context.Context, zoneID string, tokens float64, now time.Time) (float64, time.Time, error) {
+  defer q.lock()()
+  b, ok := q.st.buckets[zoneID]
+  if !ok {
+    b = bucket{tokens: tokens, at: now}
+    q.write(func(s *state) { s.buckets[zoneID] = b })
+  }
+  return b.tokens, b.at, nil
+}
+
+func (q memQueries) SaveRateBucket(ctx context.Context, zoneID string, tokens float64, at time.Time) error {
+  defer q.lock()()
+  q.write(func(s *state) { s.buckets[zoneID] = bucket{tokens: tokens, at: at} })
+  return nil
+}
+
+func (q memQueries) FindTransaction(ctx context.Context, requestID string) (*ledger.Transaction, string, error) {
+  defer q.lock()()
+  for _, t := range q.st.txns {
+    if t.In.RequestID == requestID {
+      return &ledger.Transaction{ID: t.ID, RequestID: requestID, CreatedAt: t.CreatedAt, ZoneSeq: t.ZoneSeq}, t.In.PayloadHash, nil
+    }
+  }
+  return nil, "", nil
+}
+
+func (q memQueries) FindSpooled(ctx context.Context, requestID string) (string, string, error) {
+  defer q.lock()()
+  for _, s := range q.st.spool {
+    if s.RequestID == requestID { return s.ID, s.PayloadHash, nil }
+  }
+  return "", "", nil
+}
+
+func (q memQueries) EnsureAccount(ctx context.Context, accountID, zoneID string) error {
+  defer q.lock()()
+  if _, ok := q.st.accounts[accountID]; !ok {
+    q.write(func(s *state) {
+      if _, ok := s.accounts[accountID]; !ok { s.accounts[accountID] = zoneID }
+    })
+  }
+  return nil
+}
+
+func (q memQueries) PostTransfer(ctx context.Context, t ledger.PostedTransfer) (int64, error) {
+  defer q.lock()()
+  if z, ok := q.st.zones[t.In.ZoneID]; !ok || z.retired { return 0, ledger.ErrZoneNotFound }
+  seq := int64(1)
+  for _, x := range q.st.txns {
+    if x.In.RequestID == t.In.RequestID { return 0, ledger.ErrRequestExists }
+    if x.In.ZoneID == t.In.ZoneID { seq++ }
+  }
+  in := t.In
+  ev := t.Event
+  var payload map[string]any
+  if err := json.Unmarshal(ev.Payload, &payload); err != nil { return 0, err }
+  payload["zone_seq"] = seq
+  from, to := q.st.balances[in.FromAccount]-in.AmountUnits, q.st.balances[in.ToAccount]+in.AmountUnits
+  if in.FromAccount == in.ToAccount { from, to = q.st.balances[in.FromAccount], q.st.balances[in.ToAccount] }
+  payload["from_balance_units"], payload["to_balance_units"] = from, to
+  ev.Payload, _ = json.Marshal(payload)
+  q.write(func(s *state) {
+    for _, id := range []string{in.FromAccount, in.ToAccount} {
+      if _, ok := s.accounts[id]; !ok { s.accounts[id] = in.ZoneID }
+    }
+    s.txns = append(s.txns, Txn{ID: t.ID, In: in, Metadata: slices.Clone(t.Metadata), CreatedAt: t.CreatedAt, ClockSkewMs: t.ClockSkewMs, ZoneSeq: seq})
+    s.postings = append(s.postings,
+      Posting{TxnID: t.ID, AccountID: in.FromAccount, Direction: "DEBIT", AmountUnits: in.AmountUnits},
+      Posting{TxnID: t.ID, AccountID: in.ToAccount, Direction: "CREDIT", AmountUnits: in.AmountUnits})
+    s.balances[in.FromAccount] -= in.AmountUnits
+    s.balances[in.ToAccount] += in.AmountUnits
+    s.outbox = append(s.outbox, ev)
+  })
+  return seq, nil
+}
+
+func (q memQueries) InsertOutbox(ctx context.Context, ev ledger.OutboxEvent) error {
+  defer q.lock()()
+  q.write(func(s *state) { s.outbox = append(s.outbox, ev) })
+  return nil
+}
+
+func (q memQueries) InsertAudit(ctx context.Context, a ledger.AuditRecord) error {
+  defer q.lock()()
+  q.write(func(s *state) { s.audit = append(s.audit, a) })
+  return nil
+}
+
+func (q memQueries) InsertSpooled(ctx context.Context, in ledger.CreateTransferInput, metadata []byte, failReason string, at time.Time) (string, error) {
+  defer q.lock()()
+  if z, ok := q.st.zones[in.ZoneID]; !ok || z.retired { return "", ledger.ErrZoneNotFound }
+  for _, x := range q.st.spool {
+    if x.RequestID == in.RequestID { return "", ledger.ErrRequestExists }
+  }
+  e := Spooled{SpooledTransfer: ledger.SpooledTransfer{
+    ID: q.r.newID("spool"), RequestID: in.RequestID, PayloadHash: in.PayloadHash, FromAccount: in.FromAccount, ToAccount: in.ToAccount,
+    AmountUnits: in.AmountUnits, ZoneID: in.ZoneID, Type: in.Type, Metadata: slices.Clone(metadata), FailReason: failReason,
+  }, Status: "PENDING", CreatedAt: at}
+  q.write(func(s *state) { s.spool = append(s.spool, e) })
+  return e.ID, nil
+}
+
+func (q memQueries) PendingSpool(ctx context.Context, zoneID string, limit int) ([]ledger.SpooledTransfer, error) {
+  defer q.lock()()
+  pending := slices.DeleteFunc(slices.Clone(q.st.spool), func(s Spooled) bool { return s.ZoneID != zoneID || s.Status != "PENDING" })
+  slices.SortStableFunc(pending, func(a, b Spooled) int { return a.CreatedAt.Compare(b.CreatedAt) })
+  var out []ledger.SpooledTransfer
+  for _, s := range pending {
+    if len(out) == limit { break }
+    out = append(out, s.SpooledTransfer)
+  }
+  return out, nil
+}
+
+func (q memQueries) MarkSpoolApplied(ctx context.Context, id string) error {
+  defer q.lock()()
+  q.write(func(s *state) { setSpool(s, id, "APPLIED", "") })
+  return nil
+}
+
+func (q memQueries) MarkSpoolFailed(ctx context.Context, id, reason string) error {
+  defer q.lock()()
+  q.write(func(s *state) { setSpool(s, id, "FAILED", reason) })
+  return nil
+}
+
+func setSpool(s *state, id, status, reason string) {
+  for i := range s.spool {
+    if s.spool[i].ID == id { s.spool[i].Status, s.spool[i].FailReason = status, reason }
+  }
+}
@@ -0,0 +1,73 @@
+package ledger
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "time"
+)
+
+var (
+  ErrAmountBelowMinimum = errors.New("amount below the zone's minimum")
+  ErrAmountAboveMaximum = errors.New("amount above the zone's maximum")
+  ErrDailyLimitExceeded = errors.New("daily account limit exceeded")
+)
+
+func IsAmountBelowMinimum(err error) bool { return errors.Is(err, ErrAmountBelowMinimum) }
+func IsAmountAboveMaximum(err error) bool { return errors.Is(err, ErrAmountAboveMaximum) }
+func IsDailyLimitExceeded(err error) bool { return errors.Is(err, ErrDailyLimitExceeded) }
+
+func isAmountLimit(err error) bool {
+  return IsAmountBelowMinimum(err) || IsAmountAboveMaximum(err) || IsDailyLimitExceeded(err)
+}
+
+// LimitOverride lets a transfer past its zone's amount limits. The API only
+// takes one with the admin key; each override that lets a transfer through is
+// audited as OVERRIDE_AMOUNT_LIMIT.
+type LimitOverride struct {
+  Actor string
+  Reason string
+}
+
+// checkAmountLimits checks the transfer against the zone's min_amount_units,
+// max_amount_units and daily_account_limit_units. The daily volume is what
+// from_account was debited since midnight UTC of the zone's (skewed) clock;
+// spooled transfers count once posted, and the amounts of pending prepares
+// from the account (other than the transfer's own) count as already sent.
+// Concurrent transfers from one account can together go past the cap: this
+// models a limit, it is not a lock.
+func (l *Ledger) checkAmountLimits(ctx context.Context, q Queries, in CreateTransferInput, c *ZoneControls) error {
+  if c.MinAmountUnits > 0 && in.AmountUnits < c.MinAmountUnits {
+    return fmt.Errorf("%w: %d < %d", ErrAmountBelowMinimum, in.AmountUnits, c.MinAmountUnits)
+  }
+  if c.MaxAmountUnits > 0 && in.AmountUnits > c.MaxAmountUnits {
+    return fmt.Errorf("%w: %d > %d", ErrAmountAboveMaximum, in.AmountUnits, c.MaxAmountUnits)
+  }
+  if c.DailyAccountLimitUnits > 0 {
+    day := zoneTime(l.clock.Now(), c.ClockSkewMs).UTC().Truncate(24 * time.Hour)
+    sent, err := q.DebitedSince(ctx, in.FromAccount, day)
+    if err != nil { return err }
+    held, err := q.HeldUnits(ctx, in.FromAccount, in.RequestID, l.clock.Now())
+    if err != nil { return err }
+    if sent+held+in.AmountUnits > c.DailyAccountLimitUnits {
+      if held > 0 {
+        return fmt.Errorf("%w: %s sent %d and holds %d of %d today", ErrDailyLimitExceeded, in.FromAccount, sent, held, c.DailyAccountLimitUnits)
+      }
+      return fmt.Errorf("%w: %s sent %d of %d today", ErrDailyLimitExceeded, in.FromAccount, sent, c.DailyAccountLimitUnits)
+    }
+  }
+  return nil
+}
+
+// overrideAmountLimit audits a transfer let past limitErr by its override.
+// It is written in the transfer's transaction, so it is only kept when the
+// transfer is applied or spooled.
+func (l *Ledger) overrideAmountLimit(ctx context.Context, q Queries, in CreateTransferInput, limitErr error) error {
+  return l.audit(ctx, q, AuditRecord{
+    Actor: in.LimitOverride.Actor, Action: "OVERRIDE_AMOUNT_LIMIT", TargetType: "zone", TargetID: in.ZoneID, Reason: in.LimitOverride.Reason,
+    Details: map[string]any{
+      "request_id": in.RequestID, "from_account": in.FromAccount, "to_account": in.ToAccount,
+      "amount_units": in.AmountUnits, "limit": limitErr.Error(),
+    },
+  })
+}
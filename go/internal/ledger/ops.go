@@ -5,9 +5,15 @@ import (
   "encoding/json"
   "errors"
   "fmt"
+  "slices"
   "time"
 
   "github.com/jackc/pgx/v5"
+  "go.opentelemetry.io/otel/attribute"
+  "go.opentelemetry.io/otel/trace"
+
+  "time-ledger-sim/go/internal/metrics"
+  "time-ledger-sim/go/internal/tracing"
 )
 
 type ZoneControls struct {
@@ -15,69 +21,197 @@ type ZoneControls struct {
   WritesBlocked bool `json:"writes_blocked"`
   CrossZoneThrottle int `json:"cross_zone_throttle"`
   SpoolEnabled bool `json:"spool_enabled"`
+  InjectLatencyMs int `json:"inject_latency_ms"`
+  InjectJitterMs int `json:"inject_jitter_ms"`
+  ErrorRatePercent int `json:"error_rate_percent"`
+  ThrottleMode string `json:"throttle_mode"`
+  RateLimitPerSec int `json:"rate_limit_per_sec"`
+  RateLimitBurst int `json:"rate_limit_burst"`
+  ClockSkewMs int64 `json:"clock_skew_ms"`
+  BlockedActions map[string]string `json:"blocked_actions"`
+  BlockedDelayMs int `json:"blocked_delay_ms"`
+  MinAmountUnits int64 `json:"min_amount_units"`
+  MaxAmountUnits int64 `json:"max_amount_units"`
+  DailyAccountLimitUnits int64 `json:"daily_account_limit_units"`
+  DayCutoverMinute int `json:"day_cutover_minute"`
   UpdatedAt time.Time `json:"updated_at"`
 }
 
-func (l *Ledger) GetZoneControls(ctx context.Context, zoneID string) (*ZoneControls, error) {
+// zoneControlsCols is the canonical column list for scanZoneControls.
+const zoneControlsCols = `zone_id, writes_blocked, cross_zone_throttle, spool_enabled, inject_latency_ms, inject_jitter_ms, error_rate_percent, throttle_mode, rate_limit_per_sec, rate_limit_burst, clock_skew_ms, blocked_actions, blocked_delay_ms, min_amount_units, max_amount_units, daily_account_limit_units, day_cutover_minute, updated_at`
+
+func scanZoneControls(row pgx.Row) (*ZoneControls, error) {
   var c ZoneControls
-  err := l.db.QueryRow(ctx, `
-    SELECT zone_id, writes_blocked, cross_zone_throttle, spool_enabled, updated_at
-    FROM zone_controls WHERE zone_id=$1
-  `, zoneID).Scan(&c.ZoneID, &c.WritesBlocked, &c.CrossZoneThrottle, &c.SpoolEnabled, &c.UpdatedAt)
-  if err == nil {
-    return &c, nil
-  }
-  if !errors.Is(err, pgx.ErrNoRows) {
+  if err := row.Scan(&c.ZoneID, &c.WritesBlocked, &c.CrossZoneThrottle, &c.SpoolEnabled, &c.InjectLatencyMs, &c.InjectJitterMs, &c.ErrorRatePercent, &c.ThrottleMode, &c.RateLimitPerSec, &c.RateLimitBurst, &c.ClockSkewMs, &c.BlockedActions, &c.BlockedDelayMs, &c.MinAmountUnits, &c.MaxAmountUnits, &c.DailyAccountLimitUnits, &c.DayCutoverMinute, &c.UpdatedAt); err != nil {
     return nil, err
   }
-  // default row
-  _, err = l.db.Exec(ctx, `INSERT INTO zone_controls(zone_id) VALUES($1) ON CONFLICT DO NOTHING`, zoneID)
-  if err != nil { return nil, err }
-  return l.GetZoneControls(ctx, zoneID)
+  return &c, nil
+}
+
+// input is the replacement that keeps every control as it is.
+func (c *ZoneControls) input() SetZoneControlsInput {
+  return SetZoneControlsInput{
+    WritesBlocked: c.WritesBlocked, CrossZoneThrottle: c.CrossZoneThrottle, SpoolEnabled: c.SpoolEnabled,
+    InjectLatencyMs: c.InjectLatencyMs, InjectJitterMs: c.InjectJitterMs, ErrorRatePercent: c.ErrorRatePercent,
+    ThrottleMode: c.ThrottleMode, RateLimitPerSec: c.RateLimitPerSec, RateLimitBurst: c.RateLimitBurst,
+    ClockSkewMs: c.ClockSkewMs, BlockedActions: c.BlockedActions, BlockedDelayMs: c.BlockedDelayMs,
+    MinAmountUnits: c.MinAmountUnits, MaxAmountUnits: c.MaxAmountUnits, DailyAccountLimitUnits: c.DailyAccountLimitUnits,
+    DayCutoverMinute: c.DayCutoverMinute,
+  }
 }
 
-func (l *Ledger) SetZoneControls(ctx context.Context, zoneID string, writesBlocked bool, crossZoneThrottle int, spoolEnabled bool, actor, reason string) (*ZoneControls, error) {
-  if crossZoneThrottle < 0 || crossZoneThrottle > 100 {
-    return nil, fmt.Errorf("invalid cross_zone_throttle")
+// SetZoneControlsInput is a full replacement of a zone's controls. The JSON
+// form is what gets recorded in audit details and scheduled changes.
+type SetZoneControlsInput struct {
+  WritesBlocked bool `json:"writes_blocked"`
+  CrossZoneThrottle int `json:"cross_zone_throttle"`
+  SpoolEnabled bool `json:"spool_enabled"`
+  InjectLatencyMs int `json:"inject_latency_ms"`
+  InjectJitterMs int `json:"inject_jitter_ms"`
+  ErrorRatePercent int `json:"error_rate_percent"`
+  ThrottleMode string `json:"throttle_mode"` // HASH (default) | RATE
+  RateLimitPerSec int `json:"rate_limit_per_sec"`
+  RateLimitBurst int `json:"rate_limit_burst"`
+  ClockSkewMs int64 `json:"clock_skew_ms"` // offset applied to transaction timestamps in this zone
+  BlockedActions map[string]string `json:"blocked_actions,omitempty"` // per-cause SPOOL | REJECT | DELAY, see blockedOutcome
+  BlockedDelayMs int `json:"blocked_delay_ms,omitempty"` // how long DELAY holds a transfer
+  // amount limits, see checkAmountLimits; 0 is no limit
+  MinAmountUnits int64 `json:"min_amount_units,omitempty"`
+  MaxAmountUnits int64 `json:"max_amount_units,omitempty"`
+  DailyAccountLimitUnits int64 `json:"daily_account_limit_units,omitempty"`
+  DayCutoverMinute int `json:"day_cutover_minute,omitempty"` // when the business day closes, see businessDay
+  Actor string `json:"-"`
+  ReasonCode string `json:"-"` // from the reason-code catalog
+  Reason string `json:"-"`
+}
+
+const maxInjectedLatencyMs = 60000
+
+const maxClockSkewMs = 24 * 60 * 60 * 1000
+
+// maxBlockedDelayMs bounds DELAY: the request holds its connection meanwhile.
+const maxBlockedDelayMs = 10000
+
+func (in SetZoneControlsInput) validate() error {
+  if in.CrossZoneThrottle < 0 || in.CrossZoneThrottle > 100 {
+    return fmt.Errorf("invalid cross_zone_throttle")
+  }
+  if in.InjectLatencyMs < 0 || in.InjectLatencyMs > maxInjectedLatencyMs {
+    return fmt.Errorf("invalid inject_latency_ms")
+  }
+  if in.InjectJitterMs < 0 || in.InjectJitterMs > maxInjectedLatencyMs {
+    return fmt.Errorf("invalid inject_jitter_ms")
   }
+  if in.ErrorRatePercent < 0 || in.ErrorRatePercent > 100 {
+    return fmt.Errorf("invalid error_rate_percent")
+  }
+  if in.ThrottleMode != ThrottleModeHash && in.ThrottleMode != ThrottleModeRate {
+    return fmt.Errorf("invalid throttle_mode")
+  }
+  if in.RateLimitPerSec < 0 || in.RateLimitBurst < 0 {
+    return fmt.Errorf("invalid rate limit")
+  }
+  if in.ThrottleMode == ThrottleModeRate && in.RateLimitPerSec == 0 {
+    return fmt.Errorf("rate_limit_per_sec required for RATE mode")
+  }
+  if in.ClockSkewMs < -maxClockSkewMs || in.ClockSkewMs > maxClockSkewMs {
+    return fmt.Errorf("invalid clock_skew_ms")
+  }
+  if in.BlockedDelayMs < 0 || in.BlockedDelayMs > maxBlockedDelayMs {
+    return fmt.Errorf("invalid blocked_delay_ms")
+  }
+  for cause, action := range in.BlockedActions {
+    if !slices.Contains(BlockedCauses, cause) { return fmt.Errorf("invalid blocked_actions cause %q", cause) }
+    if action != BlockedActionSpool && action != BlockedActionReject && action != BlockedActionDelay {
+      return fmt.Errorf("invalid blocked_actions action %q for %s", action, cause)
+    }
+    if action == BlockedActionDelay && in.BlockedDelayMs == 0 {
+      return fmt.Errorf("blocked_delay_ms required for DELAY")
+    }
+  }
+  if in.MinAmountUnits < 0 || in.MaxAmountUnits < 0 || in.DailyAccountLimitUnits < 0 {
+    return fmt.Errorf("invalid amount limit")
+  }
+  if in.MaxAmountUnits > 0 && in.MinAmountUnits > in.MaxAmountUnits {
+    return fmt.Errorf("min_amount_units above max_amount_units")
+  }
+  if in.DayCutoverMinute < 0 || in.DayCutoverMinute >= minutesPerDay {
+    return fmt.Errorf("invalid day_cutover_minute")
+  }
+  return nil
+}
+
+func (l *Ledger) GetZoneControls(ctx context.Context, zoneID string) (*ZoneControls, error) {
+  return l.repo.ZoneControls(ctx, zoneID)
+}
 
+func (l *Ledger) SetZoneControls(ctx context.Context, zoneID string, in SetZoneControlsInput) (*ZoneControls, error) {
   tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
   if err != nil { return nil, err }
   defer func() { _ = tx.Rollback(ctx) }()
 
+  // checked here rather than in setZoneControlsTx: a scheduled change was
+  // checked when it was scheduled
+  if err := l.checkReasonCode(ctx, tx, ReasonForZoneControls, in.ReasonCode); err != nil { return nil, err }
+  c, err := l.setZoneControlsTx(ctx, tx, zoneID, in)
+  if err != nil { return nil, err }
+
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  l.zones.invalidate(zoneID)
+  return c, nil
+}
+
+func (l *Ledger) setZoneControlsTx(ctx context.Context, tx pgx.Tx, zoneID string, in SetZoneControlsInput) (*ZoneControls, error) {
+  if in.ThrottleMode == "" { in.ThrottleMode = ThrottleModeHash }
+  if in.BlockedActions == nil { in.BlockedActions = map[string]string{} }
+  if err := in.validate(); err != nil {
+    return nil, err
+  }
+  if err := l.checkActors(ctx, tx, in.Actor); err != nil { return nil, err }
+
+  actions, _ := json.Marshal(in.BlockedActions)
+
   // ensure row exists
   _, _ = tx.Exec(ctx, `INSERT INTO zone_controls(zone_id) VALUES($1) ON CONFLICT DO NOTHING`, zoneID)
 
-  var c ZoneControls
-  err = tx.QueryRow(ctx, `
+  // who and why, for the version the update records in zone_controls_history
+  _, err := tx.Exec(ctx, `
+    SELECT set_config('ledger.controls_actor', $1, true), set_config('ledger.controls_reason_code', $2, true), set_config('ledger.controls_reason', $3, true)
+  `, in.Actor, in.ReasonCode, in.Reason)
+  if err != nil { return nil, err }
+
+  c, err := scanZoneControls(tx.QueryRow(ctx, `
     UPDATE zone_controls
-    SET writes_blocked=$2, cross_zone_throttle=$3, spool_enabled=$4, updated_at=now()
+    SET writes_blocked=$2, cross_zone_throttle=$3, spool_enabled=$4,
+        inject_latency_ms=$5, inject_jitter_ms=$6, error_rate_percent=$7,
+        throttle_mode=$8, rate_limit_per_sec=$9, rate_limit_burst=$10, clock_skew_ms=$11,
+        blocked_actions=$12::jsonb, blocked_delay_ms=$13,
+        min_amount_units=$14, max_amount_units=$15, daily_account_limit_units=$16, day_cutover_minute=$17, updated_at=now()
     WHERE zone_id=$1
-    RETURNING zone_id, writes_blocked, cross_zone_throttle, spool_enabled, updated_at
-  `, zoneID, writesBlocked, crossZoneThrottle, spoolEnabled).Scan(&c.ZoneID, &c.WritesBlocked, &c.CrossZoneThrottle, &c.SpoolEnabled, &c.UpdatedAt)
+    RETURNING `+zoneControlsCols,
+    zoneID, in.WritesBlocked, in.CrossZoneThrottle, in.SpoolEnabled, in.InjectLatencyMs, in.InjectJitterMs, in.ErrorRatePercent,
+    in.ThrottleMode, in.RateLimitPerSec, in.RateLimitBurst, in.ClockSkewMs, string(actions), in.BlockedDelayMs,
+    in.MinAmountUnits, in.MaxAmountUnits, in.DailyAccountLimitUnits, in.DayCutoverMinute))
   if err != nil { return nil, err }
 
-  _, err = tx.Exec(ctx, `
-    INSERT INTO audit_log(actor,action,target_type,target_id,reason,details)
-    VALUES($1,'SET_ZONE_CONTROLS','zone',$2,$3,
-      jsonb_build_object('writes_blocked',$4,'cross_zone_throttle',$5,'spool_enabled',$6)
-    )
-  `, actor, zoneID, reason, writesBlocked, crossZoneThrottle, spoolEnabled)
+  err = l.audit(ctx, pgQueries{tx}, AuditRecord{
+    Actor: in.Actor, Action: "SET_ZONE_CONTROLS", TargetType: "zone", TargetID: zoneID, Reason: in.Reason, ReasonCode: in.ReasonCode,
+    Details: asDetails(in),
+  })
   if err != nil { return nil, err }
 
   // Optional incident for strong containment
-  if writesBlocked || crossZoneThrottle == 0 {
+  if in.WritesBlocked || in.CrossZoneThrottle == 0 {
     sev := "WARN"
     title := "Zone controls tightened"
-    if writesBlocked { sev = "CRITICAL"; title = "Writes blocked by operator" }
+    if in.WritesBlocked { sev = "CRITICAL"; title = "Writes blocked by operator" }
     _, _ = tx.Exec(ctx, `
       INSERT INTO incidents(zone_id,severity,title,details)
-      VALUES($1,$2,$3, jsonb_build_object('reason',$4,'actor',$5,'writes_blocked',$6,'cross_zone_throttle',$7,'spool_enabled',$8))
-    `, zoneID, sev, title, reason, actor, writesBlocked, crossZoneThrottle, spoolEnabled)
+      VALUES($1,$2,$3, jsonb_build_object('reason',$4,'actor',$5,'writes_blocked',$6,'cross_zone_throttle',$7,'spool_enabled',$8,'reason_code',NULLIF($9,'')) || `+incidentTrace(10)+`)
+    `, zoneID, sev, title, in.Reason, in.Actor, in.WritesBlocked, in.CrossZoneThrottle, in.SpoolEnabled, in.ReasonCode, tracing.TraceID(ctx))
   }
 
-  if err := tx.Commit(ctx); err != nil { return nil, err }
-  return &c, nil
+  return c, nil
 }
 
 type SpoolStats struct {
@@ -101,85 +235,108 @@ func (l *Ledger) GetSpoolStats(ctx context.Context, zoneID string) (*SpoolStats,
   return &SpoolStats{ZoneID: zoneID, Pending: p, Applied: a, Failed: f}, nil
 }
 
+var errZoneNotReady = errors.New("zone not ready for replay")
+
 type ReplayResult struct {
   ZoneID string `json:"zone_id"`
   Applied int `json:"applied"`
   Failed int `json:"failed"`
+  // Skipped counts entries held back because their partition is still in place.
+  Skipped int `json:"skipped"`
+}
+
+func (l *Ledger) ReplaySpool(ctx context.Context, zoneID string, limit int, actor, reasonCode, reason string) (*ReplayResult, error) {
+  ctx, span := tracing.Start(ctx, "ledger.ReplaySpool", trace.WithAttributes(attribute.String("zone_id", zoneID), attribute.Int("limit", limit)))
+  res, err := l.replaySpool(ctx, zoneID, limit, actor, reasonCode, reason)
+  if res != nil {
+    span.SetAttributes(attribute.Int("applied", res.Applied), attribute.Int("failed", res.Failed), attribute.Int("skipped", res.Skipped))
+  }
+  tracing.End(span, err)
+  return res, err
 }
 
-func (l *Ledger) ReplaySpool(ctx context.Context, zoneID string, limit int, actor, reason string) (*ReplayResult, error) {
+func (l *Ledger) replaySpool(ctx context.Context, zoneID string, limit int, actor, reasonCode, reason string) (*ReplayResult, error) {
   if limit <= 0 || limit > 500 { limit = 50 }
+  if err := l.checkReasonCode(ctx, l.db, ReasonForReplaySpool, reasonCode); err != nil { return nil, err }
+  res, err := l.replayBatch(ctx, zoneID, limit)
+  if err != nil { return nil, err }
+
+  l.log.InfoContext(ctx, "spool replayed", "zone_id", zoneID, "actor", actor, "applied", res.Applied, "failed", res.Failed, "skipped", res.Skipped)
+
+  // Audit summary
+  _ = l.audit(ctx, l.repo, AuditRecord{
+    Actor: actor, Action: "REPLAY_SPOOL", TargetType: "zone", TargetID: zoneID, Reason: reason, ReasonCode: reasonCode,
+    Details: map[string]any{"applied": res.Applied, "failed": res.Failed, "limit": limit, "skipped": res.Skipped},
+  })
+
+  return res, nil
+}
+
+// replayBatch applies up to limit of the zone's PENDING spool entries, oldest
+// first, once the zone is ready to take them again.
+func (l *Ledger) replayBatch(ctx context.Context, zoneID string, limit int) (*ReplayResult, error) {
   // Do not replay if zone is still blocked/down.
-  var status string
-  err := l.db.QueryRow(ctx, `SELECT status FROM zones WHERE id=$1`, zoneID).Scan(&status)
+  status, err := l.repo.ZoneStatus(ctx, zoneID)
   if err != nil { return nil, err }
   c, err := l.GetZoneControls(ctx, zoneID)
   if err != nil { return nil, err }
-  if status == "DOWN" || c.WritesBlocked || c.CrossZoneThrottle == 0 {
-    return nil, fmt.Errorf("zone not ready for replay")
+  if status == "DOWN" || c.WritesBlocked || (c.ThrottleMode == ThrottleModeHash && c.CrossZoneThrottle == 0) {
+    l.log.InfoContext(ctx, "replay refused: zone not ready", "zone_id", zoneID, "status", status, "writes_blocked", c.WritesBlocked)
+    return nil, errZoneNotReady
   }
 
-  rows, err := l.db.Query(ctx, `
-    SELECT id::text, request_id, payload_hash, from_account, to_account, amount_units, zone_id, metadata
-    FROM spooled_transfers
-    WHERE zone_id=$1 AND status='PENDING'
-    ORDER BY created_at ASC
-    LIMIT $2
-  `, zoneID, limit)
+  list, err := l.repo.PendingSpool(ctx, zoneID, limit)
   if err != nil { return nil, err }
-  defer rows.Close()
 
   res := &ReplayResult{ZoneID: zoneID}
 
-  type spoolRow struct {
-    ID string
-    Req string
-    Hash string
-    From string
-    To string
-    Amt int64
-    Zone string
-    Meta []byte
-  }
-  list := []spoolRow{}
-  for rows.Next() {
-    var r spoolRow
-    if err := rows.Scan(&r.ID, &r.Req, &r.Hash, &r.From, &r.To, &r.Amt, &r.Zone, &r.Meta); err != nil { return nil, err }
-    list = append(list, r)
-  }
-  if err := rows.Err(); err != nil { return nil, err }
-
   for _, s := range list {
     meta := map[string]any{}
-    _ = json.Unmarshal(s.Meta, &meta)
+    _ = json.Unmarshal(s.Metadata, &meta)
+
+    // entries spooled by a partition stay pending until it heals
+    partitioned, err := l.isPartitioned(ctx, s.ZoneID, s.ToAccount)
+    if err != nil { return nil, err }
+    if partitioned {
+      res.Skipped++
+      l.log.DebugContext(ctx, "replay skipped: partitioned", "zone_id", s.ZoneID, "spool_id", s.ID)
+      continue
+    }
 
     // Apply bypassing gating; idempotency still enforced.
-    _, err := l.ApplyTransferBypass(ctx, CreateTransferInput{
-      RequestID: s.Req,
-      PayloadHash: s.Hash,
-      FromAccount: s.From,
-      ToAccount: s.To,
-      AmountUnits: s.Amt,
-      ZoneID: s.Zone,
+    txn, err := l.ApplyTransferBypass(ctx, CreateTransferInput{
+      RequestID: s.RequestID,
+      PayloadHash: s.PayloadHash,
+      FromAccount: s.FromAccount,
+      ToAccount: s.ToAccount,
+      AmountUnits: s.AmountUnits,
+      ZoneID: s.ZoneID,
+      Type: s.Type,
       Metadata: meta,
+      EventContext: map[string]any{"spool_id": s.ID, "spool_reason": s.FailReason},
     })
 
     if err == nil {
       res.Applied++
-      _, _ = l.db.Exec(ctx, `UPDATE spooled_transfers SET status='APPLIED', updated_at=now(), applied_at=now(), fail_reason=NULL WHERE id=$1::uuid`, s.ID)
+      _ = l.repo.InTx(ctx, func(q Queries) error {
+        if err := q.MarkSpoolApplied(ctx, s.ID); err != nil { return err }
+        return l.spoolEvent(ctx, q, "SPOOL_ITEM_APPLIED", s, map[string]any{"transaction_id": txn.ID})
+      })
       continue
     }
 
     res.Failed++
-    _, _ = l.db.Exec(ctx, `UPDATE spooled_transfers SET status='FAILED', updated_at=now(), fail_reason=$2 WHERE id=$1::uuid`, s.ID, err.Error())
+    failReason := err.Error()
+    l.log.WarnContext(ctx, "replay failed", "zone_id", s.ZoneID, "spool_id", s.ID, "transfer_request_id", s.RequestID, "err", failReason)
+    _ = l.repo.InTx(ctx, func(q Queries) error {
+      if err := q.MarkSpoolFailed(ctx, s.ID, failReason); err != nil { return err }
+      return l.spoolEvent(ctx, q, "SPOOL_ITEM_FAILED", s, map[string]any{"error": failReason})
+    })
   }
 
-  // Audit summary
-  _, _ = l.db.Exec(ctx, `
-    INSERT INTO audit_log(actor,action,target_type,target_id,reason,details)
-    VALUES($1,'REPLAY_SPOOL','zone',$2,$3, jsonb_build_object('applied',$4,'failed',$5,'limit',$6))
-  `, actor, zoneID, reason, res.Applied, res.Failed, limit)
-
+  metrics.SpoolReplayed.WithLabelValues(zoneID, metrics.ReplayApplied).Add(float64(res.Applied))
+  metrics.SpoolReplayed.WithLabelValues(zoneID, metrics.ReplayFailed).Add(float64(res.Failed))
+  metrics.SpoolReplayed.WithLabelValues(zoneID, metrics.ReplaySkipped).Add(float64(res.Skipped))
   return res, nil
 }
 
@@ -196,10 +353,10 @@ type AuditEntry struct {
 
 func (l *Ledger) ListAuditForZone(ctx context.Context, zoneID string, limit int) ([]AuditEntry, error) {
   if limit <= 0 || limit > 500 { limit = 100 }
-  rows, err := l.db.Query(ctx, `
+  rows, err := l.ro.Query(ctx, `
     (SELECT a.id::text, a.actor, a.action, a.target_type, a.target_id, a.reason, a.details, a.created_at
      FROM audit_log a
-     WHERE a.target_type='zone' AND a.target_id=$1
+     WHERE a.target_type='zone' AND a.target_id=$1 AND NOT `+drillHidden+`
      ORDER BY a.created_at DESC
      LIMIT $2)
     UNION ALL
@@ -207,15 +364,20 @@ func (l *Ledger) ListAuditForZone(ctx context.Context, zoneID string, limit int)
      FROM audit_log a
      WHERE a.target_type='incident' AND a.target_id IN (
        SELECT id::text FROM incidents WHERE zone_id=$1
-     )
+     ) AND NOT `+drillHidden+`
      ORDER BY a.created_at DESC
      LIMIT $2)
     ORDER BY created_at DESC
     LIMIT $2
   `, zoneID, limit)
   if err != nil { return nil, err }
-  defer rows.Close()
+  return scanAuditEntries(rows)
+}
 
+// scanAuditEntries reads id, actor, action, target_type, target_id, reason,
+// details, created_at rows.
+func scanAuditEntries(rows pgx.Rows) ([]AuditEntry, error) {
+  defer rows.Close()
   out := []AuditEntry{}
   for rows.Next() {
     var e AuditEntry
@@ -250,6 +412,10 @@ func (l *Ledger) ApplyIncidentAction(ctx context.Context, incidentID string, in
   if err != nil { return nil, err }
   defer func() { _ = tx.Rollback(ctx) }()
 
+  actors := []string{in.Actor}
+  if in.Action == "ASSIGN" { actors = append(actors, in.Assignee) }
+  if err := l.checkActors(ctx, tx, actors...); err != nil { return nil, err }
+
   inc, err := l.GetIncident(ctx, incidentID)
   if err != nil { return nil, err }
 
@@ -279,7 +445,7 @@ func (l *Ledger) ApplyIncidentAction(ctx context.Context, incidentID string, in
   var dbDetails []byte
   err = tx.QueryRow(ctx, `
     UPDATE incidents
-    SET status=$2, details=$3::jsonb
+    SET status=$2, details=$3::jsonb, updated_at=now()
     WHERE id=$1::uuid
     RETURNING id::text, zone_id, related_txn_id::text, severity, status, title, details, detected_at
   `, incidentID, newStatus, string(detailsBytes)).Scan(&out.ID, &out.ZoneID, &related, &out.Severity, &out.Status, &out.Title, &dbDetails, &out.DetectedAt)
@@ -287,10 +453,10 @@ func (l *Ledger) ApplyIncidentAction(ctx context.Context, incidentID string, in
   out.RelatedTxnID = related
   _ = json.Unmarshal(dbDetails, &out.Details)
 
-  _, err = tx.Exec(ctx, `
-    INSERT INTO audit_log(actor,action,target_type,target_id,reason,details)
-    VALUES($1,$2,'incident',$3,$4, jsonb_build_object('assignee',$5,'note',$6,'status',$7))
-  `, in.Actor, "INCIDENT_"+in.Action, incidentID, in.Reason, in.Assignee, in.Note, newStatus)
+  err = l.audit(ctx, pgQueries{tx}, AuditRecord{
+    Actor: in.Actor, Action: "INCIDENT_" + in.Action, TargetType: "incident", TargetID: incidentID, Reason: in.Reason,
+    Details: map[string]any{"assignee": in.Assignee, "note": in.Note, "status": newStatus},
+  })
   if err != nil { return nil, err }
 
   if err := tx.Commit(ctx); err != nil { return nil, err }
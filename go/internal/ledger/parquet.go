@@ -0,0 +1,75 @@
+package ledger
+
+import (
+  "io"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+  "github.com/parquet-go/parquet-go"
+)
+
+const parquetRowGroup = 64 * 1024
+
+// transactionRow and postingRow are the Parquet schemas. The db tags match
+// the column names of the dataset queries.
+type transactionRow struct {
+  ID string `db:"id" parquet:"id"`
+  RequestID string `db:"request_id" parquet:"request_id"`
+  FromAccount string `db:"from_account" parquet:"from_account,dict"`
+  ToAccount string `db:"to_account" parquet:"to_account,dict"`
+  AmountUnits int64 `db:"amount_units" parquet:"amount_units"`
+  ZoneID string `db:"zone_id" parquet:"zone_id,dict"`
+  FromZoneID string `db:"from_zone_id" parquet:"from_zone_id,dict"`
+  ToZoneID string `db:"to_zone_id" parquet:"to_zone_id,dict"`
+  ZoneSeq int64 `db:"zone_seq" parquet:"zone_seq"`
+  Metadata string `db:"metadata" parquet:"metadata,json"`
+  CreatedAt time.Time `db:"created_at" parquet:"created_at,timestamp(microsecond)"`
+}
+
+type postingRow struct {
+  ID string `db:"id" parquet:"id"`
+  TxnID string `db:"txn_id" parquet:"txn_id"`
+  AccountID string `db:"account_id" parquet:"account_id,dict"`
+  ZoneID string `db:"zone_id" parquet:"zone_id,dict"`
+  Direction string `db:"direction" parquet:"direction,dict"`
+  AmountUnits int64 `db:"amount_units" parquet:"amount_units"`
+  CreatedAt time.Time `db:"created_at" parquet:"created_at,timestamp(microsecond)"`
+}
+
+// redact masks a row's metadata as the export's redaction policy says.
+func (r *transactionRow) redact(p *RedactionPolicy) { r.Metadata = string(p.RedactJSON([]byte(r.Metadata))) }
+
+// redactableRow is a Parquet schema with metadata to redact.
+type redactableRow interface{ redact(p *RedactionPolicy) }
+
+// parquetWriters are the datasets that can be exported as Parquet.
+var parquetWriters = map[string]func(io.Writer, pgx.Rows, *RedactionPolicy) (int64, error){
+  ExportTransactions: writeParquet[transactionRow],
+  ExportPostings: writeParquet[postingRow],
+}
+
+// writeParquet writes rows as a Parquet file of T, a row group per
+// parquetRowGroup rows, and returns how many it wrote.
+func writeParquet[T any](w io.Writer, rows pgx.Rows, redact *RedactionPolicy) (int64, error) {
+  pw := parquet.NewGenericWriter[T](w, parquet.Compression(&parquet.Zstd), parquet.MaxRowsPerRowGroup(parquetRowGroup))
+  batch := make([]T, 0, 1024)
+  var n int64
+  flush := func() error {
+    _, err := pw.Write(batch)
+    batch = batch[:0]
+    return err
+  }
+  for rows.Next() {
+    row, err := pgx.RowToStructByName[T](rows)
+    if err != nil { return n, err }
+    if r, ok := any(&row).(redactableRow); ok && redact != nil { r.redact(redact) }
+    batch = append(batch, row)
+    n++
+    if len(batch) == cap(batch) {
+      if err := flush(); err != nil { return n, err }
+    }
+  }
+  if err := rows.Err(); err != nil { return n, err }
+  if err := flush(); err != nil { return n, err }
+  return n, pw.Close()
+}
@@ -0,0 +1,152 @@
+package ledger
+
+import (
+  "context"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+
+  "time-ledger-sim/go/internal/logging"
+  "time-ledger-sim/go/internal/tracing"
+)
+
+const (
+  PartitionModeSpool = "SPOOL"
+  PartitionModeReject = "REJECT"
+)
+
+var ErrPartitioned = errors.New("zones partitioned")
+
+func IsPartitioned(err error) bool { return errors.Is(err, ErrPartitioned) }
+
+type Partition struct {
+  FromZone string `json:"from_zone"`
+  ToZone string `json:"to_zone"`
+  Mode string `json:"mode"`
+  Actor string `json:"actor"`
+  Reason *string `json:"reason"`
+  CreatedAt time.Time `json:"created_at"`
+}
+
+type PartitionInput struct {
+  FromZone string
+  ToZone string
+  Mode string
+  Bidirectional bool
+  Actor string
+  Reason string
+}
+
+func (l *Ledger) ListPartitions(ctx context.Context) ([]Partition, error) {
+  rows, err := l.db.Query(ctx, `SELECT from_zone, to_zone, mode, actor, reason, created_at FROM zone_partitions ORDER BY from_zone, to_zone`)
+  if err != nil { return nil, err }
+  defer rows.Close()
+  out := []Partition{}
+  for rows.Next() {
+    var p Partition
+    if err := rows.Scan(&p.FromZone, &p.ToZone, &p.Mode, &p.Actor, &p.Reason, &p.CreatedAt); err != nil { return nil, err }
+    out = append(out, p)
+  }
+  return out, rows.Err()
+}
+
+func (in PartitionInput) pairs() [][2]string {
+  out := [][2]string{{in.FromZone, in.ToZone}}
+  if in.Bidirectional { out = append(out, [2]string{in.ToZone, in.FromZone}) }
+  return out
+}
+
+// CreatePartition cuts from_zone -> to_zone (and the reverse if bidirectional).
+func (l *Ledger) CreatePartition(ctx context.Context, in PartitionInput) ([]Partition, error) {
+  if in.Mode == "" { in.Mode = PartitionModeSpool }
+  if in.Mode != PartitionModeSpool && in.Mode != PartitionModeReject { return nil, fmt.Errorf("invalid mode") }
+  if in.FromZone == "" || in.ToZone == "" || in.FromZone == in.ToZone { return nil, fmt.Errorf("invalid zones") }
+
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  // the foreign keys do not see row-level security: both zones must be the
+  // caller's tenant's
+  var visible int
+  if err := tx.QueryRow(ctx, `SELECT COUNT(*) FROM zones WHERE id IN ($1,$2)`, in.FromZone, in.ToZone).Scan(&visible); err != nil { return nil, err }
+  if visible < 2 { return nil, fmt.Errorf("%w: %s or %s", ErrZoneNotFound, in.FromZone, in.ToZone) }
+
+  out := []Partition{}
+  for _, pr := range in.pairs() {
+    var p Partition
+    err := tx.QueryRow(ctx, `
+      INSERT INTO zone_partitions(from_zone,to_zone,mode,actor,reason) VALUES($1,$2,$3,$4,NULLIF($5,''))
+      ON CONFLICT (from_zone,to_zone) DO UPDATE SET mode=EXCLUDED.mode, actor=EXCLUDED.actor, reason=EXCLUDED.reason, created_at=now()
+      RETURNING from_zone, to_zone, mode, actor, reason, created_at
+    `, pr[0], pr[1], in.Mode, in.Actor, in.Reason).Scan(&p.FromZone, &p.ToZone, &p.Mode, &p.Actor, &p.Reason, &p.CreatedAt)
+    if err != nil { return nil, err }
+    if err := l.recordPartitionChangeTx(ctx, tx, "ZONE_PARTITIONED", pr[0], pr[1], in.Mode, in.Actor, in.Reason); err != nil { return nil, err }
+    out = append(out, p)
+  }
+
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  return out, nil
+}
+
+// HealPartition removes from_zone -> to_zone (and the reverse if bidirectional).
+func (l *Ledger) HealPartition(ctx context.Context, in PartitionInput) (int64, error) {
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return 0, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  var healed int64
+  for _, pr := range in.pairs() {
+    ct, err := tx.Exec(ctx, `DELETE FROM zone_partitions WHERE from_zone=$1 AND to_zone=$2`, pr[0], pr[1])
+    if err != nil { return 0, err }
+    if ct.RowsAffected() == 0 { continue }
+    healed += ct.RowsAffected()
+    if err := l.recordPartitionChangeTx(ctx, tx, "ZONE_PARTITION_HEALED", pr[0], pr[1], "", in.Actor, in.Reason); err != nil { return 0, err }
+  }
+
+  if err := tx.Commit(ctx); err != nil { return 0, err }
+  return healed, nil
+}
+
+// recordPartitionChangeTx audits a partition change and emits it through the outbox.
+func (l *Ledger) recordPartitionChangeTx(ctx context.Context, tx pgx.Tx, action, from, to, mode, actor, reason string) error {
+  err := l.audit(ctx, pgQueries{tx}, AuditRecord{
+    Actor: actor, Action: action, TargetType: "zone", TargetID: from, Reason: reason,
+    Details: map[string]any{"to_zone": to, "mode": mode},
+  })
+  if err != nil { return err }
+
+  payload, _ := json.Marshal(map[string]any{
+    "event_id": "generated_by_db",
+    "from_zone_id": from,
+    "to_zone_id": to,
+    "mode": mode,
+    "actor": actor,
+    "at": l.clock.Now().UTC().Format(time.RFC3339Nano),
+  })
+  _, err = tx.Exec(ctx, `
+    INSERT INTO outbox_events(event_type,aggregate_type,aggregate_id,payload,request_id,trace_context)
+    VALUES($1,'zone',$2,$3::jsonb,NULLIF($4,''),NULLIF($5,'')::jsonb)
+  `, action, from, string(payload), logging.RequestID(ctx), tracing.Carrier(ctx))
+  return err
+}
+
+// partitionForTransfer returns the partition the transfer would cross, if any.
+func (l *Ledger) partitionForTransfer(ctx context.Context, q Queries, zoneID, toAccount string) (*Partition, error) {
+  dest, err := q.AccountZone(ctx, toAccount)
+  if err != nil || dest == "" || dest == zoneID { return nil, err }
+  return q.Partition(ctx, zoneID, dest)
+}
+
+func (p *Partition) blockedReason() string {
+  return "partitioned: " + p.FromZone + " -> " + p.ToZone
+}
+
+// isPartitioned reports whether a transfer from zoneID to toAccount currently crosses a partition.
+func (l *Ledger) isPartitioned(ctx context.Context, zoneID, toAccount string) (bool, error) {
+  p, err := l.partitionForTransfer(ctx, l.repo, zoneID, toAccount)
+  return p != nil, err
+}
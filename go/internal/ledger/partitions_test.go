@@ -0,0 +1,14 @@
+package ledger
+
+import "testing"
+
+func TestPartitionInputPairs(t *testing.T) {
+	one := PartitionInput{FromZone: "zone-a", ToZone: "zone-b"}.pairs()
+	if len(one) != 1 || one[0] != [2]string{"zone-a", "zone-b"} {
+		t.Fatalf("unexpected pairs %v", one)
+	}
+	both := PartitionInput{FromZone: "zone-a", ToZone: "zone-b", Bidirectional: true}.pairs()
+	if len(both) != 2 || both[1] != [2]string{"zone-b", "zone-a"} {
+		t.Fatalf("unexpected pairs %v", both)
+	}
+}
@@ -0,0 +1,308 @@
+package ledger
+
+import (
+  "context"
+  "encoding/json"
+  "errors"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+  "github.com/jackc/pgx/v5/pgconn"
+  "github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PgRepo is the Postgres Repo.
+type PgRepo struct {
+  pgQueries
+  db *pgxpool.Pool
+}
+
+var _ Repo = (*PgRepo)(nil)
+
+func NewPgRepo(db *pgxpool.Pool) *PgRepo { return &PgRepo{pgQueries: pgQueries{db}, db: db} }
+
+func (r *PgRepo) InTx(ctx context.Context, fn func(q Queries) error) error {
+  return r.InTxIso(ctx, IsolationReadCommitted, fn)
+}
+
+func (r *PgRepo) InTxIso(ctx context.Context, iso Isolation, fn func(q Queries) error) error {
+  opts := pgx.TxOptions{IsoLevel: pgx.ReadCommitted}
+  switch iso {
+  case IsolationRepeatableRead:
+    opts.IsoLevel = pgx.RepeatableRead
+  case IsolationSerializable:
+    opts.IsoLevel = pgx.Serializable
+  }
+  return pgx.BeginTxFunc(ctx, r.db, opts, func(tx pgx.Tx) error { return fn(pgQueries{tx}) })
+}
+
+// querier is what a pool and a transaction have in common.
+type querier interface {
+  Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+  Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+  QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+  SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
+// pgQueries runs Queries on a pool or, inside InTx (and the ledger's own
+// transactions), on a pgx.Tx.
+type pgQueries struct{ q querier }
+
+func (p pgQueries) ZoneStatus(ctx context.Context, zoneID string) (string, error) {
+  var status string
+  var retired bool
+  err := p.q.QueryRow(ctx, `SELECT status, retired_at IS NOT NULL FROM zones WHERE id=$1`, zoneID).Scan(&status, &retired)
+  if errors.Is(err, pgx.ErrNoRows) || (err == nil && retired) { return "", ErrZoneNotFound }
+  if err != nil { return "", err }
+  return status, nil
+}
+
+func (p pgQueries) ZoneControls(ctx context.Context, zoneID string) (*ZoneControls, error) {
+  // create the default row and read it in one round trip; the insert's row is
+  // not visible to the statement's own SELECT, hence the UNION. Unknown zones
+  // insert nothing and yield pgx.ErrNoRows.
+  return scanZoneControls(p.q.QueryRow(ctx, `
+    WITH ins AS (
+      INSERT INTO zone_controls(zone_id) SELECT $1 WHERE EXISTS (SELECT 1 FROM zones WHERE id=$1)
+      ON CONFLICT DO NOTHING
+      RETURNING `+zoneControlsCols+`
+    )
+    SELECT `+zoneControlsCols+` FROM ins
+    UNION ALL
+    SELECT `+zoneControlsCols+` FROM zone_controls WHERE zone_id=$1
+    LIMIT 1
+  `, zoneID))
+}
+
+func (p pgQueries) FindZoneControls(ctx context.Context, zoneID string) (*ZoneControls, error) {
+  c, err := scanZoneControls(p.q.QueryRow(ctx, `SELECT `+zoneControlsCols+` FROM zone_controls WHERE zone_id=$1`, zoneID))
+  if errors.Is(err, pgx.ErrNoRows) { return nil, nil }
+  return c, err
+}
+
+func (p pgQueries) AccountControls(ctx context.Context, accountIDs ...string) ([]AccountControls, error) {
+  rows, err := p.q.Query(ctx, `SELECT `+accountControlsCols+` FROM account_controls WHERE account_id = ANY($1)`, accountIDs)
+  if err != nil { return nil, err }
+  defer rows.Close()
+  var out []AccountControls
+  for rows.Next() {
+    c, err := scanAccountControls(rows)
+    if err != nil { return nil, err }
+    out = append(out, *c)
+  }
+  return out, rows.Err()
+}
+
+func (p pgQueries) AccountZone(ctx context.Context, accountID string) (string, error) {
+  var zone string
+  err := p.q.QueryRow(ctx, `SELECT zone_id FROM accounts WHERE id=$1`, accountID).Scan(&zone)
+  if errors.Is(err, pgx.ErrNoRows) { return "", nil }
+  return zone, err
+}
+
+func (p pgQueries) Balance(ctx context.Context, accountID string) (int64, error) {
+  var b int64
+  err := p.q.QueryRow(ctx, `SELECT balance_units FROM balances WHERE account_id=$1`, accountID).Scan(&b)
+  if errors.Is(err, pgx.ErrNoRows) { return 0, nil }
+  return b, err
+}
+
+func (p pgQueries) DebitedSince(ctx context.Context, accountID string, since time.Time) (int64, error) {
+  var n int64
+  err := p.q.QueryRow(ctx, `
+    SELECT COALESCE(SUM(amount_units), 0)::bigint FROM postings WHERE account_id=$1 AND direction='DEBIT' AND created_at >= $2
+  `, accountID, since).Scan(&n)
+  return n, err
+}
+
+func (p pgQueries) HeldUnits(ctx context.Context, accountID, exceptRequest string, at time.Time) (int64, error) {
+  var n int64
+  err := p.q.QueryRow(ctx, `
+    SELECT COALESCE(SUM(amount_units), 0)::bigint FROM transfer_prepares
+    WHERE from_account=$1 AND status='PREPARED' AND expires_at > $3 AND request_id <> $2
+  `, accountID, exceptRequest, at).Scan(&n)
+  return n, err
+}
+
+func (p pgQueries) Partition(ctx context.Context, fromZone, toZone string) (*Partition, error) {
+  var pt Partition
+  err := p.q.QueryRow(ctx, `
+    SELECT from_zone, to_zone, mode, actor, reason, created_at FROM zone_partitions WHERE from_zone=$1 AND to_zone=$2
+  `, fromZone, toZone).Scan(&pt.FromZone, &pt.ToZone, &pt.Mode, &pt.Actor, &pt.Reason, &pt.CreatedAt)
+  if errors.Is(err, pgx.ErrNoRows) { return nil, nil }
+  if err != nil { return nil, err }
+  return &pt, nil
+}
+
+func (p pgQueries) LockRateBucket(ctx context.Context, zoneID string, tokens float64, now time.Time) (float64, time.Time, error) {
+  _, err := p.q.Exec(ctx, `INSERT INTO zone_rate_buckets(zone_id,tokens,refilled_at) VALUES($1,$2,$3) ON CONFLICT DO NOTHING`, zoneID, tokens, now)
+  if err != nil { return 0, time.Time{}, err }
+  var refilledAt time.Time
+  err = p.q.QueryRow(ctx, `SELECT tokens, refilled_at FROM zone_rate_buckets WHERE zone_id=$1 FOR UPDATE`, zoneID).
+    Scan(&tokens, &refilledAt)
+  return tokens, refilledAt, err
+}
+
+func (p pgQueries) SaveRateBucket(ctx context.Context, zoneID string, tokens float64, at time.Time) error {
+  _, err := p.q.Exec(ctx, `UPDATE zone_rate_buckets SET tokens=$2, refilled_at=$3 WHERE zone_id=$1`, zoneID, tokens, at)
+  return err
+}
+
+func (p pgQueries) FindTransaction(ctx context.Context, requestID string) (*Transaction, string, error) {
+  t := Transaction{RequestID: requestID}
+  var hash string
+  err := p.q.QueryRow(ctx, `SELECT id::text,payload_hash,created_at,zone_seq FROM transactions WHERE request_id=$1`, requestID).
+    Scan(&t.ID, &hash, &t.CreatedAt, &t.ZoneSeq)
+  if errors.Is(err, pgx.ErrNoRows) { return nil, "", nil }
+  if err != nil { return nil, "", err }
+  return &t, hash, nil
+}
+
+func (p pgQueries) FindSpooled(ctx context.Context, requestID string) (string, string, error) {
+  var id, hash string
+  err := p.q.QueryRow(ctx, `SELECT id::text,payload_hash FROM spooled_transfers WHERE request_id=$1`, requestID).
+    Scan(&id, &hash)
+  if errors.Is(err, pgx.ErrNoRows) { return "", "", nil }
+  return id, hash, err
+}
+
+func (p pgQueries) EnsureAccount(ctx context.Context, accountID, zoneID string) error {
+  _, err := p.q.Exec(ctx, ensureAccountSQL, accountID, zoneID)
+  return err
+}
+
+const (
+  // FOR KEY SHARE lets status and controls updates through but waits for
+  // RetireZone's FOR UPDATE; a zone retired meanwhile reads as retired.
+  lockZoneSQL = `SELECT retired_at IS NOT NULL FROM zones WHERE id=$1 FOR KEY SHARE`
+  ensureAccountSQL = `INSERT INTO accounts(id, zone_id) VALUES($1,$2) ON CONFLICT (id) DO NOTHING`
+  // a request_id another transaction recorded first inserts nothing (after
+  // waiting for that transaction to commit); the statements after it check
+  // that the row is theirs, so they write nothing either. INSERT ... SELECT
+  // does not infer parameter types from the target columns, hence the casts.
+  insertTransactionSQL = `
+    INSERT INTO transactions(id,request_id,payload_hash,from_account,to_account,amount_units,zone_id,metadata,created_at,clock_skew_ms,type)
+    VALUES($1::uuid,$2,$3,$4,$5,$6,$7,$8::jsonb,$9,$10,$11)
+    ON CONFLICT (request_id) DO NOTHING
+    RETURNING zone_seq`
+  ifPosted = ` WHERE EXISTS (SELECT 1 FROM transactions WHERE id=$1::uuid)`
+  insertPostingSQL = `
+    INSERT INTO postings(txn_id,account_id,direction,amount_units,created_at) SELECT $1::uuid,$2::text,$3::text,$4::bigint,$5::timestamptz` + ifPosted
+  adjustBalanceSQL = `
+    INSERT INTO balances(account_id,balance_units,updated_at)
+    SELECT $2::text,$3::bigint,now()` + ifPosted + `
+    ON CONFLICT (account_id) DO UPDATE
+      SET balance_units = balances.balance_units + EXCLUDED.balance_units,
+          updated_at = now()`
+  // the payload gains the zone_seq the insert trigger allocated and both
+  // accounts' balances after the transfer (the batch runs in order, so the
+  // adjustments above are visible)
+  postedOutboxSQL = `
+    INSERT INTO outbox_events(event_type,aggregate_type,aggregate_id,payload,request_id,trace_context)
+    SELECT $2::text,$3::text,$4::text,
+      $5::jsonb || jsonb_build_object('zone_seq', (SELECT zone_seq FROM transactions WHERE id=$1::uuid),
+        'from_balance_units', (SELECT balance_units FROM balances WHERE account_id=$8::text),
+        'to_balance_units', (SELECT balance_units FROM balances WHERE account_id=$9::text)),
+      NULLIF($6::text,''),NULLIF($7::text,'')::jsonb` + ifPosted
+  insertOutboxSQL = `
+    INSERT INTO outbox_events(event_type,aggregate_type,aggregate_id,payload,request_id,trace_context)
+    VALUES($1,$2,$3,$4::jsonb,NULLIF($5,''),NULLIF($6,'')::jsonb)`
+)
+
+// PostTransfer queues the transfer's eight statements as one batch: pgx
+// pipelines them in a single round trip, and the connection's statement cache
+// (pgx's default exec mode) keeps them prepared after the first use. The
+// batch's first failing statement is the error; the caller's transaction then
+// rolls everything back. A request_id recorded concurrently is ErrRequestExists.
+func (p pgQueries) PostTransfer(ctx context.Context, t PostedTransfer) (int64, error) {
+  in := t.In
+  b := &pgx.Batch{}
+  b.Queue(lockZoneSQL, in.ZoneID).QueryRow(func(row pgx.Row) error { return scanLiveZone(row) })
+  b.Queue(ensureAccountSQL, in.FromAccount, in.ZoneID)
+  b.Queue(ensureAccountSQL, in.ToAccount, in.ZoneID)
+  inserted := false
+  var seq int64
+  b.Queue(insertTransactionSQL, t.ID, in.RequestID, in.PayloadHash, in.FromAccount, in.ToAccount, in.AmountUnits, in.ZoneID,
+    string(t.Metadata), t.CreatedAt, t.ClockSkewMs, transactionType(in)).QueryRow(func(row pgx.Row) error {
+    err := row.Scan(&seq)
+    if errors.Is(err, pgx.ErrNoRows) { return nil }
+    inserted = err == nil
+    return err
+  })
+  b.Queue(insertPostingSQL, t.ID, in.FromAccount, "DEBIT", in.AmountUnits, t.CreatedAt)
+  b.Queue(insertPostingSQL, t.ID, in.ToAccount, "CREDIT", in.AmountUnits, t.CreatedAt)
+  // balance projection (allow negative; this is a sim)
+  b.Queue(adjustBalanceSQL, t.ID, in.FromAccount, -in.AmountUnits)
+  b.Queue(adjustBalanceSQL, t.ID, in.ToAccount, in.AmountUnits)
+  ev := t.Event
+  b.Queue(postedOutboxSQL, t.ID, ev.EventType, ev.AggregateType, ev.AggregateID, string(ev.Payload), ev.RequestID, ev.TraceContext,
+    in.FromAccount, in.ToAccount)
+  if err := p.q.SendBatch(ctx, b).Close(); err != nil { return 0, err }
+  if !inserted { return 0, ErrRequestExists }
+  return seq, nil
+}
+
+// scanLiveZone reads a lockZoneSQL row: ErrZoneNotFound unless the zone is live.
+func scanLiveZone(row pgx.Row) error {
+  var retired bool
+  err := row.Scan(&retired)
+  if errors.Is(err, pgx.ErrNoRows) || (err == nil && retired) { return ErrZoneNotFound }
+  return err
+}
+
+func (p pgQueries) InsertOutbox(ctx context.Context, ev OutboxEvent) error {
+  _, err := p.q.Exec(ctx, insertOutboxSQL, ev.EventType, ev.AggregateType, ev.AggregateID, string(ev.Payload), ev.RequestID, ev.TraceContext)
+  return err
+}
+
+func (p pgQueries) InsertAudit(ctx context.Context, a AuditRecord) error {
+  details, err := json.Marshal(a.Details)
+  if err != nil { return err }
+  _, err = p.q.Exec(ctx, `
+    INSERT INTO audit_log(id,actor,action,target_type,target_id,reason,details,created_at,signature,sig_key_id)
+    VALUES($1::uuid,$2,$3,$4,$5,NULLIF($6,''),$7::jsonb,$8,$9,NULLIF($10,''))
+  `, a.ID, a.Actor, a.Action, a.TargetType, a.TargetID, a.Reason, string(details), a.CreatedAt, a.Signature, a.KeyID)
+  return err
+}
+
+func (p pgQueries) InsertSpooled(ctx context.Context, in CreateTransferInput, metadata []byte, failReason string, at time.Time) (string, error) {
+  if err := scanLiveZone(p.q.QueryRow(ctx, lockZoneSQL, in.ZoneID)); err != nil { return "", err }
+  var id string
+  err := p.q.QueryRow(ctx, `
+    INSERT INTO spooled_transfers(request_id,payload_hash,from_account,to_account,amount_units,zone_id,metadata,status,fail_reason,created_at,updated_at,type)
+    VALUES($1,$2,$3,$4,$5,$6,$7::jsonb,'PENDING',$8,$9,$9,$10)
+    ON CONFLICT (request_id) DO NOTHING
+    RETURNING id::text
+  `, in.RequestID, in.PayloadHash, in.FromAccount, in.ToAccount, in.AmountUnits, in.ZoneID, string(metadata), failReason, at, transactionType(in)).Scan(&id)
+  if errors.Is(err, pgx.ErrNoRows) { return "", ErrRequestExists }
+  return id, err
+}
+
+func (p pgQueries) PendingSpool(ctx context.Context, zoneID string, limit int) ([]SpooledTransfer, error) {
+  rows, err := p.q.Query(ctx, `
+    SELECT id::text, request_id, payload_hash, from_account, to_account, amount_units, zone_id, type, metadata, COALESCE(fail_reason,'')
+    FROM spooled_transfers
+    WHERE zone_id=$1 AND status='PENDING'
+    ORDER BY created_at ASC
+    LIMIT $2
+  `, zoneID, limit)
+  if err != nil { return nil, err }
+  defer rows.Close()
+  var out []SpooledTransfer
+  for rows.Next() {
+    var s SpooledTransfer
+    if err := rows.Scan(&s.ID, &s.RequestID, &s.PayloadHash, &s.FromAccount, &s.ToAccount, &s.AmountUnits, &s.ZoneID, &s.Type, &s.Metadata, &s.FailReason); err != nil { return nil, err }
+    out = append(out, s)
+  }
+  return out, rows.Err()
+}
+
+func (p pgQueries) MarkSpoolApplied(ctx context.Context, id string) error {
+  _, err := p.q.Exec(ctx, `UPDATE spooled_transfers SET status='APPLIED', updated_at=now(), applied_at=now(), fail_reason=NULL WHERE id=$1::uuid`, id)
+  return err
+}
+
+func (p pgQueries) MarkSpoolFailed(ctx context.Context, id, reason string) error {
+  _, err := p.q.Exec(ctx, `UPDATE spooled_transfers SET status='FAILED', updated_at=now(), fail_reason=$2 WHERE id=$1::uuid`, id, reason)
+  return err
+}
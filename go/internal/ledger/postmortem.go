@@ -0,0 +1,206 @@
+package ledger
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "io"
+  "sort"
+  "strings"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+)
+
+// DefaultPostmortemLead is how far before detection a postmortem looks.
+const DefaultPostmortemLead = 15 * time.Minute
+
+const (
+  maxPostmortemLead = 24 * time.Hour
+  maxPostmortemTxns = 200
+  maxPostmortemAudit = 500
+)
+
+// PostmortemEvent is one line of a postmortem's timeline.
+type PostmortemEvent struct {
+  At time.Time `json:"at"`
+  Source string `json:"source"` // incident|audit|controls
+  Actor string `json:"actor,omitempty"`
+  Summary string `json:"summary"`
+}
+
+// Postmortem bundles what happened around an incident: the zone's control
+// changes, transactions and audit entries from Lead before detection until it
+// was resolved (or now), merged into one timeline.
+type Postmortem struct {
+  Incident Incident `json:"incident"`
+  WindowStart time.Time `json:"window_start"`
+  WindowEnd time.Time `json:"window_end"`
+  ResolvedAt *time.Time `json:"resolved_at"`
+  Timeline []PostmortemEvent `json:"timeline"`
+  RelatedTransaction *TransactionRow `json:"related_transaction"`
+  // Transactions touching the zone in the window, oldest first, up to
+  // maxPostmortemTxns; TransactionsTruncated reports that more exist.
+  Transactions []TransactionRow `json:"transactions"`
+  TransactionsTruncated bool `json:"transactions_truncated"`
+  ControlChanges []ZoneControlsChange `json:"control_changes"`
+  Audit []AuditEntry `json:"audit"` // the zone's and the incident's, oldest first
+  GeneratedAt time.Time `json:"generated_at"`
+}
+
+// IncidentPostmortem gathers a postmortem for the incident. lead is how far
+// before detection the window opens; 0 means DefaultPostmortemLead.
+func (l *Ledger) IncidentPostmortem(ctx context.Context, id string, lead time.Duration) (*Postmortem, error) {
+  if lead == 0 { lead = DefaultPostmortemLead }
+  if lead < 0 || lead > maxPostmortemLead { return nil, fmt.Errorf("lead must be between 0 and %s", maxPostmortemLead) }
+  inc, err := l.GetIncident(ctx, id)
+  if err != nil { return nil, err }
+
+  pm := &Postmortem{Incident: *inc, WindowStart: inc.DetectedAt.Add(-lead), GeneratedAt: time.Now().UTC()}
+  pm.WindowEnd = pm.GeneratedAt
+  if inc.Status == "RESOLVED" {
+    var at time.Time
+    err := l.ro.QueryRow(ctx, `
+      SELECT created_at FROM audit_log
+      WHERE target_type='incident' AND target_id=$1 AND action='INCIDENT_RESOLVE'
+      ORDER BY created_at DESC LIMIT 1
+    `, inc.ID).Scan(&at)
+    if err == nil {
+      pm.ResolvedAt, pm.WindowEnd = &at, at
+    } else if !errors.Is(err, pgx.ErrNoRows) {
+      return nil, err
+    }
+  }
+
+  if inc.RelatedTxnID != nil {
+    t, err := scanTransactionRow(l.ro.QueryRow(ctx, `SELECT `+transactionRowCols+` FROM transactions WHERE id::text=$1`, *inc.RelatedTxnID))
+    if err != nil && !errors.Is(err, pgx.ErrNoRows) { return nil, err }
+    pm.RelatedTransaction = t
+  }
+
+  rows, err := l.ro.Query(ctx, `
+    SELECT `+transactionRowCols+` FROM transactions
+    WHERE (from_zone_id=$1 OR to_zone_id=$1) AND created_at BETWEEN $2 AND $3
+    ORDER BY created_at, id
+    LIMIT $4
+  `, inc.ZoneID, pm.WindowStart, pm.WindowEnd, maxPostmortemTxns+1)
+  if err != nil { return nil, err }
+  pm.Transactions = []TransactionRow{}
+  for rows.Next() {
+    t, err := scanTransactionRow(rows)
+    if err != nil { rows.Close(); return nil, err }
+    pm.Transactions = append(pm.Transactions, *t)
+  }
+  rows.Close()
+  if err := rows.Err(); err != nil { return nil, err }
+  if len(pm.Transactions) > maxPostmortemTxns {
+    pm.Transactions, pm.TransactionsTruncated = pm.Transactions[:maxPostmortemTxns], true
+  }
+
+  changes, err := l.ListZoneControlsHistory(ctx, inc.ZoneID, 0, pm.WindowStart, 1000)
+  if err != nil { return nil, err }
+  pm.ControlChanges = []ZoneControlsChange{}
+  for _, c := range changes {
+    if c.ChangedAt.After(pm.WindowEnd) { break }
+    pm.ControlChanges = append(pm.ControlChanges, c)
+  }
+
+  rows, err = l.ro.Query(ctx, `
+    SELECT id::text, actor, action, target_type, target_id, reason, details, created_at
+    FROM audit_log
+    WHERE ((target_type='zone' AND target_id=$1) OR (target_type='incident' AND target_id=$2))
+      AND created_at BETWEEN $3 AND $4 AND NOT `+drillHidden+`
+    ORDER BY created_at, id
+    LIMIT $5
+  `, inc.ZoneID, inc.ID, pm.WindowStart, pm.WindowEnd, maxPostmortemAudit)
+  if err != nil { return nil, err }
+  if pm.Audit, err = scanAuditEntries(rows); err != nil { return nil, err }
+
+  pm.Timeline = postmortemTimeline(pm)
+  return pm, nil
+}
+
+// postmortemTimeline merges detection, control changes and audit entries in
+// time order.
+func postmortemTimeline(pm *Postmortem) []PostmortemEvent {
+  events := []PostmortemEvent{{At: pm.Incident.DetectedAt, Source: "incident", Summary: fmt.Sprintf("%s incident detected: %s", pm.Incident.Severity, pm.Incident.Title)}}
+  for _, c := range pm.ControlChanges {
+    e := PostmortemEvent{At: c.ChangedAt, Source: "controls", Summary: fmt.Sprintf("controls v%d: %s", c.Version, controlsSummary(c.Controls))}
+    if c.Actor != nil { e.Actor = *c.Actor }
+    events = append(events, e)
+  }
+  for _, a := range pm.Audit {
+    s := a.Action
+    if note, _ := a.Details["note"].(string); note != "" { s += ": " + note }
+    if a.Reason != nil && *a.Reason != "" { s += " (" + *a.Reason + ")" }
+    events = append(events, PostmortemEvent{At: a.CreatedAt, Source: "audit", Actor: a.Actor, Summary: s})
+  }
+  // stable: detection stays ahead of anything recorded in the same instant
+  sort.SliceStable(events, func(i, j int) bool { return events[i].At.Before(events[j].At) })
+  return events
+}
+
+func controlsSummary(c ZoneControls) string {
+  return fmt.Sprintf("writes_blocked=%t cross_zone_throttle=%d spool_enabled=%t throttle_mode=%s", c.WritesBlocked, c.CrossZoneThrottle, c.SpoolEnabled, c.ThrottleMode)
+}
+
+// WriteMarkdown renders the postmortem as a Markdown document.
+func (pm *Postmortem) WriteMarkdown(w io.Writer) error {
+  var b strings.Builder
+  inc := pm.Incident
+  ts := func(t time.Time) string { return t.UTC().Format(time.RFC3339) }
+  fmt.Fprintf(&b, "# Postmortem: %s\n\n", inc.Title)
+  fmt.Fprintf(&b, "| | |\n|---|---|\n")
+  fmt.Fprintf(&b, "| Incident | `%s` |\n| Zone | %s |\n| Severity | %s |\n| Status | %s |\n| Detected | %s |\n", inc.ID, inc.ZoneID, inc.Severity, inc.Status, ts(inc.DetectedAt))
+  if pm.ResolvedAt != nil { fmt.Fprintf(&b, "| Resolved | %s |\n", ts(*pm.ResolvedAt)) }
+  fmt.Fprintf(&b, "| Window | %s – %s |\n", ts(pm.WindowStart), ts(pm.WindowEnd))
+  if inc.RelatedTxnID != nil { fmt.Fprintf(&b, "| Related transaction | `%s` |\n", *inc.RelatedTxnID) }
+
+  fmt.Fprintf(&b, "\n## Timeline\n\n| Time | Source | Actor | Event |\n|---|---|---|---|\n")
+  for _, e := range pm.Timeline {
+    fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", ts(e.At), e.Source, mdCell(e.Actor), mdCell(e.Summary))
+  }
+
+  fmt.Fprintf(&b, "\n## Zone control changes\n\n")
+  if len(pm.ControlChanges) == 0 {
+    b.WriteString("None in the window.\n")
+  } else {
+    b.WriteString("| Version | Changed | Actor | Controls |\n|---|---|---|---|\n")
+    for _, c := range pm.ControlChanges {
+      actor := ""
+      if c.Actor != nil { actor = *c.Actor }
+      fmt.Fprintf(&b, "| %d | %s | %s | %s |\n", c.Version, ts(c.ChangedAt), mdCell(actor), mdCell(controlsSummary(c.Controls)))
+    }
+  }
+
+  fmt.Fprintf(&b, "\n## Transactions\n\n")
+  if len(pm.Transactions) == 0 {
+    b.WriteString("None in the window.\n")
+  } else {
+    b.WriteString("| Time | ID | From | To | Amount |\n|---|---|---|---|---|\n")
+    for _, t := range pm.Transactions {
+      fmt.Fprintf(&b, "| %s | `%s` | %s | %s | %d |\n", ts(t.CreatedAt), t.ID, mdCell(t.FromAccount), mdCell(t.ToAccount), t.AmountUnits)
+    }
+    if pm.TransactionsTruncated { fmt.Fprintf(&b, "\nOnly the first %d are listed.\n", maxPostmortemTxns) }
+  }
+
+  fmt.Fprintf(&b, "\n## Audit\n\n")
+  if len(pm.Audit) == 0 {
+    b.WriteString("None in the window.\n")
+  } else {
+    b.WriteString("| Time | Actor | Action | Target | Reason |\n|---|---|---|---|---|\n")
+    for _, a := range pm.Audit {
+      reason := ""
+      if a.Reason != nil { reason = *a.Reason }
+      fmt.Fprintf(&b, "| %s | %s | %s | %s/%s | %s |\n", ts(a.CreatedAt), mdCell(a.Actor), a.Action, a.TargetType, mdCell(a.TargetID), mdCell(reason))
+    }
+  }
+  fmt.Fprintf(&b, "\n_Generated %s._\n", ts(pm.GeneratedAt))
+  _, err := io.WriteString(w, b.String())
+  return err
+}
+
+// mdCell makes s safe inside a Markdown table cell.
+func mdCell(s string) string {
+  return strings.NewReplacer("|", `\|`, "\n", " ", "\r", "").Replace(s)
+}
@@ -0,0 +1,79 @@
+package ledger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"time-ledger-sim/go/internal/store/storetest"
+)
+
+func TestIncidentPostmortem(t *testing.T) {
+	db := storetest.Open(t)
+	ctx := context.Background()
+	l := New(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	zone := "zone-pm-" + uuid.NewString()[:8]
+	if _, err := l.CreateZone(ctx, CreateZoneInput{ID: zone, Name: zone, Actor: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := l.CreateTransfer(ctx, CreateTransferInput{
+		RequestID: "pm-" + uuid.NewString(), PayloadHash: "h", FromAccount: zone + "-a", ToAccount: zone + "-b", AmountUnits: 5, ZoneID: zone,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.SetZoneControls(ctx, zone, SetZoneControlsInput{WritesBlocked: true, CrossZoneThrottle: 100, Actor: "test", Reason: "drill"}); err != nil {
+		t.Fatal(err)
+	}
+	var id string
+	if err := db.QueryRow(ctx, `SELECT id::text FROM incidents WHERE zone_id=$1`, zone).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.ApplyIncidentAction(ctx, id, IncidentAction{Action: "RESOLVE", Note: "writes | reopened", Actor: "test"}); err != nil {
+		t.Fatal(err)
+	}
+
+	pm, err := l.IncidentPostmortem(ctx, id, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pm.ResolvedAt == nil || !pm.WindowEnd.Equal(*pm.ResolvedAt) {
+		t.Errorf("resolved_at = %v, window end %v", pm.ResolvedAt, pm.WindowEnd)
+	}
+	if len(pm.Transactions) != 1 || pm.Transactions[0].AmountUnits != 5 {
+		t.Errorf("transactions = %+v", pm.Transactions)
+	}
+	if len(pm.ControlChanges) == 0 || !pm.ControlChanges[len(pm.ControlChanges)-1].Controls.WritesBlocked {
+		t.Errorf("control changes = %+v", pm.ControlChanges)
+	}
+	actions := map[string]bool{}
+	for _, a := range pm.Audit {
+		actions[a.Action] = true
+	}
+	if !actions["SET_ZONE_CONTROLS"] || !actions["INCIDENT_RESOLVE"] {
+		t.Errorf("audit actions = %v", actions)
+	}
+	for i := 1; i < len(pm.Timeline); i++ {
+		if pm.Timeline[i].At.Before(pm.Timeline[i-1].At) {
+			t.Fatalf("timeline out of order at %d: %+v", i, pm.Timeline)
+		}
+	}
+
+	var md strings.Builder
+	if err := pm.WriteMarkdown(&md); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"# Postmortem: Writes blocked by operator", "## Timeline", `INCIDENT_RESOLVE: writes \| reopened`, "## Zone control changes"} {
+		if !strings.Contains(md.String(), want) {
+			t.Errorf("markdown lacks %q:\n%s", want, md.String())
+		}
+	}
+
+	if _, err := l.IncidentPostmortem(ctx, id, -1); err == nil {
+		t.Error("negative lead accepted")
+	}
+}
@@ -0,0 +1,253 @@
+package ledger
+
+import (
+  "context"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "log/slog"
+  "time"
+
+  "github.com/google/uuid"
+  "github.com/jackc/pgx/v5"
+)
+
+var (
+  ErrPrepareNotFound = errors.New("prepared transfer not found")
+  ErrPrepareNotPending = errors.New("prepared transfer already resolved")
+  ErrPrepareExpired = errors.New("prepared transfer expired")
+)
+
+func IsPrepareNotFound(err error) bool { return errors.Is(err, ErrPrepareNotFound) }
+func IsPrepareNotPending(err error) bool { return errors.Is(err, ErrPrepareNotPending) }
+func IsPrepareExpired(err error) bool { return errors.Is(err, ErrPrepareExpired) }
+
+const (
+  DefaultPrepareTTL = 5 * time.Minute
+  minPrepareTTL = time.Second
+  maxPrepareTTL = 24 * time.Hour
+)
+
+// PreparedTransfer is the first phase of a two-phase transfer: it passed the
+// transfer gates and holds its amount until committed, aborted or expired.
+type PreparedTransfer struct {
+  Token string `json:"token"`
+  RequestID string `json:"request_id"`
+  ZoneID string `json:"zone_id"`
+  FromAccount string `json:"from_account"`
+  ToAccount string `json:"to_account"`
+  AmountUnits int64 `json:"amount_units"`
+  Type string `json:"type"`
+  Metadata map[string]any `json:"metadata"`
+  Status string `json:"status"` // PREPARED|COMMITTED|ABORTED|EXPIRED
+  ExpiresAt time.Time `json:"expires_at"`
+  TransactionID *string `json:"transaction_id"` // set once COMMITTED
+  AbortReason *string `json:"abort_reason"`
+  CreatedAt time.Time `json:"created_at"`
+  ResolvedAt *time.Time `json:"resolved_at"`
+
+  payloadHash string
+}
+
+const prepareCols = `id::text, request_id, payload_hash, zone_id, from_account, to_account, amount_units, type, metadata, status,
+  expires_at, txn_id::text, abort_reason, created_at, resolved_at`
+
+func scanPreparedTransfer(row pgx.Row) (*PreparedTransfer, error) {
+  var p PreparedTransfer
+  var meta []byte
+  err := row.Scan(&p.Token, &p.RequestID, &p.payloadHash, &p.ZoneID, &p.FromAccount, &p.ToAccount, &p.AmountUnits, &p.Type, &meta, &p.Status,
+    &p.ExpiresAt, &p.TransactionID, &p.AbortReason, &p.CreatedAt, &p.ResolvedAt)
+  if err != nil { return nil, err }
+  _ = json.Unmarshal(meta, &p.Metadata)
+  return &p, nil
+}
+
+// transferInput is the transfer a commit posts, under the prepare's request_id.
+func (p *PreparedTransfer) transferInput() CreateTransferInput {
+  return CreateTransferInput{
+    RequestID: p.RequestID, PayloadHash: p.payloadHash, FromAccount: p.FromAccount, ToAccount: p.ToAccount,
+    AmountUnits: p.AmountUnits, ZoneID: p.ZoneID, Type: p.Type, Metadata: p.Metadata,
+    EventContext: map[string]any{"prepare_token": p.Token},
+  }
+}
+
+// PrepareTransfer runs the transfer gates as CreateTransfer does (taking a
+// rate limit token) and records a hold that expires after ttl (0 for
+// DefaultPrepareTTL). A transfer that would be spooled cannot be prepared and
+// fails with ErrZoneBlocked or ErrPartitioned. Preparing a request_id again
+// with the same payload returns the first prepare.
+func (l *Ledger) PrepareTransfer(ctx context.Context, in CreateTransferInput, ttl time.Duration) (*PreparedTransfer, error) {
+  if ttl == 0 { ttl = DefaultPrepareTTL }
+  if ttl < minPrepareTTL || ttl > maxPrepareTTL { return nil, fmt.Errorf("ttl must be between %s and %s", minPrepareTTL, maxPrepareTTL) }
+  if err := checkTransactionType(in.Type); err != nil { return nil, err }
+  if in.Metadata == nil { in.Metadata = map[string]any{} }
+  metaBytes, err := json.Marshal(in.Metadata)
+  if err != nil { return nil, err }
+
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+  q := pgQueries{tx}
+
+  if p, err := l.preparedByRequest(ctx, tx, in); p != nil || err != nil { return p, err }
+
+  est, err := l.checkTransfer(ctx, q, in, true)
+  if err != nil { return nil, err }
+  switch {
+  case est.Replay:
+    return nil, fmt.Errorf("%w: request_id already used by a transfer", ErrIdempotencyConflict)
+  case est.Outcome == EstimateRejected:
+    l.logTransfer(ctx, slog.LevelInfo, "prepare rejected", in, "reason", est.Reason)
+    return nil, est.Err
+  case est.Outcome == EstimateSpooled:
+    l.logTransfer(ctx, slog.LevelInfo, "prepare rejected", in, "reason", est.Reason)
+    if p, _ := l.partitionForTransfer(ctx, q, in.ZoneID, in.ToAccount); p != nil { return nil, fmt.Errorf("%w: %s -> %s", ErrPartitioned, p.FromZone, p.ToZone) }
+    return nil, fmt.Errorf("%w: %s", ErrZoneBlocked, est.Reason)
+  }
+
+  now := l.clock.Now()
+  p, err := scanPreparedTransfer(tx.QueryRow(ctx, `
+    INSERT INTO transfer_prepares(request_id,payload_hash,zone_id,from_account,to_account,amount_units,metadata,expires_at,created_at,type)
+    VALUES($1,$2,$3,$4,$5,$6,$7::jsonb,$8,$9,$10)
+    ON CONFLICT (request_id) DO NOTHING
+    RETURNING `+prepareCols,
+    in.RequestID, in.PayloadHash, in.ZoneID, in.FromAccount, in.ToAccount, in.AmountUnits, string(metaBytes), now.Add(ttl), now, transactionType(in)))
+  if errors.Is(err, pgx.ErrNoRows) {
+    // a concurrent prepare of the same request_id committed first
+    _ = tx.Rollback(ctx)
+    p, err := l.preparedByRequest(ctx, l.db, in)
+    if p == nil && err == nil { err = ErrRequestExists }
+    return p, err
+  }
+  if err != nil { return nil, err }
+
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  l.logTransfer(ctx, slog.LevelInfo, "transfer prepared", in, "prepare_token", p.Token, "expires_at", p.ExpiresAt)
+  return p, nil
+}
+
+// preparedByRequest returns the prepare recorded for the request, nil if
+// there is none, or ErrIdempotencyConflict when its payload differs.
+func (l *Ledger) preparedByRequest(ctx context.Context, db querier, in CreateTransferInput) (*PreparedTransfer, error) {
+  p, err := scanPreparedTransfer(db.QueryRow(ctx, `SELECT `+prepareCols+` FROM transfer_prepares WHERE request_id=$1`, in.RequestID))
+  if errors.Is(err, pgx.ErrNoRows) { return nil, nil }
+  if err != nil { return nil, err }
+  if p.payloadHash != in.PayloadHash { return nil, ErrIdempotencyConflict }
+  return p, nil
+}
+
+func (l *Ledger) GetPreparedTransfer(ctx context.Context, token string) (*PreparedTransfer, error) {
+  if _, err := uuid.Parse(token); err != nil { return nil, ErrPrepareNotFound }
+  p, err := scanPreparedTransfer(l.db.QueryRow(ctx, `SELECT `+prepareCols+` FROM transfer_prepares WHERE id=$1::uuid`, token))
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrPrepareNotFound }
+  return p, err
+}
+
+// lockPrepared locks a prepare for commit or abort. One whose TTL has passed
+// but that the sweeper has not reached yet is expired here (committing tx)
+// and reported as ErrPrepareExpired.
+func (l *Ledger) lockPrepared(ctx context.Context, tx pgx.Tx, token string) (*PreparedTransfer, error) {
+  if _, err := uuid.Parse(token); err != nil { return nil, ErrPrepareNotFound }
+  p, err := scanPreparedTransfer(tx.QueryRow(ctx, `SELECT `+prepareCols+` FROM transfer_prepares WHERE id=$1::uuid FOR UPDATE`, token))
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrPrepareNotFound }
+  if err != nil { return nil, err }
+  if p.Status == "PREPARED" && !l.clock.Now().Before(p.ExpiresAt) {
+    _, err := tx.Exec(ctx, `UPDATE transfer_prepares SET status='EXPIRED', resolved_at=$2 WHERE id=$1::uuid`, p.Token, l.clock.Now())
+    if err != nil { return nil, err }
+    if err := tx.Commit(ctx); err != nil { return nil, err }
+    return nil, ErrPrepareExpired
+  }
+  if p.Status == "EXPIRED" { return nil, ErrPrepareExpired }
+  return p, nil
+}
+
+// CommitPreparedTransfer posts a prepared transfer as a regular transaction
+// with the prepare's request_id. The availability gates were passed at
+// prepare and are not run again, except that a DOWN zone refuses the commit
+// until it is back or the prepare expires. Account controls and amount limits
+// are checked again. Committing again returns the committed prepare.
+func (l *Ledger) CommitPreparedTransfer(ctx context.Context, token string) (*PreparedTransfer, error) {
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+  q := pgQueries{tx}
+
+  p, err := l.lockPrepared(ctx, tx, token)
+  if err != nil { return nil, err }
+  switch p.Status {
+  case "COMMITTED":
+    return p, nil
+  case "ABORTED":
+    return nil, fmt.Errorf("%w: aborted", ErrPrepareNotPending)
+  }
+
+  status, controls, err := l.zoneState(ctx, q, p.ZoneID)
+  if err != nil { return nil, err }
+  if status == "DOWN" { return nil, ErrZoneDown }
+
+  // account blocks and amount limits set since the prepare still apply; the
+  // prepare's own hold is not counted against the daily limit
+  in := p.transferInput()
+  if err := l.checkAccountControls(ctx, q, in); err != nil { return nil, err }
+  if err := l.checkAmountLimits(ctx, q, in, controls); err != nil {
+    if isAmountLimit(err) { l.logTransfer(ctx, slog.LevelInfo, "prepared commit rejected", in, "prepare_token", p.Token, "reason", err.Error()) }
+    return nil, err
+  }
+
+  metaBytes, _ := json.Marshal(in.Metadata)
+  txn, err := l.applyTransfer(ctx, q, in, metaBytes, controls.ClockSkewMs)
+  if errors.Is(err, ErrRequestExists) { return nil, fmt.Errorf("%w: request_id used by another transfer", ErrIdempotencyConflict) }
+  if err != nil { return nil, err }
+
+  p, err = scanPreparedTransfer(tx.QueryRow(ctx, `
+    UPDATE transfer_prepares SET status='COMMITTED', txn_id=$2::uuid, resolved_at=$3 WHERE id=$1::uuid
+    RETURNING `+prepareCols, p.Token, txn.ID, l.clock.Now()))
+  if err != nil { return nil, err }
+
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  l.logTransfer(ctx, slog.LevelInfo, "prepared transfer committed", in, "prepare_token", p.Token, "txn_id", txn.ID)
+  return p, nil
+}
+
+// AbortPreparedTransfer releases a prepared transfer's hold. Aborting again
+// returns the aborted prepare.
+func (l *Ledger) AbortPreparedTransfer(ctx context.Context, token, reason string) (*PreparedTransfer, error) {
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  p, err := l.lockPrepared(ctx, tx, token)
+  if err != nil { return nil, err }
+  switch p.Status {
+  case "ABORTED":
+    return p, nil
+  case "COMMITTED":
+    return nil, fmt.Errorf("%w: committed", ErrPrepareNotPending)
+  }
+
+  p, err = scanPreparedTransfer(tx.QueryRow(ctx, `
+    UPDATE transfer_prepares SET status='ABORTED', abort_reason=NULLIF($2,''), resolved_at=$3 WHERE id=$1::uuid
+    RETURNING `+prepareCols, p.Token, reason, l.clock.Now()))
+  if err != nil { return nil, err }
+
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  l.logTransfer(ctx, slog.LevelInfo, "prepared transfer aborted", p.transferInput(), "prepare_token", p.Token, "reason", reason)
+  return p, nil
+}
+
+// ExpirePreparedTransfers aborts up to limit prepares whose TTL has passed on
+// the sim clock, marking them EXPIRED.
+func (l *Ledger) ExpirePreparedTransfers(ctx context.Context, limit int) (int, error) {
+  tag, err := l.db.Exec(ctx, `
+    UPDATE transfer_prepares SET status='EXPIRED', resolved_at=$1
+    WHERE id IN (
+      SELECT id FROM transfer_prepares
+      WHERE status='PREPARED' AND expires_at <= $1
+      ORDER BY expires_at
+      LIMIT $2
+      FOR UPDATE SKIP LOCKED
+    )
+  `, l.clock.Now(), limit)
+  if err != nil { return 0, err }
+  return int(tag.RowsAffected()), nil
+}
@@ -0,0 +1,152 @@
+package ledger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"time-ledger-sim/go/internal/store/storetest"
+)
+
+func TestTwoPhaseTransfer(t *testing.T) {
+	db := storetest.Open(t)
+	ctx := context.Background()
+	l := New(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	clock := NewVirtualClock()
+	clock.Freeze(time.Now())
+	l.SetClock(clock)
+
+	zone := "zone-2pc-" + strings.ReplaceAll(uuid.NewString()[:8], "-", "")
+	if _, err := l.CreateZone(ctx, CreateZoneInput{ID: zone, Name: zone, Actor: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	in := func(amount int64) CreateTransferInput {
+		id := uuid.NewString()
+		return CreateTransferInput{RequestID: id, PayloadHash: "h-" + id, FromAccount: zone + "-a", ToAccount: zone + "-b", AmountUnits: amount, ZoneID: zone}
+	}
+
+	first := in(40)
+	p, err := l.PrepareTransfer(ctx, first, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, err := l.PrepareTransfer(ctx, first, 0); err != nil || again.Token != p.Token {
+		t.Fatalf("repeated prepare = %+v, %v", again, err)
+	}
+	committed, err := l.CommitPreparedTransfer(ctx, p.Token)
+	if err != nil || committed.Status != "COMMITTED" || committed.TransactionID == nil {
+		t.Fatalf("commit = %+v, %v", committed, err)
+	}
+	if again, err := l.CommitPreparedTransfer(ctx, p.Token); err != nil || *again.TransactionID != *committed.TransactionID {
+		t.Fatalf("repeated commit = %+v, %v", again, err)
+	}
+	if _, err := l.AbortPreparedTransfer(ctx, p.Token, "late"); !IsPrepareNotPending(err) {
+		t.Fatalf("abort after commit: err = %v", err)
+	}
+	// the transfer itself replays under the prepare's request_id
+	txn, _, err := l.CreateTransfer(ctx, first)
+	if err != nil || txn.ID != *committed.TransactionID {
+		t.Fatalf("transfer replay = %+v, %v", txn, err)
+	}
+
+	aborted, err := l.PrepareTransfer(ctx, in(10), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, err := l.AbortPreparedTransfer(ctx, aborted.Token, "changed my mind"); err != nil || p.Status != "ABORTED" {
+		t.Fatalf("abort = %+v, %v", p, err)
+	}
+	if _, err := l.CommitPreparedTransfer(ctx, aborted.Token); !IsPrepareNotPending(err) {
+		t.Fatalf("commit after abort: err = %v", err)
+	}
+
+	expiring, err := l.PrepareTransfer(ctx, in(10), time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(2 * time.Minute)
+	if _, err := l.ExpirePreparedTransfers(ctx, 100); err != nil {
+		t.Fatal(err)
+	}
+	if p, err := l.GetPreparedTransfer(ctx, expiring.Token); err != nil || p.Status != "EXPIRED" {
+		t.Fatalf("after ttl = %+v, %v", p, err)
+	}
+	if _, err := l.CommitPreparedTransfer(ctx, expiring.Token); !IsPrepareExpired(err) {
+		t.Fatalf("commit after ttl: err = %v", err)
+	}
+
+	if _, err := l.SetZoneControls(ctx, zone, SetZoneControlsInput{WritesBlocked: true, CrossZoneThrottle: 100, SpoolEnabled: true, Actor: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.PrepareTransfer(ctx, in(10), 0); !IsZoneBlocked(err) {
+		t.Fatalf("prepare in a blocked zone: err = %v", err)
+	}
+	if _, err := l.GetPreparedTransfer(ctx, "not-a-token"); !IsPrepareNotFound(err) {
+		t.Fatalf("bad token: err = %v", err)
+	}
+}
+
+func TestPreparesHoldDailyLimit(t *testing.T) {
+	db := storetest.Open(t)
+	ctx := context.Background()
+	l := New(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	clock := NewVirtualClock()
+	clock.Freeze(time.Now())
+	l.SetClock(clock)
+
+	zone := "zone-hold-" + strings.ReplaceAll(uuid.NewString()[:8], "-", "")
+	if _, err := l.CreateZone(ctx, CreateZoneInput{ID: zone, Name: zone, Actor: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.SetZoneControls(ctx, zone, SetZoneControlsInput{CrossZoneThrottle: 100, DailyAccountLimitUnits: 100, Actor: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	in := func(amount int64) CreateTransferInput {
+		id := uuid.NewString()
+		return CreateTransferInput{RequestID: id, PayloadHash: "h-" + id, FromAccount: zone + "-a", ToAccount: zone + "-b", AmountUnits: amount, ZoneID: zone}
+	}
+
+	first, err := l.PrepareTransfer(ctx, in(60), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// together the two prepares would go past the limit
+	if _, err := l.PrepareTransfer(ctx, in(60), 0); !IsDailyLimitExceeded(err) {
+		t.Fatalf("second prepare: err = %v", err)
+	}
+	if _, _, err := l.CreateTransfer(ctx, in(60)); !IsDailyLimitExceeded(err) {
+		t.Fatalf("transfer past the hold: err = %v", err)
+	}
+	est, err := l.EstimateTransfer(ctx, in(10))
+	if err != nil || est.Balances[0].HeldUnits != 60 {
+		t.Fatalf("estimate = %+v, %v", est, err)
+	}
+
+	// the prepare's own hold does not count against its commit
+	if p, err := l.CommitPreparedTransfer(ctx, first.Token); err != nil || p.Status != "COMMITTED" {
+		t.Fatalf("commit = %+v, %v", p, err)
+	}
+
+	// a limit lowered after the prepare is enforced at commit
+	second, err := l.PrepareTransfer(ctx, in(30), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.SetZoneControls(ctx, zone, SetZoneControlsInput{CrossZoneThrottle: 100, DailyAccountLimitUnits: 80, Actor: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.CommitPreparedTransfer(ctx, second.Token); !IsDailyLimitExceeded(err) {
+		t.Fatalf("commit past a lowered limit: err = %v", err)
+	}
+	if _, err := l.AbortPreparedTransfer(ctx, second.Token, "over the limit"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.PrepareTransfer(ctx, in(20), 0); err != nil {
+		t.Fatalf("prepare after the abort released the hold: %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package ledger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"time-ledger-sim/go/internal/store/storetest"
+)
+
+func testChain(n int) []chainLink {
+	v := newChainVerifier()
+	var links []chainLink
+	for i := 1; i <= n; i++ {
+		c := chainLink{
+			Seq: int64(i), PrevHash: v.hash, TxnID: "00000000-0000-0000-0000-00000000000" + string(rune('0'+i)),
+			RequestID: "req|" + string(rune('a'+i)), PayloadHash: "h", ZoneID: "zone-eu", FromAccount: "acct-a", ToAccount: "acct-b",
+			AmountUnits: int64(10 * i), CreatedAt: time.Date(2026, 1, 1, 0, 0, i, 1000, time.UTC),
+		}
+		c.Hash = chainHash(v.hash, c)
+		v.seq, v.hash = c.Seq, c.Hash
+		links = append(links, c)
+	}
+	return links
+}
+
+func verifyLinks(links []chainLink) *ChainBreak {
+	v := newChainVerifier()
+	for _, c := range links {
+		if b := v.next(c); b != nil {
+			return b
+		}
+	}
+	return nil
+}
+
+func TestChainVerifier(t *testing.T) {
+	if b := verifyLinks(testChain(4)); b != nil {
+		t.Fatalf("intact chain: %+v", b)
+	}
+
+	tampered := testChain(4)
+	tampered[2].AmountUnits++
+	if b := verifyLinks(tampered); b == nil || b.Seq != 3 {
+		t.Fatalf("changed amount: break = %+v, want at seq 3", b)
+	}
+
+	removed := testChain(4)
+	removed = append(removed[:1], removed[2:]...)
+	if b := verifyLinks(removed); b == nil || b.Seq != 2 || b.Reason != "link missing" {
+		t.Fatalf("removed link: break = %+v, want missing seq 2", b)
+	}
+
+	// a forged link with a recomputed hash still fails against its successor
+	forged := testChain(4)
+	forged[1].ToAccount = "acct-x"
+	forged[1].Hash = chainHash(forged[1].PrevHash, forged[1])
+	if b := verifyLinks(forged); b == nil || b.Seq != 3 {
+		t.Fatalf("forged link: break = %+v, want at seq 3", b)
+	}
+
+	// length prefixes keep field boundaries unambiguous
+	a, b := testChain(1)[0], testChain(1)[0]
+	a.FromAccount, a.ToAccount = "acct-ab", "c"
+	b.FromAccount, b.ToAccount = "acct-a", "bc"
+	if string(chainPayload(a)) == string(chainPayload(b)) {
+		t.Fatal("payloads of different transactions collide")
+	}
+}
+
+// TestLedgerProofMatchesDatabase checks that the chain the migration's
+// trigger builds verifies in Go, and that editing a transaction breaks it.
+func TestLedgerProofMatchesDatabase(t *testing.T) {
+	db := storetest.Open(t)
+	ctx := context.Background()
+	l := New(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	for _, req := range []string{"proof-1", "proof-2", "proof-3"} {
+		if _, _, err := l.CreateTransfer(ctx, CreateTransferInput{
+			RequestID: req, PayloadHash: req, FromAccount: "proof-a", ToAccount: "proof-b", AmountUnits: 5, ZoneID: "zone-eu",
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p, err := l.LedgerProof(ctx, "zone-eu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.Valid || p.Length < 3 || p.Length != p.HeadSeq {
+		t.Fatalf("proof = %+v", p)
+	}
+
+	if _, err := db.Exec(ctx, `UPDATE transactions SET amount_units=6 WHERE request_id='proof-2'`); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _, _ = db.Exec(ctx, `UPDATE transactions SET amount_units=5 WHERE request_id='proof-2'`) })
+	p, err = l.LedgerProof(ctx, "zone-eu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Valid || p.Break == nil {
+		t.Fatalf("tampered proof = %+v, want a break", p)
+	}
+
+	if _, err := l.LedgerProof(ctx, "zone-nowhere"); !IsZoneNotFound(err) {
+		t.Fatalf("unknown zone err = %v", err)
+	}
+}
@@ -0,0 +1,259 @@
+package ledger
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+  "github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+  ErrThrottleRampNotFound = errors.New("throttle ramp not found")
+  ErrThrottleRampRunning = errors.New("zone already has a running throttle ramp")
+)
+
+func IsThrottleRampNotFound(err error) bool { return errors.Is(err, ErrThrottleRampNotFound) }
+func IsThrottleRampRunning(err error) bool { return errors.Is(err, ErrThrottleRampRunning) }
+
+const (
+  defaultRampStepPercent = 5
+  maxRampMinutes = 24 * 60
+)
+
+// ThrottleRamp raises a zone's hash throttle from FromPercent to 100% over
+// DurationSeconds, StepPercent at a time, so a recovered zone takes traffic
+// back gradually. Each step is an audited SET_ZONE_CONTROLS by the ramp's
+// actor.
+type ThrottleRamp struct {
+  ID string `json:"id"`
+  ZoneID string `json:"zone_id"`
+  FromPercent int `json:"from_percent"`
+  StepPercent int `json:"step_percent"`
+  DurationSeconds int `json:"duration_seconds"`
+  CurrentPercent int `json:"current_percent"`
+  Status string `json:"status"` // RUNNING, COMPLETED, CANCELLED or FAILED
+  Actor string `json:"actor"`
+  Reason *string `json:"reason"`
+  FailReason *string `json:"fail_reason"`
+  StartedAt time.Time `json:"started_at"`
+  NextStepAt *time.Time `json:"next_step_at"` // nil once the ramp is over
+  EndsAt time.Time `json:"ends_at"`
+  FinishedAt *time.Time `json:"finished_at"`
+}
+
+const throttleRampCols = `id::text, zone_id, from_percent, step_percent, duration_seconds, current_percent, status, actor, reason, fail_reason, started_at, next_step_at, finished_at`
+
+func scanThrottleRamp(row pgx.Row) (*ThrottleRamp, error) {
+  var r ThrottleRamp
+  if err := row.Scan(&r.ID, &r.ZoneID, &r.FromPercent, &r.StepPercent, &r.DurationSeconds, &r.CurrentPercent, &r.Status, &r.Actor, &r.Reason, &r.FailReason, &r.StartedAt, &r.NextStepAt, &r.FinishedAt); err != nil {
+    return nil, err
+  }
+  r.EndsAt = r.StartedAt.Add(time.Duration(r.DurationSeconds) * time.Second)
+  return &r, nil
+}
+
+// stepAt is where the ramp should be at now: the throttle percent and the
+// next step's time, or a nil time once it has reached 100%. A controller
+// that falls behind catches up in one step.
+func (r *ThrottleRamp) stepAt(now time.Time) (int, *time.Time) {
+  steps := (100 - r.FromPercent + r.StepPercent - 1) / r.StepPercent
+  interval := time.Duration(r.DurationSeconds) * time.Second / time.Duration(steps)
+  k := int(now.Sub(r.StartedAt) / interval)
+  if k >= steps { return 100, nil }
+  next := r.StartedAt.Add(time.Duration(k+1) * interval)
+  return r.FromPercent + k*r.StepPercent, &next
+}
+
+type StartThrottleRampInput struct {
+  FromPercent int
+  Minutes int
+  StepPercent int // 0 means 5
+  Actor string
+  ReasonCode string
+  Reason string
+}
+
+// StartThrottleRamp sets the zone's throttle to FromPercent (in HASH mode)
+// and records a ramp the ControlScheduler takes to 100%. A zone runs one
+// ramp at a time.
+func (l *Ledger) StartThrottleRamp(ctx context.Context, zoneID string, in StartThrottleRampInput) (*ThrottleRamp, error) {
+  if in.StepPercent == 0 { in.StepPercent = defaultRampStepPercent }
+  if in.FromPercent < 1 || in.FromPercent > 99 { return nil, fmt.Errorf("from_percent must be between 1 and 99") }
+  if in.Minutes < 1 || in.Minutes > maxRampMinutes { return nil, fmt.Errorf("minutes must be between 1 and %d", maxRampMinutes) }
+  if in.StepPercent < 1 || in.StepPercent > 100-in.FromPercent { return nil, fmt.Errorf("step_percent must be between 1 and %d", 100-in.FromPercent) }
+
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+  q := pgQueries{tx}
+
+  if err := l.checkReasonCode(ctx, tx, ReasonForZoneControls, in.ReasonCode); err != nil { return nil, err }
+  c, err := q.ZoneControls(ctx, zoneID)
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrZoneNotFound }
+  if err != nil { return nil, err }
+
+  now := l.clock.Now()
+  r, err := scanThrottleRamp(tx.QueryRow(ctx, `
+    INSERT INTO throttle_ramps(zone_id,from_percent,step_percent,duration_seconds,current_percent,actor,reason,started_at)
+    VALUES($1,$2,$3,$4,$2,$5,NULLIF($6,''),$7)
+    RETURNING `+throttleRampCols,
+    zoneID, in.FromPercent, in.StepPercent, in.Minutes*60, in.Actor, in.Reason, now))
+  var pgErr *pgconn.PgError
+  if errors.As(err, &pgErr) && pgErr.Code == "23505" { return nil, ErrThrottleRampRunning }
+  if err != nil { return nil, err }
+  _, next := r.stepAt(now)
+  if _, err := tx.Exec(ctx, `UPDATE throttle_ramps SET next_step_at=$2 WHERE id=$1::uuid`, r.ID, next); err != nil { return nil, err }
+  r.NextStepAt = next
+
+  // the first step; setZoneControlsTx checks the actor
+  set := c.input()
+  set.CrossZoneThrottle, set.ThrottleMode = in.FromPercent, ThrottleModeHash
+  set.Actor, set.ReasonCode, set.Reason = in.Actor, in.ReasonCode, fmt.Sprintf("throttle ramp %s: start at %d%%", r.ID, in.FromPercent)
+  if _, err := l.setZoneControlsTx(ctx, tx, zoneID, set); err != nil { return nil, err }
+
+  err = l.audit(ctx, q, AuditRecord{
+    Actor: in.Actor, Action: "START_THROTTLE_RAMP", TargetType: "zone", TargetID: zoneID, Reason: in.Reason, ReasonCode: in.ReasonCode,
+    Details: map[string]any{"ramp_id": r.ID, "from_percent": in.FromPercent, "step_percent": in.StepPercent, "minutes": in.Minutes},
+  })
+  if err != nil { return nil, err }
+
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  l.zones.invalidate(zoneID)
+  return r, nil
+}
+
+func (l *Ledger) GetThrottleRamp(ctx context.Context, id string) (*ThrottleRamp, error) {
+  r, err := scanThrottleRamp(l.db.QueryRow(ctx, `SELECT `+throttleRampCols+` FROM throttle_ramps WHERE id::text=$1`, id))
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrThrottleRampNotFound }
+  return r, err
+}
+
+// ListThrottleRamps returns the zone's ramps, newest first.
+func (l *Ledger) ListThrottleRamps(ctx context.Context, zoneID string, limit int) ([]ThrottleRamp, error) {
+  if limit <= 0 || limit > 500 { limit = 50 }
+  rows, err := l.db.Query(ctx, `
+    SELECT `+throttleRampCols+` FROM throttle_ramps
+    WHERE zone_id=$1
+    ORDER BY started_at DESC, id
+    LIMIT $2
+  `, zoneID, limit)
+  if err != nil { return nil, err }
+  defer rows.Close()
+
+  out := []ThrottleRamp{}
+  for rows.Next() {
+    r, err := scanThrottleRamp(rows)
+    if err != nil { return nil, err }
+    out = append(out, *r)
+  }
+  return out, rows.Err()
+}
+
+// CancelThrottleRamp stops a running ramp. The throttle stays where the
+// last step left it.
+func (l *Ledger) CancelThrottleRamp(ctx context.Context, id, actor, reason string) (*ThrottleRamp, error) {
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  if err := l.checkActors(ctx, tx, actor); err != nil { return nil, err }
+
+  r, err := scanThrottleRamp(tx.QueryRow(ctx, `
+    UPDATE throttle_ramps SET status='CANCELLED', next_step_at=NULL, finished_at=$2
+    WHERE id::text=$1 AND status='RUNNING'
+    RETURNING `+throttleRampCols, id, l.clock.Now()))
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrThrottleRampNotFound }
+  if err != nil { return nil, err }
+
+  err = l.audit(ctx, pgQueries{tx}, AuditRecord{
+    Actor: actor, Action: "CANCEL_THROTTLE_RAMP", TargetType: "zone", TargetID: r.ZoneID, Reason: reason,
+    Details: map[string]any{"ramp_id": r.ID, "current_percent": r.CurrentPercent},
+  })
+  if err != nil { return nil, err }
+
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  return r, nil
+}
+
+// ApplyDueThrottleRamps takes up to limit ramps whose next step is due one
+// step further. Ramps are claimed with SKIP LOCKED, like scheduled changes.
+func (l *Ledger) ApplyDueThrottleRamps(ctx context.Context, limit int) (int, error) {
+  stepped := 0
+  for i := 0; i < limit; i++ {
+    ok, err := l.applyNextDueThrottleRamp(ctx)
+    if err != nil { return stepped, err }
+    if !ok { break }
+    stepped++
+  }
+  return stepped, nil
+}
+
+func (l *Ledger) applyNextDueThrottleRamp(ctx context.Context) (bool, error) {
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return false, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  now := l.clock.Now()
+  r, err := scanThrottleRamp(tx.QueryRow(ctx, `
+    SELECT `+throttleRampCols+` FROM throttle_ramps
+    WHERE status='RUNNING' AND next_step_at <= $1
+    ORDER BY next_step_at
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+  `, now))
+  if errors.Is(err, pgx.ErrNoRows) { return false, nil }
+  if err != nil { return false, err }
+
+  c, err := pgQueries{tx}.ZoneControls(ctx, r.ZoneID)
+  if err != nil { return false, err }
+  pct, next := r.stepAt(now)
+
+  // an operator who changed the throttle meanwhile owns it now; so does a
+  // step that fails, e.g. because the ramp's actor was deactivated
+  failReason := ""
+  if c.ThrottleMode != ThrottleModeHash || c.CrossZoneThrottle != r.CurrentPercent {
+    failReason = "throttle changed outside the ramp"
+  } else {
+    set := c.input()
+    set.CrossZoneThrottle = pct
+    set.Actor, set.Reason = r.Actor, fmt.Sprintf("throttle ramp %s: %d%% -> %d%%", r.ID, r.CurrentPercent, pct)
+    sp, err := tx.Begin(ctx)
+    if err != nil { return false, err }
+    if _, err := l.setZoneControlsTx(ctx, sp, r.ZoneID, set); err != nil {
+      _ = sp.Rollback(ctx)
+      failReason = err.Error()
+    } else if err := sp.Commit(ctx); err != nil {
+      return false, err
+    }
+  }
+
+  status := "RUNNING"
+  switch {
+  case failReason != "":
+    status, pct, next = "FAILED", r.CurrentPercent, nil
+  case next == nil:
+    status = "COMPLETED"
+  }
+  var finishedAt *time.Time
+  if status != "RUNNING" { finishedAt = &now }
+  _, err = tx.Exec(ctx, `
+    UPDATE throttle_ramps SET current_percent=$2, next_step_at=$3, status=$4, fail_reason=NULLIF($5,''), finished_at=$6
+    WHERE id=$1::uuid
+  `, r.ID, pct, next, status, failReason, finishedAt)
+  if err != nil { return false, err }
+
+  if status != "RUNNING" {
+    err = l.audit(ctx, pgQueries{tx}, AuditRecord{
+      Actor: "scheduler", Action: "FINISH_THROTTLE_RAMP", TargetType: "zone", TargetID: r.ZoneID, Reason: failReason,
+      Details: map[string]any{"ramp_id": r.ID, "status": status, "current_percent": pct, "started_by": r.Actor},
+    })
+    if err != nil { return false, err }
+  }
+
+  if err := tx.Commit(ctx); err != nil { return false, err }
+  if failReason == "" { l.zones.invalidate(r.ZoneID) }
+  return true, nil
+}
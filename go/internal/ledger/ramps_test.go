@@ -0,0 +1,116 @@
+package ledger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"time-ledger-sim/go/internal/store/storetest"
+)
+
+func TestThrottleRampSteps(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	// 40 -> 100 by 25: steps at 40, 65, 90, then 100; 4 minutes over 3 steps
+	r := &ThrottleRamp{FromPercent: 40, StepPercent: 25, DurationSeconds: 240, StartedAt: start}
+	for _, c := range []struct {
+		at   time.Duration
+		pct  int
+		next time.Duration
+	}{
+		{0, 40, 80 * time.Second},
+		{79 * time.Second, 40, 80 * time.Second},
+		{80 * time.Second, 65, 160 * time.Second},
+		{170 * time.Second, 90, 240 * time.Second},
+		{240 * time.Second, 100, -1},
+		{time.Hour, 100, -1}, // a controller that fell behind catches up
+	} {
+		pct, next := r.stepAt(start.Add(c.at))
+		if pct != c.pct || (next == nil) != (c.next < 0) || (next != nil && !next.Equal(start.Add(c.next))) {
+			t.Errorf("at %s: %d%%, next %v; want %d%%, next %s", c.at, pct, next, c.pct, c.next)
+		}
+	}
+}
+
+func TestThrottleRamp(t *testing.T) {
+	db := storetest.Open(t)
+	ctx := context.Background()
+	l := New(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	clock := NewVirtualClock()
+	clock.Freeze(time.Now())
+	l.SetClock(clock)
+
+	zone := "zone-ramp-" + uuid.NewString()[:8]
+	if _, err := l.CreateZone(ctx, CreateZoneInput{ID: zone, Name: zone, Actor: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := l.StartThrottleRamp(ctx, zone, StartThrottleRampInput{FromPercent: 50, Minutes: 2, StepPercent: 25, Actor: "test", Reason: "recovered"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	throttle := func() int {
+		t.Helper()
+		c, err := l.GetZoneControls(ctx, zone)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c.CrossZoneThrottle
+	}
+	if throttle() != 50 || r.Status != "RUNNING" || r.NextStepAt == nil {
+		t.Fatalf("started ramp %+v, throttle %d", r, throttle())
+	}
+	if _, err := l.StartThrottleRamp(ctx, zone, StartThrottleRampInput{FromPercent: 10, Minutes: 1, Actor: "test"}); !IsThrottleRampRunning(err) {
+		t.Fatalf("second ramp err = %v, want running", err)
+	}
+
+	step := func() *ThrottleRamp {
+		t.Helper()
+		if _, err := l.ApplyDueThrottleRamps(ctx, 100); err != nil {
+			t.Fatal(err)
+		}
+		got, err := l.GetThrottleRamp(ctx, r.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+	clock.Freeze(r.StartedAt.Add(time.Minute))
+	if got := step(); got.CurrentPercent != 75 || throttle() != 75 {
+		t.Fatalf("after a minute: %+v, throttle %d", got, throttle())
+	}
+	clock.Freeze(r.StartedAt.Add(2 * time.Minute))
+	if got := step(); got.Status != "COMPLETED" || got.CurrentPercent != 100 || got.NextStepAt != nil || throttle() != 100 {
+		t.Fatalf("after two minutes: %+v, throttle %d", got, throttle())
+	}
+
+	// an operator changing the throttle takes over from the ramp
+	r, err = l.StartThrottleRamp(ctx, zone, StartThrottleRampInput{FromPercent: 20, Minutes: 10, Actor: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.SetZoneControls(ctx, zone, SetZoneControlsInput{CrossZoneThrottle: 30, Actor: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	clock.Freeze(r.StartedAt.Add(5 * time.Minute))
+	if got := step(); got.Status != "FAILED" || throttle() != 30 {
+		t.Fatalf("after an operator change: %+v, throttle %d", got, throttle())
+	}
+
+	r, err = l.StartThrottleRamp(ctx, zone, StartThrottleRampInput{FromPercent: 20, Minutes: 10, Actor: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := l.CancelThrottleRamp(ctx, r.ID, "test", "hold"); err != nil || got.Status != "CANCELLED" {
+		t.Fatalf("cancel = %+v, %v", got, err)
+	}
+	if _, err := l.CancelThrottleRamp(ctx, r.ID, "test", ""); !IsThrottleRampNotFound(err) {
+		t.Fatalf("second cancel err = %v, want not found", err)
+	}
+	list, err := l.ListThrottleRamps(ctx, zone, 10)
+	if err != nil || len(list) != 3 {
+		t.Fatalf("ramps = %d, %v", len(list), err)
+	}
+}
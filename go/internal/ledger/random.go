@@ -0,0 +1,39 @@
+package ledger
+
+import (
+  "math/rand/v2"
+  "sync"
+)
+
+// simRand is the sim-wide random source. Seeding it makes jitter and
+// generated data reproducible across runs.
+type simRand struct {
+  mu sync.Mutex
+  seed uint64
+  r *rand.Rand
+}
+
+func newSimRand(seed uint64) *simRand {
+  s := &simRand{}
+  s.reseed(seed)
+  return s
+}
+
+func (s *simRand) reseed(seed uint64) {
+  s.mu.Lock()
+  defer s.mu.Unlock()
+  s.seed = seed
+  s.r = rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15))
+}
+
+func (s *simRand) IntN(n int) int {
+  s.mu.Lock()
+  defer s.mu.Unlock()
+  return s.r.IntN(n)
+}
+
+func (s *simRand) Seed() uint64 {
+  s.mu.Lock()
+  defer s.mu.Unlock()
+  return s.seed
+}
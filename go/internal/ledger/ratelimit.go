@@ -0,0 +1,51 @@
+package ledger
+
+import (
+  "context"
+  "errors"
+  "time"
+)
+
+const (
+  ThrottleModeHash = "HASH"
+  ThrottleModeRate = "RATE"
+)
+
+var ErrRateLimited = errors.New("rate limited")
+
+func IsRateLimited(err error) bool { return errors.Is(err, ErrRateLimited) }
+
+// effectiveBurst defaults the bucket capacity to one second of traffic.
+func effectiveBurst(ratePerSec, burst int) int {
+  if burst <= 0 { return ratePerSec }
+  return burst
+}
+
+// refillTokens returns the bucket level after elapsed time, capped at burst.
+func refillTokens(tokens float64, elapsed time.Duration, ratePerSec, burst int) float64 {
+  if elapsed > 0 {
+    tokens += elapsed.Seconds() * float64(ratePerSec)
+  }
+  if tokens > float64(burst) { tokens = float64(burst) }
+  return tokens
+}
+
+// takeRateToken consumes one token from the zone's persisted bucket in q's
+// transaction, so a transfer that rolls back or is retried gives its token
+// back and no second connection is needed. It is the last gate before a
+// transfer applies, which keeps the bucket row locked only while the transfer
+// is written. Elapsed time is measured on the sim clock so frozen or
+// accelerated runs limit consistently.
+func (l *Ledger) takeRateToken(ctx context.Context, q Queries, zoneID string, ratePerSec, burst int) (bool, error) {
+  burst = effectiveBurst(ratePerSec, burst)
+  if ratePerSec <= 0 || burst <= 0 { return false, nil }
+
+  tokens, refilledAt, err := q.LockRateBucket(ctx, zoneID, float64(burst), l.clock.Now())
+  if err != nil { return false, err }
+  now := l.clock.Now()
+
+  tokens = refillTokens(tokens, now.Sub(refilledAt), ratePerSec, burst)
+  ok := tokens >= 1
+  if ok { tokens-- }
+  return ok, q.SaveRateBucket(ctx, zoneID, tokens, now)
+}
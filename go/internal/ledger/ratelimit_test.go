@@ -0,0 +1,36 @@
+package ledger
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEffectiveBurst_DefaultsToRate(t *testing.T) {
+	if got := effectiveBurst(20, 0); got != 20 {
+		t.Fatalf("expected 20, got %d", got)
+	}
+	if got := effectiveBurst(20, 50); got != 50 {
+		t.Fatalf("expected 50, got %d", got)
+	}
+}
+
+func TestRefillTokens_CapsAtBurst(t *testing.T) {
+	got := refillTokens(3, 10*time.Second, 5, 10)
+	if got != 10 {
+		t.Fatalf("expected cap 10, got %v", got)
+	}
+}
+
+func TestRefillTokens_PartialSecond(t *testing.T) {
+	got := refillTokens(0, 500*time.Millisecond, 10, 100)
+	if got != 5 {
+		t.Fatalf("expected 5 tokens after 500ms at 10/s, got %v", got)
+	}
+}
+
+func TestRefillTokens_NegativeElapsedIgnored(t *testing.T) {
+	got := refillTokens(2, -time.Second, 10, 100)
+	if got != 2 {
+		t.Fatalf("expected clock skew to be ignored, got %v", got)
+	}
+}
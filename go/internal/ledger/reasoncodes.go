@@ -0,0 +1,204 @@
+package ledger
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "regexp"
+  "slices"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+)
+
+var (
+  ErrReasonCodeNotFound = errors.New("reason code not found")
+  ErrReasonCodeExists = errors.New("reason code already exists")
+  ErrInvalidReasonCode = errors.New("invalid reason code")
+)
+
+func IsReasonCodeNotFound(err error) bool { return errors.Is(err, ErrReasonCodeNotFound) }
+func IsReasonCodeExists(err error) bool { return errors.Is(err, ErrReasonCodeExists) }
+func IsInvalidReasonCode(err error) bool { return errors.Is(err, ErrInvalidReasonCode) }
+
+// Actions that take a reason code; a code's applies_to lists some of these.
+const (
+  ReasonForZoneStatus = "SET_ZONE_STATUS"
+  ReasonForZoneControls = "SET_ZONE_CONTROLS"
+  ReasonForReplaySpool = "REPLAY_SPOOL"
+)
+
+var reasonActions = []string{ReasonForZoneStatus, ReasonForZoneControls, ReasonForReplaySpool}
+
+// ScenarioReasonCode is recorded for scenario steps that do not name a code.
+const ScenarioReasonCode = "SCENARIO"
+
+var reasonCodePattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]{1,47}$`)
+
+type ReasonCode struct {
+  Code string `json:"code"`
+  Description string `json:"description"`
+  AppliesTo []string `json:"applies_to"` // empty: every action
+  CreatedAt time.Time `json:"created_at"`
+  RetiredAt *time.Time `json:"retired_at"`
+}
+
+const reasonCodeCols = `code, description, applies_to, created_at, retired_at`
+
+func scanReasonCode(row pgx.Row) (*ReasonCode, error) {
+  var c ReasonCode
+  if err := row.Scan(&c.Code, &c.Description, &c.AppliesTo, &c.CreatedAt, &c.RetiredAt); err != nil { return nil, err }
+  if c.AppliesTo == nil { c.AppliesTo = []string{} }
+  return &c, nil
+}
+
+// SetRequireReasonCode makes status, controls and replay changes fail with
+// ErrInvalidReasonCode unless they carry a reason code.
+func (l *Ledger) SetRequireReasonCode(on bool) { l.reasonCodes.Store(on) }
+
+func (l *Ledger) RequireReasonCode() bool { return l.reasonCodes.Load() }
+
+// checkReasonCode validates code for action: it must be in the catalog, not
+// retired, and allowed for action. An empty code passes unless codes are
+// required.
+func (l *Ledger) checkReasonCode(ctx context.Context, q querier, action, code string) error {
+  if code == "" {
+    if l.reasonCodes.Load() { return fmt.Errorf("%w: reason_code is required (see GET /v1/reason-codes)", ErrInvalidReasonCode) }
+    return nil
+  }
+  var retired bool
+  var appliesTo []string
+  err := q.QueryRow(ctx, `SELECT retired_at IS NOT NULL, applies_to FROM reason_codes WHERE code=$1`, code).Scan(&retired, &appliesTo)
+  if errors.Is(err, pgx.ErrNoRows) { return fmt.Errorf("%w %q: not in the catalog", ErrInvalidReasonCode, code) }
+  if err != nil { return err }
+  if retired { return fmt.Errorf("%w %q: retired", ErrInvalidReasonCode, code) }
+  if len(appliesTo) > 0 && !slices.Contains(appliesTo, action) { return fmt.Errorf("%w %q: not for %s", ErrInvalidReasonCode, code, action) }
+  return nil
+}
+
+type RegisterReasonCodeInput struct {
+  Code string
+  Description string
+  AppliesTo []string
+  Actor string // who is adding it
+  Reason string
+}
+
+// RegisterReasonCode adds a code to the catalog. A retired code is brought
+// back (with the new description and actions) rather than rejected.
+func (l *Ledger) RegisterReasonCode(ctx context.Context, in RegisterReasonCodeInput) (*ReasonCode, error) {
+  if !reasonCodePattern.MatchString(in.Code) { return nil, fmt.Errorf("invalid code: use 2-48 of A-Z, 0-9 and _") }
+  if in.Description == "" { return nil, fmt.Errorf("description required") }
+  for _, a := range in.AppliesTo {
+    if !slices.Contains(reasonActions, a) { return nil, fmt.Errorf("invalid applies_to %q", a) }
+  }
+  if in.AppliesTo == nil { in.AppliesTo = []string{} }
+
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  if err := l.checkActors(ctx, tx, in.Actor); err != nil { return nil, err }
+
+  c, err := scanReasonCode(tx.QueryRow(ctx, `
+    INSERT INTO reason_codes(code,description,applies_to) VALUES($1,$2,$3)
+    ON CONFLICT (code) DO UPDATE
+      SET description=EXCLUDED.description, applies_to=EXCLUDED.applies_to, retired_at=NULL
+      WHERE reason_codes.retired_at IS NOT NULL
+    RETURNING `+reasonCodeCols, in.Code, in.Description, in.AppliesTo))
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrReasonCodeExists }
+  if err != nil { return nil, err }
+
+  err = l.audit(ctx, pgQueries{tx}, AuditRecord{
+    Actor: in.Actor, Action: "REGISTER_REASON_CODE", TargetType: "reason_code", TargetID: in.Code, Reason: in.Reason,
+    Details: map[string]any{"description": in.Description, "applies_to": in.AppliesTo},
+  })
+  if err != nil { return nil, err }
+
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  return c, nil
+}
+
+// RetireReasonCode stops a code from being accepted; entries already
+// recorded with it keep it.
+func (l *Ledger) RetireReasonCode(ctx context.Context, code, actor, reason string) (*ReasonCode, error) {
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  if err := l.checkActors(ctx, tx, actor); err != nil { return nil, err }
+
+  c, err := scanReasonCode(tx.QueryRow(ctx, `
+    UPDATE reason_codes SET retired_at=now() WHERE code=$1 AND retired_at IS NULL
+    RETURNING `+reasonCodeCols, code))
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrReasonCodeNotFound }
+  if err != nil { return nil, err }
+
+  err = l.audit(ctx, pgQueries{tx}, AuditRecord{Actor: actor, Action: "RETIRE_REASON_CODE", TargetType: "reason_code", TargetID: code, Reason: reason})
+  if err != nil { return nil, err }
+
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  return c, nil
+}
+
+// ListReasonCodes lists the catalog; action keeps the codes usable for it.
+func (l *Ledger) ListReasonCodes(ctx context.Context, action string, includeRetired bool) ([]ReasonCode, error) {
+  rows, err := l.ro.Query(ctx, `
+    SELECT `+reasonCodeCols+` FROM reason_codes
+    WHERE ($1='' OR cardinality(applies_to)=0 OR $1=ANY(applies_to)) AND ($2 OR retired_at IS NULL)
+    ORDER BY code
+  `, action, includeRetired)
+  if err != nil { return nil, err }
+  defer rows.Close()
+  out := []ReasonCode{}
+  for rows.Next() {
+    c, err := scanReasonCode(rows)
+    if err != nil { return nil, err }
+    out = append(out, *c)
+  }
+  return out, rows.Err()
+}
+
+// ReasonCodeCount is how often one action was taken for one reason code.
+type ReasonCodeCount struct {
+  Code string `json:"code"` // "" for actions recorded without a code
+  Action string `json:"action"`
+  Count int64 `json:"count"`
+  Zones int64 `json:"zones"` // distinct zones acted on
+  LastAt time.Time `json:"last_at"`
+}
+
+// ReasonCodeUsage aggregates coded actions over a window.
+type ReasonCodeUsage struct {
+  Since time.Time `json:"since"`
+  ZoneID string `json:"zone_id,omitempty"`
+  Total int64 `json:"total"`
+  Uncoded int64 `json:"uncoded"`
+  Codes []ReasonCodeCount `json:"codes"` // most frequent first
+}
+
+// GetReasonCodeUsage counts status, controls and replay actions since since by
+// reason code, from the audit log on the read replica. A scheduled change
+// counts when it is applied; a running drill's changes do not count until it
+// ends. zoneID ("" for all) narrows it to one zone.
+func (l *Ledger) GetReasonCodeUsage(ctx context.Context, since time.Time, zoneID string) (*ReasonCodeUsage, error) {
+  u := ReasonCodeUsage{Since: since, ZoneID: zoneID, Codes: []ReasonCodeCount{}}
+  rows, err := l.ro.Query(ctx, `
+    SELECT COALESCE(details->>'reason_code',''), action, COUNT(*), COUNT(DISTINCT target_id), MAX(created_at)
+    FROM audit_log
+    WHERE created_at >= $1 AND target_type='zone' AND ($2='' OR target_id=$2)
+      AND action IN ('SET_ZONE_STATUS','SET_ZONE_CONTROLS','REPLAY_SPOOL') AND NOT `+drillHidden+`
+    GROUP BY 1, 2
+    ORDER BY COUNT(*) DESC, 1, 2
+  `, since, zoneID)
+  if err != nil { return nil, err }
+  defer rows.Close()
+  for rows.Next() {
+    var c ReasonCodeCount
+    if err := rows.Scan(&c.Code, &c.Action, &c.Count, &c.Zones, &c.LastAt); err != nil { return nil, err }
+    u.Total += c.Count
+    if c.Code == "" { u.Uncoded += c.Count }
+    u.Codes = append(u.Codes, c)
+  }
+  return &u, rows.Err()
+}
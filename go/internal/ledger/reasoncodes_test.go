@@ -0,0 +1,80 @@
+package ledger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"time-ledger-sim/go/internal/store/storetest"
+)
+
+func TestReasonCodes(t *testing.T) {
+	db := storetest.Open(t)
+	ctx := context.Background()
+	l := New(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	t.Cleanup(func() { _, _ = db.Exec(ctx, `DELETE FROM reason_codes WHERE code LIKE 'RC_TEST_%'`) })
+	start := time.Now().Add(-time.Second)
+
+	// optional by default, but a code that is given must be in the catalog
+	if _, err := l.SetZoneStatus(ctx, "zone-eu", "OK", "ops", "", "test"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.SetZoneStatus(ctx, "zone-eu", "OK", "ops", "RC_TEST_NOPE", "test"); !IsInvalidReasonCode(err) {
+		t.Fatalf("unknown code: err = %v", err)
+	}
+
+	l.SetRequireReasonCode(true)
+	defer l.SetRequireReasonCode(false)
+	if _, err := l.SetZoneStatus(ctx, "zone-eu", "OK", "ops", "", "test"); !IsInvalidReasonCode(err) {
+		t.Fatalf("missing code: err = %v", err)
+	}
+
+	in := RegisterReasonCodeInput{Code: "RC_TEST_REPLAY", Description: "replay only", AppliesTo: []string{ReasonForReplaySpool}, Actor: "test"}
+	if _, err := l.RegisterReasonCode(ctx, in); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.RegisterReasonCode(ctx, in); !IsReasonCodeExists(err) {
+		t.Fatalf("second registration: err = %v", err)
+	}
+	if _, err := l.SetZoneStatus(ctx, "zone-eu", "OK", "ops", "RC_TEST_REPLAY", "test"); !IsInvalidReasonCode(err) {
+		t.Fatalf("code for another action: err = %v", err)
+	}
+	if _, err := l.SetZoneStatus(ctx, "zone-eu", "OK", "ops", "MAINTENANCE", "test"); err != nil {
+		t.Fatalf("seeded code: %v", err)
+	}
+
+	u, err := l.GetReasonCodeUsage(ctx, start, "zone-eu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	for _, c := range u.Codes {
+		found = found || (c.Code == "MAINTENANCE" && c.Action == ReasonForZoneStatus)
+	}
+	if !found || u.Uncoded == 0 {
+		t.Fatalf("usage = %+v", u)
+	}
+
+	if _, err := l.RetireReasonCode(ctx, "RC_TEST_REPLAY", "test", "unused"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.RetireReasonCode(ctx, "RC_TEST_REPLAY", "test", "unused"); !IsReasonCodeNotFound(err) {
+		t.Fatalf("second retire: err = %v", err)
+	}
+}
+
+func TestRegisterReasonCodeValidation(t *testing.T) {
+	l := NewWithRepo(nil, nil)
+	for _, in := range []RegisterReasonCodeInput{
+		{Code: "maintenance", Description: "lower case"},
+		{Code: "X", Description: "too short"},
+		{Code: "NO_DESCRIPTION"},
+		{Code: "BAD_ACTION", Description: "d", AppliesTo: []string{"SET_ACCOUNT_CONTROLS"}},
+	} {
+		if _, err := l.RegisterReasonCode(context.Background(), in); err == nil {
+			t.Errorf("%+v: no error", in)
+		}
+	}
+}
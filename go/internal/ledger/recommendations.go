@@ -0,0 +1,195 @@
+package ledger
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "sort"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+)
+
+var ErrRecommendationNotFound = errors.New("recommendation does not apply to the zone now")
+
+func IsRecommendationNotFound(err error) bool { return errors.Is(err, ErrRecommendationNotFound) }
+
+// Advisor thresholds.
+const (
+  recommendDownFor = 30 * time.Minute // a zone DOWN this long should start recovering
+  recommendBlockedFor = 30 * time.Minute // writes blocked this long on a healthy zone
+  recommendSpoolPending = 100 // pending entries worth draining in the background
+  recommendUnackedFor = 5 * time.Minute // an OPEN incident nobody acknowledged
+  maxRecommendedAcks = 10
+)
+
+// Recommended action kinds; each is one existing control API.
+const (
+  RecommendSetZoneStatus = "SET_ZONE_STATUS"
+  RecommendSetZoneControls = "SET_ZONE_CONTROLS"
+  RecommendStartReplayJob = "START_REPLAY_JOB"
+  RecommendAckIncident = "ACK_INCIDENT"
+)
+
+// RecommendedAction is the API call a recommendation stands for, so an
+// operator in training can see what applying it does.
+type RecommendedAction struct {
+  Kind string `json:"kind"`
+  Method string `json:"method"`
+  Path string `json:"path"`
+  Body map[string]any `json:"body"` // plus actor, reason_code and reason
+}
+
+// Recommendation is one piece of advice about a zone. ID is stable while the
+// condition lasts, so it can be applied by ID.
+type Recommendation struct {
+  ID string `json:"id"`
+  Severity string `json:"severity"` // INFO|WARN|CRITICAL
+  Title string `json:"title"`
+  Why string `json:"why"`
+  Action RecommendedAction `json:"action"`
+
+  apply func(ctx context.Context, in ApplyRecommendationInput) (any, error)
+}
+
+type ApplyRecommendationInput struct {
+  Actor string
+  ReasonCode string
+  Reason string // "" records the recommendation's id
+}
+
+// AppliedRecommendation is a recommendation and what its action returned:
+// the zone, controls, job or incident, or an *Approval when the two-person
+// rule holds the change for a second operator.
+type AppliedRecommendation struct {
+  Recommendation Recommendation `json:"recommendation"`
+  Result any `json:"result"`
+}
+
+var severityRank = map[string]int{"CRITICAL": 0, "WARN": 1, "INFO": 2}
+
+// Recommendations inspects the zone's status, controls, spool and incidents
+// and returns the actions the rules suggest, most severe first:
+//
+//   - DOWN without spooling: enable the spool so transfers are kept
+//   - DOWN for recommendDownFor: mark the zone DEGRADED to start recovering
+//   - writes blocked on an OK zone for recommendBlockedFor: unblock them
+//   - at least recommendSpoolPending entries spooled and the zone ready: drain
+//     them with a replay job, unless one is running
+//   - OPEN incidents older than recommendUnackedFor: acknowledge each
+func (l *Ledger) Recommendations(ctx context.Context, zoneID string) ([]Recommendation, error) {
+  var status string
+  var sinceMicros int64
+  err := l.db.QueryRow(ctx, `
+    SELECT status, (EXTRACT(EPOCH FROM now() - updated_at) * 1e6)::bigint FROM zones WHERE id=$1 AND retired_at IS NULL
+  `, zoneID).Scan(&status, &sinceMicros)
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrZoneNotFound }
+  if err != nil { return nil, err }
+  downFor := time.Duration(sinceMicros) * time.Microsecond
+  c, err := l.GetZoneControls(ctx, zoneID)
+  if err != nil { return nil, err }
+  var pending int64
+  var replaying bool
+  err = l.db.QueryRow(ctx, `
+    SELECT (SELECT COUNT(*) FROM spooled_transfers WHERE zone_id=$1 AND status='PENDING'),
+      EXISTS (SELECT 1 FROM jobs WHERE kind=$2 AND target=$1 AND status IN ('QUEUED','RUNNING'))
+  `, zoneID, JobKindReplaySpool).Scan(&pending, &replaying)
+  if err != nil { return nil, err }
+
+  zonePath := "/v1/zones/" + zoneID
+  out := []Recommendation{}
+  controls := func(id, sev, title, why string, body map[string]any, change func(*SetZoneControlsInput)) Recommendation {
+    return Recommendation{
+      ID: id, Severity: sev, Title: title, Why: why,
+      Action: RecommendedAction{Kind: RecommendSetZoneControls, Method: "POST", Path: zonePath + "/controls", Body: body},
+      apply: func(ctx context.Context, in ApplyRecommendationInput) (any, error) {
+        cur, err := l.GetZoneControls(ctx, zoneID)
+        if err != nil { return nil, err }
+        sin := cur.input()
+        change(&sin)
+        sin.Actor, sin.ReasonCode, sin.Reason = in.Actor, in.ReasonCode, in.Reason
+        if l.ZoneControlsNeedApproval(sin) { return l.RequestZoneControlsApproval(ctx, zoneID, time.Time{}, sin) }
+        return l.SetZoneControls(ctx, zoneID, sin)
+      },
+    }
+  }
+
+  if status == "DOWN" && !c.SpoolEnabled {
+    out = append(out, controls("enable-spool", "CRITICAL", "Enable the spool",
+      "The zone is DOWN and spooling is off, so its transfers are rejected and lost; spooled ones can be replayed once it recovers.",
+      map[string]any{"spool_enabled": true}, func(in *SetZoneControlsInput) { in.SpoolEnabled = true }))
+  }
+  if status == "DOWN" && downFor >= recommendDownFor {
+    out = append(out, Recommendation{
+      ID: "degrade-zone", Severity: "WARN", Title: "Mark the zone DEGRADED",
+      Why: fmt.Sprintf("The zone has been DOWN for %s; DEGRADED lets traffic back in while it is watched.", downFor.Truncate(time.Minute)),
+      Action: RecommendedAction{Kind: RecommendSetZoneStatus, Method: "POST", Path: zonePath + "/status", Body: map[string]any{"status": "DEGRADED"}},
+      apply: func(ctx context.Context, in ApplyRecommendationInput) (any, error) {
+        return l.SetZoneStatus(ctx, zoneID, "DEGRADED", in.Actor, in.ReasonCode, in.Reason)
+      },
+    })
+  }
+  if blockedFor := time.Since(c.UpdatedAt); status == "OK" && c.WritesBlocked && blockedFor >= recommendBlockedFor {
+    out = append(out, controls("unblock-writes", "WARN", "Unblock writes",
+      fmt.Sprintf("The zone is OK but its writes have been blocked for %s.", blockedFor.Truncate(time.Minute)),
+      map[string]any{"writes_blocked": false}, func(in *SetZoneControlsInput) { in.WritesBlocked = false }))
+  }
+  ready := status != "DOWN" && !c.WritesBlocked && !(c.ThrottleMode == ThrottleModeHash && c.CrossZoneThrottle == 0)
+  if pending >= recommendSpoolPending && ready && !replaying {
+    out = append(out, Recommendation{
+      ID: "drain-spool", Severity: "WARN", Title: "Drain the spool",
+      Why: fmt.Sprintf("%d transfers are spooled and the zone can take them again.", pending),
+      Action: RecommendedAction{Kind: RecommendStartReplayJob, Method: "POST", Path: zonePath + "/spool/replay-jobs", Body: map[string]any{"rate_per_sec": defaultReplayJobRate}},
+      apply: func(ctx context.Context, in ApplyRecommendationInput) (any, error) {
+        return l.StartReplayJob(ctx, zoneID, StartReplayJobInput{Actor: in.Actor, ReasonCode: in.ReasonCode, Reason: in.Reason})
+      },
+    })
+  }
+
+  rows, err := l.db.Query(ctx, `
+    SELECT id::text, severity, title, (EXTRACT(EPOCH FROM now() - detected_at) * 1e6)::bigint
+    FROM incidents
+    WHERE zone_id=$1 AND status='OPEN' AND detected_at <= now() - make_interval(secs => $2)
+    ORDER BY detected_at
+    LIMIT $3
+  `, zoneID, recommendUnackedFor.Seconds(), maxRecommendedAcks)
+  if err != nil { return nil, err }
+  defer rows.Close()
+  for rows.Next() {
+    var id, sev, title string
+    var ageMicros int64
+    if err := rows.Scan(&id, &sev, &title, &ageMicros); err != nil { return nil, err }
+    age := time.Duration(ageMicros) * time.Microsecond
+    out = append(out, Recommendation{
+      ID: "ack-incident-" + id, Severity: sev, Title: "Acknowledge incident: " + title,
+      Why: fmt.Sprintf("The incident has been OPEN for %s without an acknowledgement.", age.Truncate(time.Minute)),
+      Action: RecommendedAction{Kind: RecommendAckIncident, Method: "POST", Path: "/v1/incidents/" + id + "/action", Body: map[string]any{"action": "ACK"}},
+      apply: func(ctx context.Context, in ApplyRecommendationInput) (any, error) {
+        return l.ApplyIncidentAction(ctx, id, IncidentAction{Action: "ACK", Actor: in.Actor, Reason: in.Reason})
+      },
+    })
+  }
+  if err := rows.Err(); err != nil { return nil, err }
+
+  sort.SliceStable(out, func(i, j int) bool { return severityRank[out[i].Severity] < severityRank[out[j].Severity] })
+  return out, nil
+}
+
+// ApplyRecommendation re-evaluates the zone's recommendations and runs the
+// action of the one with the given ID through its control API, which audits
+// it as usual and keeps the two-person rule. A recommendation that no longer
+// applies is not found.
+func (l *Ledger) ApplyRecommendation(ctx context.Context, zoneID, id string, in ApplyRecommendationInput) (*AppliedRecommendation, error) {
+  if in.Actor == "" { return nil, fmt.Errorf("actor required") }
+  recs, err := l.Recommendations(ctx, zoneID)
+  if err != nil { return nil, err }
+  for _, rec := range recs {
+    if rec.ID != id { continue }
+    if in.Reason == "" { in.Reason = "recommendation " + id }
+    res, err := rec.apply(ctx, in)
+    if err != nil { return nil, err }
+    l.log.InfoContext(ctx, "recommendation applied", "zone_id", zoneID, "recommendation", id, "actor", in.Actor)
+    return &AppliedRecommendation{Recommendation: rec, Result: res}, nil
+  }
+  return nil, ErrRecommendationNotFound
+}
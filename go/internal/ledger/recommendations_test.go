@@ -0,0 +1,84 @@
+package ledger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"time-ledger-sim/go/internal/store/storetest"
+)
+
+func TestRecommendations(t *testing.T) {
+	db := storetest.Open(t)
+	ctx := context.Background()
+	l := New(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	zone := "zone-rec-" + uuid.NewString()[:8]
+	if _, err := l.CreateZone(ctx, CreateZoneInput{ID: zone, Name: zone, Actor: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.SetZoneControls(ctx, zone, SetZoneControlsInput{CrossZoneThrottle: 100, Actor: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.SetZoneStatus(ctx, zone, "DOWN", "test", "", "drill"); err != nil {
+		t.Fatal(err)
+	}
+	ids := func() map[string]Recommendation {
+		t.Helper()
+		recs, err := l.Recommendations(ctx, zone)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := map[string]Recommendation{}
+		for _, r := range recs {
+			m[r.ID] = r
+		}
+		return m
+	}
+	recs := ids()
+	if _, ok := recs["enable-spool"]; !ok || len(recs) != 1 {
+		t.Fatalf("fresh DOWN zone: recommendations = %v", recs)
+	}
+
+	// age the outage and its incident
+	if _, err := db.Exec(ctx, `UPDATE zones SET updated_at=now() - interval '40 minutes' WHERE id=$1`, zone); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(ctx, `UPDATE incidents SET detected_at=now() - interval '10 minutes' WHERE zone_id=$1`, zone); err != nil {
+		t.Fatal(err)
+	}
+	recs = ids()
+	var ack string
+	for id := range recs {
+		if strings.HasPrefix(id, "ack-incident-") {
+			ack = id
+		}
+	}
+	if _, ok := recs["degrade-zone"]; !ok || ack == "" {
+		t.Fatalf("aged outage: recommendations = %v", recs)
+	}
+
+	res, err := l.ApplyRecommendation(ctx, zone, "enable-spool", ApplyRecommendationInput{Actor: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, ok := res.Result.(*ZoneControls); !ok || !c.SpoolEnabled || c.CrossZoneThrottle != 100 {
+		t.Errorf("enable-spool result = %+v", res.Result)
+	}
+	if _, err := l.ApplyRecommendation(ctx, zone, "enable-spool", ApplyRecommendationInput{Actor: "test"}); !IsRecommendationNotFound(err) {
+		t.Errorf("applying twice: err = %v", err)
+	}
+	if _, err := l.ApplyRecommendation(ctx, zone, ack, ApplyRecommendationInput{Actor: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.ApplyRecommendation(ctx, zone, "degrade-zone", ApplyRecommendationInput{Actor: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	if recs := ids(); len(recs) != 0 {
+		t.Errorf("after applying everything: recommendations = %v", recs)
+	}
+}
@@ -0,0 +1,141 @@
+package ledger
+
+import (
+  "context"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+)
+
+// ReconcileCheck is one kind of inconsistency found by Reconcile.
+type ReconcileCheck struct {
+  Kind string `json:"kind"`
+  Description string `json:"description"`
+  Count int64 `json:"count"`
+  Fixable bool `json:"fixable"` // has a safe automatic fix
+  Fixed int64 `json:"fixed"`
+  Samples []ReconcileIssue `json:"samples"` // first maxReconcileSamples rows
+}
+
+type ReconcileIssue struct {
+  ID string `json:"id"` // of the inconsistent row
+  Detail string `json:"detail"`
+}
+
+type ReconcileReport struct {
+  At time.Time `json:"at"`
+  Fix bool `json:"fix"`
+  Issues int64 `json:"issues"`
+  Fixed int64 `json:"fixed"`
+  Checks []ReconcileCheck `json:"checks"`
+}
+
+const maxReconcileSamples = 20
+
+// A reconcileCheck's rows query selects (id, detail) for each inconsistent row;
+// fix, if set, repairs all of them without moving value.
+type reconcileCheck struct {
+  kind, desc, rows, fix string
+}
+
+var reconcileChecks = []reconcileCheck{
+  {
+    kind: "spool_applied_without_txn", desc: "spooled transfers marked APPLIED with no transaction for their request_id",
+    rows: `SELECT s.id::text, 'request_id '||s.request_id FROM spooled_transfers s
+      WHERE s.status='APPLIED' AND NOT EXISTS (SELECT 1 FROM transactions t WHERE t.request_id=s.request_id)`,
+  },
+  {
+    // the transfer was posted, so the spool row is marked applied rather than replayed again
+    kind: "spool_unapplied_with_txn", desc: "spooled transfers still PENDING or FAILED although their request_id was posted",
+    rows: `SELECT s.id::text, 'request_id '||s.request_id||' posted as '||t.id FROM spooled_transfers s
+      JOIN transactions t ON t.request_id=s.request_id WHERE s.status<>'APPLIED'`,
+    fix: `UPDATE spooled_transfers s SET status='APPLIED', fail_reason=NULL, applied_at=t.created_at, updated_at=now()
+      FROM transactions t WHERE t.request_id=s.request_id AND s.status<>'APPLIED'`,
+  },
+  {
+    // queue the TRANSFER_POSTED event the transfer should have written; consumers see it once
+    kind: "txn_without_outbox", desc: "transactions without an outbox event",
+    rows: `SELECT t.id::text, 'request_id '||t.request_id FROM transactions t
+      WHERE NOT EXISTS (SELECT 1 FROM outbox_events o WHERE o.aggregate_type='transaction' AND o.aggregate_id=t.id::text)`,
+    fix: `INSERT INTO outbox_events(event_type,aggregate_type,aggregate_id,payload)
+      SELECT 'TRANSFER_POSTED', 'transaction', t.id::text,
+        jsonb_build_object('event_id','generated_by_db','transaction_id',t.id::text,'zone_id',t.zone_id,'from_account',t.from_account,'to_account',t.to_account,'amount_units',t.amount_units,'created_at',t.created_at,'zone_seq',t.zone_seq,'reconciled',true)
+        || CASE WHEN t.to_zone_id<>t.zone_id THEN jsonb_build_object('to_zone_id',t.to_zone_id) ELSE '{}'::jsonb END
+      FROM transactions t
+      WHERE NOT EXISTS (SELECT 1 FROM outbox_events o WHERE o.aggregate_type='transaction' AND o.aggregate_id=t.id::text)`,
+  },
+  {
+    kind: "outbox_unpublished", desc: "outbox events still unpublished 5 minutes after they were written",
+    rows: `SELECT o.id::text, o.event_type||' for '||o.aggregate_type||' '||o.aggregate_id FROM outbox_events o
+      WHERE o.published_at IS NULL AND o.created_at < now() - interval '5 minutes'`,
+  },
+  {
+    // the foreign key normally prevents this; it is checked for databases that lost it
+    kind: "incident_missing_txn", desc: "incidents whose related transaction does not exist",
+    rows: `SELECT i.id::text, 'related_txn_id '||i.related_txn_id FROM incidents i
+      WHERE i.related_txn_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM transactions t WHERE t.id=i.related_txn_id)`,
+    fix: `UPDATE incidents i SET details=i.details||jsonb_build_object('missing_txn_id',i.related_txn_id), related_txn_id=NULL, updated_at=now()
+      WHERE i.related_txn_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM transactions t WHERE t.id=i.related_txn_id)`,
+  },
+}
+
+// Reconcile reports inconsistencies between the spool, transactions, the
+// outbox and incidents, reading from the read replica.
+func (l *Ledger) Reconcile(ctx context.Context) (*ReconcileReport, error) {
+  return runReconcile(ctx, l.ro, l.clock.Now(), false)
+}
+
+// ReconcileFix runs the checks on the primary and applies the safe fixes in
+// one transaction, audited as RECONCILE_FIX. Checks without a fix are only
+// reported.
+func (l *Ledger) ReconcileFix(ctx context.Context, actor, reason string) (*ReconcileReport, error) {
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  if err := l.checkActors(ctx, tx, actor); err != nil { return nil, err }
+
+  rep, err := runReconcile(ctx, tx, l.clock.Now(), true)
+  if err != nil { return nil, err }
+
+  fixed := map[string]any{}
+  for _, c := range rep.Checks {
+    if c.Fixed > 0 { fixed[c.Kind] = c.Fixed }
+  }
+  err = l.audit(ctx, pgQueries{tx}, AuditRecord{
+    Actor: actor, Action: "RECONCILE_FIX", TargetType: "sim", TargetID: "reconcile", Reason: reason,
+    Details: map[string]any{"issues": rep.Issues, "fixed": fixed},
+  })
+  if err != nil { return nil, err }
+
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  return rep, nil
+}
+
+func runReconcile(ctx context.Context, q querier, at time.Time, fix bool) (*ReconcileReport, error) {
+  rep := ReconcileReport{At: at, Fix: fix, Checks: []ReconcileCheck{}}
+  for _, rc := range reconcileChecks {
+    c := ReconcileCheck{Kind: rc.kind, Description: rc.desc, Fixable: rc.fix != "", Samples: []ReconcileIssue{}}
+    if err := q.QueryRow(ctx, `SELECT COUNT(*) FROM (`+rc.rows+`) x`).Scan(&c.Count); err != nil { return nil, err }
+    if c.Count > 0 {
+      rows, err := q.Query(ctx, `SELECT * FROM (`+rc.rows+`) x ORDER BY 1 LIMIT $1`, maxReconcileSamples)
+      if err != nil { return nil, err }
+      for rows.Next() {
+        var s ReconcileIssue
+        if err := rows.Scan(&s.ID, &s.Detail); err != nil { rows.Close(); return nil, err }
+        c.Samples = append(c.Samples, s)
+      }
+      rows.Close()
+      if err := rows.Err(); err != nil { return nil, err }
+    }
+    if fix && c.Fixable && c.Count > 0 {
+      ct, err := q.Exec(ctx, rc.fix)
+      if err != nil { return nil, err }
+      c.Fixed = ct.RowsAffected()
+    }
+    rep.Issues += c.Count
+    rep.Fixed += c.Fixed
+    rep.Checks = append(rep.Checks, c)
+  }
+  return &rep, nil
+}
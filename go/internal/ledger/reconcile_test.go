@@ -0,0 +1,72 @@
+package ledger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"time-ledger-sim/go/internal/store/storetest"
+)
+
+func TestReconcile(t *testing.T) {
+	db := storetest.Open(t)
+	ctx := context.Background()
+	l := New(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	reqID := "reconcile-" + uuid.NewString()
+	txn, _, err := l.CreateTransfer(ctx, CreateTransferInput{
+		RequestID: reqID, PayloadHash: "h", FromAccount: "rec-a-" + reqID, ToAccount: "rec-b-" + reqID, AmountUnits: 5, ZoneID: "zone-eu",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// lose the outbox event, and leave a spooled copy of the request pending
+	if _, err := db.Exec(ctx, `DELETE FROM outbox_events WHERE aggregate_type='transaction' AND aggregate_id=$1`, txn.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(ctx, `INSERT INTO spooled_transfers(request_id,payload_hash,from_account,to_account,amount_units,zone_id) VALUES($1,'h','x','y',5,'zone-eu')`, reqID); err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(rep *ReconcileReport, kind string) ReconcileCheck {
+		t.Helper()
+		for _, c := range rep.Checks {
+			if c.Kind == kind {
+				return c
+			}
+		}
+		t.Fatalf("no %s check", kind)
+		return ReconcileCheck{}
+	}
+	rep, err := l.Reconcile(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, kind := range []string{"txn_without_outbox", "spool_unapplied_with_txn"} {
+		if c := check(rep, kind); c.Count == 0 || !c.Fixable {
+			t.Fatalf("%s = %+v", kind, c)
+		}
+	}
+
+	fixed, err := l.ReconcileFix(ctx, "test", "reconcile test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := check(fixed, "txn_without_outbox"); c.Fixed == 0 {
+		t.Fatalf("txn_without_outbox = %+v", c)
+	}
+	var status string
+	if err := db.QueryRow(ctx, `SELECT status FROM spooled_transfers WHERE request_id=$1`, reqID).Scan(&status); err != nil || status != "APPLIED" {
+		t.Fatalf("spool status = %q, %v", status, err)
+	}
+	rel, err := l.GetTransactionRelated(ctx, txn.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rel.Events) != 1 {
+		t.Fatalf("events = %+v", rel.Events)
+	}
+}
@@ -0,0 +1,291 @@
+package ledger
+
+import (
+  "context"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+
+  "time-ledger-sim/go/internal/util"
+)
+
+var ErrRecurringTransferNotFound = errors.New("recurring transfer not found")
+
+func IsRecurringTransferNotFound(err error) bool { return errors.Is(err, ErrRecurringTransferNotFound) }
+
+const (
+  minRecurringInterval = time.Second
+  maxRecurringInterval = 31 * 24 * time.Hour
+)
+
+type RecurringTransfer struct {
+  ID string `json:"id"`
+  Name *string `json:"name"`
+  ZoneID string `json:"zone_id"`
+  FromAccount string `json:"from_account"`
+  ToAccount string `json:"to_account"`
+  AmountUnits int64 `json:"amount_units"`
+  Cron *string `json:"cron"`
+  IntervalSeconds *int64 `json:"interval_seconds"`
+  Metadata map[string]any `json:"metadata"`
+  Status string `json:"status"` // ACTIVE|PAUSED|CANCELLED
+  Runs int64 `json:"runs"`
+  NextRunAt *time.Time `json:"next_run_at"` // null unless ACTIVE
+  LastRunAt *time.Time `json:"last_run_at"`
+  LastOutcome *string `json:"last_outcome"` // applied|spooled|rejected|error
+  LastError *string `json:"last_error"`
+  Actor string `json:"actor"`
+  Reason *string `json:"reason"`
+  CreatedAt time.Time `json:"created_at"`
+  UpdatedAt time.Time `json:"updated_at"`
+}
+
+const recurringCols = `id::text, name, zone_id, from_account, to_account, amount_units, cron, interval_seconds, metadata, status,
+  runs, next_run_at, last_run_at, last_outcome, last_error, actor, reason, created_at, updated_at`
+
+func scanRecurringTransfer(row pgx.Row) (*RecurringTransfer, error) {
+  var r RecurringTransfer
+  var meta []byte
+  err := row.Scan(&r.ID, &r.Name, &r.ZoneID, &r.FromAccount, &r.ToAccount, &r.AmountUnits, &r.Cron, &r.IntervalSeconds, &meta, &r.Status,
+    &r.Runs, &r.NextRunAt, &r.LastRunAt, &r.LastOutcome, &r.LastError, &r.Actor, &r.Reason, &r.CreatedAt, &r.UpdatedAt)
+  if err != nil { return nil, err }
+  _ = json.Unmarshal(meta, &r.Metadata)
+  return &r, nil
+}
+
+// nextRun is the first run of r strictly after t, or zero if the cron
+// expression never matches again.
+func (r *RecurringTransfer) nextRun(t time.Time) time.Time {
+  if r.IntervalSeconds != nil { return t.Add(time.Duration(*r.IntervalSeconds) * time.Second) }
+  c, err := parseCron(*r.Cron)
+  if err != nil { return time.Time{} }
+  return c.next(t)
+}
+
+type CreateRecurringTransferInput struct {
+  Name string
+  ZoneID string
+  FromAccount string
+  ToAccount string
+  AmountUnits int64
+  Cron string // five-field cron expression in UTC on the sim clock; or
+  Interval time.Duration // a fixed interval between runs
+  Metadata map[string]any
+  StartAt time.Time // first run; default the first cron match or one interval from now
+  Actor string
+  Reason string
+}
+
+func (in CreateRecurringTransferInput) validate() error {
+  if in.FromAccount == "" || in.ToAccount == "" { return fmt.Errorf("from_account and to_account are required") }
+  if in.FromAccount == in.ToAccount { return fmt.Errorf("from_account and to_account must differ") }
+  if in.AmountUnits <= 0 { return fmt.Errorf("amount_units must be positive") }
+  if (in.Cron == "") == (in.Interval == 0) { return fmt.Errorf("give exactly one of cron and interval") }
+  if in.Cron != "" {
+    c, err := parseCron(in.Cron)
+    if err != nil { return err }
+    if c.next(time.Now()).IsZero() { return fmt.Errorf("cron %q never matches", in.Cron) }
+  }
+  if in.Interval != 0 && (in.Interval < minRecurringInterval || in.Interval > maxRecurringInterval || in.Interval%time.Second != 0) {
+    return fmt.Errorf("interval must be whole seconds from 1s to 744h")
+  }
+  return nil
+}
+
+// CreateRecurringTransfer stores a definition the control scheduler turns
+// into a transfer at every run.
+func (l *Ledger) CreateRecurringTransfer(ctx context.Context, in CreateRecurringTransferInput) (*RecurringTransfer, error) {
+  if err := in.validate(); err != nil { return nil, err }
+  if in.Metadata == nil { in.Metadata = map[string]any{} }
+  meta, err := json.Marshal(in.Metadata)
+  if err != nil { return nil, err }
+  var cron *string
+  var interval *int64
+  if in.Cron != "" { cron = &in.Cron } else { secs := int64(in.Interval / time.Second); interval = &secs }
+  if in.StartAt.IsZero() { in.StartAt = (&RecurringTransfer{Cron: cron, IntervalSeconds: interval}).nextRun(l.clock.Now()) }
+
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  if _, err := (pgQueries{tx}).ZoneStatus(ctx, in.ZoneID); err != nil { return nil, err }
+  if err := l.checkActors(ctx, tx, in.Actor); err != nil { return nil, err }
+
+  r, err := scanRecurringTransfer(tx.QueryRow(ctx, `
+    INSERT INTO recurring_transfers(name,zone_id,from_account,to_account,amount_units,cron,interval_seconds,metadata,next_run_at,actor,reason)
+    VALUES(NULLIF($1,''),$2,$3,$4,$5,$6,$7,$8::jsonb,$9,$10,NULLIF($11,''))
+    RETURNING `+recurringCols,
+    in.Name, in.ZoneID, in.FromAccount, in.ToAccount, in.AmountUnits, cron, interval, string(meta), in.StartAt, in.Actor, in.Reason))
+  if err != nil { return nil, err }
+
+  err = l.audit(ctx, pgQueries{tx}, AuditRecord{
+    Actor: in.Actor, Action: "CREATE_RECURRING_TRANSFER", TargetType: "recurring_transfer", TargetID: r.ID, Reason: in.Reason,
+    Details: map[string]any{"zone_id": r.ZoneID, "from_account": r.FromAccount, "to_account": r.ToAccount, "amount_units": r.AmountUnits,
+      "cron": r.Cron, "interval_seconds": r.IntervalSeconds, "next_run_at": r.NextRunAt},
+  })
+  if err != nil { return nil, err }
+
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  return r, nil
+}
+
+func (l *Ledger) GetRecurringTransfer(ctx context.Context, id string) (*RecurringTransfer, error) {
+  r, err := scanRecurringTransfer(l.db.QueryRow(ctx, `SELECT `+recurringCols+` FROM recurring_transfers WHERE id::text=$1`, id))
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrRecurringTransferNotFound }
+  return r, err
+}
+
+// ListRecurringTransfers lists definitions, optionally for one zone; cancelled
+// ones only with all.
+func (l *Ledger) ListRecurringTransfers(ctx context.Context, zoneID string, all bool) ([]RecurringTransfer, error) {
+  rows, err := l.db.Query(ctx, `
+    SELECT `+recurringCols+`
+    FROM recurring_transfers
+    WHERE ($1 = '' OR zone_id = $1) AND ($2 OR status <> 'CANCELLED')
+    ORDER BY created_at, id
+    LIMIT 500
+  `, zoneID, all)
+  if err != nil { return nil, err }
+  defer rows.Close()
+
+  out := []RecurringTransfer{}
+  for rows.Next() {
+    r, err := scanRecurringTransfer(rows)
+    if err != nil { return nil, err }
+    out = append(out, *r)
+  }
+  return out, rows.Err()
+}
+
+// Recurring transfer state changes, by audit action: the statuses they apply
+// to and the status they set.
+var recurringTransitions = map[string]struct{ from []string; to string }{
+  "PAUSE_RECURRING_TRANSFER": {[]string{"ACTIVE"}, "PAUSED"},
+  "RESUME_RECURRING_TRANSFER": {[]string{"PAUSED"}, "ACTIVE"},
+  "CANCEL_RECURRING_TRANSFER": {[]string{"ACTIVE", "PAUSED"}, "CANCELLED"},
+}
+
+func (l *Ledger) PauseRecurringTransfer(ctx context.Context, id, actor, reason string) (*RecurringTransfer, error) {
+  return l.setRecurringStatus(ctx, "PAUSE_RECURRING_TRANSFER", id, actor, reason)
+}
+
+// ResumeRecurringTransfer reactivates a paused definition from its next run
+// after now; runs missed while paused are skipped.
+func (l *Ledger) ResumeRecurringTransfer(ctx context.Context, id, actor, reason string) (*RecurringTransfer, error) {
+  return l.setRecurringStatus(ctx, "RESUME_RECURRING_TRANSFER", id, actor, reason)
+}
+
+func (l *Ledger) CancelRecurringTransfer(ctx context.Context, id, actor, reason string) (*RecurringTransfer, error) {
+  return l.setRecurringStatus(ctx, "CANCEL_RECURRING_TRANSFER", id, actor, reason)
+}
+
+func (l *Ledger) setRecurringStatus(ctx context.Context, action, id, actor, reason string) (*RecurringTransfer, error) {
+  t := recurringTransitions[action]
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  if err := l.checkActors(ctx, tx, actor); err != nil { return nil, err }
+
+  cur, err := scanRecurringTransfer(tx.QueryRow(ctx, `
+    SELECT `+recurringCols+` FROM recurring_transfers WHERE id::text=$1 AND status = ANY($2) FOR UPDATE`, id, t.from))
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrRecurringTransferNotFound }
+  if err != nil { return nil, err }
+
+  var next *time.Time
+  if t.to == "ACTIVE" {
+    n := cur.nextRun(l.clock.Now())
+    if n.IsZero() { return nil, fmt.Errorf("cron %q never matches", *cur.Cron) }
+    next = &n
+  }
+  r, err := scanRecurringTransfer(tx.QueryRow(ctx, `
+    UPDATE recurring_transfers SET status=$2, next_run_at=$3, updated_at=now()
+    WHERE id=$1::uuid
+    RETURNING `+recurringCols, cur.ID, t.to, next))
+  if err != nil { return nil, err }
+
+  err = l.audit(ctx, pgQueries{tx}, AuditRecord{
+    Actor: actor, Action: action, TargetType: "recurring_transfer", TargetID: r.ID, Reason: reason,
+    Details: map[string]any{"zone_id": r.ZoneID, "status": r.Status, "next_run_at": r.NextRunAt},
+  })
+  if err != nil { return nil, err }
+
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  return r, nil
+}
+
+// RunDueRecurringTransfers makes the transfers of up to limit due runs.
+func (l *Ledger) RunDueRecurringTransfers(ctx context.Context, limit int) (int, error) {
+  ran := 0
+  for i := 0; i < limit; i++ {
+    ok, err := l.runNextRecurringTransfer(ctx)
+    if err != nil { return ran, err }
+    if !ok { break }
+    ran++
+  }
+  return ran, nil
+}
+
+// runNextRecurringTransfer locks the most overdue definition (SKIP LOCKED, so
+// replicas never take the same run) and makes its transfer through
+// CreateTransfer while holding the lock, so zone gates, spooling and controls
+// apply. The request_id is recurring-<id>-<run>: if the run's transfer
+// committed but its bookkeeping did not, the retry is an idempotent replay.
+// A run that is late by more than one period (paused scheduler, clock jump)
+// makes one transfer and skips ahead to the next run after now.
+func (l *Ledger) runNextRecurringTransfer(ctx context.Context) (bool, error) {
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return false, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  now := l.clock.Now()
+  r, err := scanRecurringTransfer(tx.QueryRow(ctx, `
+    SELECT `+recurringCols+`
+    FROM recurring_transfers
+    WHERE status='ACTIVE' AND next_run_at <= $1
+    ORDER BY next_run_at ASC
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+  `, now))
+  if errors.Is(err, pgx.ErrNoRows) { return false, nil }
+  if err != nil { return false, err }
+
+  run := r.Runs + 1
+  meta := map[string]any{}
+  for k, v := range r.Metadata { meta[k] = v }
+  meta["recurring_id"], meta["recurring_run"] = r.ID, run
+  in := CreateTransferInput{
+    RequestID: fmt.Sprintf("recurring-%s-%d", r.ID, run),
+    FromAccount: r.FromAccount, ToAccount: r.ToAccount, AmountUnits: r.AmountUnits, ZoneID: r.ZoneID, Metadata: meta,
+  }
+  in.PayloadHash, err = util.HashCanonicalJSON(map[string]any{
+    "request_id": in.RequestID, "from_account": in.FromAccount, "to_account": in.ToAccount,
+    "amount_units": in.AmountUnits, "zone_id": in.ZoneID, "metadata": in.Metadata,
+  })
+  if err != nil { return false, err }
+  _, spoolID, terr := l.CreateTransfer(ctx, in)
+  outcome := transferOutcome(spoolID, terr)
+  var lastErr *string
+  if terr != nil { msg := terr.Error(); lastErr = &msg }
+
+  next := r.nextRun(*r.NextRunAt)
+  if !next.IsZero() && !next.After(now) { next = r.nextRun(now) }
+  status, nextAt := "ACTIVE", &next
+  if next.IsZero() { status, nextAt = "CANCELLED", nil } // a cron date that never comes again
+
+  _, err = tx.Exec(ctx, `
+    UPDATE recurring_transfers
+    SET runs=$2, last_run_at=$3, last_outcome=$4, last_error=$5, status=$6, next_run_at=$7, updated_at=now()
+    WHERE id=$1::uuid
+  `, r.ID, run, now, outcome, lastErr, status, nextAt)
+  if err != nil { return false, err }
+  if err := tx.Commit(ctx); err != nil { return false, err }
+
+  if terr != nil {
+    l.log.WarnContext(ctx, "recurring transfer run failed", "recurring_id", r.ID, "zone_id", r.ZoneID, "run", run, "outcome", outcome, "err", terr.Error())
+  }
+  return true, nil
+}
@@ -0,0 +1,85 @@
+package ledger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"time-ledger-sim/go/internal/store/storetest"
+)
+
+func TestRecurringTransfers(t *testing.T) {
+	db := storetest.Open(t)
+	ctx := context.Background()
+	l := New(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	suffix := uuid.NewString()
+	rt, err := l.CreateRecurringTransfer(ctx, CreateRecurringTransferInput{
+		ZoneID: "zone-eu", FromAccount: "rec-payer-" + suffix, ToAccount: "rec-payee-" + suffix, AmountUnits: 7,
+		Interval: time.Minute, StartAt: l.Now().Add(-time.Hour), Actor: "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _, _ = l.CancelRecurringTransfer(ctx, rt.ID, "test", "cleanup") })
+
+	if _, err := l.RunDueRecurringTransfers(ctx, 20); err != nil {
+		t.Fatal(err)
+	}
+	got, err := l.GetRecurringTransfer(ctx, rt.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// an hour overdue: one transfer, then the next run after now rather than 59 catch-up runs
+	if got.Runs != 1 || got.LastOutcome == nil || *got.LastOutcome != "applied" || !got.NextRunAt.After(l.Now()) {
+		t.Fatalf("after one run = %+v", got)
+	}
+	var amount int64
+	err = db.QueryRow(ctx, `SELECT amount_units FROM transactions WHERE request_id=$1`, fmt.Sprintf("recurring-%s-1", rt.ID)).Scan(&amount)
+	if err != nil || amount != 7 {
+		t.Fatalf("run transfer: amount %d (%v)", amount, err)
+	}
+
+	paused, err := l.PauseRecurringTransfer(ctx, rt.ID, "test", "quiet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paused.Status != "PAUSED" || paused.NextRunAt != nil {
+		t.Fatalf("paused = %+v", paused)
+	}
+	if _, err := l.PauseRecurringTransfer(ctx, rt.ID, "test", "again"); !IsRecurringTransferNotFound(err) {
+		t.Fatalf("second pause: err = %v", err)
+	}
+	resumed, err := l.ResumeRecurringTransfer(ctx, rt.ID, "test", "back")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resumed.Status != "ACTIVE" || resumed.NextRunAt == nil || !resumed.NextRunAt.After(l.Now().Add(-time.Second)) {
+		t.Fatalf("resumed = %+v", resumed)
+	}
+}
+
+func TestCreateRecurringTransferValidation(t *testing.T) {
+	l := NewWithRepo(nil, nil)
+	base := CreateRecurringTransferInput{ZoneID: "zone-eu", FromAccount: "a", ToAccount: "b", AmountUnits: 1, Actor: "test"}
+	for name, mod := range map[string]func(*CreateRecurringTransferInput){
+		"no schedule":   func(in *CreateRecurringTransferInput) {},
+		"both":          func(in *CreateRecurringTransferInput) { in.Cron, in.Interval = "* * * * *", time.Minute },
+		"bad cron":      func(in *CreateRecurringTransferInput) { in.Cron = "* * *" },
+		"sub-second":    func(in *CreateRecurringTransferInput) { in.Interval = 500 * time.Millisecond },
+		"same account":  func(in *CreateRecurringTransferInput) { in.Interval, in.ToAccount = time.Minute, "a" },
+		"zero amount":   func(in *CreateRecurringTransferInput) { in.Interval, in.AmountUnits = time.Minute, 0 },
+		"never matches": func(in *CreateRecurringTransferInput) { in.Cron = "0 0 31 4 *" },
+	} {
+		in := base
+		mod(&in)
+		if _, err := l.CreateRecurringTransfer(context.Background(), in); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
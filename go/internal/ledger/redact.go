@@ -0,0 +1,97 @@
+package ledger
+
+import (
+  "encoding/json"
+  "fmt"
+  "path"
+  "strings"
+)
+
+// Redacted replaces the value of a metadata key the redaction policy matches.
+const Redacted = "[REDACTED]"
+
+// RedactionPolicy masks transfer metadata on its way out of the ledger: the
+// change feed, snapshots and exports. The transactions table keeps the full
+// value, as do the transfer reads and the payload hash, so idempotent retries
+// and the hash chain are unaffected. A nil policy redacts nothing.
+type RedactionPolicy struct {
+  patterns []string // lower-cased path.Match patterns
+}
+
+// NewRedactionPolicy parses comma-separated key patterns such as
+// "email,*_name,card.*". A pattern matches a key case-insensitively at any
+// depth; one with dots matches the dotted path of a nested key instead. It
+// returns nil when spec has no patterns.
+func NewRedactionPolicy(spec string) (*RedactionPolicy, error) {
+  p := &RedactionPolicy{}
+  for _, s := range strings.Split(spec, ",") {
+    s = strings.ToLower(strings.TrimSpace(s))
+    if s == "" { continue }
+    if _, err := path.Match(s, ""); err != nil { return nil, fmt.Errorf("pattern %q: %v", s, err) }
+    p.patterns = append(p.patterns, s)
+  }
+  if len(p.patterns) == 0 { return nil, nil }
+  return p, nil
+}
+
+// Redact returns a copy of m with the values of matching keys replaced by
+// Redacted. m itself is never modified; without a policy it is returned as is.
+func (p *RedactionPolicy) Redact(m map[string]any) map[string]any {
+  if p == nil || m == nil { return m }
+  out, _ := p.redact("", m).(map[string]any)
+  return out
+}
+
+// RedactValue is Redact for metadata decoded into an any (e.g. a jsonb column).
+func (p *RedactionPolicy) RedactValue(v any) any {
+  if p == nil { return v }
+  return p.redact("", v)
+}
+
+// RedactJSON redacts a metadata document; input that is not a JSON object
+// passes unchanged.
+func (p *RedactionPolicy) RedactJSON(b []byte) []byte {
+  if p == nil || len(b) == 0 { return b }
+  var m map[string]any
+  if json.Unmarshal(b, &m) != nil { return b }
+  out, err := json.Marshal(p.Redact(m))
+  if err != nil { return b }
+  return out
+}
+
+func (p *RedactionPolicy) redact(prefix string, v any) any {
+  switch v := v.(type) {
+  case map[string]any:
+    out := make(map[string]any, len(v))
+    for k, val := range v {
+      key := strings.ToLower(k)
+      if prefix != "" { key = prefix + "." + key }
+      if p.matches(key) {
+        out[k] = Redacted
+      } else {
+        out[k] = p.redact(key, val)
+      }
+    }
+    return out
+  case []any:
+    out := make([]any, len(v))
+    for i, val := range v { out[i] = p.redact(prefix, val) }
+    return out
+  }
+  return v
+}
+
+// matches reports whether a key, given as its dotted path, matches a pattern.
+func (p *RedactionPolicy) matches(key string) bool {
+  leaf := key[strings.LastIndexByte(key, '.')+1:]
+  for _, pat := range p.patterns {
+    name := leaf
+    if strings.Contains(pat, ".") { name = key }
+    if ok, _ := path.Match(pat, name); ok { return true }
+  }
+  return false
+}
+
+// SetMetadataRedaction sets the policy applied to metadata in the change
+// feed, snapshots and exports; nil turns redaction off.
+func (l *Ledger) SetMetadataRedaction(p *RedactionPolicy) { l.redaction.Store(p) }
@@ -0,0 +1,55 @@
+package ledger
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestRedactionPolicy(t *testing.T) {
+	p, err := NewRedactionPolicy(" Email, *_name ,card.*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := map[string]any{
+		"email":      "a@example.com",
+		"first_name": "Ada",
+		"order":      "o-1",
+		"card":       map[string]any{"number": "4111", "brand": "visa"},
+		"payer":      map[string]any{"EMAIL": "b@example.com", "card": map[string]any{"number": "5500"}},
+		"items":      []any{map[string]any{"last_name": "L", "sku": "s"}},
+	}
+	want := map[string]any{
+		"email":      Redacted,
+		"first_name": Redacted,
+		"order":      "o-1",
+		"card":       map[string]any{"number": Redacted, "brand": Redacted},
+		"payer":      map[string]any{"EMAIL": Redacted, "card": map[string]any{"number": "5500"}},
+		"items":      []any{map[string]any{"last_name": Redacted, "sku": "s"}},
+	}
+	if got := p.Redact(in); !reflect.DeepEqual(got, want) {
+		t.Fatalf("redacted = %v", got)
+	}
+	if in["email"] != "a@example.com" || in["card"].(map[string]any)["number"] != "4111" {
+		t.Fatalf("input modified: %v", in)
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(p.RedactJSON([]byte(`{"email":"x","n":1}`)), &doc); err != nil || doc["email"] != Redacted || doc["n"] != 1.0 {
+		t.Fatalf("json = %v, %v", doc, err)
+	}
+	if got := string(p.RedactJSON([]byte(`null`))); got != "null" {
+		t.Errorf("non-object json = %s", got)
+	}
+
+	var off *RedactionPolicy
+	if got := off.Redact(in); !reflect.DeepEqual(got, in) {
+		t.Errorf("nil policy redacted: %v", got)
+	}
+	if p, err := NewRedactionPolicy(" , "); p != nil || err != nil {
+		t.Errorf("empty spec = %v, %v", p, err)
+	}
+	if _, err := NewRedactionPolicy("[card"); err == nil {
+		t.Error("bad pattern accepted")
+	}
+}
@@ -0,0 +1,112 @@
+package ledger
+
+import (
+  "context"
+  "encoding/json"
+  "errors"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+)
+
+// SpoolEntry is a spooled transfer as reported alongside the transaction it became.
+type SpoolEntry struct {
+  ID string `json:"id"`
+  ZoneID string `json:"zone_id"`
+  Status string `json:"status"` // PENDING|APPLIED|FAILED
+  FailReason *string `json:"fail_reason"` // why it was spooled, or why replay failed
+  CreatedAt time.Time `json:"created_at"`
+  AppliedAt *time.Time `json:"applied_at"`
+}
+
+// RelatedEvent is an outbox event about a transaction and whether the
+// publisher has sent it yet.
+type RelatedEvent struct {
+  ID string `json:"id"`
+  EventType string `json:"event_type"`
+  Status string `json:"status"` // PENDING|PUBLISHED
+  RequestID *string `json:"request_id"` // X-Request-Id of the API call that produced it
+  CreatedAt time.Time `json:"created_at"`
+  PublishedAt *time.Time `json:"published_at"`
+  StreamSeq *int64 `json:"stream_seq,omitempty"` // JetStream sequence from the publish ack
+}
+
+// TransactionRelated is everything recorded about one transfer.
+type TransactionRelated struct {
+  Transaction TransactionRow `json:"transaction"`
+  Spool *SpoolEntry `json:"spool"` // nil unless it was spooled and replayed
+  Events []RelatedEvent `json:"events"`
+  Incidents []Incident `json:"incidents"`
+  Audit []AuditEntry `json:"audit"` // oldest first, up to maxRelatedAudit
+}
+
+const maxRelatedAudit = 200
+
+// GetTransactionRelated gathers the records linked to a transaction: the
+// spool entry it was replayed from, its outbox events, incidents that name it,
+// and audit entries about it, its spooling, or the API call that posted it.
+func (l *Ledger) GetTransactionRelated(ctx context.Context, id string) (*TransactionRelated, error) {
+  var rel TransactionRelated
+  t, err := scanTransactionRow(l.db.QueryRow(ctx, `SELECT `+transactionRowCols+` FROM transactions WHERE id::text=$1`, id))
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrTransactionNotFound }
+  if err != nil { return nil, err }
+  rel.Transaction = *t
+
+  var s SpoolEntry
+  err = l.db.QueryRow(ctx, `
+    SELECT id::text, zone_id, status, fail_reason, created_at, applied_at
+    FROM spooled_transfers WHERE request_id=$1
+  `, t.RequestID).Scan(&s.ID, &s.ZoneID, &s.Status, &s.FailReason, &s.CreatedAt, &s.AppliedAt)
+  if err == nil { rel.Spool = &s } else if !errors.Is(err, pgx.ErrNoRows) { return nil, err }
+
+  // request ids whose audit entries belong here: the transfer's own (spooling)
+  // and the API calls that produced its events
+  requestIDs := []string{t.RequestID}
+  rel.Events = []RelatedEvent{}
+  rows, err := l.db.Query(ctx, `
+    SELECT id::text, event_type, request_id, created_at, published_at, stream_seq
+    FROM outbox_events WHERE aggregate_type='transaction' AND aggregate_id=$1
+    ORDER BY created_at
+  `, t.ID)
+  if err != nil { return nil, err }
+  for rows.Next() {
+    var e RelatedEvent
+    if err := rows.Scan(&e.ID, &e.EventType, &e.RequestID, &e.CreatedAt, &e.PublishedAt, &e.StreamSeq); err != nil { rows.Close(); return nil, err }
+    e.Status = "PENDING"
+    if e.PublishedAt != nil { e.Status = "PUBLISHED" }
+    if e.RequestID != nil { requestIDs = append(requestIDs, *e.RequestID) }
+    rel.Events = append(rel.Events, e)
+  }
+  rows.Close()
+  if err := rows.Err(); err != nil { return nil, err }
+
+  rel.Incidents = []Incident{}
+  rows, err = l.db.Query(ctx, `
+    SELECT id::text, zone_id, related_txn_id::text, severity, status, title, details, detected_at
+    FROM incidents WHERE related_txn_id::text=$1 ORDER BY detected_at
+  `, t.ID)
+  if err != nil { return nil, err }
+  for rows.Next() {
+    var inc Incident
+    var detailsBytes []byte
+    if err := rows.Scan(&inc.ID, &inc.ZoneID, &inc.RelatedTxnID, &inc.Severity, &inc.Status, &inc.Title, &detailsBytes, &inc.DetectedAt); err != nil { rows.Close(); return nil, err }
+    _ = json.Unmarshal(detailsBytes, &inc.Details)
+    rel.Incidents = append(rel.Incidents, inc)
+  }
+  rows.Close()
+  if err := rows.Err(); err != nil { return nil, err }
+
+  rows, err = l.db.Query(ctx, `
+    SELECT id::text, actor, action, target_type, target_id, reason, details, created_at
+    FROM audit_log
+    WHERE ((target_type='transaction' AND target_id=$1)
+       OR (details ? 'request_id' AND details->>'request_id' = ANY($2))
+       OR (target_type='incident' AND target_id IN (SELECT id::text FROM incidents WHERE related_txn_id::text=$1))
+    ) AND NOT `+drillHidden+`
+    ORDER BY created_at, id
+    LIMIT $3
+  `, t.ID, requestIDs, maxRelatedAudit)
+  if err != nil { return nil, err }
+  if rel.Audit, err = scanAuditEntries(rows); err != nil { return nil, err }
+  return &rel, nil
+}
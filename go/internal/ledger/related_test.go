@@ -0,0 +1,46 @@
+package ledger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"time-ledger-sim/go/internal/store/storetest"
+)
+
+func TestGetTransactionRelated(t *testing.T) {
+	db := storetest.Open(t)
+	ctx := context.Background()
+	l := New(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	txn, _, err := l.CreateTransfer(ctx, CreateTransferInput{
+		RequestID: "related-test-" + uuid.NewString(), PayloadHash: "h", FromAccount: "acct-a", ToAccount: "acct-b", AmountUnits: 1, ZoneID: "zone-eu",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.AnnotateTransaction(ctx, txn.ID, AnnotateInput{Tags: []string{"checked"}, Actor: "test"}); err != nil {
+		t.Fatal(err)
+	}
+
+	rel, err := l.GetTransactionRelated(ctx, txn.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rel.Spool != nil {
+		t.Errorf("spool = %+v for a transfer posted directly", rel.Spool)
+	}
+	if len(rel.Events) != 1 || rel.Events[0].EventType != "TRANSFER_POSTED" {
+		t.Errorf("events = %+v", rel.Events)
+	}
+	if len(rel.Audit) != 1 || rel.Audit[0].Action != "ANNOTATE_TRANSACTION" {
+		t.Errorf("audit = %+v", rel.Audit)
+	}
+
+	if _, err := l.GetTransactionRelated(ctx, uuid.NewString()); !IsTransactionNotFound(err) {
+		t.Fatalf("unknown transaction: err = %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package ledger
+
+import (
+  "context"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+)
+
+// JobKindReplaySpool replays a zone's spool in the background: each step
+// applies up to RatePerSec pending entries, one step per second of wall
+// time, until the spool is empty (entries held by a partition stay),
+// MaxItems entries were processed or the job is cancelled.
+const JobKindReplaySpool = "REPLAY_SPOOL"
+
+const (
+  defaultReplayJobRate = 50
+  maxReplayJobRate = 500 // one PendingSpool batch
+)
+
+// ReplayJobParams are a REPLAY_SPOOL job's params.
+type ReplayJobParams struct {
+  ZoneID string `json:"zone_id"`
+  RatePerSec int `json:"rate_per_sec"`
+  MaxItems int `json:"max_items"` // 0: until the spool is empty
+  ReasonCode string `json:"reason_code,omitempty"`
+}
+
+// ReplayJobProgress is a REPLAY_SPOOL job's progress.
+type ReplayJobProgress struct {
+  Applied int `json:"applied"`
+  Failed int `json:"failed"`
+  // Skipped counts entries the last batch held back behind a partition.
+  Skipped int `json:"skipped"`
+  // Remaining is the zone's PENDING entries after the last batch.
+  Remaining int64 `json:"remaining"`
+}
+
+type StartReplayJobInput struct {
+  RatePerSec int // 0 means 50
+  MaxItems int // 0: until the spool is empty
+  Actor string
+  ReasonCode string
+  Reason string
+}
+
+// StartReplayJob queues a replay of the zone's spool. A zone runs one replay
+// job at a time.
+func (l *Ledger) StartReplayJob(ctx context.Context, zoneID string, in StartReplayJobInput) (*Job, error) {
+  if in.RatePerSec == 0 { in.RatePerSec = defaultReplayJobRate }
+  if in.RatePerSec < 1 || in.RatePerSec > maxReplayJobRate { return nil, fmt.Errorf("rate_per_sec must be between 1 and %d", maxReplayJobRate) }
+  if in.MaxItems < 0 { return nil, fmt.Errorf("max_items must not be negative") }
+
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  if err := l.checkActors(ctx, tx, in.Actor); err != nil { return nil, err }
+  if err := l.checkReasonCode(ctx, tx, ReasonForReplaySpool, in.ReasonCode); err != nil { return nil, err }
+  if _, err := (pgQueries{tx}).ZoneStatus(ctx, zoneID); err != nil { return nil, err }
+
+  var pending int64
+  if err := tx.QueryRow(ctx, `SELECT COUNT(*) FROM spooled_transfers WHERE zone_id=$1 AND status='PENDING'`, zoneID).Scan(&pending); err != nil { return nil, err }
+  j, err := l.enqueueJob(ctx, tx, jobSpec{
+    Kind: JobKindReplaySpool, Target: zoneID, DedupeKey: "replay-spool:" + zoneID,
+    Params: ReplayJobParams{ZoneID: zoneID, RatePerSec: in.RatePerSec, MaxItems: in.MaxItems, ReasonCode: in.ReasonCode},
+    Progress: ReplayJobProgress{Remaining: pending},
+    Actor: in.Actor, Reason: in.Reason,
+  })
+  if err != nil { return nil, err }
+
+  err = l.audit(ctx, pgQueries{tx}, AuditRecord{
+    Actor: in.Actor, Action: "START_REPLAY_JOB", TargetType: "zone", TargetID: zoneID, Reason: in.Reason, ReasonCode: in.ReasonCode,
+    Details: map[string]any{"job_id": j.ID, "rate_per_sec": in.RatePerSec, "max_items": in.MaxItems, "remaining": pending},
+  })
+  if err != nil { return nil, err }
+
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  return j, nil
+}
+
+// ListReplayJobs returns the zone's replay jobs, newest first.
+func (l *Ledger) ListReplayJobs(ctx context.Context, zoneID string, limit int) ([]Job, error) {
+  return l.ListJobs(ctx, JobFilter{Kind: JobKindReplaySpool, Target: zoneID, Limit: limit})
+}
+
+// replaySpoolStep replays up to RatePerSec entries (fewer near MaxItems). A
+// zone that is not ready (down, blocked or fully throttled) fails the job;
+// other errors are retried on the next step.
+func (l *Ledger) replaySpoolStep(ctx context.Context, j *Job) (JobStep, error) {
+  var p ReplayJobParams
+  if err := json.Unmarshal(j.Params, &p); err != nil { return JobStep{}, err }
+  var prog ReplayJobProgress
+  if len(j.Progress) > 0 {
+    if err := json.Unmarshal(j.Progress, &prog); err != nil { return JobStep{}, err }
+  }
+
+  size := p.RatePerSec
+  if p.MaxItems > 0 { size = min(size, p.MaxItems-prog.Applied-prog.Failed) }
+  // a batch is small: finish it even if the job is cancelled meanwhile
+  res, err := l.replayBatch(context.WithoutCancel(ctx), p.ZoneID, size)
+  if errors.Is(err, errZoneNotReady) { return JobStep{}, err }
+  if err != nil {
+    l.log.WarnContext(ctx, "replay job batch failed", "job_id", j.ID, "zone_id", p.ZoneID, "err", err.Error())
+    return JobStep{After: time.Second}, nil
+  }
+
+  processed := res.Applied + res.Failed
+  prog.Applied += res.Applied
+  prog.Failed += res.Failed
+  prog.Skipped = res.Skipped
+  if err := l.db.QueryRow(ctx, `SELECT COUNT(*) FROM spooled_transfers WHERE zone_id=$1 AND status='PENDING'`, p.ZoneID).Scan(&prog.Remaining); err != nil {
+    l.log.WarnContext(ctx, "replay job remaining count failed", "job_id", j.ID, "err", err.Error())
+  }
+  // nothing processed: nothing left but entries held by a partition
+  done := processed == 0 || (p.MaxItems > 0 && prog.Applied+prog.Failed >= p.MaxItems)
+  step := JobStep{Progress: prog, Done: done, After: time.Second}
+  if done { step.Result = prog }
+  return step, nil
+}
@@ -0,0 +1,167 @@
+package ledger
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"time-ledger-sim/go/internal/store/storetest"
+)
+
+func TestReplayJob(t *testing.T) {
+	db := storetest.Open(t)
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	l := New(db, log)
+	runner := NewJobRunner(l, log, 1)
+
+	zone := "zone-rj-" + uuid.NewString()[:8]
+	if _, err := l.CreateZone(ctx, CreateZoneInput{ID: zone, Name: zone, Actor: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.SetZoneControls(ctx, zone, SetZoneControlsInput{WritesBlocked: true, CrossZoneThrottle: 100, SpoolEnabled: true, Actor: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	for i := range 5 {
+		_, spoolID, err := l.CreateTransfer(ctx, CreateTransferInput{
+			RequestID: "rj-" + uuid.NewString(), PayloadHash: "h", FromAccount: zone + "-a", ToAccount: zone + "-b", AmountUnits: int64(i + 1), ZoneID: zone,
+		})
+		if err != nil || spoolID == nil {
+			t.Fatalf("transfer %d: spool=%v err=%v", i, spoolID, err)
+		}
+	}
+	progress := func(j *Job) ReplayJobProgress {
+		t.Helper()
+		var p ReplayJobProgress
+		if err := json.Unmarshal(j.Progress, &p); err != nil {
+			t.Fatalf("progress %s: %v", j.Progress, err)
+		}
+		return p
+	}
+	// replay steps are a second apart; make the next one due at once
+	step := func(id string) *Job {
+		t.Helper()
+		if _, err := db.Exec(ctx, `UPDATE jobs SET run_after=now() WHERE id=$1::uuid`, id); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := runner.RunDue(ctx); err != nil {
+			t.Fatal(err)
+		}
+		j, err := l.GetJob(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return j
+	}
+
+	// still blocked: the first batch fails the job
+	blocked, err := l.StartReplayJob(ctx, zone, StartReplayJobInput{RatePerSec: 2, Actor: "test"})
+	if err != nil || blocked.Status != JobQueued || progress(blocked).Remaining != 5 {
+		t.Fatalf("start = %+v, %v", blocked, err)
+	}
+	if _, err := l.StartReplayJob(ctx, zone, StartReplayJobInput{Actor: "test"}); !IsJobRunning(err) {
+		t.Fatalf("second job err = %v", err)
+	}
+	if j := step(blocked.ID); j.Status != JobFailed || j.Error == nil {
+		t.Fatalf("job on a blocked zone = %+v", j)
+	}
+
+	if _, err := l.SetZoneControls(ctx, zone, SetZoneControlsInput{CrossZoneThrottle: 100, SpoolEnabled: true, Actor: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	job, err := l.StartReplayJob(ctx, zone, StartReplayJobInput{RatePerSec: 2, MaxItems: 4, Actor: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	j := step(job.ID)
+	if p := progress(j); j.Status != JobRunning || p.Applied != 2 || p.Remaining != 3 {
+		t.Fatalf("after one batch = %+v", j)
+	}
+	j = step(job.ID)
+	if p := progress(j); j.Status != JobCompleted || p.Applied != 4 || p.Remaining != 1 || j.FinishedAt == nil || j.Result == nil {
+		t.Fatalf("after max_items = %+v", j)
+	}
+
+	last, err := l.StartReplayJob(ctx, zone, StartReplayJobInput{Actor: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, err := l.CancelJob(ctx, last.ID, "test", "changed plan"); err != nil || c.Status != JobCancelled {
+		t.Fatalf("cancel = %+v, %v", c, err)
+	}
+	if _, err := l.CancelJob(ctx, last.ID, "test", ""); !IsJobNotFound(err) {
+		t.Fatalf("second cancel err = %v", err)
+	}
+	if list, err := l.ListReplayJobs(ctx, zone, 10); err != nil || len(list) != 3 || list[0].ID != last.ID {
+		t.Fatalf("jobs = %+v, %v", list, err)
+	}
+}
+
+func TestJobRunner(t *testing.T) {
+	db := storetest.Open(t)
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	l := New(db, log)
+	runner := NewJobRunner(l, log, 1)
+
+	kind := "TEST_" + uuid.NewString()[:8]
+	runner.Register(kind, func(ctx context.Context, j *Job) (JobStep, error) {
+		n := 0
+		if len(j.Progress) > 0 {
+			if err := json.Unmarshal(j.Progress, &n); err != nil {
+				return JobStep{}, err
+			}
+		}
+		n++
+		if n < 3 {
+			return JobStep{Progress: n}, nil
+		}
+		return JobStep{Progress: n, Done: true, Result: map[string]int{"steps": n}}, nil
+	})
+
+	job, err := l.EnqueueJob(ctx, EnqueueJobInput{Kind: kind, Target: "t", DedupeKey: kind, Params: map[string]any{"x": 1}, Actor: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.EnqueueJob(ctx, EnqueueJobInput{Kind: kind, DedupeKey: kind, Actor: "test"}); !IsJobRunning(err) {
+		t.Fatalf("duplicate err = %v", err)
+	}
+	// a kind no runner handles stays queued
+	orphan, err := l.EnqueueJob(ctx, EnqueueJobInput{Kind: kind + "_UNKNOWN", Actor: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := runner.RunDue(ctx); err != nil {
+		t.Fatal(err)
+	}
+	j, err := l.GetJob(ctx, job.ID)
+	if err != nil || j.Status != JobCompleted || j.Steps != 3 || string(j.Result) != `{"steps": 3}` || j.StartedAt == nil {
+		t.Fatalf("job = %+v (result %s), %v", j, j.Result, err)
+	}
+	if o, err := l.GetJob(ctx, orphan.ID); err != nil || o.Status != JobQueued {
+		t.Fatalf("orphan = %+v, %v", o, err)
+	}
+	if list, err := l.ListJobs(ctx, JobFilter{Kind: kind, Status: JobCompleted}); err != nil || len(list) != 1 || list[0].ID != job.ID {
+		t.Fatalf("list = %+v, %v", list, err)
+	}
+
+	// retention only removes finished jobs
+	if _, err := db.Exec(ctx, `UPDATE jobs SET finished_at=finished_at - interval '2 hours' WHERE id=$1::uuid`, job.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.PruneJobs(ctx, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.GetJob(ctx, job.ID); !IsJobNotFound(err) {
+		t.Fatalf("pruned job err = %v", err)
+	}
+	if _, err := l.GetJob(ctx, orphan.ID); err != nil {
+		t.Fatalf("queued job pruned: %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+package ledger
+
+import (
+  "context"
+  "time"
+)
+
+// Repo is the storage the transfer and spool-replay paths run on, so their
+// gating, idempotency and replay rules can be unit-tested against
+// ledgertest.MemRepo instead of Postgres. PgRepo is the production
+// implementation. Admin, reporting and snapshot paths still query the pool.
+type Repo interface {
+  Queries // each call outside InTx runs on its own
+  // InTx runs fn in one transaction, committed when fn returns nil and rolled
+  // back otherwise.
+  InTx(ctx context.Context, fn func(q Queries) error) error
+  // InTxIso is InTx at the given isolation level; InTx is read committed.
+  InTxIso(ctx context.Context, iso Isolation, fn func(q Queries) error) error
+}
+
+// Queries are the reads and writes of one transfer.
+type Queries interface {
+  // ZoneStatus returns ErrZoneNotFound for unknown and retired zones.
+  ZoneStatus(ctx context.Context, zoneID string) (string, error)
+  // ZoneControls returns the zone's controls, creating the default row.
+  ZoneControls(ctx context.Context, zoneID string) (*ZoneControls, error)
+  // FindZoneControls returns nil when the zone has no controls row yet.
+  FindZoneControls(ctx context.Context, zoneID string) (*ZoneControls, error)
+  // AccountControls returns the controls set on any of the accounts.
+  AccountControls(ctx context.Context, accountIDs ...string) ([]AccountControls, error)
+  // AccountZone returns the zone of an existing account ("" if unknown).
+  AccountZone(ctx context.Context, accountID string) (string, error)
+  // Balance returns the account's balance projection (0 if it has none).
+  Balance(ctx context.Context, accountID string) (int64, error)
+  // DebitedSince returns the units debited from the account at or after since.
+  DebitedSince(ctx context.Context, accountID string, since time.Time) (int64, error)
+  // HeldUnits returns the units held by the account's prepares still pending
+  // at at, leaving out the prepare of exceptRequest.
+  HeldUnits(ctx context.Context, accountID, exceptRequest string, at time.Time) (int64, error)
+  // Partition returns the from -> to partition, or nil.
+  Partition(ctx context.Context, fromZone, toZone string) (*Partition, error)
+  // LockRateBucket returns the zone's token bucket, creating it with tokens
+  // at now, and holds it until the transaction ends.
+  LockRateBucket(ctx context.Context, zoneID string, tokens float64, now time.Time) (float64, time.Time, error)
+  SaveRateBucket(ctx context.Context, zoneID string, tokens float64, at time.Time) error
+
+  // FindTransaction returns the transaction recorded for requestID and its
+  // payload hash; nil when there is none.
+  FindTransaction(ctx context.Context, requestID string) (*Transaction, string, error)
+  // FindSpooled returns the spool entry id and payload hash for requestID;
+  // "" when there is none.
+  FindSpooled(ctx context.Context, requestID string) (string, string, error)
+  EnsureAccount(ctx context.Context, accountID, zoneID string) error
+  // PostTransfer records an applied transfer: both accounts (created in the
+  // transfer's zone if new), the transaction row, its debit and credit
+  // postings, both balance projections and the outbox event, and returns
+  // the transaction's sequence number in its zone. PgRepo sends them as one
+  // pipelined batch.
+  //
+  // PostTransfer and InsertSpooled first lock the zone row against
+  // RetireZone until the transaction ends, and return ErrZoneNotFound when
+  // the zone is retired, even if the caller's zone status was cached.
+  PostTransfer(ctx context.Context, t PostedTransfer) (int64, error)
+  InsertOutbox(ctx context.Context, ev OutboxEvent) error
+  InsertAudit(ctx context.Context, a AuditRecord) error
+
+  InsertSpooled(ctx context.Context, in CreateTransferInput, metadata []byte, failReason string, at time.Time) (string, error)
+  // PendingSpool returns up to limit PENDING entries of a zone, oldest first.
+  PendingSpool(ctx context.Context, zoneID string, limit int) ([]SpooledTransfer, error)
+  MarkSpoolApplied(ctx context.Context, id string) error
+  MarkSpoolFailed(ctx context.Context, id, reason string) error
+}
+
+// PostedTransfer is what PostTransfer writes. The ledger picks ID up front so
+// the outbox event can carry it without waiting for the insert.
+type PostedTransfer struct {
+  ID string // transaction uuid
+  In CreateTransferInput
+  Metadata []byte
+  CreatedAt time.Time
+  ClockSkewMs int64
+  Event OutboxEvent
+}
+
+// OutboxEvent is a row for outbox_events; RequestID and TraceContext may be "".
+type OutboxEvent struct {
+  EventType string
+  AggregateType string
+  AggregateID string
+  Payload []byte
+  RequestID string
+  TraceContext string
+}
+
+// AuditRecord is a row for audit_log.
+type AuditRecord struct {
+  ID string
+  Actor string
+  Action string
+  TargetType string
+  TargetID string
+  Reason string // "" is stored as NULL
+  ReasonCode string // recorded as details.reason_code when set
+  Details map[string]any
+  CreatedAt time.Time
+  Signature []byte // nil when unsigned
+  KeyID string
+}
+
+// SpooledTransfer is a transfer held in the spool.
+type SpooledTransfer struct {
+  ID string
+  RequestID string
+  PayloadHash string
+  FromAccount string
+  ToAccount string
+  AmountUnits int64
+  ZoneID string
+  Type string
+  Metadata []byte
+  FailReason string
+}
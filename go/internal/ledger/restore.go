@@ -0,0 +1,404 @@
+package ledger
+
+import (
+  "bufio"
+  "context"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "io"
+  "sort"
+  "strings"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+
+  "time-ledger-sim/go/internal/store"
+)
+
+type RestoreOptions struct {
+  DryRun bool // validate and report only; nothing is written
+  Scope []string // restore only these scopes (RestoreScope*); empty restores everything
+}
+
+// Restore scopes select parts of a snapshot for a selective restore. Zones, controls
+// and balances are upserted for the rows in the snapshot and leave everything else
+// alone; incidents, spool and audit replace their table. Transaction history is never
+// touched by a scoped restore, so restored balances are not rebuilt from postings.
+const (
+  RestoreScopeZones = "zones"
+  RestoreScopeControls = "controls"
+  RestoreScopeBalances = "balances"
+  RestoreScopeIncidents = "incidents"
+  RestoreScopeSpool = "spool"
+  RestoreScopeAudit = "audit"
+)
+
+var restoreScopeSections = map[string]string{
+  RestoreScopeZones: "zones",
+  RestoreScopeControls: "zone_controls",
+  RestoreScopeBalances: "accounts",
+  RestoreScopeIncidents: "incidents",
+  RestoreScopeSpool: "spooled_transfers",
+  RestoreScopeAudit: "audit_log",
+}
+
+// restoreScopeTables are the tables a scope replaces; scopes not listed upsert.
+var restoreScopeTables = map[string]string{
+  RestoreScopeIncidents: "incidents",
+  RestoreScopeSpool: "spooled_transfers",
+  RestoreScopeAudit: "audit_log",
+}
+
+// ParseRestoreScope parses a comma-separated scope list such as "controls,balances".
+func ParseRestoreScope(s string) ([]string, error) {
+  out := []string{}
+  seen := map[string]bool{}
+  for _, p := range strings.Split(s, ",") {
+    p = strings.ToLower(strings.TrimSpace(p))
+    if p == "" || seen[p] { continue }
+    if _, ok := restoreScopeSections[p]; !ok { return nil, fmt.Errorf("unknown restore scope %q", p) }
+    seen[p] = true
+    out = append(out, p)
+  }
+  sort.Strings(out)
+  return out, nil
+}
+
+// scopeSections maps the scope to snapshot sections; nil means every section.
+func (o RestoreOptions) scopeSections() map[string]bool {
+  if len(o.Scope) == 0 { return nil }
+  m := map[string]bool{}
+  for _, sc := range o.Scope { m[restoreScopeSections[sc]] = true }
+  return m
+}
+
+func (o RestoreOptions) resetTables() []string {
+  if len(o.Scope) == 0 { return restoreTables }
+  out := []string{}
+  for _, sc := range o.Scope {
+    if t, ok := restoreScopeTables[sc]; ok { out = append(out, t) }
+  }
+  return out
+}
+
+// Restore applies an in-memory snapshot (the JSON form of Snapshot).
+func (l *Ledger) Restore(ctx context.Context, snap map[string]any, opts RestoreOptions) (*RestoreReport, error) {
+  return l.restore(ctx, opts, func(v *restoreValidator, apply SnapshotEmitter) error {
+    if err := apply("header", snap); err != nil { return err }
+    for _, s := range fullSnapshotSections {
+      if v.sections != nil && !v.sections[s] { continue }
+      raw, present := snap[s]
+      if !present || raw == nil { continue }
+      items, ok := raw.([]any)
+      if !ok {
+        v.sectionError(s, "section must be an array")
+        continue
+      }
+      for _, it := range items {
+        m, _ := it.(map[string]any)
+        if err := apply(s, m); err != nil { return err }
+      }
+    }
+    return nil
+  })
+}
+
+// RestoreNDJSON applies a snapshot written by WriteSnapshotNDJSON, one line at a time.
+func (l *Ledger) RestoreNDJSON(ctx context.Context, r io.Reader, opts RestoreOptions) (*RestoreReport, error) {
+  return l.restore(ctx, opts, func(_ *restoreValidator, apply SnapshotEmitter) error {
+    dec := json.NewDecoder(bufio.NewReader(r))
+    for {
+      var line snapshotLine
+      err := dec.Decode(&line)
+      if errors.Is(err, io.EOF) { return nil }
+      if err != nil { return fmt.Errorf("%w: ndjson: %v", ErrBadSnapshot, err) }
+      if err := apply(line.Section, line.Row); err != nil { return err }
+    }
+  })
+}
+
+// restoreTables are reset before a restore, children first.
+var restoreTables = []string{
+  "postings", "transactions", "zone_sequences", "balances", "accounts", "incidents",
+  "outbox_events", "inbox_events", "audit_log", "spooled_transfers", "zone_controls",
+}
+
+// restore validates every row fed to it. A dry run stops there; a real restore resets
+// mutable state and applies valid rows in one transaction, rolling back if any row
+// is structurally invalid or fails to apply.
+// A real restore resets every tenant's tables, so a tenant-scoped caller
+// only gets the dry run.
+func (l *Ledger) restore(ctx context.Context, opts RestoreOptions, feed func(v *restoreValidator, apply SnapshotEmitter) error) (*RestoreReport, error) {
+  if _, scoped := store.Tenant(ctx); scoped && !opts.DryRun { return nil, fmt.Errorf("%w: a restore resets every tenant; use dry_run", ErrTenantScoped) }
+  zones, err := l.allZoneIDs(ctx)
+  if err != nil { return nil, err }
+  v := newRestoreValidator(zones, opts.DryRun)
+  v.sections = opts.scopeSections()
+  rep := v.report
+  rep.Scope = opts.Scope
+
+  var tx pgx.Tx
+  if !opts.DryRun {
+    tx, err = l.db.BeginTx(ctx, pgx.TxOptions{})
+    if err != nil { return nil, err }
+    defer func(){ _ = tx.Rollback(ctx) }()
+
+    // Hard reset mutable state for a consistent restore.
+    for _, t := range opts.resetTables() {
+      if _, err := tx.Exec(ctx, `TRUNCATE TABLE `+t+` RESTART IDENTITY CASCADE`); err != nil { return nil, err }
+    }
+    // rolled-up stats are derived from the reset tables: rebuild them from scratch
+    if _, err := tx.Exec(ctx, `DELETE FROM stats_buckets`); err != nil { return nil, err }
+    if _, err := tx.Exec(ctx, `UPDATE stats_rollup_state SET rolled_up_to=NULL`); err != nil { return nil, err }
+    // so are the daily summaries; the day closer closes the days again
+    if _, err := tx.Exec(ctx, `DELETE FROM daily_summaries`); err != nil { return nil, err }
+  }
+
+  // rows before any header (or without one) are read as the oldest format
+  upgrade, _ := snapshotUpgrader("")
+  rep.Version = "v1"
+  err = feed(v, func(section string, m map[string]any) error {
+    if section == "header" {
+      if m == nil { return nil }
+      rep.Full, _ = m["full"].(bool)
+      rep.Redacted, _ = m["redacted"].(bool)
+      v.redacted = rep.Redacted
+      version, _ := m["version"].(string)
+      u, err := snapshotUpgrader(version)
+      if err != nil { return err }
+      upgrade, rep.Version = u, versionOrV1(version)
+      return nil
+    }
+    row := v.check(section, upgrade(section, m))
+    // once the snapshot is known to be invalid, keep validating but stop writing
+    if row == nil || tx == nil || rep.errorCount() > 0 { return nil }
+    if err := l.restoreRowTx(ctx, tx, section, row); err != nil {
+      return fmt.Errorf("restore %s[%d]: %w", section, v.index[section]-1, err)
+    }
+    return nil
+  })
+  if err != nil { return rep, err }
+
+  rep.Valid = rep.errorCount() == 0
+  if !rep.Valid { return rep, fmt.Errorf("%w: %d invalid rows", ErrBadSnapshot, rep.errorCount()) }
+  if opts.DryRun { return rep, nil }
+
+  // full snapshots carry postings, which are the source of truth for balances
+  if rep.Full && len(opts.Scope) == 0 {
+    _, err = tx.Exec(ctx, `
+      INSERT INTO balances(account_id,balance_units,updated_at)
+      SELECT a.id, COALESCE(SUM(CASE WHEN p.direction='CREDIT' THEN p.amount_units ELSE -p.amount_units END),0), now()
+      FROM accounts a LEFT JOIN postings p ON p.account_id=a.id
+      GROUP BY a.id
+      ON CONFLICT (account_id) DO UPDATE SET balance_units=EXCLUDED.balance_units, updated_at=now()
+    `)
+    if err != nil { return rep, err }
+  }
+
+  // default controls for zones the snapshot did not cover
+  if _, err := tx.Exec(ctx, `INSERT INTO zone_controls(zone_id) SELECT id FROM zones ON CONFLICT DO NOTHING`); err != nil { return rep, err }
+
+  if err := tx.Commit(ctx); err != nil { return rep, err }
+  l.zones.invalidate("")
+  return rep, nil
+}
+
+func (l *Ledger) allZoneIDs(ctx context.Context) ([]string, error) {
+  rows, err := l.db.Query(ctx, `SELECT id FROM zones`)
+  if err != nil { return nil, err }
+  defer rows.Close()
+  out := []string{}
+  for rows.Next() {
+    var id string
+    if err := rows.Scan(&id); err != nil { return nil, err }
+    out = append(out, id)
+  }
+  return out, rows.Err()
+}
+
+// restoreRowTx writes one validated row.
+func (l *Ledger) restoreRowTx(ctx context.Context, tx pgx.Tx, section string, m map[string]any) error {
+  var err error
+  switch section {
+  case "zones":
+    // zones: update statuses and tags only; older snapshots keep the tags
+    id, _ := m["id"].(string)
+    status, _ := m["status"].(string)
+    var tags *string
+    if m["tags"] != nil {
+      b, _ := json.Marshal(m["tags"])
+      s := string(b)
+      tags = &s
+    }
+    _, err = tx.Exec(ctx, `UPDATE zones SET status=$2, tags=COALESCE($3::jsonb, tags), updated_at=now() WHERE id=$1`, id, status, tags)
+
+  case "zone_controls":
+    zid, _ := m["zone_id"].(string)
+    wb, _ := m["writes_blocked"].(bool)
+    thr := 100
+    if thrF, ok := m["cross_zone_throttle"].(float64); ok { thr = int(thrF) }
+    sp, _ := m["spool_enabled"].(bool)
+    latF, _ := m["inject_latency_ms"].(float64)
+    jitF, _ := m["inject_jitter_ms"].(float64)
+    errF, _ := m["error_rate_percent"].(float64)
+    mode, _ := m["throttle_mode"].(string)
+    if mode != ThrottleModeRate { mode = ThrottleModeHash }
+    rateF, _ := m["rate_limit_per_sec"].(float64)
+    burstF, _ := m["rate_limit_burst"].(float64)
+    skewF, _ := m["clock_skew_ms"].(float64)
+    // blocked actions came later; older snapshots restore the defaults
+    actions, _ := json.Marshal(m["blocked_actions"])
+    if m["blocked_actions"] == nil { actions = []byte("{}") }
+    delayF, _ := m["blocked_delay_ms"].(float64)
+    minF, _ := m["min_amount_units"].(float64)
+    maxF, _ := m["max_amount_units"].(float64)
+    dailyF, _ := m["daily_account_limit_units"].(float64)
+    cutoverF, _ := m["day_cutover_minute"].(float64)
+    _, err = tx.Exec(ctx, `
+      INSERT INTO zone_controls(zone_id,writes_blocked,cross_zone_throttle,spool_enabled,inject_latency_ms,inject_jitter_ms,error_rate_percent,
+        throttle_mode,rate_limit_per_sec,rate_limit_burst,clock_skew_ms,blocked_actions,blocked_delay_ms,
+        min_amount_units,max_amount_units,daily_account_limit_units,day_cutover_minute,updated_at)
+      VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12::jsonb,$13,$14,$15,$16,$17,now())
+      ON CONFLICT (zone_id) DO UPDATE
+        SET writes_blocked=EXCLUDED.writes_blocked,
+            cross_zone_throttle=EXCLUDED.cross_zone_throttle,
+            spool_enabled=EXCLUDED.spool_enabled,
+            inject_latency_ms=EXCLUDED.inject_latency_ms,
+            inject_jitter_ms=EXCLUDED.inject_jitter_ms,
+            error_rate_percent=EXCLUDED.error_rate_percent,
+            throttle_mode=EXCLUDED.throttle_mode,
+            rate_limit_per_sec=EXCLUDED.rate_limit_per_sec,
+            rate_limit_burst=EXCLUDED.rate_limit_burst,
+            clock_skew_ms=EXCLUDED.clock_skew_ms,
+            blocked_actions=EXCLUDED.blocked_actions,
+            blocked_delay_ms=EXCLUDED.blocked_delay_ms,
+            min_amount_units=EXCLUDED.min_amount_units,
+            max_amount_units=EXCLUDED.max_amount_units,
+            daily_account_limit_units=EXCLUDED.daily_account_limit_units,
+            day_cutover_minute=EXCLUDED.day_cutover_minute,
+            updated_at=now()
+    `, zid, wb, thr, sp, int(latF), int(jitF), int(errF), mode, int(rateF), int(burstF), int64(skewF), string(actions), int(delayF),
+      int64(minF), int64(maxF), int64(dailyF), int(cutoverF))
+
+  case "accounts":
+    id, _ := m["id"].(string)
+    zid, _ := m["zone_id"].(string)
+    if _, err = tx.Exec(ctx, `INSERT INTO accounts(id, zone_id) VALUES($1,$2) ON CONFLICT DO NOTHING`, id, zid); err != nil { return err }
+    balF, _ := m["balance_units"].(float64)
+    _, err = tx.Exec(ctx, `INSERT INTO balances(account_id,balance_units,updated_at) VALUES($1,$2,now()) ON CONFLICT (account_id) DO UPDATE SET balance_units=EXCLUDED.balance_units, updated_at=now()`, id, int64(balF))
+
+  case "transactions":
+    // original id, request_id and payload_hash are kept so idempotent retries still dedupe
+    id, _ := m["id"].(string)
+    req, _ := m["request_id"].(string)
+    ph, _ := m["payload_hash"].(string)
+    from, _ := m["from_account"].(string)
+    to, _ := m["to_account"].(string)
+    zid, _ := m["zone_id"].(string)
+    amtF, _ := m["amount_units"].(float64)
+    skewF, _ := m["clock_skew_ms"].(float64)
+    mb, _ := json.Marshal(m["metadata"])
+    var runID *string
+    if rs, ok := m["run_id"].(string); ok && rs != "" { runID = &rs }
+    // snapshots taken before zone sequences get numbers from the insert trigger
+    var zoneSeq *int64
+    if f, ok := m["zone_seq"].(float64); ok && f > 0 { n := int64(f); zoneSeq = &n }
+    typ, _ := m["type"].(string) // snapshots taken before transaction types have none
+    _, err = tx.Exec(ctx, `
+      INSERT INTO transactions(id,request_id,payload_hash,from_account,to_account,amount_units,zone_id,metadata,created_at,clock_skew_ms,run_id,zone_seq,type)
+      VALUES($1::uuid,$2,$3,$4,$5,$6,$7,$8::jsonb,$9,$10,$11::uuid,$12,COALESCE(NULLIF($13,''),'TRANSFER'))
+    `, id, req, ph, from, to, int64(amtF), zid, jsonOrEmpty(mb), parseSnapshotTime(m["created_at"]), int64(skewF), runID, zoneSeq, typ)
+
+  case "postings":
+    id, _ := m["id"].(string)
+    txn, _ := m["txn_id"].(string)
+    acct, _ := m["account_id"].(string)
+    dir, _ := m["direction"].(string)
+    amtF, _ := m["amount_units"].(float64)
+    _, err = tx.Exec(ctx, `
+      INSERT INTO postings(id,txn_id,account_id,direction,amount_units,created_at)
+      VALUES($1::uuid,$2::uuid,$3,$4,$5,$6)
+    `, id, txn, acct, dir, int64(amtF), parseSnapshotTime(m["created_at"]))
+
+  case "incidents":
+    zid, _ := m["zone_id"].(string)
+    sev, _ := m["severity"].(string)
+    st, _ := m["status"].(string)
+    title, _ := m["title"].(string)
+    var rel *string
+    if rs, ok := m["related_txn_id"].(string); ok && rs != "" { rel = &rs }
+    if sev=="" { sev="INFO" }
+    if st=="" { st="OPEN" }
+    b, _ := json.Marshal(m["details"])
+    // a scoped restore keeps existing history, so references it lacks are dropped
+    _, err = tx.Exec(ctx, `INSERT INTO incidents(zone_id,related_txn_id,severity,status,title,details)
+      VALUES($1,(SELECT id FROM transactions WHERE id=$2::uuid),$3,$4,$5,$6::jsonb)`,
+      zid, rel, sev, st, title, jsonOrEmpty(b))
+
+  case "spooled_transfers":
+    req, _ := m["request_id"].(string)
+    ph, _ := m["payload_hash"].(string)
+    from, _ := m["from_account"].(string)
+    to, _ := m["to_account"].(string)
+    zid, _ := m["zone_id"].(string)
+    amtF, _ := m["amount_units"].(float64)
+    st, _ := m["status"].(string)
+    if st == "" { st = "PENDING" }
+    var fail *string
+    if fs, ok := m["fail_reason"].(string); ok && fs != "" { fail = &fs }
+    mb, _ := json.Marshal(m["metadata"])
+    typ, _ := m["type"].(string)
+    _, err = tx.Exec(ctx, `
+      INSERT INTO spooled_transfers(request_id,payload_hash,from_account,to_account,amount_units,zone_id,metadata,status,fail_reason,updated_at,type)
+      VALUES($1,$2,$3,$4,$5,$6,$7::jsonb,$8,$9,now(),COALESCE(NULLIF($10,''),'TRANSFER'))
+    `, req, ph, from, to, int64(amtF), zid, jsonOrEmpty(mb), st, fail, typ)
+
+  case "audit_log":
+    actor, _ := m["actor"].(string)
+    action, _ := m["action"].(string)
+    tt, _ := m["target_type"].(string)
+    tid, _ := m["target_id"].(string)
+    reason, _ := m["reason"].(string)
+    details, _ := m["details"].(map[string]any)
+    // re-recorded (and signed) as written now, like the rest of the restore
+    err = l.audit(ctx, pgQueries{tx}, AuditRecord{Actor: actor, Action: action, TargetType: tt, TargetID: tid, Reason: reason, Details: details})
+
+  case "outbox_events":
+    id, _ := m["id"].(string)
+    et, _ := m["event_type"].(string)
+    at, _ := m["aggregate_type"].(string)
+    aid, _ := m["aggregate_id"].(string)
+    pb, _ := json.Marshal(m["payload"])
+    var pa *time.Time
+    if _, ok := m["published_at"].(string); ok {
+      t := parseSnapshotTime(m["published_at"])
+      pa = &t
+    }
+    _, err = tx.Exec(ctx, `
+      INSERT INTO outbox_events(id,event_type,aggregate_type,aggregate_id,payload,created_at,published_at)
+      VALUES($1::uuid,$2,$3,$4,$5::jsonb,$6,$7)
+    `, id, et, at, aid, jsonOrEmpty(pb), parseSnapshotTime(m["created_at"]), pa)
+
+  case "inbox_events":
+    consumer, _ := m["consumer"].(string)
+    eid, _ := m["event_id"].(string)
+    _, err = tx.Exec(ctx, `INSERT INTO inbox_events(consumer,event_id,processed_at) VALUES($1,$2::uuid,$3)`,
+      consumer, eid, parseSnapshotTime(m["processed_at"]))
+  }
+  return err
+}
+
+// jsonOrEmpty maps a marshalled nil to an empty object for NOT NULL jsonb columns.
+func jsonOrEmpty(b []byte) string {
+  if len(b) == 0 || string(b) == "null" { return "{}" }
+  return string(b)
+}
+
+// parseSnapshotTime reads an RFC3339 timestamp, falling back to now for missing values.
+func parseSnapshotTime(v any) time.Time {
+  if s, ok := v.(string); ok {
+    if t, err := time.Parse(time.RFC3339Nano, s); err == nil { return t }
+  }
+  return time.Now()
+}
@@ -0,0 +1,297 @@
+package ledger
+
+import "fmt"
+
+const (
+  RestoreOutcomeError = "error"
+  RestoreOutcomeSkipped = "skipped"
+  RestoreOutcomeAdjusted = "adjusted"
+
+  maxRestoreIssues = 1000
+)
+
+type RestoreIssue struct {
+  Section string `json:"section"`
+  Index int `json:"index"` // position within the section; -1 for the section itself
+  Outcome string `json:"outcome"` // error | skipped | adjusted
+  Reason string `json:"reason"`
+}
+
+type RestoreSectionReport struct {
+  Insert int `json:"insert"` // includes adjusted rows
+  Skipped int `json:"skipped"`
+  Adjusted int `json:"adjusted"`
+  Errors int `json:"errors"`
+}
+
+// RestoreReport describes what a restore did (or, for a dry run, would do).
+// Any error outcome makes the snapshot invalid and a real restore is rolled back.
+type RestoreReport struct {
+  DryRun bool `json:"dry_run"`
+  Version string `json:"version"` // format version the snapshot was written in; rows are upgraded to the current one
+  Valid bool `json:"valid"`
+  Full bool `json:"full"`
+  Redacted bool `json:"redacted"` // taken under a metadata redaction policy
+  Scope []string `json:"scope,omitempty"` // empty for a complete restore
+  Sections map[string]*RestoreSectionReport `json:"sections"`
+  Issues []RestoreIssue `json:"issues"`
+  IssuesOmitted int `json:"issues_omitted"`
+}
+
+func (r *RestoreReport) errorCount() int {
+  n := 0
+  for _, s := range r.Sections { n += s.Errors }
+  return n
+}
+
+// restoreValidator checks rows in restore order, tracking keys already seen so
+// duplicates and dangling references are reported the way the restore treats them.
+type restoreValidator struct {
+  zones map[string]bool // zones present in the database
+  seen map[string]map[string]bool
+  index map[string]int
+  sections map[string]bool // sections in scope; nil means all
+  redacted bool // the snapshot's metadata is redacted
+  report *RestoreReport
+}
+
+// metadataSections are the sections whose metadata restore writes back. A
+// redacted snapshot cannot restore them without storing the redacted values.
+var metadataSections = map[string]bool{"transactions": true, "spooled_transfers": true}
+
+func newRestoreValidator(zones []string, dryRun bool) *restoreValidator {
+  v := &restoreValidator{
+    zones: map[string]bool{},
+    seen: map[string]map[string]bool{},
+    index: map[string]int{},
+    report: &RestoreReport{DryRun: dryRun, Sections: map[string]*RestoreSectionReport{}, Issues: []RestoreIssue{}},
+  }
+  for _, z := range zones { v.zones[z] = true }
+  return v
+}
+
+func (v *restoreValidator) section(name string) *RestoreSectionReport {
+  s := v.report.Sections[name]
+  if s == nil {
+    s = &RestoreSectionReport{}
+    v.report.Sections[name] = s
+  }
+  return s
+}
+
+func (v *restoreValidator) issue(section string, idx int, outcome, reason string) {
+  if len(v.report.Issues) >= maxRestoreIssues {
+    v.report.IssuesOmitted++
+    return
+  }
+  v.report.Issues = append(v.report.Issues, RestoreIssue{Section: section, Index: idx, Outcome: outcome, Reason: reason})
+}
+
+// sectionError records a structural problem with a whole section (e.g. not an array).
+func (v *restoreValidator) sectionError(section, reason string) {
+  v.section(section).Errors++
+  v.issue(section, -1, RestoreOutcomeError, reason)
+}
+
+func (v *restoreValidator) markSeen(section, key string) bool {
+  m := v.seen[section]
+  if m == nil {
+    m = map[string]bool{}
+    v.seen[section] = m
+  }
+  if m[key] { return false }
+  m[key] = true
+  return true
+}
+
+func (v *restoreValidator) wasSeen(section, key string) bool { return v.seen[section][key] }
+
+// check validates one row and returns the row to apply (possibly adjusted), or
+// nil when it is skipped or invalid. Sections outside the restore scope are
+// ignored and not reported.
+func (v *restoreValidator) check(section string, m map[string]any) map[string]any {
+  if v.sections != nil && !v.sections[section] { return nil }
+  idx := v.index[section]
+  v.index[section] = idx + 1
+  sr := v.section(section)
+  if idx == 0 && v.redacted && metadataSections[section] {
+    v.sectionError(section, "snapshot metadata is redacted; restore a scope without this section")
+  }
+
+  if m == nil {
+    sr.Errors++
+    v.issue(section, idx, RestoreOutcomeError, "row is not an object")
+    return nil
+  }
+  row, outcome, reason := v.checkRow(section, m)
+  switch outcome {
+  case RestoreOutcomeError:
+    sr.Errors++
+  case RestoreOutcomeSkipped:
+    sr.Skipped++
+  case RestoreOutcomeAdjusted:
+    sr.Adjusted++
+    sr.Insert++
+  default:
+    sr.Insert++
+  }
+  if outcome != "" { v.issue(section, idx, outcome, reason) }
+  if outcome == RestoreOutcomeError || outcome == RestoreOutcomeSkipped { return nil }
+  return row
+}
+
+func (v *restoreValidator) checkRow(section string, m map[string]any) (map[string]any, string, string) {
+  switch section {
+  case "zones":
+    id, ok := m["id"].(string)
+    if !ok || id == "" { return nil, RestoreOutcomeError, "missing id" }
+    if st, _ := m["status"].(string); st != "OK" && st != "DEGRADED" && st != "DOWN" {
+      return nil, RestoreOutcomeError, fmt.Sprintf("invalid status %v", m["status"])
+    }
+    if t, ok := m["tags"]; ok && t != nil {
+      raw, ok := t.(map[string]any)
+      if !ok { return nil, RestoreOutcomeError, "invalid tags" }
+      tags := map[string]string{}
+      for k, v := range raw {
+        tags[k], ok = v.(string)
+        if !ok { return nil, RestoreOutcomeError, "invalid tags" }
+      }
+      if _, err := normalizeZoneTags(tags); err != nil { return nil, RestoreOutcomeError, err.Error() }
+    }
+    if !v.zones[id] { return nil, RestoreOutcomeSkipped, "zone " + id + " does not exist" }
+
+  case "zone_controls":
+    zid, ok := m["zone_id"].(string)
+    if !ok || zid == "" { return nil, RestoreOutcomeError, "missing zone_id" }
+    for _, f := range []string{"cross_zone_throttle", "inject_latency_ms", "inject_jitter_ms", "error_rate_percent", "rate_limit_per_sec", "rate_limit_burst", "clock_skew_ms", "blocked_delay_ms",
+      "min_amount_units", "max_amount_units", "daily_account_limit_units", "day_cutover_minute"} {
+      if err := optionalNumber(m, f); err != nil { return nil, RestoreOutcomeError, err.Error() }
+    }
+    in := SetZoneControlsInput{CrossZoneThrottle: 100, ThrottleMode: ThrottleModeHash}
+    if f, ok := m["cross_zone_throttle"].(float64); ok { in.CrossZoneThrottle = int(f) }
+    if f, ok := m["inject_latency_ms"].(float64); ok { in.InjectLatencyMs = int(f) }
+    if f, ok := m["inject_jitter_ms"].(float64); ok { in.InjectJitterMs = int(f) }
+    if f, ok := m["error_rate_percent"].(float64); ok { in.ErrorRatePercent = int(f) }
+    if s, ok := m["throttle_mode"].(string); ok && s != "" { in.ThrottleMode = s }
+    if f, ok := m["rate_limit_per_sec"].(float64); ok { in.RateLimitPerSec = int(f) }
+    if f, ok := m["rate_limit_burst"].(float64); ok { in.RateLimitBurst = int(f) }
+    if f, ok := m["clock_skew_ms"].(float64); ok { in.ClockSkewMs = int64(f) }
+    if f, ok := m["blocked_delay_ms"].(float64); ok { in.BlockedDelayMs = int(f) }
+    if f, ok := m["min_amount_units"].(float64); ok { in.MinAmountUnits = int64(f) }
+    if f, ok := m["max_amount_units"].(float64); ok { in.MaxAmountUnits = int64(f) }
+    if f, ok := m["daily_account_limit_units"].(float64); ok { in.DailyAccountLimitUnits = int64(f) }
+    if f, ok := m["day_cutover_minute"].(float64); ok { in.DayCutoverMinute = int(f) }
+    if a, ok := m["blocked_actions"]; ok && a != nil {
+      actions, ok := a.(map[string]any)
+      if !ok { return nil, RestoreOutcomeError, "invalid blocked_actions" }
+      in.BlockedActions = map[string]string{}
+      for cause, v := range actions {
+        in.BlockedActions[cause], ok = v.(string)
+        if !ok { return nil, RestoreOutcomeError, "invalid blocked_actions" }
+      }
+    }
+    if err := in.validate(); err != nil { return nil, RestoreOutcomeError, err.Error() }
+    if !v.zones[zid] { return nil, RestoreOutcomeSkipped, "zone " + zid + " does not exist" }
+    if !v.markSeen(section, zid) { return nil, RestoreOutcomeSkipped, "duplicate zone_id " + zid }
+
+  case "accounts":
+    id, ok := m["id"].(string)
+    if !ok || id == "" { return nil, RestoreOutcomeError, "missing id" }
+    if err := optionalNumber(m, "balance_units"); err != nil { return nil, RestoreOutcomeError, err.Error() }
+    if !v.markSeen(section, id) { return nil, RestoreOutcomeSkipped, "duplicate account " + id }
+    zid, _ := m["zone_id"].(string)
+    if zid == "" {
+      adj := copyRow(m)
+      adj["zone_id"] = "zone-eu"
+      return adj, RestoreOutcomeAdjusted, "missing zone_id defaulted to zone-eu"
+    }
+    if !v.zones[zid] { return nil, RestoreOutcomeError, "unknown zone " + zid }
+
+  case "transactions":
+    id, _ := m["id"].(string)
+    req, _ := m["request_id"].(string)
+    if id == "" || req == "" { return nil, RestoreOutcomeError, "missing id or request_id" }
+    if err := requireStrings(m, "payload_hash", "from_account", "to_account", "zone_id"); err != nil { return nil, RestoreOutcomeError, err.Error() }
+    if amt, ok := m["amount_units"].(float64); !ok || amt <= 0 { return nil, RestoreOutcomeError, "amount_units must be > 0" }
+    if zid := m["zone_id"].(string); !v.zones[zid] { return nil, RestoreOutcomeError, "unknown zone " + zid }
+    if typ, _ := m["type"].(string); typ != "" && !knownTransactionType(typ) { return nil, RestoreOutcomeError, "invalid type " + typ }
+    if !v.markSeen("transaction_requests", req) { return nil, RestoreOutcomeSkipped, "duplicate request_id " + req }
+    if !v.markSeen(section, id) { return nil, RestoreOutcomeSkipped, "duplicate id " + id }
+
+  case "postings":
+    id, _ := m["id"].(string)
+    if id == "" { return nil, RestoreOutcomeError, "missing id" }
+    if err := requireStrings(m, "txn_id", "account_id"); err != nil { return nil, RestoreOutcomeError, err.Error() }
+    if d, _ := m["direction"].(string); d != "DEBIT" && d != "CREDIT" { return nil, RestoreOutcomeError, fmt.Sprintf("invalid direction %v", m["direction"]) }
+    if amt, ok := m["amount_units"].(float64); !ok || amt <= 0 { return nil, RestoreOutcomeError, "amount_units must be > 0" }
+    if txn := m["txn_id"].(string); !v.wasSeen("transactions", txn) { return nil, RestoreOutcomeError, "unknown transaction " + txn }
+    if acct := m["account_id"].(string); !v.wasSeen("accounts", acct) { return nil, RestoreOutcomeError, "unknown account " + acct }
+    if !v.markSeen(section, id) { return nil, RestoreOutcomeSkipped, "duplicate id " + id }
+
+  case "incidents":
+    if err := requireStrings(m, "zone_id", "title"); err != nil { return nil, RestoreOutcomeError, err.Error() }
+    if zid := m["zone_id"].(string); !v.zones[zid] { return nil, RestoreOutcomeError, "unknown zone " + zid }
+    if sev, ok := m["severity"].(string); ok && sev != "" && sev != "INFO" && sev != "WARN" && sev != "CRITICAL" {
+      return nil, RestoreOutcomeError, "invalid severity " + sev
+    }
+    if st, ok := m["status"].(string); ok && st != "" && st != "OPEN" && st != "ACK" && st != "RESOLVED" {
+      return nil, RestoreOutcomeError, "invalid status " + st
+    }
+    // a scoped restore keeps the existing history, which the snapshot may not cover
+    if rel, ok := m["related_txn_id"].(string); ok && rel != "" && v.sections == nil && !v.wasSeen("transactions", rel) {
+      adj := copyRow(m)
+      adj["related_txn_id"] = nil
+      return adj, RestoreOutcomeAdjusted, "related_txn_id " + rel + " not in snapshot; reference dropped"
+    }
+
+  case "spooled_transfers":
+    req, _ := m["request_id"].(string)
+    if req == "" { return nil, RestoreOutcomeError, "missing request_id" }
+    if err := requireStrings(m, "payload_hash", "from_account", "to_account", "zone_id"); err != nil { return nil, RestoreOutcomeError, err.Error() }
+    if amt, ok := m["amount_units"].(float64); !ok || amt <= 0 { return nil, RestoreOutcomeError, "amount_units must be > 0" }
+    if zid := m["zone_id"].(string); !v.zones[zid] { return nil, RestoreOutcomeError, "unknown zone " + zid }
+    if st, ok := m["status"].(string); ok && st != "" && st != "PENDING" && st != "APPLIED" && st != "FAILED" {
+      return nil, RestoreOutcomeError, "invalid status " + st
+    }
+    if typ, _ := m["type"].(string); typ != "" && !knownTransactionType(typ) { return nil, RestoreOutcomeError, "invalid type " + typ }
+    if !v.markSeen(section, req) { return nil, RestoreOutcomeSkipped, "duplicate request_id " + req }
+
+  case "audit_log":
+    if err := requireStrings(m, "actor", "action", "target_type", "target_id"); err != nil { return nil, RestoreOutcomeError, err.Error() }
+
+  case "outbox_events":
+    id, _ := m["id"].(string)
+    if id == "" { return nil, RestoreOutcomeError, "missing id" }
+    if err := requireStrings(m, "event_type", "aggregate_type", "aggregate_id"); err != nil { return nil, RestoreOutcomeError, err.Error() }
+    if !v.markSeen(section, id) { return nil, RestoreOutcomeSkipped, "duplicate id " + id }
+
+  case "inbox_events":
+    if err := requireStrings(m, "consumer", "event_id"); err != nil { return nil, RestoreOutcomeError, err.Error() }
+    key := m["consumer"].(string) + "/" + m["event_id"].(string)
+    if !v.markSeen(section, key) { return nil, RestoreOutcomeSkipped, "duplicate event " + key }
+
+  default:
+    return nil, RestoreOutcomeSkipped, "unknown section"
+  }
+  return m, "", ""
+}
+
+func requireStrings(m map[string]any, keys ...string) error {
+  for _, k := range keys {
+    if s, ok := m[k].(string); !ok || s == "" { return fmt.Errorf("missing %s", k) }
+  }
+  return nil
+}
+
+func optionalNumber(m map[string]any, key string) error {
+  v, ok := m[key]
+  if !ok || v == nil { return nil }
+  if _, ok := v.(float64); !ok { return fmt.Errorf("%s must be a number", key) }
+  return nil
+}
+
+func copyRow(m map[string]any) map[string]any {
+  out := make(map[string]any, len(m))
+  for k, v := range m { out[k] = v }
+  return out
+}
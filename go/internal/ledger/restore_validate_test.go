@@ -0,0 +1,143 @@
+package ledger
+
+import "testing"
+
+func TestRestoreValidatorAccounts(t *testing.T) {
+	v := newRestoreValidator([]string{"zone-eu", "zone-us"}, true)
+
+	if row := v.check("accounts", map[string]any{"id": "a", "zone_id": "zone-us", "balance_units": float64(5)}); row == nil {
+		t.Fatal("expected valid account to be inserted")
+	}
+	if row := v.check("accounts", map[string]any{"id": "a", "zone_id": "zone-us"}); row != nil {
+		t.Fatal("expected duplicate account to be skipped")
+	}
+	row := v.check("accounts", map[string]any{"id": "b"})
+	if row == nil || row["zone_id"] != "zone-eu" {
+		t.Fatalf("expected zone_id defaulted to zone-eu, got %v", row)
+	}
+	if row := v.check("accounts", map[string]any{"id": "c", "zone_id": "zone-mars"}); row != nil {
+		t.Fatal("expected unknown zone to be an error")
+	}
+
+	s := v.report.Sections["accounts"]
+	if s.Insert != 2 || s.Skipped != 1 || s.Adjusted != 1 || s.Errors != 1 {
+		t.Fatalf("unexpected section report %+v", s)
+	}
+	if len(v.report.Issues) != 3 {
+		t.Fatalf("expected 3 issues, got %+v", v.report.Issues)
+	}
+	if v.report.Issues[2].Index != 3 || v.report.Issues[2].Outcome != RestoreOutcomeError {
+		t.Fatalf("unexpected issue %+v", v.report.Issues[2])
+	}
+}
+
+func TestRestoreValidatorIncidentDanglingTxn(t *testing.T) {
+	v := newRestoreValidator([]string{"zone-eu"}, true)
+	in := map[string]any{"zone_id": "zone-eu", "title": "x", "severity": "WARN", "related_txn_id": "t-missing"}
+	row := v.check("incidents", in)
+	if row == nil || row["related_txn_id"] != nil {
+		t.Fatalf("expected related_txn_id dropped, got %v", row)
+	}
+	if in["related_txn_id"] != "t-missing" {
+		t.Fatal("adjustment must not modify the input row")
+	}
+	if row := v.check("incidents", map[string]any{"zone_id": "zone-eu", "title": "x", "status": "CLOSED"}); row != nil {
+		t.Fatal("expected invalid status to be an error")
+	}
+	if v.report.errorCount() != 1 {
+		t.Fatalf("expected 1 error, got %d", v.report.errorCount())
+	}
+}
+
+func TestRestoreValidatorPostingsNeedTransaction(t *testing.T) {
+	v := newRestoreValidator([]string{"zone-eu"}, false)
+	v.check("accounts", map[string]any{"id": "a", "zone_id": "zone-eu"})
+	txn := map[string]any{"id": "t1", "request_id": "r1", "payload_hash": "h", "from_account": "a", "to_account": "a", "zone_id": "zone-eu", "amount_units": float64(1)}
+	if v.check("transactions", txn) == nil {
+		t.Fatal("expected transaction to be inserted")
+	}
+	dup := copyRow(txn)
+	dup["id"] = "t2"
+	if v.check("transactions", dup) != nil {
+		t.Fatal("expected duplicate request_id to be skipped")
+	}
+	if v.check("postings", map[string]any{"id": "p1", "txn_id": "t1", "account_id": "a", "direction": "DEBIT", "amount_units": float64(1)}) == nil {
+		t.Fatal("expected posting to be inserted")
+	}
+	if v.check("postings", map[string]any{"id": "p2", "txn_id": "t2", "account_id": "a", "direction": "CREDIT", "amount_units": float64(1)}) != nil {
+		t.Fatal("expected posting for skipped transaction to be an error")
+	}
+}
+
+func TestRestoreValidatorIssueCap(t *testing.T) {
+	v := newRestoreValidator(nil, true)
+	v.sectionError("zones", "zones must be an array")
+	for i := 0; i < maxRestoreIssues+5; i++ {
+		v.check("accounts", nil)
+	}
+	if len(v.report.Issues) != maxRestoreIssues || v.report.IssuesOmitted != 6 {
+		t.Fatalf("issues=%d omitted=%d", len(v.report.Issues), v.report.IssuesOmitted)
+	}
+	if v.report.Issues[0].Index != -1 {
+		t.Fatalf("expected section issue first, got %+v", v.report.Issues[0])
+	}
+}
+
+func TestParseRestoreScope(t *testing.T) {
+	got, err := ParseRestoreScope(" Controls,balances,,controls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != RestoreScopeBalances || got[1] != RestoreScopeControls {
+		t.Fatalf("unexpected scope %v", got)
+	}
+	if _, err := ParseRestoreScope("controls,history"); err == nil {
+		t.Fatal("expected unknown scope error")
+	}
+	opts := RestoreOptions{Scope: got}
+	if tables := opts.resetTables(); len(tables) != 0 {
+		t.Fatalf("controls/balances must not truncate, got %v", tables)
+	}
+	if tables := (RestoreOptions{Scope: []string{RestoreScopeAudit}}).resetTables(); len(tables) != 1 || tables[0] != "audit_log" {
+		t.Fatalf("unexpected tables %v", tables)
+	}
+}
+
+func TestRestoreValidatorScope(t *testing.T) {
+	v := newRestoreValidator([]string{"zone-eu"}, true)
+	v.sections = RestoreOptions{Scope: []string{RestoreScopeIncidents}}.scopeSections()
+
+	if v.check("accounts", map[string]any{"id": "a", "zone_id": "zone-mars"}) != nil {
+		t.Fatal("out-of-scope rows must not be applied")
+	}
+	row := v.check("incidents", map[string]any{"zone_id": "zone-eu", "title": "x", "related_txn_id": "t1"})
+	if row == nil || row["related_txn_id"] != "t1" {
+		t.Fatalf("scoped restore keeps references to existing history, got %v", row)
+	}
+	if _, ok := v.report.Sections["accounts"]; ok || len(v.report.Issues) != 0 {
+		t.Fatalf("out-of-scope sections must not be reported: %+v", v.report)
+	}
+}
+
+func TestRestoreValidatorRedacted(t *testing.T) {
+	spooled := map[string]any{"request_id": "r1", "zone_id": "zone-eu", "from_account": "a", "to_account": "b", "amount_units": float64(5), "metadata": map[string]any{"email": Redacted}}
+
+	v := newRestoreValidator([]string{"zone-eu"}, false)
+	v.redacted = true
+	v.check("spooled_transfers", spooled)
+	v.check("spooled_transfers", spooled)
+	if v.report.Sections["spooled_transfers"].Errors == 0 || v.report.Issues[0].Index != -1 {
+		t.Fatalf("redacted metadata must not be restored: %+v", v.report)
+	}
+	if v.check("accounts", map[string]any{"id": "a", "zone_id": "zone-eu"}) == nil {
+		t.Fatal("sections without metadata restore from a redacted snapshot")
+	}
+
+	v = newRestoreValidator([]string{"zone-eu"}, false)
+	v.redacted = true
+	v.sections = RestoreOptions{Scope: []string{RestoreScopeBalances}}.scopeSections()
+	v.check("spooled_transfers", spooled)
+	if v.report.errorCount() != 0 {
+		t.Fatalf("a scope without metadata restores a redacted snapshot: %+v", v.report)
+	}
+}
@@ -0,0 +1,378 @@
+package ledger
+
+import (
+  "context"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "log/slog"
+  "strings"
+  "time"
+
+  "github.com/google/uuid"
+  "github.com/jackc/pgx/v5"
+
+  "time-ledger-sim/go/internal/logging"
+  "time-ledger-sim/go/internal/tracing"
+  "time-ledger-sim/go/internal/util"
+)
+
+var ErrSagaNotFound = errors.New("saga not found")
+
+func IsSagaNotFound(err error) bool { return errors.Is(err, ErrSagaNotFound) }
+
+const (
+  SagaRunning = "RUNNING"
+  SagaCompleted = "COMPLETED"
+  SagaCompensated = "COMPENSATED"
+  SagaFailed = "FAILED"
+
+  SagaStepDebit = "DEBIT"
+  SagaStepCredit = "CREDIT"
+  SagaStepCompensate = "COMPENSATE"
+
+  // SagaStepDueEvent is the outbox event that makes the saga consumer run a step.
+  SagaStepDueEvent = "SAGA_STEP_DUE"
+
+  DefaultSagaStepTimeout = 5 * time.Minute
+  maxSagaStepTimeout = 24 * time.Hour
+  // sagaStallAfter is how long a step event may go unprocessed before the
+  // control scheduler emits it again.
+  sagaStallAfter = 30 * time.Second
+)
+
+// Saga moves AmountUnits from FromAccount in FromZone to ToAccount in ToZone
+// in steps: DEBIT into the source zone's transit account, CREDIT out of the
+// destination zone's, and COMPENSATE (refund the debit) when the credit fails.
+type Saga struct {
+  ID string `json:"id"`
+  RequestID string `json:"request_id"`
+  FromZone string `json:"from_zone"`
+  ToZone string `json:"to_zone"`
+  FromAccount string `json:"from_account"`
+  ToAccount string `json:"to_account"`
+  AmountUnits int64 `json:"amount_units"`
+  Metadata map[string]any `json:"metadata"`
+  Status string `json:"status"` // RUNNING|COMPLETED|COMPENSATED|FAILED
+  Step *string `json:"step"` // DEBIT|CREDIT|COMPENSATE; null once finished
+  StepStartedAt time.Time `json:"step_started_at"`
+  StepTimeoutSeconds int `json:"step_timeout_seconds"`
+  Attempts int `json:"attempts"` // of the current step
+  NextAttemptAt *time.Time `json:"next_attempt_at"`
+  LastError *string `json:"last_error"`
+  CreatedAt time.Time `json:"created_at"`
+  UpdatedAt time.Time `json:"updated_at"`
+  Steps []SagaStep `json:"steps,omitempty"`
+
+  payloadHash string
+}
+
+// SagaStep is one attempt at a step. BLOCKED means the transfer would have
+// been spooled or rate limited; the step is tried again until its timeout.
+type SagaStep struct {
+  Step string `json:"step"`
+  Attempt int `json:"attempt"`
+  Outcome string `json:"outcome"` // APPLIED|BLOCKED|REJECTED
+  TransactionID *string `json:"transaction_id"`
+  Reason *string `json:"reason"`
+  At time.Time `json:"at"`
+}
+
+const sagaCols = `id::text, request_id, payload_hash, from_zone, to_zone, from_account, to_account, amount_units, metadata, status, step,
+  step_started_at, step_timeout_seconds, attempts, next_attempt_at, last_error, created_at, updated_at`
+
+func scanSaga(row pgx.Row) (*Saga, error) {
+  var s Saga
+  var meta []byte
+  err := row.Scan(&s.ID, &s.RequestID, &s.payloadHash, &s.FromZone, &s.ToZone, &s.FromAccount, &s.ToAccount, &s.AmountUnits, &meta, &s.Status, &s.Step,
+    &s.StepStartedAt, &s.StepTimeoutSeconds, &s.Attempts, &s.NextAttemptAt, &s.LastError, &s.CreatedAt, &s.UpdatedAt)
+  if err != nil { return nil, err }
+  _ = json.Unmarshal(meta, &s.Metadata)
+  return &s, nil
+}
+
+// SagaTransitAccount is the zone's account that holds saga funds between the
+// debit and the credit (or compensation).
+func SagaTransitAccount(zoneID string) string { return zoneID + "-saga-transit" }
+
+type StartSagaInput struct {
+  RequestID string
+  PayloadHash string
+  FromZone string
+  ToZone string
+  FromAccount string
+  ToAccount string
+  AmountUnits int64
+  Metadata map[string]any
+  StepTimeout time.Duration // how long a blocked step is retried; default DefaultSagaStepTimeout
+}
+
+// StartSaga records a saga and emits its DEBIT step. Starting a request_id
+// again with the same payload returns the first saga.
+func (l *Ledger) StartSaga(ctx context.Context, in StartSagaInput) (*Saga, error) {
+  if in.StepTimeout == 0 { in.StepTimeout = DefaultSagaStepTimeout }
+  if in.StepTimeout < time.Second || in.StepTimeout > maxSagaStepTimeout || in.StepTimeout%time.Second != 0 {
+    return nil, fmt.Errorf("step_timeout must be whole seconds between 1s and %s", maxSagaStepTimeout)
+  }
+  if in.AmountUnits <= 0 { return nil, fmt.Errorf("amount_units must be positive") }
+  if in.FromAccount == in.ToAccount { return nil, fmt.Errorf("from_account and to_account must differ") }
+  if in.Metadata == nil { in.Metadata = map[string]any{} }
+  meta, err := json.Marshal(in.Metadata)
+  if err != nil { return nil, err }
+
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+  q := pgQueries{tx}
+
+  for _, z := range []string{in.FromZone, in.ToZone} {
+    if _, err := q.ZoneStatus(ctx, z); err != nil { return nil, err }
+  }
+
+  now := l.clock.Now()
+  s, err := scanSaga(tx.QueryRow(ctx, `
+    INSERT INTO sagas(request_id,payload_hash,from_zone,to_zone,from_account,to_account,amount_units,metadata,step,
+      step_started_at,step_timeout_seconds,next_attempt_at,created_at,updated_at)
+    VALUES($1,$2,$3,$4,$5,$6,$7,$8::jsonb,'DEBIT',$9,$10,$11,$9,$9)
+    ON CONFLICT (request_id) DO NOTHING
+    RETURNING `+sagaCols,
+    in.RequestID, in.PayloadHash, in.FromZone, in.ToZone, in.FromAccount, in.ToAccount, in.AmountUnits, string(meta),
+    now, int(in.StepTimeout/time.Second), now.Add(sagaStallAfter)))
+  if errors.Is(err, pgx.ErrNoRows) {
+    s, err := scanSaga(tx.QueryRow(ctx, `SELECT `+sagaCols+` FROM sagas WHERE request_id=$1`, in.RequestID))
+    if err != nil { return nil, err }
+    if s.payloadHash != in.PayloadHash { return nil, ErrIdempotencyConflict }
+    return s, nil
+  }
+  if err != nil { return nil, err }
+  if err := l.sagaEvent(ctx, q, s, SagaStepDueEvent); err != nil { return nil, err }
+
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  if l.log != nil { l.log.InfoContext(ctx, "saga started", "saga_id", s.ID, "from_zone", s.FromZone, "to_zone", s.ToZone) }
+  return s, nil
+}
+
+// sagaEvent writes a saga outbox event: SAGA_STEP_DUE for the running step,
+// or SAGA_<status> once the saga finished.
+func (l *Ledger) sagaEvent(ctx context.Context, q Queries, s *Saga, eventType string) error {
+  payload := map[string]any{
+    "event_id": "generated_by_db",
+    "saga_id": s.ID,
+    "status": s.Status,
+    "from_zone": s.FromZone,
+    "to_zone": s.ToZone,
+    "amount_units": s.AmountUnits,
+  }
+  if s.Step != nil { payload["step"] = *s.Step }
+  pb, _ := json.Marshal(payload)
+  return q.InsertOutbox(ctx, OutboxEvent{
+    EventType: eventType, AggregateType: "saga", AggregateID: s.ID, Payload: pb,
+    RequestID: logging.RequestID(ctx), TraceContext: tracing.Carrier(ctx),
+  })
+}
+
+// stepTransfer is the transfer a step posts, with request ID saga-<id>-<step>.
+func (s *Saga) stepTransfer(step string) (CreateTransferInput, error) {
+  in := CreateTransferInput{RequestID: "saga-" + s.ID + "-" + strings.ToLower(step), AmountUnits: s.AmountUnits, Metadata: map[string]any{}}
+  switch step {
+  case SagaStepDebit:
+    in.ZoneID, in.FromAccount, in.ToAccount = s.FromZone, s.FromAccount, SagaTransitAccount(s.FromZone)
+  case SagaStepCredit:
+    in.ZoneID, in.FromAccount, in.ToAccount = s.ToZone, SagaTransitAccount(s.ToZone), s.ToAccount
+  case SagaStepCompensate:
+    in.ZoneID, in.FromAccount, in.ToAccount, in.Type = s.FromZone, SagaTransitAccount(s.FromZone), s.FromAccount, TxnTypeReversal
+  }
+  for k, v := range s.Metadata { in.Metadata[k] = v }
+  in.Metadata["saga_id"], in.Metadata["saga_step"] = s.ID, step
+  var err error
+  in.PayloadHash, err = util.HashCanonicalJSON(map[string]any{
+    "request_id": in.RequestID, "from_account": in.FromAccount, "to_account": in.ToAccount,
+    "amount_units": in.AmountUnits, "zone_id": in.ZoneID, "metadata": in.Metadata,
+  })
+  return in, err
+}
+
+// AdvanceSaga runs the saga's current step if it is step; the saga consumer
+// calls it for each SAGA_STEP_DUE event. An event for a step the saga has
+// left, or for a finished saga, is a no-op, so redelivery is harmless. The
+// step's transfer goes through the transfer gates but is never spooled: a
+// transfer that would be spooled or rate limited leaves the step BLOCKED and
+// it is retried with backoff until its timeout, when it counts as rejected.
+// A rejected DEBIT fails the saga, a rejected CREDIT starts COMPENSATE, and
+// COMPENSATE is retried until it applies.
+func (l *Ledger) AdvanceSaga(ctx context.Context, sagaID, step string) error {
+  if _, err := uuid.Parse(sagaID); err != nil { return ErrSagaNotFound }
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return err }
+  defer func() { _ = tx.Rollback(ctx) }()
+  q := pgQueries{tx}
+
+  s, err := scanSaga(tx.QueryRow(ctx, `SELECT `+sagaCols+` FROM sagas WHERE id=$1::uuid FOR UPDATE`, sagaID))
+  if errors.Is(err, pgx.ErrNoRows) { return ErrSagaNotFound }
+  if err != nil { return err }
+  if s.Status != SagaRunning || s.Step == nil || *s.Step != step { return nil }
+
+  in, err := s.stepTransfer(step)
+  if err != nil { return err }
+  outcome, txnID, reason, err := l.postSagaStep(ctx, tx, in)
+  if err != nil { return err }
+
+  now := l.clock.Now()
+  attempt := s.Attempts + 1
+  if outcome == "BLOCKED" && step != SagaStepCompensate && !now.Before(s.StepStartedAt.Add(time.Duration(s.StepTimeoutSeconds)*time.Second)) {
+    outcome, reason = "REJECTED", "timed out: "+reason
+  }
+  _, err = tx.Exec(ctx, `
+    INSERT INTO saga_steps(saga_id,step,attempt,outcome,transaction_id,reason,at) VALUES($1::uuid,$2,$3,$4,NULLIF($5,'')::uuid,NULLIF($6,''),$7)
+  `, s.ID, step, attempt, outcome, txnID, reason, now)
+  if err != nil { return err }
+
+  var next, status string
+  switch {
+  case outcome == "APPLIED" && step == SagaStepDebit:
+    next = SagaStepCredit
+  case outcome == "APPLIED" && step == SagaStepCredit:
+    status = SagaCompleted
+  case outcome == "APPLIED":
+    status = SagaCompensated
+  case outcome == "REJECTED" && step == SagaStepDebit:
+    status = SagaFailed
+  case outcome == "REJECTED" && step == SagaStepCredit:
+    next = SagaStepCompensate
+  }
+
+  switch {
+  case next != "":
+    s, err = scanSaga(tx.QueryRow(ctx, `
+      UPDATE sagas SET step=$2, step_started_at=$3, attempts=0, next_attempt_at=$4, last_error=NULLIF($5,''), updated_at=$3
+      WHERE id=$1::uuid RETURNING `+sagaCols, s.ID, next, now, now.Add(sagaStallAfter), reason))
+    if err != nil { return err }
+    err = l.sagaEvent(ctx, q, s, SagaStepDueEvent)
+  case status != "":
+    s, err = scanSaga(tx.QueryRow(ctx, `
+      UPDATE sagas SET status=$2, step=NULL, attempts=$3, next_attempt_at=NULL, last_error=COALESCE(NULLIF($4,''), last_error), updated_at=$5
+      WHERE id=$1::uuid RETURNING `+sagaCols, s.ID, status, attempt, reason, now))
+    if err != nil { return err }
+    err = l.sagaEvent(ctx, q, s, "SAGA_"+status)
+  default:
+    // blocked (or a compensation that cannot apply yet): the control scheduler
+    // emits the step again at next_attempt_at
+    _, err = tx.Exec(ctx, `
+      UPDATE sagas SET attempts=$2, next_attempt_at=$3, last_error=$4, updated_at=$5 WHERE id=$1::uuid
+    `, s.ID, attempt, now.Add(sagaRetryDelay(attempt)), reason, now)
+  }
+  if err != nil { return err }
+
+  if err := tx.Commit(ctx); err != nil { return err }
+  if l.log != nil {
+    l.log.InfoContext(ctx, "saga step", "saga_id", s.ID, "step", step, "attempt", attempt, "outcome", outcome, "reason", reason, "status", s.Status)
+  }
+  return nil
+}
+
+// sagaRetryDelay is the sim-clock wait before a blocked step's next attempt:
+// 1s, 2s, 4s ... capped at a minute.
+func sagaRetryDelay(attempt int) time.Duration {
+  return min(time.Second<<min(attempt-1, 6), time.Minute)
+}
+
+// postSagaStep applies a step's transfer in tx when the transfer gates let it
+// through. It returns APPLIED with the transaction (also for a step applied
+// before), BLOCKED when the transfer would be spooled or was rate limited, or
+// REJECTED, each with the reason.
+func (l *Ledger) postSagaStep(ctx context.Context, tx pgx.Tx, in CreateTransferInput) (string, string, string, error) {
+  q := pgQueries{tx}
+  est, err := l.checkTransfer(ctx, q, in, true)
+  if err != nil { return "", "", "", err }
+  switch {
+  case est.Replay && est.TransactionID != nil:
+    return "APPLIED", *est.TransactionID, "", nil
+  case est.Outcome == EstimateSpooled, IsRateLimited(est.Err):
+    return "BLOCKED", "", est.Reason, nil
+  case est.Outcome == EstimateRejected:
+    return "REJECTED", "", est.Reason, nil
+  }
+
+  skewMs, err := zoneClockSkew(ctx, q, in.ZoneID)
+  if err != nil { return "", "", "", err }
+  metaBytes, _ := json.Marshal(in.Metadata)
+  txn, err := l.applyTransfer(ctx, q, in, metaBytes, skewMs)
+  if err != nil { return "", "", "", err }
+  l.logTransfer(ctx, slog.LevelDebug, "saga transfer applied", in, "txn_id", txn.ID)
+  return "APPLIED", txn.ID, "", nil
+}
+
+// RetryDueSagas emits the step event again for up to limit running sagas
+// whose next attempt is due: blocked steps, and steps whose event was not
+// processed within sagaStallAfter.
+func (l *Ledger) RetryDueSagas(ctx context.Context, limit int) (int, error) {
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return 0, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  now := l.clock.Now()
+  rows, err := tx.Query(ctx, `
+    UPDATE sagas SET next_attempt_at=$1::timestamptz + make_interval(secs => $3)
+    WHERE id IN (
+      SELECT id FROM sagas
+      WHERE status='RUNNING' AND next_attempt_at <= $1
+      ORDER BY next_attempt_at
+      LIMIT $2
+      FOR UPDATE SKIP LOCKED
+    )
+    RETURNING `+sagaCols, now, limit, sagaStallAfter.Seconds())
+  if err != nil { return 0, err }
+  var due []*Saga
+  for rows.Next() {
+    s, err := scanSaga(rows)
+    if err != nil { rows.Close(); return 0, err }
+    due = append(due, s)
+  }
+  rows.Close()
+  if err := rows.Err(); err != nil { return 0, err }
+
+  for _, s := range due {
+    if err := l.sagaEvent(ctx, pgQueries{tx}, s, SagaStepDueEvent); err != nil { return 0, err }
+  }
+  if err := tx.Commit(ctx); err != nil { return 0, err }
+  return len(due), nil
+}
+
+// GetSaga returns the saga with its step attempts, oldest first.
+func (l *Ledger) GetSaga(ctx context.Context, id string) (*Saga, error) {
+  if _, err := uuid.Parse(id); err != nil { return nil, ErrSagaNotFound }
+  s, err := scanSaga(l.db.QueryRow(ctx, `SELECT `+sagaCols+` FROM sagas WHERE id=$1::uuid`, id))
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrSagaNotFound }
+  if err != nil { return nil, err }
+
+  rows, err := l.db.Query(ctx, `
+    SELECT step, attempt, outcome, transaction_id::text, reason, at FROM saga_steps WHERE saga_id=$1::uuid ORDER BY id
+  `, id)
+  if err != nil { return nil, err }
+  defer rows.Close()
+  s.Steps = []SagaStep{}
+  for rows.Next() {
+    var st SagaStep
+    if err := rows.Scan(&st.Step, &st.Attempt, &st.Outcome, &st.TransactionID, &st.Reason, &st.At); err != nil { return nil, err }
+    s.Steps = append(s.Steps, st)
+  }
+  return s, rows.Err()
+}
+
+// ListSagas returns the latest sagas, newest first, optionally of one status.
+func (l *Ledger) ListSagas(ctx context.Context, status string, limit int) ([]Saga, error) {
+  if limit <= 0 || limit > 500 { limit = 100 }
+  rows, err := l.ro.Query(ctx, `
+    SELECT `+sagaCols+` FROM sagas WHERE ($1 = '' OR status = $1) ORDER BY created_at DESC LIMIT $2
+  `, status, limit)
+  if err != nil { return nil, err }
+  defer rows.Close()
+
+  out := []Saga{}
+  for rows.Next() {
+    s, err := scanSaga(rows)
+    if err != nil { return nil, err }
+    out = append(out, *s)
+  }
+  return out, rows.Err()
+}
@@ -0,0 +1,108 @@
+package ledger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"time-ledger-sim/go/internal/store/storetest"
+)
+
+func TestSagas(t *testing.T) {
+	db := storetest.Open(t)
+	ctx := context.Background()
+	l := New(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	clock := NewVirtualClock()
+	clock.Freeze(time.Now())
+	l.SetClock(clock)
+
+	suffix := strings.ReplaceAll(uuid.NewString()[:8], "-", "")
+	from, to := "zone-sga-"+suffix, "zone-sgb-"+suffix
+	for _, z := range []string{from, to} {
+		if _, err := l.CreateZone(ctx, CreateZoneInput{ID: z, Name: z, Actor: "test"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	start := func() *Saga {
+		t.Helper()
+		id := uuid.NewString()
+		s, err := l.StartSaga(ctx, StartSagaInput{
+			RequestID: id, PayloadHash: "h-" + id, FromZone: from, ToZone: to, FromAccount: from + "-alice", ToAccount: to + "-bob",
+			AmountUnits: 30, StepTimeout: time.Minute,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	advance := func(s *Saga, step string) *Saga {
+		t.Helper()
+		if err := l.AdvanceSaga(ctx, s.ID, step); err != nil {
+			t.Fatal(err)
+		}
+		got, err := l.GetSaga(ctx, s.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+	balance := func(acct string) int64 {
+		t.Helper()
+		var b int64
+		_ = db.QueryRow(ctx, `SELECT balance_units FROM balances WHERE account_id=$1`, acct).Scan(&b)
+		return b
+	}
+
+	s := start()
+	if s = advance(s, SagaStepDebit); s.Step == nil || *s.Step != SagaStepCredit {
+		t.Fatalf("after debit = %+v", s)
+	}
+	if again := advance(s, SagaStepDebit); len(again.Steps) != 1 {
+		t.Fatalf("a stale step event ran again: %+v", again.Steps)
+	}
+	if s = advance(s, SagaStepCredit); s.Status != SagaCompleted || len(s.Steps) != 2 {
+		t.Fatalf("after credit = %+v", s)
+	}
+	if balance(to+"-bob") != 30 || balance(from+"-alice") != -30 {
+		t.Fatal("completed saga did not move the funds")
+	}
+
+	// the destination zone is down: the credit is rejected and the debit refunded
+	if _, err := l.SetZoneStatus(ctx, to, "DOWN", "test", "", "drill"); err != nil {
+		t.Fatal(err)
+	}
+	s = advance(start(), SagaStepDebit)
+	if s = advance(s, SagaStepCredit); s.Step == nil || *s.Step != SagaStepCompensate {
+		t.Fatalf("after rejected credit = %+v", s)
+	}
+	if s = advance(s, SagaStepCompensate); s.Status != SagaCompensated {
+		t.Fatalf("after compensation = %+v", s)
+	}
+	if balance(from+"-alice") != -30 || balance(SagaTransitAccount(from)) != 0 {
+		t.Fatal("compensation did not refund the debit")
+	}
+
+	// writes blocked with spooling: the credit is retried until its timeout
+	if _, err := l.SetZoneStatus(ctx, to, "OK", "test", "", "drill over"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.SetZoneControls(ctx, to, SetZoneControlsInput{WritesBlocked: true, CrossZoneThrottle: 100, SpoolEnabled: true, Actor: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	s = advance(start(), SagaStepDebit)
+	if s = advance(s, SagaStepCredit); *s.Step != SagaStepCredit || s.Attempts != 1 || s.Steps[1].Outcome != "BLOCKED" {
+		t.Fatalf("blocked credit = %+v", s)
+	}
+	clock.Advance(2 * time.Minute)
+	if n, err := l.RetryDueSagas(ctx, 100); err != nil || n == 0 {
+		t.Fatalf("retry = %d, %v", n, err)
+	}
+	if s = advance(s, SagaStepCredit); *s.Step != SagaStepCompensate {
+		t.Fatalf("credit past its timeout = %+v", s)
+	}
+}
@@ -0,0 +1,117 @@
+package ledger
+
+import (
+  "context"
+  "sync"
+  "time"
+
+  "log/slog"
+)
+
+// ScenarioRunner executes stored scenarios and drill injects in-process. At
+// most one run per scenario name is active at a time; runs are tracked in
+// scenario_runs, drills in drills.
+type ScenarioRunner struct {
+  led *Ledger
+  log *slog.Logger
+
+  base context.Context
+  stopAll context.CancelFunc
+
+  mu sync.Mutex
+  active map[string]context.CancelFunc
+  drills map[string]drillRun // by drill id
+}
+
+func NewScenarioRunner(led *Ledger, log *slog.Logger) *ScenarioRunner {
+  base, cancel := context.WithCancel(context.Background())
+  return &ScenarioRunner{led: led, log: log, base: base, stopAll: cancel, active: map[string]context.CancelFunc{}, drills: map[string]drillRun{}}
+}
+
+// Run blocks until ctx is done, then stops all active scenario runs.
+func (s *ScenarioRunner) Run(ctx context.Context) {
+  <-ctx.Done()
+  s.stopAll()
+}
+
+func (s *ScenarioRunner) Start(ctx context.Context, name string) (*ScenarioRun, error) {
+  sc, err := s.led.GetScenario(ctx, name)
+  if err != nil { return nil, err }
+
+  s.mu.Lock()
+  defer s.mu.Unlock()
+  if _, ok := s.active[name]; ok { return nil, ErrScenarioRunning }
+
+  run, err := s.led.startScenarioRun(ctx, sc)
+  if err != nil { return nil, err }
+
+  runCtx, cancel := context.WithCancel(s.base)
+  s.active[name] = cancel
+  go s.execute(runCtx, sc, run.ID)
+  return run, nil
+}
+
+func (s *ScenarioRunner) Stop(ctx context.Context, name string) (*ScenarioRun, error) {
+  s.mu.Lock()
+  cancel, ok := s.active[name]
+  s.mu.Unlock()
+  if !ok { return nil, ErrScenarioNotRunning }
+  cancel()
+  // the run goroutine records STOPPED; wait briefly so the caller sees it
+  for i := 0; i < 20; i++ {
+    if !s.IsRunning(name) { break }
+    time.Sleep(50 * time.Millisecond)
+  }
+  return s.led.LatestScenarioRun(ctx, name)
+}
+
+func (s *ScenarioRunner) IsRunning(name string) bool {
+  s.mu.Lock()
+  defer s.mu.Unlock()
+  _, ok := s.active[name]
+  return ok
+}
+
+func (s *ScenarioRunner) execute(ctx context.Context, sc *Scenario, runID string) {
+  defer func() {
+    s.mu.Lock()
+    delete(s.active, sc.Name)
+    s.mu.Unlock()
+  }()
+
+  // bookkeeping must survive cancellation of the run itself
+  bg := context.WithoutCancel(ctx)
+  clock := s.led.clock
+  start := clock.Now()
+  status := "COMPLETED"
+
+  for i, st := range sc.Steps {
+    if err := waitUntil(ctx, clock, start.Add(time.Duration(st.At))); err != nil {
+      status = "STOPPED"
+      break
+    }
+
+    entry := map[string]any{
+      "step": i,
+      "at": time.Duration(st.At).String(),
+      "action": st.Action,
+      "zone_id": st.ZoneID,
+      "executed_at": clock.Now().UTC().Format(time.RFC3339Nano),
+      "ok": true,
+    }
+    if err := s.led.executeScenarioStep(ctx, sc, st); err != nil {
+      entry["ok"] = false
+      entry["error"] = err.Error()
+      s.log.Warn("scenario step failed", "scenario", sc.Name, "step", i, "err", err.Error())
+    }
+    if err := s.led.recordScenarioStep(bg, runID, entry); err != nil {
+      s.log.Warn("scenario step record failed", "scenario", sc.Name, "err", err.Error())
+      status = "FAILED"
+      break
+    }
+  }
+
+  if err := s.led.finishScenarioRun(bg, sc.Name, runID, status); err != nil {
+    s.log.Warn("scenario finish failed", "scenario", sc.Name, "err", err.Error())
+  }
+}
@@ -0,0 +1,284 @@
+package ledger
+
+import (
+  "context"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "regexp"
+  "sort"
+  "strconv"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+  "go.yaml.in/yaml/v3"
+)
+
+var (
+  ErrScenarioNotFound = errors.New("scenario not found")
+  ErrScenarioRunning = errors.New("scenario already running")
+  ErrScenarioNotRunning = errors.New("scenario not running")
+)
+
+func IsScenarioNotFound(err error) bool { return errors.Is(err, ErrScenarioNotFound) }
+func IsScenarioRunning(err error) bool { return errors.Is(err, ErrScenarioRunning) }
+func IsScenarioNotRunning(err error) bool { return errors.Is(err, ErrScenarioNotRunning) }
+
+const (
+  ScenarioActionSetStatus = "set_status"
+  ScenarioActionSetControls = "set_controls"
+  ScenarioActionReplaySpool = "replay_spool"
+  ScenarioActionTransfer = "transfer"
+)
+
+// maxScenarioTransfers bounds the transfers one transfer step makes.
+const maxScenarioTransfers = 1000
+
+var scenarioNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// ScenarioOffset is a step's offset from scenario start: a Go duration
+// string ("10s", "2m") or a number of seconds.
+type ScenarioOffset time.Duration
+
+func (o *ScenarioOffset) UnmarshalJSON(b []byte) error {
+  var s string
+  if err := json.Unmarshal(b, &s); err == nil {
+    d, err := time.ParseDuration(s)
+    if err != nil { return fmt.Errorf("invalid offset %q", s) }
+    *o = ScenarioOffset(d)
+    return nil
+  }
+  var secs float64
+  if err := json.Unmarshal(b, &secs); err != nil { return fmt.Errorf("invalid offset") }
+  *o = ScenarioOffset(time.Duration(secs * float64(time.Second)))
+  return nil
+}
+
+func (o ScenarioOffset) MarshalJSON() ([]byte, error) {
+  return json.Marshal(time.Duration(o).String())
+}
+
+type ScenarioStep struct {
+  At ScenarioOffset `json:"at"`
+  Action string `json:"action"` // set_status|set_controls|replay_spool|transfer
+  ZoneID string `json:"zone_id"` // for transfer, overrides the template's zone
+  Template string `json:"template,omitempty"` // transfer: the transfer template to fire
+  Count int `json:"count,omitempty"` // transfer: how many, default 1
+  Status string `json:"status,omitempty"`
+  Controls *SetZoneControlsInput `json:"controls,omitempty"`
+  Limit int `json:"limit,omitempty"`
+  ReasonCode string `json:"reason_code,omitempty"` // default SCENARIO
+  Reason string `json:"reason,omitempty"`
+}
+
+type Scenario struct {
+  Name string `json:"name"`
+  Description string `json:"description,omitempty"`
+  Steps []ScenarioStep `json:"steps"`
+}
+
+// ParseScenario decodes a JSON or YAML scenario definition and validates it.
+// Steps are returned sorted by offset.
+func ParseScenario(body []byte, isYAML bool) (*Scenario, error) {
+  if isYAML {
+    b, err := yamlToJSON(body)
+    if err != nil { return nil, err }
+    body = b
+  }
+  var sc Scenario
+  if err := json.Unmarshal(body, &sc); err != nil { return nil, fmt.Errorf("bad scenario: %w", err) }
+  if err := sc.validate(); err != nil { return nil, err }
+  sort.SliceStable(sc.Steps, func(i, j int) bool { return sc.Steps[i].At < sc.Steps[j].At })
+  return &sc, nil
+}
+
+// yamlToJSON re-encodes a YAML document as JSON, so YAML definitions decode
+// through the same json tags and UnmarshalJSON methods.
+func yamlToJSON(body []byte) ([]byte, error) {
+  var generic any
+  if err := yaml.Unmarshal(body, &generic); err != nil { return nil, fmt.Errorf("bad yaml: %w", err) }
+  b, err := json.Marshal(generic)
+  if err != nil { return nil, fmt.Errorf("bad yaml: %w", err) }
+  return b, nil
+}
+
+func (sc *Scenario) validate() error {
+  if !scenarioNamePattern.MatchString(sc.Name) { return fmt.Errorf("invalid scenario name") }
+  if len(sc.Steps) == 0 { return fmt.Errorf("scenario has no steps") }
+  for i, st := range sc.Steps {
+    where := "steps[" + strconv.Itoa(i) + "]"
+    if st.At < 0 { return fmt.Errorf("%s: negative offset", where) }
+    if st.ZoneID == "" && st.Action != ScenarioActionTransfer { return fmt.Errorf("%s: zone_id required", where) }
+    if st.ReasonCode != "" && !reasonCodePattern.MatchString(st.ReasonCode) { return fmt.Errorf("%s: invalid reason_code", where) }
+    switch st.Action {
+    case ScenarioActionSetStatus:
+      if st.Status != "OK" && st.Status != "DEGRADED" && st.Status != "DOWN" {
+        return fmt.Errorf("%s: invalid status", where)
+      }
+    case ScenarioActionSetControls:
+      if st.Controls == nil { return fmt.Errorf("%s: controls required", where) }
+      c := *st.Controls
+      if c.ThrottleMode == "" { c.ThrottleMode = ThrottleModeHash }
+      if err := c.validate(); err != nil { return fmt.Errorf("%s: %w", where, err) }
+    case ScenarioActionReplaySpool:
+    case ScenarioActionTransfer:
+      if st.Template == "" { return fmt.Errorf("%s: template required", where) }
+      if st.Count < 0 || st.Count > maxScenarioTransfers { return fmt.Errorf("%s: count must be 0-%d", where, maxScenarioTransfers) }
+    default:
+      return fmt.Errorf("%s: unknown action %q", where, st.Action)
+    }
+  }
+  return nil
+}
+
+func (sc *Scenario) Actor() string { return "scenario:" + sc.Name }
+
+// SaveScenario stores the definition and registers the scenario's actor, so
+// its steps pass actor enforcement.
+func (l *Ledger) SaveScenario(ctx context.Context, sc *Scenario) error {
+  def, _ := json.Marshal(sc)
+  _, err := l.db.Exec(ctx, `
+    WITH s AS (
+      INSERT INTO sim_scenarios(name,definition) VALUES($1,$2::jsonb)
+      ON CONFLICT (name) DO UPDATE SET definition=EXCLUDED.definition, updated_at=now()
+      RETURNING name
+    )
+    INSERT INTO actors(id,kind,name) SELECT 'scenario:' || name, '`+ActorKindScenario+`', name FROM s
+    ON CONFLICT (id) DO NOTHING
+  `, sc.Name, string(def))
+  return err
+}
+
+func (l *Ledger) GetScenario(ctx context.Context, name string) (*Scenario, error) {
+  var def []byte
+  err := l.db.QueryRow(ctx, `SELECT definition FROM sim_scenarios WHERE name=$1`, name).Scan(&def)
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrScenarioNotFound }
+  if err != nil { return nil, err }
+  var sc Scenario
+  if err := json.Unmarshal(def, &sc); err != nil { return nil, err }
+  return &sc, nil
+}
+
+func (l *Ledger) ListScenarios(ctx context.Context) ([]Scenario, error) {
+  rows, err := l.db.Query(ctx, `SELECT definition FROM sim_scenarios ORDER BY name`)
+  if err != nil { return nil, err }
+  defer rows.Close()
+  out := []Scenario{}
+  for rows.Next() {
+    var def []byte
+    if err := rows.Scan(&def); err != nil { return nil, err }
+    var sc Scenario
+    if err := json.Unmarshal(def, &sc); err != nil { return nil, err }
+    out = append(out, sc)
+  }
+  return out, rows.Err()
+}
+
+type ScenarioRun struct {
+  ID string `json:"id"`
+  ScenarioName string `json:"scenario_name"`
+  Status string `json:"status"`
+  StepsTotal int `json:"steps_total"`
+  StepsDone int `json:"steps_done"`
+  Log []map[string]any `json:"log"`
+  StartedAt time.Time `json:"started_at"`
+  FinishedAt *time.Time `json:"finished_at"`
+}
+
+const scenarioRunCols = `id::text, scenario_name, status, steps_total, steps_done, log, started_at, finished_at`
+
+func scanScenarioRun(row pgx.Row) (*ScenarioRun, error) {
+  var r ScenarioRun
+  var logBytes []byte
+  if err := row.Scan(&r.ID, &r.ScenarioName, &r.Status, &r.StepsTotal, &r.StepsDone, &logBytes, &r.StartedAt, &r.FinishedAt); err != nil {
+    return nil, err
+  }
+  r.Log = []map[string]any{}
+  _ = json.Unmarshal(logBytes, &r.Log)
+  return &r, nil
+}
+
+func (l *Ledger) LatestScenarioRun(ctx context.Context, name string) (*ScenarioRun, error) {
+  r, err := scanScenarioRun(l.db.QueryRow(ctx, `
+    SELECT `+scenarioRunCols+` FROM scenario_runs WHERE scenario_name=$1 ORDER BY started_at DESC LIMIT 1
+  `, name))
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrScenarioNotRunning }
+  return r, err
+}
+
+func (l *Ledger) startScenarioRun(ctx context.Context, sc *Scenario) (*ScenarioRun, error) {
+  r, err := scanScenarioRun(l.db.QueryRow(ctx, `
+    INSERT INTO scenario_runs(scenario_name,steps_total) VALUES($1,$2)
+    RETURNING `+scenarioRunCols, sc.Name, len(sc.Steps)))
+  if err != nil { return nil, err }
+  l.auditScenario(ctx, sc.Name, "SCENARIO_STARTED", r.ID, "")
+  return r, nil
+}
+
+func (l *Ledger) recordScenarioStep(ctx context.Context, runID string, entry map[string]any) error {
+  b, _ := json.Marshal(entry)
+  _, err := l.db.Exec(ctx, `
+    UPDATE scenario_runs SET steps_done=steps_done+1, log=log || jsonb_build_array($2::jsonb) WHERE id=$1::uuid
+  `, runID, string(b))
+  return err
+}
+
+func (l *Ledger) finishScenarioRun(ctx context.Context, name, runID, status string) error {
+  _, err := l.db.Exec(ctx, `UPDATE scenario_runs SET status=$2, finished_at=now() WHERE id=$1::uuid AND status='RUNNING'`, runID, status)
+  if err != nil { return err }
+  l.auditScenario(ctx, name, "SCENARIO_"+status, runID, "")
+  return nil
+}
+
+func (l *Ledger) auditScenario(ctx context.Context, name, action, runID, reason string) {
+  _ = l.audit(ctx, pgQueries{l.db}, AuditRecord{
+    Actor: "scenario:" + name, Action: action, TargetType: "scenario", TargetID: name, Reason: reason,
+    Details: map[string]any{"run_id": runID},
+  })
+}
+
+// executeScenarioStep performs one step through the normal operator APIs so
+// every action is audited with the scenario actor.
+func (l *Ledger) executeScenarioStep(ctx context.Context, sc *Scenario, st ScenarioStep) error {
+  reason := st.Reason
+  if reason == "" { reason = "scenario " + sc.Name }
+  code := st.ReasonCode
+  if code == "" { code = ScenarioReasonCode }
+  switch st.Action {
+  case ScenarioActionSetStatus:
+    _, err := l.SetZoneStatus(ctx, st.ZoneID, st.Status, sc.Actor(), code, reason)
+    return err
+  case ScenarioActionSetControls:
+    in := *st.Controls
+    in.Actor = sc.Actor()
+    in.ReasonCode = code
+    in.Reason = reason
+    _, err := l.SetZoneControls(ctx, st.ZoneID, in)
+    return err
+  case ScenarioActionReplaySpool:
+    _, err := l.ReplaySpool(ctx, st.ZoneID, st.Limit, sc.Actor(), code, reason)
+    return err
+  case ScenarioActionTransfer:
+    return l.scenarioTransfers(ctx, sc, st)
+  }
+  return fmt.Errorf("unknown action %q", st.Action)
+}
+
+// scenarioTransfers fires a transfer step's template Count times. Rejected or
+// failed transfers fail the step, which reports how many and the first error.
+func (l *Ledger) scenarioTransfers(ctx context.Context, sc *Scenario, st ScenarioStep) error {
+  n := st.Count
+  if n == 0 { n = 1 }
+  var failed int
+  var first error
+  for i := 0; i < n; i++ {
+    in, err := l.TemplateTransfer(ctx, st.Template, TemplateOverrides{ZoneID: st.ZoneID, Metadata: map[string]any{"scenario": sc.Name}})
+    if err != nil { return err } // the template itself is unusable
+    if _, _, err := l.CreateTransfer(ctx, in); err != nil {
+      failed++
+      if first == nil { first = err }
+    }
+  }
+  if failed > 0 { return fmt.Errorf("%d of %d transfers failed: %w", failed, n, first) }
+  return nil
+}
@@ -0,0 +1,63 @@
+package ledger
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseScenario_JSONSortsSteps(t *testing.T) {
+	body := []byte(`{"name":"eu-outage","steps":[
+		{"at":"2m","action":"set_status","zone_id":"zone-eu","status":"OK"},
+		{"at":"10s","action":"set_status","zone_id":"zone-eu","status":"DOWN"},
+		{"at":30,"action":"set_controls","zone_id":"zone-eu","controls":{"cross_zone_throttle":20,"spool_enabled":true}}
+	]}`)
+	sc, err := ParseScenario(body, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []time.Duration{10 * time.Second, 30 * time.Second, 2 * time.Minute}
+	for i, d := range want {
+		if time.Duration(sc.Steps[i].At) != d {
+			t.Fatalf("step %d: expected %v, got %v", i, d, time.Duration(sc.Steps[i].At))
+		}
+	}
+	if sc.Actor() != "scenario:eu-outage" {
+		t.Fatalf("unexpected actor %q", sc.Actor())
+	}
+}
+
+func TestParseScenario_YAML(t *testing.T) {
+	body := []byte(`
+name: drill
+steps:
+  - at: 5s
+    action: replay_spool
+    zone_id: zone-na
+    limit: 100
+`)
+	sc, err := ParseScenario(body, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sc.Steps) != 1 || sc.Steps[0].Limit != 100 {
+		t.Fatalf("unexpected steps: %+v", sc.Steps)
+	}
+}
+
+func TestParseScenario_RejectsInvalid(t *testing.T) {
+	cases := map[string]string{
+		"bad name":       `{"name":"Bad Name","steps":[{"at":"1s","action":"replay_spool","zone_id":"zone-eu"}]}`,
+		"no steps":       `{"name":"x","steps":[]}`,
+		"unknown action": `{"name":"x","steps":[{"at":"1s","action":"explode","zone_id":"zone-eu"}]}`,
+		"bad status":     `{"name":"x","steps":[{"at":"1s","action":"set_status","zone_id":"zone-eu","status":"MAYBE"}]}`,
+		"bad controls":   `{"name":"x","steps":[{"at":"1s","action":"set_controls","zone_id":"zone-eu","controls":{"cross_zone_throttle":101}}]}`,
+		"bad offset":     `{"name":"x","steps":[{"at":"soon","action":"replay_spool","zone_id":"zone-eu"}]}`,
+		"no template":    `{"name":"x","steps":[{"at":"1s","action":"transfer","count":3}]}`,
+		"too many":       `{"name":"x","steps":[{"at":"1s","action":"transfer","template":"pay","count":1001}]}`,
+	}
+	for name, body := range cases {
+		if _, err := ParseScenario([]byte(body), false); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
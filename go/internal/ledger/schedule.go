@@ -0,0 +1,231 @@
+package ledger
+
+import (
+  "context"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+  "log/slog"
+)
+
+var ErrScheduleNotFound = errors.New("scheduled change not found")
+
+func IsScheduleNotFound(err error) bool { return errors.Is(err, ErrScheduleNotFound) }
+
+type ScheduledControlChange struct {
+  ID string `json:"id"`
+  ZoneID string `json:"zone_id"`
+  ApplyAt time.Time `json:"apply_at"`
+  Controls SetZoneControlsInput `json:"controls"`
+  Actor string `json:"actor"`
+  ReasonCode *string `json:"reason_code"`
+  Reason *string `json:"reason"`
+  Status string `json:"status"`
+  FailReason *string `json:"fail_reason"`
+  CreatedAt time.Time `json:"created_at"`
+  AppliedAt *time.Time `json:"applied_at"`
+}
+
+const scheduledChangeCols = `id::text, zone_id, apply_at, controls, actor, reason_code, reason, status, fail_reason, created_at, applied_at`
+
+func scanScheduledChange(row pgx.Row) (*ScheduledControlChange, error) {
+  var s ScheduledControlChange
+  var controls []byte
+  if err := row.Scan(&s.ID, &s.ZoneID, &s.ApplyAt, &controls, &s.Actor, &s.ReasonCode, &s.Reason, &s.Status, &s.FailReason, &s.CreatedAt, &s.AppliedAt); err != nil {
+    return nil, err
+  }
+  _ = json.Unmarshal(controls, &s.Controls)
+  return &s, nil
+}
+
+// ScheduleZoneControls records a full controls replacement to be applied at applyAt.
+func (l *Ledger) ScheduleZoneControls(ctx context.Context, zoneID string, applyAt time.Time, in SetZoneControlsInput) (*ScheduledControlChange, error) {
+  if in.ThrottleMode == "" { in.ThrottleMode = ThrottleModeHash }
+  if err := in.validate(); err != nil { return nil, err }
+  if !applyAt.After(l.clock.Now()) { return nil, fmt.Errorf("apply_at must be in the future") }
+  controls, _ := json.Marshal(in)
+
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  if _, err := (pgQueries{tx}).ZoneStatus(ctx, zoneID); err != nil { return nil, err }
+  if err := l.checkActors(ctx, tx, in.Actor); err != nil { return nil, err }
+  if err := l.checkReasonCode(ctx, tx, ReasonForZoneControls, in.ReasonCode); err != nil { return nil, err }
+
+  s, err := scanScheduledChange(tx.QueryRow(ctx, `
+    INSERT INTO scheduled_control_changes(zone_id,apply_at,controls,actor,reason_code,reason)
+    VALUES($1,$2,$3::jsonb,$4,NULLIF($5,''),NULLIF($6,''))
+    RETURNING `+scheduledChangeCols,
+    zoneID, applyAt, string(controls), in.Actor, in.ReasonCode, in.Reason))
+  if err != nil { return nil, err }
+
+  err = l.audit(ctx, pgQueries{tx}, AuditRecord{
+    Actor: in.Actor, Action: "SCHEDULE_ZONE_CONTROLS", TargetType: "zone", TargetID: zoneID, Reason: in.Reason, ReasonCode: in.ReasonCode,
+    Details: map[string]any{"schedule_id": s.ID, "apply_at": applyAt, "controls": json.RawMessage(controls)},
+  })
+  if err != nil { return nil, err }
+
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  return s, nil
+}
+
+func (l *Ledger) ListScheduledControls(ctx context.Context, zoneID string, includeDone bool) ([]ScheduledControlChange, error) {
+  rows, err := l.db.Query(ctx, `
+    SELECT `+scheduledChangeCols+`
+    FROM scheduled_control_changes
+    WHERE zone_id=$1 AND ($2 OR status='PENDING')
+    ORDER BY apply_at ASC
+    LIMIT 500
+  `, zoneID, includeDone)
+  if err != nil { return nil, err }
+  defer rows.Close()
+
+  out := []ScheduledControlChange{}
+  for rows.Next() {
+    s, err := scanScheduledChange(rows)
+    if err != nil { return nil, err }
+    out = append(out, *s)
+  }
+  return out, rows.Err()
+}
+
+func (l *Ledger) CancelScheduledControls(ctx context.Context, id, actor, reason string) (*ScheduledControlChange, error) {
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  if err := l.checkActors(ctx, tx, actor); err != nil { return nil, err }
+
+  s, err := scanScheduledChange(tx.QueryRow(ctx, `
+    UPDATE scheduled_control_changes SET status='CANCELLED'
+    WHERE id::text=$1 AND status='PENDING'
+    RETURNING `+scheduledChangeCols, id))
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrScheduleNotFound }
+  if err != nil { return nil, err }
+
+  err = l.audit(ctx, pgQueries{tx}, AuditRecord{
+    Actor: actor, Action: "CANCEL_SCHEDULED_CONTROLS", TargetType: "zone", TargetID: s.ZoneID, Reason: reason,
+    Details: map[string]any{"schedule_id": s.ID},
+  })
+  if err != nil { return nil, err }
+
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  return s, nil
+}
+
+// ApplyDueControlChanges applies pending changes whose apply_at has passed.
+// Rows are claimed with SKIP LOCKED so concurrent replicas never apply one twice;
+// each change is applied in its own transaction together with its status update.
+func (l *Ledger) ApplyDueControlChanges(ctx context.Context, limit int) (int, error) {
+  applied := 0
+  for i := 0; i < limit; i++ {
+    ok, err := l.applyNextDueControlChange(ctx)
+    if err != nil { return applied, err }
+    if !ok { break }
+    applied++
+  }
+  return applied, nil
+}
+
+func (l *Ledger) applyNextDueControlChange(ctx context.Context) (bool, error) {
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return false, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  s, err := scanScheduledChange(tx.QueryRow(ctx, `
+    SELECT `+scheduledChangeCols+`
+    FROM scheduled_control_changes
+    WHERE status='PENDING' AND apply_at <= $1
+    ORDER BY apply_at ASC
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+  `, l.clock.Now()))
+  if errors.Is(err, pgx.ErrNoRows) { return false, nil }
+  if err != nil { return false, err }
+
+  in := s.Controls
+  in.Actor = s.Actor
+  if s.ReasonCode != nil { in.ReasonCode = *s.ReasonCode }
+  if s.Reason != nil { in.Reason = *s.Reason }
+
+  // Apply in a savepoint so a failing change is recorded as FAILED instead of retried forever.
+  sp, err := tx.Begin(ctx)
+  if err != nil { return false, err }
+  _, applyErr := l.setZoneControlsTx(ctx, sp, s.ZoneID, in)
+  if applyErr != nil {
+    _ = sp.Rollback(ctx)
+    _, err = tx.Exec(ctx, `UPDATE scheduled_control_changes SET status='FAILED', fail_reason=$2 WHERE id=$1::uuid`, s.ID, applyErr.Error())
+  } else {
+    if err := sp.Commit(ctx); err != nil { return false, err }
+    _, err = tx.Exec(ctx, `UPDATE scheduled_control_changes SET status='APPLIED', applied_at=$2 WHERE id=$1::uuid`, s.ID, l.clock.Now())
+  }
+  if err != nil { return false, err }
+
+  status := "APPLIED"
+  if applyErr != nil { status = "FAILED" }
+  err = l.audit(ctx, pgQueries{tx}, AuditRecord{
+    Actor: "scheduler", Action: "APPLY_SCHEDULED_CONTROLS", TargetType: "zone", TargetID: s.ZoneID, Reason: in.Reason, ReasonCode: in.ReasonCode,
+    Details: map[string]any{"schedule_id": s.ID, "status": status, "scheduled_by": s.Actor},
+  })
+  if err != nil { return false, err }
+
+  if err := tx.Commit(ctx); err != nil { return false, err }
+  if applyErr == nil { l.zones.invalidate(s.ZoneID) }
+  return true, nil
+}
+
+// ControlScheduler periodically applies due scheduled control changes,
+// throttle ramp steps and accrual periods and makes due recurring transfers.
+// Its poll interval shrinks with the sim clock rate so accelerated runs stay
+// precise.
+type ControlScheduler struct {
+  led *Ledger
+  log *slog.Logger
+  interval time.Duration
+}
+
+func NewControlScheduler(led *Ledger, log *slog.Logger) *ControlScheduler {
+  return &ControlScheduler{led: led, log: log, interval: 1 * time.Second}
+}
+
+func (s *ControlScheduler) Run(ctx context.Context) {
+  timer := time.NewTimer(s.led.scaledInterval(s.interval))
+  defer timer.Stop()
+  for {
+    select {
+    case <-ctx.Done():
+      return
+    case <-timer.C:
+      timer.Reset(s.led.scaledInterval(s.interval))
+      n, err := s.led.ApplyDueControlChanges(context.WithoutCancel(ctx), 50) // let a started batch commit
+      if err != nil {
+        s.log.Warn("scheduled controls apply failed", "err", err.Error())
+        continue
+      }
+      if n > 0 {
+        s.log.Info("scheduled controls applied", "count", n)
+      }
+      if _, err := s.led.ApplyDueAccruals(context.WithoutCancel(ctx), 50); err != nil {
+        s.log.Warn("accrual run failed", "err", err.Error())
+      }
+      if _, err := s.led.RunDueRecurringTransfers(context.WithoutCancel(ctx), 100); err != nil {
+        s.log.Warn("recurring transfers failed", "err", err.Error())
+      }
+      if _, err := s.led.ApplyDueThrottleRamps(context.WithoutCancel(ctx), 50); err != nil {
+        s.log.Warn("throttle ramp step failed", "err", err.Error())
+      }
+      if _, err := s.led.RetryDueSagas(context.WithoutCancel(ctx), 100); err != nil {
+        s.log.Warn("saga retry failed", "err", err.Error())
+      }
+      if n, err := s.led.ExpirePreparedTransfers(context.WithoutCancel(ctx), 100); err != nil {
+        s.log.Warn("prepared transfer expiry failed", "err", err.Error())
+      } else if n > 0 {
+        s.log.Info("prepared transfers expired", "count", n)
+      }
+    }
+  }
+}
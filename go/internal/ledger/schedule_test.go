@@ -0,0 +1,138 @@
+package ledger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"time-ledger-sim/go/internal/store"
+	"time-ledger-sim/go/internal/store/storetest"
+)
+
+func TestScheduledControls(t *testing.T) {
+	db := storetest.OpenMultiTenant(t)
+	ctx := context.Background()
+	l := New(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	l.SetMultiTenant(true)
+	clock := NewVirtualClock()
+	now := time.Date(2026, 4, 1, 9, 0, 0, 0, time.UTC)
+	clock.Freeze(now)
+	l.SetClock(clock)
+
+	// a tenant of its own, so the scheduler only sees this test's changes
+	id := "t-" + uuid.NewString()[:8]
+	if _, err := l.CreateTenant(ctx, CreateTenantInput{ID: id, Actor: "ops"}); err != nil {
+		t.Fatal(err)
+	}
+	ctx = store.WithTenant(ctx, id)
+	zone := "zone-sched-" + id
+	if _, err := l.CreateZone(ctx, CreateZoneInput{ID: zone, Name: zone, Actor: "ops"}); err != nil {
+		t.Fatal(err)
+	}
+	alice, bob := "sched-"+id+"-alice", "sched-"+id+"-bob"
+	t.Cleanup(func() { _, _ = db.Exec(context.Background(), `DELETE FROM actors WHERE id IN ($1,$2)`, alice, bob) })
+	for _, a := range []string{alice, bob} {
+		if _, err := l.RegisterActor(ctx, RegisterActorInput{ID: a, Kind: ActorKindHuman, Actor: "ops"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	schedule := func(at time.Time, throttle int, actor string) (*ScheduledControlChange, error) {
+		return l.ScheduleZoneControls(ctx, zone, at, SetZoneControlsInput{CrossZoneThrottle: throttle, Actor: actor, Reason: "planned"})
+	}
+	for _, at := range []time.Time{now, now.Add(-time.Minute)} {
+		if _, err := schedule(at, 40, alice); err == nil || !strings.Contains(err.Error(), "apply_at must be in the future") {
+			t.Fatalf("apply_at %v: err = %v", at, err)
+		}
+	}
+	a, err := schedule(now.Add(time.Minute), 40, alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Status != "PENDING" || a.Controls.CrossZoneThrottle != 40 || a.Controls.ThrottleMode != ThrottleModeHash {
+		t.Fatalf("scheduled = %+v", a)
+	}
+	b, err := schedule(now.Add(2*time.Minute), 30, alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := schedule(now.Add(3*time.Minute), 20, bob)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cancelled, err := l.CancelScheduledControls(ctx, b.ID, alice, "not needed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cancelled.Status != "CANCELLED" {
+		t.Fatalf("cancelled = %+v", cancelled)
+	}
+	if _, err := l.CancelScheduledControls(ctx, b.ID, alice, ""); !IsScheduleNotFound(err) {
+		t.Fatalf("cancel twice: err = %v", err)
+	}
+
+	if n, err := l.ApplyDueControlChanges(ctx, 10); err != nil || n != 0 {
+		t.Fatalf("nothing due yet: applied %d, %v", n, err)
+	}
+
+	// bob leaves before his change is due: it is recorded as FAILED, not retried
+	if _, err := l.DisableActor(ctx, bob, "ops", "left"); err != nil {
+		t.Fatal(err)
+	}
+	l.SetRequireKnownActors(true)
+	clock.Freeze(now.Add(5 * time.Minute))
+
+	// a change another replica holds is skipped, not waited for
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM scheduled_control_changes WHERE id=$1::uuid FOR UPDATE`, a.ID); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := l.ApplyDueControlChanges(ctx, 10); err != nil || n != 1 {
+		t.Fatalf("with a locked change: applied %d, %v", n, err)
+	}
+	if err := tx.Rollback(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := l.ApplyDueControlChanges(ctx, 10); err != nil || n != 1 {
+		t.Fatalf("after the lock: applied %d, %v", n, err)
+	}
+
+	all, err := l.ListScheduledControls(ctx, zone, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := map[string]ScheduledControlChange{}
+	for _, s := range all {
+		status[s.ID] = s
+	}
+	if s := status[a.ID]; s.Status != "APPLIED" || s.AppliedAt == nil {
+		t.Fatalf("alice's change = %+v", s)
+	}
+	if s := status[b.ID]; s.Status != "CANCELLED" {
+		t.Fatalf("cancelled change = %+v", s)
+	}
+	if s := status[c.ID]; s.Status != "FAILED" || s.FailReason == nil || !strings.Contains(*s.FailReason, "disabled") {
+		t.Fatalf("bob's change = %+v", s)
+	}
+	if pending, err := l.ListScheduledControls(ctx, zone, false); err != nil || len(pending) != 0 {
+		t.Fatalf("pending = %+v, %v", pending, err)
+	}
+
+	zc, err := l.GetZoneControls(ctx, zone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if zc.CrossZoneThrottle != 40 {
+		t.Fatalf("throttle = %d, want alice's 40", zc.CrossZoneThrottle)
+	}
+}
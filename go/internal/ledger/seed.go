@@ -0,0 +1,167 @@
+package ledger
+
+import (
+  "context"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "hash/fnv"
+  "sort"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+
+  "time-ledger-sim/go/internal/util"
+)
+
+const (
+  maxSeedAccountsPerZone = 1000
+  maxSeedHistory = 20000
+)
+
+type SeedInput struct {
+  Seed uint64 // 0 = current sim seed
+  Zones []string // empty = all active zones
+  AccountsPerZone int
+  StartingBalanceUnits int64
+  HistoryTransactions int // per zone
+  HistorySpan time.Duration
+  MaxAmountUnits int64
+  Actor string
+  Reason string
+}
+
+type SeedResult struct {
+  Seed uint64 `json:"seed"`
+  Zones []string `json:"zones"`
+  Accounts int `json:"accounts"`
+  FundingTransfers int `json:"funding_transfers"`
+  HistoricalTransfers int `json:"historical_transfers"`
+  Skipped int `json:"skipped"`
+}
+
+func (in *SeedInput) normalize() error {
+  if in.AccountsPerZone <= 0 || in.AccountsPerZone > maxSeedAccountsPerZone {
+    return fmt.Errorf("accounts_per_zone must be 1..%d", maxSeedAccountsPerZone)
+  }
+  if in.StartingBalanceUnits < 0 { return fmt.Errorf("invalid starting_balance_units") }
+  if in.HistoryTransactions < 0 || in.HistoryTransactions > maxSeedHistory {
+    return fmt.Errorf("history_transactions must be 0..%d", maxSeedHistory)
+  }
+  if in.HistorySpan <= 0 { in.HistorySpan = 24 * time.Hour }
+  if in.MaxAmountUnits <= 0 { in.MaxAmountUnits = 1000 }
+  if in.HistoryTransactions > 0 && in.AccountsPerZone < 2 {
+    return fmt.Errorf("history needs at least 2 accounts per zone")
+  }
+  return nil
+}
+
+func seedTreasuryAccount(zoneID string) string { return zoneID + "-treasury" }
+
+func seedAccountID(zoneID string, i int) string { return fmt.Sprintf("%s-acct-%04d", zoneID, i) }
+
+// planSeed generates the deterministic transfer plan for one zone: funding from the
+// zone treasury at the start of the history window, then time-ordered history.
+func planSeed(in SeedInput, seed uint64, zoneID string, now time.Time) []CreateTransferInput {
+  h := fnv.New64a()
+  _, _ = h.Write([]byte(zoneID))
+  rnd := newSimRand(seed ^ h.Sum64())
+  start := now.Add(-in.HistorySpan)
+  prefix := fmt.Sprintf("seed-%d-%s", seed, zoneID)
+
+  out := []CreateTransferInput{}
+  if in.StartingBalanceUnits > 0 {
+    for i := 1; i <= in.AccountsPerZone; i++ {
+      out = append(out, CreateTransferInput{
+        RequestID: fmt.Sprintf("%s-fund-%04d", prefix, i),
+        FromAccount: seedTreasuryAccount(zoneID),
+        ToAccount: seedAccountID(zoneID, i),
+        AmountUnits: in.StartingBalanceUnits,
+        ZoneID: zoneID,
+        Type: TxnTypeSeed,
+        At: start,
+      })
+    }
+  }
+
+  spanMs := int(in.HistorySpan / time.Millisecond)
+  hist := make([]CreateTransferInput, 0, in.HistoryTransactions)
+  for i := 1; i <= in.HistoryTransactions; i++ {
+    from := rnd.IntN(in.AccountsPerZone) + 1
+    to := rnd.IntN(in.AccountsPerZone-1) + 1
+    if to >= from { to++ }
+    hist = append(hist, CreateTransferInput{
+      RequestID: fmt.Sprintf("%s-hist-%05d", prefix, i),
+      FromAccount: seedAccountID(zoneID, from),
+      ToAccount: seedAccountID(zoneID, to),
+      AmountUnits: int64(rnd.IntN(int(in.MaxAmountUnits))) + 1,
+      ZoneID: zoneID,
+      Type: TxnTypeSeed,
+      At: start.Add(time.Duration(rnd.IntN(spanMs)+1) * time.Millisecond),
+    })
+  }
+  sort.SliceStable(hist, func(i, j int) bool { return hist[i].At.Before(hist[j].At) })
+  return append(out, hist...)
+}
+
+// SeedData generates accounts with starting balances and optional historical transfers.
+// Output is deterministic for a given seed and re-running it is idempotent (request
+// IDs are derived from the seed). Zone controls are bypassed, as for spool replay.
+func (l *Ledger) SeedData(ctx context.Context, in SeedInput) (*SeedResult, error) {
+  if err := in.normalize(); err != nil { return nil, err }
+  seed := in.Seed
+  if seed == 0 { seed = l.Seed() }
+
+  zones := in.Zones
+  if len(zones) == 0 {
+    zs, err := l.ListZones(ctx, nil)
+    if err != nil { return nil, err }
+    for _, z := range zs { zones = append(zones, z.ID) }
+  }
+
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+  q := pgQueries{tx}
+
+  res := &SeedResult{Seed: seed, Zones: zones}
+  now := l.clock.Now()
+  for _, zoneID := range zones {
+    if _, err := q.ZoneStatus(ctx, zoneID); err != nil { return nil, fmt.Errorf("%s: %w", zoneID, err) }
+    skewMs, err := zoneClockSkew(ctx, q, zoneID)
+    if err != nil { return nil, err }
+    if err := q.EnsureAccount(ctx, seedTreasuryAccount(zoneID), zoneID); err != nil { return nil, err }
+    for i := 1; i <= in.AccountsPerZone; i++ {
+      if err := q.EnsureAccount(ctx, seedAccountID(zoneID, i), zoneID); err != nil { return nil, err }
+    }
+    res.Accounts += in.AccountsPerZone
+
+    for _, t := range planSeed(in, seed, zoneID, now) {
+      t.Metadata = map[string]any{"seed": seed}
+      t.PayloadHash, err = util.HashCanonicalJSON(map[string]any{
+        "request_id": t.RequestID, "from_account": t.FromAccount, "to_account": t.ToAccount,
+        "amount_units": t.AmountUnits, "zone_id": t.ZoneID, "metadata": t.Metadata,
+      })
+      if err != nil { return nil, err }
+
+      // request ids derive from the seed, so re-seeding skips what exists
+      metaBytes, _ := json.Marshal(t.Metadata)
+      _, err := l.applyTransfer(ctx, q, t, metaBytes, skewMs)
+      if errors.Is(err, ErrRequestExists) { res.Skipped++; continue }
+      if err != nil { return nil, err }
+      if t.FromAccount == seedTreasuryAccount(zoneID) {
+        res.FundingTransfers++
+      } else {
+        res.HistoricalTransfers++
+      }
+    }
+  }
+
+  err = l.audit(ctx, pgQueries{tx}, AuditRecord{
+    Actor: in.Actor, Action: "SIM_SEED", TargetType: "sim", TargetID: "seed", Reason: in.Reason, Details: asDetails(res),
+  })
+  if err != nil { return nil, err }
+
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  return res, nil
+}
@@ -0,0 +1,63 @@
+package ledger
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPlanSeedDeterministic(t *testing.T) {
+	in := SeedInput{AccountsPerZone: 5, StartingBalanceUnits: 1000, HistoryTransactions: 20}
+	if err := in.normalize(); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	a := planSeed(in, 42, "zone-eu", now)
+	b := planSeed(in, 42, "zone-eu", now)
+	if len(a) != 25 || len(b) != 25 {
+		t.Fatalf("expected 25 transfers, got %d/%d", len(a), len(b))
+	}
+	for i := range a {
+		if a[i].RequestID != b[i].RequestID || a[i].FromAccount != b[i].FromAccount ||
+			a[i].ToAccount != b[i].ToAccount || a[i].AmountUnits != b[i].AmountUnits || !a[i].At.Equal(b[i].At) {
+			t.Fatalf("plan differs at %d: %+v vs %+v", i, a[i], b[i])
+		}
+	}
+
+	c := planSeed(in, 43, "zone-eu", now)
+	same := true
+	for i := range a {
+		if a[i].AmountUnits != c[i].AmountUnits || a[i].FromAccount != c[i].FromAccount {
+			same = false
+		}
+	}
+	if same {
+		t.Fatal("different seeds produced the same plan")
+	}
+}
+
+func TestPlanSeedHistoryShape(t *testing.T) {
+	in := SeedInput{AccountsPerZone: 3, HistoryTransactions: 50, HistorySpan: time.Hour, MaxAmountUnits: 10}
+	if err := in.normalize(); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	plan := planSeed(in, 7, "zone-us", now)
+	if len(plan) != 50 {
+		t.Fatalf("expected no funding transfers, got %d", len(plan))
+	}
+	for i, p := range plan {
+		if p.FromAccount == p.ToAccount {
+			t.Fatalf("self transfer at %d", i)
+		}
+		if p.AmountUnits < 1 || p.AmountUnits > 10 {
+			t.Fatalf("amount out of range: %d", p.AmountUnits)
+		}
+		if p.At.Before(now.Add(-time.Hour)) || p.At.After(now) {
+			t.Fatalf("timestamp outside span: %v", p.At)
+		}
+		if i > 0 && p.At.Before(plan[i-1].At) {
+			t.Fatalf("history not time ordered at %d", i)
+		}
+	}
+}
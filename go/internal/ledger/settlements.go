@@ -0,0 +1,279 @@
+package ledger
+
+import (
+  "context"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "log/slog"
+  "sort"
+  "sync/atomic"
+  "time"
+
+  "github.com/google/uuid"
+  "github.com/jackc/pgx/v5"
+
+  "time-ledger-sim/go/internal/util"
+)
+
+var ErrSettlementRunNotFound = errors.New("settlement run not found")
+
+func IsSettlementRunNotFound(err error) bool { return errors.Is(err, ErrSettlementRunNotFound) }
+
+// SettlementAccount is the account a zone settles with other zones through.
+func SettlementAccount(zoneID string) string { return zoneID + "-settlement" }
+
+// SettlementRun is one netting of the cross-zone transactions that were not
+// settled yet, up to CutoffAt on the sim clock.
+type SettlementRun struct {
+  ID string `json:"id"`
+  CutoffAt time.Time `json:"cutoff_at"`
+  Transfers int `json:"transfers"` // cross-zone transactions covered
+  GrossUnits int64 `json:"gross_units"`
+  NetUnits int64 `json:"net_units"`
+  Settlements int `json:"settlements"` // settlement transactions posted
+  Actor string `json:"actor"`
+  Reason *string `json:"reason"`
+  CreatedAt time.Time `json:"created_at"`
+  Obligations []SettlementObligation `json:"obligations,omitempty"`
+}
+
+// SettlementObligation is what one zone owed another in a run: GrossUnits
+// moved from PayerZone accounts to PayeeZone accounts and ReverseUnits back,
+// so PayerZone pays NetUnits. TransactionID is the settlement transaction,
+// null when the pair netted to zero.
+type SettlementObligation struct {
+  PayerZone string `json:"payer_zone"`
+  PayeeZone string `json:"payee_zone"`
+  Transfers int `json:"transfers"`
+  GrossUnits int64 `json:"gross_units"`
+  ReverseUnits int64 `json:"reverse_units"`
+  NetUnits int64 `json:"net_units"`
+  TransactionID *string `json:"transaction_id"`
+}
+
+const settlementRunCols = `id::text, cutoff_at, transfers, gross_units, net_units, settlements, actor, reason, created_at`
+
+func scanSettlementRun(row pgx.Row) (*SettlementRun, error) {
+  var r SettlementRun
+  err := row.Scan(&r.ID, &r.CutoffAt, &r.Transfers, &r.GrossUnits, &r.NetUnits, &r.Settlements, &r.Actor, &r.Reason, &r.CreatedAt)
+  if err != nil { return nil, err }
+  return &r, nil
+}
+
+// RunSettlement settles now and records the run even when nothing was due.
+func (l *Ledger) RunSettlement(ctx context.Context, actor, reason string) (*SettlementRun, error) {
+  return l.runSettlement(ctx, actor, reason, true)
+}
+
+// runSettlement claims every cross-zone transaction created up to the sim
+// clock's now that no run covered yet, skipping those touching a DOWN zone
+// (they wait for the zone to come back). Claims per zone pair are netted and
+// the payer zone's settlement account pays the payee's the difference. The
+// settlement transactions bypass zone gates like accruals do, and are claimed
+// by the run themselves so they are never settled again. Runs hold a
+// transaction-scoped advisory lock, so concurrent runs queue instead of
+// netting the same transactions. A scheduled run (the system actor, not
+// checked against the registry) with nothing due is rolled back and returns nil.
+func (l *Ledger) runSettlement(ctx context.Context, actor, reason string, manual bool) (*SettlementRun, error) {
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+  q := pgQueries{tx}
+
+  if manual {
+    if err := l.checkActors(ctx, tx, actor); err != nil { return nil, err }
+  }
+  if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('settlement-run'))`); err != nil { return nil, err }
+
+  cutoff := l.clock.Now().Truncate(time.Microsecond)
+  run, err := scanSettlementRun(tx.QueryRow(ctx, `
+    INSERT INTO settlement_runs(cutoff_at, actor, reason) VALUES($1,$2,NULLIF($3,''))
+    RETURNING `+settlementRunCols, cutoff, actor, reason))
+  if err != nil { return nil, err }
+
+  rows, err := tx.Query(ctx, `
+    WITH due AS (
+      SELECT t.id, t.from_zone_id, t.to_zone_id, t.amount_units
+      FROM transactions t
+      WHERE t.from_zone_id <> t.to_zone_id AND t.created_at <= $2
+        AND NOT EXISTS (SELECT 1 FROM settlement_items s WHERE s.txn_id = t.id)
+        AND NOT EXISTS (SELECT 1 FROM zones z WHERE z.id IN (t.from_zone_id, t.to_zone_id) AND z.status = 'DOWN')
+    ),
+    claimed AS (
+      INSERT INTO settlement_items(txn_id, run_id) SELECT id, $1::uuid FROM due
+    )
+    SELECT from_zone_id, to_zone_id, COUNT(*)::int, SUM(amount_units)::bigint FROM due GROUP BY from_zone_id, to_zone_id
+  `, run.ID, cutoff)
+  if err != nil { return nil, err }
+  type pair struct{ a, b string }
+  gross := map[pair]int64{}
+  counts := map[pair]int{}
+  for rows.Next() {
+    var p pair
+    var n int
+    var units int64
+    if err := rows.Scan(&p.a, &p.b, &n, &units); err != nil { rows.Close(); return nil, err }
+    gross[p] = units
+    // counts are per unordered pair, keyed by the smaller zone id first
+    if p.a > p.b { p.a, p.b = p.b, p.a }
+    counts[p] += n
+    run.Transfers += n
+    run.GrossUnits += units
+  }
+  rows.Close()
+  if err := rows.Err(); err != nil { return nil, err }
+  if run.Transfers == 0 && !manual { return nil, nil }
+
+  pairs := make([]pair, 0, len(counts))
+  for p := range counts { pairs = append(pairs, p) }
+  sort.Slice(pairs, func(i, j int) bool { return pairs[i].a < pairs[j].a || (pairs[i].a == pairs[j].a && pairs[i].b < pairs[j].b) })
+
+  run.Obligations = []SettlementObligation{}
+  for _, p := range pairs {
+    o := SettlementObligation{PayerZone: p.a, PayeeZone: p.b, Transfers: counts[p], GrossUnits: gross[p], ReverseUnits: gross[pair{p.b, p.a}]}
+    if o.ReverseUnits > o.GrossUnits { o.PayerZone, o.PayeeZone, o.GrossUnits, o.ReverseUnits = p.b, p.a, o.ReverseUnits, o.GrossUnits }
+    o.NetUnits = o.GrossUnits - o.ReverseUnits
+    if o.NetUnits > 0 {
+      txnID, err := l.postSettlement(ctx, q, run.ID, o)
+      if err != nil { return nil, err }
+      o.TransactionID = &txnID
+      run.Settlements++
+      run.NetUnits += o.NetUnits
+    }
+    _, err := tx.Exec(ctx, `
+      INSERT INTO settlement_obligations(run_id,payer_zone,payee_zone,transfers,gross_units,reverse_units,net_units,transaction_id)
+      VALUES($1::uuid,$2,$3,$4,$5,$6,$7,$8::uuid)
+    `, run.ID, o.PayerZone, o.PayeeZone, o.Transfers, o.GrossUnits, o.ReverseUnits, o.NetUnits, o.TransactionID)
+    if err != nil { return nil, err }
+    run.Obligations = append(run.Obligations, o)
+  }
+
+  _, err = tx.Exec(ctx, `
+    UPDATE settlement_runs SET transfers=$2, gross_units=$3, net_units=$4, settlements=$5 WHERE id=$1::uuid
+  `, run.ID, run.Transfers, run.GrossUnits, run.NetUnits, run.Settlements)
+  if err != nil { return nil, err }
+
+  err = l.audit(ctx, q, AuditRecord{
+    Actor: actor, Action: "RUN_SETTLEMENT", TargetType: "settlement_run", TargetID: run.ID, Reason: reason,
+    Details: map[string]any{"cutoff_at": run.CutoffAt, "transfers": run.Transfers, "gross_units": run.GrossUnits,
+      "net_units": run.NetUnits, "settlements": run.Settlements},
+  })
+  if err != nil { return nil, err }
+
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  return run, nil
+}
+
+// postSettlement posts o's net from the payer's settlement account to the
+// payee's and claims the transaction for the run. Both accounts are created
+// in their own zone first; applyTransfer would put a new payee account in the
+// payer's zone.
+func (l *Ledger) postSettlement(ctx context.Context, q pgQueries, runID string, o SettlementObligation) (string, error) {
+  if err := q.EnsureAccount(ctx, SettlementAccount(o.PayerZone), o.PayerZone); err != nil { return "", err }
+  if err := q.EnsureAccount(ctx, SettlementAccount(o.PayeeZone), o.PayeeZone); err != nil { return "", err }
+  skewMs, err := zoneClockSkew(ctx, q, o.PayerZone)
+  if err != nil { return "", err }
+
+  in := CreateTransferInput{
+    RequestID: fmt.Sprintf("settlement-%s-%s-%s", runID, o.PayerZone, o.PayeeZone),
+    FromAccount: SettlementAccount(o.PayerZone), ToAccount: SettlementAccount(o.PayeeZone),
+    AmountUnits: o.NetUnits, ZoneID: o.PayerZone, Type: TxnTypeSettlement,
+    Metadata: map[string]any{"settlement_run": runID, "payee_zone": o.PayeeZone, "gross_units": o.GrossUnits, "reverse_units": o.ReverseUnits},
+  }
+  in.PayloadHash, err = util.HashCanonicalJSON(map[string]any{
+    "request_id": in.RequestID, "from_account": in.FromAccount, "to_account": in.ToAccount,
+    "amount_units": in.AmountUnits, "zone_id": in.ZoneID, "metadata": in.Metadata,
+  })
+  if err != nil { return "", err }
+  metaBytes, _ := json.Marshal(in.Metadata)
+  txn, err := l.applyTransfer(ctx, q, in, metaBytes, skewMs)
+  if err != nil { return "", err }
+  _, err = q.q.Exec(ctx, `INSERT INTO settlement_items(txn_id, run_id) VALUES($1::uuid,$2::uuid)`, txn.ID, runID)
+  return txn.ID, err
+}
+
+// GetSettlementRun returns a run with its obligations.
+func (l *Ledger) GetSettlementRun(ctx context.Context, id string) (*SettlementRun, error) {
+  if _, err := uuid.Parse(id); err != nil { return nil, ErrSettlementRunNotFound }
+  r, err := scanSettlementRun(l.db.QueryRow(ctx, `SELECT `+settlementRunCols+` FROM settlement_runs WHERE id=$1::uuid`, id))
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrSettlementRunNotFound }
+  if err != nil { return nil, err }
+
+  rows, err := l.db.Query(ctx, `
+    SELECT payer_zone, payee_zone, transfers, gross_units, reverse_units, net_units, transaction_id::text
+    FROM settlement_obligations WHERE run_id=$1::uuid ORDER BY payer_zone, payee_zone
+  `, id)
+  if err != nil { return nil, err }
+  defer rows.Close()
+  r.Obligations = []SettlementObligation{}
+  for rows.Next() {
+    var o SettlementObligation
+    if err := rows.Scan(&o.PayerZone, &o.PayeeZone, &o.Transfers, &o.GrossUnits, &o.ReverseUnits, &o.NetUnits, &o.TransactionID); err != nil { return nil, err }
+    r.Obligations = append(r.Obligations, o)
+  }
+  return r, rows.Err()
+}
+
+// ListSettlementRuns returns the latest runs, newest first, without their
+// obligations.
+func (l *Ledger) ListSettlementRuns(ctx context.Context, limit int) ([]SettlementRun, error) {
+  if limit <= 0 || limit > 500 { limit = 100 }
+  rows, err := l.ro.Query(ctx, `SELECT `+settlementRunCols+` FROM settlement_runs ORDER BY created_at DESC LIMIT $1`, limit)
+  if err != nil { return nil, err }
+  defer rows.Close()
+
+  out := []SettlementRun{}
+  for rows.Next() {
+    r, err := scanSettlementRun(rows)
+    if err != nil { return nil, err }
+    out = append(out, *r)
+  }
+  return out, rows.Err()
+}
+
+// SettlementRunner settles on an interval; runs with nothing due are not
+// recorded. The interval can change while it runs; 0 pauses it.
+type SettlementRunner struct {
+  led *Ledger
+  log *slog.Logger
+  interval atomic.Int64
+}
+
+// settlementRunnerIdle is how often a paused runner looks for a new interval.
+const settlementRunnerIdle = 5 * time.Second
+
+func NewSettlementRunner(led *Ledger, log *slog.Logger) *SettlementRunner {
+  r := &SettlementRunner{led: led, log: log}
+  r.SetInterval(time.Hour)
+  return r
+}
+
+func (r *SettlementRunner) SetInterval(d time.Duration) { r.interval.Store(int64(d)) }
+
+func (r *SettlementRunner) Run(ctx context.Context) {
+  next := func() time.Duration {
+    iv := time.Duration(r.interval.Load())
+    if iv <= 0 { return settlementRunnerIdle }
+    return r.led.scaledInterval(iv)
+  }
+  timer := time.NewTimer(next())
+  defer timer.Stop()
+  for {
+    select {
+    case <-ctx.Done():
+      return
+    case <-timer.C:
+      timer.Reset(next())
+      if r.interval.Load() <= 0 { continue }
+      run, err := r.led.runSettlement(context.WithoutCancel(ctx), "system", "scheduled settlement", false)
+      if err != nil {
+        r.log.Warn("settlement run failed", "err", err.Error())
+        continue
+      }
+      if run != nil {
+        r.log.Info("settlement run posted", "run_id", run.ID, "transfers", run.Transfers, "net_units", run.NetUnits, "settlements", run.Settlements)
+      }
+    }
+  }
+}
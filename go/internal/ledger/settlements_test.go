@@ -0,0 +1,132 @@
+package ledger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"time-ledger-sim/go/internal/store/storetest"
+)
+
+func TestSettlementRun(t *testing.T) {
+	db := storetest.Open(t)
+	ctx := context.Background()
+	l := New(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	suffix := uuid.NewString()[:8]
+	za, zb := "zone-sta-"+suffix, "zone-stb-"+suffix
+	for _, z := range []string{za, zb} {
+		if _, err := l.CreateZone(ctx, CreateZoneInput{ID: z, Name: z, Actor: "test"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	alice, bob := za+"-alice", zb+"-bob"
+	transfer := func(zone, from, to string, units int64) {
+		t.Helper()
+		_, _, err := l.CreateTransfer(ctx, CreateTransferInput{
+			RequestID: "st-" + uuid.NewString(), PayloadHash: "h", FromAccount: from, ToAccount: to, AmountUnits: units, ZoneID: zone,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	transfer(zb, zb+"-treasury", bob, 1) // creates bob in zb
+	transfer(za, alice, bob, 100)
+	transfer(za, alice, bob, 20)
+	transfer(zb, bob, alice, 30)
+
+	obligation := func(run *SettlementRun) *SettlementObligation {
+		t.Helper()
+		for i, o := range run.Obligations {
+			if (o.PayerZone == za && o.PayeeZone == zb) || (o.PayerZone == zb && o.PayeeZone == za) {
+				return &run.Obligations[i]
+			}
+		}
+		return nil
+	}
+	balance := func(acct string) int64 {
+		t.Helper()
+		var b int64
+		_ = db.QueryRow(ctx, `SELECT balance_units FROM balances WHERE account_id=$1`, acct).Scan(&b)
+		return b
+	}
+
+	run, err := l.RunSettlement(ctx, "test", "close of day")
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := obligation(run)
+	if o == nil {
+		t.Fatalf("no obligation between %s and %s in %+v", za, zb, run.Obligations)
+	}
+	if o.PayerZone != za || o.Transfers != 3 || o.GrossUnits != 120 || o.ReverseUnits != 30 || o.NetUnits != 90 || o.TransactionID == nil {
+		t.Fatalf("obligation = %+v", o)
+	}
+	if got := balance(SettlementAccount(za)); got != -90 {
+		t.Fatalf("payer settlement balance = %d, want -90", got)
+	}
+	if got := balance(SettlementAccount(zb)); got != 90 {
+		t.Fatalf("payee settlement balance = %d, want 90", got)
+	}
+	d, err := l.GetTransaction(ctx, *o.TransactionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.FromZoneID != za || d.ToZoneID != zb {
+		t.Fatalf("settlement zones = %s -> %s", d.FromZoneID, d.ToZoneID)
+	}
+
+	got, err := l.GetSettlementRun(ctx, run.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g := obligation(got); g == nil || *g.TransactionID != *o.TransactionID || got.Settlements != run.Settlements {
+		t.Fatalf("stored run = %+v", got)
+	}
+
+	// settled transactions, the settlement itself included, are not settled again
+	run, err = l.RunSettlement(ctx, "test", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o := obligation(run); o != nil {
+		t.Fatalf("settled again: %+v", o)
+	}
+
+	// transactions touching a DOWN zone wait for it
+	transfer(za, alice, bob, 5)
+	if _, err := l.SetZoneStatus(ctx, zb, "DOWN", "test", "", "drill"); err != nil {
+		t.Fatal(err)
+	}
+	run, err = l.RunSettlement(ctx, "test", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o := obligation(run); o != nil {
+		t.Fatalf("settled with %s DOWN: %+v", zb, o)
+	}
+	if _, err := l.SetZoneStatus(ctx, zb, "OK", "test", "", "drill over"); err != nil {
+		t.Fatal(err)
+	}
+	run, err = l.RunSettlement(ctx, "test", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o := obligation(run); o == nil || o.NetUnits != 5 || o.Transfers != 1 {
+		t.Fatalf("after recovery = %+v", o)
+	}
+
+	list, err := l.ListSettlementRuns(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) == 0 || list[0].ID != run.ID {
+		t.Fatalf("latest run = %+v, want %s", list, run.ID)
+	}
+	if _, err := l.GetSettlementRun(ctx, uuid.NewString()); !IsSettlementRunNotFound(err) {
+		t.Fatalf("err = %v, want not found", err)
+	}
+}
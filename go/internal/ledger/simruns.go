@@ -0,0 +1,201 @@
+package ledger
+
+import (
+  "context"
+  "errors"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+  "github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+  ErrSimRunNotFound = errors.New("sim run not found")
+  ErrSimRunActive = errors.New("a sim run is already active")
+)
+
+func IsSimRunNotFound(err error) bool { return errors.Is(err, ErrSimRunNotFound) }
+func IsSimRunActive(err error) bool { return errors.Is(err, ErrSimRunActive) }
+
+type SimRun struct {
+  ID string `json:"id"`
+  Name string `json:"name"`
+  Status string `json:"status"`
+  Actor string `json:"actor"`
+  Reason *string `json:"reason"`
+  StartedAt time.Time `json:"started_at"`
+  StoppedAt *time.Time `json:"stopped_at"`
+}
+
+const simRunCols = `id::text, name, status, actor, reason, started_at, stopped_at`
+
+func scanSimRun(row pgx.Row) (*SimRun, error) {
+  var r SimRun
+  if err := row.Scan(&r.ID, &r.Name, &r.Status, &r.Actor, &r.Reason, &r.StartedAt, &r.StoppedAt); err != nil {
+    return nil, err
+  }
+  return &r, nil
+}
+
+// StartSimRun opens a named run; rows created until it stops are tagged with its id.
+func (l *Ledger) StartSimRun(ctx context.Context, name, actor, reason string) (*SimRun, error) {
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  r, err := scanSimRun(tx.QueryRow(ctx, `
+    INSERT INTO sim_runs(name,actor,reason,started_at) VALUES($1,$2,NULLIF($3,''),$4)
+    RETURNING `+simRunCols, name, actor, reason, l.clock.Now()))
+  var pgErr *pgconn.PgError
+  if errors.As(err, &pgErr) && pgErr.Code == "23505" { return nil, ErrSimRunActive }
+  if err != nil { return nil, err }
+  if err := l.auditSimRun(ctx, tx, actor, "SIM_RUN_STARTED", r.ID, reason); err != nil { return nil, err }
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  return r, nil
+}
+
+func (l *Ledger) StopSimRun(ctx context.Context, id, actor, reason string) (*SimRun, error) {
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  r, err := scanSimRun(tx.QueryRow(ctx, `
+    UPDATE sim_runs SET status='STOPPED', stopped_at=$2 WHERE id=$1::uuid AND status='RUNNING'
+    RETURNING `+simRunCols, id, l.clock.Now()))
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrSimRunNotFound }
+  if err != nil { return nil, err }
+  if err := l.auditSimRun(ctx, tx, actor, "SIM_RUN_STOPPED", r.ID, reason); err != nil { return nil, err }
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  return r, nil
+}
+
+func (l *Ledger) GetSimRun(ctx context.Context, id string) (*SimRun, error) {
+  r, err := scanSimRun(l.db.QueryRow(ctx, `SELECT `+simRunCols+` FROM sim_runs WHERE id::text=$1`, id))
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrSimRunNotFound }
+  return r, err
+}
+
+func (l *Ledger) ListSimRuns(ctx context.Context, limit int) ([]SimRun, error) {
+  if limit <= 0 || limit > 200 { limit = 50 }
+  rows, err := l.ro.Query(ctx, `SELECT `+simRunCols+` FROM sim_runs ORDER BY started_at DESC LIMIT $1`, limit)
+  if err != nil { return nil, err }
+  defer rows.Close()
+  out := []SimRun{}
+  for rows.Next() {
+    r, err := scanSimRun(rows)
+    if err != nil { return nil, err }
+    out = append(out, *r)
+  }
+  return out, rows.Err()
+}
+
+func (l *Ledger) auditSimRun(ctx context.Context, tx pgx.Tx, actor, action, id, reason string) error {
+  return l.audit(ctx, pgQueries{tx}, AuditRecord{Actor: actor, Action: action, TargetType: "sim_run", TargetID: id, Reason: reason})
+}
+
+type SimRunZoneSummary struct {
+  ZoneID string `json:"zone_id"`
+  Transfers int64 `json:"transfers"`
+  AmountUnits int64 `json:"amount_units"`
+  Spooled int64 `json:"spooled"`
+  Incidents int64 `json:"incidents"`
+}
+
+type SimRunSummary struct {
+  Run SimRun `json:"run"`
+  DurationSeconds float64 `json:"duration_seconds"`
+  Throughput struct {
+    Transfers int64 `json:"transfers"`
+    AmountUnits int64 `json:"amount_units"`
+    TransfersPerSec float64 `json:"transfers_per_sec"`
+  } `json:"throughput"`
+  Spool struct {
+    Pending int64 `json:"pending"`
+    Applied int64 `json:"applied"`
+    Failed int64 `json:"failed"`
+  } `json:"spool"`
+  Incidents map[string]int64 `json:"incidents"` // by severity
+  Zones []SimRunZoneSummary `json:"zones"`
+}
+
+// SimRunSummary aggregates everything tagged with the run so chaos drills can be
+// compared before/after. Duration is measured on the sim clock.
+func (l *Ledger) SimRunSummary(ctx context.Context, id string) (*SimRunSummary, error) {
+  r, err := l.GetSimRun(ctx, id)
+  if err != nil { return nil, err }
+  s := &SimRunSummary{Run: *r, Incidents: map[string]int64{"INFO": 0, "WARN": 0, "CRITICAL": 0}, Zones: []SimRunZoneSummary{}}
+
+  end := l.clock.Now()
+  if r.StoppedAt != nil { end = *r.StoppedAt }
+  s.DurationSeconds = end.Sub(r.StartedAt).Seconds()
+
+  err = l.ro.QueryRow(ctx, `
+    SELECT
+      (SELECT COUNT(*) FROM transactions WHERE run_id=$1::uuid),
+      (SELECT COALESCE(SUM(amount_units),0)::bigint FROM transactions WHERE run_id=$1::uuid),
+      (SELECT COUNT(*) FROM spooled_transfers WHERE run_id=$1::uuid AND status='PENDING'),
+      (SELECT COUNT(*) FROM spooled_transfers WHERE run_id=$1::uuid AND status='APPLIED'),
+      (SELECT COUNT(*) FROM spooled_transfers WHERE run_id=$1::uuid AND status='FAILED')
+  `, r.ID).Scan(&s.Throughput.Transfers, &s.Throughput.AmountUnits, &s.Spool.Pending, &s.Spool.Applied, &s.Spool.Failed)
+  if err != nil { return nil, err }
+  if s.DurationSeconds > 0 { s.Throughput.TransfersPerSec = float64(s.Throughput.Transfers) / s.DurationSeconds }
+
+  rows, err := l.ro.Query(ctx, `SELECT severity, COUNT(*) FROM incidents WHERE run_id=$1::uuid GROUP BY severity`, r.ID)
+  if err != nil { return nil, err }
+  for rows.Next() {
+    var sev string
+    var n int64
+    if err := rows.Scan(&sev, &n); err != nil { rows.Close(); return nil, err }
+    s.Incidents[sev] = n
+  }
+  rows.Close()
+  if err := rows.Err(); err != nil { return nil, err }
+
+  zrows, err := l.ro.Query(ctx, `
+    SELECT z.id,
+      (SELECT COUNT(*) FROM transactions t WHERE t.run_id=$1::uuid AND t.zone_id=z.id),
+      (SELECT COALESCE(SUM(amount_units),0)::bigint FROM transactions t WHERE t.run_id=$1::uuid AND t.zone_id=z.id),
+      (SELECT COUNT(*) FROM spooled_transfers sp WHERE sp.run_id=$1::uuid AND sp.zone_id=z.id),
+      (SELECT COUNT(*) FROM incidents i WHERE i.run_id=$1::uuid AND i.zone_id=z.id)
+    FROM zones z ORDER BY z.id
+  `, r.ID)
+  if err != nil { return nil, err }
+  defer zrows.Close()
+  for zrows.Next() {
+    var z SimRunZoneSummary
+    if err := zrows.Scan(&z.ZoneID, &z.Transfers, &z.AmountUnits, &z.Spooled, &z.Incidents); err != nil { return nil, err }
+    if z.Transfers == 0 && z.Spooled == 0 && z.Incidents == 0 { continue }
+    s.Zones = append(s.Zones, z)
+  }
+  return s, zrows.Err()
+}
+
+// SimRunComparison sets two runs' summaries side by side, e.g. a baseline
+// and the same load under a chaos drill. Delta is after minus before.
+type SimRunComparison struct {
+  Before *SimRunSummary `json:"before"`
+  After *SimRunSummary `json:"after"`
+  Delta struct {
+    Transfers int64 `json:"transfers"`
+    AmountUnits int64 `json:"amount_units"`
+    TransfersPerSec float64 `json:"transfers_per_sec"`
+    SpoolFailed int64 `json:"spool_failed"`
+    Incidents map[string]int64 `json:"incidents"` // by severity
+  } `json:"delta"`
+}
+
+func (l *Ledger) CompareSimRuns(ctx context.Context, beforeID, afterID string) (*SimRunComparison, error) {
+  before, err := l.SimRunSummary(ctx, beforeID)
+  if err != nil { return nil, err }
+  after, err := l.SimRunSummary(ctx, afterID)
+  if err != nil { return nil, err }
+  c := &SimRunComparison{Before: before, After: after}
+  c.Delta.Transfers = after.Throughput.Transfers - before.Throughput.Transfers
+  c.Delta.AmountUnits = after.Throughput.AmountUnits - before.Throughput.AmountUnits
+  c.Delta.TransfersPerSec = after.Throughput.TransfersPerSec - before.Throughput.TransfersPerSec
+  c.Delta.SpoolFailed = after.Spool.Failed - before.Spool.Failed
+  c.Delta.Incidents = map[string]int64{}
+  for sev, n := range after.Incidents { c.Delta.Incidents[sev] += n }
+  for sev, n := range before.Incidents { c.Delta.Incidents[sev] -= n }
+  return c, nil
+}
@@ -0,0 +1,137 @@
+package ledger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"time-ledger-sim/go/internal/store"
+	"time-ledger-sim/go/internal/store/storetest"
+)
+
+func TestSimRuns(t *testing.T) {
+	db := storetest.OpenMultiTenant(t)
+	ctx := context.Background()
+	l := New(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	l.SetMultiTenant(true)
+	clock := NewVirtualClock()
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	clock.Freeze(start)
+	l.SetClock(clock)
+
+	// a tenant of its own, so no other test's run is active
+	id := "t-" + uuid.NewString()[:8]
+	if _, err := l.CreateTenant(ctx, CreateTenantInput{ID: id, Actor: "ops"}); err != nil {
+		t.Fatal(err)
+	}
+	ctx = store.WithTenant(ctx, id)
+	zone := "zone-run-" + id
+	if _, err := l.CreateZone(ctx, CreateZoneInput{ID: zone, Name: zone, Actor: "ops"}); err != nil {
+		t.Fatal(err)
+	}
+	transfer := func(amount int64) string {
+		t.Helper()
+		req := uuid.NewString()
+		in := CreateTransferInput{RequestID: req, PayloadHash: "h-" + req, FromAccount: zone + "-a", ToAccount: zone + "-b", AmountUnits: amount, ZoneID: zone}
+		if _, _, err := l.CreateTransfer(ctx, in); err != nil {
+			t.Fatal(err)
+		}
+		var runID *string
+		if err := db.QueryRow(ctx, `SELECT run_id::text FROM transactions WHERE request_id=$1`, req).Scan(&runID); err != nil {
+			t.Fatal(err)
+		}
+		if runID == nil {
+			return ""
+		}
+		return *runID
+	}
+	audited := func(runID, action string) bool {
+		t.Helper()
+		var n int
+		if err := db.QueryRow(ctx, `SELECT COUNT(*) FROM audit_log WHERE target_type='sim_run' AND target_id=$1 AND action=$2`, runID, action).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n == 1
+	}
+
+	if got := transfer(1); got != "" {
+		t.Fatalf("transfer before any run tagged %q", got)
+	}
+
+	run, err := l.StartSimRun(ctx, "baseline", "ops", "before the drill")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !audited(run.ID, "SIM_RUN_STARTED") {
+		t.Fatal("start not audited")
+	}
+	if _, err := l.StartSimRun(ctx, "second", "ops", ""); !IsSimRunActive(err) {
+		t.Fatalf("second active run: err = %v", err)
+	}
+	for range 3 {
+		if got := transfer(5); got != run.ID {
+			t.Fatalf("transfer run = %q, want %q", got, run.ID)
+		}
+	}
+	if _, err := l.SetZoneStatus(ctx, zone, "DOWN", "ops", "", "drill"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.SetZoneStatus(ctx, zone, "OK", "ops", "", "drill over"); err != nil {
+		t.Fatal(err)
+	}
+	clock.Freeze(start.Add(10 * time.Second))
+	stopped, err := l.StopSimRun(ctx, run.ID, "ops", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stopped.Status != "STOPPED" || stopped.StoppedAt == nil || !audited(run.ID, "SIM_RUN_STOPPED") {
+		t.Fatalf("stopped run = %+v", stopped)
+	}
+	if _, err := l.StopSimRun(ctx, run.ID, "ops", ""); !IsSimRunNotFound(err) {
+		t.Fatalf("stop twice: err = %v", err)
+	}
+	if got := transfer(1); got != "" {
+		t.Fatalf("transfer after the run tagged %q", got)
+	}
+
+	s, err := l.SimRunSummary(ctx, run.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.DurationSeconds != 10 || s.Throughput.Transfers != 3 || s.Throughput.AmountUnits != 15 || s.Throughput.TransfersPerSec != 0.3 {
+		t.Fatalf("summary = %+v", s)
+	}
+	if s.Incidents["CRITICAL"] != 1 {
+		t.Fatalf("incidents = %v", s.Incidents)
+	}
+	if len(s.Zones) != 1 || s.Zones[0] != (SimRunZoneSummary{ZoneID: zone, Transfers: 3, AmountUnits: 15, Incidents: 1}) {
+		t.Fatalf("zones = %+v", s.Zones)
+	}
+
+	after, err := l.StartSimRun(ctx, "after", "ops", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	transfer(7)
+	clock.Freeze(start.Add(20 * time.Second))
+	if _, err := l.StopSimRun(ctx, after.ID, "ops", ""); err != nil {
+		t.Fatal(err)
+	}
+	c, err := l.CompareSimRuns(ctx, run.ID, after.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Before.Run.ID != run.ID || c.After.Run.ID != after.ID {
+		t.Fatalf("compared %s with %s", c.Before.Run.ID, c.After.Run.ID)
+	}
+	if c.Delta.Transfers != -2 || c.Delta.AmountUnits != -8 || c.Delta.Incidents["CRITICAL"] != -1 {
+		t.Fatalf("delta = %+v", c.Delta)
+	}
+	if _, err := l.CompareSimRuns(ctx, run.ID, uuid.NewString()); !IsSimRunNotFound(err) {
+		t.Fatalf("compare with an unknown run: err = %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package ledger
+
+import (
+  "context"
+  "time"
+)
+
+// zoneTime applies a zone's clock skew to the sim clock reading.
+func zoneTime(now time.Time, skewMs int64) time.Time {
+  return now.Add(time.Duration(skewMs) * time.Millisecond)
+}
+
+func zoneClockSkew(ctx context.Context, q Queries, zoneID string) (int64, error) {
+  c, err := q.FindZoneControls(ctx, zoneID)
+  if err != nil || c == nil { return 0, err }
+  return c.ClockSkewMs, nil
+}
+
+type SkewAnomaly struct {
+  TransactionID string `json:"transaction_id"`
+  ZoneID string `json:"zone_id"`
+  RecordedSeq int64 `json:"recorded_seq"`
+  CreatedAt time.Time `json:"created_at"`
+  PrecedingMaxAt time.Time `json:"preceding_max_created_at"`
+  RegressionMs int64 `json:"regression_ms"`
+  ClockSkewMs int64 `json:"clock_skew_ms"`
+}
+
+type ZoneSkewSummary struct {
+  ZoneID string `json:"zone_id"`
+  ClockSkewMs int64 `json:"clock_skew_ms"`
+  Transactions int64 `json:"transactions"`
+  Anomalies int64 `json:"anomalies"`
+  MaxRegressionMs int64 `json:"max_regression_ms"`
+}
+
+type ClockSkewReport struct {
+  GeneratedAt time.Time `json:"generated_at"`
+  Transactions int64 `json:"transactions"`
+  Anomalies int64 `json:"anomalies"`
+  Zones []ZoneSkewSummary `json:"zones"`
+  Samples []SkewAnomaly `json:"samples"`
+}
+
+// skewWindow tags every transaction with the latest timestamp recorded before it;
+// a transaction is out of order when its own timestamp is earlier than that.
+const skewWindow = `
+  WITH ordered AS (
+    SELECT id::text AS id, zone_id, recorded_seq, created_at, clock_skew_ms,
+      MAX(created_at) OVER (ORDER BY recorded_seq ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING) AS prev_max
+    FROM transactions
+  ), anomalies AS (
+    SELECT *, (EXTRACT(EPOCH FROM (prev_max - created_at)) * 1000)::bigint AS regression_ms
+    FROM ordered WHERE prev_max > created_at
+  )`
+
+// ClockSkewReport reconciles transaction timestamps against recording order and
+// reports out-of-order anomalies per zone, with up to sampleLimit examples.
+func (l *Ledger) ClockSkewReport(ctx context.Context, sampleLimit int) (*ClockSkewReport, error) {
+  if sampleLimit <= 0 || sampleLimit > 500 { sampleLimit = 50 }
+  rep := &ClockSkewReport{GeneratedAt: l.clock.Now(), Zones: []ZoneSkewSummary{}, Samples: []SkewAnomaly{}}
+
+  rows, err := l.ro.Query(ctx, skewWindow+`
+    SELECT z.id, COALESCE(c.clock_skew_ms,0),
+      (SELECT COUNT(*) FROM transactions t WHERE t.zone_id=z.id),
+      COUNT(a.id), COALESCE(MAX(a.regression_ms),0)
+    FROM zones z
+    LEFT JOIN zone_controls c ON c.zone_id=z.id
+    LEFT JOIN anomalies a ON a.zone_id=z.id
+    WHERE z.retired_at IS NULL
+    GROUP BY z.id, c.clock_skew_ms
+    ORDER BY z.id
+  `)
+  if err != nil { return nil, err }
+  defer rows.Close()
+  for rows.Next() {
+    var s ZoneSkewSummary
+    if err := rows.Scan(&s.ZoneID, &s.ClockSkewMs, &s.Transactions, &s.Anomalies, &s.MaxRegressionMs); err != nil { return nil, err }
+    rep.Transactions += s.Transactions
+    rep.Anomalies += s.Anomalies
+    rep.Zones = append(rep.Zones, s)
+  }
+  if err := rows.Err(); err != nil { return nil, err }
+
+  srows, err := l.ro.Query(ctx, skewWindow+`
+    SELECT id, zone_id, recorded_seq, created_at, prev_max, regression_ms, clock_skew_ms
+    FROM anomalies ORDER BY regression_ms DESC, recorded_seq LIMIT $1
+  `, sampleLimit)
+  if err != nil { return nil, err }
+  defer srows.Close()
+  for srows.Next() {
+    var a SkewAnomaly
+    if err := srows.Scan(&a.TransactionID, &a.ZoneID, &a.RecordedSeq, &a.CreatedAt, &a.PrecedingMaxAt, &a.RegressionMs, &a.ClockSkewMs); err != nil { return nil, err }
+    rep.Samples = append(rep.Samples, a)
+  }
+  return rep, srows.Err()
+}
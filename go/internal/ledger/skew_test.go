@@ -0,0 +1,30 @@
+package ledger
+
+import (
+	"testing"
+	"time"
+)
+
+func TestZoneTimeAppliesSkew(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	if got := zoneTime(now, 0); !got.Equal(now) {
+		t.Fatalf("zero skew changed time: %v", got)
+	}
+	if got := zoneTime(now, 1500); !got.Equal(now.Add(1500 * time.Millisecond)) {
+		t.Fatalf("positive skew: %v", got)
+	}
+	if got := zoneTime(now, -60000); !got.Equal(now.Add(-time.Minute)) {
+		t.Fatalf("negative skew: %v", got)
+	}
+}
+
+func TestValidateClockSkewBounds(t *testing.T) {
+	in := SetZoneControlsInput{CrossZoneThrottle: 100, ThrottleMode: ThrottleModeHash, ClockSkewMs: maxClockSkewMs}
+	if err := in.validate(); err != nil {
+		t.Fatalf("max skew rejected: %v", err)
+	}
+	in.ClockSkewMs = -maxClockSkewMs - 1
+	if err := in.validate(); err == nil {
+		t.Fatal("expected out-of-range skew to be rejected")
+	}
+}
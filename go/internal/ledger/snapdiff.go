@@ -0,0 +1,166 @@
+package ledger
+
+import (
+  "context"
+  "encoding/json"
+  "reflect"
+  "sort"
+)
+
+type BalanceDiff struct {
+  AccountID string `json:"account_id"`
+  Before *int64 `json:"before"` // nil = account absent
+  After *int64 `json:"after"`
+  Delta int64 `json:"delta"`
+}
+
+type FieldDiff struct {
+  ZoneID string `json:"zone_id"`
+  Field string `json:"field"`
+  Before any `json:"before"`
+  After any `json:"after"`
+}
+
+type IncidentCounts struct {
+  Total int64 `json:"total"`
+  Open int64 `json:"open"` // not RESOLVED
+}
+
+type IncidentCountDiff struct {
+  ZoneID string `json:"zone_id"`
+  Before IncidentCounts `json:"before"`
+  After IncidentCounts `json:"after"`
+}
+
+type SnapshotDiff struct {
+  Identical bool `json:"identical"`
+  Zones []FieldDiff `json:"zones"`
+  Controls []FieldDiff `json:"controls"`
+  Balances []BalanceDiff `json:"balances"`
+  Incidents []IncidentCountDiff `json:"incidents"`
+}
+
+// DiffWithCurrent compares a snapshot against the current state.
+func (l *Ledger) DiffWithCurrent(ctx context.Context, before map[string]any) (*SnapshotDiff, error) {
+  cur, err := l.Snapshot(ctx, SnapshotOptions{})
+  if err != nil { return nil, err }
+  // normalize through JSON so numbers compare like an uploaded snapshot's
+  b, err := json.Marshal(cur)
+  if err != nil { return nil, err }
+  var after map[string]any
+  if err := json.Unmarshal(b, &after); err != nil { return nil, err }
+  return DiffSnapshots(before, after), nil
+}
+
+// DiffSnapshots reports differences in zone status, zone controls, balances, and
+// incident counts between two decoded snapshots. Control fields missing from either
+// side (older snapshot formats) are not compared.
+func DiffSnapshots(before, after map[string]any) *SnapshotDiff {
+  d := &SnapshotDiff{Zones: []FieldDiff{}, Controls: []FieldDiff{}, Balances: []BalanceDiff{}, Incidents: []IncidentCountDiff{}}
+
+  d.Zones = diffKeyedRows(snapshotRows(before, "zones"), snapshotRows(after, "zones"), "id", map[string]bool{"status": true})
+  d.Controls = diffKeyedRows(snapshotRows(before, "zone_controls"), snapshotRows(after, "zone_controls"), "zone_id", nil)
+
+  bb, ab := snapshotBalances(before), snapshotBalances(after)
+  for _, id := range unionKeys(bb, ab) {
+    b, bok := bb[id]
+    a, aok := ab[id]
+    if bok && aok && b == a { continue }
+    bd := BalanceDiff{AccountID: id}
+    if bok { bd.Before = &b }
+    if aok { bd.After = &a }
+    bd.Delta = a - b
+    d.Balances = append(d.Balances, bd)
+  }
+
+  bi, ai := snapshotIncidentCounts(before), snapshotIncidentCounts(after)
+  for _, z := range unionKeys(bi, ai) {
+    if bi[z] == ai[z] { continue }
+    d.Incidents = append(d.Incidents, IncidentCountDiff{ZoneID: z, Before: bi[z], After: ai[z]})
+  }
+
+  d.Identical = len(d.Zones) == 0 && len(d.Controls) == 0 && len(d.Balances) == 0 && len(d.Incidents) == 0
+  return d
+}
+
+func snapshotRows(snap map[string]any, section string) []map[string]any {
+  items, _ := snap[section].([]any)
+  out := make([]map[string]any, 0, len(items))
+  for _, it := range items {
+    if m, ok := it.(map[string]any); ok { out = append(out, m) }
+  }
+  return out
+}
+
+// diffKeyedRows compares rows matched on key. With only set, just those fields are
+// compared; otherwise every field except the key and updated_at.
+func diffKeyedRows(before, after []map[string]any, key string, only map[string]bool) []FieldDiff {
+  index := func(rows []map[string]any) map[string]map[string]any {
+    out := map[string]map[string]any{}
+    for _, r := range rows {
+      if k, _ := r[key].(string); k != "" { out[k] = r }
+    }
+    return out
+  }
+  bi, ai := index(before), index(after)
+  out := []FieldDiff{}
+  for _, k := range unionKeys(bi, ai) {
+    b, a := bi[k], ai[k]
+    if b == nil || a == nil {
+      out = append(out, FieldDiff{ZoneID: k, Field: "*", Before: b != nil, After: a != nil})
+      continue
+    }
+    fields := map[string]bool{}
+    for f := range b { fields[f] = true }
+    for f := range a { fields[f] = true }
+    for _, f := range sortedKeys(fields) {
+      if f == key || f == "updated_at" { continue }
+      if only != nil && !only[f] { continue }
+      bv, bok := b[f]
+      av, aok := a[f]
+      if !bok || !aok { continue }
+      if !reflect.DeepEqual(bv, av) {
+        out = append(out, FieldDiff{ZoneID: k, Field: f, Before: bv, After: av})
+      }
+    }
+  }
+  return out
+}
+
+func snapshotBalances(snap map[string]any) map[string]int64 {
+  out := map[string]int64{}
+  for _, r := range snapshotRows(snap, "accounts") {
+    id, _ := r["id"].(string)
+    if id == "" { continue }
+    bal, _ := r["balance_units"].(float64)
+    out[id] = int64(bal)
+  }
+  return out
+}
+
+func snapshotIncidentCounts(snap map[string]any) map[string]IncidentCounts {
+  out := map[string]IncidentCounts{}
+  for _, r := range snapshotRows(snap, "incidents") {
+    z, _ := r["zone_id"].(string)
+    if z == "" { continue }
+    c := out[z]
+    c.Total++
+    if st, _ := r["status"].(string); st != "RESOLVED" { c.Open++ }
+    out[z] = c
+  }
+  return out
+}
+
+func unionKeys[V any](a, b map[string]V) []string {
+  set := map[string]bool{}
+  for k := range a { set[k] = true }
+  for k := range b { set[k] = true }
+  return sortedKeys(set)
+}
+
+func sortedKeys(m map[string]bool) []string {
+  out := make([]string, 0, len(m))
+  for k := range m { out = append(out, k) }
+  sort.Strings(out)
+  return out
+}
@@ -0,0 +1,67 @@
+package ledger
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func decodeSnap(t *testing.T, s string) map[string]any {
+	t.Helper()
+	var m map[string]any
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestDiffSnapshotsIdentical(t *testing.T) {
+	s := `{"zones":[{"id":"zone-eu","status":"OK","updated_at":"a"}],
+	  "zone_controls":[{"zone_id":"zone-eu","writes_blocked":false,"updated_at":"x"}],
+	  "accounts":[{"id":"acct-a","zone_id":"zone-eu","balance_units":10}],
+	  "incidents":[{"zone_id":"zone-eu","status":"OPEN"}]}`
+	other := `{"zones":[{"id":"zone-eu","status":"OK","updated_at":"b"}],
+	  "zone_controls":[{"zone_id":"zone-eu","writes_blocked":false,"updated_at":"y"}],
+	  "accounts":[{"id":"acct-a","zone_id":"zone-eu","balance_units":10}],
+	  "incidents":[{"zone_id":"zone-eu","status":"OPEN"}]}`
+	d := DiffSnapshots(decodeSnap(t, s), decodeSnap(t, other))
+	if !d.Identical {
+		t.Fatalf("expected identical, got %+v", d)
+	}
+}
+
+func TestDiffSnapshotsReportsChanges(t *testing.T) {
+	before := decodeSnap(t, `{"zones":[{"id":"zone-eu","status":"OK"}],
+	  "zone_controls":[{"zone_id":"zone-eu","writes_blocked":false,"cross_zone_throttle":100}],
+	  "accounts":[{"id":"acct-a","balance_units":10},{"id":"acct-b","balance_units":-10}],
+	  "incidents":[]}`)
+	after := decodeSnap(t, `{"zones":[{"id":"zone-eu","status":"DOWN"}],
+	  "zone_controls":[{"zone_id":"zone-eu","writes_blocked":true,"cross_zone_throttle":100,"clock_skew_ms":0}],
+	  "accounts":[{"id":"acct-a","balance_units":4},{"id":"acct-c","balance_units":6}],
+	  "incidents":[{"zone_id":"zone-eu","status":"OPEN"},{"zone_id":"zone-eu","status":"RESOLVED"}]}`)
+	d := DiffSnapshots(before, after)
+	if d.Identical {
+		t.Fatal("expected differences")
+	}
+	if len(d.Zones) != 1 || d.Zones[0].Field != "status" || d.Zones[0].After != "DOWN" {
+		t.Fatalf("zones: %+v", d.Zones)
+	}
+	// clock_skew_ms only exists on one side and is not compared
+	if len(d.Controls) != 1 || d.Controls[0].Field != "writes_blocked" {
+		t.Fatalf("controls: %+v", d.Controls)
+	}
+	if len(d.Balances) != 3 {
+		t.Fatalf("balances: %+v", d.Balances)
+	}
+	if a := d.Balances[0]; a.AccountID != "acct-a" || a.Delta != -6 {
+		t.Fatalf("acct-a: %+v", a)
+	}
+	if b := d.Balances[1]; b.AccountID != "acct-b" || b.After != nil || b.Delta != 10 {
+		t.Fatalf("acct-b: %+v", b)
+	}
+	if c := d.Balances[2]; c.AccountID != "acct-c" || c.Before != nil || c.Delta != 6 {
+		t.Fatalf("acct-c: %+v", c)
+	}
+	if len(d.Incidents) != 1 || d.Incidents[0].After.Total != 2 || d.Incidents[0].After.Open != 1 {
+		t.Fatalf("incidents: %+v", d.Incidents)
+	}
+}
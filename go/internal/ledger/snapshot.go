@@ -0,0 +1,373 @@
+package ledger
+
+import (
+  "bufio"
+  "context"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "io"
+  "strconv"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+)
+
+const snapshotPageSize = 1000
+
+// snapshotSections is the order sections are written and restored in
+// (zones before anything that references them).
+var snapshotSections = []string{"zones", "zone_controls", "accounts", "incidents", "spooled_transfers", "audit_log"}
+
+// fullSnapshotSections adds transaction history and messaging state; transactions
+// come before incidents so related_txn_id references resolve on restore.
+var fullSnapshotSections = []string{"zones", "zone_controls", "accounts", "transactions", "postings", "incidents", "spooled_transfers", "audit_log", "outbox_events", "inbox_events"}
+
+type SnapshotOptions struct {
+  Full bool // include transactions, postings, outbox and inbox
+}
+
+func (o SnapshotOptions) sections() []string {
+  if o.Full { return fullSnapshotSections }
+  return snapshotSections
+}
+
+var ErrBadSnapshot = errors.New("bad snapshot")
+
+func IsBadSnapshot(err error) bool { return errors.Is(err, ErrBadSnapshot) }
+
+// SnapshotEmitter receives snapshot rows one at a time, section by section.
+type SnapshotEmitter func(section string, row map[string]any) error
+
+// snapshotHeader describes the snapshot. A snapshot taken under a redaction
+// policy is marked "redacted", and restore refuses to write its metadata back.
+func (l *Ledger) snapshotHeader(opts SnapshotOptions, redact *RedactionPolicy) map[string]any {
+  h := map[string]any{
+    "version": snapshotVersion,
+    "created_at": l.clock.Now().UTC().Format(time.RFC3339Nano),
+    "note": "Restore resets transaction history; balances/incidents/controls/spool/audit are restored.",
+  }
+  if opts.Full {
+    h["full"] = true
+    h["note"] = "Full snapshot: restore keeps transaction history and idempotency keys and rebuilds balances from postings."
+  }
+  if redact != nil { h["redacted"] = true }
+  return h
+}
+
+// Snapshot returns the whole snapshot as one JSON-able map. Prefer
+// WriteSnapshotNDJSON for large datasets.
+func (l *Ledger) Snapshot(ctx context.Context, opts SnapshotOptions) (map[string]any, error) {
+  redact := l.redaction.Load()
+  snap := l.snapshotHeader(opts, redact)
+  for _, s := range opts.sections() { snap[s] = []any{} }
+  err := l.streamSnapshot(ctx, opts, redact, func(section string, row map[string]any) error {
+    snap[section] = append(snap[section].([]any), row)
+    return nil
+  })
+  if err != nil { return nil, err }
+  return snap, nil
+}
+
+// snapshotLine is one NDJSON record: {"section":"accounts","row":{...}}.
+// The first line is the header section.
+type snapshotLine struct {
+  Section string `json:"section"`
+  Row map[string]any `json:"row"`
+}
+
+// WriteSnapshotNDJSON streams the snapshot as NDJSON without holding it in memory.
+func (l *Ledger) WriteSnapshotNDJSON(ctx context.Context, w io.Writer, opts SnapshotOptions) error {
+  bw := bufio.NewWriter(w)
+  enc := json.NewEncoder(bw)
+  redact := l.redaction.Load()
+  header := l.snapshotHeader(opts, redact)
+  header["format"] = "ndjson"
+  if err := enc.Encode(snapshotLine{Section: "header", Row: header}); err != nil { return err }
+  err := l.streamSnapshot(ctx, opts, redact, func(section string, row map[string]any) error {
+    return enc.Encode(snapshotLine{Section: section, Row: row})
+  })
+  if err != nil { return err }
+  return bw.Flush()
+}
+
+// StreamSnapshot reads every section in one repeatable-read transaction using
+// keyset pagination, so the result is consistent and memory use is bounded.
+// Metadata is redacted by the ledger's redaction policy.
+func (l *Ledger) StreamSnapshot(ctx context.Context, opts SnapshotOptions, emit SnapshotEmitter) error {
+  return l.streamSnapshot(ctx, opts, l.redaction.Load(), emit)
+}
+
+func (l *Ledger) streamSnapshot(ctx context.Context, opts SnapshotOptions, redact *RedactionPolicy, emit SnapshotEmitter) error {
+  tx, err := l.ro.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+  if err != nil { return err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  for _, s := range opts.sections() {
+    section := s
+    q, scan := snapshotQuery(section)
+    err := keysetScan(ctx, tx, q, scan, func(row map[string]any) error {
+      if m, ok := row["metadata"]; ok { row["metadata"] = redact.RedactValue(m) }
+      return emit(section, row)
+    })
+    if err != nil { return fmt.Errorf("snapshot %s: %w", section, err) }
+  }
+  return nil
+}
+
+type snapshotScanner func(rows pgx.Rows) (key string, row map[string]any, err error)
+
+// keysetScan pages through query, which takes ($1 = last key, $2 = page size)
+// and must order by the key returned from scan.
+func keysetScan(ctx context.Context, tx pgx.Tx, query string, scan snapshotScanner, emit func(map[string]any) error) error {
+  after := ""
+  for {
+    rows, err := tx.Query(ctx, query, after, snapshotPageSize)
+    if err != nil { return err }
+    n := 0
+    for rows.Next() {
+      key, row, err := scan(rows)
+      if err == nil { err = emit(row) }
+      if err != nil { rows.Close(); return err }
+      after = key
+      n++
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil { return err }
+    if n < snapshotPageSize { return nil }
+  }
+}
+
+// uuidAfter turns the keyset cursor into a uuid lower bound.
+const uuidAfter = `COALESCE(NULLIF($1,''),'00000000-0000-0000-0000-000000000000')::uuid`
+
+func fmtTime(t time.Time) string { return t.UTC().Format(time.RFC3339Nano) }
+
+func snapshotQuery(section string) (string, snapshotScanner) {
+  switch section {
+  case "zones":
+    return `SELECT id,name,status,tags,updated_at FROM zones WHERE retired_at IS NULL AND id > $1 ORDER BY id LIMIT $2`,
+      func(rows pgx.Rows) (string, map[string]any, error) {
+        var z Zone
+        if err := rows.Scan(&z.ID, &z.Name, &z.Status, &z.Tags, &z.UpdatedAt); err != nil { return "", nil, err }
+        return z.ID, map[string]any{"id": z.ID, "name": z.Name, "status": z.Status, "tags": z.Tags, "updated_at": fmtTime(z.UpdatedAt)}, nil
+      }
+
+  case "zone_controls":
+    return `SELECT ` + zoneControlsCols + ` FROM zone_controls WHERE zone_id > $1 ORDER BY zone_id LIMIT $2`,
+      func(rows pgx.Rows) (string, map[string]any, error) {
+        c, err := scanZoneControls(rows)
+        if err != nil { return "", nil, err }
+        return c.ZoneID, map[string]any{
+          "zone_id": c.ZoneID,
+          "writes_blocked": c.WritesBlocked,
+          "cross_zone_throttle": c.CrossZoneThrottle,
+          "spool_enabled": c.SpoolEnabled,
+          "inject_latency_ms": c.InjectLatencyMs,
+          "inject_jitter_ms": c.InjectJitterMs,
+          "error_rate_percent": c.ErrorRatePercent,
+          "throttle_mode": c.ThrottleMode,
+          "rate_limit_per_sec": c.RateLimitPerSec,
+          "rate_limit_burst": c.RateLimitBurst,
+          "clock_skew_ms": c.ClockSkewMs,
+          "blocked_actions": c.BlockedActions,
+          "blocked_delay_ms": c.BlockedDelayMs,
+          "min_amount_units": c.MinAmountUnits,
+          "max_amount_units": c.MaxAmountUnits,
+          "daily_account_limit_units": c.DailyAccountLimitUnits,
+          "day_cutover_minute": c.DayCutoverMinute,
+          "updated_at": fmtTime(c.UpdatedAt),
+        }, nil
+      }
+
+  case "accounts":
+    return `
+      SELECT a.id, a.zone_id, COALESCE(b.balance_units,0) as balance_units
+      FROM accounts a
+      LEFT JOIN balances b ON b.account_id=a.id
+      WHERE a.id > $1
+      ORDER BY a.id
+      LIMIT $2`,
+      func(rows pgx.Rows) (string, map[string]any, error) {
+        var id, zid string
+        var bal int64
+        if err := rows.Scan(&id, &zid, &bal); err != nil { return "", nil, err }
+        return id, map[string]any{"id": id, "zone_id": zid, "balance_units": bal}, nil
+      }
+
+  case "incidents":
+    return `
+      SELECT id::text, zone_id, related_txn_id::text, severity, status, title, details, detected_at
+      FROM incidents
+      WHERE id > ` + uuidAfter + `
+      ORDER BY id
+      LIMIT $2`,
+      func(rows pgx.Rows) (string, map[string]any, error) {
+        var id, zid, sev, st, title string
+        var related *string
+        var detailsBytes []byte
+        var dt time.Time
+        if err := rows.Scan(&id, &zid, &related, &sev, &st, &title, &detailsBytes, &dt); err != nil { return "", nil, err }
+        var d any
+        _ = json.Unmarshal(detailsBytes, &d)
+        return id, map[string]any{
+          "id": id,
+          "zone_id": zid,
+          "related_txn_id": related,
+          "severity": sev,
+          "status": st,
+          "title": title,
+          "details": d,
+          "detected_at": fmtTime(dt),
+        }, nil
+      }
+
+  case "spooled_transfers":
+    return `
+      SELECT id::text, request_id, payload_hash, from_account, to_account, amount_units, zone_id, metadata, status, fail_reason, created_at, updated_at, applied_at, type
+      FROM spooled_transfers
+      WHERE id > ` + uuidAfter + `
+      ORDER BY id
+      LIMIT $2`,
+      func(rows pgx.Rows) (string, map[string]any, error) {
+        var id, req, ph, from, to, zid, st, typ string
+        var amt int64
+        var meta []byte
+        var fail *string
+        var ca, ua time.Time
+        var aa *time.Time
+        if err := rows.Scan(&id, &req, &ph, &from, &to, &amt, &zid, &meta, &st, &fail, &ca, &ua, &aa, &typ); err != nil { return "", nil, err }
+        var m any
+        _ = json.Unmarshal(meta, &m)
+        item := map[string]any{
+          "id": id,
+          "request_id": req,
+          "payload_hash": ph,
+          "from_account": from,
+          "to_account": to,
+          "amount_units": amt,
+          "zone_id": zid,
+          "type": typ,
+          "metadata": m,
+          "status": st,
+          "fail_reason": fail,
+          "created_at": fmtTime(ca),
+          "updated_at": fmtTime(ua),
+          "applied_at": nil,
+        }
+        if aa != nil { item["applied_at"] = fmtTime(*aa) }
+        return id, item, nil
+      }
+
+  case "audit_log":
+    return `
+      SELECT id::text, actor, action, target_type, target_id, reason, details, created_at
+      FROM audit_log
+      WHERE id > ` + uuidAfter + `
+      ORDER BY id
+      LIMIT $2`,
+      func(rows pgx.Rows) (string, map[string]any, error) {
+        var id, actor, action, tt, tid string
+        var reason *string
+        var details []byte
+        var ca time.Time
+        if err := rows.Scan(&id, &actor, &action, &tt, &tid, &reason, &details, &ca); err != nil { return "", nil, err }
+        var d any
+        _ = json.Unmarshal(details, &d)
+        return id, map[string]any{
+          "id": id,
+          "actor": actor,
+          "action": action,
+          "target_type": tt,
+          "target_id": tid,
+          "reason": reason,
+          "details": d,
+          "created_at": fmtTime(ca),
+        }, nil
+      }
+
+  case "transactions":
+    return `
+      SELECT recorded_seq, id::text, request_id, payload_hash, from_account, to_account, amount_units, zone_id, metadata,
+        created_at, clock_skew_ms, run_id::text, zone_seq, type
+      FROM transactions
+      WHERE recorded_seq > COALESCE(NULLIF($1,''),'0')::bigint
+      ORDER BY recorded_seq
+      LIMIT $2`,
+      func(rows pgx.Rows) (string, map[string]any, error) {
+        var seq, amt, skew, zoneSeq int64
+        var id, req, ph, from, to, zid, typ string
+        var meta []byte
+        var ca time.Time
+        var runID *string
+        if err := rows.Scan(&seq, &id, &req, &ph, &from, &to, &amt, &zid, &meta, &ca, &skew, &runID, &zoneSeq, &typ); err != nil { return "", nil, err }
+        var m any
+        _ = json.Unmarshal(meta, &m)
+        return strconv.FormatInt(seq, 10), map[string]any{
+          "id": id,
+          "request_id": req,
+          "payload_hash": ph,
+          "from_account": from,
+          "to_account": to,
+          "amount_units": amt,
+          "zone_id": zid,
+          "metadata": m,
+          "created_at": fmtTime(ca),
+          "clock_skew_ms": skew,
+          "run_id": runID,
+          "zone_seq": zoneSeq,
+          "type": typ,
+        }, nil
+      }
+
+  case "postings":
+    return `
+      SELECT id::text, txn_id::text, account_id, direction, amount_units, created_at
+      FROM postings
+      WHERE id > ` + uuidAfter + `
+      ORDER BY id
+      LIMIT $2`,
+      func(rows pgx.Rows) (string, map[string]any, error) {
+        var id, txn, acct, dir string
+        var amt int64
+        var ca time.Time
+        if err := rows.Scan(&id, &txn, &acct, &dir, &amt, &ca); err != nil { return "", nil, err }
+        return id, map[string]any{"id": id, "txn_id": txn, "account_id": acct, "direction": dir, "amount_units": amt, "created_at": fmtTime(ca)}, nil
+      }
+
+  case "outbox_events":
+    return `
+      SELECT id::text, event_type, aggregate_type, aggregate_id, payload, created_at, published_at
+      FROM outbox_events
+      WHERE id > ` + uuidAfter + `
+      ORDER BY id
+      LIMIT $2`,
+      func(rows pgx.Rows) (string, map[string]any, error) {
+        var id, et, at, aid string
+        var payload []byte
+        var ca time.Time
+        var pa *time.Time
+        if err := rows.Scan(&id, &et, &at, &aid, &payload, &ca, &pa); err != nil { return "", nil, err }
+        var p any
+        _ = json.Unmarshal(payload, &p)
+        item := map[string]any{"id": id, "event_type": et, "aggregate_type": at, "aggregate_id": aid, "payload": p, "created_at": fmtTime(ca), "published_at": nil}
+        if pa != nil { item["published_at"] = fmtTime(*pa) }
+        return id, item, nil
+      }
+
+  case "inbox_events":
+    return `
+      SELECT consumer || '/' || event_id::text AS k, consumer, event_id::text, processed_at
+      FROM inbox_events
+      WHERE consumer || '/' || event_id::text > $1
+      ORDER BY k
+      LIMIT $2`,
+      func(rows pgx.Rows) (string, map[string]any, error) {
+        var k, consumer, eid string
+        var pa time.Time
+        if err := rows.Scan(&k, &consumer, &eid, &pa); err != nil { return "", nil, err }
+        return k, map[string]any{"consumer": consumer, "event_id": eid, "processed_at": fmtTime(pa)}, nil
+      }
+  }
+  panic("unknown snapshot section " + section)
+}
@@ -0,0 +1,65 @@
+package ledger
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestSnapshotQueryCoversAllSections(t *testing.T) {
+	for _, s := range fullSnapshotSections {
+		q, scan := snapshotQuery(s)
+		if q == "" || scan == nil {
+			t.Fatalf("section %s has no query", s)
+		}
+	}
+}
+
+func TestSnapshotLineRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	_ = enc.Encode(snapshotLine{Section: "accounts", Row: map[string]any{"id": "acct-a", "balance_units": 5}})
+	_ = enc.Encode(snapshotLine{Section: "zones", Row: map[string]any{"id": "zone-eu"}})
+
+	dec := json.NewDecoder(&buf)
+	var got []snapshotLine
+	for dec.More() {
+		var l snapshotLine
+		if err := dec.Decode(&l); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, l)
+	}
+	if len(got) != 2 || got[0].Section != "accounts" || got[0].Row["balance_units"].(float64) != 5 || got[1].Row["id"] != "zone-eu" {
+		t.Fatalf("unexpected lines %+v", got)
+	}
+}
+
+func TestFullSnapshotSectionsSuperset(t *testing.T) {
+	full := map[string]int{}
+	for i, s := range fullSnapshotSections {
+		full[s] = i
+	}
+	for _, s := range snapshotSections {
+		if _, ok := full[s]; !ok {
+			t.Fatalf("full snapshot missing section %s", s)
+		}
+	}
+	if full["transactions"] > full["incidents"] || full["postings"] < full["transactions"] || full["accounts"] > full["postings"] {
+		t.Fatal("full snapshot sections out of dependency order")
+	}
+}
+
+func TestSnapshotHeaderMarksRedaction(t *testing.T) {
+	l := &Ledger{clock: NewVirtualClock()}
+	if _, ok := l.snapshotHeader(SnapshotOptions{}, nil)["redacted"]; ok {
+		t.Fatal("unredacted snapshot marked redacted")
+	}
+	p, err := NewRedactionPolicy("email")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.snapshotHeader(SnapshotOptions{Full: true}, p)["redacted"] != true {
+		t.Fatal("redacted snapshot not marked")
+	}
+}
@@ -0,0 +1,105 @@
+package ledger
+
+import (
+  "fmt"
+  "strconv"
+  "strings"
+)
+
+// Snapshot format history:
+//   v1  schema 0001: zones, accounts, incidents, audit_log. No version field was
+//       written by the earliest builds, so a missing version reads as v1.
+//   v2  schema 0002: adds zone_controls (writes_blocked, cross_zone_throttle,
+//       spool_enabled) and spooled_transfers.
+//   v3  adds the extended zone controls (latency/error injection, throttle mode,
+//       rate limit, clock skew), run_id tagging, and optional full history.
+const snapshotVersion = "v3"
+
+// snapshotUpgrade rewrites one row written in version from so it reads as version to.
+// A nil row func means the step only adds sections older snapshots do not have.
+type snapshotUpgrade struct {
+  from, to string
+  row func(section string, m map[string]any) map[string]any
+}
+
+// snapshotUpgrades is the upgrade path, oldest first. Add a step here (and bump
+// snapshotVersion) whenever the row format changes.
+var snapshotUpgrades = []snapshotUpgrade{
+  {from: "v1", to: "v2"},
+  {from: "v2", to: "v3", row: upgradeSnapshotV2toV3},
+}
+
+// upgradeSnapshotV2toV3 spells out the defaults for controls v2 did not carry, so a
+// v2 restore resets them explicitly instead of relying on whatever the row omits.
+func upgradeSnapshotV2toV3(section string, m map[string]any) map[string]any {
+  if section != "zone_controls" { return m }
+  out := copyRow(m)
+  defaults := map[string]any{
+    "inject_latency_ms": float64(0),
+    "inject_jitter_ms": float64(0),
+    "error_rate_percent": float64(0),
+    "throttle_mode": ThrottleModeHash,
+    "rate_limit_per_sec": float64(0),
+    "rate_limit_burst": float64(0),
+    "clock_skew_ms": float64(0),
+  }
+  for k, v := range defaults {
+    if _, ok := out[k]; !ok { out[k] = v }
+  }
+  return out
+}
+
+func snapshotVersionNumber(v string) (int, bool) {
+  n, err := strconv.Atoi(strings.TrimPrefix(v, "v"))
+  if err != nil || !strings.HasPrefix(v, "v") { return 0, false }
+  return n, true
+}
+
+// snapshotUpgrader returns a row transformer taking rows of the given header
+// version to snapshotVersion.
+func snapshotUpgrader(version string) (func(section string, m map[string]any) map[string]any, error) {
+  if version == "" { version = "v1" }
+  from, ok := snapshotVersionNumber(version)
+  cur, _ := snapshotVersionNumber(snapshotVersion)
+  if !ok || from < 1 || from > cur {
+    return nil, fmt.Errorf("%w: unsupported version %q (this build reads v1..%s)", ErrBadSnapshot, version, snapshotVersion)
+  }
+  steps := []func(string, map[string]any) map[string]any{}
+  for _, u := range snapshotUpgrades {
+    n, _ := snapshotVersionNumber(u.from)
+    if n >= from && u.row != nil { steps = append(steps, u.row) }
+  }
+  return func(section string, m map[string]any) map[string]any {
+    if m == nil { return nil }
+    for _, step := range steps { m = step(section, m) }
+    return m
+  }, nil
+}
+
+// UpgradeSnapshot returns a copy of an in-memory snapshot rewritten to the current
+// format version. The input is not modified.
+func UpgradeSnapshot(snap map[string]any) (map[string]any, error) {
+  version, _ := snap["version"].(string)
+  upgrade, err := snapshotUpgrader(version)
+  if err != nil { return nil, err }
+  out := copyRow(snap)
+  out["version"] = snapshotVersion
+  if version != snapshotVersion { out["upgraded_from"] = versionOrV1(version) }
+  for _, s := range fullSnapshotSections {
+    items, ok := snap[s].([]any)
+    if !ok { continue }
+    rows := make([]any, 0, len(items))
+    for _, it := range items {
+      m, isRow := it.(map[string]any)
+      if !isRow { rows = append(rows, it); continue }
+      rows = append(rows, upgrade(s, m))
+    }
+    out[s] = rows
+  }
+  return out, nil
+}
+
+func versionOrV1(v string) string {
+  if v == "" { return "v1" }
+  return v
+}
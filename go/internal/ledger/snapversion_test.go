@@ -0,0 +1,67 @@
+package ledger
+
+import "testing"
+
+func TestSnapshotUpgraderV2FillsControls(t *testing.T) {
+	up, err := snapshotUpgrader("v2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := map[string]any{"zone_id": "zone-eu", "writes_blocked": true, "cross_zone_throttle": float64(50)}
+	out := up("zone_controls", in)
+	if out["throttle_mode"] != ThrottleModeHash || out["clock_skew_ms"] != float64(0) {
+		t.Fatalf("expected v3 defaults, got %v", out)
+	}
+	if out["cross_zone_throttle"] != float64(50) || out["writes_blocked"] != true {
+		t.Fatalf("existing fields changed: %v", out)
+	}
+	if _, ok := in["throttle_mode"]; ok {
+		t.Fatal("upgrade must not modify the input row")
+	}
+
+	acct := map[string]any{"id": "a", "zone_id": "zone-eu"}
+	if got := up("accounts", acct); len(got) != 2 {
+		t.Fatalf("accounts should pass through unchanged, got %v", got)
+	}
+}
+
+func TestSnapshotUpgraderCurrentKeepsRows(t *testing.T) {
+	up, err := snapshotUpgrader(snapshotVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := map[string]any{"zone_id": "zone-eu", "throttle_mode": ThrottleModeRate}
+	if out := up("zone_controls", in); len(out) != 2 || out["throttle_mode"] != ThrottleModeRate {
+		t.Fatalf("current rows must not be rewritten, got %v", out)
+	}
+}
+
+func TestSnapshotUpgraderRejectsUnknown(t *testing.T) {
+	for _, v := range []string{"v99", "v0", "latest", "2"} {
+		if _, err := snapshotUpgrader(v); !IsBadSnapshot(err) {
+			t.Fatalf("%q: expected bad snapshot, got %v", v, err)
+		}
+	}
+	if _, err := snapshotUpgrader(""); err != nil {
+		t.Fatalf("missing version should read as v1: %v", err)
+	}
+}
+
+func TestUpgradeSnapshot(t *testing.T) {
+	snap := decodeSnap(t, `{"version":"v2","zones":[{"id":"zone-eu","status":"OK"}],
+	  "zone_controls":[{"zone_id":"zone-eu","writes_blocked":false,"cross_zone_throttle":100,"spool_enabled":true}]}`)
+	out, err := UpgradeSnapshot(snap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["version"] != snapshotVersion || out["upgraded_from"] != "v2" {
+		t.Fatalf("unexpected header %v", out)
+	}
+	ctrl := out["zone_controls"].([]any)[0].(map[string]any)
+	if ctrl["inject_latency_ms"] != float64(0) || ctrl["spool_enabled"] != true {
+		t.Fatalf("unexpected controls %v", ctrl)
+	}
+	if snap["version"] != "v2" {
+		t.Fatal("input snapshot modified")
+	}
+}
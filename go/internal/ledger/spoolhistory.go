@@ -0,0 +1,135 @@
+package ledger
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "log/slog"
+  "sync/atomic"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+)
+
+// SpoolDepthSample is a zone's spool depth at one sampler pass.
+type SpoolDepthSample struct {
+  SampledAt time.Time `json:"sampled_at"`
+  Pending int64 `json:"pending"`
+  Failed int64 `json:"failed"`
+  // OldestPendingAt is when the oldest PENDING entry was spooled; nil when none is.
+  OldestPendingAt *time.Time `json:"oldest_pending_at"`
+}
+
+// SpoolDepthHistory is a zone's samples, oldest first.
+type SpoolDepthHistory struct {
+  ZoneID string `json:"zone_id"`
+  From time.Time `json:"from"`
+  To time.Time `json:"to"`
+  Samples []SpoolDepthSample `json:"samples"`
+}
+
+// spoolSampleRetention is how long samples are kept, on the sim clock.
+const spoolSampleRetention = 7 * 24 * time.Hour
+
+// SampleSpoolDepth records the spool depth of every active zone, stamped with
+// the sim clock, and prunes samples past the retention. It returns the number
+// of zones sampled.
+func (l *Ledger) SampleSpoolDepth(ctx context.Context) (int, error) {
+  now := l.clock.Now()
+  tx, err := l.db.Begin(ctx)
+  if err != nil { return 0, err }
+  defer func(){ _ = tx.Rollback(ctx) }()
+
+  // a clock moved back can land on an existing sample; keep the first
+  ct, err := tx.Exec(ctx, `
+    INSERT INTO spool_depth_samples(zone_id,sampled_at,pending,failed,oldest_pending_at)
+    SELECT z.id, $1,
+      COUNT(s.id) FILTER (WHERE s.status='PENDING'),
+      COUNT(s.id) FILTER (WHERE s.status='FAILED'),
+      MIN(s.created_at) FILTER (WHERE s.status='PENDING')
+    FROM zones z
+    LEFT JOIN spooled_transfers s ON s.zone_id = z.id AND s.status IN ('PENDING','FAILED')
+    WHERE z.retired_at IS NULL
+    GROUP BY z.id
+    ON CONFLICT (zone_id, sampled_at) DO NOTHING
+  `, now)
+  if err != nil { return 0, err }
+  if _, err := tx.Exec(ctx, `DELETE FROM spool_depth_samples WHERE sampled_at < $1`, now.Add(-spoolSampleRetention)); err != nil { return 0, err }
+
+  if err := tx.Commit(ctx); err != nil { return 0, err }
+  return int(ct.RowsAffected()), nil
+}
+
+// GetSpoolDepthHistory returns the zone's samples taken from from to to
+// (both inclusive), oldest first. A zero to is the sim clock's now and a zero
+// from is a day before to. Retired zones keep their samples until pruned.
+func (l *Ledger) GetSpoolDepthHistory(ctx context.Context, zoneID string, from, to time.Time, limit int) (*SpoolDepthHistory, error) {
+  if to.IsZero() { to = l.clock.Now() }
+  if from.IsZero() { from = to.Add(-24 * time.Hour) }
+  if from.After(to) { return nil, fmt.Errorf("from must not be after to") }
+  if limit <= 0 || limit > 10000 { limit = 1000 }
+  var exists bool
+  if err := l.ro.QueryRow(ctx, `SELECT true FROM zones WHERE id=$1`, zoneID).Scan(&exists); err != nil {
+    if errors.Is(err, pgx.ErrNoRows) { return nil, ErrZoneNotFound }
+    return nil, err
+  }
+
+  rows, err := l.ro.Query(ctx, `
+    SELECT sampled_at, pending, failed, oldest_pending_at
+    FROM spool_depth_samples
+    WHERE zone_id=$1 AND sampled_at BETWEEN $2 AND $3
+    ORDER BY sampled_at
+    LIMIT $4
+  `, zoneID, from, to, limit)
+  if err != nil { return nil, err }
+  defer rows.Close()
+
+  h := &SpoolDepthHistory{ZoneID: zoneID, From: from, To: to, Samples: []SpoolDepthSample{}}
+  for rows.Next() {
+    var s SpoolDepthSample
+    if err := rows.Scan(&s.SampledAt, &s.Pending, &s.Failed, &s.OldestPendingAt); err != nil { return nil, err }
+    h.Samples = append(h.Samples, s)
+  }
+  return h, rows.Err()
+}
+
+// SpoolSampler runs SampleSpoolDepth on an interval of the sim clock. The
+// interval can change while it runs; 0 pauses it.
+type SpoolSampler struct {
+  led *Ledger
+  log *slog.Logger
+  interval atomic.Int64
+}
+
+// spoolSamplerIdle is how often a paused sampler looks for a new interval.
+const spoolSamplerIdle = 5 * time.Second
+
+func NewSpoolSampler(led *Ledger, log *slog.Logger) *SpoolSampler {
+  s := &SpoolSampler{led: led, log: log}
+  s.SetInterval(15 * time.Second)
+  return s
+}
+
+func (s *SpoolSampler) SetInterval(d time.Duration) { s.interval.Store(int64(d)) }
+
+func (s *SpoolSampler) Run(ctx context.Context) {
+  next := func() time.Duration {
+    iv := time.Duration(s.interval.Load())
+    if iv <= 0 { return spoolSamplerIdle }
+    return s.led.scaledInterval(iv)
+  }
+  timer := time.NewTimer(next())
+  defer timer.Stop()
+  for {
+    select {
+    case <-ctx.Done():
+      return
+    case <-timer.C:
+      timer.Reset(next())
+      if s.interval.Load() <= 0 { continue }
+      if _, err := s.led.SampleSpoolDepth(context.WithoutCancel(ctx)); err != nil {
+        s.log.Warn("spool depth sample failed", "err", err.Error())
+      }
+    }
+  }
+}
@@ -0,0 +1,82 @@
+package ledger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"time-ledger-sim/go/internal/store/storetest"
+)
+
+func TestSpoolDepthHistory(t *testing.T) {
+	db := storetest.Open(t)
+	ctx := context.Background()
+	l := New(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	clock := NewVirtualClock()
+	start := time.Now().Truncate(time.Second)
+	clock.Freeze(start)
+	l.SetClock(clock)
+
+	zone := "zone-sh-" + uuid.NewString()[:8]
+	if _, err := l.CreateZone(ctx, CreateZoneInput{ID: zone, Name: zone, Actor: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	sample := func() {
+		t.Helper()
+		if _, err := l.SampleSpoolDepth(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sample()
+
+	if _, err := l.SetZoneControls(ctx, zone, SetZoneControlsInput{WritesBlocked: true, CrossZoneThrottle: 100, SpoolEnabled: true, Actor: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	for i := range 3 {
+		_, spoolID, err := l.CreateTransfer(ctx, CreateTransferInput{
+			RequestID: "sh-" + uuid.NewString(), PayloadHash: "h", FromAccount: zone + "-a", ToAccount: zone + "-b", AmountUnits: int64(i + 1), ZoneID: zone,
+		})
+		if err != nil || spoolID == nil {
+			t.Fatalf("transfer %d: spool=%v err=%v", i, spoolID, err)
+		}
+	}
+	clock.Advance(time.Minute)
+	sample()
+
+	if _, err := l.SetZoneControls(ctx, zone, SetZoneControlsInput{CrossZoneThrottle: 100, SpoolEnabled: true, Actor: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.ReplaySpool(ctx, zone, 10, "test", "", "drain"); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Minute)
+	sample()
+	sample() // same instant: kept once
+
+	h, err := l.GetSpoolDepthHistory(ctx, zone, time.Time{}, time.Time{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var depth []int64
+	for _, s := range h.Samples {
+		depth = append(depth, s.Pending)
+	}
+	if len(depth) != 3 || depth[0] != 0 || depth[1] != 3 || depth[2] != 0 {
+		t.Fatalf("pending over time = %v", depth)
+	}
+	if h.Samples[1].OldestPendingAt == nil || h.Samples[2].OldestPendingAt != nil {
+		t.Fatalf("oldest pending = %+v", h.Samples)
+	}
+
+	h, err = l.GetSpoolDepthHistory(ctx, zone, start.Add(time.Minute), start.Add(time.Minute), 0)
+	if err != nil || len(h.Samples) != 1 || h.Samples[0].Pending != 3 {
+		t.Fatalf("one instant = %+v, %v", h, err)
+	}
+	if _, err := l.GetSpoolDepthHistory(ctx, "zone-nowhere", time.Time{}, time.Time{}, 0); !IsZoneNotFound(err) {
+		t.Fatalf("unknown zone err = %v", err)
+	}
+}
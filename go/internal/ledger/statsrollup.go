@@ -0,0 +1,205 @@
+package ledger
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "log/slog"
+  "slices"
+  "sync/atomic"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+)
+
+// Time series metrics, rolled up per zone and minute.
+const (
+  StatsTransfers = "transfers" // transactions posted
+  StatsAmountUnits = "amount_units" // units moved by them
+  StatsSpooled = "spooled" // transfers spooled
+  StatsIncidents = "incidents" // incidents opened
+)
+
+var StatsMetrics = []string{StatsTransfers, StatsAmountUnits, StatsSpooled, StatsIncidents}
+
+const (
+  statsBucket = time.Minute
+  // statsLateness is how far behind the watermark each pass recomputes, for
+  // rows committed after the minute they are stamped with.
+  statsLateness = 5 * time.Minute
+  // statsChunk bounds one pass's transaction when catching up on history.
+  statsChunk = 24 * time.Hour
+  statsRetention = 90 * 24 * time.Hour
+  MaxStatsPoints = 1440
+)
+
+// StatsPoint is one step of a time series: the total over [T, T+step).
+type StatsPoint struct {
+  T time.Time `json:"t"`
+  V int64 `json:"v"`
+}
+
+// StatsSeries is a metric over a range, one point per step, zero-filled.
+type StatsSeries struct {
+  Metric string `json:"metric"`
+  ZoneID string `json:"zone_id,omitempty"` // empty: every zone
+  StepSeconds int64 `json:"step_seconds"`
+  From time.Time `json:"from"`
+  To time.Time `json:"to"`
+  // RolledUpTo is how far the rollup has got; points after it are partial.
+  RolledUpTo *time.Time `json:"rolled_up_to"`
+  Points []StatsPoint `json:"points"`
+}
+
+// RollupStats brings the per-minute buckets up to the sim clock's now: it
+// recomputes them from statsLateness before the watermark, or from the first
+// record on the first pass, a day at a time. Buckets past the retention are
+// pruned. It returns the buckets written.
+func (l *Ledger) RollupStats(ctx context.Context) (int64, error) {
+  now := l.clock.Now()
+  current := now.Truncate(statsBucket)
+  var mark *time.Time
+  if err := l.db.QueryRow(ctx, `SELECT rolled_up_to FROM stats_rollup_state`).Scan(&mark); err != nil { return 0, err }
+  var from time.Time
+  if mark != nil {
+    from = mark.Add(-statsLateness).Truncate(statsBucket)
+  } else {
+    var first *time.Time
+    err := l.db.QueryRow(ctx, `
+      SELECT LEAST((SELECT MIN(created_at) FROM transactions), (SELECT MIN(created_at) FROM spooled_transfers), (SELECT MIN(detected_at) FROM incidents))
+    `).Scan(&first)
+    if err != nil { return 0, err }
+    from = current
+    if first != nil && first.Before(current) { from = first.Truncate(statsBucket) }
+  }
+  if retained := current.Add(-statsRetention); from.Before(retained) { from = retained }
+
+  var written int64
+  for {
+    // the current minute is partial; later passes redo it
+    to, mark := from.Add(statsChunk), current
+    if end := current.Add(statsBucket); !to.Before(end) { to = end } else { mark = to }
+    n, err := l.rollupStatsRange(ctx, from, to, mark)
+    if err != nil { return written, err }
+    written += n
+    if !to.Before(current) { break }
+    from = to
+  }
+  _, err := l.db.Exec(ctx, `DELETE FROM stats_buckets WHERE bucket_start < $1`, current.Add(-statsRetention))
+  return written, err
+}
+
+// rollupStatsRange replaces the buckets in [from, to) and moves the
+// watermark to mark, in one transaction.
+func (l *Ledger) rollupStatsRange(ctx context.Context, from, to, mark time.Time) (int64, error) {
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return 0, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  if _, err := tx.Exec(ctx, `DELETE FROM stats_buckets WHERE bucket_start >= $1 AND bucket_start < $2`, from, to); err != nil { return 0, err }
+  tag, err := tx.Exec(ctx, `
+    WITH t AS (
+      SELECT zone_id, date_trunc('minute', created_at) AS b, COUNT(*) AS n, SUM(amount_units) AS s
+      FROM transactions WHERE created_at >= $1 AND created_at < $2
+      GROUP BY 1, 2
+    )
+    INSERT INTO stats_buckets(metric, zone_id, bucket_start, value)
+    SELECT 'transfers', zone_id, b, n FROM t
+    UNION ALL SELECT 'amount_units', zone_id, b, s FROM t
+    UNION ALL
+    SELECT 'spooled', zone_id, date_trunc('minute', created_at), COUNT(*)
+    FROM spooled_transfers WHERE created_at >= $1 AND created_at < $2
+    GROUP BY 2, 3
+    UNION ALL
+    SELECT 'incidents', zone_id, date_trunc('minute', detected_at), COUNT(*)
+    FROM incidents WHERE detected_at >= $1 AND detected_at < $2
+    GROUP BY 2, 3
+  `, from, to)
+  if err != nil { return 0, err }
+  if _, err := tx.Exec(ctx, `UPDATE stats_rollup_state SET rolled_up_to=$1`, mark); err != nil { return 0, err }
+  return tag.RowsAffected(), tx.Commit(ctx)
+}
+
+// StatsTimeSeries sums a metric's buckets into steps over [from, to), for
+// one zone or ("") all of them. step is a whole number of minutes; from is
+// rounded down and to up to a multiple of it (since the Unix epoch).
+func (l *Ledger) StatsTimeSeries(ctx context.Context, metric, zoneID string, step time.Duration, from, to time.Time) (*StatsSeries, error) {
+  if !slices.Contains(StatsMetrics, metric) { return nil, fmt.Errorf("unknown metric %q", metric) }
+  if step < statsBucket || step%statsBucket != 0 { return nil, fmt.Errorf("step must be a whole number of minutes") }
+  if !from.Before(to) { return nil, fmt.Errorf("from must be before to") }
+  if to.Sub(from)/step > MaxStatsPoints { return nil, fmt.Errorf("at most %d points; widen step or narrow the range", MaxStatsPoints) }
+  from = from.Truncate(step)
+  if t := to.Truncate(step); t.Before(to) { to = t.Add(step) } else { to = t }
+  if zoneID != "" {
+    var exists bool
+    if err := l.ro.QueryRow(ctx, `SELECT true FROM zones WHERE id=$1`, zoneID).Scan(&exists); err != nil {
+      if errors.Is(err, pgx.ErrNoRows) { return nil, ErrZoneNotFound }
+      return nil, err
+    }
+  }
+
+  s := &StatsSeries{Metric: metric, ZoneID: zoneID, StepSeconds: int64(step / time.Second), From: from.UTC(), To: to.UTC(), Points: []StatsPoint{}}
+  if err := l.ro.QueryRow(ctx, `SELECT rolled_up_to FROM stats_rollup_state`).Scan(&s.RolledUpTo); err != nil { return nil, err }
+  rows, err := l.ro.Query(ctx, `
+    WITH agg AS (
+      SELECT to_timestamp((floor(extract(epoch FROM bucket_start) / $3::bigint) * $3::bigint)::float8) AS t, SUM(value) AS v
+      FROM stats_buckets
+      WHERE metric=$1 AND ($2='' OR zone_id=$2) AND bucket_start >= $4 AND bucket_start < $5
+      GROUP BY 1
+    )
+    SELECT g.t, COALESCE(agg.v, 0)::bigint
+    FROM generate_series($4::timestamptz, $5::timestamptz - make_interval(secs => $3::bigint), make_interval(secs => $3::bigint)) AS g(t)
+    LEFT JOIN agg ON agg.t=g.t
+    ORDER BY g.t
+  `, metric, zoneID, s.StepSeconds, from, to)
+  if err != nil { return nil, err }
+  defer rows.Close()
+  for rows.Next() {
+    var p StatsPoint
+    if err := rows.Scan(&p.T, &p.V); err != nil { return nil, err }
+    p.T = p.T.UTC()
+    s.Points = append(s.Points, p)
+  }
+  return s, rows.Err()
+}
+
+// StatsRollup runs RollupStats on an interval. The interval can change while
+// it runs; 0 pauses it.
+type StatsRollup struct {
+  led *Ledger
+  log *slog.Logger
+  interval atomic.Int64
+}
+
+// statsRollupIdle is how often a paused rollup looks for a new interval.
+const statsRollupIdle = 5 * time.Second
+
+func NewStatsRollup(led *Ledger, log *slog.Logger) *StatsRollup {
+  r := &StatsRollup{led: led, log: log}
+  r.SetInterval(time.Minute)
+  return r
+}
+
+func (r *StatsRollup) SetInterval(d time.Duration) { r.interval.Store(int64(d)) }
+
+func (r *StatsRollup) Run(ctx context.Context) {
+  next := func() time.Duration {
+    iv := time.Duration(r.interval.Load())
+    if iv <= 0 { return statsRollupIdle }
+    return r.led.scaledInterval(iv)
+  }
+  timer := time.NewTimer(next())
+  defer timer.Stop()
+  for {
+    select {
+    case <-ctx.Done():
+      return
+    case <-timer.C:
+      timer.Reset(next())
+      if r.interval.Load() <= 0 { continue }
+      if _, err := r.led.RollupStats(context.WithoutCancel(ctx)); err != nil {
+        r.log.Warn("stats rollup failed", "err", err.Error())
+      }
+    }
+  }
+}
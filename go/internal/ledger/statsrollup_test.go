@@ -0,0 +1,80 @@
+package ledger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"time-ledger-sim/go/internal/store/storetest"
+)
+
+func TestStatsRollup(t *testing.T) {
+	db := storetest.Open(t)
+	ctx := context.Background()
+	l := New(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	clock := NewVirtualClock()
+	start := time.Now().Truncate(time.Minute).Add(30 * time.Second)
+	clock.Freeze(start)
+	l.SetClock(clock)
+
+	zone := "zone-st-" + uuid.NewString()[:8]
+	if _, err := l.CreateZone(ctx, CreateZoneInput{ID: zone, Name: zone, Actor: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	transfer := func(units int64) {
+		t.Helper()
+		_, _, err := l.CreateTransfer(ctx, CreateTransferInput{
+			RequestID: "st-" + uuid.NewString(), PayloadHash: "h", FromAccount: zone + "-a", ToAccount: zone + "-b", AmountUnits: units, ZoneID: zone,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	transfer(2)
+	transfer(3)
+	clock.Advance(2 * time.Minute)
+	transfer(5)
+	for range 2 { // a second pass recomputes the same buckets
+		if _, err := l.RollupStats(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	from := start.Truncate(time.Minute)
+	s, err := l.StatsTimeSeries(ctx, StatsTransfers, zone, time.Minute, from, from.Add(3*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := []int64{s.Points[0].V, s.Points[1].V, s.Points[2].V}; len(s.Points) != 3 || got[0] != 2 || got[1] != 0 || got[2] != 1 {
+		t.Errorf("transfers per minute = %+v", s.Points)
+	}
+	if s.RolledUpTo == nil || !s.RolledUpTo.Equal(from.Add(2*time.Minute)) {
+		t.Errorf("rolled_up_to = %v", s.RolledUpTo)
+	}
+
+	s, err = l.StatsTimeSeries(ctx, StatsAmountUnits, zone, 5*time.Minute, from, from.Add(3*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var total int64
+	for _, p := range s.Points {
+		if p.T.Unix()%300 != 0 {
+			t.Errorf("point %v not aligned to the step", p.T)
+		}
+		total += p.V
+	}
+	if total != 10 {
+		t.Errorf("amount_units over 5m steps = %+v", s.Points)
+	}
+
+	if _, err := l.StatsTimeSeries(ctx, "latency", zone, time.Minute, from, from.Add(time.Minute)); err == nil {
+		t.Error("unknown metric accepted")
+	}
+	if _, err := l.StatsTimeSeries(ctx, StatsTransfers, "zone-none-"+uuid.NewString()[:8], time.Minute, from, from.Add(time.Minute)); !IsZoneNotFound(err) {
+		t.Errorf("unknown zone: err = %v", err)
+	}
+}
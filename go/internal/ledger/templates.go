@@ -0,0 +1,171 @@
+package ledger
+
+import (
+  "context"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "time"
+
+  "github.com/google/uuid"
+  "github.com/jackc/pgx/v5"
+
+  "time-ledger-sim/go/internal/util"
+)
+
+var ErrTemplateNotFound = errors.New("transfer template not found")
+
+func IsTemplateNotFound(err error) bool { return errors.Is(err, ErrTemplateNotFound) }
+
+type TransferTemplate struct {
+  Name string `json:"name"`
+  Description *string `json:"description"`
+  ZoneID string `json:"zone_id"`
+  FromAccount string `json:"from_account"`
+  ToAccount string `json:"to_account"`
+  AmountUnits int64 `json:"amount_units"`
+  Metadata map[string]any `json:"metadata"`
+  Actor string `json:"actor"`
+  CreatedAt time.Time `json:"created_at"`
+  UpdatedAt time.Time `json:"updated_at"`
+}
+
+const templateCols = `name, description, zone_id, from_account, to_account, amount_units, metadata, actor, created_at, updated_at`
+
+func scanTransferTemplate(row pgx.Row) (*TransferTemplate, error) {
+  var t TransferTemplate
+  var meta []byte
+  err := row.Scan(&t.Name, &t.Description, &t.ZoneID, &t.FromAccount, &t.ToAccount, &t.AmountUnits, &meta, &t.Actor, &t.CreatedAt, &t.UpdatedAt)
+  if err != nil { return nil, err }
+  _ = json.Unmarshal(meta, &t.Metadata)
+  return &t, nil
+}
+
+type SaveTransferTemplateInput struct {
+  Name string
+  Description string
+  ZoneID string
+  FromAccount string
+  ToAccount string
+  AmountUnits int64
+  Metadata map[string]any
+  Actor string
+  Reason string
+}
+
+// SaveTransferTemplate creates the named template or replaces its fields.
+func (l *Ledger) SaveTransferTemplate(ctx context.Context, in SaveTransferTemplateInput) (*TransferTemplate, error) {
+  if !scenarioNamePattern.MatchString(in.Name) { return nil, fmt.Errorf("name must match [a-z0-9][a-z0-9_-]{0,63}") }
+  if in.FromAccount == "" || in.ToAccount == "" { return nil, fmt.Errorf("from_account and to_account are required") }
+  if in.FromAccount == in.ToAccount { return nil, fmt.Errorf("from_account and to_account must differ") }
+  if in.AmountUnits <= 0 { return nil, fmt.Errorf("amount_units must be positive") }
+  if in.Metadata == nil { in.Metadata = map[string]any{} }
+  meta, err := json.Marshal(in.Metadata)
+  if err != nil { return nil, err }
+
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  if _, err := (pgQueries{tx}).ZoneStatus(ctx, in.ZoneID); err != nil { return nil, err }
+  if err := l.checkActors(ctx, tx, in.Actor); err != nil { return nil, err }
+
+  t, err := scanTransferTemplate(tx.QueryRow(ctx, `
+    INSERT INTO transfer_templates(name,description,zone_id,from_account,to_account,amount_units,metadata,actor)
+    VALUES($1,NULLIF($2,''),$3,$4,$5,$6,$7::jsonb,$8)
+    ON CONFLICT (name) DO UPDATE SET description=EXCLUDED.description, zone_id=EXCLUDED.zone_id, from_account=EXCLUDED.from_account,
+      to_account=EXCLUDED.to_account, amount_units=EXCLUDED.amount_units, metadata=EXCLUDED.metadata, actor=EXCLUDED.actor, updated_at=now()
+    RETURNING `+templateCols,
+    in.Name, in.Description, in.ZoneID, in.FromAccount, in.ToAccount, in.AmountUnits, string(meta), in.Actor))
+  if err != nil { return nil, err }
+
+  err = l.audit(ctx, pgQueries{tx}, AuditRecord{
+    Actor: in.Actor, Action: "SAVE_TRANSFER_TEMPLATE", TargetType: "transfer_template", TargetID: t.Name, Reason: in.Reason,
+    Details: asDetails(t),
+  })
+  if err != nil { return nil, err }
+
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  return t, nil
+}
+
+func (l *Ledger) GetTransferTemplate(ctx context.Context, name string) (*TransferTemplate, error) {
+  t, err := scanTransferTemplate(l.db.QueryRow(ctx, `SELECT `+templateCols+` FROM transfer_templates WHERE name=$1`, name))
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrTemplateNotFound }
+  return t, err
+}
+
+func (l *Ledger) ListTransferTemplates(ctx context.Context) ([]TransferTemplate, error) {
+  rows, err := l.db.Query(ctx, `SELECT `+templateCols+` FROM transfer_templates ORDER BY name LIMIT 500`)
+  if err != nil { return nil, err }
+  defer rows.Close()
+
+  out := []TransferTemplate{}
+  for rows.Next() {
+    t, err := scanTransferTemplate(rows)
+    if err != nil { return nil, err }
+    out = append(out, *t)
+  }
+  return out, rows.Err()
+}
+
+func (l *Ledger) DeleteTransferTemplate(ctx context.Context, name, actor, reason string) (*TransferTemplate, error) {
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  if err := l.checkActors(ctx, tx, actor); err != nil { return nil, err }
+
+  t, err := scanTransferTemplate(tx.QueryRow(ctx, `DELETE FROM transfer_templates WHERE name=$1 RETURNING `+templateCols, name))
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrTemplateNotFound }
+  if err != nil { return nil, err }
+
+  err = l.audit(ctx, pgQueries{tx}, AuditRecord{
+    Actor: actor, Action: "DELETE_TRANSFER_TEMPLATE", TargetType: "transfer_template", TargetID: t.Name, Reason: reason,
+  })
+  if err != nil { return nil, err }
+
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  return t, nil
+}
+
+// TemplateOverrides replace a template's fields for one transfer; zero
+// values keep the template's. Metadata is merged over the template's.
+type TemplateOverrides struct {
+  RequestID string // default a new UUID
+  ZoneID string
+  FromAccount string
+  ToAccount string
+  AmountUnits int64
+  Metadata map[string]any
+}
+
+// TemplateTransfer resolves a template and overrides into a transfer input
+// for CreateTransfer, with the payload hash a POST /v1/transfers of the same
+// fields would get. Its metadata names the template.
+func (l *Ledger) TemplateTransfer(ctx context.Context, name string, o TemplateOverrides) (CreateTransferInput, error) {
+  t, err := l.GetTransferTemplate(ctx, name)
+  if err != nil { return CreateTransferInput{}, err }
+
+  in := CreateTransferInput{
+    RequestID: o.RequestID, ZoneID: t.ZoneID, FromAccount: t.FromAccount, ToAccount: t.ToAccount, AmountUnits: t.AmountUnits,
+    Metadata: map[string]any{},
+  }
+  if in.RequestID == "" { in.RequestID = uuid.NewString() }
+  if o.ZoneID != "" { in.ZoneID = o.ZoneID }
+  if o.FromAccount != "" { in.FromAccount = o.FromAccount }
+  if o.ToAccount != "" { in.ToAccount = o.ToAccount }
+  if o.AmountUnits != 0 { in.AmountUnits = o.AmountUnits }
+  if in.AmountUnits <= 0 { return CreateTransferInput{}, fmt.Errorf("amount_units must be positive") }
+  if in.FromAccount == in.ToAccount { return CreateTransferInput{}, fmt.Errorf("from_account and to_account must differ") }
+  for k, v := range t.Metadata { in.Metadata[k] = v }
+  for k, v := range o.Metadata { in.Metadata[k] = v }
+  in.Metadata["template"] = t.Name
+
+  in.PayloadHash, err = util.HashCanonicalJSON(map[string]any{
+    "request_id": in.RequestID, "from_account": in.FromAccount, "to_account": in.ToAccount,
+    "amount_units": in.AmountUnits, "zone_id": in.ZoneID, "metadata": in.Metadata,
+  })
+  if err != nil { return CreateTransferInput{}, err }
+  return in, nil
+}
@@ -0,0 +1,76 @@
+package ledger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"time-ledger-sim/go/internal/store/storetest"
+)
+
+func TestTransferTemplates(t *testing.T) {
+	db := storetest.Open(t)
+	ctx := context.Background()
+	l := New(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	zone := "zone-tmpl-" + strings.ReplaceAll(uuid.NewString()[:8], "-", "")
+	if _, err := l.CreateZone(ctx, CreateZoneInput{ID: zone, Name: zone, Actor: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	name := "payroll-" + uuid.NewString()[:8]
+	_, err := l.SaveTransferTemplate(ctx, SaveTransferTemplateInput{
+		Name: name, ZoneID: zone, FromAccount: zone + "-treasury", ToAccount: zone + "-alice", AmountUnits: 300,
+		Metadata: map[string]any{"memo": "shift"}, Actor: "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _, _ = l.DeleteTransferTemplate(ctx, name, "test", "cleanup") })
+
+	in, err := l.TemplateTransfer(ctx, name, TemplateOverrides{AmountUnits: 500, Metadata: map[string]any{"run": "1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if in.AmountUnits != 500 || in.ToAccount != zone+"-alice" || in.Metadata["template"] != name || in.Metadata["memo"] != "shift" || in.Metadata["run"] != "1" {
+		t.Fatalf("resolved input = %+v", in)
+	}
+	txn, spoolID, err := l.CreateTransfer(ctx, in)
+	if err != nil || spoolID != nil {
+		t.Fatalf("create: spool=%v err=%v", spoolID, err)
+	}
+	if txn.RequestID != in.RequestID {
+		t.Fatalf("request id %q, want %q", txn.RequestID, in.RequestID)
+	}
+	// each call without a request id is a new transfer
+	again, err := l.TemplateTransfer(ctx, name, TemplateOverrides{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.RequestID == in.RequestID || again.AmountUnits != 300 {
+		t.Fatalf("second input = %+v", again)
+	}
+
+	if _, err := l.DeleteTransferTemplate(ctx, name, "test", "done"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.TemplateTransfer(ctx, name, TemplateOverrides{}); !IsTemplateNotFound(err) {
+		t.Fatalf("after delete: err = %v", err)
+	}
+}
+
+func TestSaveTransferTemplateValidation(t *testing.T) {
+	l := NewWithRepo(nil, nil)
+	for _, in := range []SaveTransferTemplateInput{
+		{Name: "Bad Name", ZoneID: "zone-eu", FromAccount: "a", ToAccount: "b", AmountUnits: 1},
+		{Name: "ok", ZoneID: "zone-eu", FromAccount: "a", ToAccount: "a", AmountUnits: 1},
+		{Name: "ok", ZoneID: "zone-eu", FromAccount: "a", ToAccount: "b", AmountUnits: 0},
+	} {
+		if _, err := l.SaveTransferTemplate(context.Background(), in); err == nil {
+			t.Errorf("%+v: no error", in)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package ledger
+
+import (
+  "context"
+  "crypto/rand"
+  "crypto/sha256"
+  "encoding/base64"
+  "encoding/hex"
+  "errors"
+  "fmt"
+  "regexp"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+  "github.com/jackc/pgx/v5/pgconn"
+
+  "time-ledger-sim/go/internal/store"
+)
+
+var (
+  ErrTenantNotFound = errors.New("tenant not found")
+  ErrTenantExists = errors.New("tenant already exists")
+  ErrTenantKeyNotFound = errors.New("tenant key not found")
+  ErrInvalidTenantKey = errors.New("invalid or revoked tenant key")
+  // ErrTenantScoped rejects what would reach past the caller's tenant, such
+  // as a snapshot restore, which resets every tenant's tables.
+  ErrTenantScoped = errors.New("not available to a tenant")
+)
+
+func IsTenantNotFound(err error) bool { return errors.Is(err, ErrTenantNotFound) }
+func IsTenantExists(err error) bool { return errors.Is(err, ErrTenantExists) }
+func IsTenantKeyNotFound(err error) bool { return errors.Is(err, ErrTenantKeyNotFound) }
+func IsInvalidTenantKey(err error) bool { return errors.Is(err, ErrInvalidTenantKey) }
+func IsTenantScoped(err error) bool { return errors.Is(err, ErrTenantScoped) }
+
+var tenantIDPattern = regexp.MustCompile(`^[a-z][a-z0-9-]{1,31}$`)
+
+// tenantKeyPrefix starts every tenant key, so a leaked one is recognisable.
+const tenantKeyPrefix = "tk_"
+
+// Tenant is an independent simulation on the deployment: its zones, accounts,
+// incidents and everything under them (migration 0047).
+type Tenant struct {
+  ID string `json:"id"`
+  Name string `json:"name"`
+  ActiveKeys int64 `json:"active_keys"`
+  CreatedBy string `json:"created_by"`
+  CreatedAt time.Time `json:"created_at"`
+}
+
+// TenantKey is an API key that scopes requests to its tenant. Only the
+// prefix is kept; the key is returned once, by CreateTenantKey.
+type TenantKey struct {
+  ID string `json:"id"`
+  TenantID string `json:"tenant_id"`
+  Prefix string `json:"prefix"`
+  Label string `json:"label,omitempty"`
+  CreatedBy string `json:"created_by"`
+  CreatedAt time.Time `json:"created_at"`
+  RevokedBy *string `json:"revoked_by"`
+  RevokedAt *time.Time `json:"revoked_at"`
+}
+
+// NewTenantKey is a created key with its secret.
+type NewTenantKey struct {
+  TenantKey
+  Key string `json:"key"`
+}
+
+const tenantKeyCols = `id::text, tenant_id, prefix, COALESCE(label,''), created_by, created_at, revoked_by, revoked_at`
+
+func scanTenantKey(row pgx.Row) (*TenantKey, error) {
+  var k TenantKey
+  if err := row.Scan(&k.ID, &k.TenantID, &k.Prefix, &k.Label, &k.CreatedBy, &k.CreatedAt, &k.RevokedBy, &k.RevokedAt); err != nil { return nil, err }
+  return &k, nil
+}
+
+func hashTenantKey(key string) string {
+  sum := sha256.Sum256([]byte(key))
+  return hex.EncodeToString(sum[:])
+}
+
+// SetMultiTenant tells the ledger its store scopes store.WithTenant
+// contexts (MULTI_TENANT), so background work done for a tenant runs as it.
+func (l *Ledger) SetMultiTenant(on bool) { l.multiTenant.Store(on) }
+
+func (l *Ledger) MultiTenant() bool { return l.multiTenant.Load() }
+
+// jobContext scopes ctx to the tenant that enqueued the job, so its steps
+// see what the tenant sees.
+func (l *Ledger) jobContext(ctx context.Context, jobID string) (context.Context, error) {
+  if !l.multiTenant.Load() { return ctx, nil }
+  var tenantID string
+  if err := l.db.QueryRow(ctx, `SELECT tenant_id FROM jobs WHERE id=$1::uuid`, jobID).Scan(&tenantID); err != nil { return ctx, err }
+  return store.WithTenant(ctx, tenantID), nil
+}
+
+type CreateTenantInput struct {
+  ID string
+  Name string // "" = the ID
+  Actor string
+  Reason string
+}
+
+// CreateTenant adds a tenant. Tenants and their keys live outside every
+// tenant, so these calls run unscoped whoever makes them.
+func (l *Ledger) CreateTenant(ctx context.Context, in CreateTenantInput) (*Tenant, error) {
+  if !tenantIDPattern.MatchString(in.ID) { return nil, fmt.Errorf("invalid id: use 2-32 of a-z, 0-9 and -, starting with a letter") }
+  if in.Actor == "" { return nil, fmt.Errorf("actor required") }
+  if in.Name == "" { in.Name = in.ID }
+  ctx = store.Unscoped(ctx)
+
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  if err := l.checkActors(ctx, tx, in.Actor); err != nil { return nil, err }
+  t := Tenant{ID: in.ID, Name: in.Name, CreatedBy: in.Actor}
+  err = tx.QueryRow(ctx, `INSERT INTO tenants(id, name, created_by) VALUES ($1,$2,$3) RETURNING created_at`, in.ID, in.Name, in.Actor).Scan(&t.CreatedAt)
+  var pgErr *pgconn.PgError
+  if errors.As(err, &pgErr) && pgErr.Code == "23505" { return nil, ErrTenantExists }
+  if err != nil { return nil, err }
+
+  err = l.audit(ctx, pgQueries{tx}, AuditRecord{Actor: in.Actor, Action: "CREATE_TENANT", TargetType: "tenant", TargetID: in.ID, Reason: in.Reason, Details: map[string]any{"name": in.Name}})
+  if err != nil { return nil, err }
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  l.log.InfoContext(ctx, "tenant created", "tenant_id", in.ID, "actor", in.Actor)
+  return &t, nil
+}
+
+// ListTenants lists every tenant with its count of unrevoked keys.
+func (l *Ledger) ListTenants(ctx context.Context) ([]Tenant, error) {
+  rows, err := l.db.Query(store.Unscoped(ctx), `
+    SELECT t.id, t.name, (SELECT COUNT(*) FROM tenant_api_keys k WHERE k.tenant_id=t.id AND k.revoked_at IS NULL), t.created_by, t.created_at
+    FROM tenants t
+    ORDER BY t.id
+  `)
+  if err != nil { return nil, err }
+  defer rows.Close()
+  out := []Tenant{}
+  for rows.Next() {
+    var t Tenant
+    if err := rows.Scan(&t.ID, &t.Name, &t.ActiveKeys, &t.CreatedBy, &t.CreatedAt); err != nil { return nil, err }
+    out = append(out, t)
+  }
+  return out, rows.Err()
+}
+
+type CreateTenantKeyInput struct {
+  Label string
+  Actor string
+  Reason string
+}
+
+// CreateTenantKey issues a key for the tenant. The key is in the result and
+// nowhere else; the database keeps its SHA-256.
+func (l *Ledger) CreateTenantKey(ctx context.Context, tenantID string, in CreateTenantKeyInput) (*NewTenantKey, error) {
+  if in.Actor == "" { return nil, fmt.Errorf("actor required") }
+  ctx = store.Unscoped(ctx)
+  secret := make([]byte, 24)
+  if _, err := rand.Read(secret); err != nil { return nil, err }
+  key := tenantKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  if err := l.checkActors(ctx, tx, in.Actor); err != nil { return nil, err }
+  k, err := scanTenantKey(tx.QueryRow(ctx, `
+    INSERT INTO tenant_api_keys(tenant_id, key_hash, prefix, label, created_by)
+    SELECT id, $2, $3, NULLIF($4,''), $5 FROM tenants WHERE id=$1
+    RETURNING `+tenantKeyCols, tenantID, hashTenantKey(key), key[:len(tenantKeyPrefix)+6], in.Label, in.Actor))
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrTenantNotFound }
+  if err != nil { return nil, err }
+
+  err = l.audit(ctx, pgQueries{tx}, AuditRecord{
+    Actor: in.Actor, Action: "CREATE_TENANT_KEY", TargetType: "tenant", TargetID: tenantID, Reason: in.Reason,
+    Details: map[string]any{"key_id": k.ID, "prefix": k.Prefix, "label": in.Label},
+  })
+  if err != nil { return nil, err }
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  return &NewTenantKey{TenantKey: *k, Key: key}, nil
+}
+
+// ListTenantKeys lists a tenant's keys, newest first, revoked ones included.
+func (l *Ledger) ListTenantKeys(ctx context.Context, tenantID string) ([]TenantKey, error) {
+  ctx = store.Unscoped(ctx)
+  var exists bool
+  if err := l.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM tenants WHERE id=$1)`, tenantID).Scan(&exists); err != nil { return nil, err }
+  if !exists { return nil, ErrTenantNotFound }
+  rows, err := l.db.Query(ctx, `SELECT `+tenantKeyCols+` FROM tenant_api_keys WHERE tenant_id=$1 ORDER BY created_at DESC`, tenantID)
+  if err != nil { return nil, err }
+  defer rows.Close()
+  out := []TenantKey{}
+  for rows.Next() {
+    k, err := scanTenantKey(rows)
+    if err != nil { return nil, err }
+    out = append(out, *k)
+  }
+  return out, rows.Err()
+}
+
+// RevokeTenantKey stops a key from being accepted. Revoking a revoked key
+// is not found.
+func (l *Ledger) RevokeTenantKey(ctx context.Context, tenantID, keyID, actor, reason string) (*TenantKey, error) {
+  if actor == "" { return nil, fmt.Errorf("actor required") }
+  ctx = store.Unscoped(ctx)
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  if err := l.checkActors(ctx, tx, actor); err != nil { return nil, err }
+  k, err := scanTenantKey(tx.QueryRow(ctx, `
+    UPDATE tenant_api_keys SET revoked_by=$3, revoked_at=now()
+    WHERE tenant_id=$1 AND id::text=$2 AND revoked_at IS NULL
+    RETURNING `+tenantKeyCols, tenantID, keyID, actor))
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrTenantKeyNotFound }
+  if err != nil { return nil, err }
+
+  err = l.audit(ctx, pgQueries{tx}, AuditRecord{
+    Actor: actor, Action: "REVOKE_TENANT_KEY", TargetType: "tenant", TargetID: tenantID, Reason: reason,
+    Details: map[string]any{"key_id": k.ID, "prefix": k.Prefix},
+  })
+  if err != nil { return nil, err }
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  return k, nil
+}
+
+// TenantForKey resolves a tenant key to its tenant, reading the primary so a
+// revocation takes effect on the next request.
+func (l *Ledger) TenantForKey(ctx context.Context, key string) (string, error) {
+  var tenantID string
+  err := l.db.QueryRow(store.Unscoped(ctx), `
+    SELECT tenant_id FROM tenant_api_keys WHERE key_hash=$1 AND revoked_at IS NULL
+  `, hashTenantKey(key)).Scan(&tenantID)
+  if errors.Is(err, pgx.ErrNoRows) { return "", ErrInvalidTenantKey }
+  return tenantID, err
+}
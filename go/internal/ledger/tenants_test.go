@@ -0,0 +1,211 @@
+package ledger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"time-ledger-sim/go/internal/store"
+	"time-ledger-sim/go/internal/store/storetest"
+)
+
+func TestTenantKeys(t *testing.T) {
+	db := storetest.Open(t)
+	ctx := context.Background()
+	l := New(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	id := "t-" + uuid.NewString()[:8]
+
+	if _, err := l.CreateTenant(ctx, CreateTenantInput{ID: "Bad_ID", Actor: "ops"}); err == nil {
+		t.Fatal("invalid id accepted")
+	}
+	tn, err := l.CreateTenant(ctx, CreateTenantInput{ID: id, Actor: "ops"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tn.Name != id {
+		t.Errorf("name = %q, want the id", tn.Name)
+	}
+	if _, err := l.CreateTenant(ctx, CreateTenantInput{ID: id, Actor: "ops"}); !IsTenantExists(err) {
+		t.Fatalf("second create: err = %v", err)
+	}
+	if _, err := l.CreateTenantKey(ctx, "t-missing", CreateTenantKeyInput{Actor: "ops"}); !IsTenantNotFound(err) {
+		t.Fatalf("key for a missing tenant: err = %v", err)
+	}
+
+	k, err := l.CreateTenantKey(ctx, id, CreateTenantKeyInput{Label: "cohort 1", Actor: "ops"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(k.Key, tenantKeyPrefix) || !strings.HasPrefix(k.Key, k.Prefix) {
+		t.Errorf("key %q, prefix %q", k.Key, k.Prefix)
+	}
+	if got, err := l.TenantForKey(ctx, k.Key); err != nil || got != id {
+		t.Fatalf("TenantForKey = %q, %v", got, err)
+	}
+	if _, err := l.TenantForKey(ctx, k.Key+"x"); !IsInvalidTenantKey(err) {
+		t.Fatalf("wrong key: err = %v", err)
+	}
+
+	if _, err := l.RevokeTenantKey(ctx, id, k.ID, "ops", "rotated"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.RevokeTenantKey(ctx, id, k.ID, "ops", "again"); !IsTenantKeyNotFound(err) {
+		t.Fatalf("second revoke: err = %v", err)
+	}
+	if _, err := l.TenantForKey(ctx, k.Key); !IsInvalidTenantKey(err) {
+		t.Fatalf("revoked key: err = %v", err)
+	}
+	keys, err := l.ListTenantKeys(ctx, id)
+	if err != nil || len(keys) != 1 || keys[0].RevokedAt == nil {
+		t.Fatalf("keys = %+v, %v", keys, err)
+	}
+}
+
+func TestTenantIsolation(t *testing.T) {
+	db := storetest.OpenMultiTenant(t)
+	ctx := context.Background()
+	l := New(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	l.SetMultiTenant(true)
+	id := "t-" + uuid.NewString()[:8]
+	if _, err := l.CreateTenant(ctx, CreateTenantInput{ID: id, Actor: "ops"}); err != nil {
+		t.Fatal(err)
+	}
+	mine := store.WithTenant(ctx, id)
+	other := store.WithTenant(ctx, store.DefaultTenant)
+
+	zone := "zone-" + id
+	if _, err := l.CreateZone(mine, CreateZoneInput{ID: zone, Name: "cohort zone", Actor: "ops"}); err != nil {
+		t.Fatal(err)
+	}
+	has := func(ctx context.Context) bool {
+		zs, err := l.ListZones(ctx, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, z := range zs {
+			if z.ID == zone {
+				return true
+			}
+		}
+		return false
+	}
+	if !has(mine) || has(other) || !has(ctx) {
+		t.Fatalf("zone visible to tenant %v, default %v, unscoped %v; want true, false, true", has(mine), has(other), has(ctx))
+	}
+	if _, err := l.SetZoneStatus(other, zone, "DEGRADED", "ops", "", "not mine"); !IsZoneNotFound(err) {
+		t.Fatalf("status change from another tenant: err = %v", err)
+	}
+
+	// work done unscoped on the tenant's zone is filed under the tenant
+	if _, err := l.SetZoneStatus(ctx, zone, "DEGRADED", "ops", "", "unscoped"); err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	if err := db.QueryRow(other, `SELECT COUNT(*) FROM audit_log WHERE target_id=$1`, zone).Scan(&n); err != nil || n != 0 {
+		t.Fatalf("default tenant sees %d audit entries of the zone (%v)", n, err)
+	}
+	if err := db.QueryRow(mine, `SELECT COUNT(*) FROM audit_log WHERE target_id=$1`, zone).Scan(&n); err != nil || n < 2 {
+		t.Fatalf("tenant sees %d audit entries of its zone (%v)", n, err)
+	}
+
+	if _, err := l.Restore(mine, map[string]any{}, RestoreOptions{}); !IsTenantScoped(err) {
+		t.Fatalf("restore by a tenant: err = %v", err)
+	}
+}
+
+func TestTenantPartitionsAndRuns(t *testing.T) {
+	db := storetest.OpenMultiTenant(t)
+	ctx := context.Background()
+	l := New(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	l.SetMultiTenant(true)
+	id := "t-" + uuid.NewString()[:8]
+	if _, err := l.CreateTenant(ctx, CreateTenantInput{ID: id, Actor: "ops"}); err != nil {
+		t.Fatal(err)
+	}
+	mine := store.WithTenant(ctx, id)
+	other := store.WithTenant(ctx, store.DefaultTenant)
+
+	zone, zone2, theirs := "zone-"+id, "zone-"+id+"-2", "zone-d-"+id
+	for _, z := range []struct {
+		ctx context.Context
+		id  string
+	}{{mine, zone}, {mine, zone2}, {other, theirs}} {
+		if _, err := l.CreateZone(z.ctx, CreateZoneInput{ID: z.id, Name: z.id, Actor: "ops"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// partitions: only between the tenant's own zones, seen and healed by it alone
+	if _, err := l.CreatePartition(mine, PartitionInput{FromZone: zone, ToZone: theirs, Actor: "ops"}); !IsZoneNotFound(err) {
+		t.Fatalf("partition towards another tenant's zone: err = %v", err)
+	}
+	if _, err := l.CreatePartition(other, PartitionInput{FromZone: theirs, ToZone: zone, Bidirectional: true, Actor: "ops"}); !IsZoneNotFound(err) {
+		t.Fatalf("partition of another tenant's zone: err = %v", err)
+	}
+	if _, err := l.CreatePartition(mine, PartitionInput{FromZone: zone, ToZone: zone2, Actor: "ops"}); err != nil {
+		t.Fatal(err)
+	}
+	hasPartition := func(ctx context.Context) bool {
+		ps, err := l.ListPartitions(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range ps {
+			if p.FromZone == zone && p.ToZone == zone2 {
+				return true
+			}
+		}
+		return false
+	}
+	if !hasPartition(mine) || hasPartition(other) {
+		t.Fatalf("partition visible to tenant %v, default %v; want true, false", hasPartition(mine), hasPartition(other))
+	}
+	if healed, err := l.HealPartition(other, PartitionInput{FromZone: zone, ToZone: zone2, Actor: "ops"}); err != nil || healed != 0 {
+		t.Fatalf("heal by another tenant = %d, %v", healed, err)
+	}
+	if !hasPartition(mine) {
+		t.Fatal("another tenant healed the partition")
+	}
+
+	// runs: one per tenant, each tagging only its tenant's rows
+	run, err := l.StartSimRun(mine, "cohort run", "ops", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _, _ = l.StopSimRun(mine, run.ID, "ops", "") }()
+	if _, err := l.GetSimRun(other, run.ID); !IsSimRunNotFound(err) {
+		t.Fatalf("run of another tenant: err = %v", err)
+	}
+	if _, err := l.StopSimRun(other, run.ID, "ops", ""); !IsSimRunNotFound(err) {
+		t.Fatalf("stop by another tenant: err = %v", err)
+	}
+	transfer := func(ctx context.Context, zone string) string {
+		id := uuid.NewString()
+		in := CreateTransferInput{RequestID: id, PayloadHash: "h-" + id, FromAccount: zone + "-a", ToAccount: zone + "-b", AmountUnits: 5, ZoneID: zone}
+		if _, _, err := l.CreateTransfer(ctx, in); err != nil {
+			t.Fatal(err)
+		}
+		var runID *string
+		if err := db.QueryRow(ctx, `SELECT run_id::text FROM transactions WHERE request_id=$1`, id).Scan(&runID); err != nil {
+			t.Fatal(err)
+		}
+		if runID == nil {
+			return ""
+		}
+		return *runID
+	}
+	if got := transfer(mine, zone); got != run.ID {
+		t.Fatalf("tenant's transfer run = %q, want %q", got, run.ID)
+	}
+	if got := transfer(other, theirs); got == run.ID {
+		t.Fatal("another tenant's transfer tagged with the run")
+	}
+	// unscoped work is tagged by the tenant of the zone it is filed under
+	if got := transfer(ctx, zone2); got != run.ID {
+		t.Fatalf("unscoped transfer in the tenant's zone run = %q, want %q", got, run.ID)
+	}
+}
@@ -0,0 +1,501 @@
+package ledger_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"time-ledger-sim/go/internal/ledger"
+	"time-ledger-sim/go/internal/ledger/ledgertest"
+)
+
+func newLedger(t *testing.T, zones ...string) (*ledger.Ledger, *ledgertest.MemRepo) {
+	t.Helper()
+	repo := ledgertest.NewMemRepo(zones...)
+	led := ledger.NewWithRepo(repo, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	clock := ledger.NewVirtualClock()
+	clock.Freeze(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	led.SetClock(clock)
+	return led, repo
+}
+
+func transfer(req string, amount int64) ledger.CreateTransferInput {
+	return ledger.CreateTransferInput{
+		RequestID: req, PayloadHash: "hash-" + req, FromAccount: "acct-a", ToAccount: "acct-b",
+		AmountUnits: amount, ZoneID: "zone-eu", Metadata: map[string]any{},
+	}
+}
+
+// assertBalanced checks the double-entry invariants: every transaction has one
+// debit and one credit of its amount, and balances sum to zero.
+func assertBalanced(t *testing.T, repo *ledgertest.MemRepo) {
+	t.Helper()
+	legs := map[string][2]int64{}
+	for _, p := range repo.Postings() {
+		l := legs[p.TxnID]
+		if p.Direction == "DEBIT" {
+			l[0] += p.AmountUnits
+		} else {
+			l[1] += p.AmountUnits
+		}
+		legs[p.TxnID] = l
+	}
+	for _, txn := range repo.Transactions() {
+		if l := legs[txn.ID]; l[0] != txn.In.AmountUnits || l[1] != txn.In.AmountUnits {
+			t.Errorf("txn %s postings debit=%d credit=%d, want %d each", txn.ID, l[0], l[1], txn.In.AmountUnits)
+		}
+	}
+	var sum int64
+	for _, b := range repo.Balances() {
+		sum += b
+	}
+	if sum != 0 {
+		t.Errorf("balances sum to %d, want 0", sum)
+	}
+}
+
+func TestCreateTransferAppliesDoubleEntry(t *testing.T) {
+	led, repo := newLedger(t, "zone-eu")
+	ctx := context.Background()
+	for i, amt := range []int64{100, 250, 31536000} {
+		txn, spoolID, err := led.CreateTransfer(ctx, transfer("req-"+string(rune('a'+i)), amt))
+		if err != nil || spoolID != nil || txn == nil {
+			t.Fatalf("transfer %d: txn=%v spool=%v err=%v", i, txn, spoolID, err)
+		}
+	}
+	assertBalanced(t, repo)
+	if b := repo.Balances()["acct-b"]; b != 100+250+31536000 {
+		t.Fatalf("acct-b balance = %d", b)
+	}
+	out := repo.Outbox()
+	if len(out) != 3 || out[0].EventType != "TRANSFER_POSTED" {
+		t.Fatalf("outbox = %+v", out)
+	}
+}
+
+func TestCreateTransferIdempotency(t *testing.T) {
+	led, repo := newLedger(t, "zone-eu")
+	ctx := context.Background()
+	first, _, err := led.CreateTransfer(ctx, transfer("req-1", 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _, err := led.CreateTransfer(ctx, transfer("req-1", 10))
+	if err != nil || again.ID != first.ID {
+		t.Fatalf("replayed request: txn=%v err=%v, want %s", again, err, first.ID)
+	}
+	changed := transfer("req-1", 10)
+	changed.PayloadHash = "other"
+	if _, _, err := led.CreateTransfer(ctx, changed); !ledger.IsIdempotencyConflict(err) {
+		t.Fatalf("changed payload err = %v, want idempotency conflict", err)
+	}
+	if n := len(repo.Transactions()); n != 1 {
+		t.Fatalf("%d transactions, want 1", n)
+	}
+}
+
+func TestCreateTransferZoneSequence(t *testing.T) {
+	led, repo := newLedger(t, "zone-eu", "zone-na")
+	ctx := context.Background()
+	var seqs []int64
+	for i, zone := range []string{"zone-eu", "zone-na", "zone-eu"} {
+		in := transfer("req-"+string(rune('a'+i)), 5)
+		in.ZoneID = zone
+		txn, _, err := led.CreateTransfer(ctx, in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seqs = append(seqs, txn.ZoneSeq)
+	}
+	if seqs[0] != 1 || seqs[1] != 1 || seqs[2] != 2 {
+		t.Fatalf("zone seqs = %v, want [1 1 2]", seqs)
+	}
+	in := transfer("req-c", 5)
+	in.ZoneID = "zone-eu"
+	again, _, err := led.CreateTransfer(ctx, in)
+	if err != nil || again.ZoneSeq != 2 {
+		t.Fatalf("replay: txn=%+v err=%v, want zone_seq 2", again, err)
+	}
+	var payload map[string]any
+	if err := json.Unmarshal(repo.Outbox()[2].Payload, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload["zone_seq"] != float64(2) {
+		t.Fatalf("event zone_seq = %v, want 2", payload["zone_seq"])
+	}
+	// the balances after the third transfer, for the balance notifier
+	if payload["from_account"] != "acct-a" || payload["from_balance_units"] != float64(-15) || payload["to_balance_units"] != float64(15) {
+		t.Fatalf("event balances = %v / %v / %v", payload["from_account"], payload["from_balance_units"], payload["to_balance_units"])
+	}
+}
+
+// racingRepo hides recorded requests from the first lookup, as if a
+// concurrent request with the same request_id committed right after it.
+type racingRepo struct {
+	*ledgertest.MemRepo
+	stale *bool
+}
+
+func (r racingRepo) InTx(ctx context.Context, fn func(q ledger.Queries) error) error {
+	return r.MemRepo.InTx(ctx, func(q ledger.Queries) error { return fn(racingQueries{q, r.stale}) })
+}
+
+type racingQueries struct {
+	ledger.Queries
+	stale *bool
+}
+
+func (q racingQueries) FindTransaction(ctx context.Context, requestID string) (*ledger.Transaction, string, error) {
+	if *q.stale {
+		return nil, "", nil
+	}
+	return q.Queries.FindTransaction(ctx, requestID)
+}
+
+func (q racingQueries) FindSpooled(ctx context.Context, requestID string) (string, string, error) {
+	if *q.stale {
+		*q.stale = false
+		return "", "", nil
+	}
+	return q.Queries.FindSpooled(ctx, requestID)
+}
+
+func TestCreateTransferDuplicateRace(t *testing.T) {
+	led, repo := newLedger(t, "zone-eu")
+	ctx := context.Background()
+	first, _, err := led.CreateTransfer(ctx, transfer("req-1", 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stale := true
+	racing := ledger.NewWithRepo(racingRepo{repo, &stale}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	again, _, err := racing.CreateTransfer(ctx, transfer("req-1", 10))
+	if err != nil || again == nil || again.ID != first.ID {
+		t.Fatalf("lost race: txn=%v err=%v, want idempotent hit on %s", again, err, first.ID)
+	}
+
+	stale = true
+	changed := transfer("req-1", 10)
+	changed.PayloadHash = "other"
+	if _, _, err := racing.CreateTransfer(ctx, changed); !ledger.IsIdempotencyConflict(err) {
+		t.Fatalf("lost race with changed payload: err = %v, want idempotency conflict", err)
+	}
+	if n := len(repo.Transactions()); n != 1 {
+		t.Fatalf("%d transactions, want 1", n)
+	}
+	assertBalanced(t, repo)
+}
+
+func TestCreateTransferRetriesSerializationFailures(t *testing.T) {
+	led, repo := newLedger(t, "zone-eu")
+	ctx := context.Background()
+	led.SetTransferIsolation(ledger.IsolationSerializable, 2)
+
+	repo.FailCommits(2)
+	if _, _, err := led.CreateTransfer(ctx, transfer("req-1", 10)); err != nil {
+		t.Fatalf("retried transfer: %v", err)
+	}
+	if got := repo.Isolations(); len(got) != 3 || got[0] != ledger.IsolationSerializable {
+		t.Fatalf("transactions = %v, want 3 serializable attempts", got)
+	}
+	if n := len(repo.Transactions()); n != 1 {
+		t.Fatalf("%d transactions, want 1", n)
+	}
+
+	repo.FailCommits(3)
+	if _, _, err := led.CreateTransfer(ctx, transfer("req-2", 10)); !ledger.IsSerializationFailure(err) {
+		t.Fatalf("err = %v once retries ran out, want a serialization failure", err)
+	}
+	if n := len(repo.Transactions()); n != 1 {
+		t.Fatalf("%d transactions after the failed transfer, want 1", n)
+	}
+	assertBalanced(t, repo)
+}
+
+func TestCreateTransferGating(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("down without spool rejects and records nothing", func(t *testing.T) {
+		led, repo := newLedger(t, "zone-eu")
+		repo.SetZoneStatus("zone-eu", "DOWN")
+		if _, _, err := led.CreateTransfer(ctx, transfer("req-1", 10)); !ledger.IsZoneDown(err) {
+			t.Fatalf("err = %v, want zone down", err)
+		}
+		if len(repo.Transactions()) != 0 || len(repo.Spool()) != 0 || len(repo.Balances()) != 0 {
+			t.Fatal("rejected transfer left records behind")
+		}
+	})
+
+	t.Run("unknown zone", func(t *testing.T) {
+		led, _ := newLedger(t, "zone-eu")
+		in := transfer("req-1", 10)
+		in.ZoneID = "zone-nowhere"
+		if _, _, err := led.CreateTransfer(ctx, in); !ledger.IsZoneNotFound(err) {
+			t.Fatalf("err = %v, want zone not found", err)
+		}
+	})
+
+	t.Run("retired zone", func(t *testing.T) {
+		led, repo := newLedger(t, "zone-eu")
+		repo.RetireZone("zone-eu")
+		if _, _, err := led.CreateTransfer(ctx, transfer("req-1", 10)); !ledger.IsZoneNotFound(err) {
+			t.Fatalf("err = %v, want zone not found", err)
+		}
+		if len(repo.Transactions()) != 0 || len(repo.Spool()) != 0 {
+			t.Fatal("retired zone recorded a transfer")
+		}
+	})
+
+	t.Run("blocked debits reject", func(t *testing.T) {
+		led, repo := newLedger(t, "zone-eu")
+		repo.SetAccountControls(ledger.AccountControls{AccountID: "acct-a", DebitsBlocked: true, Throttle: 100})
+		if _, _, err := led.CreateTransfer(ctx, transfer("req-1", 10)); !ledger.IsAccountBlocked(err) {
+			t.Fatalf("err = %v, want account blocked", err)
+		}
+	})
+
+	t.Run("strict accounts reject unknown accounts", func(t *testing.T) {
+		led, repo := newLedger(t, "zone-eu")
+		led.SetStrictAccounts(true)
+		repo.AddAccount("acct-a", "zone-eu")
+		if _, _, err := led.CreateTransfer(ctx, transfer("req-1", 10)); !ledger.IsAccountNotFound(err) {
+			t.Fatalf("err = %v, want account not found", err)
+		}
+		if len(repo.Transactions()) != 0 || len(repo.Spool()) != 0 {
+			t.Fatal("rejected transfer left records behind")
+		}
+		repo.AddAccount("acct-b", "zone-eu")
+		if _, _, err := led.CreateTransfer(ctx, transfer("req-1", 10)); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("rate limit", func(t *testing.T) {
+		led, repo := newLedger(t, "zone-eu")
+		repo.SetZoneControls(ledger.ZoneControls{ZoneID: "zone-eu", CrossZoneThrottle: 100, ThrottleMode: ledger.ThrottleModeRate, RateLimitPerSec: 1, RateLimitBurst: 1})
+		if _, _, err := led.CreateTransfer(ctx, transfer("req-1", 10)); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := led.CreateTransfer(ctx, transfer("req-2", 10)); !ledger.IsRateLimited(err) {
+			t.Fatalf("second transfer on a frozen clock err = %v, want rate limited", err)
+		}
+	})
+
+	t.Run("rate limit token is taken in the transfer's transaction", func(t *testing.T) {
+		led, repo := newLedger(t, "zone-eu")
+		led.SetTransferIsolation(ledger.IsolationSerializable, 2)
+		repo.SetZoneControls(ledger.ZoneControls{ZoneID: "zone-eu", CrossZoneThrottle: 100, ThrottleMode: ledger.ThrottleModeRate, RateLimitPerSec: 1, RateLimitBurst: 2})
+		repo.FailCommits(2)
+		if _, _, err := led.CreateTransfer(ctx, transfer("req-1", 10)); err != nil {
+			t.Fatalf("retried transfer: %v", err)
+		}
+		// one transaction per attempt: the token needs no second connection,
+		// and the failed attempts gave theirs back
+		if got := repo.Isolations(); len(got) != 3 {
+			t.Fatalf("transactions = %v, want 3 attempts", got)
+		}
+		if _, _, err := led.CreateTransfer(ctx, transfer("req-2", 10)); err != nil {
+			t.Fatalf("second transfer: %v, want the second token", err)
+		}
+		if _, _, err := led.CreateTransfer(ctx, transfer("req-3", 10)); !ledger.IsRateLimited(err) {
+			t.Fatalf("third transfer err = %v, want rate limited", err)
+		}
+	})
+
+	t.Run("blocked actions override spool_enabled per cause", func(t *testing.T) {
+		led, repo := newLedger(t, "zone-eu")
+		repo.SetZoneControls(ledger.ZoneControls{ZoneID: "zone-eu", CrossZoneThrottle: 0, SpoolEnabled: true, BlockedActions: map[string]string{"throttled": ledger.BlockedActionReject}})
+		if _, _, err := led.CreateTransfer(ctx, transfer("req-1", 10)); !ledger.IsRateLimited(err) {
+			t.Fatalf("rejected throttle err = %v, want rate limited (429)", err)
+		}
+		repo.SetZoneControls(ledger.ZoneControls{ZoneID: "zone-eu", WritesBlocked: true, CrossZoneThrottle: 100, BlockedActions: map[string]string{"writes_blocked": ledger.BlockedActionSpool}})
+		if _, spoolID, err := led.CreateTransfer(ctx, transfer("req-2", 10)); err != nil || spoolID == nil {
+			t.Fatalf("spool = %v, %v, want spooled without spool_enabled", spoolID, err)
+		}
+	})
+
+	t.Run("delay lets a hash throttle through late", func(t *testing.T) {
+		led, repo := newLedger(t, "zone-eu")
+		repo.SetZoneControls(ledger.ZoneControls{ZoneID: "zone-eu", CrossZoneThrottle: 0, BlockedActions: map[string]string{"throttled": ledger.BlockedActionDelay}, BlockedDelayMs: 20})
+		start := time.Now()
+		txn, _, err := led.CreateTransfer(ctx, transfer("req-1", 10))
+		if err != nil || txn == nil {
+			t.Fatalf("delayed transfer = %v, %v", txn, err)
+		}
+		if d := time.Since(start); d < 20*time.Millisecond {
+			t.Fatalf("applied after %s, want a 20ms delay first", d)
+		}
+	})
+
+	t.Run("delay falls back when the block stays", func(t *testing.T) {
+		led, repo := newLedger(t, "zone-eu")
+		repo.SetZoneStatus("zone-eu", "DOWN")
+		repo.SetZoneControls(ledger.ZoneControls{ZoneID: "zone-eu", CrossZoneThrottle: 100, SpoolEnabled: true, BlockedActions: map[string]string{"zone_down": ledger.BlockedActionDelay}, BlockedDelayMs: 1})
+		if _, spoolID, err := led.CreateTransfer(ctx, transfer("req-1", 10)); err != nil || spoolID == nil {
+			t.Fatalf("spool = %v, %v, want spooled after the delay", spoolID, err)
+		}
+	})
+}
+
+func TestSpoolAndReplay(t *testing.T) {
+	led, repo := newLedger(t, "zone-eu")
+	ctx := context.Background()
+	repo.SetZoneControls(ledger.ZoneControls{ZoneID: "zone-eu", WritesBlocked: true, CrossZoneThrottle: 100, SpoolEnabled: true})
+
+	_, spoolID, err := led.CreateTransfer(ctx, transfer("req-1", 40))
+	if err != nil || spoolID == nil {
+		t.Fatalf("blocked transfer: spool=%v err=%v, want spooled", spoolID, err)
+	}
+	if _, again, _ := led.CreateTransfer(ctx, transfer("req-1", 40)); again == nil || *again != *spoolID {
+		t.Fatalf("retry of spooled transfer = %v, want %s", again, *spoolID)
+	}
+	if _, err := led.ReplaySpool(ctx, "zone-eu", 10, "ops", "", "too early"); err == nil {
+		t.Fatal("replay while writes are blocked should be refused")
+	}
+
+	repo.SetZoneControls(ledger.ZoneControls{ZoneID: "zone-eu", CrossZoneThrottle: 100, SpoolEnabled: true})
+	res, err := led.ReplaySpool(ctx, "zone-eu", 10, "ops", "", "recovered")
+	if err != nil || res.Applied != 1 || res.Failed != 0 {
+		t.Fatalf("replay = %+v, %v", res, err)
+	}
+	if s := repo.Spool(); len(s) != 1 || s[0].Status != "APPLIED" {
+		t.Fatalf("spool = %+v", s)
+	}
+	assertBalanced(t, repo)
+
+	out := repo.Outbox()
+	if len(out) != 3 || out[0].EventType != "TRANSFER_SPOOLED" || out[1].EventType != "TRANSFER_POSTED" || out[2].EventType != "SPOOL_ITEM_APPLIED" {
+		t.Fatalf("outbox = %+v", out)
+	}
+	var payload map[string]any
+	_ = json.Unmarshal(out[1].Payload, &payload)
+	if payload["spool_id"] != *spoolID || payload["spool_reason"] != "writes blocked" {
+		t.Fatalf("replayed event payload = %v", payload)
+	}
+	var applied map[string]any
+	_ = json.Unmarshal(out[2].Payload, &applied)
+	if out[2].AggregateID != *spoolID || applied["transaction_id"] != payload["transaction_id"] || applied["zone_id"] != "zone-eu" {
+		t.Fatalf("applied event = %s", out[2].Payload)
+	}
+	audit := repo.Audit()
+	if last := audit[len(audit)-1]; last.Action != "REPLAY_SPOOL" || last.Details["applied"] != 1 {
+		t.Fatalf("last audit = %+v", last)
+	}
+}
+
+func TestReplayHoldsPartitionedEntries(t *testing.T) {
+	led, repo := newLedger(t, "zone-eu", "zone-na")
+	ctx := context.Background()
+	repo.AddAccount("acct-b", "zone-na")
+	repo.AddPartition("zone-eu", "zone-na", ledger.PartitionModeSpool)
+
+	_, spoolID, err := led.CreateTransfer(ctx, transfer("req-1", 5))
+	if err != nil || spoolID == nil {
+		t.Fatalf("partitioned transfer: spool=%v err=%v", spoolID, err)
+	}
+	res, err := led.ReplaySpool(ctx, "zone-eu", 10, "ops", "", "")
+	if err != nil || res.Skipped != 1 || res.Applied != 0 {
+		t.Fatalf("replay across partition = %+v, %v", res, err)
+	}
+
+	repo.HealPartition("zone-eu", "zone-na")
+	res, err = led.ReplaySpool(ctx, "zone-eu", 10, "ops", "", "")
+	if err != nil || res.Applied != 1 {
+		t.Fatalf("replay after heal = %+v, %v", res, err)
+	}
+	var payload map[string]any
+	_ = json.Unmarshal(repo.Outbox()[1].Payload, &payload)
+	if payload["to_zone_id"] != "zone-na" {
+		t.Fatalf("cross-zone event payload = %v", payload)
+	}
+}
+
+func TestTransferTypes(t *testing.T) {
+	led, repo := newLedger(t, "zone-eu")
+	ctx := context.Background()
+	eventType := func(ev ledger.OutboxEvent) any {
+		var p map[string]any
+		_ = json.Unmarshal(ev.Payload, &p)
+		return p["type"]
+	}
+
+	if _, _, err := led.CreateTransfer(ctx, transfer("req-plain", 10)); err != nil {
+		t.Fatal(err)
+	}
+	for _, typ := range []string{"SETTLEMENT", "BONUS"} {
+		in := transfer("req-"+typ, 10)
+		in.Type = typ
+		if _, _, err := led.CreateTransfer(ctx, in); !ledger.IsInvalidTransactionType(err) {
+			t.Fatalf("type %s: err = %v, want invalid transaction type", typ, err)
+		}
+	}
+	if out := repo.Outbox(); len(out) != 1 || eventType(out[0]) != "TRANSFER" {
+		t.Fatalf("outbox = %+v, want one TRANSFER", out)
+	}
+
+	// a spooled fee is posted as a fee when replayed
+	repo.SetZoneControls(ledger.ZoneControls{ZoneID: "zone-eu", WritesBlocked: true, CrossZoneThrottle: 100, SpoolEnabled: true})
+	fee := transfer("req-fee", 5)
+	fee.Type = ledger.TxnTypeFee
+	if _, spoolID, err := led.CreateTransfer(ctx, fee); err != nil || spoolID == nil {
+		t.Fatalf("fee: spool=%v err=%v, want spooled", spoolID, err)
+	}
+	repo.SetZoneControls(ledger.ZoneControls{ZoneID: "zone-eu", CrossZoneThrottle: 100, SpoolEnabled: true})
+	if res, err := led.ReplaySpool(ctx, "zone-eu", 10, "ops", "", "recovered"); err != nil || res.Applied != 1 {
+		t.Fatalf("replay = %+v, %v", res, err)
+	}
+	out := repo.Outbox()
+	if len(out) != 4 || eventType(out[1]) != "FEE" || out[2].EventType != "TRANSFER_POSTED" || eventType(out[2]) != "FEE" {
+		t.Fatalf("outbox = %+v, want the fee spooled and posted as FEE", out)
+	}
+}
+
+func TestAmountLimits(t *testing.T) {
+	led, repo := newLedger(t, "zone-eu")
+	ctx := context.Background()
+	repo.SetZoneControls(ledger.ZoneControls{ZoneID: "zone-eu", CrossZoneThrottle: 100, MinAmountUnits: 10, MaxAmountUnits: 500, DailyAccountLimitUnits: 800})
+
+	if _, _, err := led.CreateTransfer(ctx, transfer("req-small", 5)); !ledger.IsAmountBelowMinimum(err) {
+		t.Fatalf("below min: err = %v", err)
+	}
+	if _, _, err := led.CreateTransfer(ctx, transfer("req-big", 501)); !ledger.IsAmountAboveMaximum(err) {
+		t.Fatalf("above max: err = %v", err)
+	}
+	for _, req := range []string{"req-1", "req-2"} {
+		if _, _, err := led.CreateTransfer(ctx, transfer(req, 400)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, _, err := led.CreateTransfer(ctx, transfer("req-3", 10)); !ledger.IsDailyLimitExceeded(err) {
+		t.Fatalf("over the daily cap: err = %v", err)
+	}
+
+	// an override lets the transfer through and is audited
+	over := transfer("req-3", 10)
+	over.LimitOverride = &ledger.LimitOverride{Actor: "ops", Reason: "month-end payroll"}
+	if txn, _, err := led.CreateTransfer(ctx, over); err != nil || txn == nil {
+		t.Fatalf("override: txn=%v err=%v", txn, err)
+	}
+	var audited int
+	for _, a := range repo.Audit() {
+		if a.Action == "OVERRIDE_AMOUNT_LIMIT" && a.Actor == "ops" && a.TargetID == "zone-eu" {
+			audited++
+		}
+	}
+	if audited != 1 {
+		t.Fatalf("audit = %+v, want one OVERRIDE_AMOUNT_LIMIT", repo.Audit())
+	}
+
+	// the cap starts over the next day
+	clock := ledger.NewVirtualClock()
+	clock.Freeze(time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC))
+	led.SetClock(clock)
+	if _, _, err := led.CreateTransfer(ctx, transfer("req-4", 400)); err != nil {
+		t.Fatalf("next day: %v", err)
+	}
+	assertBalanced(t, repo)
+}
@@ -0,0 +1,68 @@
+package ledger
+
+import (
+  "errors"
+  "fmt"
+  "slices"
+)
+
+var ErrInvalidTransactionType = errors.New("invalid transaction type")
+
+func IsInvalidTransactionType(err error) bool { return errors.Is(err, ErrInvalidTransactionType) }
+
+// Transaction types (migration 0048).
+const (
+  TxnTypeTransfer = "TRANSFER"
+  TxnTypeFee = "FEE"
+  TxnTypeReversal = "REVERSAL"
+  TxnTypeSettlement = "SETTLEMENT"
+  TxnTypeAccrual = "ACCRUAL"
+  TxnTypeSeed = "SEED"
+)
+
+// TransactionType is an entry of the transaction type catalog.
+type TransactionType struct {
+  Code string `json:"code"`
+  Description string `json:"description"`
+  // System types are posted by the ledger itself and refused from clients.
+  System bool `json:"system"`
+}
+
+var transactionTypes = []TransactionType{
+  {Code: TxnTypeTransfer, Description: "Client transfer (the default)"},
+  {Code: TxnTypeFee, Description: "Fee charged by a client"},
+  {Code: TxnTypeReversal, Description: "Reverses an earlier transfer, including saga compensations"},
+  {Code: TxnTypeSettlement, Description: "Net settlement of a zone pair", System: true},
+  {Code: TxnTypeAccrual, Description: "Interest or fee accrual run", System: true},
+  {Code: TxnTypeSeed, Description: "Generated seed data", System: true},
+}
+
+// TransactionTypes is the catalog, in the order above.
+func TransactionTypes() []TransactionType { return slices.Clone(transactionTypes) }
+
+func findTransactionType(code string) (TransactionType, bool) {
+  i := slices.IndexFunc(transactionTypes, func(t TransactionType) bool { return t.Code == code })
+  if i < 0 { return TransactionType{}, false }
+  return transactionTypes[i], true
+}
+
+func knownTransactionType(code string) bool {
+  _, ok := findTransactionType(code)
+  return ok
+}
+
+// checkTransactionType validates the type of a client's transfer: "" (which
+// is TRANSFER) or a catalog type that is not a system type.
+func checkTransactionType(code string) error {
+  if code == "" { return nil }
+  t, ok := findTransactionType(code)
+  if !ok { return fmt.Errorf("%w %q: not in the catalog (see GET /v1/transaction-types)", ErrInvalidTransactionType, code) }
+  if t.System { return fmt.Errorf("%w %q: posted only by the ledger", ErrInvalidTransactionType, code) }
+  return nil
+}
+
+// transactionType is the type a transfer is recorded with.
+func transactionType(in CreateTransferInput) string {
+  if in.Type == "" { return TxnTypeTransfer }
+  return in.Type
+}
@@ -0,0 +1,47 @@
+package ledger
+
+import (
+  "context"
+  "fmt"
+  "time"
+
+  "github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ListVersion cheaply identifies the state of a polled resource: the row count
+// plus the latest updated_at. Adding, removing or updating a row changes it, so
+// handlers can answer If-None-Match without loading the rows.
+type ListVersion struct {
+  Count int64
+  UpdatedAt time.Time
+}
+
+// ETag renders the version as a weak entity tag; the body encoding (e.g.
+// compression) may differ for the same version.
+func (v ListVersion) ETag() string {
+  return fmt.Sprintf(`W/"%d-%x"`, v.Count, v.UpdatedAt.UnixMicro())
+}
+
+func (l *Ledger) ZonesVersion(ctx context.Context) (ListVersion, error) {
+  return listVersion(ctx, l.db, `SELECT count(*), max(updated_at) FROM zones WHERE retired_at IS NULL`)
+}
+
+func (l *Ledger) ZoneControlsVersion(ctx context.Context, zoneID string) (ListVersion, error) {
+  return listVersion(ctx, l.db, `SELECT count(*), max(updated_at) FROM zone_controls WHERE zone_id=$1`, zoneID)
+}
+
+// IncidentsVersion covers every incident, or one zone's when zoneID is set.
+// It reads the same pool as the incident lists, so an ETag never names rows
+// the replica has not served yet.
+func (l *Ledger) IncidentsVersion(ctx context.Context, zoneID string) (ListVersion, error) {
+  if zoneID == "" { return listVersion(ctx, l.ro, `SELECT count(*), max(updated_at) FROM incidents`) }
+  return listVersion(ctx, l.ro, `SELECT count(*), max(updated_at) FROM incidents WHERE zone_id=$1`, zoneID)
+}
+
+func listVersion(ctx context.Context, db *pgxpool.Pool, sql string, args ...any) (ListVersion, error) {
+  var v ListVersion
+  var at *time.Time
+  if err := db.QueryRow(ctx, sql, args...).Scan(&v.Count, &at); err != nil { return ListVersion{}, err }
+  if at != nil { v.UpdatedAt = *at }
+  return v, nil
+}
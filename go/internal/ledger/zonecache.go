@@ -0,0 +1,150 @@
+package ledger
+
+import (
+  "context"
+  "sync"
+  "sync/atomic"
+  "time"
+
+  "github.com/jackc/pgx/v5/pgxpool"
+  "log/slog"
+
+  "time-ledger-sim/go/internal/metrics"
+  "time-ledger-sim/go/internal/store"
+)
+
+// ZoneChangedChannel is the NOTIFY channel the zones and zone_controls
+// triggers (migration 0017) signal with the changed zone's id.
+const ZoneChangedChannel = "zone_changed"
+
+// zoneCache keeps each zone's status and controls for the transfer path, so a
+// transfer does not query them in its transaction. Entries expire after the
+// TTL and are dropped on every zone_changed notification; the cache is only
+// used while ZoneCacheListener holds its LISTEN, so a lost notification
+// connection falls back to reading in the transaction. A TTL of 0 (strict)
+// disables it.
+type zoneCache struct {
+  ttl atomic.Int64 // time.Duration; 0 = strict
+  listening atomic.Bool
+  mu sync.Mutex
+  gen uint64 // bumped by every invalidation; a fill started before one is discarded
+  entries map[string]zoneEntry
+}
+
+type zoneEntry struct {
+  status string
+  controls ZoneControls
+  expires time.Time
+}
+
+func newZoneCache() *zoneCache { return &zoneCache{entries: map[string]zoneEntry{}} }
+
+func (c *zoneCache) active() bool { return c.ttl.Load() > 0 && c.listening.Load() }
+
+// get returns a live entry, or the generation to pass to put after a miss.
+func (c *zoneCache) get(zoneID string) (zoneEntry, uint64, bool) {
+  c.mu.Lock()
+  defer c.mu.Unlock()
+  e, ok := c.entries[zoneID]
+  if ok && time.Now().Before(e.expires) { return e, c.gen, true }
+  return zoneEntry{}, c.gen, false
+}
+
+func (c *zoneCache) put(zoneID string, gen uint64, status string, controls ZoneControls) {
+  c.mu.Lock()
+  defer c.mu.Unlock()
+  if gen != c.gen { return }
+  c.entries[zoneID] = zoneEntry{status: status, controls: controls, expires: time.Now().Add(time.Duration(c.ttl.Load()))}
+}
+
+// invalidate drops one zone, or every zone when zoneID is "".
+func (c *zoneCache) invalidate(zoneID string) {
+  c.mu.Lock()
+  defer c.mu.Unlock()
+  c.gen++
+  if zoneID == "" {
+    clear(c.entries)
+    return
+  }
+  delete(c.entries, zoneID)
+}
+
+// SetZoneCacheTTL sets how long the transfer path may reuse a zone's status
+// and controls; 0 makes it read them in every transfer's transaction.
+func (l *Ledger) SetZoneCacheTTL(ttl time.Duration) {
+  if ttl < 0 { ttl = 0 }
+  if l.zones.ttl.Swap(int64(ttl)) != int64(ttl) { l.zones.invalidate("") }
+}
+
+// zoneState returns the zone's status and controls, from the cache when it is
+// active and otherwise from q (creating the default controls row).
+func (l *Ledger) zoneState(ctx context.Context, q Queries, zoneID string) (string, *ZoneControls, error) {
+  // the cache is shared by every tenant; a tenant's reads go to the database,
+  // where row-level security applies to them
+  _, scoped := store.Tenant(ctx)
+  cached := l.zones.active() && !scoped
+  var gen uint64
+  if cached {
+    e, g, ok := l.zones.get(zoneID)
+    if ok {
+      metrics.ZoneCacheLookups.WithLabelValues("hit").Inc()
+      c := e.controls
+      return e.status, &c, nil
+    }
+    metrics.ZoneCacheLookups.WithLabelValues("miss").Inc()
+    gen = g
+  }
+
+  status, err := q.ZoneStatus(ctx, zoneID)
+  if err != nil { return "", nil, err }
+  controls, err := q.ZoneControls(ctx, zoneID)
+  if err != nil { return "", nil, err }
+  if cached { l.zones.put(zoneID, gen, status, *controls) }
+  return status, controls, nil
+}
+
+// ZoneCacheListener keeps a LISTEN on ZoneChangedChannel and invalidates the
+// ledger's zone cache on each notification. Every replica runs one.
+type ZoneCacheListener struct {
+  led *Ledger
+  db *pgxpool.Pool
+  log *slog.Logger
+}
+
+func NewZoneCacheListener(led *Ledger, db *pgxpool.Pool, log *slog.Logger) *ZoneCacheListener {
+  return &ZoneCacheListener{led: led, db: db, log: log}
+}
+
+// Run listens until ctx is done, reconnecting after a second when the
+// connection fails. The cache is off (cleared) while it is not listening.
+func (z *ZoneCacheListener) Run(ctx context.Context) {
+  for {
+    err := z.listen(ctx)
+    z.led.zones.listening.Store(false)
+    z.led.zones.invalidate("")
+    if ctx.Err() != nil { return }
+    z.log.Warn("zone cache listener stopped; transfers read zones from the database", "err", err.Error())
+    select {
+    case <-ctx.Done():
+      return
+    case <-time.After(time.Second):
+    }
+  }
+}
+
+func (z *ZoneCacheListener) listen(ctx context.Context) error {
+  pc, err := z.db.Acquire(ctx)
+  if err != nil { return err }
+  // a LISTEN session must not return to the pool
+  conn := pc.Hijack()
+  defer func() { _ = conn.Close(context.WithoutCancel(ctx)) }()
+  if _, err := conn.Exec(ctx, `LISTEN `+ZoneChangedChannel); err != nil { return err }
+  // anything changed before the LISTEN is not notified: start empty
+  z.led.zones.invalidate("")
+  z.led.zones.listening.Store(true)
+  for {
+    n, err := conn.WaitForNotification(ctx)
+    if err != nil { return err }
+    z.led.zones.invalidate(n.Payload)
+  }
+}
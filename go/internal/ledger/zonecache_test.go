@@ -0,0 +1,71 @@
+package ledger
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// zoneReads counts the zone lookups that reach the database.
+type zoneReads struct {
+	Queries
+	status string
+	n      int
+}
+
+func (z *zoneReads) ZoneStatus(ctx context.Context, zoneID string) (string, error) {
+	z.n++
+	return z.status, nil
+}
+
+func (z *zoneReads) ZoneControls(ctx context.Context, zoneID string) (*ZoneControls, error) {
+	return &ZoneControls{ZoneID: zoneID, CrossZoneThrottle: 100}, nil
+}
+
+func TestZoneCache(t *testing.T) {
+	l := NewWithRepo(nil, nil)
+	q := &zoneReads{status: "OK"}
+	ctx := context.Background()
+	read := func() string {
+		t.Helper()
+		status, _, err := l.zoneState(ctx, q, "zone-eu")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return status
+	}
+
+	l.SetZoneCacheTTL(time.Minute)
+	read()
+	read()
+	if q.n != 2 {
+		t.Fatalf("cache used without a LISTEN: %d reads", q.n)
+	}
+
+	l.zones.listening.Store(true)
+	read()
+	read()
+	if q.n != 3 {
+		t.Fatalf("reads = %d, want 3 (one miss, then hits)", q.n)
+	}
+
+	q.status = "DOWN"
+	l.zones.invalidate("zone-eu")
+	if got := read(); got != "DOWN" || q.n != 4 {
+		t.Fatalf("after invalidate: status %s, reads %d", got, q.n)
+	}
+
+	// a fill that started before an invalidation must not be stored
+	_, gen, _ := l.zones.get("zone-na")
+	l.zones.invalidate("zone-na")
+	l.zones.put("zone-na", gen, "OK", ZoneControls{})
+	if _, _, ok := l.zones.get("zone-na"); ok {
+		t.Fatal("stale fill cached")
+	}
+
+	l.SetZoneCacheTTL(0) // strict
+	read()
+	if q.n != 5 {
+		t.Fatalf("strict mode served from cache: %d reads", q.n)
+	}
+}
@@ -0,0 +1,131 @@
+package ledger
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "regexp"
+
+  "github.com/jackc/pgx/v5"
+)
+
+var (
+  ErrZoneNotFound = errors.New("zone not found")
+  ErrZoneExists = errors.New("zone already exists")
+  ErrZoneNotRetirable = errors.New("zone not retirable")
+)
+
+func IsZoneNotFound(err error) bool { return errors.Is(err, ErrZoneNotFound) }
+func IsZoneExists(err error) bool { return errors.Is(err, ErrZoneExists) }
+func IsZoneNotRetirable(err error) bool { return errors.Is(err, ErrZoneNotRetirable) }
+
+var zoneIDPattern = regexp.MustCompile(`^zone-[a-z0-9-]+$`)
+
+func ValidZoneID(id string) bool { return zoneIDPattern.MatchString(id) }
+
+type CreateZoneInput struct {
+  ID string
+  Name string
+  Tags map[string]string
+  Actor string
+  Reason string
+}
+
+// CreateZone adds a zone (status OK) with default controls. A previously
+// retired zone with the same ID is reactivated rather than rejected.
+func (l *Ledger) CreateZone(ctx context.Context, in CreateZoneInput) (*Zone, error) {
+  if !ValidZoneID(in.ID) { return nil, fmt.Errorf("invalid zone id") }
+  if in.Name == "" { return nil, fmt.Errorf("name required") }
+  tags, err := normalizeZoneTags(in.Tags)
+  if err != nil { return nil, err }
+
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  var z Zone
+  err = tx.QueryRow(ctx, `
+    INSERT INTO zones(id,name,status,tags,updated_at) VALUES($1,$2,'OK',$3,now())
+    ON CONFLICT (id) DO UPDATE
+      SET name=EXCLUDED.name, status='OK', tags=EXCLUDED.tags, retired_at=NULL, updated_at=now()
+      WHERE zones.retired_at IS NOT NULL
+    RETURNING id,name,status,tags,updated_at
+  `, in.ID, in.Name, tags).Scan(&z.ID, &z.Name, &z.Status, &z.Tags, &z.UpdatedAt)
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrZoneExists }
+  if err != nil { return nil, err }
+
+  _, err = tx.Exec(ctx, `INSERT INTO zone_controls(zone_id) VALUES($1) ON CONFLICT DO NOTHING`, in.ID)
+  if err != nil { return nil, err }
+
+  err = l.audit(ctx, pgQueries{tx}, AuditRecord{
+    Actor: in.Actor, Action: "CREATE_ZONE", TargetType: "zone", TargetID: in.ID, Reason: in.Reason,
+    Details: map[string]any{"name": in.Name, "tags": tags},
+  })
+  if err != nil { return nil, err }
+
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  l.zones.invalidate(in.ID)
+  return &z, nil
+}
+
+type RetireZoneInput struct {
+  MigrateAccountsTo string // optional target zone for remaining accounts
+  Actor string
+  Reason string
+}
+
+// RetireZone soft-deletes a zone. It refuses while spooled transfers are
+// pending or accounts still live in the zone, unless MigrateAccountsTo names
+// an active zone to move them to.
+func (l *Ledger) RetireZone(ctx context.Context, zoneID string, in RetireZoneInput) error {
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  // FOR UPDATE waits for transfers holding the key-share lock PostTransfer
+  // and InsertSpooled take, so their accounts and spool entries are counted
+  // below; a transfer that locks the row after this reads it as retired
+  var retired bool
+  err = tx.QueryRow(ctx, `SELECT retired_at IS NOT NULL FROM zones WHERE id=$1 FOR UPDATE`, zoneID).Scan(&retired)
+  if errors.Is(err, pgx.ErrNoRows) || retired { return ErrZoneNotFound }
+  if err != nil { return err }
+
+  var pending int64
+  if err := tx.QueryRow(ctx, `SELECT COUNT(*) FROM spooled_transfers WHERE zone_id=$1 AND status='PENDING'`, zoneID).Scan(&pending); err != nil { return err }
+  if pending > 0 {
+    return fmt.Errorf("%w: %d pending spooled transfers", ErrZoneNotRetirable, pending)
+  }
+
+  migrated := int64(0)
+  if in.MigrateAccountsTo != "" {
+    if in.MigrateAccountsTo == zoneID { return fmt.Errorf("%w: cannot migrate accounts to the retiring zone", ErrZoneNotRetirable) }
+    var ok bool
+    err := tx.QueryRow(ctx, `SELECT retired_at IS NULL FROM zones WHERE id=$1`, in.MigrateAccountsTo).Scan(&ok)
+    if errors.Is(err, pgx.ErrNoRows) || (err == nil && !ok) {
+      return fmt.Errorf("%w: migration target %s", ErrZoneNotFound, in.MigrateAccountsTo)
+    }
+    if err != nil { return err }
+    ct, err := tx.Exec(ctx, `UPDATE accounts SET zone_id=$2 WHERE zone_id=$1`, zoneID, in.MigrateAccountsTo)
+    if err != nil { return err }
+    migrated = ct.RowsAffected()
+  }
+
+  var accounts int64
+  if err := tx.QueryRow(ctx, `SELECT COUNT(*) FROM accounts WHERE zone_id=$1`, zoneID).Scan(&accounts); err != nil { return err }
+  if accounts > 0 {
+    return fmt.Errorf("%w: %d accounts not migrated", ErrZoneNotRetirable, accounts)
+  }
+
+  _, err = tx.Exec(ctx, `UPDATE zones SET retired_at=now(), status='DOWN', updated_at=now() WHERE id=$1`, zoneID)
+  if err != nil { return err }
+
+  err = l.audit(ctx, pgQueries{tx}, AuditRecord{
+    Actor: in.Actor, Action: "RETIRE_ZONE", TargetType: "zone", TargetID: zoneID, Reason: in.Reason,
+    Details: map[string]any{"migrated_accounts": migrated, "migrate_accounts_to": in.MigrateAccountsTo},
+  })
+  if err != nil { return err }
+
+  if err := tx.Commit(ctx); err != nil { return err }
+  l.zones.invalidate(zoneID)
+  return nil
+}
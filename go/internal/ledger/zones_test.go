@@ -0,0 +1,102 @@
+package ledger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"time-ledger-sim/go/internal/store/storetest"
+)
+
+func TestRetireZone(t *testing.T) {
+	db := storetest.Open(t)
+	ctx := context.Background()
+	l := New(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	prefix := "zone-retire-" + uuid.NewString()[:8]
+	a, b, c := prefix+"-a", prefix+"-b", prefix+"-c"
+	for _, id := range []string{a, b, c} {
+		if _, err := l.CreateZone(ctx, CreateZoneInput{ID: id, Name: id, Actor: "test"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	transfer := func(zoneID string) error {
+		_, _, err := l.CreateTransfer(ctx, CreateTransferInput{
+			RequestID: uuid.NewString(), PayloadHash: "h", FromAccount: zoneID + "-x", ToAccount: zoneID + "-y",
+			AmountUnits: 1, ZoneID: zoneID, Metadata: map[string]any{},
+		})
+		return err
+	}
+	if err := transfer(a); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := l.RetireZone(ctx, a, RetireZoneInput{Actor: "test"}); !IsZoneNotRetirable(err) {
+		t.Fatalf("retire with accounts: err = %v", err)
+	}
+	if err := l.RetireZone(ctx, a, RetireZoneInput{MigrateAccountsTo: a, Actor: "test"}); !IsZoneNotRetirable(err) {
+		t.Fatalf("migrate to itself: err = %v", err)
+	}
+	if err := l.RetireZone(ctx, a, RetireZoneInput{MigrateAccountsTo: b, Actor: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	if zone, err := l.repo.AccountZone(ctx, a+"-x"); err != nil || zone != b {
+		t.Fatalf("migrated account zone = %q, %v", zone, err)
+	}
+	if err := l.RetireZone(ctx, a, RetireZoneInput{Actor: "test"}); !IsZoneNotFound(err) {
+		t.Fatalf("retire twice: err = %v", err)
+	}
+	if err := transfer(a); !IsZoneNotFound(err) {
+		t.Fatalf("transfer in a retired zone: err = %v", err)
+	}
+
+	// A transfer that read the zone before a retire committed waits for it on
+	// the zone row lock and then fails, instead of writing into the zone.
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM zones WHERE id=$1 FOR UPDATE`, c); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec(ctx, `UPDATE zones SET retired_at=now(), status='DOWN' WHERE id=$1`, c); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- transfer(c) }()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var waiting int
+		err := db.QueryRow(ctx, `SELECT COUNT(*) FROM pg_stat_activity WHERE datname=current_database() AND wait_event_type='Lock'`).Scan(&waiting)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if waiting > 0 {
+			break
+		}
+		select {
+		case err := <-done:
+			t.Fatalf("transfer did not wait for the retire: err = %v", err)
+		default:
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("transfer never waited on the zone row")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; !IsZoneNotFound(err) {
+		t.Fatalf("transfer racing a retire: err = %v", err)
+	}
+	var accounts int
+	if err := db.QueryRow(ctx, `SELECT COUNT(*) FROM accounts WHERE zone_id=$1`, c).Scan(&accounts); err != nil || accounts != 0 {
+		t.Fatalf("retired zone accounts = %d, %v", accounts, err)
+	}
+}
@@ -0,0 +1,57 @@
+package ledger
+
+import (
+  "context"
+  "errors"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+
+  "time-ledger-sim/go/internal/metrics"
+)
+
+// ZoneStats is a zone's throughput over a recent window for the ops UI.
+// Applied, spooled and amount come from the database and cover every instance;
+// rejections and latency are never persisted, so they come from this
+// instance's in-process window (see metrics.ZoneWindowStats).
+type ZoneStats struct {
+  ZoneID string `json:"zone_id"`
+  WindowSeconds int `json:"window_seconds"`
+  Applied int64 `json:"applied"`
+  Spooled int64 `json:"spooled"`
+  AmountUnits int64 `json:"amount_units"` // moved by applied transfers
+  Rejected int64 `json:"rejected"` // this instance
+  Errors int64 `json:"errors"` // this instance
+  LatencyP50Ms *float64 `json:"latency_p50_ms"` // this instance; null without samples
+  LatencyP95Ms *float64 `json:"latency_p95_ms"`
+  LatencySamples int64 `json:"latency_samples"`
+  SpoolDepth int64 `json:"spool_depth"` // PENDING now
+}
+
+// GetZoneStats computes the zone's stats over the last window (at most
+// metrics.MaxWindow).
+func (l *Ledger) GetZoneStats(ctx context.Context, zoneID string, window time.Duration) (*ZoneStats, error) {
+  st := ZoneStats{ZoneID: zoneID, WindowSeconds: int(window.Seconds())}
+  since := l.clock.Now().Add(-window)
+  err := l.ro.QueryRow(ctx, `
+    SELECT
+      (SELECT COUNT(*) FROM transactions WHERE zone_id=z.id AND created_at >= $2),
+      (SELECT COALESCE(SUM(amount_units),0)::bigint FROM transactions WHERE zone_id=z.id AND created_at >= $2),
+      (SELECT COUNT(*) FROM spooled_transfers WHERE zone_id=z.id AND created_at >= $2),
+      (SELECT COUNT(*) FROM spooled_transfers WHERE zone_id=z.id AND status='PENDING')
+    FROM zones z
+    WHERE z.id=$1 AND z.retired_at IS NULL
+  `, zoneID, since).Scan(&st.Applied, &st.AmountUnits, &st.Spooled, &st.SpoolDepth)
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrZoneNotFound }
+  if err != nil { return nil, err }
+
+  w := metrics.ZoneWindowStats(zoneID, window)
+  st.Rejected = w.Outcomes[metrics.OutcomeRejected]
+  st.Errors = w.Outcomes[metrics.OutcomeError]
+  st.LatencySamples = w.Samples
+  if w.Samples > 0 {
+    p50, p95 := float64(w.P50.Microseconds())/1000, float64(w.P95.Microseconds())/1000
+    st.LatencyP50Ms, st.LatencyP95Ms = &p50, &p95
+  }
+  return &st, nil
+}
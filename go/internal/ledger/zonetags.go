@@ -0,0 +1,137 @@
+package ledger
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "maps"
+  "slices"
+  "strings"
+
+  "github.com/jackc/pgx/v5"
+)
+
+var ErrZoneTagNotFound = errors.New("zone tag not found")
+
+func IsZoneTagNotFound(err error) bool { return errors.Is(err, ErrZoneTagNotFound) }
+
+const maxZoneTagValueLen = 128
+
+// ZoneTags is a zone's key=value tags, e.g. region=EMEA, tier=critical.
+type ZoneTags struct {
+  ZoneID string `json:"zone_id"`
+  Tags map[string]string `json:"tags"`
+}
+
+// normalizeZoneTags lower-cases the keys (same rules as transaction tags) and
+// checks the values; values keep their case.
+func normalizeZoneTags(in map[string]string) (map[string]string, error) {
+  tags := map[string]string{}
+  for _, k := range slices.Sorted(maps.Keys(in)) {
+    v := strings.TrimSpace(in[k])
+    k = strings.ToLower(strings.TrimSpace(k))
+    if !tagPattern.MatchString(k) { return nil, fmt.Errorf("invalid tag key %q", k) }
+    if v == "" || len(v) > maxZoneTagValueLen { return nil, fmt.Errorf("tag %s: value must be 1 to %d characters", k, maxZoneTagValueLen) }
+    if old, dup := tags[k]; dup && old != v { return nil, fmt.Errorf("tag %s given twice", k) }
+    tags[k] = v
+  }
+  if len(tags) > maxTags { return nil, fmt.Errorf("more than %d tags", maxTags) }
+  return tags, nil
+}
+
+// ParseTagSelector reads key=value terms (each may hold several separated by
+// commas) into the tags a zone must all carry to match.
+func ParseTagSelector(terms []string) (map[string]string, error) {
+  sel := map[string]string{}
+  for _, term := range terms {
+    for _, kv := range strings.Split(term, ",") {
+      k, v, ok := strings.Cut(kv, "=")
+      if !ok { return nil, fmt.Errorf("tag selector %q is not key=value", kv) }
+      if old, dup := sel[k]; dup && old != v { return nil, fmt.Errorf("tag %s selected twice", k) }
+      sel[k] = v
+    }
+  }
+  return normalizeZoneTags(sel)
+}
+
+// FormatTagSelector is the inverse of ParseTagSelector, in key order.
+func FormatTagSelector(tags map[string]string) string {
+  terms := []string{}
+  for _, k := range slices.Sorted(maps.Keys(tags)) { terms = append(terms, k+"="+tags[k]) }
+  return strings.Join(terms, ",")
+}
+
+// tagSet never returns nil, so a missing selector encodes as {} (matching
+// every zone) rather than JSON null.
+func tagSet(tags map[string]string) map[string]string {
+  if tags == nil { return map[string]string{} }
+  return tags
+}
+
+// GetZoneTags returns an active zone's tags.
+func (l *Ledger) GetZoneTags(ctx context.Context, zoneID string) (*ZoneTags, error) {
+  return getZoneTags(ctx, l.db, zoneID, false)
+}
+
+func getZoneTags(ctx context.Context, q querier, zoneID string, lock bool) (*ZoneTags, error) {
+  sql := `SELECT tags FROM zones WHERE id=$1 AND retired_at IS NULL`
+  if lock { sql += ` FOR UPDATE` }
+  t := ZoneTags{ZoneID: zoneID}
+  err := q.QueryRow(ctx, sql, zoneID).Scan(&t.Tags)
+  if errors.Is(err, pgx.ErrNoRows) { return nil, ErrZoneNotFound }
+  if err != nil { return nil, err }
+  return &t, nil
+}
+
+type TagZoneInput struct {
+  Tags map[string]string
+  Actor string
+  Reason string
+}
+
+// TagZone sets tags on a zone: new keys are added, existing keys take the new
+// value and other tags are kept. A zone carries at most maxTags tags.
+func (l *Ledger) TagZone(ctx context.Context, zoneID string, in TagZoneInput) (*ZoneTags, error) {
+  tags, err := normalizeZoneTags(in.Tags)
+  if err != nil { return nil, err }
+  if len(tags) == 0 { return nil, fmt.Errorf("tags required") }
+  return l.updateZoneTags(ctx, zoneID, in.Actor, func(t *ZoneTags) (AuditRecord, error) {
+    maps.Copy(t.Tags, tags)
+    if len(t.Tags) > maxTags { return AuditRecord{}, fmt.Errorf("more than %d tags on %s", maxTags, zoneID) }
+    return AuditRecord{Action: "TAG_ZONE", Reason: in.Reason, Details: map[string]any{"tags": tags}}, nil
+  })
+}
+
+// UntagZone removes one tag (by key) from a zone.
+func (l *Ledger) UntagZone(ctx context.Context, zoneID, key, actor, reason string) (*ZoneTags, error) {
+  key = strings.ToLower(strings.TrimSpace(key))
+  return l.updateZoneTags(ctx, zoneID, actor, func(t *ZoneTags) (AuditRecord, error) {
+    value, ok := t.Tags[key]
+    if !ok { return AuditRecord{}, fmt.Errorf("%w: %s on %s", ErrZoneTagNotFound, key, zoneID) }
+    delete(t.Tags, key)
+    return AuditRecord{Action: "UNTAG_ZONE", Reason: reason, Details: map[string]any{"key": key, "value": value}}, nil
+  })
+}
+
+// updateZoneTags applies change to an active zone's tags under its row lock
+// and audits the record change returns.
+func (l *Ledger) updateZoneTags(ctx context.Context, zoneID, actor string, change func(*ZoneTags) (AuditRecord, error)) (*ZoneTags, error) {
+  tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+  if err != nil { return nil, err }
+  defer func() { _ = tx.Rollback(ctx) }()
+
+  if err := l.checkActors(ctx, tx, actor); err != nil { return nil, err }
+  t, err := getZoneTags(ctx, tx, zoneID, true)
+  if err != nil { return nil, err }
+  t.Tags = tagSet(t.Tags)
+  rec, err := change(t)
+  if err != nil { return nil, err }
+  // updated_at moves so GET /v1/zones answers with a new ETag
+  if _, err := tx.Exec(ctx, `UPDATE zones SET tags=$2, updated_at=now() WHERE id=$1`, zoneID, t.Tags); err != nil { return nil, err }
+
+  rec.Actor, rec.TargetType, rec.TargetID = actor, "zone", zoneID
+  if err := l.audit(ctx, pgQueries{tx}, rec); err != nil { return nil, err }
+
+  if err := tx.Commit(ctx); err != nil { return nil, err }
+  return t, nil
+}
@@ -0,0 +1,95 @@
+package ledger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"time-ledger-sim/go/internal/store/storetest"
+)
+
+func TestParseTagSelector(t *testing.T) {
+	sel, err := ParseTagSelector([]string{"Region=EMEA,tier=critical", "region=EMEA"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sel) != 2 || sel["region"] != "EMEA" || sel["tier"] != "critical" {
+		t.Fatalf("selector = %v", sel)
+	}
+	if got := FormatTagSelector(sel); got != "region=EMEA,tier=critical" {
+		t.Fatalf("format = %q", got)
+	}
+	for _, bad := range [][]string{{"region"}, {"region=EMEA", "region=APAC"}, {"region="}} {
+		if _, err := ParseTagSelector(bad); err == nil {
+			t.Fatalf("%v: want an error", bad)
+		}
+	}
+}
+
+func TestZoneTags(t *testing.T) {
+	db := storetest.Open(t)
+	ctx := context.Background()
+	l := New(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	prefix := "zone-tag-" + uuid.NewString()[:8]
+	region := "r-" + prefix
+	a, b := prefix+"-a", prefix+"-b"
+	if _, err := l.CreateZone(ctx, CreateZoneInput{ID: a, Name: a, Tags: map[string]string{"Region": region, "tier": "critical"}, Actor: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.CreateZone(ctx, CreateZoneInput{ID: b, Name: b, Actor: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	tags, err := l.TagZone(ctx, b, TagZoneInput{Tags: map[string]string{"region": region, "tier": "standard"}, Actor: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tags.Tags) != 2 || tags.Tags["tier"] != "standard" {
+		t.Fatalf("tags = %+v", tags)
+	}
+
+	zones, err := l.ListZones(ctx, map[string]string{"region": region})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(zones) != 2 || zones[0].ID != a || zones[0].Tags["region"] != region {
+		t.Fatalf("region zones = %+v", zones)
+	}
+	zones, err = l.ListZones(ctx, map[string]string{"region": region, "tier": "critical"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(zones) != 1 || zones[0].ID != a {
+		t.Fatalf("critical zones = %+v", zones)
+	}
+
+	throttle := 40
+	res, err := l.BatchSetZoneControls(ctx, BatchZoneControlsInput{
+		Tags: map[string]string{"region": region}, Patch: ZoneControlsPatch{CrossZoneThrottle: &throttle}, Actor: "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Zones) != 2 || res.Zones[0].ZoneID != a || res.Zones[1].ZoneID != b {
+		t.Fatalf("batch = %+v", res)
+	}
+	_, err = l.BatchSetZoneControls(ctx, BatchZoneControlsInput{
+		Tags: map[string]string{"region": "nowhere-" + prefix}, Patch: ZoneControlsPatch{CrossZoneThrottle: &throttle}, Actor: "test",
+	})
+	if !IsZoneNotFound(err) {
+		t.Fatalf("unmatched selector err = %v", err)
+	}
+
+	if _, err := l.UntagZone(ctx, b, "region", "test", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.UntagZone(ctx, b, "region", "test", ""); !IsZoneTagNotFound(err) {
+		t.Fatalf("second untag: err = %v", err)
+	}
+	if zones, err := l.ListZones(ctx, map[string]string{"region": region}); err != nil || len(zones) != 1 {
+		t.Fatalf("region zones after untag = %+v, %v", zones, err)
+	}
+}
@@ -0,0 +1,76 @@
+// Package logging carries the request ID through contexts and adds it to every
+// slog record logged with that context, so API, ledger, outbox and consumer
+// lines for one request can be correlated.
+package logging
+
+import (
+  "context"
+  "crypto/rand"
+  "encoding/hex"
+  "io"
+  "log/slog"
+)
+
+// Header is the HTTP header (and NATS message header) carrying the request ID.
+const Header = "X-Request-Id"
+
+type ctxKey struct{}
+
+func WithRequestID(ctx context.Context, id string) context.Context {
+  if id == "" { return ctx }
+  return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// RequestID returns the request ID in ctx, or "".
+func RequestID(ctx context.Context) string {
+  id, _ := ctx.Value(ctxKey{}).(string)
+  return id
+}
+
+// NewRequestID returns a random 128-bit hex ID.
+func NewRequestID() string {
+  var b [16]byte
+  _, _ = rand.Read(b[:])
+  return hex.EncodeToString(b[:])
+}
+
+// ValidRequestID accepts client-supplied IDs of up to 128 visible ASCII characters.
+func ValidRequestID(id string) bool {
+  if id == "" || len(id) > 128 { return false }
+  for i := 0; i < len(id); i++ {
+    if id[i] < 0x21 || id[i] > 0x7e { return false }
+  }
+  return true
+}
+
+// ParseLevel reads debug, info, warn or error (case-insensitive, optionally with
+// an offset such as "info+2"); empty or unknown values give def.
+func ParseLevel(s string, def slog.Level) slog.Level {
+  var l slog.Level
+  if s == "" || l.UnmarshalText([]byte(s)) != nil { return def }
+  return l
+}
+
+// New returns the service's JSON logger with request ID support. Pass a
+// *slog.LevelVar to change the level while running.
+func New(w io.Writer, level slog.Leveler) *slog.Logger {
+  return slog.New(ContextHandler{slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})})
+}
+
+// ContextHandler adds request_id to records logged with a context that has one.
+type ContextHandler struct {
+  slog.Handler
+}
+
+func (h ContextHandler) Handle(ctx context.Context, r slog.Record) error {
+  if id := RequestID(ctx); id != "" { r.AddAttrs(slog.String("request_id", id)) }
+  return h.Handler.Handle(ctx, r)
+}
+
+func (h ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+  return ContextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h ContextHandler) WithGroup(name string) slog.Handler {
+  return ContextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,61 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestContextHandlerAddsRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(&buf, slog.LevelInfo).With("component", "test")
+
+	log.InfoContext(WithRequestID(context.Background(), "req-1"), "hello")
+	log.Info("no context")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %q", buf.String())
+	}
+	var first, second map[string]any
+	_ = json.Unmarshal([]byte(lines[0]), &first)
+	_ = json.Unmarshal([]byte(lines[1]), &second)
+	if first["request_id"] != "req-1" || first["component"] != "test" {
+		t.Fatalf("first line %v", first)
+	}
+	if _, ok := second["request_id"]; ok {
+		t.Fatalf("second line %v", second)
+	}
+}
+
+func TestValidRequestID(t *testing.T) {
+	for id, want := range map[string]bool{
+		"abc-123":                true,
+		"":                       false,
+		"has space":              false,
+		"line\nbreak":            false,
+		strings.Repeat("a", 129): false,
+		NewRequestID():           true,
+	} {
+		if got := ValidRequestID(id); got != want {
+			t.Errorf("%q: got %v", id, got)
+		}
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for s, want := range map[string]slog.Level{
+		"":        slog.LevelInfo,
+		"debug":   slog.LevelDebug,
+		"WARN":    slog.LevelWarn,
+		"error":   slog.LevelError,
+		"verbose": slog.LevelInfo,
+	} {
+		if got := ParseLevel(s, slog.LevelInfo); got != want {
+			t.Errorf("%q: got %v", s, got)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package messaging
+
+import (
+  "bytes"
+  "context"
+  "crypto/hmac"
+  "crypto/sha256"
+  "encoding/hex"
+  "encoding/json"
+  "fmt"
+  "io"
+  "net/http"
+  "sync"
+  "time"
+
+  "github.com/nats-io/nats.go"
+  "go.opentelemetry.io/otel/attribute"
+  "go.opentelemetry.io/otel/trace"
+  "log/slog"
+
+  "time-ledger-sim/go/internal/ledger"
+  "time-ledger-sim/go/internal/logging"
+  "time-ledger-sim/go/internal/tracing"
+)
+
+const (
+  // maxBalanceDeliveries is how often JetStream delivers an event to the
+  // balance notifier before a webhook that keeps failing is given up on.
+  maxBalanceDeliveries = 6
+  webhookTimeout = 5 * time.Second
+  // SignatureHeader carries the hex HMAC-SHA256 of a webhook body, keyed
+  // with the subscription's secret: "sha256=<hex>".
+  SignatureHeader = "X-Signature"
+)
+
+// BalanceStore is what the balance notifier reads and writes; the ledger
+// implements it.
+type BalanceStore interface {
+  BalanceWebhooks(ctx context.Context, accountIDs []string) ([]ledger.BalanceWebhook, error)
+  BalanceDelivered(ctx context.Context, subscriptionID, eventID string) (bool, error)
+  RecordBalanceDelivery(ctx context.Context, subscriptionID, eventID string, statusCode int, deliveryErr string) error
+}
+
+// BalanceNotifier delivers balance subscriptions' webhooks from
+// TRANSFER_POSTED events. An event whose webhooks did not all take it is
+// redelivered with a growing delay; the ones that did are skipped.
+type BalanceNotifier struct {
+  store BalanceStore
+  js nats.JetStreamContext
+  cipher *Cipher
+  client *http.Client
+  log *slog.Logger
+}
+
+func NewBalanceNotifier(store BalanceStore, js nats.JetStreamContext, cipher *Cipher, log *slog.Logger) *BalanceNotifier {
+  return &BalanceNotifier{store: store, js: js, cipher: cipher, client: &http.Client{Timeout: webhookTimeout}, log: log}
+}
+
+type balancePosted struct {
+  transferPosted
+  FromAccount string `json:"from_account"`
+  ToAccount string `json:"to_account"`
+  FromBalanceUnits *int64 `json:"from_balance_units"`
+  ToBalanceUnits *int64 `json:"to_balance_units"`
+}
+
+// balanceChanges is what the transfer did to both balances. Events without
+// the balances after the transfer, such as those queued by reconciliation,
+// change nothing, and neither do a transfer to the same account and events
+// that are not transfers (see transferPosted.posted).
+func balanceChanges(data []byte, msgID string) []ledger.BalanceChange {
+  var ev balancePosted
+  if err := json.Unmarshal(data, &ev); err != nil { return nil }
+  if ev.EventID == "" { ev.EventID = msgID }
+  if ev.EventID == "" || !ev.posted() || ev.FromBalanceUnits == nil || ev.ToBalanceUnits == nil || ev.FromAccount == ev.ToAccount { return nil }
+  change := func(account string, balance, delta int64) ledger.BalanceChange {
+    return ledger.BalanceChange{
+      EventID: ev.EventID, TransactionID: ev.TransactionID, ZoneID: ev.ZoneID,
+      AccountID: account, BalanceUnits: balance, DeltaUnits: delta, At: ev.CreatedAt,
+    }
+  }
+  return []ledger.BalanceChange{
+    change(ev.FromAccount, *ev.FromBalanceUnits, -ev.AmountUnits),
+    change(ev.ToAccount, *ev.ToBalanceUnits, ev.AmountUnits),
+  }
+}
+
+func (c *BalanceNotifier) Run(ctx context.Context) {
+  sub, err := c.js.PullSubscribe("events.transfer_posted", "balance-notify-v1", nats.BindStream(StreamName), nats.MaxDeliver(maxBalanceDeliveries))
+  if err != nil {
+    c.log.Error("balance notifier subscribe failed", "err", err.Error())
+    return
+  }
+
+  for {
+    select {
+    case <-ctx.Done():
+      return
+    default:
+    }
+
+    msgs, err := sub.Fetch(10, nats.MaxWait(1*time.Second))
+    if err != nil && err != nats.ErrTimeout {
+      c.log.Warn("fetch failed", "err", err.Error())
+      continue
+    }
+    bctx := context.WithoutCancel(ctx)
+    for _, msg := range msgs {
+      _ = c.handleMsg(bctx, msg)
+    }
+  }
+}
+
+// handleMsg delivers one event to the webhooks it fires, under a consumer
+// span joined to the trace of the transfer.
+func (c *BalanceNotifier) handleMsg(ctx context.Context, msg *nats.Msg) (err error) {
+  ctx = logging.WithRequestID(ctx, msg.Header.Get(logging.Header))
+  ctx = tracing.Propagator.Extract(ctx, tracing.HeaderCarrier(msg.Header))
+  ctx, span := tracing.Start(ctx, "balance-notify-v1 process", trace.WithSpanKind(trace.SpanKindConsumer), trace.WithAttributes(
+    attribute.String("messaging.system", "nats"),
+    attribute.String("messaging.destination.name", msg.Subject),
+    attribute.String("messaging.message.id", msg.Header.Get("Nats-Msg-Id")),
+  ))
+  defer func() { tracing.End(span, err) }()
+  if err = openPayload(ctx, c.cipher, msg, "balance-notify-v1", c.log); err != nil { return err }
+  changes := balanceChanges(msg.Data, msg.Header.Get("Nats-Msg-Id"))
+  if len(changes) == 0 {
+    _ = msg.Ack()
+    return nil
+  }
+
+  hooks, err := c.store.BalanceWebhooks(ctx, []string{changes[0].AccountID, changes[1].AccountID})
+  if err != nil {
+    c.log.WarnContext(ctx, "balance webhooks lookup failed", "event_id", changes[0].EventID, "err", err.Error())
+    return err // redelivered after the ack wait
+  }
+  failed := 0
+  for _, h := range hooks {
+    for _, ch := range changes {
+      if !h.Matches(ch) { continue }
+      done, err := c.store.BalanceDelivered(ctx, h.ID, ch.EventID)
+      if err != nil { return err }
+      if done { continue }
+      status, derr := c.post(ctx, h, h.Notification(ch))
+      msgErr := ""
+      if derr != nil {
+        failed++
+        msgErr = derr.Error()
+        c.log.WarnContext(ctx, "balance webhook failed", "subscription_id", h.ID, "event_id", ch.EventID, "err", msgErr)
+      }
+      if err := c.store.RecordBalanceDelivery(ctx, h.ID, ch.EventID, status, msgErr); err != nil { return err }
+    }
+  }
+  span.SetAttributes(attribute.Int("balance.webhooks_failed", failed))
+  if failed == 0 {
+    _ = msg.Ack()
+    return nil
+  }
+
+  delivered := uint64(1)
+  if meta, err := msg.Metadata(); err == nil { delivered = meta.NumDelivered }
+  if delivered >= maxBalanceDeliveries {
+    c.log.WarnContext(ctx, "balance webhooks given up", "event_id", changes[0].EventID, "failed", failed, "deliveries", delivered)
+    _ = msg.Term()
+    return nil
+  }
+  _ = msg.NakWithDelay(time.Duration(delivered*delivered) * 5 * time.Second) // 5s, 20s, 45s, ...
+  return fmt.Errorf("%d balance webhooks failed", failed)
+}
+
+// post sends one signed notification. Any 2xx response is a delivery.
+func (c *BalanceNotifier) post(ctx context.Context, h ledger.BalanceWebhook, n ledger.BalanceNotification) (int, error) {
+  body, _ := json.Marshal(n)
+  mac := hmac.New(sha256.New, []byte(h.Secret))
+  mac.Write(body)
+  req, err := http.NewRequestWithContext(ctx, http.MethodPost, *h.WebhookURL, bytes.NewReader(body))
+  if err != nil { return 0, err }
+  req.Header.Set("Content-Type", "application/json")
+  req.Header.Set(SignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+  req.Header.Set("X-Event-Id", n.EventID)
+  if id := logging.RequestID(ctx); id != "" { req.Header.Set(logging.Header, id) }
+  resp, err := c.client.Do(req)
+  if err != nil { return 0, err }
+  defer resp.Body.Close()
+  _, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+  if resp.StatusCode < 200 || resp.StatusCode > 299 { return resp.StatusCode, fmt.Errorf("webhook returned %s", resp.Status) }
+  return resp.StatusCode, nil
+}
+
+// BalanceHub fans TRANSFER_POSTED events out to the SSE streams of balance
+// subscriptions on this replica. Every replica reads the stream from its own
+// ephemeral consumer, starting with new events, so a stream only sees what
+// is posted while it is open.
+type BalanceHub struct {
+  mu sync.Mutex
+  watchers map[*balanceWatcher]struct{}
+  closed bool
+  cipher *Cipher
+  log *slog.Logger
+}
+
+type balanceWatcher struct {
+  sub ledger.BalanceSubscription
+  ch chan ledger.BalanceNotification
+}
+
+// balanceWatchBuffer is how many notifications a stream may fall behind;
+// a client slower than that misses the ones past it.
+const balanceWatchBuffer = 64
+
+func NewBalanceHub(cipher *Cipher, log *slog.Logger) *BalanceHub {
+  return &BalanceHub{watchers: map[*balanceWatcher]struct{}{}, cipher: cipher, log: log}
+}
+
+// Watch streams the subscription's notifications until stop is called or
+// the hub stops, which closes the channel.
+func (h *BalanceHub) Watch(sub ledger.BalanceSubscription) (<-chan ledger.BalanceNotification, func()) {
+  w := &balanceWatcher{sub: sub, ch: make(chan ledger.BalanceNotification, balanceWatchBuffer)}
+  h.mu.Lock()
+  defer h.mu.Unlock()
+  if h.closed {
+    close(w.ch)
+    return w.ch, func() {}
+  }
+  h.watchers[w] = struct{}{}
+  return w.ch, func() {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+    if _, ok := h.watchers[w]; ok {
+      delete(h.watchers, w)
+      close(w.ch)
+    }
+  }
+}
+
+// publish hands the changes to the streams they fire, never blocking.
+func (h *BalanceHub) publish(changes []ledger.BalanceChange) {
+  h.mu.Lock()
+  defer h.mu.Unlock()
+  for w := range h.watchers {
+    for _, c := range changes {
+      if !w.sub.Matches(c) { continue }
+      select {
+      case w.ch <- w.sub.Notification(c):
+      default:
+      }
+    }
+  }
+}
+
+// Run feeds the hub until ctx ends. It does not close the streams: Stop
+// does, whether or not the hub ever ran.
+func (h *BalanceHub) Run(ctx context.Context, js nats.JetStreamContext) {
+  sub, err := js.Subscribe("events.transfer_posted", func(msg *nats.Msg) {
+    // streams are best effort: an event this replica cannot read is skipped
+    if openPayload(ctx, h.cipher, msg, "balance-hub", h.log) != nil { return }
+    h.publish(balanceChanges(msg.Data, msg.Header.Get("Nats-Msg-Id")))
+  }, nats.BindStream(StreamName), nats.OrderedConsumer(), nats.DeliverNew())
+  if err != nil {
+    h.log.Error("balance hub subscribe failed", "err", err.Error())
+    return
+  }
+  defer func() { _ = sub.Unsubscribe() }()
+  <-ctx.Done()
+}
+
+// Stop closes every stream and any watched later, so their SSE responses
+// end before the HTTP server shuts down.
+func (h *BalanceHub) Stop() {
+  h.mu.Lock()
+  defer h.mu.Unlock()
+  h.closed = true
+  for w := range h.watchers {
+    delete(h.watchers, w)
+    close(w.ch)
+  }
+}
@@ -0,0 +1,132 @@
+package messaging
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"time-ledger-sim/go/internal/ledger"
+)
+
+type fakeBalanceStore struct {
+	hooks     []ledger.BalanceWebhook
+	delivered map[string]bool
+	attempts  []string
+}
+
+func (f *fakeBalanceStore) BalanceWebhooks(_ context.Context, _ []string) ([]ledger.BalanceWebhook, error) {
+	return f.hooks, nil
+}
+
+func (f *fakeBalanceStore) BalanceDelivered(_ context.Context, subID, eventID string) (bool, error) {
+	return f.delivered[subID+"/"+eventID], nil
+}
+
+func (f *fakeBalanceStore) RecordBalanceDelivery(_ context.Context, subID, eventID string, status int, deliveryErr string) error {
+	f.attempts = append(f.attempts, subID+"/"+eventID)
+	if deliveryErr == "" {
+		f.delivered[subID+"/"+eventID] = true
+	}
+	return nil
+}
+
+const posted = `{"event_id":"e1","transaction_id":"t1","zone_id":"zone-eu","from_account":"a","to_account":"b","amount_units":30,
+	"from_balance_units":70,"to_balance_units":130}`
+
+func TestBalanceNotifierDeliversSignedWebhooks(t *testing.T) {
+	var got []ledger.BalanceNotification
+	fail := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mac := hmac.New(sha256.New, []byte("whsec_test"))
+		mac.Write(body)
+		if r.Header.Get(SignatureHeader) != "sha256="+hex.EncodeToString(mac.Sum(nil)) {
+			t.Errorf("bad signature %q", r.Header.Get(SignatureHeader))
+		}
+		var n ledger.BalanceNotification
+		_ = json.Unmarshal(body, &n)
+		got = append(got, n)
+		if fail && n.SubscriptionID == "s-cross" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	threshold := int64(120)
+	hook := func(id, account, trigger string, th *int64) ledger.BalanceWebhook {
+		return ledger.BalanceWebhook{Secret: "whsec_test", BalanceSubscription: ledger.BalanceSubscription{
+			ID: id, AccountID: account, Trigger: trigger, ThresholdUnits: th, WebhookURL: &srv.URL,
+		}}
+	}
+	store := &fakeBalanceStore{delivered: map[string]bool{}, hooks: []ledger.BalanceWebhook{
+		hook("s-change", "a", ledger.BalanceTriggerChange, nil),
+		hook("s-cross", "b", ledger.BalanceTriggerCross, &threshold),
+		hook("s-quiet", "a", ledger.BalanceTriggerCross, &threshold), // a stays below 120
+	}}
+	c := &BalanceNotifier{store: store, client: srv.Client(), log: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	ctx := context.Background()
+
+	if err := c.handleMsg(ctx, fraudMsg(posted)); err == nil {
+		t.Fatal("failed webhook was not reported")
+	}
+	if len(got) != 2 || got[0].SubscriptionID != "s-change" || got[0].PreviousUnits != 100 || got[0].DeltaUnits != -30 {
+		t.Fatalf("first delivery = %+v", got)
+	}
+
+	// the redelivery only goes to the webhook that failed
+	fail = false
+	if err := c.handleMsg(ctx, fraudMsg(posted)); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || got[2].SubscriptionID != "s-cross" || got[2].BalanceUnits != 130 || len(store.attempts) != 3 {
+		t.Fatalf("deliveries = %+v, attempts = %v", got, store.attempts)
+	}
+
+	// events without balances (reconciled ones) notify nobody
+	if err := c.handleMsg(ctx, fraudMsg(`{"event_id":"e2","from_account":"a","to_account":"b","amount_units":5,"reconciled":true}`)); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("reconciled event delivered: %+v", got)
+	}
+}
+
+func TestBalanceHubStreams(t *testing.T) {
+	h := NewBalanceHub(nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	notes, stop := h.Watch(ledger.BalanceSubscription{ID: "s1", AccountID: "b", Trigger: ledger.BalanceTriggerChange})
+	other, _ := h.Watch(ledger.BalanceSubscription{ID: "s2", AccountID: "c", Trigger: ledger.BalanceTriggerChange})
+
+	// a spool event misrouted to events.transfer_posted changes no balance
+	spooled := `{"event_id":"e9","spool_id":"s1","from_account":"a","to_account":"b","amount_units":30,"from_balance_units":0,"to_balance_units":0}`
+	if c := balanceChanges([]byte(spooled), ""); c != nil {
+		t.Fatalf("spool event changes = %+v", c)
+	}
+	h.publish(balanceChanges([]byte(posted), ""))
+	if n := <-notes; n.SubscriptionID != "s1" || n.BalanceUnits != 130 || n.EventID != "e1" {
+		t.Fatalf("notification = %+v", n)
+	}
+	if len(other) != 0 {
+		t.Fatal("unrelated stream notified")
+	}
+	for range balanceWatchBuffer + 1 { // a full stream drops, never blocks
+		h.publish(balanceChanges([]byte(posted), ""))
+	}
+	stop()
+	stop()
+
+	h.Stop()
+	if _, ok := <-other; ok {
+		t.Fatal("stream open after Stop")
+	}
+	late, _ := h.Watch(ledger.BalanceSubscription{ID: "s3"})
+	if _, ok := <-late; ok {
+		t.Fatal("watch after Stop is open")
+	}
+}
@@ -0,0 +1,50 @@
+package messaging
+
+import (
+  "errors"
+  "os"
+  "time"
+
+  "github.com/nats-io/nats-server/v2/server"
+)
+
+// EmbeddedURL is the NATS_URL value that runs a JetStream server in-process.
+const EmbeddedURL = "embedded"
+
+// EmbeddedServer is an in-process NATS server with JetStream, for running the
+// sim without docker-compose. It listens on a random loopback port.
+type EmbeddedServer struct {
+  srv *server.Server
+  dir string
+}
+
+// StartEmbedded starts a server storing JetStream data in a temporary
+// directory that Shutdown removes.
+func StartEmbedded() (*EmbeddedServer, error) {
+  dir, err := os.MkdirTemp("", "sim-nats-")
+  if err != nil { return nil, err }
+  srv, err := server.NewServer(&server.Options{
+    Host: "127.0.0.1",
+    Port: server.RANDOM_PORT,
+    JetStream: true,
+    StoreDir: dir,
+    NoSigs: true,
+  })
+  if err != nil { _ = os.RemoveAll(dir); return nil, err }
+  go srv.Start()
+  if !srv.ReadyForConnections(10 * time.Second) {
+    srv.Shutdown()
+    _ = os.RemoveAll(dir)
+    return nil, errors.New("embedded nats: not ready after 10s")
+  }
+  return &EmbeddedServer{srv: srv, dir: dir}, nil
+}
+
+// URL is the client URL to pass to nats.Connect.
+func (s *EmbeddedServer) URL() string { return s.srv.ClientURL() }
+
+func (s *EmbeddedServer) Shutdown() {
+  s.srv.Shutdown()
+  s.srv.WaitForShutdown()
+  _ = os.RemoveAll(s.dir)
+}
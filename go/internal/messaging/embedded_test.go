@@ -0,0 +1,38 @@
+package messaging
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+)
+
+func TestEmbeddedServerRunsJetStream(t *testing.T) {
+	srv, err := StartEmbedded()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Shutdown()
+	nc, err := nats.Connect(srv.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer nc.Close()
+	js, err := nc.JetStream()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := EnsureStreams(context.Background(), js, DefaultStreamConfig()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := js.Publish("events.transfer_posted", []byte(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+	info, err := js.StreamInfo(StreamName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.State.Msgs != 1 {
+		t.Fatalf("stream has %d messages, want 1", info.State.Msgs)
+	}
+}
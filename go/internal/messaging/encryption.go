@@ -0,0 +1,140 @@
+package messaging
+
+import (
+  "context"
+  "crypto/aes"
+  "crypto/cipher"
+  "crypto/rand"
+  "crypto/sha256"
+  "encoding/base64"
+  "encoding/hex"
+  "errors"
+  "fmt"
+  "log/slog"
+  "os"
+  "strings"
+
+  "github.com/nats-io/nats.go"
+
+  "time-ledger-sim/go/internal/metrics"
+)
+
+// Event payload encryption: with a key configured the outbox publisher seals
+// each event's JSON with AES-256-GCM and consumers open it again, so the
+// payloads (account ids, amounts, actors) are never readable on the bus or in
+// the stream's storage. Headers stay in the clear for tracing and dedup.
+
+const (
+  EncryptionHeader = "Ledger-Encryption" // algorithm of a sealed payload; absent when in the clear
+  EncryptionKeyIDHeader = "Ledger-Key-Id"
+  EncryptionAlg = "aes-256-gcm"
+)
+
+var ErrUndecryptable = errors.New("event payload cannot be decrypted")
+
+func IsUndecryptable(err error) bool { return errors.Is(err, ErrUndecryptable) }
+
+type EncryptionConfig struct {
+  Key string `yaml:"key"` // EVENT_ENCRYPTION_KEY: base64 32-byte AES key; payloads are published in the clear when empty
+  KeyFile string `yaml:"key_file"` // EVENT_ENCRYPTION_KEY_FILE; holds the key (e.g. mounted by a KMS or secret manager), instead of Key
+  // PreviousKeys still decrypt, so events sealed before a key rotation are
+  // read after it.
+  PreviousKeys []string `yaml:"previous_keys"` // EVENT_ENCRYPTION_PREVIOUS_KEYS, comma-separated
+}
+
+// Cipher seals and opens event payloads. A nil *Cipher publishes in the
+// clear and only opens clear payloads.
+type Cipher struct {
+  keyID string
+  aead cipher.AEAD
+  keys map[string]cipher.AEAD // by key id, current and previous
+}
+
+// NewCipher parses the configured keys. It returns nil (no error) when
+// encryption is off; previous keys then still decrypt.
+func NewCipher(cfg EncryptionConfig) (*Cipher, error) {
+  if cfg.Key != "" && cfg.KeyFile != "" { return nil, errors.New("set either key or key_file, not both") }
+  spec := cfg.Key
+  if cfg.KeyFile != "" {
+    b, err := os.ReadFile(cfg.KeyFile)
+    if err != nil { return nil, err }
+    spec = strings.TrimSpace(string(b))
+  }
+  c := &Cipher{keys: map[string]cipher.AEAD{}}
+  for i, k := range append([]string{spec}, cfg.PreviousKeys...) {
+    if k == "" { continue }
+    id, aead, err := parseKey(k)
+    if err != nil && i == 0 { return nil, fmt.Errorf("key: %v", err) }
+    if err != nil { return nil, fmt.Errorf("previous key %d: %v", i, err) }
+    c.keys[id] = aead
+    if i == 0 { c.keyID, c.aead = id, aead }
+  }
+  if len(c.keys) == 0 { return nil, nil }
+  return c, nil
+}
+
+// parseKey returns an AES-256-GCM key and its id, a fingerprint that names
+// the key in messages without revealing it.
+func parseKey(s string) (string, cipher.AEAD, error) {
+  raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+  if err != nil { return "", nil, err }
+  if len(raw) != 32 { return "", nil, fmt.Errorf("want 32 bytes (base64), got %d", len(raw)) }
+  block, err := aes.NewCipher(raw)
+  if err != nil { return "", nil, err }
+  aead, err := cipher.NewGCM(block)
+  if err != nil { return "", nil, err }
+  sum := sha256.Sum256(raw)
+  return "aes-" + hex.EncodeToString(sum[:6]), aead, nil
+}
+
+// KeyID is the id of the key new events are sealed with ("" when encryption is off).
+func (c *Cipher) KeyID() string {
+  if c == nil { return "" }
+  return c.keyID
+}
+
+// Seal encrypts msg.Data in place. The subject and message id are
+// authenticated with it, so a payload cannot be replayed under another event.
+func (c *Cipher) Seal(msg *nats.Msg) error {
+  if c == nil || c.aead == nil { return nil }
+  nonce := make([]byte, c.aead.NonceSize())
+  if _, err := rand.Read(nonce); err != nil { return err }
+  msg.Data = c.aead.Seal(nonce, nonce, msg.Data, sealedAAD(msg))
+  msg.Header.Set(EncryptionHeader, EncryptionAlg)
+  msg.Header.Set(EncryptionKeyIDHeader, c.keyID)
+  return nil
+}
+
+// Open decrypts a sealed msg.Data in place; clear payloads pass unchanged, so
+// events published before encryption was turned on are still read.
+func (c *Cipher) Open(msg *nats.Msg) error {
+  alg := msg.Header.Get(EncryptionHeader)
+  if alg == "" { return nil }
+  if alg != EncryptionAlg { return fmt.Errorf("%w: unknown algorithm %q", ErrUndecryptable, alg) }
+  kid := msg.Header.Get(EncryptionKeyIDHeader)
+  var aead cipher.AEAD
+  if c != nil { aead = c.keys[kid] }
+  if aead == nil { return fmt.Errorf("%w: no key %q", ErrUndecryptable, kid) }
+  n := aead.NonceSize()
+  if len(msg.Data) < n { return fmt.Errorf("%w: payload too short", ErrUndecryptable) }
+  plain, err := aead.Open(nil, msg.Data[:n], msg.Data[n:], sealedAAD(msg))
+  if err != nil { return fmt.Errorf("%w: %v", ErrUndecryptable, err) }
+  msg.Data = plain
+  return nil
+}
+
+// openPayload decrypts msg for consumer. An event it cannot decrypt is left
+// unacked, so JetStream redelivers it, e.g. to a replica that already has a
+// rotated key.
+func openPayload(ctx context.Context, c *Cipher, msg *nats.Msg, consumer string, log *slog.Logger) error {
+  err := c.Open(msg)
+  if err != nil {
+    metrics.EventDecryptFailures.WithLabelValues(consumer).Inc()
+    log.ErrorContext(ctx, "event payload not decrypted", "consumer", consumer, "event_id", msg.Header.Get("Nats-Msg-Id"), "err", err.Error())
+  }
+  return err
+}
+
+func sealedAAD(msg *nats.Msg) []byte {
+  return []byte(msg.Subject + "\n" + msg.Header.Get("Nats-Msg-Id"))
+}
@@ -0,0 +1,135 @@
+package messaging
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+)
+
+func testKey(t *testing.T) string {
+	t.Helper()
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func TestCipherSealsAndOpensPayloads(t *testing.T) {
+	old, cur := testKey(t), testKey(t)
+	c, err := NewCipher(EncryptionConfig{Key: cur})
+	if err != nil || c.KeyID() == "" {
+		t.Fatalf("cipher = %v, %v", c, err)
+	}
+	payload := `{"event_id":"e1","from_account":"alice"}`
+	sealed := func(c *Cipher) *nats.Msg {
+		msg := fraudMsg(payload)
+		msg.Header.Set("Nats-Msg-Id", "e1")
+		if err := c.Seal(msg); err != nil {
+			t.Fatal(err)
+		}
+		return msg
+	}
+
+	msg := sealed(c)
+	if bytes.Contains(msg.Data, []byte("alice")) || msg.Header.Get(EncryptionKeyIDHeader) != c.KeyID() {
+		t.Fatalf("sealed = %q, headers %v", msg.Data, msg.Header)
+	}
+	if err := c.Open(msg); err != nil || string(msg.Data) != payload {
+		t.Fatalf("opened = %q, %v", msg.Data, err)
+	}
+
+	// a payload moved to another subject or event does not open
+	moved := sealed(c)
+	moved.Subject = "events.saga_step_due"
+	if err := c.Open(moved); !IsUndecryptable(err) {
+		t.Errorf("moved payload: err = %v", err)
+	}
+	relabeled := sealed(c)
+	relabeled.Header.Set("Nats-Msg-Id", "e2")
+	if err := c.Open(relabeled); !IsUndecryptable(err) {
+		t.Errorf("relabeled payload: err = %v", err)
+	}
+
+	// after a rotation the previous key still opens what it sealed
+	before, _ := NewCipher(EncryptionConfig{Key: old})
+	rotated, err := NewCipher(EncryptionConfig{Key: cur, PreviousKeys: []string{old}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg := sealed(before); rotated.Open(msg) != nil || string(msg.Data) != payload {
+		t.Errorf("previous key did not open: %q", msg.Data)
+	}
+	if err := c.Open(sealed(before)); !IsUndecryptable(err) {
+		t.Errorf("unknown key: err = %v", err)
+	}
+
+	// no key: published in the clear, and only clear payloads open
+	var off *Cipher
+	clear := fraudMsg(payload)
+	if err := off.Seal(clear); err != nil || string(clear.Data) != payload || clear.Header.Get(EncryptionHeader) != "" {
+		t.Fatalf("seal without key = %q, %v", clear.Data, err)
+	}
+	if err := c.Open(clear); err != nil || string(clear.Data) != payload {
+		t.Errorf("clear payload with a key: %q, %v", clear.Data, err)
+	}
+	if err := off.Open(sealed(c)); !IsUndecryptable(err) {
+		t.Errorf("sealed payload without a key: err = %v", err)
+	}
+}
+
+func TestNewCipherKeys(t *testing.T) {
+	if c, err := NewCipher(EncryptionConfig{}); c != nil || err != nil {
+		t.Fatalf("no key = %v, %v", c, err)
+	}
+	key := testKey(t)
+	file := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(file, []byte(key+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	fromFile, err := NewCipher(EncryptionConfig{KeyFile: file})
+	inline, _ := NewCipher(EncryptionConfig{Key: key})
+	if err != nil || fromFile.KeyID() != inline.KeyID() {
+		t.Fatalf("key file = %v, %v", fromFile, err)
+	}
+	for name, cfg := range map[string]EncryptionConfig{
+		"short key":    {Key: base64.StdEncoding.EncodeToString([]byte("too short"))},
+		"not base64":   {Key: "not base64!"},
+		"both":         {Key: key, KeyFile: file},
+		"bad previous": {Key: key, PreviousKeys: []string{"nope"}},
+		"missing file": {KeyFile: filepath.Join(t.TempDir(), "none")},
+	} {
+		if _, err := NewCipher(cfg); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
+
+func TestFraudConsumerOpensSealedEvents(t *testing.T) {
+	c, _ := NewCipher(EncryptionConfig{Key: testKey(t)})
+	store := &fakeFraudStore{}
+	consumer := &FraudConsumer{store: store, cipher: c, log: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	msg := fraudMsg(`{"event_id":"e1","transaction_id":"t1","zone_id":"zone-eu","amount_units":3600}`)
+	if err := c.Seal(msg); err != nil {
+		t.Fatal(err)
+	}
+	if err := consumer.handleMsg(context.Background(), msg); err != nil || len(store.incidents) != 1 {
+		t.Fatalf("incidents = %v, err = %v", store.incidents, err)
+	}
+
+	// a replica without the key leaves the event for redelivery
+	other := &FraudConsumer{store: store, log: consumer.log}
+	msg = fraudMsg(`{"event_id":"e2","amount_units":3600}`)
+	_ = c.Seal(msg)
+	if err := other.handleMsg(context.Background(), msg); !IsUndecryptable(err) || len(store.inbox) != 1 {
+		t.Fatalf("err = %v, inbox = %v", err, store.inbox)
+	}
+}
@@ -7,17 +7,45 @@ import (
 
   "github.com/jackc/pgx/v5/pgxpool"
   "github.com/nats-io/nats.go"
+  "go.opentelemetry.io/otel/attribute"
+  "go.opentelemetry.io/otel/trace"
   "log/slog"
+
+  "time-ledger-sim/go/internal/logging"
+  "time-ledger-sim/go/internal/tracing"
 )
 
 type FraudConsumer struct {
-  db *pgxpool.Pool
+  store FraudStore
   js nats.JetStreamContext
+  cipher *Cipher
   log *slog.Logger
 }
 
-func NewFraudConsumer(db *pgxpool.Pool, js nats.JetStreamContext, log *slog.Logger) *FraudConsumer {
-  return &FraudConsumer{db: db, js: js, log: log}
+// FraudStore is what the fraud consumer writes: its inbox and incidents.
+type FraudStore interface {
+  RecordInbox(ctx context.Context, consumer, eventID string) error
+  RaiseLargeTransfer(ctx context.Context, zoneID, txnID string, amountUnits int64) error
+}
+
+func NewFraudConsumer(db *pgxpool.Pool, js nats.JetStreamContext, cipher *Cipher, log *slog.Logger) *FraudConsumer {
+  return &FraudConsumer{store: pgFraudStore{db}, js: js, cipher: cipher, log: log}
+}
+
+// pgFraudStore is the Postgres FraudStore.
+type pgFraudStore struct{ db *pgxpool.Pool }
+
+func (s pgFraudStore) RecordInbox(ctx context.Context, consumer, eventID string) error {
+  _, err := s.db.Exec(ctx, `INSERT INTO inbox_events(consumer,event_id) VALUES($1,$2::uuid) ON CONFLICT DO NOTHING`, consumer, eventID)
+  return err
+}
+
+func (s pgFraudStore) RaiseLargeTransfer(ctx context.Context, zoneID, txnID string, amountUnits int64) error {
+  _, err := s.db.Exec(ctx, `
+    INSERT INTO incidents(zone_id, related_txn_id, severity, title, details)
+    VALUES($1, $2::uuid, 'WARN', 'Large time transfer', jsonb_strip_nulls(jsonb_build_object('amount_units',$3,'rule','large_transfer','trace_id',NULLIF($4::text,''))))
+  `, zoneID, txnID, amountUnits, tracing.TraceID(ctx))
+  return err
 }
 
 type transferPosted struct {
@@ -26,6 +54,16 @@ type transferPosted struct {
   ZoneID string `json:"zone_id"`
   AmountUnits int64 `json:"amount_units"`
   CreatedAt string `json:"created_at"`
+  SpoolID string `json:"spool_id"`
+  SagaID string `json:"saga_id"`
+}
+
+// posted reports whether the event is a TRANSFER_POSTED. Rust publishers
+// that predate routing by event type sent every outbox row, spool and saga
+// events included, to events.transfer_posted, and the stream may still hold
+// them.
+func (ev transferPosted) posted() bool {
+  return ev.TransactionID != "" && ev.SpoolID == "" && ev.SagaID == ""
 }
 
 func (c *FraudConsumer) Run(ctx context.Context) {
@@ -47,15 +85,29 @@ func (c *FraudConsumer) Run(ctx context.Context) {
       c.log.Warn("fetch failed", "err", err.Error())
       continue
     }
+    // finish (and ack) a fetched batch even when shutdown starts meanwhile;
+    // Run returns before the next fetch
+    bctx := context.WithoutCancel(ctx)
     for _, msg := range msgs {
-      _ = c.handleMsg(ctx, msg)
+      _ = c.handleMsg(bctx, msg)
     }
   }
 }
 
-func (c *FraudConsumer) handleMsg(ctx context.Context, msg *nats.Msg) error {
+// handleMsg processes one event under a consumer span joined to the trace the
+// outbox publisher forwarded in the message headers.
+func (c *FraudConsumer) handleMsg(ctx context.Context, msg *nats.Msg) (err error) {
+  ctx = logging.WithRequestID(ctx, msg.Header.Get(logging.Header))
+  ctx = tracing.Propagator.Extract(ctx, tracing.HeaderCarrier(msg.Header))
+  ctx, span := tracing.Start(ctx, "fraud-v1 process", trace.WithSpanKind(trace.SpanKindConsumer), trace.WithAttributes(
+    attribute.String("messaging.system", "nats"),
+    attribute.String("messaging.destination.name", msg.Subject),
+    attribute.String("messaging.message.id", msg.Header.Get("Nats-Msg-Id")),
+  ))
+  defer func() { tracing.End(span, err) }()
+  if err = openPayload(ctx, c.cipher, msg, "fraud-v1", c.log); err != nil { return err }
   var ev transferPosted
-  if err := json.Unmarshal(msg.Data, &ev); err != nil {
+  if err := json.Unmarshal(msg.Data, &ev); err != nil || !ev.posted() {
     _ = msg.Ack()
     return nil
   }
@@ -69,20 +121,16 @@ func (c *FraudConsumer) handleMsg(ctx context.Context, msg *nats.Msg) error {
   }
 
   // inbox dedup
-  _, err := c.db.Exec(ctx, `INSERT INTO inbox_events(consumer,event_id) VALUES('fraud-v1',$1::uuid) ON CONFLICT DO NOTHING`, ev.EventID)
-  if err != nil {
-    c.log.Warn("inbox insert failed", "event_id", ev.EventID, "err", err.Error())
+  if err = c.store.RecordInbox(ctx, "fraud-v1", ev.EventID); err != nil {
+    c.log.WarnContext(ctx, "inbox insert failed", "event_id", ev.EventID, "err", err.Error())
     return err // retry => at-least-once
   }
 
   // basic fraud rule: unusually large transfer triggers incident
   if ev.AmountUnits >= 3600 { // 1 hour worth (in seconds)
-    _, err := c.db.Exec(ctx, `
-      INSERT INTO incidents(zone_id, related_txn_id, severity, title, details)
-      VALUES($1, $2::uuid, 'WARN', 'Large time transfer', jsonb_build_object('amount_units',$3,'rule','large_transfer'))
-    `, ev.ZoneID, ev.TransactionID, ev.AmountUnits)
-    if err != nil {
-      c.log.Warn("incident insert failed", "event_id", ev.EventID, "err", err.Error())
+    span.SetAttributes(attribute.String("fraud.rule", "large_transfer"))
+    if err := c.store.RaiseLargeTransfer(ctx, ev.ZoneID, ev.TransactionID, ev.AmountUnits); err != nil {
+      c.log.WarnContext(ctx, "incident insert failed", "event_id", ev.EventID, "err", err.Error())
       return err
     }
   }
@@ -0,0 +1,86 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+)
+
+type fakeFraudStore struct {
+	inbox     []string
+	incidents []string
+	inboxErr  error
+}
+
+func (f *fakeFraudStore) RecordInbox(_ context.Context, consumer, eventID string) error {
+	if f.inboxErr != nil {
+		return f.inboxErr
+	}
+	f.inbox = append(f.inbox, consumer+"/"+eventID)
+	return nil
+}
+
+func (f *fakeFraudStore) RaiseLargeTransfer(_ context.Context, zoneID, txnID string, _ int64) error {
+	f.incidents = append(f.incidents, zoneID+"/"+txnID)
+	return nil
+}
+
+func fraudMsg(data string) *nats.Msg {
+	return &nats.Msg{Subject: "events.transfer_posted", Data: []byte(data), Header: nats.Header{}}
+}
+
+func TestFraudConsumerRaisesIncidentForLargeTransfers(t *testing.T) {
+	store := &fakeFraudStore{}
+	c := &FraudConsumer{store: store, log: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	ctx := context.Background()
+
+	if err := c.handleMsg(ctx, fraudMsg(`{"event_id":"e1","transaction_id":"t1","zone_id":"zone-eu","amount_units":60}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.handleMsg(ctx, fraudMsg(`{"event_id":"e2","transaction_id":"t2","zone_id":"zone-eu","amount_units":3600}`)); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.inbox) != 2 || len(store.incidents) != 1 || store.incidents[0] != "zone-eu/t2" {
+		t.Fatalf("inbox=%v incidents=%v", store.inbox, store.incidents)
+	}
+
+	// malformed and id-less events are dropped, not retried
+	for _, data := range []string{`not json`, `{"amount_units":9999}`} {
+		if err := c.handleMsg(ctx, fraudMsg(data)); err != nil {
+			t.Fatalf("%s: %v", data, err)
+		}
+	}
+	if len(store.inbox) != 2 {
+		t.Fatalf("dropped events reached the inbox: %v", store.inbox)
+	}
+
+	// spool and saga events misrouted to events.transfer_posted are not transfers
+	for _, data := range []string{
+		`{"event_id":"e3","spool_id":"s1","zone_id":"zone-eu","amount_units":9999,"spool_reason":"zone down"}`,
+		`{"event_id":"e4","spool_id":"s1","transaction_id":"t4","zone_id":"zone-eu","amount_units":9999}`,
+		`{"event_id":"e5","saga_id":"g1","status":"RUNNING","step":"debit","amount_units":9999}`,
+	} {
+		if err := c.handleMsg(ctx, fraudMsg(data)); err != nil {
+			t.Fatalf("%s: %v", data, err)
+		}
+	}
+	if len(store.inbox) != 2 || len(store.incidents) != 1 {
+		t.Fatalf("non-transfer events processed: inbox=%v incidents=%v", store.inbox, store.incidents)
+	}
+}
+
+func TestFraudConsumerReturnsStoreErrorsForRedelivery(t *testing.T) {
+	store := &fakeFraudStore{inboxErr: errors.New("db down")}
+	c := &FraudConsumer{store: store, log: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	msg := fraudMsg(`{"event_id":"e1","transaction_id":"t1","zone_id":"zone-eu","amount_units":5000}`)
+	if err := c.handleMsg(context.Background(), msg); err == nil {
+		t.Fatal("want the store error so the message is redelivered")
+	}
+	if len(store.incidents) != 0 {
+		t.Fatalf("incident raised despite inbox failure: %v", store.incidents)
+	}
+}
@@ -3,84 +3,308 @@ package messaging
 import (
   "context"
   "encoding/json"
+  "errors"
+  "strings"
+  "sync"
+  "sync/atomic"
   "time"
 
+  "github.com/google/uuid"
   "github.com/jackc/pgx/v5/pgxpool"
   "github.com/nats-io/nats.go"
+  "go.opentelemetry.io/otel/attribute"
+  "go.opentelemetry.io/otel/trace"
   "log/slog"
+
+  "time-ledger-sim/go/internal/logging"
+  "time-ledger-sim/go/internal/metrics"
+  "time-ledger-sim/go/internal/tracing"
+)
+
+// outboxBatchTimeout bounds one batch; batches are not cancelled with the
+// publisher's context, so shutdown never abandons rows half-published.
+const outboxBatchTimeout = 10 * time.Second
+
+// outboxRecoverScan bounds how many stream messages Recover reads.
+const outboxRecoverScan = 10000
+
+// Default bounds of the publisher loop's adaptive polling.
+const (
+  DefaultOutboxMinInterval = 10 * time.Millisecond
+  DefaultOutboxInterval = time.Second
+  DefaultOutboxBatch = 50
+  DefaultOutboxMaxBatch = 500
 )
 
+// OutboxTuning bounds the publisher's polling. Under load it polls as soon
+// as a batch comes back full, doubling the batch up to MaxBatch; while the
+// outbox stays empty it waits longer after each poll, from MinInterval up to
+// MaxInterval, and its batch shrinks back to MinBatch.
+type OutboxTuning struct {
+  MinInterval time.Duration
+  MaxInterval time.Duration
+  MinBatch int
+  MaxBatch int
+}
+
+// next is the batch size and wait after a poll of batch events that
+// published n, having waited wait before it.
+func (t OutboxTuning) next(batch int, wait time.Duration, n int, err error) (int, time.Duration) {
+  batch = min(max(batch, t.MinBatch), t.MaxBatch) // the bounds may have been reloaded
+  backoff := min(max(wait*2, t.MinInterval), t.MaxInterval)
+  switch {
+  case err != nil:
+    return batch, backoff
+  case n >= batch:
+    return min(batch*2, t.MaxBatch), 0 // more is waiting
+  case n == 0:
+    return max(batch/2, t.MinBatch), backoff
+  case n < batch/4:
+    return max(batch/2, t.MinBatch), t.MinInterval
+  }
+  return batch, t.MinInterval
+}
+
 type OutboxPublisher struct {
   db *pgxpool.Pool
   js nats.JetStreamContext
+  cipher *Cipher // nil: payloads in the clear
   log *slog.Logger
+  mu sync.Mutex // one batch at a time (Run and Flush)
+  tuning atomic.Pointer[OutboxTuning]
+}
+
+func NewOutboxPublisher(db *pgxpool.Pool, js nats.JetStreamContext, cipher *Cipher, log *slog.Logger) *OutboxPublisher {
+  p := &OutboxPublisher{db: db, js: js, cipher: cipher, log: log}
+  p.SetTuning(OutboxTuning{
+    MinInterval: DefaultOutboxMinInterval, MaxInterval: DefaultOutboxInterval, MinBatch: DefaultOutboxBatch, MaxBatch: DefaultOutboxMaxBatch,
+  })
+  return p
 }
 
-func NewOutboxPublisher(db *pgxpool.Pool, js nats.JetStreamContext, log *slog.Logger) *OutboxPublisher {
-  return &OutboxPublisher{db: db, js: js, log: log}
+// SetTuning changes the polling bounds from the next poll. Non-positive
+// values leave a bound unchanged; a maximum below its minimum is raised to it.
+func (p *OutboxPublisher) SetTuning(t OutboxTuning) {
+  cur := OutboxTuning{}
+  if old := p.tuning.Load(); old != nil { cur = *old }
+  if t.MinInterval > 0 { cur.MinInterval = t.MinInterval }
+  if t.MaxInterval > 0 { cur.MaxInterval = t.MaxInterval }
+  if t.MinBatch > 0 { cur.MinBatch = t.MinBatch }
+  if t.MaxBatch > 0 { cur.MaxBatch = t.MaxBatch }
+  cur.MaxInterval = max(cur.MaxInterval, cur.MinInterval)
+  cur.MaxBatch = max(cur.MaxBatch, cur.MinBatch)
+  p.tuning.Store(&cur)
 }
 
 func (p *OutboxPublisher) Run(ctx context.Context) {
-  ticker := time.NewTicker(250 * time.Millisecond)
-  defer ticker.Stop()
+  if n, err := p.Recover(ctx); err != nil {
+    p.log.WarnContext(ctx, "outbox recovery check failed; unacked events rely on stream dedup", "err", err.Error())
+  } else if n > 0 {
+    p.log.InfoContext(ctx, "outbox events found in the stream marked published", "events", n)
+  }
+  t := *p.tuning.Load()
+  batch, wait := t.MinBatch, t.MinInterval
+  timer := time.NewTimer(wait)
+  defer timer.Stop()
   for {
     select {
     case <-ctx.Done():
       return
-    case <-ticker.C:
-      _ = p.publishBatch(ctx, 50)
+    case <-timer.C:
+      bctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), outboxBatchTimeout)
+      n, err := p.publishBatch(bctx, batch)
+      cancel()
+      metrics.OutboxPolls.WithLabelValues(pollResult(batch, n, err)).Inc()
+      batch, wait = p.tuning.Load().next(batch, wait, n, err)
+      metrics.OutboxBatchLimit.Set(float64(batch))
+      metrics.OutboxPollWait.Set(wait.Seconds())
+      timer.Reset(wait)
     }
   }
 }
 
+// pollResult labels a poll for the outbox_polls_total metric.
+func pollResult(batch, n int, err error) string {
+  switch {
+  case err != nil:
+    return "error"
+  case n == 0:
+    return "empty"
+  case n >= batch:
+    return "full"
+  }
+  return "partial"
+}
+
+// Flush publishes batches until the outbox is empty or ctx is done, and returns
+// how many events it published.
+func (p *OutboxPublisher) Flush(ctx context.Context) (int, error) {
+  total := 0
+  for {
+    n, err := p.publishBatch(ctx, p.tuning.Load().MaxBatch)
+    total += n
+    if err != nil || n == 0 { return total, err }
+  }
+}
+
 type outboxRow struct {
   ID string
   EventType string
   Payload []byte
+  RequestID *string
+  TraceContext []byte
+  CreatedAt time.Time
 }
 
-func (p *OutboxPublisher) publishBatch(ctx context.Context, limit int) error {
+func (p *OutboxPublisher) publishBatch(ctx context.Context, limit int) (int, error) {
+  p.mu.Lock()
+  defer p.mu.Unlock()
   rows, err := p.db.Query(ctx, `
-    SELECT id::text, event_type, payload
+    SELECT id::text, event_type, payload, request_id, trace_context, created_at
     FROM outbox_events
     WHERE published_at IS NULL
     ORDER BY created_at
     LIMIT $1
   `, limit)
-  if err != nil { return err }
+  if err != nil { return 0, err }
   defer rows.Close()
 
   batch := []outboxRow{}
   for rows.Next() {
     var r outboxRow
-    if err := rows.Scan(&r.ID, &r.EventType, &r.Payload); err != nil { return err }
+    if err := rows.Scan(&r.ID, &r.EventType, &r.Payload, &r.RequestID, &r.TraceContext, &r.CreatedAt); err != nil { return 0, err }
     batch = append(batch, r)
   }
-  if len(batch) == 0 { return nil }
-
-  for _, r := range batch {
-    // attach event_id = outbox id if not present
-    var m map[string]any
-    _ = json.Unmarshal(r.Payload, &m)
-    if _, ok := m["event_id"]; !ok || m["event_id"] == "generated_by_db" {
-      m["event_id"] = r.ID
-    }
-    body, _ := json.Marshal(m)
+  rows.Close()
+  if len(batch) == 0 { return 0, nil }
 
-    // NATS message-id enables JetStream de-dup
-    msg := &nats.Msg{Subject: "events.transfer_posted", Data: body, Header: nats.Header{}}
-    msg.Header.Set("Nats-Msg-Id", r.ID)
+  for i, r := range batch {
+    if err := p.publishOne(ctx, r); err != nil { return i, err }
+  }
+  return len(batch), nil
+}
 
-    if _, err := p.js.PublishMsg(msg); err != nil {
-      p.log.Warn("publish failed", "event_id", r.ID, "err", err.Error())
-      return err
-    }
+// Recover marks unpublished events that are already in the stream as
+// published, so a publisher that died between the JetStream ack and the
+// UPDATE does not send them again once the stream's duplicate window has
+// passed. It reads the messages after the highest stream sequence stored on
+// an outbox row and matches their Nats-Msg-Id to unpublished rows. Run calls
+// it whenever this replica starts publishing.
+func (p *OutboxPublisher) Recover(ctx context.Context) (int, error) {
+  p.mu.Lock()
+  defer p.mu.Unlock()
+  var pending int64
+  var after *int64
+  err := p.db.QueryRow(ctx, `
+    SELECT (SELECT COUNT(*) FROM outbox_events WHERE published_at IS NULL), (SELECT MAX(stream_seq) FROM outbox_events)
+  `).Scan(&pending, &after)
+  if err != nil || pending == 0 { return 0, err }
+  info, err := p.js.StreamInfo(StreamName, nats.Context(ctx))
+  if err != nil { return 0, err }
+  from, ok := recoverFrom(after, info.State.FirstSeq, info.State.LastSeq, outboxRecoverScan)
+  if !ok { return 0, nil }
+  found, err := streamMsgIDs(ctx, p.js, from, info.State.LastSeq)
+  if err != nil { return 0, err }
+  ids, seqs := make([]string, 0, len(found)), make([]int64, 0, len(found))
+  for id, seq := range found {
+    if _, err := uuid.Parse(id); err != nil { continue } // not an outbox event id
+    ids, seqs = append(ids, id), append(seqs, int64(seq))
+  }
+  tag, err := p.db.Exec(ctx, `
+    UPDATE outbox_events o SET published_at=now(), stream_seq=s.seq
+    FROM unnest($1::uuid[], $2::bigint[]) AS s(id, seq)
+    WHERE o.id=s.id AND o.published_at IS NULL
+  `, ids, seqs)
+  if err != nil { return 0, err }
+  n := int(tag.RowsAffected())
+  metrics.OutboxDuplicates.WithLabelValues("recovery").Add(float64(n))
+  return n, nil
+}
 
-    _, err := p.db.Exec(ctx, `UPDATE outbox_events SET published_at=now() WHERE id=$1::uuid`, r.ID)
-    if err != nil {
-      p.log.Warn("mark published failed", "event_id", r.ID, "err", err.Error())
-      return err
-    }
+// recoverFrom is the first stream sequence Recover reads, given the highest
+// one stored on an outbox row (nil when none is). It reads at most limit
+// messages, ending at last, and none when no message followed after. A stored
+// sequence past last means the stream was recreated, so the tail is read.
+func recoverFrom(after *int64, first, last, limit uint64) (uint64, bool) {
+  if last == 0 || last < first { return 0, false }
+  from := first
+  if after != nil && uint64(*after) == last { return 0, false }
+  if after != nil && uint64(*after) < last { from = max(first, uint64(*after)+1) }
+  if last >= limit && from < last-limit+1 { from = last - limit + 1 }
+  return from, true
+}
+
+// streamMsgIDs reads the message ids of the stream's messages from..last.
+func streamMsgIDs(ctx context.Context, js nats.JetStreamContext, from, last uint64) (map[string]uint64, error) {
+  ids := map[string]uint64{}
+  for seq := from; seq <= last; seq++ {
+    m, err := js.GetMsg(StreamName, seq, nats.Context(ctx))
+    if errors.Is(err, nats.ErrMsgNotFound) { continue } // deleted or purged
+    if err != nil { return nil, err }
+    if id := m.Header.Get("Nats-Msg-Id"); id != "" { ids[id] = seq }
   }
+  return ids, nil
+}
+
+// OutboxBacklog counts events not yet published to JetStream.
+func OutboxBacklog(ctx context.Context, db *pgxpool.Pool) (int64, error) {
+  var n int64
+  err := db.QueryRow(ctx, `SELECT COUNT(*) FROM outbox_events WHERE published_at IS NULL`).Scan(&n)
+  return n, err
+}
+
+// publishOne publishes one event under a producer span that continues the trace
+// of the request that wrote it, and passes the trace on in the NATS headers.
+func (p *OutboxPublisher) publishOne(ctx context.Context, r outboxRow) (err error) {
+  // attach event_id = outbox id if not present
+  var m map[string]any
+  _ = json.Unmarshal(r.Payload, &m)
+  if _, ok := m["event_id"]; !ok || m["event_id"] == "generated_by_db" {
+    m["event_id"] = r.ID
+  }
+  body, _ := json.Marshal(m)
+
+  subject := eventSubject(r.EventType)
+  // NATS message-id enables JetStream de-dup
+  msg := &nats.Msg{Subject: subject, Data: body, Header: nats.Header{}}
+  msg.Header.Set("Nats-Msg-Id", r.ID)
+  if r.RequestID != nil {
+    msg.Header.Set(logging.Header, *r.RequestID)
+    ctx = logging.WithRequestID(ctx, *r.RequestID)
+  }
+  ctx, span := tracing.Start(tracing.FromCarrier(ctx, r.TraceContext), "outbox publish "+subject, trace.WithSpanKind(trace.SpanKindProducer), trace.WithAttributes(
+    attribute.String("messaging.system", "nats"),
+    attribute.String("messaging.destination.name", subject),
+    attribute.String("messaging.message.id", r.ID),
+  ))
+  defer func() { tracing.End(span, err) }()
+  tracing.Propagator.Inject(ctx, tracing.HeaderCarrier(msg.Header))
+  if err := p.cipher.Seal(msg); err != nil { return err }
+
+  ack, err := p.js.PublishMsg(msg)
+  if err != nil {
+    metrics.OutboxPublishFailures.Inc()
+    p.log.WarnContext(ctx, "publish failed", "event_id", r.ID, "err", err.Error())
+    return err
+  }
+  // a duplicate ack carries the sequence the event was first stored under
+  if ack.Duplicate {
+    metrics.OutboxDuplicates.WithLabelValues("ack").Inc()
+    p.log.InfoContext(ctx, "event already in the stream", "event_id", r.ID, "stream_seq", ack.Sequence)
+  }
+  span.SetAttributes(attribute.Int64("messaging.nats.stream_seq", int64(ack.Sequence)))
+
+  if _, err := p.db.Exec(ctx, `UPDATE outbox_events SET published_at=now(), stream_seq=$2 WHERE id=$1::uuid`, r.ID, int64(ack.Sequence)); err != nil {
+    metrics.OutboxPublishFailures.Inc()
+    p.log.WarnContext(ctx, "mark published failed", "event_id", r.ID, "err", err.Error())
+    return err
+  }
+  metrics.OutboxPublished.Inc()
+  metrics.OutboxPublishLatency.Observe(time.Since(r.CreatedAt).Seconds())
   return nil
 }
+
+// eventSubject maps an outbox event type to its JetStream subject (TRANSFER_POSTED -> events.transfer_posted).
+func eventSubject(eventType string) string {
+  return "events." + strings.ToLower(eventType)
+}
@@ -0,0 +1,118 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+func TestOutboxTuningAdapts(t *testing.T) {
+	tun := OutboxTuning{MinInterval: 10 * time.Millisecond, MaxInterval: 80 * time.Millisecond, MinBatch: 50, MaxBatch: 300}
+	batch, wait := tun.MinBatch, tun.MinInterval
+	step := func(n int, err error, wantBatch int, wantWait time.Duration) {
+		t.Helper()
+		batch, wait = tun.next(batch, wait, n, err)
+		if batch != wantBatch || wait != wantWait {
+			t.Fatalf("after %d published (%v): batch %d wait %s, want %d %s", n, err, batch, wait, wantBatch, wantWait)
+		}
+	}
+	// a burst: full batches poll again at once with twice the batch, up to the max
+	step(50, nil, 100, 0)
+	step(100, nil, 200, 0)
+	step(200, nil, 300, 0)
+	step(300, nil, 300, 0)
+	// draining: a partial batch waits the minimum; a small one also shrinks the batch
+	step(120, nil, 300, 10*time.Millisecond)
+	step(20, nil, 150, 10*time.Millisecond)
+	// idle: the wait doubles up to the max and the batch shrinks to the min
+	step(0, nil, 75, 20*time.Millisecond)
+	step(0, nil, 50, 40*time.Millisecond)
+	step(0, nil, 50, 80*time.Millisecond)
+	step(0, nil, 50, 80*time.Millisecond)
+	// errors back off like an empty poll but keep the batch
+	batch, wait = 200, 0
+	step(0, errors.New("nats down"), 200, 10*time.Millisecond)
+
+	// reloaded bounds apply to the next poll
+	tun.MaxBatch = 100
+	step(100, nil, 100, 0)
+}
+
+func TestOutboxSetTuningKeepsBounds(t *testing.T) {
+	p := NewOutboxPublisher(nil, nil, nil, nil)
+	p.SetTuning(OutboxTuning{MinBatch: 800})
+	got := *p.tuning.Load()
+	if got.MinBatch != 800 || got.MaxBatch != 800 || got.MinInterval != DefaultOutboxMinInterval || got.MaxInterval != DefaultOutboxInterval {
+		t.Fatalf("tuning = %+v", got)
+	}
+}
+
+func TestRecoverFrom(t *testing.T) {
+	seq := func(n int64) *int64 { return &n }
+	for _, tc := range []struct {
+		name              string
+		after             *int64
+		first, last, want uint64
+		ok                bool
+	}{
+		{"empty stream", nil, 0, 0, 0, false},
+		{"nothing stored yet", nil, 1, 40, 1, true},
+		{"nothing stored, long stream", nil, 1, 25000, 15001, true},
+		{"after the last stored", seq(30), 1, 40, 31, true},
+		{"nothing newer", seq(40), 1, 40, 0, false},
+		{"stored before a purge", seq(5), 20, 40, 20, true},
+		{"stream recreated", seq(900), 1, 40, 1, true},
+		{"far behind", seq(10), 1, 50000, 40001, true},
+	} {
+		from, ok := recoverFrom(tc.after, tc.first, tc.last, outboxRecoverScan)
+		if from != tc.want || ok != tc.ok {
+			t.Errorf("%s: from %d %v, want %d %v", tc.name, from, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestStreamMsgIDs(t *testing.T) {
+	srv, err := StartEmbedded()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Shutdown()
+	nc, err := nats.Connect(srv.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer nc.Close()
+	js, err := nc.JetStream()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := EnsureStreams(ctx, js, DefaultStreamConfig()); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"e1", "e2", "", "e1", "e3"} {
+		msg := &nats.Msg{Subject: "events.transfer_posted", Data: []byte(`{}`), Header: nats.Header{}}
+		if id != "" {
+			msg.Header.Set("Nats-Msg-Id", id)
+		}
+		ack, err := js.PublishMsg(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// a republished id is acked with the sequence it was first stored under
+		if id == "e1" && ack.Sequence != 1 {
+			t.Fatalf("e1 acked as %d (duplicate %v)", ack.Sequence, ack.Duplicate)
+		}
+	}
+	if err := js.DeleteMsg(StreamName, 2); err != nil {
+		t.Fatal(err)
+	}
+	ids, err := streamMsgIDs(ctx, js, 1, 4)
+	if err != nil || !reflect.DeepEqual(ids, map[string]uint64{"e1": 1, "e3": 4}) {
+		t.Fatalf("ids = %v, %v", ids, err)
+	}
+}
@@ -0,0 +1,92 @@
+package messaging
+
+import (
+  "context"
+  "encoding/json"
+  "time"
+
+  "github.com/nats-io/nats.go"
+  "go.opentelemetry.io/otel/attribute"
+  "go.opentelemetry.io/otel/trace"
+  "log/slog"
+
+  "time-ledger-sim/go/internal/logging"
+  "time-ledger-sim/go/internal/tracing"
+)
+
+// SagaStepper runs a saga step; the ledger implements it.
+type SagaStepper interface {
+  AdvanceSaga(ctx context.Context, sagaID, step string) error
+}
+
+// SagaConsumer drives sagas: each SAGA_STEP_DUE event runs that step. It
+// keeps no inbox, since a step event for a step the saga has left is a no-op.
+type SagaConsumer struct {
+  sagas SagaStepper
+  js nats.JetStreamContext
+  cipher *Cipher
+  log *slog.Logger
+}
+
+func NewSagaConsumer(sagas SagaStepper, js nats.JetStreamContext, cipher *Cipher, log *slog.Logger) *SagaConsumer {
+  return &SagaConsumer{sagas: sagas, js: js, cipher: cipher, log: log}
+}
+
+type sagaStepDue struct {
+  SagaID string `json:"saga_id"`
+  Step string `json:"step"`
+}
+
+func (c *SagaConsumer) Run(ctx context.Context) {
+  sub, err := c.js.PullSubscribe("events.saga_step_due", "saga-v1", nats.BindStream(StreamName))
+  if err != nil {
+    c.log.Error("saga subscribe failed", "err", err.Error())
+    return
+  }
+
+  for {
+    select {
+    case <-ctx.Done():
+      return
+    default:
+    }
+
+    msgs, err := sub.Fetch(10, nats.MaxWait(1*time.Second))
+    if err != nil && err != nats.ErrTimeout {
+      c.log.Warn("fetch failed", "err", err.Error())
+      continue
+    }
+    bctx := context.WithoutCancel(ctx)
+    for _, msg := range msgs {
+      _ = c.handleMsg(bctx, msg)
+    }
+  }
+}
+
+// handleMsg runs one step under a consumer span joined to the trace of the
+// request that started the saga. A failed step is not acked, so JetStream
+// redelivers it; the control scheduler also emits stalled steps again.
+func (c *SagaConsumer) handleMsg(ctx context.Context, msg *nats.Msg) (err error) {
+  ctx = logging.WithRequestID(ctx, msg.Header.Get(logging.Header))
+  ctx = tracing.Propagator.Extract(ctx, tracing.HeaderCarrier(msg.Header))
+  ctx, span := tracing.Start(ctx, "saga-v1 process", trace.WithSpanKind(trace.SpanKindConsumer), trace.WithAttributes(
+    attribute.String("messaging.system", "nats"),
+    attribute.String("messaging.destination.name", msg.Subject),
+    attribute.String("messaging.message.id", msg.Header.Get("Nats-Msg-Id")),
+  ))
+  defer func() { tracing.End(span, err) }()
+  if err = openPayload(ctx, c.cipher, msg, "saga-v1", c.log); err != nil { return err }
+  var ev sagaStepDue
+  if err := json.Unmarshal(msg.Data, &ev); err != nil || ev.SagaID == "" || ev.Step == "" {
+    _ = msg.Ack()
+    return nil
+  }
+  span.SetAttributes(attribute.String("saga.id", ev.SagaID), attribute.String("saga.step", ev.Step))
+
+  if err = c.sagas.AdvanceSaga(ctx, ev.SagaID, ev.Step); err != nil {
+    c.log.WarnContext(ctx, "saga step failed", "saga_id", ev.SagaID, "step", ev.Step, "err", err.Error())
+    return err
+  }
+  _ = msg.Ack()
+  return nil
+}
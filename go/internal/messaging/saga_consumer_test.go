@@ -0,0 +1,48 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+)
+
+type fakeSagaStepper struct {
+	steps []string
+	err   error
+}
+
+func (f *fakeSagaStepper) AdvanceSaga(_ context.Context, sagaID, step string) error {
+	f.steps = append(f.steps, sagaID+"/"+step)
+	return f.err
+}
+
+func TestSagaConsumerAdvancesSteps(t *testing.T) {
+	stepper := &fakeSagaStepper{}
+	c := &SagaConsumer{sagas: stepper, log: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	ctx := context.Background()
+	msg := func(data string) *nats.Msg {
+		return &nats.Msg{Subject: "events.saga_step_due", Data: []byte(data), Header: nats.Header{}}
+	}
+
+	if err := c.handleMsg(ctx, msg(`{"event_id":"e1","saga_id":"s1","step":"DEBIT"}`)); err != nil {
+		t.Fatal(err)
+	}
+	// malformed and incomplete events are dropped, not retried
+	for _, data := range []string{`not json`, `{"saga_id":"s1"}`} {
+		if err := c.handleMsg(ctx, msg(data)); err != nil {
+			t.Fatalf("%s: %v", data, err)
+		}
+	}
+	if len(stepper.steps) != 1 || stepper.steps[0] != "s1/DEBIT" {
+		t.Fatalf("steps = %v", stepper.steps)
+	}
+
+	stepper.err = errors.New("db down")
+	if err := c.handleMsg(ctx, msg(`{"saga_id":"s1","step":"CREDIT"}`)); err == nil {
+		t.Fatal("a failed step should be left for redelivery")
+	}
+}
@@ -0,0 +1,222 @@
+package messaging
+
+import (
+  "context"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "time"
+
+  "github.com/nats-io/nats.go"
+)
+
+var (
+  ErrStreamNotFound = errors.New("stream not found")
+  ErrConsumerNotFound = errors.New("consumer not found")
+  ErrConsumerNotDurable = errors.New("consumer is not durable")
+)
+
+func IsStreamNotFound(err error) bool { return errors.Is(err, ErrStreamNotFound) }
+func IsConsumerNotFound(err error) bool { return errors.Is(err, ErrConsumerNotFound) }
+func IsConsumerNotDurable(err error) bool { return errors.Is(err, ErrConsumerNotDurable) }
+
+// StreamInfo is a JetStream stream's configuration and state.
+type StreamInfo struct {
+  Name string `json:"name"`
+  Subjects []string `json:"subjects"`
+  Retention string `json:"retention"`
+  Storage string `json:"storage"`
+  Discard string `json:"discard"`
+  MaxAge string `json:"max_age"` // "0s": unlimited
+  MaxMsgsPerSubject int64 `json:"max_msgs_per_subject"`
+  DuplicateWindow string `json:"duplicate_window"`
+  Replicas int `json:"replicas"`
+  Messages uint64 `json:"messages"`
+  Bytes uint64 `json:"bytes"`
+  FirstSeq uint64 `json:"first_seq"`
+  FirstAt *time.Time `json:"first_at"`
+  LastSeq uint64 `json:"last_seq"`
+  LastAt *time.Time `json:"last_at"`
+  Consumers int `json:"consumers"`
+  CreatedAt time.Time `json:"created_at"`
+}
+
+// ConsumerInfo is a stream consumer's configuration and progress.
+type ConsumerInfo struct {
+  Stream string `json:"stream"`
+  Name string `json:"name"`
+  Durable bool `json:"durable"`
+  FilterSubject string `json:"filter_subject"`
+  DeliverPolicy string `json:"deliver_policy"`
+  AckPolicy string `json:"ack_policy"`
+  AckWait string `json:"ack_wait"`
+  MaxDeliver int `json:"max_deliver"` // -1: unlimited
+  Pending uint64 `json:"pending"` // messages not yet delivered
+  AckPending int `json:"ack_pending"` // delivered, not yet acked
+  Redelivered int `json:"redelivered"`
+  Waiting int `json:"waiting"` // open pull requests
+  DeliveredSeq uint64 `json:"delivered_seq"` // stream sequence last delivered
+  AckFloorSeq uint64 `json:"ack_floor_seq"` // stream sequence acked up to
+  LastActive *time.Time `json:"last_active"`
+  CreatedAt time.Time `json:"created_at"`
+}
+
+// StreamDetail is a stream with its consumers.
+type StreamDetail struct {
+  StreamInfo
+  ConsumerList []ConsumerInfo `json:"consumer_list"`
+}
+
+// StreamAdmin inspects and manages JetStream streams and consumers for the
+// admin API, so operators need not reach for the nats CLI.
+type StreamAdmin struct {
+  js nats.JetStreamContext
+}
+
+func NewStreamAdmin(js nats.JetStreamContext) *StreamAdmin { return &StreamAdmin{js: js} }
+
+// jsErr maps the client's not-found errors to ours.
+func jsErr(err error, name string) error {
+  switch {
+  case errors.Is(err, nats.ErrStreamNotFound):
+    return fmt.Errorf("%w: %s", ErrStreamNotFound, name)
+  case errors.Is(err, nats.ErrConsumerNotFound):
+    return fmt.Errorf("%w: %s", ErrConsumerNotFound, name)
+  }
+  return err
+}
+
+// enumName is a JetStream policy as the server spells it ("limits", "explicit").
+func enumName(m json.Marshaler) string {
+  b, err := m.MarshalJSON()
+  if err != nil { return "" }
+  var s string
+  _ = json.Unmarshal(b, &s)
+  return s
+}
+
+func optTime(t time.Time) *time.Time {
+  if t.IsZero() { return nil }
+  return &t
+}
+
+func toStreamInfo(si *nats.StreamInfo) StreamInfo {
+  c, st := si.Config, si.State
+  return StreamInfo{
+    Name: c.Name, Subjects: c.Subjects, Retention: enumName(c.Retention), Storage: enumName(c.Storage), Discard: enumName(c.Discard),
+    MaxAge: c.MaxAge.String(), MaxMsgsPerSubject: c.MaxMsgsPerSubject, DuplicateWindow: c.Duplicates.String(), Replicas: c.Replicas,
+    Messages: st.Msgs, Bytes: st.Bytes, FirstSeq: st.FirstSeq, FirstAt: optTime(st.FirstTime), LastSeq: st.LastSeq, LastAt: optTime(st.LastTime),
+    Consumers: st.Consumers, CreatedAt: si.Created,
+  }
+}
+
+func toConsumerInfo(ci *nats.ConsumerInfo) ConsumerInfo {
+  c := ci.Config
+  return ConsumerInfo{
+    Stream: ci.Stream, Name: ci.Name, Durable: c.Durable != "", FilterSubject: c.FilterSubject,
+    DeliverPolicy: enumName(c.DeliverPolicy), AckPolicy: enumName(c.AckPolicy), AckWait: c.AckWait.String(), MaxDeliver: c.MaxDeliver,
+    Pending: ci.NumPending, AckPending: ci.NumAckPending, Redelivered: ci.NumRedelivered, Waiting: ci.NumWaiting,
+    DeliveredSeq: ci.Delivered.Stream, AckFloorSeq: ci.AckFloor.Stream, LastActive: ci.Delivered.Last, CreatedAt: ci.Created,
+  }
+}
+
+// Streams lists every stream on the server, by name.
+func (s *StreamAdmin) Streams(ctx context.Context) ([]StreamInfo, error) {
+  out := []StreamInfo{}
+  lister := s.js.StreamsInfo(nats.Context(ctx))
+  for si := range lister {
+    out = append(out, toStreamInfo(si))
+  }
+  if err := ctx.Err(); err != nil { return nil, err }
+  return out, nil
+}
+
+// Stream returns a stream with its consumers.
+func (s *StreamAdmin) Stream(ctx context.Context, name string) (*StreamDetail, error) {
+  si, err := s.js.StreamInfo(name, nats.Context(ctx))
+  if err != nil { return nil, jsErr(err, name) }
+  d := &StreamDetail{StreamInfo: toStreamInfo(si), ConsumerList: []ConsumerInfo{}}
+  for ci := range s.js.ConsumersInfo(name, nats.Context(ctx)) {
+    d.ConsumerList = append(d.ConsumerList, toConsumerInfo(ci))
+  }
+  if err := ctx.Err(); err != nil { return nil, err }
+  return d, nil
+}
+
+func (s *StreamAdmin) Consumer(ctx context.Context, stream, name string) (*ConsumerInfo, error) {
+  if _, err := s.js.StreamInfo(stream, nats.Context(ctx)); err != nil { return nil, jsErr(err, stream) }
+  ci, err := s.js.ConsumerInfo(stream, name, nats.Context(ctx))
+  if err != nil { return nil, jsErr(err, name) }
+  info := toConsumerInfo(ci)
+  return &info, nil
+}
+
+type PurgeInput struct {
+  Subject string // only messages on this subject (wildcards allowed); empty: all
+  Keep uint64 // keep this many of the newest matching messages
+}
+
+// PurgeReport tells how many messages a purge removed. Messages published
+// during the purge count against it.
+type PurgeReport struct {
+  Stream string `json:"stream"`
+  Subject string `json:"subject,omitempty"`
+  Purged uint64 `json:"purged"`
+  Messages uint64 `json:"messages"` // left in the stream
+}
+
+// Purge removes messages from a stream. Consumers skip past purged
+// messages; the outbox keeps its copy of every event either way.
+func (s *StreamAdmin) Purge(ctx context.Context, stream string, in PurgeInput) (*PurgeReport, error) {
+  before, err := s.js.StreamInfo(stream, nats.Context(ctx))
+  if err != nil { return nil, jsErr(err, stream) }
+  req := &nats.StreamPurgeRequest{Subject: in.Subject, Keep: in.Keep}
+  if err := s.js.PurgeStream(stream, req, nats.Context(ctx)); err != nil { return nil, jsErr(err, stream) }
+  after, err := s.js.StreamInfo(stream, nats.Context(ctx))
+  if err != nil { return nil, jsErr(err, stream) }
+  rep := &PurgeReport{Stream: stream, Subject: in.Subject, Messages: after.State.Msgs}
+  if before.State.Msgs > after.State.Msgs { rep.Purged = before.State.Msgs - after.State.Msgs }
+  return rep, nil
+}
+
+type ResetConsumerInput struct {
+  Deliver string // all (default), new, last, by_start_sequence or by_start_time
+  StartSeq uint64 // by_start_sequence
+  StartTime time.Time // by_start_time
+}
+
+// ResetConsumer recreates a durable consumer with its configuration and a
+// new starting point, dropping its delivery state: messages it had not acked
+// are delivered again from there. Its subscribers keep pulling from the new
+// consumer, which has the same name. Consumers with an inbox skip events they
+// have already processed.
+func (s *StreamAdmin) ResetConsumer(ctx context.Context, stream, name string, in ResetConsumerInput) (*ConsumerInfo, error) {
+  if in.Deliver == "" { in.Deliver = "all" }
+  var policy nats.DeliverPolicy
+  if err := policy.UnmarshalJSON([]byte(`"` + in.Deliver + `"`)); err != nil || policy == nats.DeliverLastPerSubjectPolicy {
+    return nil, fmt.Errorf("deliver must be all, new, last, by_start_sequence or by_start_time")
+  }
+  if policy == nats.DeliverByStartSequencePolicy && in.StartSeq == 0 { return nil, fmt.Errorf("start_seq required for by_start_sequence") }
+  if policy == nats.DeliverByStartTimePolicy && in.StartTime.IsZero() { return nil, fmt.Errorf("start_time required for by_start_time") }
+
+  if _, err := s.js.StreamInfo(stream, nats.Context(ctx)); err != nil { return nil, jsErr(err, stream) }
+  ci, err := s.js.ConsumerInfo(stream, name, nats.Context(ctx))
+  if err != nil { return nil, jsErr(err, name) }
+  cfg := ci.Config
+  // ephemeral consumers go away with their subscriber
+  if cfg.Durable == "" { return nil, fmt.Errorf("%w: %s", ErrConsumerNotDurable, name) }
+  cfg.DeliverPolicy, cfg.OptStartSeq, cfg.OptStartTime = policy, 0, nil
+  switch policy {
+  case nats.DeliverByStartSequencePolicy:
+    cfg.OptStartSeq = in.StartSeq
+  case nats.DeliverByStartTimePolicy:
+    t := in.StartTime.UTC()
+    cfg.OptStartTime = &t
+  }
+
+  if err := s.js.DeleteConsumer(stream, name, nats.Context(ctx)); err != nil { return nil, jsErr(err, name) }
+  ci, err = s.js.AddConsumer(stream, &cfg, nats.Context(ctx))
+  if err != nil { return nil, err }
+  info := toConsumerInfo(ci)
+  return &info, nil
+}
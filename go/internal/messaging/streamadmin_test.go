@@ -0,0 +1,113 @@
+package messaging
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+func TestStreamAdmin(t *testing.T) {
+	srv, err := StartEmbedded()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Shutdown()
+	nc, err := nats.Connect(srv.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer nc.Close()
+	js, err := nc.JetStream()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := EnsureStreams(ctx, js, DefaultStreamConfig()); err != nil {
+		t.Fatal(err)
+	}
+	for _, subj := range []string{"events.transfer_posted", "events.transfer_posted", "events.incident_opened"} {
+		if _, err := js.Publish(subj, []byte(`{}`)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sub, err := js.PullSubscribe("events.>", "admin-test-v1", nats.BindStream(StreamName), nats.ManualAck())
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs, err := sub.Fetch(2, nats.MaxWait(time.Second))
+	if err != nil || len(msgs) != 2 {
+		t.Fatalf("fetch = %d, %v", len(msgs), err)
+	}
+	for _, m := range msgs {
+		_ = m.AckSync()
+	}
+	a := NewStreamAdmin(js)
+
+	streams, err := a.Streams(ctx)
+	if err != nil || len(streams) != 1 || streams[0].Name != StreamName || streams[0].Messages != 3 || streams[0].Retention != "limits" {
+		t.Fatalf("streams = %+v, %v", streams, err)
+	}
+	detail, err := a.Stream(ctx, StreamName)
+	if err != nil || len(detail.ConsumerList) != 1 || detail.ConsumerList[0].Name != "admin-test-v1" {
+		t.Fatalf("stream = %+v, %v", detail, err)
+	}
+	c, err := a.Consumer(ctx, StreamName, "admin-test-v1")
+	if err != nil || !c.Durable || c.AckFloorSeq != 2 || c.Pending != 1 || c.AckPolicy != "explicit" || c.DeliverPolicy != "all" {
+		t.Fatalf("consumer = %+v, %v", c, err)
+	}
+	if _, err := a.Stream(ctx, "NOPE"); !IsStreamNotFound(err) {
+		t.Errorf("unknown stream: err = %v", err)
+	}
+	if _, err := a.Consumer(ctx, StreamName, "nope"); !IsConsumerNotFound(err) {
+		t.Errorf("unknown consumer: err = %v", err)
+	}
+	if _, err := a.Consumer(ctx, "NOPE", "admin-test-v1"); !IsStreamNotFound(err) {
+		t.Errorf("consumer of unknown stream: err = %v", err)
+	}
+
+	// reset to the start: the same subscription gets the acked messages again
+	c, err = a.ResetConsumer(ctx, StreamName, "admin-test-v1", ResetConsumerInput{})
+	if err != nil || c.AckFloorSeq != 0 || c.Pending != 3 {
+		t.Fatalf("reset = %+v, %v", c, err)
+	}
+	msgs, err = sub.Fetch(1, nats.MaxWait(time.Second))
+	if err != nil || len(msgs) != 1 {
+		t.Fatalf("fetch after reset = %d, %v", len(msgs), err)
+	}
+	if meta, _ := msgs[0].Metadata(); meta.Sequence.Stream != 1 {
+		t.Fatalf("redelivered from %d, want 1", meta.Sequence.Stream)
+	}
+	c, err = a.ResetConsumer(ctx, StreamName, "admin-test-v1", ResetConsumerInput{Deliver: "by_start_sequence", StartSeq: 3})
+	if err != nil || c.DeliverPolicy != "by_start_sequence" || c.Pending != 1 {
+		t.Fatalf("reset to seq 3 = %+v, %v", c, err)
+	}
+	if _, err := a.ResetConsumer(ctx, StreamName, "admin-test-v1", ResetConsumerInput{Deliver: "by_start_sequence"}); err == nil {
+		t.Error("by_start_sequence without start_seq accepted")
+	}
+	eph, err := js.PullSubscribe("events.>", "", nats.BindStream(StreamName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer eph.Unsubscribe()
+	info, err := eph.ConsumerInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.ResetConsumer(ctx, StreamName, info.Name, ResetConsumerInput{}); !IsConsumerNotDurable(err) {
+		t.Errorf("ephemeral reset: err = %v", err)
+	}
+
+	rep, err := a.Purge(ctx, StreamName, PurgeInput{Subject: "events.transfer_posted", Keep: 1})
+	if err != nil || rep.Purged != 1 || rep.Messages != 2 {
+		t.Fatalf("subject purge = %+v, %v", rep, err)
+	}
+	rep, err = a.Purge(ctx, StreamName, PurgeInput{})
+	if err != nil || rep.Purged != 2 || rep.Messages != 0 {
+		t.Fatalf("purge = %+v, %v", rep, err)
+	}
+	if _, err := a.Purge(ctx, "NOPE", PurgeInput{}); !IsStreamNotFound(err) {
+		t.Errorf("purge unknown stream: err = %v", err)
+	}
+}
@@ -2,6 +2,10 @@ package messaging
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/nats-io/nats.go"
@@ -9,22 +13,152 @@ import (
 
 const (
 	StreamName = "EVENTS"
+	// EventSubjects are the subjects the outbox publishes on; the stream must
+	// capture all of them.
+	EventSubjects = "events.>"
 )
 
-func EnsureStreams(ctx context.Context, js nats.JetStreamContext) error {
-	// Create stream if missing (idempotent)
-	_, err := js.StreamInfo(StreamName)
-	if err == nil {
-		return nil
+// ErrStreamConfigConflict means the existing stream differs from the
+// configured one in a way EnsureStreams will not change in place.
+var ErrStreamConfigConflict = errors.New("stream config conflict")
+
+func IsStreamConfigConflict(err error) bool { return errors.Is(err, ErrStreamConfigConflict) }
+
+// StreamConfig is the EVENTS stream's configuration. Zero limits are
+// unlimited.
+type StreamConfig struct {
+	Subjects          []string      `yaml:"subjects"`             // NATS_STREAM_SUBJECTS, comma-separated; must include events.> (or >)
+	Retention         string        `yaml:"retention"`            // NATS_STREAM_RETENTION: limits (default) or interest
+	Storage           string        `yaml:"storage"`              // NATS_STREAM_STORAGE: file (default) or memory
+	MaxAge            time.Duration `yaml:"max_age"`              // NATS_STREAM_MAX_AGE
+	MaxBytes          int64         `yaml:"max_bytes"`            // NATS_STREAM_MAX_BYTES
+	MaxMsgsPerSubject int64         `yaml:"max_msgs_per_subject"` // NATS_STREAM_MAX_MSGS_PER_SUBJECT
+	DuplicateWindow   time.Duration `yaml:"duplicate_window"`     // NATS_STREAM_DUPLICATE_WINDOW; publishes with a seen Nats-Msg-Id within it are dropped
+	Replicas          int           `yaml:"replicas"`             // NATS_STREAM_REPLICAS; 1 unless NATS is clustered
+	// AllowDataLoss lets EnsureStreams tighten the limits of an existing
+	// stream, which deletes the messages over the new ones at once.
+	AllowDataLoss bool `yaml:"allow_data_loss"` // NATS_STREAM_ALLOW_DATA_LOSS
+}
+
+func DefaultStreamConfig() StreamConfig {
+	return StreamConfig{
+		Subjects:          []string{EventSubjects},
+		Retention:         "limits",
+		Storage:           "file",
+		MaxMsgsPerSubject: 1000000,
+		DuplicateWindow:   2 * time.Minute,
+		Replicas:          1,
 	}
-	_, err = js.AddStream(&nats.StreamConfig{
+}
+
+// natsConfig is the stream config to create or update to. Unlimited is -1,
+// as the server reports it, so it compares with StreamInfo.
+func (c StreamConfig) natsConfig() (*nats.StreamConfig, error) {
+	out := &nats.StreamConfig{
 		Name:              StreamName,
-		Subjects:          []string{"events.>"},
-		Storage:           nats.FileStorage,
-		Retention:         nats.LimitsPolicy,
-		MaxMsgsPerSubject: 1000000,
+		Subjects:          c.Subjects,
+		MaxAge:            c.MaxAge,
+		MaxBytes:          unlimited(c.MaxBytes),
+		MaxMsgsPerSubject: unlimited(c.MaxMsgsPerSubject),
+		MaxMsgs:           -1,
+		MaxConsumers:      -1,
 		Discard:           nats.DiscardOld,
-		Duplicates:        2 * time.Minute, // 2 minutes
-	})
-	return err
+		Duplicates:        c.DuplicateWindow,
+		Replicas:          c.Replicas,
+	}
+	if err := out.Retention.UnmarshalJSON([]byte(`"` + c.Retention + `"`)); err != nil || out.Retention == nats.WorkQueuePolicy {
+		return nil, fmt.Errorf("retention: want limits or interest, got %q", c.Retention)
+	}
+	if err := out.Storage.UnmarshalJSON([]byte(`"` + c.Storage + `"`)); err != nil {
+		return nil, fmt.Errorf("storage: want file or memory, got %q", c.Storage)
+	}
+	return out, nil
+}
+
+func unlimited(n int64) int64 {
+	if n == 0 {
+		return -1
+	}
+	return n
+}
+
+// EnsureStreams creates the EVENTS stream, or updates an existing one to
+// match cfg, and returns the settings it changed. Storage and retention
+// cannot change in place, and tighter limits would delete messages unless
+// cfg.AllowDataLoss; either leaves the stream as it is and returns
+// ErrStreamConfigConflict. Settings cfg does not cover (e.g. ones set with
+// the nats CLI) are kept.
+func EnsureStreams(ctx context.Context, js nats.JetStreamContext, cfg StreamConfig) ([]string, error) {
+	want, err := cfg.natsConfig()
+	if err != nil {
+		return nil, err
+	}
+	info, err := js.StreamInfo(StreamName, nats.Context(ctx))
+	if errors.Is(err, nats.ErrStreamNotFound) {
+		_, err = js.AddStream(want, nats.Context(ctx))
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	have := info.Config
+	changed, conflicts := streamDrift(have, *want, cfg.AllowDataLoss)
+	if len(conflicts) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrStreamConfigConflict, strings.Join(conflicts, "; "))
+	}
+	if len(changed) == 0 {
+		return nil, nil
+	}
+	upd := have
+	upd.Subjects, upd.Discard, upd.Duplicates, upd.Replicas = want.Subjects, want.Discard, want.Duplicates, want.Replicas
+	upd.MaxAge, upd.MaxBytes, upd.MaxMsgsPerSubject = want.MaxAge, want.MaxBytes, want.MaxMsgsPerSubject
+	if _, err := js.UpdateStream(&upd, nats.Context(ctx)); err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
+// streamDrift compares the settings EnsureStreams manages: what differs, and
+// which of those differences it must not apply.
+func streamDrift(have, want nats.StreamConfig, allowDataLoss bool) (changed, conflicts []string) {
+	if have.Storage != want.Storage {
+		conflicts = append(conflicts, fmt.Sprintf("storage is %s, not %s; delete the stream to recreate it", enumName(have.Storage), enumName(want.Storage)))
+	}
+	if have.Retention != want.Retention {
+		conflicts = append(conflicts, fmt.Sprintf("retention is %s, not %s; delete the stream to recreate it", enumName(have.Retention), enumName(want.Retention)))
+	}
+	limit := func(key string, have, want int64, show func(int64) string) {
+		if have == want {
+			return
+		}
+		changed = append(changed, key)
+		if want > 0 && (have <= 0 || want < have) && !allowDataLoss {
+			conflicts = append(conflicts, fmt.Sprintf("%s would tighten from %s to %s and delete messages; set allow_data_loss", key, show(have), show(want)))
+		}
+	}
+	if !slices.Equal(have.Subjects, want.Subjects) {
+		changed = append(changed, "subjects")
+	}
+	count := func(n int64) string { return limitName(n, fmt.Sprint(n)) }
+	limit("max_age", int64(have.MaxAge), int64(want.MaxAge), func(n int64) string { return limitName(n, time.Duration(n).String()) })
+	limit("max_bytes", have.MaxBytes, want.MaxBytes, count)
+	limit("max_msgs_per_subject", have.MaxMsgsPerSubject, want.MaxMsgsPerSubject, count)
+	if have.Duplicates != want.Duplicates {
+		changed = append(changed, "duplicate_window")
+	}
+	if have.Replicas != want.Replicas {
+		changed = append(changed, "replicas")
+	}
+	if have.Discard != want.Discard {
+		changed = append(changed, "discard")
+	}
+	return changed, conflicts
+}
+
+func limitName(n int64, shown string) string {
+	if n <= 0 {
+		return "unlimited"
+	}
+	return shown
 }
@@ -0,0 +1,81 @@
+package messaging
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+func TestEnsureStreamsReconcilesConfig(t *testing.T) {
+	srv, err := StartEmbedded()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Shutdown()
+	nc, err := nats.Connect(srv.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer nc.Close()
+	js, err := nc.JetStream()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	cfg := DefaultStreamConfig()
+	if changed, err := EnsureStreams(ctx, js, cfg); err != nil || changed != nil {
+		t.Fatalf("create: changed = %v, err = %v", changed, err)
+	}
+	if changed, err := EnsureStreams(ctx, js, cfg); err != nil || changed != nil {
+		t.Fatalf("unchanged config: changed = %v, err = %v", changed, err)
+	}
+
+	// looser limits and more subjects apply in place
+	cfg.Subjects = []string{EventSubjects, "audit.>"}
+	cfg.MaxMsgsPerSubject = 0
+	cfg.DuplicateWindow = 5 * time.Minute
+	changed, err := EnsureStreams(ctx, js, cfg)
+	if err != nil || !reflect.DeepEqual(changed, []string{"subjects", "max_msgs_per_subject", "duplicate_window"}) {
+		t.Fatalf("loosen: changed = %v, err = %v", changed, err)
+	}
+	info, err := js.StreamInfo(StreamName)
+	if err != nil || len(info.Config.Subjects) != 2 || info.Config.MaxMsgsPerSubject != -1 || info.Config.Duplicates != 5*time.Minute {
+		t.Fatalf("stream config = %+v, %v", info.Config, err)
+	}
+
+	// tighter limits would delete messages, so they need allow_data_loss
+	cfg.MaxAge = time.Hour
+	if _, err := EnsureStreams(ctx, js, cfg); !IsStreamConfigConflict(err) {
+		t.Fatalf("tighten: err = %v", err)
+	}
+	if info, _ := js.StreamInfo(StreamName); info.Config.MaxAge != 0 {
+		t.Fatalf("refused config applied: max_age = %s", info.Config.MaxAge)
+	}
+	cfg.AllowDataLoss = true
+	if changed, err := EnsureStreams(ctx, js, cfg); err != nil || !reflect.DeepEqual(changed, []string{"max_age"}) {
+		t.Fatalf("tighten allowed: changed = %v, err = %v", changed, err)
+	}
+
+	// storage and retention never change in place
+	for _, bad := range []StreamConfig{{Storage: "memory"}, {Retention: "interest"}} {
+		c := cfg
+		if bad.Storage != "" {
+			c.Storage = bad.Storage
+		}
+		if bad.Retention != "" {
+			c.Retention = bad.Retention
+		}
+		if _, err := EnsureStreams(ctx, js, c); !IsStreamConfigConflict(err) {
+			t.Errorf("%+v: err = %v", bad, err)
+		}
+	}
+	c := cfg
+	c.Retention = "workqueue"
+	if _, err := EnsureStreams(ctx, js, c); err == nil || IsStreamConfigConflict(err) {
+		t.Errorf("workqueue retention: err = %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package metrics
+
+import (
+  "sync"
+  "time"
+
+  "github.com/prometheus/client_golang/prometheus"
+)
+
+// alertNamespace prefixes the alerting metrics so rules can select them
+// apart from the service's own instrumentation.
+const alertNamespace = "sim"
+
+// ZoneStatuses are the values sim_zone_status reports, one series each.
+var ZoneStatuses = []string{"OK", "DEGRADED", "DOWN"}
+
+// AlertZone is the alerting view of one zone.
+type AlertZone struct {
+  Zone string
+  Status string // OK|DEGRADED|DOWN
+  SpoolPending int64
+  OpenIncidents map[string]int64 // by severity, unresolved
+}
+
+// AlertState is what the alerting metrics report, read by a background
+// updater rather than at scrape time so a slow database never slows a scrape.
+type AlertState struct {
+  Zones []AlertZone
+  OutboxBacklog int64 // unpublished outbox events
+  OutboxOldestSeconds float64 // age of the oldest unpublished event; 0 when none
+}
+
+// AlertCollector exports the last AlertState it was given. Nothing is
+// exported before the first Set.
+type AlertCollector struct {
+  mu sync.Mutex
+  state *AlertState
+  updated time.Time
+
+  incidents *prometheus.Desc
+  status *prometheus.Desc
+  spool *prometheus.Desc
+  outbox *prometheus.Desc
+  outboxAge *prometheus.Desc
+  updatedAt *prometheus.Desc
+}
+
+func NewAlertCollector() *AlertCollector {
+  return &AlertCollector{
+    incidents: prometheus.NewDesc(alertNamespace+"_open_incidents", "Unresolved incidents per zone and severity.", []string{"zone", "severity"}, nil),
+    status: prometheus.NewDesc(alertNamespace+"_zone_status", "1 for the zone's current status, 0 for the others.", []string{"zone", "status"}, nil),
+    spool: prometheus.NewDesc(alertNamespace+"_spool_pending", "Pending spooled transfers per zone.", []string{"zone"}, nil),
+    outbox: prometheus.NewDesc(alertNamespace+"_outbox_backlog", "Outbox events not yet published.", nil, nil),
+    outboxAge: prometheus.NewDesc(alertNamespace+"_outbox_oldest_pending_seconds", "Age of the oldest unpublished outbox event; 0 when there is none.", nil, nil),
+    updatedAt: prometheus.NewDesc(alertNamespace+"_alert_metrics_updated_timestamp_seconds", "When the alerting metrics were last read from the database.", nil, nil),
+  }
+}
+
+// Set replaces the exported state.
+func (c *AlertCollector) Set(s AlertState, at time.Time) {
+  c.mu.Lock()
+  defer c.mu.Unlock()
+  c.state, c.updated = &s, at
+}
+
+func (c *AlertCollector) Describe(ch chan<- *prometheus.Desc) {
+  ch <- c.incidents
+  ch <- c.status
+  ch <- c.spool
+  ch <- c.outbox
+  ch <- c.outboxAge
+  ch <- c.updatedAt
+}
+
+func (c *AlertCollector) Collect(ch chan<- prometheus.Metric) {
+  c.mu.Lock()
+  s, updated := c.state, c.updated
+  c.mu.Unlock()
+  if s == nil { return }
+  ch <- prometheus.MustNewConstMetric(c.updatedAt, prometheus.GaugeValue, float64(updated.UnixMilli())/1000)
+  ch <- prometheus.MustNewConstMetric(c.outbox, prometheus.GaugeValue, float64(s.OutboxBacklog))
+  ch <- prometheus.MustNewConstMetric(c.outboxAge, prometheus.GaugeValue, s.OutboxOldestSeconds)
+  for _, z := range s.Zones {
+    ch <- prometheus.MustNewConstMetric(c.spool, prometheus.GaugeValue, float64(z.SpoolPending), z.Zone)
+    for _, st := range ZoneStatuses {
+      v := 0.0
+      if st == z.Status { v = 1 }
+      ch <- prometheus.MustNewConstMetric(c.status, prometheus.GaugeValue, v, z.Zone, st)
+    }
+    for sev, n := range z.OpenIncidents {
+      ch <- prometheus.MustNewConstMetric(c.incidents, prometheus.GaugeValue, float64(n), z.Zone, sev)
+    }
+  }
+}
@@ -0,0 +1,190 @@
+// Package metrics defines the sim's Prometheus metrics. They register on the
+// default registry, which /metrics serves alongside the Go runtime collectors.
+package metrics
+
+import (
+  "context"
+  "time"
+
+  "github.com/prometheus/client_golang/prometheus"
+  "github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "timeledger"
+
+// Transfer outcomes (the "outcome" label of the transfer metrics).
+const (
+  OutcomeApplied = "applied"
+  OutcomeSpooled = "spooled"
+  OutcomeRejected = "rejected" // refused by zone/account/partition/rate controls or an idempotency conflict
+  OutcomeError = "error"
+)
+
+// Replay outcomes (the "outcome" label of SpoolReplayed).
+const (
+  ReplayApplied = "applied"
+  ReplayFailed = "failed"
+  ReplaySkipped = "skipped" // still behind a partition
+)
+
+var (
+  Transfers = promauto.NewCounterVec(prometheus.CounterOpts{
+    Namespace: namespace, Name: "transfers_total",
+    Help: "Transfer requests by zone and outcome.",
+  }, []string{"zone", "outcome"})
+
+  TransferDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+    Namespace: namespace, Name: "transfer_duration_seconds",
+    Help: "Time to decide and commit a transfer, including injected latency.",
+    Buckets: latencyBounds,
+  }, []string{"zone", "outcome"})
+
+  SpoolReplayed = promauto.NewCounterVec(prometheus.CounterOpts{
+    Namespace: namespace, Name: "spool_replayed_total",
+    Help: "Spooled transfers processed by replay, by zone and outcome.",
+  }, []string{"zone", "outcome"})
+
+  OutboxPublished = promauto.NewCounter(prometheus.CounterOpts{
+    Namespace: namespace, Name: "outbox_published_total",
+    Help: "Outbox events published to JetStream.",
+  })
+
+  OutboxPublishFailures = promauto.NewCounter(prometheus.CounterOpts{
+    Namespace: namespace, Name: "outbox_publish_failures_total",
+    Help: "Outbox events whose publish or mark-published failed (retried next batch).",
+  })
+
+  OutboxPublishLatency = promauto.NewHistogram(prometheus.HistogramOpts{
+    Namespace: namespace, Name: "outbox_publish_latency_seconds",
+    Help: "Time from an event's outbox insert to its JetStream ack.",
+    Buckets: prometheus.ExponentialBuckets(0.01, 2, 12), // 10ms .. ~20s
+  })
+
+  OutboxDuplicates = promauto.NewCounterVec(prometheus.CounterOpts{
+    Namespace: namespace, Name: "outbox_duplicates_total",
+    Help: "Outbox events JetStream already held, so they were not stored twice, by how they were found: ack (deduplicated on republish) or recovery (found in the stream at startup).",
+  }, []string{"source"})
+
+  EventDecryptFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+    Namespace: namespace, Name: "event_decrypt_failures_total",
+    Help: "JetStream events a consumer could not decrypt (no key for them, or tampered), by consumer.",
+  }, []string{"consumer"})
+
+  OutboxPolls = promauto.NewCounterVec(prometheus.CounterOpts{
+    Namespace: namespace, Name: "outbox_polls_total",
+    Help: "Outbox publisher polls by result: empty, partial, full (more waiting) or error.",
+  }, []string{"result"})
+
+  OutboxBatchLimit = promauto.NewGauge(prometheus.GaugeOpts{
+    Namespace: namespace, Name: "outbox_batch_limit",
+    Help: "Events the outbox publisher asks for in its next poll.",
+  })
+
+  OutboxPollWait = promauto.NewGauge(prometheus.GaugeOpts{
+    Namespace: namespace, Name: "outbox_poll_wait_seconds",
+    Help: "Wait before the outbox publisher's next poll.",
+  })
+)
+
+// ObserveTransfer records one transfer request.
+func ObserveTransfer(zone, outcome string, d time.Duration) {
+  Transfers.WithLabelValues(zone, outcome).Inc()
+  TransferDuration.WithLabelValues(zone, outcome).Observe(d.Seconds())
+  observeWindow(zone, outcome, d)
+}
+
+// ZoneGauge is the current state of one zone, read from the database at
+// scrape time.
+type ZoneGauge struct {
+  Zone string
+  SpoolDepth int64 // PENDING spooled transfers
+  OpenIncidents map[string]int64 // by severity, unresolved
+}
+
+// ZoneCollector exports spool depth and open incidents per zone. Reading them
+// at scrape time keeps the gauges right across restarts and restores, which a
+// counter kept in process would not.
+type ZoneCollector struct {
+  read func(context.Context) ([]ZoneGauge, error)
+  timeout time.Duration
+  spool *prometheus.Desc
+  incidents *prometheus.Desc
+  up *prometheus.Desc
+}
+
+func NewZoneCollector(read func(context.Context) ([]ZoneGauge, error)) *ZoneCollector {
+  return &ZoneCollector{
+    read: read,
+    timeout: 2 * time.Second,
+    spool: prometheus.NewDesc(namespace+"_spool_depth", "Pending spooled transfers per zone.", []string{"zone"}, nil),
+    incidents: prometheus.NewDesc(namespace+"_incidents_open", "Unresolved incidents per zone and severity.", []string{"zone", "severity"}, nil),
+    up: prometheus.NewDesc(namespace+"_zone_gauges_up", "1 if the zone gauges could be read at this scrape.", nil, nil),
+  }
+}
+
+func (c *ZoneCollector) Describe(ch chan<- *prometheus.Desc) {
+  ch <- c.spool
+  ch <- c.incidents
+  ch <- c.up
+}
+
+func (c *ZoneCollector) Collect(ch chan<- prometheus.Metric) {
+  ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+  defer cancel()
+  zones, err := c.read(ctx)
+  if err != nil {
+    ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, 0)
+    return
+  }
+  ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, 1)
+  for _, z := range zones {
+    ch <- prometheus.MustNewConstMetric(c.spool, prometheus.GaugeValue, float64(z.SpoolDepth), z.Zone)
+    for sev, n := range z.OpenIncidents {
+      ch <- prometheus.MustNewConstMetric(c.incidents, prometheus.GaugeValue, float64(n), z.Zone, sev)
+    }
+  }
+}
+
+var (
+  DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+    Namespace: namespace, Name: "db_query_duration_seconds",
+    Help: "SQL statement latency by operation (SELECT, INSERT, ...).",
+    Buckets: prometheus.ExponentialBuckets(0.0005, 2, 14), // 0.5ms .. ~4s
+  }, []string{"operation"})
+
+  DBSlowQueries = promauto.NewCounterVec(prometheus.CounterOpts{
+    Namespace: namespace, Name: "db_slow_queries_total",
+    Help: "Statements slower than DB_SLOW_QUERY_MS, by operation.",
+  }, []string{"operation"})
+
+  DBLongTransactions = promauto.NewCounter(prometheus.CounterOpts{
+    Namespace: namespace, Name: "db_long_transactions_total",
+    Help: "Transactions held open longer than DB_LONG_TX_MS.",
+  })
+
+  Leader = promauto.NewGaugeVec(prometheus.GaugeOpts{
+    Namespace: namespace, Name: "leader",
+    Help: "1 while this replica holds the role's leader lock (outbox publisher, control scheduler).",
+  }, []string{"role"})
+
+  LeaderChanges = promauto.NewCounterVec(prometheus.CounterOpts{
+    Namespace: namespace, Name: "leader_changes_total",
+    Help: "Times this replica gained or lost a role's leadership.",
+  }, []string{"role", "event"}) // event: acquired | lost
+
+  ZoneCacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+    Namespace: namespace, Name: "zone_cache_lookups_total",
+    Help: "Zone status/controls lookups on the transfer path served from the in-process cache (hit) or the database (miss).",
+  }, []string{"result"})
+
+  TxRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+    Namespace: namespace, Name: "tx_retries_total",
+    Help: "Transfer transactions aborted by a serialization failure or deadlock, by isolation level: retried, or returned once the retries ran out.",
+  }, []string{"isolation", "outcome"}) // outcome: retried | exhausted
+
+  TxAttempts = promauto.NewHistogramVec(prometheus.HistogramOpts{
+    Namespace: namespace, Name: "tx_attempts",
+    Help: "Attempts each transfer transaction took, by isolation level.",
+    Buckets: []float64{1, 2, 3, 4, 6, 8, 11},
+  }, []string{"isolation"})
+)
@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestObserveTransfer(t *testing.T) {
+	before := testutil.ToFloat64(Transfers.WithLabelValues("zone-test", OutcomeSpooled))
+	ObserveTransfer("zone-test", OutcomeSpooled, 3*time.Millisecond)
+	if got := testutil.ToFloat64(Transfers.WithLabelValues("zone-test", OutcomeSpooled)); got != before+1 {
+		t.Fatalf("transfers = %v, want %v", got, before+1)
+	}
+}
+
+func TestZoneCollector(t *testing.T) {
+	c := NewZoneCollector(func(context.Context) ([]ZoneGauge, error) {
+		return []ZoneGauge{{Zone: "zone-eu", SpoolDepth: 7, OpenIncidents: map[string]int64{"CRITICAL": 2}}}, nil
+	})
+	want := `
+# HELP timeledger_incidents_open Unresolved incidents per zone and severity.
+# TYPE timeledger_incidents_open gauge
+timeledger_incidents_open{severity="CRITICAL",zone="zone-eu"} 2
+# HELP timeledger_spool_depth Pending spooled transfers per zone.
+# TYPE timeledger_spool_depth gauge
+timeledger_spool_depth{zone="zone-eu"} 7
+# HELP timeledger_zone_gauges_up 1 if the zone gauges could be read at this scrape.
+# TYPE timeledger_zone_gauges_up gauge
+timeledger_zone_gauges_up 1
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want)); err != nil {
+		t.Fatal(err)
+	}
+
+	down := NewZoneCollector(func(context.Context) ([]ZoneGauge, error) { return nil, errors.New("db down") })
+	if err := testutil.CollectAndCompare(down, strings.NewReader(`
+# HELP timeledger_zone_gauges_up 1 if the zone gauges could be read at this scrape.
+# TYPE timeledger_zone_gauges_up gauge
+timeledger_zone_gauges_up 0
+`)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAlertCollector(t *testing.T) {
+	c := NewAlertCollector()
+	if n := testutil.CollectAndCount(c); n != 0 {
+		t.Fatalf("%d metrics before the first Set", n)
+	}
+	c.Set(AlertState{
+		Zones:         []AlertZone{{Zone: "zone-eu", Status: "DOWN", SpoolPending: 4, OpenIncidents: map[string]int64{"CRITICAL": 1}}},
+		OutboxBacklog: 9, OutboxOldestSeconds: 2.5,
+	}, time.Unix(1700000000, 0))
+	want := `
+# HELP sim_open_incidents Unresolved incidents per zone and severity.
+# TYPE sim_open_incidents gauge
+sim_open_incidents{severity="CRITICAL",zone="zone-eu"} 1
+# HELP sim_outbox_backlog Outbox events not yet published.
+# TYPE sim_outbox_backlog gauge
+sim_outbox_backlog 9
+# HELP sim_spool_pending Pending spooled transfers per zone.
+# TYPE sim_spool_pending gauge
+sim_spool_pending{zone="zone-eu"} 4
+# HELP sim_zone_status 1 for the zone's current status, 0 for the others.
+# TYPE sim_zone_status gauge
+sim_zone_status{status="DEGRADED",zone="zone-eu"} 0
+sim_zone_status{status="DOWN",zone="zone-eu"} 1
+sim_zone_status{status="OK",zone="zone-eu"} 0
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want), "sim_open_incidents", "sim_outbox_backlog", "sim_spool_pending", "sim_zone_status"); err != nil {
+		t.Fatal(err)
+	}
+}
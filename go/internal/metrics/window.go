@@ -0,0 +1,114 @@
+package metrics
+
+import (
+  "sort"
+  "sync"
+  "time"
+
+  "github.com/prometheus/client_golang/prometheus"
+)
+
+// Prometheus histograms are cumulative since process start; the ops UI wants
+// "the last five minutes". transferWindows keeps per-zone outcome counts and
+// latency histograms in 10s slots for the last hour, in process.
+
+const (
+  slotWidth = 10 * time.Second
+  slotCount = 360 // one hour
+  // MaxWindow is the longest window WindowStats can answer.
+  MaxWindow = slotWidth * slotCount
+)
+
+// latencyBounds are TransferDuration's buckets (seconds).
+var latencyBounds = prometheus.ExponentialBuckets(0.001, 2, 14) // 1ms .. ~8s
+
+type slot struct {
+  epoch int64 // unix time / slotWidth; stale slots are reset on reuse
+  outcomes map[string]int64
+  latency []int64 // per latencyBounds, plus +Inf
+}
+
+type zoneWindow struct {
+  slots [slotCount]slot
+}
+
+var transferWindows = struct {
+  sync.Mutex
+  zones map[string]*zoneWindow
+}{zones: map[string]*zoneWindow{}}
+
+// now is replaceable in tests.
+var now = time.Now
+
+func observeWindow(zone, outcome string, d time.Duration) {
+  epoch := now().UnixNano() / int64(slotWidth)
+  transferWindows.Lock()
+  defer transferWindows.Unlock()
+  zw := transferWindows.zones[zone]
+  if zw == nil {
+    zw = &zoneWindow{}
+    transferWindows.zones[zone] = zw
+  }
+  s := &zw.slots[epoch%slotCount]
+  if s.epoch != epoch || s.outcomes == nil {
+    *s = slot{epoch: epoch, outcomes: map[string]int64{}, latency: make([]int64, len(latencyBounds)+1)}
+  }
+  s.outcomes[outcome]++
+  s.latency[sort.SearchFloat64s(latencyBounds, d.Seconds())]++
+}
+
+// WindowStats summarizes one zone's transfers seen by this process.
+type WindowStats struct {
+  Outcomes map[string]int64
+  // P50 and P95 are estimated from histogram buckets like Prometheus'
+  // histogram_quantile; zero when Samples is zero.
+  P50, P95 time.Duration
+  Samples int64
+}
+
+// ZoneWindowStats returns the zone's stats over the last window (at most MaxWindow,
+// rounded up to whole 10s slots).
+func ZoneWindowStats(zone string, window time.Duration) WindowStats {
+  if window > MaxWindow { window = MaxWindow }
+  slots := int64((window + slotWidth - 1) / slotWidth)
+  cur := now().UnixNano() / int64(slotWidth)
+  out := WindowStats{Outcomes: map[string]int64{}}
+  lat := make([]int64, len(latencyBounds)+1)
+
+  transferWindows.Lock()
+  if zw := transferWindows.zones[zone]; zw != nil {
+    for e := cur - slots + 1; e <= cur; e++ {
+      s := zw.slots[e%slotCount]
+      if s.epoch != e || s.outcomes == nil { continue }
+      for k, v := range s.outcomes { out.Outcomes[k] += v }
+      for i, v := range s.latency { lat[i] += v }
+    }
+  }
+  transferWindows.Unlock()
+
+  for _, v := range lat { out.Samples += v }
+  out.P50 = quantile(0.5, lat)
+  out.P95 = quantile(0.95, lat)
+  return out
+}
+
+// quantile interpolates linearly within the bucket holding rank q; samples in
+// the +Inf bucket report the highest finite bound.
+func quantile(q float64, counts []int64) time.Duration {
+  var total int64
+  for _, c := range counts { total += c }
+  if total == 0 { return 0 }
+  rank := q * float64(total)
+  var cum int64
+  for i, c := range counts {
+    if float64(cum+c) < rank { cum += c; continue }
+    if i == len(latencyBounds) { return secs(latencyBounds[i-1]) }
+    lo := 0.0
+    if i > 0 { lo = latencyBounds[i-1] }
+    hi := latencyBounds[i]
+    return secs(lo + (hi-lo)*(rank-float64(cum))/float64(c))
+  }
+  return secs(latencyBounds[len(latencyBounds)-1])
+}
+
+func secs(s float64) time.Duration { return time.Duration(s * float64(time.Second)) }
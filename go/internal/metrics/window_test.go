@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestZoneWindowStats(t *testing.T) {
+	clock := time.Unix(1_700_000_000, 0)
+	now = func() time.Time { return clock }
+	t.Cleanup(func() { now = time.Now })
+
+	// outside a 1m window
+	observeWindow("zone-w", OutcomeApplied, 500*time.Millisecond)
+	clock = clock.Add(2 * time.Minute)
+
+	for i := 0; i < 90; i++ {
+		observeWindow("zone-w", OutcomeApplied, 3*time.Millisecond) // (0.002, 0.004]
+	}
+	for i := 0; i < 10; i++ {
+		observeWindow("zone-w", OutcomeRejected, 100*time.Millisecond) // (0.064, 0.128]
+	}
+
+	st := ZoneWindowStats("zone-w", time.Minute)
+	if st.Outcomes[OutcomeApplied] != 90 || st.Outcomes[OutcomeRejected] != 10 || st.Samples != 100 {
+		t.Fatalf("stats = %+v", st)
+	}
+	if st.P50 <= 2*time.Millisecond || st.P50 > 4*time.Millisecond {
+		t.Fatalf("p50 = %s", st.P50)
+	}
+	if st.P95 <= 64*time.Millisecond || st.P95 > 128*time.Millisecond {
+		t.Fatalf("p95 = %s", st.P95)
+	}
+
+	if all := ZoneWindowStats("zone-w", 10*time.Minute); all.Samples != 101 {
+		t.Fatalf("10m samples = %d", all.Samples)
+	}
+	// a slot reused an hour later starts empty
+	clock = clock.Add(MaxWindow)
+	if st := ZoneWindowStats("zone-w", time.Minute); st.Samples != 0 || st.P50 != 0 {
+		t.Fatalf("after an hour = %+v", st)
+	}
+}
@@ -0,0 +1,85 @@
+// Package objstore reads and writes snapshot blobs in S3-compatible storage.
+package objstore
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "io"
+  "net/url"
+  "strings"
+
+  "github.com/minio/minio-go/v7"
+  "github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+var ErrNotConfigured = errors.New("object storage not configured")
+
+type Config struct {
+  Endpoint string `yaml:"endpoint"` // host[:port], e.g. s3.amazonaws.com or minio:9000
+  AccessKeyID string `yaml:"access_key_id"`
+  SecretAccessKey string `yaml:"secret_access_key"`
+  Region string `yaml:"region"`
+  UseSSL bool `yaml:"use_ssl"`
+  Bucket string `yaml:"bucket"` // default bucket when a location omits one
+}
+
+type Store struct {
+  c *minio.Client
+  bucket string
+}
+
+// New returns nil (no error) when no endpoint is configured.
+func New(cfg Config) (*Store, error) {
+  if cfg.Endpoint == "" { return nil, nil }
+  c, err := minio.New(cfg.Endpoint, &minio.Options{
+    Creds: credentials.NewStaticV4(cfg.AccessKeyID, cfg.SecretAccessKey, ""),
+    Secure: cfg.UseSSL,
+    Region: cfg.Region,
+  })
+  if err != nil { return nil, err }
+  return &Store{c: c, bucket: cfg.Bucket}, nil
+}
+
+// Location is a parsed s3://bucket/key URL.
+type Location struct {
+  Bucket string
+  Key string
+}
+
+func (l Location) String() string { return "s3://" + l.Bucket + "/" + l.Key }
+
+// ParseLocation parses s3://bucket/key. Missing bucket falls back to defBucket;
+// a missing key (or one ending in "/") gets defKey appended.
+func ParseLocation(raw, defBucket, defKey string) (Location, error) {
+  u, err := url.Parse(raw)
+  if err != nil || u.Scheme != "s3" { return Location{}, fmt.Errorf("invalid s3 location %q", raw) }
+  loc := Location{Bucket: u.Host, Key: strings.TrimPrefix(u.Path, "/")}
+  if loc.Bucket == "" { loc.Bucket = defBucket }
+  if loc.Bucket == "" { return Location{}, fmt.Errorf("s3 location %q has no bucket", raw) }
+  if loc.Key == "" || strings.HasSuffix(loc.Key, "/") { loc.Key += defKey }
+  if loc.Key == "" { return Location{}, fmt.Errorf("s3 location %q has no key", raw) }
+  return loc, nil
+}
+
+func (s *Store) Parse(raw, defKey string) (Location, error) {
+  if s == nil { return Location{}, ErrNotConfigured }
+  return ParseLocation(raw, s.bucket, defKey)
+}
+
+// Put uploads r; size may be -1 for unknown length (multipart upload).
+func (s *Store) Put(ctx context.Context, loc Location, r io.Reader, size int64, contentType string) (int64, error) {
+  if s == nil { return 0, ErrNotConfigured }
+  info, err := s.c.PutObject(ctx, loc.Bucket, loc.Key, r, size, minio.PutObjectOptions{ContentType: contentType})
+  if err != nil { return 0, err }
+  return info.Size, nil
+}
+
+func (s *Store) Get(ctx context.Context, loc Location) (io.ReadCloser, error) {
+  if s == nil { return nil, ErrNotConfigured }
+  obj, err := s.c.GetObject(ctx, loc.Bucket, loc.Key, minio.GetObjectOptions{})
+  if err != nil { return nil, err }
+  // GetObject is lazy; surface missing objects here rather than mid-decode.
+  if _, err := obj.Stat(); err != nil { _ = obj.Close(); return nil, err }
+  return obj, nil
+}
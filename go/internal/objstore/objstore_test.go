@@ -0,0 +1,33 @@
+package objstore
+
+import "testing"
+
+func TestParseLocation(t *testing.T) {
+	cases := []struct {
+		raw, defBucket, want string
+		ok                   bool
+	}{
+		{"s3://snaps/drill/pre.json", "", "s3://snaps/drill/pre.json", true},
+		{"s3://snaps/", "", "s3://snaps/snap.json", true},
+		{"s3://snaps", "", "s3://snaps/snap.json", true},
+		{"s3:///drill/", "default", "s3://default/drill/snap.json", true},
+		{"s3:///x.json", "", "", false},
+		{"https://snaps/x.json", "", "", false},
+	}
+	for _, c := range cases {
+		loc, err := ParseLocation(c.raw, c.defBucket, "snap.json")
+		if (err == nil) != c.ok {
+			t.Fatalf("%s: err=%v", c.raw, err)
+		}
+		if c.ok && loc.String() != c.want {
+			t.Fatalf("%s: got %s want %s", c.raw, loc, c.want)
+		}
+	}
+}
+
+func TestNilStoreNotConfigured(t *testing.T) {
+	var s *Store
+	if _, err := s.Parse("s3://b/k", "x"); err != ErrNotConfigured {
+		t.Fatalf("expected ErrNotConfigured, got %v", err)
+	}
+}
@@ -0,0 +1,294 @@
+// Package simtest runs the whole sim-go app in-process against real Postgres
+// and NATS (testcontainers) for end-to-end tests:
+//
+//   h := simtest.Start(t)
+//   h.SetZoneStatus("zone-eu", "DOWN")
+//   res := h.Transfer(simtest.Transfer{Zone: "zone-eu", From: "a", To: "b", Amount: 10})
+//   h.AssertInvariants()
+//
+// One Postgres container per test binary holds a migrated template database;
+// each Start gets a fresh copy of it and its own NATS container, so tests do
+// not share rows or events. TEST_DATABASE_URL / TEST_NATS_URL point the
+// harness at existing servers instead (the database must allow CREATE
+// DATABASE). Tests skip under -short or when Docker is unavailable.
+package simtest
+
+import (
+  "bytes"
+  "context"
+  "encoding/json"
+  "fmt"
+  "io"
+  "log/slog"
+  "net/http"
+  "net/http/httptest"
+  "net/url"
+  "os"
+  "sync"
+  "sync/atomic"
+  "testing"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+  "github.com/jackc/pgx/v5/pgxpool"
+  "github.com/testcontainers/testcontainers-go"
+  tcnats "github.com/testcontainers/testcontainers-go/modules/nats"
+  tcpostgres "github.com/testcontainers/testcontainers-go/modules/postgres"
+
+  "time-ledger-sim/go/internal/app"
+  "time-ledger-sim/go/internal/store"
+  "time-ledger-sim/go/internal/store/storetest"
+)
+
+// Images match infra/docker-compose.yml.
+const (
+  PostgresImage = "postgres:16.13"
+  NATSImage = "nats:2.12.5"
+  AdminKey = "simtest-admin-key"
+  templateDB = "simtest_template"
+)
+
+// Harness is a running app with its HTTP server and a direct DB pool for
+// assertions.
+type Harness struct {
+  t testing.TB
+  App *app.App
+  Server *httptest.Server
+  DB *pgxpool.Pool
+}
+
+var (
+  pgOnce sync.Once
+  pgURL string // server URL of the shared Postgres, on the postgres database
+  pgErr error
+  dbSeq atomic.Int64
+)
+
+// Start runs a fresh app for t and stops it when t finishes.
+func Start(t testing.TB) *Harness {
+  t.Helper()
+  if testing.Short() { t.Skip("integration test skipped in -short mode") }
+  ctx := context.Background()
+
+  dbURL := database(t)
+  natsURL := natsServer(t)
+
+  cfg := app.DefaultConfig()
+  cfg.LogLevel = slog.LevelWarn
+  cfg.Store.URL = dbURL
+  cfg.NatsURL = natsURL
+  cfg.GRPCPort = ""
+  cfg.AdminKey = AdminKey
+  cfg.CorsAllowOrigins = "*"
+  cfg.ShutdownTimeout = 10 * time.Second
+  a, err := app.New(ctx, cfg)
+  if err != nil { t.Fatalf("simtest: start app: %v", err) }
+  srv := httptest.NewServer(a.Router())
+  h := &Harness{t: t, App: a, Server: srv}
+  db, err := pgxpool.New(ctx, dbURL)
+  if err != nil { t.Fatal(err) }
+  t.Cleanup(func() {
+    srv.Close()
+    sctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+    defer cancel()
+    if err := a.Shutdown(sctx); err != nil { t.Logf("simtest: shutdown: %v", err) }
+    a.Close()
+    db.Close()
+  })
+  h.DB = db
+  // writes are held until JetStream is set up
+  h.Eventually(30*time.Second, a.MessagingReady, "messaging ready")
+  return h
+}
+
+// database returns the URL of a new database cloned from the migrated template.
+func database(t testing.TB) string {
+  t.Helper()
+  pgOnce.Do(func() { pgURL, pgErr = startPostgres() })
+  if pgErr != nil { t.Skipf("simtest: postgres unavailable (set TEST_DATABASE_URL or start Docker): %v", pgErr) }
+  ctx := context.Background()
+  conn, err := pgx.Connect(ctx, pgURL)
+  if err != nil { t.Fatal(err) }
+  defer conn.Close(ctx)
+  name := fmt.Sprintf("simtest_%d_%d", os.Getpid(), dbSeq.Add(1))
+  if _, err := conn.Exec(ctx, `CREATE DATABASE `+name+` TEMPLATE `+templateDB); err != nil { t.Fatal(err) }
+  t.Cleanup(func() {
+    conn, err := pgx.Connect(context.Background(), pgURL)
+    if err != nil { return }
+    defer conn.Close(context.Background())
+    _, _ = conn.Exec(context.Background(), `DROP DATABASE IF EXISTS `+name+` WITH (FORCE)`)
+  })
+  return withDatabase(pgURL, name)
+}
+
+// startPostgres starts (or connects to) the shared server and migrates the
+// template database. The container lives until the test binary exits.
+func startPostgres() (string, error) {
+  ctx := context.Background()
+  server := os.Getenv("TEST_DATABASE_URL")
+  if server == "" {
+    c, err := tcpostgres.Run(ctx, PostgresImage,
+      tcpostgres.WithDatabase("postgres"),
+      tcpostgres.WithUsername("postgres"),
+      tcpostgres.WithPassword("postgres"),
+      tcpostgres.BasicWaitStrategies())
+    if err != nil { return "", err }
+    server, err = c.ConnectionString(ctx, "sslmode=disable")
+    if err != nil { return "", err }
+  }
+  conn, err := pgx.Connect(ctx, server)
+  if err != nil { return "", err }
+  defer conn.Close(ctx)
+  _, _ = conn.Exec(ctx, `DROP DATABASE IF EXISTS `+templateDB+` WITH (FORCE)`)
+  if _, err := conn.Exec(ctx, `CREATE DATABASE `+templateDB); err != nil { return "", err }
+
+  pool, err := pgxpool.New(ctx, withDatabase(server, templateDB))
+  if err != nil { return "", err }
+  defer pool.Close()
+  if _, err := store.Migrate(ctx, pool, storetest.MigrationsDir()); err != nil { return "", err }
+  return server, nil
+}
+
+func withDatabase(server, name string) string {
+  u, err := url.Parse(server)
+  if err != nil { return server }
+  u.Path = "/" + name
+  return u.String()
+}
+
+// natsServer starts a JetStream container for one harness.
+func natsServer(t testing.TB) string {
+  t.Helper()
+  if u := os.Getenv("TEST_NATS_URL"); u != "" { return u }
+  ctx := context.Background()
+  c, err := tcnats.Run(ctx, NATSImage)
+  if c != nil { t.Cleanup(func() { _ = testcontainers.TerminateContainer(c) }) }
+  if err != nil { t.Skipf("simtest: nats unavailable (set TEST_NATS_URL or start Docker): %v", err) }
+  u, err := c.ConnectionString(ctx)
+  if err != nil { t.Fatal(err) }
+  return u
+}
+
+// Response is an API response with its decoded JSON body.
+type Response struct {
+  Status int
+  Body map[string]any
+  Raw []byte
+}
+
+// Do sends body as JSON with the admin key and decodes the JSON reply.
+func (h *Harness) Do(method, path string, body any) *Response {
+  h.t.Helper()
+  var rd io.Reader
+  if body != nil {
+    b, err := json.Marshal(body)
+    if err != nil { h.t.Fatal(err) }
+    rd = bytes.NewReader(b)
+  }
+  req, err := http.NewRequest(method, h.Server.URL+path, rd)
+  if err != nil { h.t.Fatal(err) }
+  req.Header.Set("Content-Type", "application/json")
+  req.Header.Set("X-Admin-Key", AdminKey)
+  resp, err := h.Server.Client().Do(req)
+  if err != nil { h.t.Fatal(err) }
+  defer resp.Body.Close()
+  out := &Response{Status: resp.StatusCode}
+  out.Raw, _ = io.ReadAll(resp.Body)
+  _ = json.Unmarshal(out.Raw, &out.Body)
+  return out
+}
+
+// must fails the test unless the response has one of the statuses.
+func (h *Harness) must(r *Response, statuses ...int) *Response {
+  h.t.Helper()
+  for _, s := range statuses {
+    if r.Status == s { return r }
+  }
+  h.t.Fatalf("simtest: got %d, want %v: %s", r.Status, statuses, r.Raw)
+  return nil
+}
+
+// Transfer is a transfer request; RequestID defaults to a unique value.
+type Transfer struct {
+  RequestID string
+  Zone string
+  From string
+  To string
+  Amount int64
+}
+
+var reqSeq atomic.Int64
+
+// Transfer posts a transfer and returns the response whatever its status.
+func (h *Harness) Transfer(tr Transfer) *Response {
+  h.t.Helper()
+  if tr.RequestID == "" { tr.RequestID = fmt.Sprintf("simtest-%d", reqSeq.Add(1)) }
+  return h.Do("POST", "/v1/transfers", map[string]any{
+    "request_id": tr.RequestID, "zone_id": tr.Zone, "from_account": tr.From, "to_account": tr.To, "amount_units": tr.Amount,
+  })
+}
+
+// SetZoneStatus sets OK, DEGRADED or DOWN.
+func (h *Harness) SetZoneStatus(zone, status string) {
+  h.t.Helper()
+  h.must(h.Do("POST", "/v1/zones/"+zone+"/status", map[string]any{"status": status, "actor": "simtest"}), 200)
+}
+
+// SetZoneControls replaces a zone's controls; fields are SetZoneControlsRequest's.
+func (h *Harness) SetZoneControls(zone string, controls map[string]any) {
+  h.t.Helper()
+  body := map[string]any{"cross_zone_throttle": 100, "actor": "simtest"}
+  for k, v := range controls { body[k] = v }
+  h.must(h.Do("POST", "/v1/zones/"+zone+"/controls", body), 200)
+}
+
+// ReplaySpool replays a zone's spool and returns the result body.
+func (h *Harness) ReplaySpool(zone string) map[string]any {
+  h.t.Helper()
+  return h.must(h.Do("POST", "/v1/zones/"+zone+"/spool/replay", map[string]any{"actor": "simtest"}), 200).Body
+}
+
+// Eventually polls cond until it holds or timeout passes.
+func (h *Harness) Eventually(timeout time.Duration, cond func() bool, msg string) {
+  h.t.Helper()
+  deadline := time.Now().Add(timeout)
+  for !cond() {
+    if time.Now().After(deadline) { h.t.Fatalf("simtest: timed out: %s", msg) }
+    time.Sleep(50 * time.Millisecond)
+  }
+}
+
+// Count runs a COUNT-style query.
+func (h *Harness) Count(sql string, args ...any) int64 {
+  h.t.Helper()
+  var n int64
+  if err := h.DB.QueryRow(context.Background(), sql, args...).Scan(&n); err != nil { h.t.Fatal(err) }
+  return n
+}
+
+// AssertInvariants checks the ledger's double-entry invariants: every
+// transaction has one debit and one credit of its amount, balances equal the
+// sum of postings per account and sum to zero, and every transaction has a
+// TRANSFER_POSTED outbox event and a link in its zone's hash chain.
+func (h *Harness) AssertInvariants() {
+  h.t.Helper()
+  checks := []struct{ name, sql string }{
+    {"unbalanced transactions", `
+      SELECT COUNT(*) FROM transactions t
+      WHERE (SELECT COUNT(*) FROM postings p WHERE p.txn_id=t.id AND p.direction='DEBIT' AND p.amount_units=t.amount_units) <> 1
+         OR (SELECT COUNT(*) FROM postings p WHERE p.txn_id=t.id AND p.direction='CREDIT' AND p.amount_units=t.amount_units) <> 1`},
+    {"balances not matching postings", `
+      SELECT COUNT(*) FROM balances b
+      WHERE b.balance_units <> COALESCE((SELECT SUM(CASE p.direction WHEN 'CREDIT' THEN p.amount_units ELSE -p.amount_units END)
+        FROM postings p WHERE p.account_id=b.account_id), 0)`},
+    {"non-zero balance total", `SELECT COALESCE(ABS(SUM(balance_units)),0) FROM balances`},
+    {"transactions without outbox event", `
+      SELECT COUNT(*) FROM transactions t
+      WHERE NOT EXISTS (SELECT 1 FROM outbox_events o WHERE o.event_type='TRANSFER_POSTED' AND o.aggregate_id=t.id::text)`},
+    {"transactions outside the hash chain", `
+      SELECT COUNT(*) FROM transactions t WHERE NOT EXISTS (SELECT 1 FROM ledger_chain c WHERE c.txn_id=t.id)`},
+  }
+  for _, c := range checks {
+    if n := h.Count(c.sql); n != 0 { h.t.Errorf("invariant violated: %s (%d)", c.name, n) }
+  }
+}
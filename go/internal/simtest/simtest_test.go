@@ -0,0 +1,37 @@
+package simtest_test
+
+import (
+	"testing"
+	"time"
+
+	"time-ledger-sim/go/internal/simtest"
+)
+
+func TestOutageSpoolReplay(t *testing.T) {
+	h := simtest.Start(t)
+
+	if r := h.Transfer(simtest.Transfer{Zone: "zone-eu", From: "alice", To: "bob", Amount: 30}); r.Status != 200 {
+		t.Fatalf("transfer = %d %s", r.Status, r.Raw)
+	}
+
+	h.SetZoneStatus("zone-eu", "DOWN")
+	h.SetZoneControls("zone-eu", map[string]any{"spool_enabled": true})
+	if r := h.Transfer(simtest.Transfer{Zone: "zone-eu", From: "alice", To: "bob", Amount: 5000}); r.Status != 202 {
+		t.Fatalf("spooled transfer = %d %s, want 202", r.Status, r.Raw)
+	}
+	h.AssertInvariants()
+
+	h.SetZoneStatus("zone-eu", "OK")
+	if out := h.ReplaySpool("zone-eu"); out["applied"] != float64(1) {
+		t.Fatalf("replay = %v", out)
+	}
+	if n := h.Count(`SELECT COUNT(*) FROM transactions`); n != 2 {
+		t.Fatalf("transactions = %d, want 2", n)
+	}
+	h.AssertInvariants()
+
+	// the large replayed transfer reaches the fraud consumer through the outbox
+	h.Eventually(10*time.Second, func() bool {
+		return h.Count(`SELECT COUNT(*) FROM incidents WHERE title='Large time transfer'`) == 1
+	}, "large transfer incident")
+}
@@ -0,0 +1,65 @@
+package store
+
+import (
+  "context"
+  "fmt"
+  "os"
+  "path/filepath"
+  "regexp"
+  "sort"
+
+  "github.com/jackc/pgx/v5"
+  "github.com/jackc/pgx/v5/pgxpool"
+)
+
+// migrationFile matches db/migrations names: a numeric version, then a name.
+var migrationFile = regexp.MustCompile(`^(\d+)_[a-z0-9_]+\.sql$`)
+
+// Migrate applies the migrations in dir that are not yet recorded in
+// sim_schema_migrations, in version order, each in its own transaction. It
+// returns the versions it applied. Flyway owns the schema of external
+// databases; this runner exists for the embedded store and tests.
+func Migrate(ctx context.Context, pool *pgxpool.Pool, dir string) ([]string, error) {
+  files, err := migrationFiles(dir)
+  if err != nil { return nil, err }
+  if _, err := pool.Exec(ctx, `CREATE TABLE IF NOT EXISTS sim_schema_migrations (
+    version TEXT PRIMARY KEY,
+    applied_at TIMESTAMPTZ NOT NULL DEFAULT now())`); err != nil { return nil, err }
+
+  var applied []string
+  for _, name := range files {
+    version := migrationFile.FindStringSubmatch(name)[1]
+    var done bool
+    if err := pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM sim_schema_migrations WHERE version=$1)`, version).Scan(&done); err != nil { return applied, err }
+    if done { continue }
+    sql, err := os.ReadFile(filepath.Join(dir, name))
+    if err != nil { return applied, err }
+    err = pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+      if _, err := tx.Exec(ctx, string(sql)); err != nil { return err }
+      _, err := tx.Exec(ctx, `INSERT INTO sim_schema_migrations(version) VALUES ($1)`, version)
+      return err
+    })
+    if err != nil { return applied, fmt.Errorf("migration %s: %w", name, err) }
+    applied = append(applied, version)
+  }
+  return applied, nil
+}
+
+// migrationFiles lists dir's migrations sorted by version.
+func migrationFiles(dir string) ([]string, error) {
+  entries, err := os.ReadDir(dir)
+  if err != nil { return nil, fmt.Errorf("migrations: %w", err) }
+  var files []string
+  for _, e := range entries {
+    if !e.IsDir() && migrationFile.MatchString(e.Name()) { files = append(files, e.Name()) }
+  }
+  if len(files) == 0 { return nil, fmt.Errorf("migrations: none in %s", dir) }
+  sort.Slice(files, func(i, j int) bool { return version(files[i]) < version(files[j]) })
+  return files, nil
+}
+
+func version(name string) int {
+  var n int
+  fmt.Sscanf(migrationFile.FindStringSubmatch(name)[1], "%d", &n)
+  return n
+}
@@ -0,0 +1,46 @@
+package store_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"time-ledger-sim/go/internal/store"
+	"time-ledger-sim/go/internal/store/storetest"
+)
+
+func TestOpenRequiresURL(t *testing.T) {
+	if _, err := store.Open(context.Background(), store.Config{}, nil); err == nil {
+		t.Fatal("want error for empty DATABASE_URL")
+	}
+}
+
+func TestMigrationsDirHasFiles(t *testing.T) {
+	entries, err := os.ReadDir(storetest.MigrationsDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) == 0 || filepath.Ext(entries[0].Name()) != ".sql" {
+		t.Fatalf("unexpected migrations dir contents: %v", entries)
+	}
+}
+
+func TestMigrateAppliesOnce(t *testing.T) {
+	pool := storetest.Open(t)
+	ctx := context.Background()
+	applied, err := store.Migrate(ctx, pool, storetest.MigrationsDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 0 {
+		t.Fatalf("re-run applied %v, want nothing", applied)
+	}
+	var zones int
+	if err := pool.QueryRow(ctx, `SELECT count(*) FROM zones`).Scan(&zones); err != nil {
+		t.Fatal(err)
+	}
+	if zones == 0 {
+		t.Fatal("want seeded zones after migrating")
+	}
+}
@@ -0,0 +1,75 @@
+// Package store opens the Postgres database the sim runs on. Production points
+// DATABASE_URL at an external server whose schema Flyway manages; the embedded
+// store (DATABASE_URL=embedded) runs a private Postgres in-process and applies
+// db/migrations itself, so sim-go and its tests run without docker-compose.
+package store
+
+import (
+  "context"
+  "errors"
+  "fmt"
+
+  "github.com/jackc/pgx/v5"
+  "github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Embedded is the DATABASE_URL value that selects the embedded store.
+const Embedded = "embedded"
+
+// Store is an open database. Callers share Pool; Close releases the pool and
+// whatever the store started.
+type Store interface {
+  Pool() *pgxpool.Pool
+  // ReadPool is the read replica for queries, or Pool when none is configured.
+  ReadPool() *pgxpool.Pool
+  Close()
+}
+
+// Config selects and configures a store.
+type Config struct {
+  URL string `yaml:"url"` // DATABASE_URL; Embedded starts the embedded store
+  ReadURL string `yaml:"read_url"` // DATABASE_READ_URL, a read-only replica; "" = read from URL
+  DataDir string `yaml:"data_dir"` // EMBEDDED_PG_DIR; "" = temporary, removed on Close
+  Port uint32 `yaml:"port"` // EMBEDDED_PG_PORT; 0 = any free port
+  Migrations string `yaml:"migrations"` // MIGRATIONS_DIR, applied by the embedded store
+  MultiTenant bool `yaml:"multi_tenant"` // MULTI_TENANT; queries made with WithTenant see only that tenant's rows
+}
+
+// Open connects to cfg.URL, or starts the embedded store. tracer may be nil.
+func Open(ctx context.Context, cfg Config, tracer pgx.QueryTracer) (Store, error) {
+  switch cfg.URL {
+  case "":
+    return nil, errors.New("DATABASE_URL required")
+  case Embedded:
+    return openEmbedded(ctx, cfg, tracer)
+  }
+  pool, err := connect(ctx, cfg, cfg.URL, tracer)
+  if err != nil { return nil, err }
+  read := pool
+  if cfg.ReadURL != "" {
+    read, err = connect(ctx, cfg, cfg.ReadURL, tracer)
+    if err != nil { pool.Close(); return nil, fmt.Errorf("read replica: %w", err) }
+  }
+  return postgres{pool, read}, nil
+}
+
+func connect(ctx context.Context, cfg Config, url string, tracer pgx.QueryTracer) (*pgxpool.Pool, error) {
+  poolCfg, err := pgxpool.ParseConfig(url)
+  if err != nil { return nil, err }
+  if tracer != nil { poolCfg.ConnConfig.Tracer = tracer }
+  if cfg.MultiTenant { new(tenantConns).install(poolCfg) }
+  pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
+  if err != nil { return nil, err }
+  if err := pool.Ping(ctx); err != nil { pool.Close(); return nil, err }
+  return pool, nil
+}
+
+// postgres is an external server; its schema is managed outside the process.
+type postgres struct{ pool, read *pgxpool.Pool }
+
+func (s postgres) Pool() *pgxpool.Pool { return s.pool }
+func (s postgres) ReadPool() *pgxpool.Pool { return s.read }
+func (s postgres) Close() {
+  if s.read != s.pool { s.read.Close() }
+  s.pool.Close()
+}
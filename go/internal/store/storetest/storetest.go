@@ -0,0 +1,47 @@
+// Package storetest gives tests a migrated database without docker-compose:
+// TEST_DATABASE_URL when set (a throwaway database; migrations are applied to
+// it), otherwise an embedded Postgres per call. Tests skip under -short or when
+// the embedded server cannot start (no network for the first download, root).
+package storetest
+
+import (
+  "context"
+  "os"
+  "path/filepath"
+  "runtime"
+  "testing"
+
+  "github.com/jackc/pgx/v5/pgxpool"
+
+  "time-ledger-sim/go/internal/store"
+)
+
+// MigrationsDir is db/migrations, located from this source file.
+func MigrationsDir() string {
+  _, file, _, _ := runtime.Caller(0)
+  return filepath.Join(filepath.Dir(file), "..", "..", "..", "..", "db", "migrations")
+}
+
+// Open returns a pool on a migrated database, closed when t finishes.
+func Open(t testing.TB) *pgxpool.Pool { return open(t, false) }
+
+// OpenMultiTenant is Open with store.Config.MultiTenant: queries made with a
+// store.WithTenant context see only that tenant's rows.
+func OpenMultiTenant(t testing.TB) *pgxpool.Pool { return open(t, true) }
+
+func open(t testing.TB, multiTenant bool) *pgxpool.Pool {
+  t.Helper()
+  if testing.Short() { t.Skip("database test skipped in -short mode") }
+  ctx := context.Background()
+  if url := os.Getenv("TEST_DATABASE_URL"); url != "" {
+    st, err := store.Open(ctx, store.Config{URL: url, MultiTenant: multiTenant}, nil)
+    if err != nil { t.Fatalf("TEST_DATABASE_URL: %v", err) }
+    t.Cleanup(st.Close)
+    if _, err := store.Migrate(ctx, st.Pool(), MigrationsDir()); err != nil { t.Fatal(err) }
+    return st.Pool()
+  }
+  st, err := store.Open(ctx, store.Config{URL: store.Embedded, Migrations: MigrationsDir(), MultiTenant: multiTenant}, nil)
+  if err != nil { t.Skipf("embedded postgres unavailable (set TEST_DATABASE_URL): %v", err) }
+  t.Cleanup(st.Close)
+  return st.Pool()
+}
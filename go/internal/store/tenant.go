@@ -0,0 +1,59 @@
+package store
+
+import (
+  "context"
+  "sync"
+
+  "github.com/jackc/pgx/v5"
+  "github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DefaultTenant owns every row written without a tenant, and the requests
+// that carry no tenant key when tenancy is on.
+const DefaultTenant = "default"
+
+// tenantRole is the role a tenant's queries run as: it does not own the
+// tables, so their row-level security policies apply to it (migration 0047).
+const tenantRole = "sim_tenant"
+
+type tenantKey struct{}
+
+// WithTenant scopes the queries made with ctx to a tenant's rows, on a store
+// opened with MultiTenant. Elsewhere it has no effect.
+func WithTenant(ctx context.Context, id string) context.Context {
+  return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// Tenant is the tenant ctx is scoped to, if any.
+func Tenant(ctx context.Context) (string, bool) {
+  id, ok := ctx.Value(tenantKey{}).(string)
+  return id, ok && id != ""
+}
+
+// Unscoped drops ctx's tenant, for work that spans tenants.
+func Unscoped(ctx context.Context) context.Context { return WithTenant(ctx, "") }
+
+// tenantConns sets up each connection as it is acquired for the tenant in the
+// acquiring context: a tenant's queries run as tenantRole with app.tenant
+// set, everything else as the pool's own role with app.tenant '*', so rows
+// written there are filed under their parent's tenant. It remembers each
+// connection's setting to skip the round trip when it is unchanged.
+type tenantConns struct{ set sync.Map } // *pgx.Conn -> app.tenant
+
+func (t *tenantConns) install(cfg *pgxpool.Config) {
+  cfg.PrepareConn = t.prepare
+  cfg.BeforeClose = func(c *pgx.Conn) { t.set.Delete(c) }
+}
+
+func (t *tenantConns) prepare(ctx context.Context, c *pgx.Conn) (bool, error) {
+  id, role := "*", "none"
+  if tid, ok := Tenant(ctx); ok { id, role = tid, tenantRole }
+  if cur, ok := t.set.Load(c); ok && cur == id { return true, nil }
+  if _, err := c.Exec(ctx, `SELECT set_config('role', $1, false), set_config('app.tenant', $2, false)`, role, id); err != nil {
+    // the connection's state is unknown now
+    t.set.Delete(c)
+    return false, err
+  }
+  t.set.Store(c, id)
+  return true, nil
+}
@@ -0,0 +1,38 @@
+package tracing
+
+import (
+  "context"
+  "strings"
+
+  "github.com/jackc/pgx/v5"
+  "go.opentelemetry.io/otel/attribute"
+  "go.opentelemetry.io/otel/trace"
+)
+
+// QueryTracer is a pgx.QueryTracer that records a client span per statement.
+// Statements outside a trace (background polling) get no span, so idle loops
+// do not flood the exporter.
+type QueryTracer struct{}
+
+const maxStatementAttr = 500
+
+func (QueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+  if !trace.SpanFromContext(ctx).SpanContext().IsValid() { return ctx }
+  sql := strings.TrimSpace(data.SQL)
+  op := "QUERY"
+  if f := strings.Fields(sql); len(f) > 0 { op = strings.ToUpper(f[0]) }
+  if len(sql) > maxStatementAttr { sql = sql[:maxStatementAttr] }
+  ctx, _ = Start(ctx, "db "+op, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+    attribute.String("db.system", "postgresql"),
+    attribute.String("db.operation.name", op),
+    attribute.String("db.query.text", sql),
+  ))
+  return ctx
+}
+
+func (QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+  span := trace.SpanFromContext(ctx)
+  if !span.IsRecording() { return }
+  span.SetAttributes(attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+  End(span, data.Err)
+}
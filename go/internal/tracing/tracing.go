@@ -0,0 +1,78 @@
+// Package tracing holds the sim's OpenTelemetry tracer and carries trace
+// context where OTel has no transport of its own: through outbox rows into
+// NATS headers, so one trace covers HTTP -> DB -> JetStream -> consumer.
+package tracing
+
+import (
+  "context"
+  "encoding/json"
+
+  "go.opentelemetry.io/otel"
+  "go.opentelemetry.io/otel/codes"
+  "go.opentelemetry.io/otel/propagation"
+  "go.opentelemetry.io/otel/trace"
+)
+
+const instrumentation = "time-ledger-sim/go"
+
+// Propagator is W3C trace context plus baggage; app installs it globally.
+var Propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// Tracer returns the sim's tracer from the global provider.
+func Tracer() trace.Tracer { return otel.Tracer(instrumentation) }
+
+// Start starts a span with the sim's tracer.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+  return Tracer().Start(ctx, name, opts...)
+}
+
+// End marks the span failed when err is non-nil and ends it.
+func End(span trace.Span, err error) {
+  if err != nil {
+    span.RecordError(err)
+    span.SetStatus(codes.Error, err.Error())
+  }
+  span.End()
+}
+
+// TraceID is the id of ctx's trace, "" when ctx carries none. It is stored
+// with audit entries and incidents so they lead back to the trace.
+func TraceID(ctx context.Context) string {
+  sc := trace.SpanContextFromContext(ctx)
+  if !sc.HasTraceID() { return "" }
+  return sc.TraceID().String()
+}
+
+// Carrier serializes ctx's trace context for storage (outbox_events.trace_context).
+// It returns "" when ctx carries no sampled span.
+func Carrier(ctx context.Context) string {
+  c := propagation.MapCarrier{}
+  Propagator.Inject(ctx, c)
+  if len(c) == 0 { return "" }
+  b, _ := json.Marshal(c)
+  return string(b)
+}
+
+// FromCarrier restores trace context saved by Carrier.
+func FromCarrier(ctx context.Context, raw []byte) context.Context {
+  if len(raw) == 0 { return ctx }
+  c := propagation.MapCarrier{}
+  if json.Unmarshal(raw, &c) != nil { return ctx }
+  return Propagator.Extract(ctx, c)
+}
+
+// HeaderCarrier adapts NATS (or HTTP) headers for inject/extract.
+type HeaderCarrier map[string][]string
+
+func (h HeaderCarrier) Get(key string) string {
+  if v := h[key]; len(v) > 0 { return v[0] }
+  return ""
+}
+
+func (h HeaderCarrier) Set(key, value string) { h[key] = []string{value} }
+
+func (h HeaderCarrier) Keys() []string {
+  keys := make([]string, 0, len(h))
+  for k := range h { keys = append(keys, k) }
+  return keys
+}
@@ -0,0 +1,87 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func withRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	rec := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return rec
+}
+
+func TestTraceCrossesOutboxAndNATS(t *testing.T) {
+	rec := withRecorder(t)
+	ctx, root := Start(context.Background(), "POST /v1/transfers")
+
+	// request -> outbox row
+	stored := Carrier(ctx)
+	if stored == "" {
+		t.Fatal("no carrier for a recording span")
+	}
+	root.End()
+
+	// outbox row -> publisher span -> NATS headers
+	pctx, pub := Start(FromCarrier(context.Background(), []byte(stored)), "outbox publish")
+	headers := HeaderCarrier{}
+	Propagator.Inject(pctx, headers)
+	pub.End()
+
+	// NATS headers -> consumer span
+	_, consume := Start(Propagator.Extract(context.Background(), headers), "fraud-v1 process")
+	consume.End()
+
+	spans := rec.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans", len(spans))
+	}
+	traceID := root.SpanContext().TraceID()
+	for _, s := range spans {
+		if s.SpanContext().TraceID() != traceID {
+			t.Fatalf("span %q left the trace", s.Name())
+		}
+	}
+	if spans[2].Parent().SpanID() != spans[1].SpanContext().SpanID() {
+		t.Fatal("consumer span is not a child of the publish span")
+	}
+}
+
+func TestCarrierWithoutSpan(t *testing.T) {
+	if c := Carrier(context.Background()); c != "" {
+		t.Fatalf("carrier = %q", c)
+	}
+	ctx := FromCarrier(context.Background(), []byte("not json"))
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		t.Fatal("bad carrier produced a span context")
+	}
+}
+
+func TestQueryTracerOnlyInsideTraces(t *testing.T) {
+	rec := withRecorder(t)
+	var qt QueryTracer
+	data := pgx.TraceQueryStartData{SQL: "\n  SELECT 1"}
+
+	ctx := qt.TraceQueryStart(context.Background(), nil, data)
+	qt.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{})
+	if n := len(rec.Ended()); n != 0 {
+		t.Fatalf("untraced query recorded %d spans", n)
+	}
+
+	parent, span := Start(context.Background(), "request")
+	ctx = qt.TraceQueryStart(parent, nil, data)
+	qt.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{})
+	span.End()
+	if got := rec.Ended(); len(got) != 2 || got[0].Name() != "db SELECT" {
+		t.Fatalf("spans = %v", got)
+	}
+}
@@ -0,0 +1,109 @@
+package web
+
+import (
+  "encoding/json"
+  "net/http"
+  "time"
+
+  "github.com/go-chi/chi/v5"
+
+  "time-ledger-sim/go/internal/ledger"
+)
+
+type CreateAccountRequest struct {
+  AccountID string `json:"account_id" validate:"required,max=128"`
+  ZoneID string `json:"zone_id" validate:"required,zone_id"`
+  Actor string `json:"actor" validate:"required"`
+  Reason string `json:"reason"`
+}
+
+func (a *API) handleCreateAccount(w http.ResponseWriter, r *http.Request) {
+  var req CreateAccountRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  req.Actor = actorFor(r, req.Actor)
+  if !validRequest(w, r, req) { return }
+  acct, err := a.led.CreateAccount(r.Context(), ledger.CreateAccountInput{AccountID: req.AccountID, ZoneID: req.ZoneID, Actor: req.Actor, Reason: req.Reason})
+  if err != nil { writeError(w, r, err, 400); return }
+  writeJSON(w, http.StatusCreated, acct)
+}
+
+// --- account tags ---
+
+func (a *API) handleGetAccountTags(w http.ResponseWriter, r *http.Request) {
+  t, err := a.led.GetAccountTags(r.Context(), chi.URLParam(r, "account_id"))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, t)
+}
+
+type TagAccountRequest struct {
+  Tags []string `json:"tags" validate:"required"` // lower-cased; e.g. merchant, consumer, treasury
+  Actor string `json:"actor" validate:"required"`
+  Reason string `json:"reason"`
+}
+
+func (a *API) handleTagAccount(w http.ResponseWriter, r *http.Request) {
+  var req TagAccountRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  req.Actor = actorFor(r, req.Actor)
+  if !validRequest(w, r, req) { return }
+  t, err := a.led.TagAccount(r.Context(), chi.URLParam(r, "account_id"), ledger.TagAccountInput{Tags: req.Tags, Actor: req.Actor, Reason: req.Reason})
+  if err != nil { writeError(w, r, err, 400); return }
+  writeJSON(w, 200, t)
+}
+
+// handleUntagAccount takes actor/reason as query params (DELETE has no body).
+func (a *API) handleUntagAccount(w http.ResponseWriter, r *http.Request) {
+  q := r.URL.Query()
+  actor := actorFor(r, q.Get("actor"))
+  if actor == "" { writeValidationProblem(w, r, FieldError{Field: "actor", Message: "is required"}); return }
+  t, err := a.led.UntagAccount(r.Context(), chi.URLParam(r, "account_id"), chi.URLParam(r, "tag"), actor, q.Get("reason"))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, t)
+}
+
+const defaultTagStatsWindow = 24 * time.Hour
+
+func (a *API) handleAccountTagStats(w http.ResponseWriter, r *http.Request) {
+  since, ok := auditSince(w, r)
+  if !ok { return }
+  if since.IsZero() { since = time.Now().UTC().Add(-defaultTagStatsWindow) }
+  st, err := a.led.GetAccountTagStats(r.Context(), since)
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, st)
+}
+
+// --- per-account controls ---
+
+func (a *API) handleGetAccountControls(w http.ResponseWriter, r *http.Request) {
+  accountID := chi.URLParam(r, "account_id")
+  c, err := a.led.GetAccountControls(r.Context(), accountID)
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, c)
+}
+
+type SetAccountControlsRequest struct {
+  DebitsBlocked bool `json:"debits_blocked"`
+  CreditsBlocked bool `json:"credits_blocked"`
+  Throttle *int `json:"throttle" validate:"min=0,max=100"` // defaults to 100 (no throttle)
+  Actor string `json:"actor" validate:"required"`
+  Reason string `json:"reason"`
+}
+
+func (a *API) handleSetAccountControls(w http.ResponseWriter, r *http.Request) {
+  accountID := chi.URLParam(r, "account_id")
+  var req SetAccountControlsRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  req.Actor = actorFor(r, req.Actor)
+  if !validRequest(w, r, req) { return }
+  throttle := 100
+  if req.Throttle != nil { throttle = *req.Throttle }
+  c, err := a.led.SetAccountControls(r.Context(), accountID, ledger.SetAccountControlsInput{
+    DebitsBlocked: req.DebitsBlocked,
+    CreditsBlocked: req.CreditsBlocked,
+    Throttle: throttle,
+    Actor: req.Actor,
+    Reason: req.Reason,
+  })
+  if err != nil { writeError(w, r, err, 400); return }
+  writeJSON(w, 200, c)
+}
@@ -0,0 +1,60 @@
+package web
+
+import (
+  "encoding/json"
+  "net/http"
+  "strings"
+  "time"
+
+  "github.com/go-chi/chi/v5"
+
+  "time-ledger-sim/go/internal/ledger"
+)
+
+// --- interest/decay accrual rules ---
+
+type CreateAccrualRuleRequest struct {
+  ZoneID string `json:"zone_id" validate:"required,zone_id"`
+  Kind string `json:"kind" validate:"required,oneof=INTEREST DECAY"`
+  RateBps int `json:"rate_bps" validate:"min=1,max=10000"` // per period, e.g. 100 = 1%
+  Period string `json:"period" validate:"required,duration"` // e.g. 24h; 1m to 8784h
+  AccountTag string `json:"account_tag"` // only accounts with this tag
+  MinBalanceUnits int64 `json:"min_balance_units" validate:"min=0"` // default 1
+  SinkAccount string `json:"sink_account"` // default <zone_id>-accrual
+  StartAt *time.Time `json:"start_at"` // first run on the sim clock; default one period from now
+  Actor string `json:"actor" validate:"required"`
+  Reason string `json:"reason"`
+}
+
+func (a *API) handleCreateAccrualRule(w http.ResponseWriter, r *http.Request) {
+  var req CreateAccrualRuleRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  req.Actor = actorFor(r, req.Actor)
+  if !validRequest(w, r, req) { return }
+  period, _ := time.ParseDuration(strings.TrimSpace(req.Period))
+  in := ledger.CreateAccrualRuleInput{
+    ZoneID: req.ZoneID, Kind: req.Kind, RateBps: req.RateBps, Period: period, AccountTag: req.AccountTag,
+    MinBalanceUnits: req.MinBalanceUnits, SinkAccount: req.SinkAccount, Actor: req.Actor, Reason: req.Reason,
+  }
+  if req.StartAt != nil { in.StartAt = *req.StartAt }
+  rule, err := a.led.CreateAccrualRule(r.Context(), in)
+  if err != nil { writeError(w, r, err, 400); return }
+  writeJSON(w, http.StatusCreated, rule)
+}
+
+func (a *API) handleListAccrualRules(w http.ResponseWriter, r *http.Request) {
+  q := r.URL.Query()
+  rules, err := a.led.ListAccrualRules(r.Context(), q.Get("zone_id"), q.Get("all") == "true")
+  if err != nil { writeError(w, r, err, 500); return }
+  writeList(w, r, "accruals", rules)
+}
+
+// handleDisableAccrualRule takes actor/reason as query params (DELETE has no body).
+func (a *API) handleDisableAccrualRule(w http.ResponseWriter, r *http.Request) {
+  q := r.URL.Query()
+  actor := actorFor(r, q.Get("actor"))
+  if actor == "" { writeValidationProblem(w, r, FieldError{Field: "actor", Message: "is required"}); return }
+  rule, err := a.led.DisableAccrualRule(r.Context(), chi.URLParam(r, "rule_id"), actor, q.Get("reason"))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, rule)
+}
@@ -0,0 +1,65 @@
+package web
+
+import (
+  "encoding/json"
+  "net/http"
+  "time"
+
+  "github.com/go-chi/chi/v5"
+
+  "time-ledger-sim/go/internal/ledger"
+)
+
+// --- actor directory ---
+
+type RegisterActorRequest struct {
+  ID string `json:"id" validate:"required"`
+  Kind string `json:"kind" validate:"required,oneof=HUMAN SERVICE SCENARIO"`
+  Name string `json:"name"` // defaults to id
+  Actor string `json:"actor" validate:"required"` // who is registering it
+  Reason string `json:"reason"`
+}
+
+func (a *API) handleRegisterActor(w http.ResponseWriter, r *http.Request) {
+  var req RegisterActorRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  req.Actor = actorFor(r, req.Actor)
+  if !validRequest(w, r, req) { return }
+  act, err := a.led.RegisterActor(r.Context(), ledger.RegisterActorInput{ID: req.ID, Kind: req.Kind, Name: req.Name, Actor: req.Actor, Reason: req.Reason})
+  if err != nil { writeError(w, r, err, 400); return }
+  writeJSON(w, http.StatusCreated, act)
+}
+
+func (a *API) handleListActors(w http.ResponseWriter, r *http.Request) {
+  q := r.URL.Query()
+  actors, err := a.led.ListActors(r.Context(), q.Get("kind"), q.Get("all") == "true")
+  if err != nil { writeError(w, r, err, 500); return }
+  writeList(w, r, "actors", actors)
+}
+
+func (a *API) handleGetActor(w http.ResponseWriter, r *http.Request) {
+  act, err := a.led.GetActor(r.Context(), chi.URLParam(r, "actor_id"))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, act)
+}
+
+// handleDisableActor takes actor/reason as query params (DELETE has no body).
+func (a *API) handleDisableActor(w http.ResponseWriter, r *http.Request) {
+  q := r.URL.Query()
+  actor := actorFor(r, q.Get("actor"))
+  if actor == "" { writeValidationProblem(w, r, FieldError{Field: "actor", Message: "is required"}); return }
+  act, err := a.led.DisableActor(r.Context(), chi.URLParam(r, "actor_id"), actor, q.Get("reason"))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, act)
+}
+
+const defaultActivityWindow = 7 * 24 * time.Hour
+
+func (a *API) handleActorActivity(w http.ResponseWriter, r *http.Request) {
+  since, ok := auditSince(w, r)
+  if !ok { return }
+  if since.IsZero() { since = time.Now().UTC().Add(-defaultActivityWindow) }
+  act, err := a.led.GetActorActivity(r.Context(), chi.URLParam(r, "actor_id"), since)
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, act)
+}
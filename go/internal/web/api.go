@@ -1,75 +1,68 @@
 package web
 
 import (
+  "bytes"
   "encoding/json"
+  "io"
   "net/http"
+  "slices"
   "strconv"
+  "strings"
+  "sync"
   "time"
 
   "github.com/go-chi/chi/v5"
+  "github.com/google/uuid"
   "log/slog"
 
+  "time-ledger-sim/go/internal/auth"
+  "time-ledger-sim/go/internal/dbwatch"
     "time-ledger-sim/go/internal/ledger"
+  "time-ledger-sim/go/internal/objstore"
   "time-ledger-sim/go/internal/util"
 )
 
 type API struct {
   adminKey string
   led *ledger.Ledger
+  scenarios *ledger.ScenarioRunner
+  store *objstore.Store // nil when S3 is not configured
+  drain Drainer
+  reload Reloader
+  balances BalanceStreamer // nil: no balance streams
+  streams StreamManager // nil: no stream admin
+  dbwatch *dbwatch.Watcher
   log *slog.Logger
-}
 
-func NewAPI(adminKey string, led *ledger.Ledger, log *slog.Logger) *API {
-  return &API{adminKey: adminKey, led: led, log: log}
+  specOnce sync.Once
+  spec map[string]any // OpenAPI document, built on first request
 }
 
-func (a *API) RegisterRoutes(r chi.Router) {
-  r.Get("/v1/version", a.handleVersion)
-
-  r.Get("/v1/zones", a.handleListZones)
-
-  r.Post("/v1/transfers", a.handleCreateTransfer)
-
-  r.Get("/v1/balances", a.handleListBalances)
-  r.Get("/v1/transactions", a.handleListTransactions)
-  r.Get("/v1/transactions/{transaction_id}", a.handleGetTransaction)
-
-  r.Post("/v1/zones/{zone_id}/status", a.handleSetZoneStatus)
-
-  // incidents
-  r.Get("/v1/zones/{zone_id}/incidents", a.handleListIncidentsByZone)
-  r.Get("/v1/incidents", a.handleListRecentIncidents)
-  r.Get("/v1/incidents/{incident_id}", a.handleGetIncident)
-  r.Post("/v1/incidents/{incident_id}/action", a.handleIncidentAction)
-
-  // ops controls + spool + audit
-  r.Get("/v1/zones/{zone_id}/controls", a.handleGetZoneControls)
-  r.Post("/v1/zones/{zone_id}/controls", a.handleSetZoneControls)
-
-  r.Get("/v1/zones/{zone_id}/spool", a.handleGetSpoolStats)
-  r.Post("/v1/zones/{zone_id}/spool/replay", a.handleReplaySpool)
-
-  r.Get("/v1/zones/{zone_id}/audit", a.handleListAudit)
-
-  // sim admin (snapshots)
-  r.Post("/v1/sim/snapshot", a.admin(a.handleSnapshot))
-  r.Post("/v1/sim/restore", a.admin(a.handleRestore))
+func NewAPI(adminKey string, led *ledger.Ledger, scenarios *ledger.ScenarioRunner, store *objstore.Store, drain Drainer, reload Reloader, balances BalanceStreamer, streams StreamManager, watch *dbwatch.Watcher, log *slog.Logger) *API {
+  return &API{adminKey: adminKey, led: led, scenarios: scenarios, store: store, drain: drain, reload: reload, balances: balances, streams: streams, dbwatch: watch, log: log}
 }
 
 func (a *API) admin(next http.HandlerFunc) http.HandlerFunc {
   return func(w http.ResponseWriter, r *http.Request) {
     if a.adminKey == "" {
-      http.Error(w, "admin disabled", http.StatusForbidden)
+      writeProblem(w, r, http.StatusForbidden, CodeAdminDisabled, "admin disabled")
       return
     }
     if r.Header.Get("X-Admin-Key") != a.adminKey {
-      http.Error(w, "forbidden", http.StatusForbidden)
+      writeProblem(w, r, http.StatusForbidden, CodeForbidden, "forbidden")
       return
     }
     next(w, r)
   }
 }
 
+// actorFor returns the authenticated caller when OIDC is enabled, so audit entries
+// name who actually made the change; otherwise the actor the client supplied.
+func actorFor(r *http.Request, supplied string) string {
+  if id, ok := auth.FromContext(r.Context()); ok { return id.Actor }
+  return supplied
+}
+
 func writeJSON(w http.ResponseWriter, status int, v any) {
   w.Header().Set("content-type", "application/json")
   w.WriteHeader(status)
@@ -77,18 +70,32 @@ func writeJSON(w http.ResponseWriter, status int, v any) {
 }
 
 func (a *API) handleListZones(w http.ResponseWriter, r *http.Request) {
-  zones, err := a.led.ListZones(r.Context())
-  if err != nil { http.Error(w, err.Error(), 500); return }
-  writeJSON(w, 200, map[string]any{"zones": zones})
+  v, err := a.led.ZonesVersion(r.Context())
+  if err != nil { writeError(w, r, err, 500); return }
+  if notModified(w, r, v) { return }
+  tags, ok := tagSelectorQuery(w, r)
+  if !ok { return }
+  zones, err := a.led.ListZones(r.Context(), tags)
+  if err != nil { writeError(w, r, err, 500); return }
+  writeList(w, r, "zones", zones)
 }
 
 type CreateTransferRequest struct {
-  RequestID string        `json:"request_id"`
-  FromAccount string      `json:"from_account"`
-  ToAccount string        `json:"to_account"`
-  AmountUnits int64       `json:"amount_units"`
-  ZoneID string           `json:"zone_id"`
+  RequestID string        `json:"request_id" validate:"required"`
+  FromAccount string      `json:"from_account" validate:"required"`
+  ToAccount string        `json:"to_account" validate:"required"`
+  AmountUnits int64       `json:"amount_units" validate:"gt=0"`
+  ZoneID string           `json:"zone_id" validate:"required,zone_id"`
+  Type string             `json:"type,omitempty"` // TRANSFER (default), FEE or REVERSAL; see GET /v1/transaction-types
   Metadata map[string]any `json:"metadata"`
+  // LimitOverride lets the transfer past the zone's amount limits (admin key
+  // required). It is not part of the payload hash.
+  LimitOverride *LimitOverrideRequest `json:"limit_override,omitempty"`
+}
+
+type LimitOverrideRequest struct {
+  Actor string `json:"actor" validate:"required"`
+  Reason string `json:"reason" validate:"required"`
 }
 
 type TransferAppliedResponse struct {
@@ -96,6 +103,7 @@ type TransferAppliedResponse struct {
   TransactionID string `json:"transaction_id"`
   RequestID string `json:"request_id"`
   CreatedAt time.Time `json:"created_at"`
+  ZoneSeq int64 `json:"zone_seq"` // position in the zone's sequence, see GET /v1/zones/{zone_id}/transactions
 }
 
 type TransferSpooledResponse struct {
@@ -106,14 +114,17 @@ type TransferSpooledResponse struct {
 
 func (a *API) handleCreateTransfer(w http.ResponseWriter, r *http.Request) {
   var req CreateTransferRequest
-  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { http.Error(w, "bad json", 400); return }
-  if req.RequestID == "" || req.FromAccount == "" || req.ToAccount == "" || req.ZoneID == "" || req.AmountUnits <= 0 {
-    http.Error(w, "missing/invalid fields", 400); return
-  }
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  if req.LimitOverride != nil { req.LimitOverride.Actor = actorFor(r, req.LimitOverride.Actor) }
+  if !validRequest(w, r, req) { return }
+  override, ok := a.limitOverride(w, r, req.LimitOverride)
+  if !ok { return }
+  req.LimitOverride = nil
   if req.Metadata == nil { req.Metadata = map[string]any{} }
+  req.Type = hashedType(req.Type)
 
   payloadHash, err := util.HashCanonicalJSON(req)
-  if err != nil { http.Error(w, "hash error", 500); return }
+  if err != nil { writeProblem(w, r, 500, CodeInternal, "hash error"); return }
 
   txn, spoolID, err := a.led.CreateTransfer(r.Context(), ledger.CreateTransferInput{
     RequestID: req.RequestID,
@@ -122,30 +133,85 @@ func (a *API) handleCreateTransfer(w http.ResponseWriter, r *http.Request) {
     ToAccount: req.ToAccount,
     AmountUnits: req.AmountUnits,
     ZoneID: req.ZoneID,
+    Type: req.Type,
     Metadata: req.Metadata,
+    LimitOverride: override,
   })
-  if err != nil {
-    if ledger.IsIdempotencyConflict(err) {
-      http.Error(w, "idempotency conflict", http.StatusConflict)
-      return
-    }
-    if ledger.IsZoneDown(err) {
-      http.Error(w, "zone down", http.StatusServiceUnavailable)
-      return
-    }
-    if ledger.IsZoneBlocked(err) {
-      http.Error(w, "zone blocked", http.StatusServiceUnavailable)
-      return
-    }
-    http.Error(w, err.Error(), 500)
-    return
-  }
+  if err != nil { writeError(w, r, err, 500); return }
 
   if spoolID != nil {
     writeJSON(w, http.StatusAccepted, TransferSpooledResponse{Status: "SPOOLED", SpoolID: *spoolID, RequestID: req.RequestID})
     return
   }
-  writeJSON(w, 200, TransferAppliedResponse{Status: "APPLIED", TransactionID: txn.ID, RequestID: txn.RequestID, CreatedAt: txn.CreatedAt})
+  writeJSON(w, 200, TransferAppliedResponse{Status: "APPLIED", TransactionID: txn.ID, RequestID: txn.RequestID, CreatedAt: txn.CreatedAt, ZoneSeq: txn.ZoneSeq})
+}
+
+// limitOverride converts a transfer's limit_override, which takes the admin
+// key; callers drop it from the request before hashing.
+func (a *API) limitOverride(w http.ResponseWriter, r *http.Request, o *LimitOverrideRequest) (*ledger.LimitOverride, bool) {
+  if o == nil { return nil, true }
+  if a.adminKey == "" || r.Header.Get("X-Admin-Key") != a.adminKey {
+    writeProblem(w, r, http.StatusForbidden, CodeForbidden, "limit_override requires the admin key")
+    return nil, false
+  }
+  return &ledger.LimitOverride{Actor: o.Actor, Reason: o.Reason}, true
+}
+
+// hashedType leaves the default type out of the payload hash, so a transfer
+// hashes the same with or without "type": "TRANSFER", and as it did before
+// transfers had types (and still does in the Rust service).
+func hashedType(t string) string {
+  if t == ledger.TxnTypeTransfer { return "" }
+  return t
+}
+
+// EstimateTransferRequest is a CreateTransferRequest whose request_id may be
+// left out; the estimate then picks one and returns it.
+type EstimateTransferRequest struct {
+  RequestID string        `json:"request_id"`
+  FromAccount string      `json:"from_account" validate:"required"`
+  ToAccount string        `json:"to_account" validate:"required"`
+  AmountUnits int64       `json:"amount_units" validate:"gt=0"`
+  ZoneID string           `json:"zone_id" validate:"required,zone_id"`
+  Type string             `json:"type,omitempty"` // TRANSFER (default), FEE or REVERSAL; see GET /v1/transaction-types
+  Metadata map[string]any `json:"metadata"`
+  // LimitOverride lets the transfer past the zone's amount limits (admin key
+  // required). It is not part of the payload hash.
+  LimitOverride *LimitOverrideRequest `json:"limit_override,omitempty"`
+}
+
+// EstimateTransferResponse adds the problem code a rejected transfer would get.
+type EstimateTransferResponse struct {
+  ledger.TransferEstimate
+  Code string `json:"code,omitempty"`
+}
+
+// handleEstimateTransfer takes a transfer body (request_id optional) and
+// reports what POST /v1/transfers would do with it, without recording anything.
+func (a *API) handleEstimateTransfer(w http.ResponseWriter, r *http.Request) {
+  var req EstimateTransferRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  if req.LimitOverride != nil { req.LimitOverride.Actor = actorFor(r, req.LimitOverride.Actor) }
+  if !validRequest(w, r, req) { return }
+  override, ok := a.limitOverride(w, r, req.LimitOverride)
+  if !ok { return }
+  req.LimitOverride = nil
+  if req.RequestID == "" { req.RequestID = uuid.NewString() } // hash throttles hold for this id
+  if req.Metadata == nil { req.Metadata = map[string]any{} }
+  req.Type = hashedType(req.Type)
+
+  // hashed as the transfer itself would be, so a used request_id replays
+  payloadHash, err := util.HashCanonicalJSON(CreateTransferRequest(req))
+  if err != nil { writeProblem(w, r, 500, CodeInternal, "hash error"); return }
+
+  est, err := a.led.EstimateTransfer(r.Context(), ledger.CreateTransferInput{
+    RequestID: req.RequestID, PayloadHash: payloadHash, FromAccount: req.FromAccount, ToAccount: req.ToAccount,
+    AmountUnits: req.AmountUnits, ZoneID: req.ZoneID, Type: req.Type, Metadata: req.Metadata, LimitOverride: override,
+  })
+  if err != nil { writeError(w, r, err, 500); return }
+  resp := EstimateTransferResponse{TransferEstimate: *est}
+  if est.Err != nil { _, resp.Code = problemFor(est.Err, 500) }
+  writeJSON(w, 200, resp)
 }
 
 func (a *API) handleListBalances(w http.ResponseWriter, r *http.Request) {
@@ -153,9 +219,21 @@ func (a *API) handleListBalances(w http.ResponseWriter, r *http.Request) {
   if q := r.URL.Query().Get("limit"); q != "" {
     if n, err := strconv.Atoi(q); err == nil { limit = n }
   }
-  rows, err := a.led.ListBalances(r.Context(), limit)
-  if err != nil { http.Error(w, err.Error(), 500); return }
-  writeJSON(w, 200, map[string]any{"balances": rows})
+  q := r.URL.Query()
+  asOf, ok := timeQuery(w, r, "as_of")
+  if !ok { return }
+  page, err := a.led.ListBalances(r.Context(), ledger.BalanceFilter{
+    Limit: limit, AccountTag: q.Get("account_tag"), ZoneID: q.Get("zone_id"), Order: q.Get("order"), Cursor: q.Get("cursor"), AsOf: asOf,
+  })
+  if err != nil { writeError(w, r, err, 400); return }
+  writePage(w, r, "balances", page.Balances, page.NextCursor)
+}
+
+// handleTransactionChanges serves the change feed; JSON, NDJSON or CSV by Accept.
+func (a *API) handleTransactionChanges(w http.ResponseWriter, r *http.Request) {
+  page, err := a.led.ListTransactionChanges(r.Context(), r.URL.Query().Get("cursor"), util.QueryInt(r, "limit", 100))
+  if err != nil { writeError(w, r, err, 400); return }
+  writePage(w, r, "changes", page.Changes, page.NextCursor)
 }
 
 func (a *API) handleListTransactions(w http.ResponseWriter, r *http.Request) {
@@ -163,39 +241,88 @@ func (a *API) handleListTransactions(w http.ResponseWriter, r *http.Request) {
   if q := r.URL.Query().Get("limit"); q != "" {
     if n, err := strconv.Atoi(q); err == nil { limit = n }
   }
-  rows, err := a.led.ListTransactions(r.Context(), limit)
-  if err != nil { http.Error(w, err.Error(), 500); return }
-  writeJSON(w, 200, map[string]any{"transactions": rows})
+  q := r.URL.Query()
+  rows, err := a.led.ListTransactions(r.Context(), ledger.TransactionFilter{Limit: limit, Tag: q.Get("tag"), AccountTag: q.Get("account_tag"), Type: q.Get("type")})
+  if err != nil { writeError(w, r, err, 500); return }
+  writeList(w, r, "transactions", rows)
+}
+
+func (a *API) handleListTransactionTypes(w http.ResponseWriter, r *http.Request) {
+  writeList(w, r, "transaction_types", ledger.TransactionTypes())
+}
+
+type AnnotateTransactionRequest struct {
+  Note string `json:"note"`
+  Tags []string `json:"tags"` // lower-cased; e.g. fraud-review, case:1234
+  Actor string `json:"actor" validate:"required"`
+}
+
+func (a *API) handleAnnotateTransaction(w http.ResponseWriter, r *http.Request) {
+  var req AnnotateTransactionRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  req.Actor = actorFor(r, req.Actor)
+  if !validRequest(w, r, req) { return }
+  an, err := a.led.AnnotateTransaction(r.Context(), chi.URLParam(r, "transaction_id"), ledger.AnnotateInput{Note: req.Note, Tags: req.Tags, Actor: req.Actor})
+  if err != nil { writeError(w, r, err, 400); return }
+  writeJSON(w, http.StatusCreated, an)
+}
+
+func (a *API) handleTransactionRelated(w http.ResponseWriter, r *http.Request) {
+  rel, err := a.led.GetTransactionRelated(r.Context(), chi.URLParam(r, "transaction_id"))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, rel)
+}
+
+func (a *API) handleListAnnotations(w http.ResponseWriter, r *http.Request) {
+  id := chi.URLParam(r, "transaction_id")
+  if _, err := a.led.GetTransaction(r.Context(), id); err != nil { writeError(w, r, err, 404); return }
+  list, err := a.led.ListAnnotations(r.Context(), id)
+  if err != nil { writeError(w, r, err, 500); return }
+  writeList(w, r, "annotations", list)
 }
 
 func (a *API) handleGetTransaction(w http.ResponseWriter, r *http.Request) {
   id := chi.URLParam(r, "transaction_id")
   t, err := a.led.GetTransaction(r.Context(), id)
-  if err != nil { http.Error(w, err.Error(), 404); return }
+  if err != nil { writeError(w, r, err, 404); return }
   writeJSON(w, 200, t)
 }
 
 type SetZoneStatusRequest struct {
-  Status string `json:"status"`
-  Actor string `json:"actor"`
+  Status string `json:"status" validate:"required,oneof=OK DEGRADED DOWN"`
+  Actor string `json:"actor" validate:"required"`
+  ReasonCode string `json:"reason_code"` // from GET /v1/reason-codes
   Reason string `json:"reason"`
 }
 
 func (a *API) handleSetZoneStatus(w http.ResponseWriter, r *http.Request) {
   zoneID := chi.URLParam(r, "zone_id")
   var req SetZoneStatusRequest
-  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { http.Error(w, "bad json", 400); return }
-  if zoneID == "" || req.Status == "" || req.Actor == "" { http.Error(w, "missing fields", 400); return }
-  z, err := a.led.SetZoneStatus(r.Context(), zoneID, req.Status, req.Actor, req.Reason)
-  if err != nil { http.Error(w, err.Error(), 500); return }
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  req.Actor = actorFor(r, req.Actor)
+  if !validRequest(w, r, req) { return }
+  if a.led.ZoneStatusNeedsApproval(req.Status) {
+    ap, err := a.led.RequestZoneStatusApproval(r.Context(), zoneID, req.Status, req.Actor, req.ReasonCode, req.Reason)
+    if err != nil { writeError(w, r, err, 500); return }
+    writeJSON(w, http.StatusAccepted, ap)
+    return
+  }
+  z, err := a.led.SetZoneStatus(r.Context(), zoneID, req.Status, req.Actor, req.ReasonCode, req.Reason)
+  if err != nil {
+    writeError(w, r, err, 500)
+    return
+  }
   writeJSON(w, 200, z)
 }
 
 func (a *API) handleListIncidentsByZone(w http.ResponseWriter, r *http.Request) {
   zoneID := chi.URLParam(r, "zone_id")
+  v, err := a.led.IncidentsVersion(r.Context(), zoneID)
+  if err != nil { writeError(w, r, err, 500); return }
+  if notModified(w, r, v) { return }
   inc, err := a.led.ListIncidentsByZone(r.Context(), zoneID)
-  if err != nil { http.Error(w, err.Error(), 500); return }
-  writeJSON(w, 200, map[string]any{"incidents": inc})
+  if err != nil { writeError(w, r, err, 500); return }
+  writeList(w, r, "incidents", inc)
 }
 
 func (a *API) handleListRecentIncidents(w http.ResponseWriter, r *http.Request) {
@@ -203,15 +330,24 @@ func (a *API) handleListRecentIncidents(w http.ResponseWriter, r *http.Request)
   if q := r.URL.Query().Get("limit"); q != "" {
     if n, err := strconv.Atoi(q); err == nil { limit = n }
   }
-  inc, err := a.led.ListRecentIncidents(r.Context(), limit)
-  if err != nil { http.Error(w, err.Error(), 500); return }
-  writeJSON(w, 200, map[string]any{"incidents": inc})
+  tags, ok := tagSelectorQuery(w, r)
+  if !ok { return }
+  // the incidents version does not cover zone tags, so a filtered list is
+  // always served in full
+  if len(tags) == 0 {
+    v, err := a.led.IncidentsVersion(r.Context(), "")
+    if err != nil { writeError(w, r, err, 500); return }
+    if notModified(w, r, v) { return }
+  }
+  inc, err := a.led.ListRecentIncidents(r.Context(), limit, tags)
+  if err != nil { writeError(w, r, err, 500); return }
+  writeList(w, r, "incidents", inc)
 }
 
 func (a *API) handleGetIncident(w http.ResponseWriter, r *http.Request) {
   id := chi.URLParam(r, "incident_id")
   inc, err := a.led.GetIncident(r.Context(), id)
-  if err != nil { http.Error(w, err.Error(), 404); return }
+  if err != nil { writeError(w, r, err, 404); return }
   writeJSON(w, 200, inc)
 }
 
@@ -219,49 +355,172 @@ func (a *API) handleGetIncident(w http.ResponseWriter, r *http.Request) {
 
 func (a *API) handleGetZoneControls(w http.ResponseWriter, r *http.Request) {
   zoneID := chi.URLParam(r, "zone_id")
+  v, err := a.led.ZoneControlsVersion(r.Context(), zoneID)
+  if err != nil { writeError(w, r, err, 500); return }
+  if notModified(w, r, v) { return }
   c, err := a.led.GetZoneControls(r.Context(), zoneID)
-  if err != nil { http.Error(w, err.Error(), 500); return }
+  if err != nil { writeError(w, r, err, 500); return }
   writeJSON(w, 200, c)
 }
 
+func (a *API) handleZoneControlsHistory(w http.ResponseWriter, r *http.Request) {
+  var after int64
+  if q := r.URL.Query().Get("after_version"); q != "" {
+    n, err := strconv.ParseInt(q, 10, 64)
+    if err != nil || n < 0 { writeValidationProblem(w, r, FieldError{Field: "after_version", Message: "must be a non-negative integer"}); return }
+    after = n
+  }
+  since, ok := timeQuery(w, r, "since")
+  if !ok { return }
+  list, err := a.led.ListZoneControlsHistory(r.Context(), chi.URLParam(r, "zone_id"), after, since, util.QueryInt(r, "limit", 100))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeList(w, r, "history", list)
+}
+
 type SetZoneControlsRequest struct {
   WritesBlocked bool `json:"writes_blocked"`
-  CrossZoneThrottle int `json:"cross_zone_throttle"`
+  CrossZoneThrottle int `json:"cross_zone_throttle" validate:"min=0,max=100"`
   SpoolEnabled bool `json:"spool_enabled"`
-  Actor string `json:"actor"`
+  InjectLatencyMs int `json:"inject_latency_ms" validate:"min=0,max=60000"`
+  InjectJitterMs int `json:"inject_jitter_ms" validate:"min=0,max=60000"`
+  ErrorRatePercent int `json:"error_rate_percent" validate:"min=0,max=100"`
+  ThrottleMode string `json:"throttle_mode" validate:"omitempty,oneof=HASH RATE"`
+  RateLimitPerSec int `json:"rate_limit_per_sec" validate:"min=0"`
+  RateLimitBurst int `json:"rate_limit_burst" validate:"min=0"`
+  ClockSkewMs int64 `json:"clock_skew_ms" validate:"min=-86400000,max=86400000"`
+  BlockedActions map[string]string `json:"blocked_actions,omitempty"` // cause -> SPOOL | REJECT | DELAY
+  BlockedDelayMs int `json:"blocked_delay_ms,omitempty" validate:"min=0,max=10000"`
+  MinAmountUnits int64 `json:"min_amount_units,omitempty" validate:"min=0"` // smallest transfer; 0 is no limit
+  MaxAmountUnits int64 `json:"max_amount_units,omitempty" validate:"min=0"` // largest transfer; 0 is no limit
+  DailyAccountLimitUnits int64 `json:"daily_account_limit_units,omitempty" validate:"min=0"` // what one account may send per day; 0 is no limit
+  DayCutoverMinute int `json:"day_cutover_minute,omitempty" validate:"min=0,max=1439"` // minute of the zone's day the business day closes; 0 is midnight
+  Actor string `json:"actor" validate:"required"`
+  ReasonCode string `json:"reason_code"`
   Reason string `json:"reason"`
 }
 
+// validBlockedActions checks the blocked_actions map, which validate tags
+// cannot describe.
+func validBlockedActions(w http.ResponseWriter, r *http.Request, actions map[string]string) bool {
+  for cause, action := range actions {
+    msg := ""
+    switch {
+    case !slices.Contains(ledger.BlockedCauses, cause):
+      msg = "has unknown cause " + cause + "; must be one of " + strings.Join(ledger.BlockedCauses, ", ")
+    case action != ledger.BlockedActionSpool && action != ledger.BlockedActionReject && action != ledger.BlockedActionDelay:
+      msg = cause + " must be one of SPOOL, REJECT, DELAY"
+    }
+    if msg != "" {
+      writeValidationProblem(w, r, FieldError{Field: "blocked_actions", Message: msg})
+      return false
+    }
+  }
+  return true
+}
+
+func (req SetZoneControlsRequest) toInput() ledger.SetZoneControlsInput {
+  return ledger.SetZoneControlsInput{
+    WritesBlocked: req.WritesBlocked,
+    CrossZoneThrottle: req.CrossZoneThrottle,
+    SpoolEnabled: req.SpoolEnabled,
+    InjectLatencyMs: req.InjectLatencyMs,
+    InjectJitterMs: req.InjectJitterMs,
+    ErrorRatePercent: req.ErrorRatePercent,
+    ThrottleMode: req.ThrottleMode,
+    RateLimitPerSec: req.RateLimitPerSec,
+    RateLimitBurst: req.RateLimitBurst,
+    ClockSkewMs: req.ClockSkewMs,
+    BlockedActions: req.BlockedActions,
+    BlockedDelayMs: req.BlockedDelayMs,
+    MinAmountUnits: req.MinAmountUnits,
+    MaxAmountUnits: req.MaxAmountUnits,
+    DailyAccountLimitUnits: req.DailyAccountLimitUnits,
+    DayCutoverMinute: req.DayCutoverMinute,
+    Actor: req.Actor,
+    ReasonCode: req.ReasonCode,
+    Reason: req.Reason,
+  }
+}
+
 func (a *API) handleSetZoneControls(w http.ResponseWriter, r *http.Request) {
   zoneID := chi.URLParam(r, "zone_id")
   var req SetZoneControlsRequest
-  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { http.Error(w, "bad json", 400); return }
-  if zoneID == "" || req.Actor == "" { http.Error(w, "missing fields", 400); return }
-  c, err := a.led.SetZoneControls(r.Context(), zoneID, req.WritesBlocked, req.CrossZoneThrottle, req.SpoolEnabled, req.Actor, req.Reason)
-  if err != nil { http.Error(w, err.Error(), 500); return }
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  req.Actor = actorFor(r, req.Actor)
+  if !validRequest(w, r, req) || !validBlockedActions(w, r, req.BlockedActions) { return }
+  if a.led.ZoneControlsNeedApproval(req.toInput()) {
+    ap, err := a.led.RequestZoneControlsApproval(r.Context(), zoneID, time.Time{}, req.toInput())
+    if err != nil { writeError(w, r, err, 400); return }
+    writeJSON(w, http.StatusAccepted, ap)
+    return
+  }
+  c, err := a.led.SetZoneControls(r.Context(), zoneID, req.toInput())
+  if err != nil { writeError(w, r, err, 500); return }
   writeJSON(w, 200, c)
 }
 
+type BatchZoneControlsRequest struct {
+  ZoneIDs []string `json:"zone_ids,omitempty"`
+  Tags map[string]string `json:"tags,omitempty"` // zones carrying all of these tags
+  All bool `json:"all,omitempty"` // every active zone
+  Controls ledger.ZoneControlsPatch `json:"controls"` // only the fields given change
+  Actor string `json:"actor" validate:"required"`
+  ReasonCode string `json:"reason_code"`
+  Reason string `json:"reason"`
+}
+
+func (a *API) handleBatchZoneControls(w http.ResponseWriter, r *http.Request) {
+  var req BatchZoneControlsRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  req.Actor = actorFor(r, req.Actor)
+  if !validRequest(w, r, req) || !validBlockedActions(w, r, req.Controls.BlockedActions) { return }
+  in := ledger.BatchZoneControlsInput{
+    ZoneIDs: req.ZoneIDs, Tags: req.Tags, All: req.All, Patch: req.Controls,
+    Actor: req.Actor, ReasonCode: req.ReasonCode, Reason: req.Reason,
+  }
+  if a.led.ZoneControlsBatchNeedsApproval(in) {
+    ap, err := a.led.RequestZoneControlsBatchApproval(r.Context(), in)
+    if err != nil { writeError(w, r, err, 400); return }
+    writeJSON(w, http.StatusAccepted, ap)
+    return
+  }
+  res, err := a.led.BatchSetZoneControls(r.Context(), in)
+  if err != nil { writeError(w, r, err, 400); return }
+  writeJSON(w, 200, res)
+}
+
 func (a *API) handleGetSpoolStats(w http.ResponseWriter, r *http.Request) {
   zoneID := chi.URLParam(r, "zone_id")
   s, err := a.led.GetSpoolStats(r.Context(), zoneID)
-  if err != nil { http.Error(w, err.Error(), 500); return }
+  if err != nil { writeError(w, r, err, 500); return }
   writeJSON(w, 200, s)
 }
 
+func (a *API) handleSpoolHistory(w http.ResponseWriter, r *http.Request) {
+  from, ok := timeQuery(w, r, "from")
+  if !ok { return }
+  to, ok := timeQuery(w, r, "to")
+  if !ok { return }
+  h, err := a.led.GetSpoolDepthHistory(r.Context(), chi.URLParam(r, "zone_id"), from, to, util.QueryInt(r, "limit", 1000))
+  if err != nil { writeError(w, r, err, 400); return }
+  writeJSON(w, 200, h)
+}
+
 type ReplaySpoolRequest struct {
-  Limit int `json:"limit"`
-  Actor string `json:"actor"`
+  Limit int `json:"limit" validate:"min=0,max=500"`
+  Actor string `json:"actor" validate:"required"`
+  ReasonCode string `json:"reason_code"`
   Reason string `json:"reason"`
 }
 
 func (a *API) handleReplaySpool(w http.ResponseWriter, r *http.Request) {
   zoneID := chi.URLParam(r, "zone_id")
   var req ReplaySpoolRequest
-  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { http.Error(w, "bad json", 400); return }
-  if zoneID == "" || req.Actor == "" { http.Error(w, "missing fields", 400); return }
-  res, err := a.led.ReplaySpool(r.Context(), zoneID, req.Limit, req.Actor, req.Reason)
-  if err != nil { http.Error(w, err.Error(), 409); return }
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  req.Actor = actorFor(r, req.Actor)
+  if !validRequest(w, r, req) { return }
+  res, err := a.led.ReplaySpool(r.Context(), zoneID, req.Limit, req.Actor, req.ReasonCode, req.Reason)
+  if err != nil { writeError(w, r, err, 409); return }
   writeJSON(w, 200, res)
 }
 
@@ -272,23 +531,25 @@ func (a *API) handleListAudit(w http.ResponseWriter, r *http.Request) {
     if n, err := strconv.Atoi(q); err == nil { limit = n }
   }
   entries, err := a.led.ListAuditForZone(r.Context(), zoneID, limit)
-  if err != nil { http.Error(w, err.Error(), 500); return }
-  writeJSON(w, 200, map[string]any{"audit": entries})
+  if err != nil { writeError(w, r, err, 500); return }
+  writeList(w, r, "audit", entries)
 }
 
 type IncidentActionRequest struct {
-  Action string `json:"action"` // ACK|ASSIGN|RESOLVE
+  Action string `json:"action" validate:"required,oneof=ACK ASSIGN RESOLVE"`
   Assignee string `json:"assignee"`
   Note string `json:"note"`
-  Actor string `json:"actor"`
+  Actor string `json:"actor" validate:"required"`
   Reason string `json:"reason"`
 }
 
 func (a *API) handleIncidentAction(w http.ResponseWriter, r *http.Request) {
   id := chi.URLParam(r, "incident_id")
   var req IncidentActionRequest
-  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { http.Error(w, "bad json", 400); return }
-  if id == "" || req.Actor == "" || req.Action == "" { http.Error(w, "missing fields", 400); return }
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  req.Actor = actorFor(r, req.Actor)
+  if !validRequest(w, r, req) { return }
+  if req.Action == "ASSIGN" && req.Assignee == "" { writeValidationProblem(w, r, FieldError{Field: "assignee", Message: "is required for ASSIGN"}); return }
 
   out, err := a.led.ApplyIncidentAction(r.Context(), id, ledger.IncidentAction{
     Action: req.Action,
@@ -297,19 +558,139 @@ func (a *API) handleIncidentAction(w http.ResponseWriter, r *http.Request) {
     Actor: req.Actor,
     Reason: req.Reason,
   })
-  if err != nil { http.Error(w, err.Error(), 409); return }
+  if err != nil { writeError(w, r, err, 409); return }
   writeJSON(w, 200, out)
 }
 
+// handleSnapshot returns the snapshot, or with ?dest=s3://bucket/key writes it to
+// object storage and returns the location instead. ?format=ndjson (or Accept:
+// application/x-ndjson) streams it; ?full=true includes transaction history,
+// postings, and outbox/inbox state.
 func (a *API) handleSnapshot(w http.ResponseWriter, r *http.Request) {
-  snap, err := a.led.Snapshot(r.Context())
-  if err != nil { http.Error(w, err.Error(), 500); return }
-  writeJSON(w, 200, snap)
+  ndjson := r.URL.Query().Get("format") == "ndjson" || negotiate(r, mediaJSON, mediaNDJSON) == mediaNDJSON
+  dest := r.URL.Query().Get("dest")
+  opts := ledger.SnapshotOptions{Full: r.URL.Query().Get("full") == "true"}
+
+  if dest == "" {
+    if ndjson {
+      w.Header().Set("Content-Type", "application/x-ndjson")
+      if err := a.led.WriteSnapshotNDJSON(r.Context(), w, opts); err != nil {
+        // headers are already sent; all we can do is log and cut the stream short
+        a.log.Warn("snapshot stream failed", "err", err.Error())
+      }
+      return
+    }
+    snap, err := a.led.Snapshot(r.Context(), opts)
+    if err != nil { writeError(w, r, err, 500); return }
+    writeJSON(w, 200, snap)
+    return
+  }
+
+  ext := ".json"
+  if ndjson { ext = ".ndjson" }
+  loc, err := a.store.Parse(dest, "snapshot-"+a.led.Now().UTC().Format("20060102T150405Z")+ext)
+  if err != nil { writeError(w, r, err, 400); return }
+
+  var n int64
+  if ndjson {
+    pr, pw := io.Pipe()
+    go func() { pw.CloseWithError(a.led.WriteSnapshotNDJSON(r.Context(), pw, opts)) }()
+    n, err = a.store.Put(r.Context(), loc, pr, -1, "application/x-ndjson")
+    _ = pr.Close()
+  } else {
+    snap, serr := a.led.Snapshot(r.Context(), opts)
+    if serr != nil { writeError(w, r, serr, 500); return }
+    body, _ := json.Marshal(snap)
+    n, err = a.store.Put(r.Context(), loc, bytes.NewReader(body), int64(len(body)), "application/json")
+  }
+  if err != nil { writeError(w, r, err, http.StatusBadGateway); return }
+  writeJSON(w, 200, map[string]any{"location": loc.String(), "bytes": n})
 }
 
+// handleRestore restores from the request body, or with ?src=s3://bucket/key from
+// object storage. NDJSON is selected by ?format=ndjson, the request content type,
+// or a .ndjson object key. With ?dry_run=true nothing is written and only the
+// validation report is returned; ?scope=controls,balances restores only those parts.
 func (a *API) handleRestore(w http.ResponseWriter, r *http.Request) {
-  var snap map[string]any
-  if err := json.NewDecoder(r.Body).Decode(&snap); err != nil { http.Error(w, "bad json", 400); return }
-  if err := a.led.Restore(r.Context(), snap); err != nil { http.Error(w, err.Error(), 500); return }
-  writeJSON(w, 200, map[string]any{"status":"ok"})
+  body := r.Body
+  ndjson := r.URL.Query().Get("format") == "ndjson" || strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-ndjson")
+  if src := r.URL.Query().Get("src"); src != "" {
+    loc, err := a.store.Parse(src, "")
+    if err != nil { writeError(w, r, err, 400); return }
+    rc, err := a.store.Get(r.Context(), loc)
+    if err != nil { writeError(w, r, err, http.StatusBadGateway); return }
+    defer rc.Close()
+    body = rc
+    ndjson = ndjson || strings.HasSuffix(loc.Key, ".ndjson")
+  }
+
+  opts := ledger.RestoreOptions{DryRun: r.URL.Query().Get("dry_run") == "true"}
+  if sc := r.URL.Query().Get("scope"); sc != "" {
+    scope, err := ledger.ParseRestoreScope(sc)
+    if err != nil { writeError(w, r, err, 400); return }
+    opts.Scope = scope
+  }
+  if !opts.DryRun && a.led.TwoPersonRule() {
+    a.requestRestoreApproval(w, r, body, ndjson, opts)
+    return
+  }
+  var report *ledger.RestoreReport
+  var err error
+  if ndjson {
+    report, err = a.led.RestoreNDJSON(r.Context(), body, opts)
+  } else {
+    var snap map[string]any
+    if err := json.NewDecoder(body).Decode(&snap); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+    report, err = a.led.Restore(r.Context(), snap, opts)
+  }
+  if err != nil { writeRestoreError(w, r, err, report); return }
+  writeJSON(w, 200, report)
+}
+
+// requestRestoreApproval holds the restore under the two-person rule: the
+// snapshot is validated and stored, and a second operator approves it.
+func (a *API) requestRestoreApproval(w http.ResponseWriter, r *http.Request, body io.Reader, ndjson bool, opts ledger.RestoreOptions) {
+  actor := actorFor(r, r.URL.Query().Get("actor"))
+  if actor == "" { writeValidationProblem(w, r, FieldError{Field: "actor", Message: "is required while restores need approval"}); return }
+  snapshot, err := io.ReadAll(body)
+  if err != nil { writeProblem(w, r, 400, CodeInvalidRequest, "reading snapshot: "+err.Error()); return }
+  ap, report, err := a.led.RequestRestoreApproval(r.Context(), snapshot, ndjson, opts, actor, r.URL.Query().Get("reason"))
+  if err != nil { writeRestoreError(w, r, err, report); return }
+  writeJSON(w, http.StatusAccepted, ap)
+}
+
+// writeRestoreError includes the validation report with a bad snapshot.
+func writeRestoreError(w http.ResponseWriter, r *http.Request, err error, report *ledger.RestoreReport) {
+  if ledger.IsBadSnapshot(err) && report != nil {
+    status, code := problemFor(err, 400)
+    writeProblemBody(w, status, struct {
+      Problem
+      Report *ledger.RestoreReport `json:"report"`
+    }{newProblem(r, status, code, err.Error()), report})
+    return
+  }
+  writeError(w, r, err, 500)
+}
+
+type SnapshotDiffRequest struct {
+  Before map[string]any `json:"before" validate:"required"`
+  After map[string]any `json:"after"` // omitted = current state
+}
+
+func (a *API) handleSnapshotDiff(w http.ResponseWriter, r *http.Request) {
+  var req SnapshotDiffRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  if !validRequest(w, r, req) { return }
+  // compare in the current format so older snapshots diff the way they would restore
+  before, err := ledger.UpgradeSnapshot(req.Before)
+  if err != nil { writeError(w, r, err, 400); return }
+  if req.After != nil {
+    after, err := ledger.UpgradeSnapshot(req.After)
+    if err != nil { writeError(w, r, err, 400); return }
+    writeJSON(w, 200, ledger.DiffSnapshots(before, after))
+    return
+  }
+  d, err := a.led.DiffWithCurrent(r.Context(), before)
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, d)
 }
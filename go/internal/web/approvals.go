@@ -0,0 +1,63 @@
+package web
+
+import (
+  "encoding/json"
+  "net/http"
+  "strconv"
+
+  "github.com/go-chi/chi/v5"
+
+  "time-ledger-sim/go/internal/ledger"
+)
+
+// --- two-person rule approvals ---
+
+func (a *API) handleListApprovals(w http.ResponseWriter, r *http.Request) {
+  q := r.URL.Query()
+  limit := 100
+  if s := q.Get("limit"); s != "" {
+    if n, err := strconv.Atoi(s); err == nil { limit = n }
+  }
+  aps, err := a.led.ListApprovals(r.Context(), q.Get("status"), limit)
+  if err != nil { writeError(w, r, err, 500); return }
+  writeList(w, r, "approvals", aps)
+}
+
+func (a *API) handleGetApproval(w http.ResponseWriter, r *http.Request) {
+  ap, err := a.led.GetApproval(r.Context(), chi.URLParam(r, "approval_id"))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, ap)
+}
+
+type DecideApprovalRequest struct {
+  Actor string `json:"actor" validate:"required"`
+  Reason string `json:"reason"`
+}
+
+// handleDecideApproval approves or rejects. Deciding on a restore needs the
+// admin key, like the restore itself.
+func (a *API) handleDecideApproval(approve bool) http.HandlerFunc {
+  return func(w http.ResponseWriter, r *http.Request) {
+    id := chi.URLParam(r, "approval_id")
+    var req DecideApprovalRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+    req.Actor = actorFor(r, req.Actor)
+    if !validRequest(w, r, req) { return }
+
+    ap, err := a.led.GetApproval(r.Context(), id)
+    if err != nil { writeError(w, r, err, 500); return }
+    decide := func(w http.ResponseWriter, r *http.Request) {
+      var out *ledger.Approval
+      var err error
+      if approve {
+        out, err = a.led.ApproveApproval(r.Context(), id, req.Actor, req.Reason)
+      } else {
+        out, err = a.led.RejectApproval(r.Context(), id, req.Actor, req.Reason)
+      }
+      if err != nil { writeError(w, r, err, 500); return }
+      writeJSON(w, 200, out)
+    }
+    if ap.Kind == ledger.ApprovalRestore { decide = a.admin(decide) }
+    decide(w, r)
+  }
+}
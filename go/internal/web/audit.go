@@ -0,0 +1,173 @@
+package web
+
+import (
+  "bytes"
+  "context"
+  "crypto/sha256"
+  "encoding/hex"
+  "encoding/json"
+  "hash"
+  "io"
+  "net/http"
+  "time"
+
+  "github.com/go-chi/chi/v5"
+  "github.com/go-chi/chi/v5/middleware"
+
+  "time-ledger-sim/go/internal/auth"
+  "time-ledger-sim/go/internal/ledger"
+  "time-ledger-sim/go/internal/logging"
+  "time-ledger-sim/go/internal/util"
+)
+
+const (
+  auditActorSniffBytes = 64 << 10 // body prefix searched for "actor"
+  auditMaxDrainBytes = 8 << 20 // unread body hashed after the handler returns
+)
+
+// callRecord collects what the audit middleware can only learn downstream.
+type callRecord struct {
+  identity *auth.Identity
+}
+
+type callRecordKey struct{}
+
+// noteIdentity lets AuthMiddleware tell the audit middleware (which runs
+// outside it, to also see 401s) who the caller is.
+func noteIdentity(ctx context.Context, id *auth.Identity) {
+  if rec, ok := ctx.Value(callRecordKey{}).(*callRecord); ok { rec.identity = id }
+}
+
+// AuditMiddleware records every mutating call (POST/PUT/PATCH/DELETE) in the
+// audit log with its route, actor, credential ID, body hash and final status,
+// including calls that are rejected or fail. Recording errors are logged and
+// never change the response.
+func (a *API) AuditMiddleware(next http.Handler) http.Handler {
+  return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+    switch r.Method {
+    case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+    default:
+      next.ServeHTTP(w, r)
+      return
+    }
+    start := time.Now()
+    rec := &callRecord{}
+    body := &auditBody{ReadCloser: r.Body, hash: sha256.New()}
+    r.Body = body
+    ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+    next.ServeHTTP(ww, r.WithContext(context.WithValue(r.Context(), callRecordKey{}, rec)))
+
+    truncated := body.drain()
+    status := ww.Status()
+    if status == 0 { status = http.StatusOK }
+    route := r.URL.Path
+    if rc := chi.RouteContext(r.Context()); rc != nil && rc.RoutePattern() != "" { route = rc.RoutePattern() }
+    call := ledger.APICall{
+      Method: r.Method,
+      Route: route,
+      Path: r.URL.Path,
+      Actor: auditActor(r, rec, body.prefix.Bytes()),
+      KeyID: credentialID(r, rec),
+      PayloadHash: hex.EncodeToString(body.hash.Sum(nil)),
+      PayloadBytes: body.n,
+      PayloadTruncated: truncated,
+      Status: status,
+      DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+      RequestID: logging.RequestID(r.Context()),
+    }
+    if err := a.led.RecordAPICall(context.WithoutCancel(r.Context()), call); err != nil {
+      a.log.WarnContext(r.Context(), "api call audit failed", "route", route, "err", err.Error())
+    }
+  })
+}
+
+// auditBody hashes the request body as the handler reads it and keeps a prefix
+// for actor extraction.
+type auditBody struct {
+  io.ReadCloser
+  hash hash.Hash
+  prefix bytes.Buffer
+  n int64
+}
+
+func (b *auditBody) Read(p []byte) (int, error) {
+  n, err := b.ReadCloser.Read(p)
+  if n > 0 {
+    b.hash.Write(p[:n])
+    if room := auditActorSniffBytes - b.prefix.Len(); room > 0 { b.prefix.Write(p[:min(n, room)]) }
+    b.n += int64(n)
+  }
+  return n, err
+}
+
+// drain hashes what the handler left unread (e.g. a request rejected before its
+// body was decoded), up to auditMaxDrainBytes. It reports whether more remained.
+func (b *auditBody) drain() bool {
+  n, _ := io.Copy(io.Discard, io.LimitReader(b, auditMaxDrainBytes))
+  if n < auditMaxDrainBytes { return false }
+  var one [1]byte
+  m, _ := b.ReadCloser.Read(one[:])
+  return m > 0
+}
+
+// auditActor prefers the authenticated caller, then the actor the client named
+// in the body or query, so rejected calls are still attributed.
+func auditActor(r *http.Request, rec *callRecord, prefix []byte) string {
+  if rec.identity != nil { return rec.identity.Actor }
+  var body struct{ Actor string `json:"actor"` }
+  if json.Unmarshal(prefix, &body) == nil && body.Actor != "" { return body.Actor }
+  return r.URL.Query().Get("actor")
+}
+
+// credentialID names the credential presented without revealing it: the OIDC
+// subject, or a fingerprint of the admin key.
+func credentialID(r *http.Request, rec *callRecord) string {
+  if rec.identity != nil { return "oidc:" + rec.identity.Subject }
+  if k := r.Header.Get("X-Admin-Key"); k != "" {
+    sum := sha256.Sum256([]byte(k))
+    return "admin-key:" + hex.EncodeToString(sum[:4])
+  }
+  return ""
+}
+
+// auditSince parses ?since (zero when absent), writing a problem when invalid.
+func auditSince(w http.ResponseWriter, r *http.Request) (time.Time, bool) { return timeQuery(w, r, "since") }
+
+// timeQuery parses the RFC 3339 query parameter name (zero when absent),
+// writing a validation problem when it is malformed.
+func timeQuery(w http.ResponseWriter, r *http.Request, name string) (time.Time, bool) {
+  q := r.URL.Query().Get(name)
+  if q == "" { return time.Time{}, true }
+  t, err := time.Parse(time.RFC3339, q)
+  if err != nil {
+    writeValidationProblem(w, r, FieldError{Field: name, Message: "must be an RFC 3339 time"})
+    return time.Time{}, false
+  }
+  return t, true
+}
+
+func (a *API) handleVerifyAudit(w http.ResponseWriter, r *http.Request) {
+  since, ok := auditSince(w, r)
+  if !ok { return }
+  v, err := a.led.VerifyAudit(r.Context(), since)
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, v)
+}
+
+// handleExportAudit streams the audit trail with signatures; see
+// ledger.WriteAuditExport.
+func (a *API) handleExportAudit(w http.ResponseWriter, r *http.Request) {
+  since, ok := auditSince(w, r)
+  if !ok { return }
+  w.Header().Set("Content-Type", "application/x-ndjson")
+  if err := a.led.WriteAuditExport(r.Context(), w, since); err != nil {
+    // headers are already sent; all we can do is log and cut the stream short
+    a.log.Warn("audit export failed", "err", err.Error())
+  }
+}
+
+func (a *API) handleListAPICalls(w http.ResponseWriter, r *http.Request) {
+  calls, err := a.led.ListAPICalls(r.Context(), util.QueryInt(r, "limit", 100))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeList(w, r, "calls", calls)
+}
@@ -0,0 +1,56 @@
+package web
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"time-ledger-sim/go/internal/auth"
+)
+
+func TestAuditBodyHashesUnreadRemainder(t *testing.T) {
+	payload := `{"actor":"alice","amount_units":5}`
+	r := httptest.NewRequest("POST", "/v1/transfers?actor=bob", strings.NewReader(payload))
+	body := &auditBody{ReadCloser: r.Body, hash: sha256.New()}
+
+	// the handler reads only part of the body before rejecting the request
+	if _, err := io.ReadFull(body, make([]byte, 10)); err != nil {
+		t.Fatal(err)
+	}
+	if body.drain() {
+		t.Fatal("small body reported as truncated")
+	}
+	sum := sha256.Sum256([]byte(payload))
+	if got := hex.EncodeToString(body.hash.Sum(nil)); got != hex.EncodeToString(sum[:]) {
+		t.Fatalf("hash = %s", got)
+	}
+	if body.n != int64(len(payload)) {
+		t.Fatalf("bytes = %d", body.n)
+	}
+	if got := auditActor(r, &callRecord{}, body.prefix.Bytes()); got != "alice" {
+		t.Fatalf("actor = %q", got)
+	}
+}
+
+func TestAuditActorAndCredential(t *testing.T) {
+	r := httptest.NewRequest("POST", "/v1/zones?actor=bob", nil)
+	r.Header.Set("X-Admin-Key", "s3cret")
+	if got := auditActor(r, &callRecord{}, []byte("not json")); got != "bob" {
+		t.Fatalf("actor = %q", got)
+	}
+	id := credentialID(r, &callRecord{})
+	if !strings.HasPrefix(id, "admin-key:") || strings.Contains(id, "s3cret") {
+		t.Fatalf("key id = %q", id)
+	}
+
+	rec := &callRecord{identity: &auth.Identity{Subject: "u-1", Actor: "carol"}}
+	if got := auditActor(r, rec, nil); got != "carol" {
+		t.Fatalf("actor = %q", got)
+	}
+	if got := credentialID(r, rec); got != "oidc:u-1" {
+		t.Fatalf("key id = %q", got)
+	}
+}
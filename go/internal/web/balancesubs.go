@@ -0,0 +1,112 @@
+package web
+
+import (
+  "encoding/json"
+  "fmt"
+  "net/http"
+  "time"
+
+  "github.com/go-chi/chi/v5"
+
+  "time-ledger-sim/go/internal/ledger"
+)
+
+// --- balance subscriptions (change and threshold notifications) ---
+
+// BalanceStreamer streams balance subscriptions' notifications as they are
+// posted (messaging.BalanceHub).
+type BalanceStreamer interface {
+  Watch(sub ledger.BalanceSubscription) (<-chan ledger.BalanceNotification, func())
+}
+
+// balanceKeepalive is how often an idle stream gets an SSE comment; each one
+// also ends the stream if its subscription was deleted.
+const balanceKeepalive = 15 * time.Second
+
+type CreateBalanceSubscriptionRequest struct {
+  AccountID string `json:"account_id" validate:"required"`
+  Trigger string `json:"trigger" validate:"omitempty,oneof=CHANGE CROSS"` // default CHANGE
+  ThresholdUnits *int64 `json:"threshold_units"` // CROSS only
+  // WebhookURL gets each notification as a signed POST (admin key
+  // required); without one the subscription is only streamed.
+  WebhookURL string `json:"webhook_url"`
+  Actor string `json:"actor" validate:"required"`
+  Reason string `json:"reason"`
+}
+
+func (a *API) handleCreateBalanceSubscription(w http.ResponseWriter, r *http.Request) {
+  var req CreateBalanceSubscriptionRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  req.Actor = actorFor(r, req.Actor)
+  if !validRequest(w, r, req) { return }
+  if req.WebhookURL != "" && (a.adminKey == "" || r.Header.Get("X-Admin-Key") != a.adminKey) {
+    writeProblem(w, r, http.StatusForbidden, CodeForbidden, "webhook_url requires the admin key")
+    return
+  }
+  s, err := a.led.CreateBalanceSubscription(r.Context(), ledger.CreateBalanceSubscriptionInput{
+    AccountID: req.AccountID, Trigger: req.Trigger, ThresholdUnits: req.ThresholdUnits, WebhookURL: req.WebhookURL,
+    Actor: req.Actor, Reason: req.Reason,
+  })
+  if err != nil { writeError(w, r, err, 400); return }
+  writeJSON(w, http.StatusCreated, s)
+}
+
+func (a *API) handleListBalanceSubscriptions(w http.ResponseWriter, r *http.Request) {
+  subs, err := a.led.ListBalanceSubscriptions(r.Context(), r.URL.Query().Get("account_id"))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeList(w, r, "subscriptions", subs)
+}
+
+func (a *API) handleGetBalanceSubscription(w http.ResponseWriter, r *http.Request) {
+  s, err := a.led.GetBalanceSubscription(r.Context(), chi.URLParam(r, "subscription_id"))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, s)
+}
+
+// handleDeleteBalanceSubscription takes actor/reason as query params (DELETE has no body).
+func (a *API) handleDeleteBalanceSubscription(w http.ResponseWriter, r *http.Request) {
+  q := r.URL.Query()
+  actor := actorFor(r, q.Get("actor"))
+  if actor == "" { writeValidationProblem(w, r, FieldError{Field: "actor", Message: "is required"}); return }
+  s, err := a.led.DeleteBalanceSubscription(r.Context(), chi.URLParam(r, "subscription_id"), actor, q.Get("reason"))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, s)
+}
+
+// handleStreamBalanceSubscription streams the subscription's notifications
+// as server-sent events ("event: balance", id the TRANSFER_POSTED event's)
+// until the client goes away, the subscription is deleted or the app stops.
+func (a *API) handleStreamBalanceSubscription(w http.ResponseWriter, r *http.Request) {
+  if a.balances == nil { writeProblem(w, r, http.StatusNotFound, CodeNotFound, "balance streaming not available"); return }
+  flusher, ok := w.(http.Flusher)
+  if !ok { writeProblem(w, r, http.StatusNotAcceptable, CodeNotAcceptable, "streaming not supported"); return }
+  id := chi.URLParam(r, "subscription_id")
+  s, err := a.led.GetBalanceSubscription(r.Context(), id)
+  if err != nil { writeError(w, r, err, 500); return }
+
+  notes, stop := a.balances.Watch(*s)
+  defer stop()
+  w.Header().Set("Content-Type", mediaEventStream)
+  w.Header().Set("Cache-Control", "no-cache")
+  w.Header().Set("X-Accel-Buffering", "no")
+  w.WriteHeader(http.StatusOK)
+  fmt.Fprint(w, ": subscribed\n\n")
+  flusher.Flush()
+
+  tick := time.NewTicker(balanceKeepalive)
+  defer tick.Stop()
+  for {
+    select {
+    case <-r.Context().Done():
+      return
+    case n, ok := <-notes:
+      if !ok { return }
+      data, _ := json.Marshal(n)
+      fmt.Fprintf(w, "event: balance\nid: %s\ndata: %s\n\n", n.EventID, data)
+    case <-tick.C:
+      if _, err := a.led.GetBalanceSubscription(r.Context(), id); ledger.IsBalanceSubscriptionNotFound(err) { return }
+      fmt.Fprint(w, ": keepalive\n\n")
+    }
+    flusher.Flush()
+  }
+}
@@ -0,0 +1,95 @@
+package web
+
+import (
+  "encoding/json"
+  "net/http"
+  "strconv"
+  "strings"
+  "time"
+
+  "time-ledger-sim/go/internal/ledger"
+)
+
+// --- sim clock + random seed (admin) ---
+
+func (a *API) handleGetClock(w http.ResponseWriter, r *http.Request) {
+  st, err := a.led.ClockState()
+  if err != nil { writeError(w, r, err, 409); return }
+  writeJSON(w, 200, st)
+}
+
+type AdjustClockRequest struct {
+  At time.Time `json:"at"` // freeze: optional instant
+  By string `json:"by" validate:"omitempty,duration"` // advance: Go duration, e.g. "1h30m"
+  Actor string `json:"actor"`
+  Reason string `json:"reason"`
+}
+
+// handleAdjustClock serves /v1/sim/clock/{freeze|resume|advance|reset}; the op comes from the route.
+func (a *API) handleAdjustClock(op string) http.HandlerFunc {
+  return func(w http.ResponseWriter, r *http.Request) {
+    var req AdjustClockRequest
+    if r.ContentLength != 0 {
+      if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+    }
+    req.Actor = actorFor(r, req.Actor)
+    if req.Actor == "" { req.Actor = "admin" }
+    in := ledger.ClockAdjustment{Op: op, At: req.At, Actor: req.Actor, Reason: req.Reason}
+    if !validRequest(w, r, req) { return }
+    if op == ledger.ClockOpAdvance {
+      if req.By == "" { writeValidationProblem(w, r, FieldError{Field: "by", Message: "is required for advance"}); return }
+      in.By, _ = time.ParseDuration(strings.TrimSpace(req.By))
+    }
+    st, err := a.led.AdjustClock(r.Context(), in)
+    if err != nil {
+      writeError(w, r, err, 400)
+      return
+    }
+    writeJSON(w, 200, st)
+  }
+}
+
+type SetClockRateRequest struct {
+  Rate float64 `json:"rate" validate:"gt=0,max=3600"` // virtual seconds per real second, e.g. 60 = 1 minute per second
+  Actor string `json:"actor"`
+  Reason string `json:"reason"`
+}
+
+func (a *API) handleSetClockRate(w http.ResponseWriter, r *http.Request) {
+  var req SetClockRateRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  req.Actor = actorFor(r, req.Actor)
+  if req.Actor == "" { req.Actor = "admin" }
+  if !validRequest(w, r, req) { return }
+  st, err := a.led.AdjustClock(r.Context(), ledger.ClockAdjustment{Op: ledger.ClockOpRate, Rate: req.Rate, Actor: req.Actor, Reason: req.Reason})
+  if err != nil {
+    writeError(w, r, err, 400)
+    return
+  }
+  writeJSON(w, 200, st)
+}
+
+type ReseedRequest struct {
+  Seed uint64 `json:"seed"`
+  Actor string `json:"actor"`
+  Reason string `json:"reason"`
+}
+
+func (a *API) handleReseed(w http.ResponseWriter, r *http.Request) {
+  var req ReseedRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  req.Actor = actorFor(r, req.Actor)
+  if req.Actor == "" { req.Actor = "admin" }
+  a.led.ReseedRandom(r.Context(), req.Seed, req.Actor, req.Reason)
+  writeJSON(w, 200, map[string]any{"seed": req.Seed})
+}
+
+func (a *API) handleClockSkewReport(w http.ResponseWriter, r *http.Request) {
+  limit := 50
+  if s := r.URL.Query().Get("limit"); s != "" {
+    if n, err := strconv.Atoi(s); err == nil { limit = n }
+  }
+  rep, err := a.led.ClockSkewReport(r.Context(), limit)
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, rep)
+}
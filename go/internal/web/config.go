@@ -0,0 +1,33 @@
+package web
+
+import (
+  "context"
+  "net/http"
+)
+
+const reloadPath = "/v1/sim/config/reload"
+
+// ReloadReport is the result of re-reading the process config.
+type ReloadReport struct {
+  Changed []string `json:"changed"` // tunables that took new values
+  RestartRequired []string `json:"restart_required"` // other changed settings; they apply after a restart
+  Tunables any `json:"tunables"` // now in effect
+}
+
+// Reloader re-reads config and applies what can change at runtime (implemented by app.App).
+type Reloader interface {
+  Reload(ctx context.Context) (*ReloadReport, error)
+  Tunables() any
+}
+
+func (a *API) handleGetConfig(w http.ResponseWriter, r *http.Request) {
+  if a.reload == nil { writeProblem(w, r, http.StatusNotFound, CodeNotFound, "config reload not available"); return }
+  writeJSON(w, 200, a.reload.Tunables())
+}
+
+func (a *API) handleReloadConfig(w http.ResponseWriter, r *http.Request) {
+  if a.reload == nil { writeProblem(w, r, http.StatusNotFound, CodeNotFound, "config reload not available"); return }
+  rep, err := a.reload.Reload(r.Context())
+  if err != nil { writeProblem(w, r, http.StatusBadRequest, CodeInvalidConfig, err.Error()); return }
+  writeJSON(w, 200, rep)
+}
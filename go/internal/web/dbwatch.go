@@ -0,0 +1,31 @@
+package web
+
+import (
+  "net/http"
+
+  "time-ledger-sim/go/internal/dbwatch"
+  "time-ledger-sim/go/internal/ledger"
+)
+
+// DBActivity is the admin view of database latency problems.
+type DBActivity struct {
+  SlowQueryMs int64 `json:"slow_query_ms"`
+  LongTxMs int64 `json:"long_tx_ms"`
+  SlowQueries []dbwatch.Event `json:"slow_queries"` // recent, newest first
+  LongTransactions []dbwatch.Event `json:"long_transactions"` // recent, newest first
+  OpenTransactions []ledger.OpenTransaction `json:"open_transactions"` // open now for longer than long_tx_ms
+}
+
+func (a *API) handleDBActivity(w http.ResponseWriter, r *http.Request) {
+  slow, longTx := a.dbwatch.Recent()
+  slowQuery, longTxAfter := a.dbwatch.Thresholds()
+  open, err := a.led.OpenTransactions(r.Context(), longTxAfter)
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, DBActivity{
+    SlowQueryMs: slowQuery.Milliseconds(),
+    LongTxMs: longTxAfter.Milliseconds(),
+    SlowQueries: slow,
+    LongTransactions: longTx,
+    OpenTransactions: open,
+  })
+}
@@ -0,0 +1,102 @@
+package web
+
+import (
+  "context"
+  "net/http"
+  "sync/atomic"
+  "time"
+)
+
+const drainPath = "/v1/sim/drain"
+
+// DrainReport is the result of draining the process before shutdown.
+type DrainReport struct {
+  Draining bool `json:"draining"`
+  OutboxPublished int `json:"outbox_published"` // events flushed by this drain
+  OutboxBacklog int64 `json:"outbox_backlog"` // still unpublished afterwards
+  DurationMs float64 `json:"duration_ms"`
+}
+
+// Drainer stops write intake and flushes pending work (implemented by app.App).
+type Drainer interface {
+  Drain(ctx context.Context) (*DrainReport, error)
+}
+
+// DrainGate counts in-flight mutating requests and, once closed, rejects new
+// ones with 503 so a drain can wait for the rest to finish. Reads still pass.
+type DrainGate struct {
+  closed atomic.Bool
+  inflight atomic.Int64
+}
+
+func (g *DrainGate) Close() { g.closed.Store(true) }
+
+func (g *DrainGate) Closed() bool { return g.closed.Load() }
+
+// Wait blocks until no mutating request is in flight.
+func (g *DrainGate) Wait(ctx context.Context) error {
+  t := time.NewTicker(20 * time.Millisecond)
+  defer t.Stop()
+  for g.inflight.Load() > 0 {
+    select {
+    case <-ctx.Done():
+      return ctx.Err()
+    case <-t.C:
+    }
+  }
+  return nil
+}
+
+// Enter counts a mutating request in flight and reports whether the gate is
+// still open; the caller calls Leave either way. Counting comes first, so a
+// drain that closed the gate either sees the request in flight or the request
+// sees the gate closed. The gRPC server shares the gate through these.
+func (g *DrainGate) Enter() bool {
+  g.inflight.Add(1)
+  return !g.closed.Load()
+}
+
+func (g *DrainGate) Leave() { g.inflight.Add(-1) }
+
+func (g *DrainGate) Middleware(next http.Handler) http.Handler {
+  return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+    if !isWrite(r) || r.URL.Path == drainPath {
+      next.ServeHTTP(w, r)
+      return
+    }
+    open := g.Enter()
+    defer g.Leave()
+    if !open {
+      w.Header().Set("Retry-After", "5")
+      writeProblem(w, r, http.StatusServiceUnavailable, CodeDraining, "instance is draining; retry against another instance")
+      return
+    }
+    next.ServeHTTP(w, r)
+  })
+}
+
+// HoldWrites rejects mutating requests with 503 until ready reports true, so a
+// process that started without messaging still serves reads. Draining and
+// config reload stay available.
+func HoldWrites(ready func() bool) func(http.Handler) http.Handler {
+  return func(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+      if isWrite(r) && r.URL.Path != drainPath && r.URL.Path != reloadPath && !ready() {
+        w.Header().Set("Retry-After", "5")
+        writeProblem(w, r, http.StatusServiceUnavailable, CodeMessagingUnavailable, "messaging is not connected yet; reads are served, writes are held")
+        return
+      }
+      next.ServeHTTP(w, r)
+    })
+  }
+}
+
+func isWrite(r *http.Request) bool {
+  return r.Method != http.MethodGet && r.Method != http.MethodHead && r.Method != http.MethodOptions
+}
+
+func (a *API) handleDrain(w http.ResponseWriter, r *http.Request) {
+  rep, err := a.drain.Drain(r.Context())
+  if err != nil { writeError(w, r, err, http.StatusServiceUnavailable); return }
+  writeJSON(w, 200, rep)
+}
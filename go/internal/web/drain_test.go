@@ -0,0 +1,80 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDrainGate(t *testing.T) {
+	var g DrainGate
+	release := make(chan struct{})
+	entered := make(chan struct{})
+	h := g.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.URL.Path == "/v1/transfers" {
+			close(entered)
+			<-release
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// a write in flight when the drain starts
+	inflight := httptest.NewRecorder()
+	go h.ServeHTTP(inflight, httptest.NewRequest("POST", "/v1/transfers", nil))
+	<-entered
+	g.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := g.Wait(ctx); err == nil {
+		t.Fatal("Wait returned with a write in flight")
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("POST", "/v1/zones/zone-eu/controls", nil))
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("write while draining = %d", rec.Code)
+	}
+	for _, req := range []*http.Request{httptest.NewRequest("GET", "/v1/zones", nil), httptest.NewRequest("POST", drainPath, nil)} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s %s while draining = %d", req.Method, req.URL.Path, rec.Code)
+		}
+	}
+
+	close(release)
+	if err := g.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHoldWrites(t *testing.T) {
+	ready := false
+	h := HoldWrites(func() bool { return ready })(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(method, path string) int {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec.Code
+	}
+
+	if c := serve("POST", "/v1/transfers"); c != http.StatusServiceUnavailable {
+		t.Fatalf("write before ready = %d", c)
+	}
+	for _, p := range []string{"/v1/zones", "/v1/balances"} {
+		if c := serve("GET", p); c != http.StatusOK {
+			t.Fatalf("read %s before ready = %d", p, c)
+		}
+	}
+	if c := serve("POST", reloadPath); c != http.StatusOK {
+		t.Fatalf("config reload before ready = %d", c)
+	}
+	ready = true
+	if c := serve("POST", "/v1/transfers"); c != http.StatusOK {
+		t.Fatalf("write after ready = %d", c)
+	}
+}
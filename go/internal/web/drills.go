@@ -0,0 +1,70 @@
+package web
+
+import (
+  "encoding/json"
+  "io"
+  "net/http"
+  "strings"
+
+  "github.com/go-chi/chi/v5"
+
+  "time-ledger-sim/go/internal/ledger"
+  "time-ledger-sim/go/internal/util"
+)
+
+// --- training drills (admin: the game master) ---
+
+func (a *API) handleStartDrill(w http.ResponseWriter, r *http.Request) {
+  body, err := io.ReadAll(io.LimitReader(r.Body, maxScenarioBytes))
+  if err != nil { writeProblem(w, r, 400, CodeInvalidRequest, "bad body"); return }
+  in, err := ledger.ParseDrill(body, strings.Contains(r.Header.Get("Content-Type"), "yaml"))
+  if err != nil { writeError(w, r, err, 400); return }
+  in.Actor = actorFor(r, in.Actor)
+  if in.Actor == "" { writeValidationProblem(w, r, FieldError{Field: "actor", Message: "is required"}); return }
+  d, err := a.scenarios.StartDrill(r.Context(), *in)
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, http.StatusCreated, d)
+}
+
+func (a *API) handleListDrills(w http.ResponseWriter, r *http.Request) {
+  list, err := a.led.ListDrills(r.Context(), util.QueryInt(r, "limit", 50))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeList(w, r, "drills", list)
+}
+
+func (a *API) handleGetDrill(w http.ResponseWriter, r *http.Request) {
+  d, err := a.led.GetDrill(r.Context(), chi.URLParam(r, "drill_id"))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, d)
+}
+
+// handleDrillInject makes an ad hoc inject in a running drill.
+func (a *API) handleDrillInject(w http.ResponseWriter, r *http.Request) {
+  var in ledger.DrillInject
+  if err := json.NewDecoder(r.Body).Decode(&in); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  d, err := a.scenarios.Inject(r.Context(), chi.URLParam(r, "drill_id"), in)
+  if err != nil { writeError(w, r, err, 400); return }
+  writeJSON(w, 200, d)
+}
+
+type EndDrillRequest struct {
+  Actor string `json:"actor" validate:"required"`
+}
+
+// handleEndDrill ends the drill, revealing its audit entries, and answers
+// with the debrief.
+func (a *API) handleEndDrill(w http.ResponseWriter, r *http.Request) {
+  var req EndDrillRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  req.Actor = actorFor(r, req.Actor)
+  if !validRequest(w, r, req) { return }
+  db, err := a.scenarios.EndDrill(r.Context(), chi.URLParam(r, "drill_id"), req.Actor)
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, db)
+}
+
+func (a *API) handleDrillDebrief(w http.ResponseWriter, r *http.Request) {
+  db, err := a.led.DrillDebrief(r.Context(), chi.URLParam(r, "drill_id"))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, db)
+}
@@ -0,0 +1,35 @@
+package web
+
+import (
+  "net/http"
+  "strings"
+
+  "time-ledger-sim/go/internal/ledger"
+)
+
+// notModified sets the ETag for v and reports whether the client already has it
+// (If-None-Match), in which case it has written 304 and the handler should stop.
+// Cache-Control: no-cache makes browsers revalidate on every poll instead of
+// guessing a freshness lifetime.
+//
+// Handlers read the version before the rows, so a concurrent write can only make
+// the tag older than the body; the next poll then fetches again, never misses.
+func notModified(w http.ResponseWriter, r *http.Request, v ledger.ListVersion) bool {
+  tag := v.ETag()
+  w.Header().Set("ETag", tag)
+  w.Header().Set("Cache-Control", "no-cache")
+  if !etagMatches(r.Header.Get("If-None-Match"), tag) { return false }
+  w.WriteHeader(http.StatusNotModified)
+  return true
+}
+
+// etagMatches applies the weak comparison If-None-Match requires.
+func etagMatches(header, tag string) bool {
+  if header == "" { return false }
+  tag = strings.TrimPrefix(tag, "W/")
+  for _, t := range strings.Split(header, ",") {
+    t = strings.TrimSpace(t)
+    if t == "*" || strings.TrimPrefix(t, "W/") == tag { return true }
+  }
+  return false
+}
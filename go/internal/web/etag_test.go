@@ -0,0 +1,42 @@
+package web
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"time-ledger-sim/go/internal/ledger"
+)
+
+func TestNotModified(t *testing.T) {
+	v := ledger.ListVersion{Count: 10, UpdatedAt: time.Date(2026, 1, 2, 3, 4, 5, 6000, time.UTC)}
+	tag := v.ETag()
+
+	w := httptest.NewRecorder()
+	if notModified(w, httptest.NewRequest("GET", "/v1/zones", nil), v) {
+		t.Fatal("no If-None-Match must not be a 304")
+	}
+	if w.Header().Get("ETag") != tag || w.Header().Get("Cache-Control") != "no-cache" {
+		t.Fatalf("headers %v", w.Header())
+	}
+
+	for _, inm := range []string{tag, `"x", ` + tag, tag[2:], "*"} {
+		r := httptest.NewRequest("GET", "/v1/zones", nil)
+		r.Header.Set("If-None-Match", inm)
+		w := httptest.NewRecorder()
+		if !notModified(w, r, v) || w.Code != 304 {
+			t.Fatalf("If-None-Match %s: expected 304, got %d", inm, w.Code)
+		}
+	}
+
+	changed := v
+	changed.Count++
+	if changed.ETag() == tag {
+		t.Fatal("count must be part of the tag")
+	}
+	r := httptest.NewRequest("GET", "/v1/zones", nil)
+	r.Header.Set("If-None-Match", tag)
+	if notModified(httptest.NewRecorder(), r, changed) {
+		t.Fatal("stale tag must not be a 304")
+	}
+}
@@ -0,0 +1,59 @@
+package web
+
+import (
+  "encoding/json"
+  "fmt"
+  "net/http"
+  "time"
+
+  "github.com/go-chi/chi/v5"
+
+  "time-ledger-sim/go/internal/ledger"
+  "time-ledger-sim/go/internal/util"
+)
+
+// --- exports ---
+
+type StartExportRequest struct {
+  Dataset string `json:"dataset" validate:"required,oneof=transactions postings incidents audit"`
+  Format string `json:"format" validate:"omitempty,oneof=csv ndjson parquet"` // "" means csv; parquet for transactions and postings
+  ZoneID string `json:"zone_id"` // "": every zone
+  From *time.Time `json:"from"`
+  To *time.Time `json:"to"`
+  TTLHours int `json:"ttl_hours" validate:"min=0,max=168"` // 0 means 24
+  Actor string `json:"actor" validate:"required"`
+  Reason string `json:"reason"`
+}
+
+func (a *API) handleStartExport(w http.ResponseWriter, r *http.Request) {
+  var req StartExportRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  req.Actor = actorFor(r, req.Actor)
+  if !validRequest(w, r, req) { return }
+  job, err := a.led.StartExport(r.Context(), ledger.StartExportInput{
+    Dataset: req.Dataset, Format: req.Format, ZoneID: req.ZoneID, From: req.From, To: req.To,
+    TTL: time.Duration(req.TTLHours) * time.Hour, Actor: req.Actor, Reason: req.Reason,
+  })
+  if err != nil { writeError(w, r, err, 400); return }
+  writeJSON(w, http.StatusAccepted, job)
+}
+
+func (a *API) handleListExports(w http.ResponseWriter, r *http.Request) {
+  list, err := a.led.ListExports(r.Context(), util.QueryInt(r, "limit", 50))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeList(w, r, "exports", list)
+}
+
+// handleDownloadArtifact streams a finished job's artifact.
+func (a *API) handleDownloadArtifact(w http.ResponseWriter, r *http.Request) {
+  art, err := a.led.GetArtifact(r.Context(), chi.URLParam(r, "job_id"))
+  if err != nil { writeError(w, r, err, 500); return }
+  w.Header().Set("Content-Type", art.ContentType)
+  w.Header().Set("Content-Length", fmt.Sprint(art.Bytes))
+  w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", art.Filename))
+  w.Header().Set("Expires", art.ExpiresAt.UTC().Format(http.TimeFormat))
+  if err := a.led.WriteArtifact(r.Context(), w, art.JobID); err != nil {
+    // headers are already sent; all we can do is log and cut the stream short
+    a.log.Warn("artifact download failed", "job_id", art.JobID, "err", err.Error())
+  }
+}
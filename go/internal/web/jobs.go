@@ -0,0 +1,43 @@
+package web
+
+import (
+  "encoding/json"
+  "net/http"
+
+  "github.com/go-chi/chi/v5"
+
+  "time-ledger-sim/go/internal/ledger"
+  "time-ledger-sim/go/internal/util"
+)
+
+// --- background jobs ---
+
+func (a *API) handleListJobs(w http.ResponseWriter, r *http.Request) {
+  q := r.URL.Query()
+  list, err := a.led.ListJobs(r.Context(), ledger.JobFilter{
+    Kind: q.Get("kind"), Target: q.Get("target"), Status: q.Get("status"), Limit: util.QueryInt(r, "limit", 50),
+  })
+  if err != nil { writeError(w, r, err, 500); return }
+  writeList(w, r, "jobs", list)
+}
+
+func (a *API) handleGetJob(w http.ResponseWriter, r *http.Request) {
+  job, err := a.led.GetJob(r.Context(), chi.URLParam(r, "job_id"))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, job)
+}
+
+type CancelJobRequest struct {
+  Actor string `json:"actor" validate:"required"`
+  Reason string `json:"reason"`
+}
+
+func (a *API) handleCancelJob(w http.ResponseWriter, r *http.Request) {
+  var req CancelJobRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  req.Actor = actorFor(r, req.Actor)
+  if !validRequest(w, r, req) { return }
+  job, err := a.led.CancelJob(r.Context(), chi.URLParam(r, "job_id"), req.Actor, req.Reason)
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, job)
+}
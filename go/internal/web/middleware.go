@@ -1,47 +1,176 @@
 package web
 
 import (
+  "log/slog"
   "net/http"
+  "slices"
   "strings"
+  "sync/atomic"
+  "time"
+
+  "github.com/go-chi/chi/v5"
+  "github.com/go-chi/chi/v5/middleware"
+  "go.opentelemetry.io/otel/attribute"
+  "go.opentelemetry.io/otel/codes"
+  "go.opentelemetry.io/otel/propagation"
+  "go.opentelemetry.io/otel/trace"
+
+  "time-ledger-sim/go/internal/auth"
+  "time-ledger-sim/go/internal/logging"
+  "time-ledger-sim/go/internal/tracing"
 )
 
+// RequestIDMiddleware takes the caller's X-Request-Id (when well-formed) or
+// assigns one, echoes it on the response and puts it in the request context, where
+// logging adds it to slog lines, problems report it and the outbox carries it on.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+  return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+    id := r.Header.Get(logging.Header)
+    if !logging.ValidRequestID(id) { id = logging.NewRequestID() }
+    w.Header().Set(logging.Header, id)
+    next.ServeHTTP(w, r.WithContext(logging.WithRequestID(r.Context(), id)))
+  })
+}
+
+// TraceMiddleware starts a server span per request, continuing the caller's
+// traceparent. The span is renamed to the chi route once routing is done.
+// Probe and scrape endpoints are not traced.
+func TraceMiddleware(next http.Handler) http.Handler {
+  return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+    if r.URL.Path == "/livez" || r.URL.Path == "/readyz" || r.URL.Path == "/metrics" {
+      next.ServeHTTP(w, r)
+      return
+    }
+    ctx := tracing.Propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+    ctx, span := tracing.Start(ctx, r.Method, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(
+      attribute.String("http.request.method", r.Method),
+      attribute.String("url.path", r.URL.Path),
+      attribute.String("request_id", logging.RequestID(ctx)),
+    ))
+    defer span.End()
+    ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+    next.ServeHTTP(ww, r.WithContext(ctx))
+
+    status := ww.Status()
+    if status == 0 { status = http.StatusOK }
+    if rc := chi.RouteContext(r.Context()); rc != nil && rc.RoutePattern() != "" {
+      span.SetName(r.Method + " " + rc.RoutePattern())
+      span.SetAttributes(attribute.String("http.route", rc.RoutePattern()))
+    }
+    span.SetAttributes(attribute.Int("http.response.status_code", status))
+    if status >= 500 { span.SetStatus(codes.Error, http.StatusText(status)) }
+  })
+}
+
+// AccessLogMiddleware logs one structured line per request with status, size and
+// latency. Probe and scrape endpoints log at debug so they don't drown the rest.
+func AccessLogMiddleware(log *slog.Logger) func(http.Handler) http.Handler {
+  return func(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+      start := time.Now()
+      ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+      next.ServeHTTP(ww, r)
+
+      status := ww.Status()
+      if status == 0 { status = http.StatusOK }
+      level := slog.LevelInfo
+      switch {
+      case r.URL.Path == "/livez" || r.URL.Path == "/readyz" || r.URL.Path == "/metrics":
+        level = slog.LevelDebug // polled; /readyz logs its own transitions
+      case status >= 500:
+        level = slog.LevelError
+      }
+      route := ""
+      if rc := chi.RouteContext(r.Context()); rc != nil { route = rc.RoutePattern() }
+      log.Log(r.Context(), level, "http request",
+        "method", r.Method,
+        "path", r.URL.Path,
+        "route", route,
+        "status", status,
+        "bytes", ww.BytesWritten(),
+        "duration_ms", float64(time.Since(start).Microseconds())/1000,
+        "remote", r.RemoteAddr,
+      )
+    })
+  }
+}
+
 func CORSMiddleware(corsAllowOrigins string) func(http.Handler) http.Handler {
-  allowed := []string{}
+  return NewCORS(corsAllowOrigins).Middleware
+}
+
+// CORS answers cross-origin requests for a comma-separated origin list ("*"
+// allows any) that can be replaced while serving.
+type CORS struct {
+  policy atomic.Pointer[corsPolicy]
+}
+
+type corsPolicy struct {
+  allowed []string
+  allowAny bool
+}
+
+func NewCORS(corsAllowOrigins string) *CORS {
+  c := &CORS{}
+  c.Set(corsAllowOrigins)
+  return c
+}
+
+// Set replaces the allowed origins.
+func (c *CORS) Set(corsAllowOrigins string) {
+  p := &corsPolicy{}
   for _, o := range strings.Split(corsAllowOrigins, ",") {
     t := strings.TrimSpace(o)
-    if t != "" { allowed = append(allowed, t) }
+    if t == "" { continue }
+    if t == "*" { p.allowAny = true }
+    p.allowed = append(p.allowed, t)
   }
+  c.policy.Store(p)
+}
 
-  allowAny := false
-  for _, a := range allowed {
-    if a == "*" { allowAny = true; break }
-  }
+func (c *CORS) Middleware(next http.Handler) http.Handler {
+  return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+    origin := r.Header.Get("Origin")
+    if origin != "" {
+      p := c.policy.Load()
+      if p.allowAny || slices.Contains(p.allowed, origin) {
+        w.Header().Set("Access-Control-Allow-Origin", origin)
+      }
+      w.Header().Set("Vary", "Origin")
+      w.Header().Set("Access-Control-Allow-Methods", "GET,POST,DELETE,OPTIONS")
+      w.Header().Set("Access-Control-Allow-Headers", "Content-Type,X-Admin-Key,X-Tenant-Key,Authorization,If-None-Match,X-Request-Id,traceparent,tracestate")
+      w.Header().Set("Access-Control-Expose-Headers", "ETag,X-Request-Id")
+    }
+
+    if r.Method == http.MethodOptions {
+      w.WriteHeader(http.StatusNoContent)
+      return
+    }
+
+    next.ServeHTTP(w, r)
+  })
+}
 
+// AuthMiddleware requires a valid OIDC bearer token and puts the caller's identity
+// in the request context. A nil verifier (OIDC not configured) lets every request through.
+func AuthMiddleware(v *auth.Verifier) func(http.Handler) http.Handler {
   return func(next http.Handler) http.Handler {
+    if v == nil { return next }
     return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-      origin := r.Header.Get("Origin")
-      if origin != "" {
-        if allowAny {
-          w.Header().Set("Access-Control-Allow-Origin", origin)
-        } else {
-          for _, a := range allowed {
-            if origin == a {
-              w.Header().Set("Access-Control-Allow-Origin", origin)
-              break
-            }
-          }
-        }
-        w.Header().Set("Vary", "Origin")
-        w.Header().Set("Access-Control-Allow-Methods", "GET,POST,OPTIONS")
-        w.Header().Set("Access-Control-Allow-Headers", "Content-Type,X-Admin-Key")
+      raw, ok := auth.BearerToken(r.Header.Get("Authorization"))
+      if !ok {
+        w.Header().Set("WWW-Authenticate", `Bearer`)
+        writeProblem(w, r, http.StatusUnauthorized, CodeUnauthenticated, "missing bearer token")
+        return
       }
-
-      if r.Method == http.MethodOptions {
-        w.WriteHeader(http.StatusNoContent)
+      id, err := v.Verify(r.Context(), raw)
+      if err != nil {
+        w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+        writeProblem(w, r, http.StatusUnauthorized, CodeUnauthenticated, err.Error())
         return
       }
-
-      next.ServeHTTP(w, r)
+      noteIdentity(r.Context(), id)
+      next.ServeHTTP(w, r.WithContext(auth.WithIdentity(r.Context(), id)))
     })
   }
 }
@@ -0,0 +1,49 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"time-ledger-sim/go/internal/logging"
+)
+
+func TestRequestIDAndAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	log := logging.New(&buf, slog.LevelInfo)
+	h := RequestIDMiddleware(AccessLogMiddleware(log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeProblem(w, r, http.StatusNotFound, CodeNotFound, "nope")
+	})))
+
+	r := httptest.NewRequest("GET", "/v1/zones/x", nil)
+	r.Header.Set("X-Request-Id", "abc-123")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Header().Get("X-Request-Id") != "abc-123" {
+		t.Fatalf("request id not echoed: %v", w.Header())
+	}
+	var p Problem
+	_ = json.Unmarshal(w.Body.Bytes(), &p)
+	if p.RequestID != "abc-123" {
+		t.Fatalf("problem request_id %q", p.RequestID)
+	}
+	var line map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("access log %q: %v", buf.String(), err)
+	}
+	if line["request_id"] != "abc-123" || line["status"] != float64(404) || line["method"] != "GET" {
+		t.Fatalf("access log %v", line)
+	}
+
+	r = httptest.NewRequest("GET", "/v1/zones/x", nil)
+	r.Header.Set("X-Request-Id", "bad id")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if id := w.Header().Get("X-Request-Id"); id == "bad id" || !logging.ValidRequestID(id) {
+		t.Fatalf("malformed id should be replaced, got %q", id)
+	}
+}
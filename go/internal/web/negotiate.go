@@ -0,0 +1,186 @@
+package web
+
+import (
+  "encoding/csv"
+  "encoding/json"
+  "fmt"
+  "net/http"
+  "reflect"
+  "sort"
+  "strconv"
+  "strings"
+  "time"
+)
+
+// Media types endpoints can produce. JSON is the default.
+const (
+  mediaJSON = "application/json"
+  mediaNDJSON = "application/x-ndjson"
+  mediaCSV = "text/csv"
+  mediaMarkdown = "text/markdown"
+  mediaEventStream = "text/event-stream"
+)
+
+// CompressibleTypes are the response types the gzip middleware compresses.
+var CompressibleTypes = []string{
+  mediaJSON, "application/problem+json", mediaNDJSON, mediaCSV, mediaMarkdown,
+  "text/html", "text/css", "text/plain", "text/javascript", "application/javascript", "image/svg+xml",
+}
+
+// negotiate picks the offer the Accept header prefers (by q, then by the order
+// of offers). A missing or empty header accepts the first offer; "" means none
+// is acceptable.
+func negotiate(r *http.Request, offers ...string) string {
+  accept := strings.TrimSpace(r.Header.Get("Accept"))
+  if accept == "" { return offers[0] }
+  best, bestQ := "", 0.0
+  for _, offer := range offers {
+    if q := acceptQ(accept, offer); q > bestQ { best, bestQ = offer, q }
+  }
+  return best
+}
+
+// acceptQ returns the quality the Accept header gives typ, using the most
+// specific matching range.
+func acceptQ(accept, typ string) float64 {
+  q, specificity := 0.0, -1
+  for _, part := range strings.Split(accept, ",") {
+    fields := strings.Split(part, ";")
+    rng := strings.ToLower(strings.TrimSpace(fields[0]))
+    s := -1
+    switch {
+    case rng == typ:
+      s = 2
+    case strings.HasSuffix(rng, "/*") && strings.HasPrefix(typ, strings.TrimSuffix(rng, "*")):
+      s = 1
+    case rng == "*/*":
+      s = 0
+    }
+    if s <= specificity { continue }
+    rq := 1.0
+    for _, p := range fields[1:] {
+      k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+      if k == "q" {
+        if f, err := strconv.ParseFloat(v, 64); err == nil { rq = f }
+      }
+    }
+    q, specificity = rq, s
+  }
+  return q
+}
+
+// writeList writes rows (a slice) as {"<key>": rows} JSON, NDJSON (one row per
+// line) or CSV, as negotiated from Accept.
+func writeList(w http.ResponseWriter, r *http.Request, key string, rows any) { writePage(w, r, key, rows, "") }
+
+// writePage is writeList for one page of a keyset-paginated list. A next
+// cursor goes in the X-Next-Cursor header for every format, and as
+// next_cursor in JSON.
+func writePage(w http.ResponseWriter, r *http.Request, key string, rows any, next string) {
+  w.Header().Add("Vary", "Accept")
+  body := map[string]any{key: rows}
+  if next != "" {
+    w.Header().Set("X-Next-Cursor", next)
+    body["next_cursor"] = next
+  }
+  switch negotiate(r, mediaJSON, mediaNDJSON, mediaCSV) {
+  case mediaJSON:
+    writeJSON(w, 200, body)
+  case mediaNDJSON:
+    w.Header().Set("content-type", mediaNDJSON)
+    w.WriteHeader(200)
+    enc := json.NewEncoder(w)
+    rv := reflect.ValueOf(rows)
+    for i := 0; i < rv.Len(); i++ { _ = enc.Encode(rv.Index(i).Interface()) }
+  case mediaCSV:
+    w.Header().Set("content-type", mediaCSV+"; charset=utf-8")
+    w.WriteHeader(200)
+    _ = writeCSV(w, rows)
+  default:
+    writeProblem(w, r, http.StatusNotAcceptable, CodeNotAcceptable, "supported types: "+strings.Join([]string{mediaJSON, mediaNDJSON, mediaCSV}, ", "))
+  }
+}
+
+// writeCSV writes a slice of structs (or maps) with a header row. Columns are
+// the json names; embedded structs are flattened, nested values are JSON.
+func writeCSV(w http.ResponseWriter, rows any) error {
+  rv := reflect.ValueOf(rows)
+  cw := csv.NewWriter(w)
+  cols := csvColumns(rv.Type().Elem())
+  if cols != nil {
+    if err := cw.Write(cols); err != nil { return err }
+  }
+  for i := 0; i < rv.Len(); i++ {
+    row := csvRow(rv.Index(i))
+    if cols == nil { // map rows: sorted keys of the first row
+      for k := range row { cols = append(cols, k) }
+      sort.Strings(cols)
+      if err := cw.Write(cols); err != nil { return err }
+    }
+    rec := make([]string, len(cols))
+    for j, c := range cols { rec[j] = row[c] }
+    if err := cw.Write(rec); err != nil { return err }
+  }
+  cw.Flush()
+  return cw.Error()
+}
+
+// csvColumns lists a struct's json names in field order; nil for other types.
+func csvColumns(t reflect.Type) []string {
+  for t.Kind() == reflect.Pointer { t = t.Elem() }
+  if t.Kind() != reflect.Struct { return nil }
+  cols := []string{}
+  for i := 0; i < t.NumField(); i++ {
+    f := t.Field(i)
+    tag := f.Tag.Get("json")
+    if tag == "-" || (!f.IsExported() && !f.Anonymous) { continue }
+    name, _, _ := strings.Cut(tag, ",")
+    if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct { cols = append(cols, csvColumns(f.Type)...); continue }
+    if name == "" { name = f.Name }
+    cols = append(cols, name)
+  }
+  return cols
+}
+
+func csvRow(v reflect.Value) map[string]string {
+  out := map[string]string{}
+  v = reflect.Indirect(v)
+  if v.Kind() == reflect.Map {
+    for _, k := range v.MapKeys() { out[fmt.Sprint(k.Interface())] = csvCell(v.MapIndex(k)) }
+    return out
+  }
+  csvFields(v, out)
+  return out
+}
+
+func csvFields(v reflect.Value, out map[string]string) {
+  t := v.Type()
+  for i := 0; i < t.NumField(); i++ {
+    f := t.Field(i)
+    tag := f.Tag.Get("json")
+    if tag == "-" || (!f.IsExported() && !f.Anonymous) { continue }
+    name, _, _ := strings.Cut(tag, ",")
+    if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct { csvFields(v.Field(i), out); continue }
+    if name == "" { name = f.Name }
+    out[name] = csvCell(v.Field(i))
+  }
+}
+
+func csvCell(v reflect.Value) string {
+  if v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer {
+    if v.IsNil() { return "" }
+    v = v.Elem()
+  }
+  switch x := v.Interface().(type) {
+  case time.Time:
+    return x.Format(time.RFC3339Nano)
+  case string:
+    return x
+  }
+  switch v.Kind() {
+  case reflect.Map, reflect.Slice, reflect.Struct, reflect.Array:
+    b, _ := json.Marshal(v.Interface())
+    return string(b)
+  }
+  return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,85 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNegotiate(t *testing.T) {
+	cases := map[string]string{
+		"":                                  mediaJSON,
+		"*/*":                               mediaJSON,
+		"application/json, text/plain, */*": mediaJSON,
+		"text/csv":                          mediaCSV,
+		"text/*":                            mediaCSV,
+		"application/x-ndjson":              mediaNDJSON,
+		"application/json;q=0.5, text/csv":  mediaCSV,
+		"text/csv;q=0, */*;q=0.1":           mediaJSON,
+		"application/xml":                   "",
+	}
+	for accept, want := range cases {
+		r := httptest.NewRequest("GET", "/v1/transactions", nil)
+		r.Header.Set("Accept", accept)
+		if got := negotiate(r, mediaJSON, mediaNDJSON, mediaCSV); got != want {
+			t.Errorf("Accept %q: got %q, want %q", accept, got, want)
+		}
+	}
+}
+
+type listRow struct {
+	listInner
+	Name string         `json:"name"`
+	At   time.Time      `json:"at"`
+	Opt  *string        `json:"opt"`
+	Meta map[string]any `json:"meta"`
+}
+
+type listInner struct {
+	ID string `json:"id"`
+}
+
+func TestWriteListFormats(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	rows := []listRow{{listInner{"1"}, "a,b", at, nil, map[string]any{"k": 1}}, {listInner{"2"}, "c", at, nil, nil}}
+	get := func(accept string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/v1/x", nil)
+		r.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		writeList(w, r, "rows", rows)
+		return w
+	}
+
+	w := get("text/csv")
+	want := "id,name,at,opt,meta\n1,\"a,b\",2026-01-02T03:04:05Z,,\"{\"\"k\"\":1}\"\n2,c,2026-01-02T03:04:05Z,,null\n"
+	if w.Body.String() != want {
+		t.Fatalf("csv:\n%s\nwant:\n%s", w.Body.String(), want)
+	}
+	if w.Header().Get("Vary") != "Accept" {
+		t.Fatal("negotiated responses must vary on Accept")
+	}
+
+	w = get("application/x-ndjson")
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if len(lines) != 2 || w.Header().Get("content-type") != mediaNDJSON {
+		t.Fatalf("ndjson: %q", w.Body.String())
+	}
+	var first map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil || first["id"] != "1" {
+		t.Fatalf("ndjson row: %v %v", first, err)
+	}
+
+	if w = get("application/xml"); w.Code != 406 {
+		t.Fatalf("expected 406, got %d", w.Code)
+	}
+
+	r := httptest.NewRequest("GET", "/v1/x", nil)
+	r.Header.Set("Accept", "text/csv")
+	w = httptest.NewRecorder()
+	writeList(w, r, "rows", []listRow{})
+	if w.Body.String() != "id,name,at,opt,meta\n" {
+		t.Fatalf("empty csv should still have a header, got %q", w.Body.String())
+	}
+}
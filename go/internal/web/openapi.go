@@ -0,0 +1,321 @@
+package web
+
+import (
+  "encoding/json"
+  "net/http"
+  "reflect"
+  "regexp"
+  "strconv"
+  "strings"
+  "time"
+
+  "github.com/go-chi/chi/v5"
+  "github.com/swaggest/swgui/v5emb"
+)
+
+// OpenAPI document for the route table, built by reflecting over the request and
+// response types (json tags) so it follows the code.
+
+var (
+  timeType = reflect.TypeOf(time.Time{})
+  rawMessageType = reflect.TypeOf(json.RawMessage{})
+  marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+  pathParamRE = regexp.MustCompile(`\{([a-z_]+)\}`)
+)
+
+// RegisterDocs mounts the OpenAPI document and Swagger UI. They are registered
+// outside the auth group so the docs stay reachable when OIDC is enabled.
+func (a *API) RegisterDocs(r chi.Router) {
+  r.Get("/v1/openapi.json", a.handleOpenAPI)
+  r.Handle("/v1/docs", http.RedirectHandler("/v1/docs/", http.StatusMovedPermanently))
+  r.Handle("/v1/docs/*", v5emb.New("Time Ledger Sim API", "/v1/openapi.json", "/v1/docs/"))
+}
+
+func (a *API) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+  a.specOnce.Do(func() { a.spec = buildOpenAPI(a.routes()) })
+  writeJSON(w, 200, a.spec)
+}
+
+type specBuilder struct {
+  schemas map[string]any
+  names map[reflect.Type]string
+}
+
+func buildOpenAPI(routes []route) map[string]any {
+  b := &specBuilder{schemas: map[string]any{}, names: map[reflect.Type]string{}}
+  problem := b.schema(reflect.TypeOf(Problem{}))
+  paths := map[string]any{}
+  for _, rt := range routes {
+    item, _ := paths[rt.path].(map[string]any)
+    if item == nil {
+      item = map[string]any{}
+      paths[rt.path] = item
+    }
+    item[strings.ToLower(rt.method)] = b.operation(rt, problem)
+  }
+  return map[string]any{
+    "openapi": "3.1.0",
+    "info": map[string]any{
+      "title": "Time Ledger Sim API",
+      "version": buildVersion,
+      "description": "Errors are application/problem+json; branch on `code`.",
+    },
+    "paths": paths,
+    "components": map[string]any{
+      "schemas": b.schemas,
+      "securitySchemes": map[string]any{
+        "adminKey": map[string]any{"type": "apiKey", "in": "header", "name": "X-Admin-Key"},
+        "tenantKey": map[string]any{"type": "apiKey", "in": "header", "name": "X-Tenant-Key", "description": "scopes the request to a tenant when MULTI_TENANT is on"},
+        "bearer": map[string]any{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+      },
+    },
+  }
+}
+
+func (b *specBuilder) operation(rt route, problem map[string]any) map[string]any {
+  op := map[string]any{
+    "operationId": operationID(rt.method, rt.path),
+    "summary": rt.summary,
+    "tags": []string{rt.tag},
+  }
+  params := []any{}
+  for _, m := range pathParamRE.FindAllStringSubmatch(rt.path, -1) {
+    params = append(params, map[string]any{"name": m[1], "in": "path", "required": true, "schema": map[string]any{"type": "string"}})
+  }
+  for _, q := range rt.query {
+    p := map[string]any{"name": q.name, "in": "query", "schema": map[string]any{"type": q.typ}}
+    if q.desc != "" { p["description"] = q.desc }
+    params = append(params, p)
+  }
+  if len(params) > 0 { op["parameters"] = params }
+  if rt.body != nil {
+    op["requestBody"] = map[string]any{
+      "required": true,
+      "content": map[string]any{"application/json": map[string]any{"schema": b.value(rt.body)}},
+    }
+  }
+  status := rt.status
+  if status == 0 { status = http.StatusOK }
+  responses := map[string]any{
+    strconv.Itoa(status): b.response(status, rt.resp, rt.method == "GET"),
+    "default": map[string]any{
+      "description": "Error",
+      "content": map[string]any{"application/problem+json": map[string]any{"schema": problem}},
+    },
+  }
+  if rt.body != nil && hasRules(reflect.TypeOf(rt.body)) {
+    responses["400"] = map[string]any{
+      "description": "Validation failed",
+      "content": map[string]any{"application/problem+json": map[string]any{"schema": b.schema(reflect.TypeOf(ValidationProblem{}))}},
+    }
+  }
+  if rt.stream {
+    // each event's data is one resp
+    responses[strconv.Itoa(status)] = map[string]any{
+      "description": http.StatusText(status),
+      "content": map[string]any{mediaEventStream: map[string]any{"schema": b.value(rt.resp)}},
+    }
+  }
+  for st, v := range rt.extra { responses[strconv.Itoa(st)] = b.response(st, v, false) }
+  op["responses"] = responses
+  if rt.admin { op["security"] = []any{map[string]any{"adminKey": []string{}}} }
+  return op
+}
+
+func (b *specBuilder) response(status int, v any, get bool) map[string]any {
+  resp := map[string]any{"description": http.StatusText(status)}
+  if v != nil {
+    content := map[string]any{mediaJSON: map[string]any{"schema": b.value(v)}}
+    if items := listItems(v); get && items != nil {
+      // writeList: one row per line, or CSV with a header row
+      content[mediaNDJSON] = map[string]any{"schema": b.value(items)}
+      content[mediaCSV] = map[string]any{"schema": map[string]any{"type": "string"}}
+    }
+    resp["content"] = content
+  }
+  return resp
+}
+
+// listItems returns a zero row when v documents a list body ({"<key>": []T}); GET
+// routes write those with writeList.
+func listItems(v any) any {
+  o, ok := v.(obj)
+  if !ok || len(o) != 1 { return nil }
+  for _, rows := range o {
+    t := reflect.TypeOf(rows)
+    if t == nil || t.Kind() != reflect.Slice { return nil }
+    return reflect.Zero(t.Elem()).Interface()
+  }
+  return nil
+}
+
+// value documents a route's body: obj maps become inline objects, anything else
+// is reflected from its type.
+func (b *specBuilder) value(v any) map[string]any {
+  if o, ok := v.(obj); ok {
+    props := map[string]any{}
+    for k, fv := range o { props[k] = b.value(fv) }
+    return map[string]any{"type": "object", "properties": props}
+  }
+  return b.schema(reflect.TypeOf(v))
+}
+
+func (b *specBuilder) schema(t reflect.Type) map[string]any {
+  if t.Kind() == reflect.Pointer {
+    inner := b.schema(t.Elem())
+    if _, isRef := inner["$ref"]; isRef {
+      return map[string]any{"oneOf": []any{inner, map[string]any{"type": "null"}}}
+    }
+    if typ, ok := inner["type"].(string); ok { inner["type"] = []string{typ, "null"} }
+    return inner
+  }
+  switch {
+  case t == timeType:
+    return map[string]any{"type": "string", "format": "date-time"}
+  case t == rawMessageType:
+    return map[string]any{}
+  case t.Kind() != reflect.Struct && t.Implements(marshalerType):
+    return marshaledSchema(t)
+  }
+  switch t.Kind() {
+  case reflect.Bool:
+    return map[string]any{"type": "boolean"}
+  case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+    return map[string]any{"type": "integer", "format": "int32"}
+  case reflect.Int64, reflect.Uint64:
+    return map[string]any{"type": "integer", "format": "int64"}
+  case reflect.Float32, reflect.Float64:
+    return map[string]any{"type": "number"}
+  case reflect.String:
+    return map[string]any{"type": "string"}
+  case reflect.Slice, reflect.Array:
+    if t.Elem().Kind() == reflect.Uint8 { return map[string]any{"type": "string", "format": "byte"} }
+    return map[string]any{"type": "array", "items": b.schema(t.Elem())}
+  case reflect.Map:
+    s := map[string]any{"type": "object"}
+    if t.Elem().Kind() != reflect.Interface { s["additionalProperties"] = b.schema(t.Elem()) }
+    return s
+  case reflect.Struct:
+    return b.structRef(t)
+  }
+  return map[string]any{}
+}
+
+// structRef registers a named struct under components/schemas and refers to it;
+// anonymous structs are inlined.
+func (b *specBuilder) structRef(t reflect.Type) map[string]any {
+  if t.Name() == "" { return b.structSchema(t) }
+  name, ok := b.names[t]
+  if !ok {
+    name = t.Name()
+    for _, taken := b.schemas[name]; taken; _, taken = b.schemas[name] {
+      name = pkgName(t) + name
+    }
+    b.names[t] = name
+    b.schemas[name] = map[string]any{} // placeholder for recursive types
+    b.schemas[name] = b.structSchema(t)
+  }
+  return map[string]any{"$ref": "#/components/schemas/" + name}
+}
+
+func (b *specBuilder) structSchema(t reflect.Type) map[string]any {
+  props := map[string]any{}
+  var required []string
+  b.fields(t, props, &required)
+  s := map[string]any{"type": "object", "properties": props}
+  if len(required) > 0 { s["required"] = required }
+  return s
+}
+
+func (b *specBuilder) fields(t reflect.Type, props map[string]any, required *[]string) {
+  for i := 0; i < t.NumField(); i++ {
+    f := t.Field(i)
+    tag := f.Tag.Get("json")
+    if tag == "-" || (!f.IsExported() && !f.Anonymous) { continue }
+    name, _, _ := strings.Cut(tag, ",")
+    if f.Anonymous && name == "" {
+      ft := f.Type
+      if ft.Kind() == reflect.Pointer { ft = ft.Elem() }
+      if ft.Kind() == reflect.Struct { b.fields(ft, props, required); continue }
+    }
+    if name == "" { name = f.Name }
+    props[name] = b.schema(f.Type)
+    if rules := f.Tag.Get("validate"); rules != "" {
+      if constrain(props[name].(map[string]any), rules) { *required = append(*required, name) }
+    }
+  }
+}
+
+// hasRules reports whether a body type declares validate tags, i.e. can fail
+// with a ValidationProblem.
+func hasRules(t reflect.Type) bool {
+  if t.Kind() != reflect.Struct { return false }
+  for i := 0; i < t.NumField(); i++ {
+    f := t.Field(i)
+    if f.Tag.Get("validate") != "" || (f.Anonymous && hasRules(f.Type)) { return true }
+  }
+  return false
+}
+
+// constrain documents validate tag rules on a property schema and reports
+// whether the field is required.
+func constrain(s map[string]any, rules string) (required bool) {
+  for _, rule := range strings.Split(rules, ",") {
+    name, arg, _ := strings.Cut(rule, "=")
+    switch name {
+    case "required":
+      required = true
+    case "gt":
+      s["exclusiveMinimum"] = mustFloat(arg)
+    case "min":
+      s["minimum"] = mustFloat(arg)
+    case "max":
+      s["maximum"] = mustFloat(arg)
+    case "oneof":
+      s["enum"] = strings.Fields(arg)
+    case "zone_id":
+      s["pattern"] = "^zone-[a-z0-9-]+$"
+    case "duration":
+      s["format"] = "duration"
+    }
+  }
+  return required
+}
+
+// marshaledSchema infers the JSON type of a custom marshaler from its zero value.
+func marshaledSchema(t reflect.Type) map[string]any {
+  out, err := json.Marshal(reflect.Zero(t).Interface())
+  if err != nil || len(out) == 0 { return map[string]any{} }
+  switch out[0] {
+  case '"':
+    return map[string]any{"type": "string"}
+  case '{':
+    return map[string]any{"type": "object"}
+  case '[':
+    return map[string]any{"type": "array"}
+  case 't', 'f':
+    return map[string]any{"type": "boolean"}
+  case 'n':
+    return map[string]any{}
+  }
+  return map[string]any{"type": "number"}
+}
+
+func pkgName(t reflect.Type) string {
+  p := t.PkgPath()
+  if i := strings.LastIndex(p, "/"); i >= 0 { p = p[i+1:] }
+  if p == "" { return "" }
+  return strings.ToUpper(p[:1]) + p[1:]
+}
+
+// operationID turns "GET /v1/zones/{zone_id}/health" into "getZonesZoneIdHealth".
+func operationID(method, path string) string {
+  var sb strings.Builder
+  sb.WriteString(strings.ToLower(method))
+  for _, part := range strings.FieldsFunc(strings.TrimPrefix(path, "/v1"), func(r rune) bool {
+    return r == '/' || r == '{' || r == '}' || r == '_' || r == '-'
+  }) {
+    sb.WriteString(strings.ToUpper(part[:1]) + part[1:])
+  }
+  return sb.String()
+}
@@ -0,0 +1,90 @@
+package web
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOpenAPICoversRoutes(t *testing.T) {
+	a := &API{}
+	doc := buildOpenAPI(a.routes())
+	paths := doc["paths"].(map[string]any)
+	for _, rt := range a.routes() {
+		item, ok := paths[rt.path].(map[string]any)
+		if !ok {
+			t.Fatalf("path %s missing", rt.path)
+		}
+		op, ok := item[strings.ToLower(rt.method)].(map[string]any)
+		if !ok {
+			t.Fatalf("%s %s missing", rt.method, rt.path)
+		}
+		if _, ok := op["responses"].(map[string]any)["default"]; !ok {
+			t.Fatalf("%s %s has no error response", rt.method, rt.path)
+		}
+		if rt.admin && op["security"] == nil {
+			t.Fatalf("%s %s should require the admin key", rt.method, rt.path)
+		}
+	}
+	if _, err := json.Marshal(doc); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpenAPIOperationIDsUnique(t *testing.T) {
+	seen := map[string]string{}
+	for _, rt := range (&API{}).routes() {
+		id := operationID(rt.method, rt.path)
+		if prev, dup := seen[id]; dup {
+			t.Fatalf("operationId %s used by %s and %s %s", id, prev, rt.method, rt.path)
+		}
+		seen[id] = rt.method + " " + rt.path
+	}
+	if got := operationID("GET", "/v1/zones/{zone_id}/health"); got != "getZonesZoneIdHealth" {
+		t.Fatalf("got %s", got)
+	}
+}
+
+type specInner struct {
+	N int64 `json:"n"`
+}
+
+type specSample struct {
+	specInner
+	Name    string            `json:"name"`
+	At      time.Time         `json:"at"`
+	Maybe   *string           `json:"maybe,omitempty"`
+	Tags    map[string]string `json:"tags"`
+	Any     map[string]any    `json:"any"`
+	Child   *specInner        `json:"child"`
+	Skipped string            `json:"-"`
+}
+
+func TestSpecSchemaReflection(t *testing.T) {
+	b := &specBuilder{schemas: map[string]any{}, names: map[reflect.Type]string{}}
+	ref := b.value(specSample{})
+	if ref["$ref"] != "#/components/schemas/specSample" {
+		t.Fatalf("unexpected ref %v", ref)
+	}
+	props := b.schemas["specSample"].(map[string]any)["properties"].(map[string]any)
+	if _, ok := props["n"]; !ok {
+		t.Fatal("embedded struct fields should be flattened")
+	}
+	if _, ok := props["Skipped"]; ok {
+		t.Fatal(`json:"-" fields must be skipped`)
+	}
+	if props["at"].(map[string]any)["format"] != "date-time" {
+		t.Fatalf("time: %v", props["at"])
+	}
+	if typ := props["maybe"].(map[string]any)["type"].([]string); typ[1] != "null" {
+		t.Fatalf("pointer: %v", typ)
+	}
+	if _, ok := props["any"].(map[string]any)["additionalProperties"]; ok {
+		t.Fatal("map[string]any should be a free-form object")
+	}
+	if _, ok := props["child"].(map[string]any)["oneOf"]; !ok {
+		t.Fatalf("pointer to struct: %v", props["child"])
+	}
+}
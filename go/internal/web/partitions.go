@@ -0,0 +1,54 @@
+package web
+
+import (
+  "encoding/json"
+  "net/http"
+
+  "time-ledger-sim/go/internal/ledger"
+)
+
+type PartitionRequest struct {
+  FromZone string `json:"from_zone" validate:"required,zone_id"`
+  ToZone string `json:"to_zone" validate:"required,zone_id"`
+  Mode string `json:"mode" validate:"omitempty,oneof=SPOOL REJECT"`
+  Bidirectional bool `json:"bidirectional"`
+  Actor string `json:"actor" validate:"required"`
+  Reason string `json:"reason"`
+}
+
+func (req PartitionRequest) toInput() ledger.PartitionInput {
+  return ledger.PartitionInput{
+    FromZone: req.FromZone,
+    ToZone: req.ToZone,
+    Mode: req.Mode,
+    Bidirectional: req.Bidirectional,
+    Actor: req.Actor,
+    Reason: req.Reason,
+  }
+}
+
+func (a *API) handleListPartitions(w http.ResponseWriter, r *http.Request) {
+  ps, err := a.led.ListPartitions(r.Context())
+  if err != nil { writeError(w, r, err, 500); return }
+  writeList(w, r, "partitions", ps)
+}
+
+func (a *API) handleCreatePartition(w http.ResponseWriter, r *http.Request) {
+  var req PartitionRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  req.Actor = actorFor(r, req.Actor)
+  if !validRequest(w, r, req) { return }
+  ps, err := a.led.CreatePartition(r.Context(), req.toInput())
+  if err != nil { writeError(w, r, err, 400); return }
+  writeJSON(w, http.StatusCreated, map[string]any{"partitions": ps})
+}
+
+func (a *API) handleHealPartition(w http.ResponseWriter, r *http.Request) {
+  var req PartitionRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  req.Actor = actorFor(r, req.Actor)
+  if !validRequest(w, r, req) { return }
+  healed, err := a.led.HealPartition(r.Context(), req.toInput())
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, map[string]any{"healed": healed})
+}
@@ -0,0 +1,47 @@
+package web
+
+import (
+  "fmt"
+  "net/http"
+  "time"
+
+  "github.com/go-chi/chi/v5"
+
+  "time-ledger-sim/go/internal/util"
+)
+
+// handleExportIncident downloads an incident's postmortem bundle as JSON or
+// Markdown.
+func (a *API) handleExportIncident(w http.ResponseWriter, r *http.Request) {
+  lead := util.QueryInt(r, "lead_minutes", 0)
+  if lead < 0 || lead > 1440 { writeProblem(w, r, 400, CodeInvalidRequest, "lead_minutes must be between 0 and 1440"); return }
+  media := mediaJSON
+  switch r.URL.Query().Get("format") {
+  case "":
+    if media = negotiate(r, mediaJSON, mediaMarkdown); media == "" {
+      writeProblem(w, r, http.StatusNotAcceptable, CodeNotAcceptable, "supported types: application/json, text/markdown")
+      return
+    }
+  case "json":
+  case "markdown", "md":
+    media = mediaMarkdown
+  default:
+    writeProblem(w, r, 400, CodeInvalidRequest, "format must be json or markdown")
+    return
+  }
+
+  pm, err := a.led.IncidentPostmortem(r.Context(), chi.URLParam(r, "incident_id"), time.Duration(lead)*time.Minute)
+  if err != nil { writeError(w, r, err, 404); return }
+  name := "postmortem-" + pm.Incident.ID[:8]
+  if media == mediaJSON {
+    w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".json"))
+    writeJSON(w, 200, pm)
+    return
+  }
+  w.Header().Set("Content-Type", mediaMarkdown+"; charset=utf-8")
+  w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".md"))
+  w.WriteHeader(200)
+  if err := pm.WriteMarkdown(w); err != nil {
+    a.log.Warn("postmortem export failed", "incident_id", pm.Incident.ID, "err", err.Error())
+  }
+}
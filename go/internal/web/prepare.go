@@ -0,0 +1,74 @@
+package web
+
+import (
+  "encoding/json"
+  "net/http"
+  "strings"
+  "time"
+
+  "github.com/go-chi/chi/v5"
+
+  "time-ledger-sim/go/internal/ledger"
+  "time-ledger-sim/go/internal/util"
+)
+
+// --- two-phase transfers ---
+
+type PrepareTransferRequest struct {
+  CreateTransferRequest
+  TTL string `json:"ttl" validate:"omitempty,duration"` // default 5m; 1s to 24h
+}
+
+func (a *API) handlePrepareTransfer(w http.ResponseWriter, r *http.Request) {
+  var req PrepareTransferRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  if !validRequest(w, r, req) { return }
+  if req.LimitOverride != nil {
+    writeValidationProblem(w, r, FieldError{Field: "limit_override", Message: "is not available for prepared transfers"})
+    return
+  }
+  if req.Metadata == nil { req.Metadata = map[string]any{} }
+  req.Type = hashedType(req.Type)
+  var ttl time.Duration
+  if req.TTL != "" { ttl, _ = time.ParseDuration(strings.TrimSpace(req.TTL)) }
+
+  // hashed as the transfer itself would be, without the ttl
+  payloadHash, err := util.HashCanonicalJSON(req.CreateTransferRequest)
+  if err != nil { writeProblem(w, r, 500, CodeInternal, "hash error"); return }
+
+  p, err := a.led.PrepareTransfer(r.Context(), ledger.CreateTransferInput{
+    RequestID: req.RequestID, PayloadHash: payloadHash, FromAccount: req.FromAccount, ToAccount: req.ToAccount,
+    AmountUnits: req.AmountUnits, ZoneID: req.ZoneID, Type: req.Type, Metadata: req.Metadata,
+  }, ttl)
+  if err != nil { writeError(w, r, err, 400); return }
+  writeJSON(w, 200, p)
+}
+
+type ResolvePreparedRequest struct {
+  Token string `json:"token" validate:"required"`
+  Reason string `json:"reason"` // abort only
+}
+
+func (a *API) handleCommitPrepared(w http.ResponseWriter, r *http.Request) {
+  var req ResolvePreparedRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  if !validRequest(w, r, req) { return }
+  p, err := a.led.CommitPreparedTransfer(r.Context(), req.Token)
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, p)
+}
+
+func (a *API) handleAbortPrepared(w http.ResponseWriter, r *http.Request) {
+  var req ResolvePreparedRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  if !validRequest(w, r, req) { return }
+  p, err := a.led.AbortPreparedTransfer(r.Context(), req.Token, req.Reason)
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, p)
+}
+
+func (a *API) handleGetPrepared(w http.ResponseWriter, r *http.Request) {
+  p, err := a.led.GetPreparedTransfer(r.Context(), chi.URLParam(r, "token"))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, p)
+}
@@ -0,0 +1,166 @@
+package web
+
+import (
+  "encoding/json"
+  "net/http"
+
+  "time-ledger-sim/go/internal/ledger"
+  "time-ledger-sim/go/internal/logging"
+  "time-ledger-sim/go/internal/messaging"
+)
+
+// Problem is an RFC 7807 error body. Code is stable and meant for clients to
+// branch on; Detail is human-readable and may change.
+type Problem struct {
+  Type string `json:"type"`
+  Title string `json:"title"`
+  Status int `json:"status"`
+  Detail string `json:"detail,omitempty"`
+  Code string `json:"code"`
+  RequestID string `json:"request_id,omitempty"`
+}
+
+const problemTypePrefix = "urn:time-ledger-sim:problem:"
+
+// Generic problem codes; ledger sentinel errors map to their own codes below.
+const (
+  CodeInvalidJSON = "invalid_json"
+  CodeValidation = "validation_failed" // body is a ValidationProblem with per-field errors
+  CodeInvalidRequest = "invalid_request"
+  CodeNotFound = "not_found"
+  CodeConflict = "conflict"
+  CodeUnauthenticated = "unauthenticated"
+  CodeForbidden = "forbidden"
+  CodeNotAcceptable = "not_acceptable"
+  CodeAdminDisabled = "admin_disabled"
+  CodeObjectStore = "object_store_error"
+  CodeDraining = "draining"
+  CodeMessagingUnavailable = "messaging_unavailable"
+  CodeInvalidConfig = "invalid_config"
+  CodeInternal = "internal"
+)
+
+type problemMapping struct {
+  is func(error) bool
+  status int
+  code string
+}
+
+// problemMappings turn ledger sentinel errors into a status and a stable code.
+// The first match wins.
+var problemMappings = []problemMapping{
+  {ledger.IsIdempotencyConflict, http.StatusConflict, "idempotency_conflict"},
+  {ledger.IsZoneNotFound, http.StatusNotFound, "zone_not_found"},
+  {ledger.IsTransactionNotFound, http.StatusNotFound, "transaction_not_found"},
+  {ledger.IsZoneExists, http.StatusConflict, "zone_exists"},
+  {ledger.IsZoneNotRetirable, http.StatusConflict, "zone_not_retirable"},
+  {ledger.IsZoneDown, http.StatusServiceUnavailable, "zone_down"},
+  {ledger.IsZoneBlocked, http.StatusServiceUnavailable, "zone_blocked"},
+  {ledger.IsAccountBlocked, http.StatusForbidden, "account_blocked"},
+  {ledger.IsAmountBelowMinimum, http.StatusUnprocessableEntity, "amount_below_minimum"},
+  {ledger.IsAmountAboveMaximum, http.StatusUnprocessableEntity, "amount_above_maximum"},
+  {ledger.IsDailyLimitExceeded, http.StatusUnprocessableEntity, "daily_limit_exceeded"},
+  {ledger.IsAccountNotFound, http.StatusNotFound, "account_not_found"},
+  {ledger.IsAccountExists, http.StatusConflict, "account_exists"},
+  {ledger.IsAccountTagNotFound, http.StatusNotFound, "account_tag_not_found"},
+  {ledger.IsZoneTagNotFound, http.StatusNotFound, "zone_tag_not_found"},
+  {ledger.IsAccrualRuleNotFound, http.StatusNotFound, "accrual_rule_not_found"},
+  {ledger.IsRecurringTransferNotFound, http.StatusNotFound, "recurring_transfer_not_found"},
+  {ledger.IsTemplateNotFound, http.StatusNotFound, "template_not_found"},
+  {ledger.IsPrepareNotFound, http.StatusNotFound, "prepare_not_found"},
+  {ledger.IsDayNotFound, http.StatusNotFound, "day_not_found"},
+  {ledger.IsBalanceSubscriptionNotFound, http.StatusNotFound, "balance_subscription_not_found"},
+  {messaging.IsStreamNotFound, http.StatusNotFound, "stream_not_found"},
+  {messaging.IsConsumerNotFound, http.StatusNotFound, "consumer_not_found"},
+  {messaging.IsConsumerNotDurable, http.StatusConflict, "consumer_not_durable"},
+  {ledger.IsPrepareNotPending, http.StatusConflict, "prepare_not_pending"},
+  {ledger.IsPrepareExpired, http.StatusGone, "prepare_expired"},
+  {ledger.IsSagaNotFound, http.StatusNotFound, "saga_not_found"},
+  {ledger.IsSettlementRunNotFound, http.StatusNotFound, "settlement_run_not_found"},
+  {ledger.IsPartitioned, http.StatusServiceUnavailable, "zone_partitioned"},
+  {ledger.IsRateLimited, http.StatusTooManyRequests, "rate_limited"},
+  {ledger.IsInjectedFault, http.StatusInternalServerError, "injected_fault"},
+  {ledger.IsSerializationFailure, http.StatusServiceUnavailable, "serialization_failure"},
+  {ledger.IsUnknownActor, http.StatusUnprocessableEntity, "unknown_actor"},
+  {ledger.IsActorNotFound, http.StatusNotFound, "actor_not_found"},
+  {ledger.IsActorExists, http.StatusConflict, "actor_exists"},
+  {ledger.IsInvalidReasonCode, http.StatusUnprocessableEntity, "invalid_reason_code"},
+  {ledger.IsReasonCodeNotFound, http.StatusNotFound, "reason_code_not_found"},
+  {ledger.IsReasonCodeExists, http.StatusConflict, "reason_code_exists"},
+  {ledger.IsInvalidTransactionType, http.StatusUnprocessableEntity, "invalid_transaction_type"},
+  {ledger.IsApprovalNotFound, http.StatusNotFound, "approval_not_found"},
+  {ledger.IsApprovalNotPending, http.StatusConflict, "approval_not_pending"},
+  {ledger.IsSelfApproval, http.StatusForbidden, "self_approval"},
+  {ledger.IsAuditSigningOff, http.StatusConflict, "audit_signing_disabled"},
+  {ledger.IsClockNotVirtual, http.StatusConflict, "clock_not_virtual"},
+  {ledger.IsScenarioNotFound, http.StatusNotFound, "scenario_not_found"},
+  {ledger.IsScenarioRunning, http.StatusConflict, "scenario_running"},
+  {ledger.IsScenarioNotRunning, http.StatusConflict, "scenario_not_running"},
+  {ledger.IsScheduleNotFound, http.StatusNotFound, "schedule_not_found"},
+  {ledger.IsThrottleRampNotFound, http.StatusNotFound, "throttle_ramp_not_found"},
+  {ledger.IsThrottleRampRunning, http.StatusConflict, "throttle_ramp_running"},
+  {ledger.IsJobNotFound, http.StatusNotFound, "job_not_found"},
+  {ledger.IsJobRunning, http.StatusConflict, "job_running"},
+  {ledger.IsArtifactNotFound, http.StatusNotFound, "artifact_not_found"},
+  {ledger.IsArtifactNotReady, http.StatusConflict, "artifact_not_ready"},
+  {ledger.IsArtifactExpired, http.StatusGone, "artifact_expired"},
+  {ledger.IsRecommendationNotFound, http.StatusNotFound, "recommendation_not_found"},
+  {ledger.IsDrillNotFound, http.StatusNotFound, "drill_not_found"},
+  {ledger.IsDrillRunning, http.StatusConflict, "drill_running"},
+  {ledger.IsDrillEnded, http.StatusConflict, "drill_ended"},
+  {ledger.IsTenantNotFound, http.StatusNotFound, "tenant_not_found"},
+  {ledger.IsTenantExists, http.StatusConflict, "tenant_exists"},
+  {ledger.IsTenantKeyNotFound, http.StatusNotFound, "tenant_key_not_found"},
+  {ledger.IsInvalidTenantKey, http.StatusUnauthorized, "invalid_tenant_key"},
+  {ledger.IsTenantScoped, http.StatusForbidden, "tenant_scoped"},
+  {ledger.IsSimRunNotFound, http.StatusNotFound, "sim_run_not_found"},
+  {ledger.IsSimRunActive, http.StatusConflict, "sim_run_active"},
+  {ledger.IsBadSnapshot, http.StatusBadRequest, "bad_snapshot"},
+}
+
+// writeProblem writes an application/problem+json response.
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, code, detail string) {
+  writeProblemBody(w, status, newProblem(r, status, code, detail))
+}
+
+func newProblem(r *http.Request, status int, code, detail string) Problem {
+  return Problem{
+    Type: problemTypePrefix + code,
+    Title: http.StatusText(status),
+    Status: status,
+    Detail: detail,
+    Code: code,
+    RequestID: logging.RequestID(r.Context()),
+  }
+}
+
+// writeProblemBody writes a Problem, or a struct embedding one with extension members.
+func writeProblemBody(w http.ResponseWriter, status int, body any) {
+  w.Header().Set("content-type", "application/problem+json")
+  w.WriteHeader(status)
+  _ = json.NewEncoder(w).Encode(body)
+}
+
+// writeError maps err to a problem. Ledger sentinels use their own status and code;
+// anything else is reported with the fallback status and a generic code.
+func writeError(w http.ResponseWriter, r *http.Request, err error, fallback int) {
+  status, code := problemFor(err, fallback)
+  writeProblem(w, r, status, code, err.Error())
+}
+
+func problemFor(err error, fallback int) (int, string) {
+  for _, m := range problemMappings {
+    if m.is(err) { return m.status, m.code }
+  }
+  switch fallback {
+  case http.StatusBadRequest:
+    return fallback, CodeInvalidRequest
+  case http.StatusNotFound:
+    return fallback, CodeNotFound
+  case http.StatusConflict:
+    return fallback, CodeConflict
+  case http.StatusBadGateway:
+    return fallback, CodeObjectStore
+  }
+  return http.StatusInternalServerError, CodeInternal
+}
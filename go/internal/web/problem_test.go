@@ -0,0 +1,108 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"time-ledger-sim/go/internal/ledger"
+	"time-ledger-sim/go/internal/ledger/ledgertest"
+	"time-ledger-sim/go/internal/messaging"
+)
+
+func TestProblemMappings(t *testing.T) {
+	codePattern := regexp.MustCompile(`^[a-z]+(_[a-z]+)*$`)
+	seen := map[string]bool{}
+	for _, m := range problemMappings {
+		if !codePattern.MatchString(m.code) || seen[m.code] {
+			t.Errorf("code %q: want unique snake_case", m.code)
+		}
+		seen[m.code] = true
+		if m.status < 400 || http.StatusText(m.status) == "" {
+			t.Errorf("code %q: status %d is not an error status", m.code, m.status)
+		}
+	}
+
+	for _, tc := range []struct {
+		name     string
+		err      error
+		fallback int
+		status   int
+		code     string
+	}{
+		{"sentinel", ledger.ErrZoneNotFound, 500, http.StatusNotFound, "zone_not_found"},
+		{"wrapped sentinel", fmt.Errorf("%w: 3 accounts not migrated", ledger.ErrZoneNotRetirable), 500, http.StatusConflict, "zone_not_retirable"},
+		{"sentinel beats the fallback", ledger.ErrIdempotencyConflict, http.StatusBadRequest, http.StatusConflict, "idempotency_conflict"},
+		{"first match wins", errors.Join(ledger.ErrZoneNotFound, ledger.ErrIdempotencyConflict), 500, http.StatusConflict, "idempotency_conflict"},
+		{"zone down", ledger.ErrZoneDown, 500, http.StatusServiceUnavailable, "zone_down"},
+		{"rate limited", fmt.Errorf("%w: throttled", ledger.ErrRateLimited), 500, http.StatusTooManyRequests, "rate_limited"},
+		{"expired", ledger.ErrPrepareExpired, 500, http.StatusGone, "prepare_expired"},
+		{"tenant key", ledger.ErrInvalidTenantKey, 500, http.StatusUnauthorized, "invalid_tenant_key"},
+		{"sim run active", ledger.ErrSimRunActive, 500, http.StatusConflict, "sim_run_active"},
+		{"messaging", messaging.ErrStreamNotFound, 500, http.StatusNotFound, "stream_not_found"},
+		{"serialization failure", fmt.Errorf("commit: %w", &pgconn.PgError{Code: "40001"}), 500, http.StatusServiceUnavailable, "serialization_failure"},
+		{"bad request fallback", errors.New("bad"), http.StatusBadRequest, http.StatusBadRequest, CodeInvalidRequest},
+		{"not found fallback", errors.New("gone"), http.StatusNotFound, http.StatusNotFound, CodeNotFound},
+		{"conflict fallback", errors.New("clash"), http.StatusConflict, http.StatusConflict, CodeConflict},
+		{"object store fallback", errors.New("s3"), http.StatusBadGateway, http.StatusBadGateway, CodeObjectStore},
+		{"other fallbacks are internal", errors.New("boom"), http.StatusTeapot, http.StatusInternalServerError, CodeInternal},
+		{"internal", errors.New("boom"), http.StatusInternalServerError, http.StatusInternalServerError, CodeInternal},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			status, code := problemFor(tc.err, tc.fallback)
+			if status != tc.status || code != tc.code {
+				t.Fatalf("problemFor = %d %s, want %d %s", status, code, tc.status, tc.code)
+			}
+		})
+	}
+}
+
+func TestProblemResponse(t *testing.T) {
+	repo := ledgertest.NewMemRepo("zone-eu")
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	api := NewAPI("", ledger.NewWithRepo(repo, log), nil, nil, nil, nil, nil, nil, nil, log)
+	r := chi.NewRouter()
+	api.RegisterRoutes(r)
+	h := RequestIDMiddleware(r)
+
+	req := httptest.NewRequest("POST", "/v1/transfers", strings.NewReader(`{"request_id":"req-1","from_account":"a","to_account":"b","amount_units":1,"zone_id":"zone-xx"}`))
+	req.Header.Set("X-Request-Id", "problem-test-1")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotFound || rec.Header().Get("content-type") != "application/problem+json" {
+		t.Fatalf("response = %d %s", rec.Code, rec.Header().Get("content-type"))
+	}
+	var body map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"type":       "urn:time-ledger-sim:problem:zone_not_found",
+		"title":      "Not Found",
+		"status":     float64(404),
+		"code":       "zone_not_found",
+		"request_id": "problem-test-1",
+	}
+	for k, v := range want {
+		if body[k] != v {
+			t.Errorf("%s = %v, want %v", k, body[k], v)
+		}
+	}
+	if detail, _ := body["detail"].(string); !strings.Contains(detail, "zone not found") {
+		t.Errorf("detail = %q", body["detail"])
+	}
+	if len(body) != len(want)+1 {
+		t.Errorf("unexpected members in %v", body)
+	}
+}
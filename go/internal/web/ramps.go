@@ -0,0 +1,63 @@
+package web
+
+import (
+  "encoding/json"
+  "net/http"
+
+  "github.com/go-chi/chi/v5"
+
+  "time-ledger-sim/go/internal/ledger"
+  "time-ledger-sim/go/internal/util"
+)
+
+// --- throttle ramps ---
+
+type StartThrottleRampRequest struct {
+  FromPercent int `json:"from_percent" validate:"min=1,max=99"`
+  Minutes int `json:"minutes" validate:"min=1,max=1440"`
+  StepPercent int `json:"step_percent" validate:"min=0,max=99"` // 0 means 5
+  Actor string `json:"actor" validate:"required"`
+  ReasonCode string `json:"reason_code"`
+  Reason string `json:"reason"`
+}
+
+func (a *API) handleStartThrottleRamp(w http.ResponseWriter, r *http.Request) {
+  zoneID := chi.URLParam(r, "zone_id")
+  var req StartThrottleRampRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  req.Actor = actorFor(r, req.Actor)
+  if !validRequest(w, r, req) { return }
+  ramp, err := a.led.StartThrottleRamp(r.Context(), zoneID, ledger.StartThrottleRampInput{
+    FromPercent: req.FromPercent, Minutes: req.Minutes, StepPercent: req.StepPercent,
+    Actor: req.Actor, ReasonCode: req.ReasonCode, Reason: req.Reason,
+  })
+  if err != nil { writeError(w, r, err, 400); return }
+  writeJSON(w, http.StatusCreated, ramp)
+}
+
+func (a *API) handleListThrottleRamps(w http.ResponseWriter, r *http.Request) {
+  list, err := a.led.ListThrottleRamps(r.Context(), chi.URLParam(r, "zone_id"), util.QueryInt(r, "limit", 50))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeList(w, r, "ramps", list)
+}
+
+func (a *API) handleGetThrottleRamp(w http.ResponseWriter, r *http.Request) {
+  ramp, err := a.led.GetThrottleRamp(r.Context(), chi.URLParam(r, "ramp_id"))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, ramp)
+}
+
+type CancelThrottleRampRequest struct {
+  Actor string `json:"actor" validate:"required"`
+  Reason string `json:"reason"`
+}
+
+func (a *API) handleCancelThrottleRamp(w http.ResponseWriter, r *http.Request) {
+  var req CancelThrottleRampRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  req.Actor = actorFor(r, req.Actor)
+  if !validRequest(w, r, req) { return }
+  ramp, err := a.led.CancelThrottleRamp(r.Context(), chi.URLParam(r, "ramp_id"), req.Actor, req.Reason)
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, ramp)
+}
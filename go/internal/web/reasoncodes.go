@@ -0,0 +1,59 @@
+package web
+
+import (
+  "encoding/json"
+  "net/http"
+  "time"
+
+  "github.com/go-chi/chi/v5"
+
+  "time-ledger-sim/go/internal/ledger"
+)
+
+// --- reason-code catalog ---
+
+type RegisterReasonCodeRequest struct {
+  Code string `json:"code" validate:"required"`
+  Description string `json:"description" validate:"required"`
+  AppliesTo []string `json:"applies_to"` // SET_ZONE_STATUS, SET_ZONE_CONTROLS, REPLAY_SPOOL; empty: every action
+  Actor string `json:"actor" validate:"required"` // who is adding it
+  Reason string `json:"reason"`
+}
+
+func (a *API) handleRegisterReasonCode(w http.ResponseWriter, r *http.Request) {
+  var req RegisterReasonCodeRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  req.Actor = actorFor(r, req.Actor)
+  if !validRequest(w, r, req) { return }
+  c, err := a.led.RegisterReasonCode(r.Context(), ledger.RegisterReasonCodeInput{
+    Code: req.Code, Description: req.Description, AppliesTo: req.AppliesTo, Actor: req.Actor, Reason: req.Reason,
+  })
+  if err != nil { writeError(w, r, err, 400); return }
+  writeJSON(w, http.StatusCreated, c)
+}
+
+func (a *API) handleListReasonCodes(w http.ResponseWriter, r *http.Request) {
+  q := r.URL.Query()
+  codes, err := a.led.ListReasonCodes(r.Context(), q.Get("action"), q.Get("all") == "true")
+  if err != nil { writeError(w, r, err, 500); return }
+  writeList(w, r, "reason_codes", codes)
+}
+
+// handleRetireReasonCode takes actor/reason as query params (DELETE has no body).
+func (a *API) handleRetireReasonCode(w http.ResponseWriter, r *http.Request) {
+  q := r.URL.Query()
+  actor := actorFor(r, q.Get("actor"))
+  if actor == "" { writeValidationProblem(w, r, FieldError{Field: "actor", Message: "is required"}); return }
+  c, err := a.led.RetireReasonCode(r.Context(), chi.URLParam(r, "code"), actor, q.Get("reason"))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, c)
+}
+
+func (a *API) handleReasonCodeUsage(w http.ResponseWriter, r *http.Request) {
+  since, ok := auditSince(w, r)
+  if !ok { return }
+  if since.IsZero() { since = time.Now().UTC().Add(-defaultActivityWindow) }
+  u, err := a.led.GetReasonCodeUsage(r.Context(), since, r.URL.Query().Get("zone_id"))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, u)
+}
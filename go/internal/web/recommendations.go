@@ -0,0 +1,39 @@
+package web
+
+import (
+  "encoding/json"
+  "net/http"
+
+  "github.com/go-chi/chi/v5"
+
+  "time-ledger-sim/go/internal/ledger"
+)
+
+// --- recommendations ---
+
+func (a *API) handleListRecommendations(w http.ResponseWriter, r *http.Request) {
+  recs, err := a.led.Recommendations(r.Context(), chi.URLParam(r, "zone_id"))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeList(w, r, "recommendations", recs)
+}
+
+type ApplyRecommendationRequest struct {
+  Actor string `json:"actor" validate:"required"`
+  ReasonCode string `json:"reason_code"` // from GET /v1/reason-codes
+  Reason string `json:"reason"` // default: "recommendation <id>"
+}
+
+// handleApplyRecommendation runs a recommendation's action, if it still
+// applies. A change held for approval answers 202.
+func (a *API) handleApplyRecommendation(w http.ResponseWriter, r *http.Request) {
+  var req ApplyRecommendationRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  req.Actor = actorFor(r, req.Actor)
+  if !validRequest(w, r, req) { return }
+  res, err := a.led.ApplyRecommendation(r.Context(), chi.URLParam(r, "zone_id"), chi.URLParam(r, "recommendation_id"), ledger.ApplyRecommendationInput{
+    Actor: req.Actor, ReasonCode: req.ReasonCode, Reason: req.Reason,
+  })
+  if err != nil { writeError(w, r, err, 500); return }
+  if ap, ok := res.Result.(*ledger.Approval); ok { writeJSON(w, http.StatusAccepted, ap); return }
+  writeJSON(w, 200, res)
+}
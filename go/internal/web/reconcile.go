@@ -0,0 +1,27 @@
+package web
+
+import (
+  "encoding/json"
+  "net/http"
+)
+
+type ReconcileFixRequest struct {
+  Actor string `json:"actor" validate:"required"`
+  Reason string `json:"reason"`
+}
+
+func (a *API) handleReconcile(w http.ResponseWriter, r *http.Request) {
+  rep, err := a.led.Reconcile(r.Context())
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, rep)
+}
+
+func (a *API) handleReconcileFix(w http.ResponseWriter, r *http.Request) {
+  var req ReconcileFixRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  req.Actor = actorFor(r, req.Actor)
+  if !validRequest(w, r, req) { return }
+  rep, err := a.led.ReconcileFix(r.Context(), req.Actor, req.Reason)
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, rep)
+}
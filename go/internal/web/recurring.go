@@ -0,0 +1,88 @@
+package web
+
+import (
+  "context"
+  "encoding/json"
+  "net/http"
+  "strings"
+  "time"
+
+  "github.com/go-chi/chi/v5"
+
+  "time-ledger-sim/go/internal/ledger"
+)
+
+// --- recurring transfers ---
+
+type CreateRecurringTransferRequest struct {
+  Name string `json:"name"`
+  ZoneID string `json:"zone_id" validate:"required,zone_id"`
+  FromAccount string `json:"from_account" validate:"required"`
+  ToAccount string `json:"to_account" validate:"required"`
+  AmountUnits int64 `json:"amount_units" validate:"gt=0"`
+  Cron string `json:"cron"` // five fields, UTC on the sim clock, e.g. "*/5 * * * *"; or
+  Interval string `json:"interval" validate:"omitempty,duration"` // e.g. "30s"
+  Metadata map[string]any `json:"metadata"`
+  StartAt *time.Time `json:"start_at"` // first run; default the first cron match or one interval from now
+  Actor string `json:"actor" validate:"required"`
+  Reason string `json:"reason"`
+}
+
+func (a *API) handleCreateRecurringTransfer(w http.ResponseWriter, r *http.Request) {
+  var req CreateRecurringTransferRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  req.Actor = actorFor(r, req.Actor)
+  if !validRequest(w, r, req) { return }
+  var interval time.Duration
+  if req.Interval != "" { interval, _ = time.ParseDuration(strings.TrimSpace(req.Interval)) }
+  in := ledger.CreateRecurringTransferInput{
+    Name: req.Name, ZoneID: req.ZoneID, FromAccount: req.FromAccount, ToAccount: req.ToAccount, AmountUnits: req.AmountUnits,
+    Cron: strings.TrimSpace(req.Cron), Interval: interval, Metadata: req.Metadata, Actor: req.Actor, Reason: req.Reason,
+  }
+  if req.StartAt != nil { in.StartAt = *req.StartAt }
+  rt, err := a.led.CreateRecurringTransfer(r.Context(), in)
+  if err != nil { writeError(w, r, err, 400); return }
+  writeJSON(w, http.StatusCreated, rt)
+}
+
+func (a *API) handleListRecurringTransfers(w http.ResponseWriter, r *http.Request) {
+  q := r.URL.Query()
+  list, err := a.led.ListRecurringTransfers(r.Context(), q.Get("zone_id"), q.Get("all") == "true")
+  if err != nil { writeError(w, r, err, 500); return }
+  writeList(w, r, "recurring_transfers", list)
+}
+
+func (a *API) handleGetRecurringTransfer(w http.ResponseWriter, r *http.Request) {
+  rt, err := a.led.GetRecurringTransfer(r.Context(), chi.URLParam(r, "recurring_id"))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, rt)
+}
+
+type RecurringTransferActionRequest struct {
+  Actor string `json:"actor" validate:"required"`
+  Reason string `json:"reason"`
+}
+
+// handleRecurringTransferAction serves pause and resume, given as method
+// expressions such as (*ledger.Ledger).PauseRecurringTransfer.
+func (a *API) handleRecurringTransferAction(act func(*ledger.Ledger, context.Context, string, string, string) (*ledger.RecurringTransfer, error)) http.HandlerFunc {
+  return func(w http.ResponseWriter, r *http.Request) {
+    var req RecurringTransferActionRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+    req.Actor = actorFor(r, req.Actor)
+    if !validRequest(w, r, req) { return }
+    rt, err := act(a.led, r.Context(), chi.URLParam(r, "recurring_id"), req.Actor, req.Reason)
+    if err != nil { writeError(w, r, err, 500); return }
+    writeJSON(w, 200, rt)
+  }
+}
+
+// handleCancelRecurringTransfer takes actor/reason as query params (DELETE has no body).
+func (a *API) handleCancelRecurringTransfer(w http.ResponseWriter, r *http.Request) {
+  q := r.URL.Query()
+  actor := actorFor(r, q.Get("actor"))
+  if actor == "" { writeValidationProblem(w, r, FieldError{Field: "actor", Message: "is required"}); return }
+  rt, err := a.led.CancelRecurringTransfer(r.Context(), chi.URLParam(r, "recurring_id"), actor, q.Get("reason"))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, rt)
+}
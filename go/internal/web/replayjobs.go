@@ -0,0 +1,40 @@
+package web
+
+import (
+  "encoding/json"
+  "net/http"
+
+  "github.com/go-chi/chi/v5"
+
+  "time-ledger-sim/go/internal/ledger"
+  "time-ledger-sim/go/internal/util"
+)
+
+// --- spool replay jobs ---
+
+type StartReplayJobRequest struct {
+  RatePerSec int `json:"rate_per_sec" validate:"min=0,max=500"` // 0 means 50
+  MaxItems int `json:"max_items" validate:"min=0"` // 0: until the spool is empty
+  Actor string `json:"actor" validate:"required"`
+  ReasonCode string `json:"reason_code"`
+  Reason string `json:"reason"`
+}
+
+func (a *API) handleStartReplayJob(w http.ResponseWriter, r *http.Request) {
+  zoneID := chi.URLParam(r, "zone_id")
+  var req StartReplayJobRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  req.Actor = actorFor(r, req.Actor)
+  if !validRequest(w, r, req) { return }
+  job, err := a.led.StartReplayJob(r.Context(), zoneID, ledger.StartReplayJobInput{
+    RatePerSec: req.RatePerSec, MaxItems: req.MaxItems, Actor: req.Actor, ReasonCode: req.ReasonCode, Reason: req.Reason,
+  })
+  if err != nil { writeError(w, r, err, 400); return }
+  writeJSON(w, http.StatusAccepted, job)
+}
+
+func (a *API) handleListReplayJobs(w http.ResponseWriter, r *http.Request) {
+  list, err := a.led.ListReplayJobs(r.Context(), chi.URLParam(r, "zone_id"), util.QueryInt(r, "limit", 50))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeList(w, r, "jobs", list)
+}
@@ -0,0 +1,434 @@
+package web
+
+import (
+  "net/http"
+
+  "github.com/go-chi/chi/v5"
+
+  "time-ledger-sim/go/internal/ledger"
+  "time-ledger-sim/go/internal/messaging"
+)
+
+// route is one endpoint. RegisterRoutes mounts the table and the OpenAPI builder
+// documents the same table, so the served spec cannot drift from the router.
+type route struct {
+  method, path string
+  summary string
+  tag string
+  admin bool // requires X-Admin-Key
+  stream bool // a text/event-stream of resp events
+  handler http.HandlerFunc
+  query []queryParam
+  body any // request body type; nil when the endpoint takes none
+  status int // success status, default 200
+  resp any // success body type
+  extra map[int]any // other success responses by status
+}
+
+type queryParam struct {
+  name, typ, desc string
+}
+
+// obj documents an ad-hoc JSON object written as map[string]any: each value is a
+// zero value of the field's type.
+type obj map[string]any
+
+var limitParam = queryParam{"limit", "integer", "maximum number of rows"}
+
+var sinceParam = queryParam{"since", "string", "RFC 3339 time; entries created at or after it"}
+
+var tagSelectorParam = queryParam{"tag", "string", "key=value zone tag; repeat or separate with commas to require several"}
+
+// notModifiedResp documents the 304 of routes that honor If-None-Match (see notModified).
+var notModifiedResp = map[int]any{http.StatusNotModified: nil}
+
+// pendingApprovalResp documents the 202 of actions held under the two-person rule.
+var pendingApprovalResp = map[int]any{http.StatusAccepted: ledger.Approval{}}
+
+func (a *API) routes() []route {
+  return []route{
+    {method: "GET", path: "/v1/version", summary: "Build version", tag: "meta", handler: a.handleVersion,
+      resp: obj{"service": "", "version": "", "commit": "", "buildDate": "", "go": ""}},
+
+    // zones
+    {method: "GET", path: "/v1/zones", summary: "List zones", tag: "zones", handler: a.handleListZones,
+      query: []queryParam{tagSelectorParam}, resp: obj{"zones": []ledger.Zone{}}, extra: notModifiedResp},
+    {method: "POST", path: "/v1/zones", summary: "Create a zone", tag: "zones", admin: true, handler: a.handleCreateZone,
+      body: CreateZoneRequest{}, status: http.StatusCreated, resp: ledger.Zone{}},
+    {method: "DELETE", path: "/v1/zones/{zone_id}", summary: "Retire a zone", tag: "zones", admin: true, handler: a.handleRetireZone,
+      query: []queryParam{{"actor", "string", "who is retiring the zone"}, {"reason", "string", ""}, {"migrate_to", "string", "zone that receives the retired zone's accounts"}},
+      resp: obj{"status": "", "zone_id": ""}},
+    {method: "GET", path: "/v1/zones/{zone_id}/tags", summary: "Get a zone's tags", tag: "zones", handler: a.handleGetZoneTags,
+      resp: ledger.ZoneTags{}},
+    {method: "POST", path: "/v1/zones/{zone_id}/tags", summary: "Set tags on a zone", tag: "zones", handler: a.handleTagZone,
+      body: TagZoneRequest{}, resp: ledger.ZoneTags{}},
+    {method: "DELETE", path: "/v1/zones/{zone_id}/tags/{key}", summary: "Remove a tag from a zone", tag: "zones", handler: a.handleUntagZone,
+      query: []queryParam{{"actor", "string", "who is removing it"}, {"reason", "string", ""}}, resp: ledger.ZoneTags{}},
+    {method: "GET", path: "/v1/zones/{zone_id}/health", summary: "Composite zone health", tag: "zones", handler: a.handleGetZoneHealth,
+      resp: ledger.ZoneHealth{}},
+    {method: "GET", path: "/v1/zones/{zone_id}/stats", summary: "Zone throughput, rejections, latency and spool depth over a window", tag: "zones", handler: a.handleGetZoneStats,
+      query: []queryParam{{"window", "string", "duration, 10s to 1h (default 5m)"}}, resp: ledger.ZoneStats{}},
+    {method: "GET", path: "/v1/zones/{zone_id}/transactions", summary: "The zone's transactions in zone sequence order, for incremental sync", tag: "zones", handler: a.handleListZoneTransactions,
+      query: []queryParam{{"after_seq", "integer", "return transactions with a higher zone_seq (default 0)"}, {"limit", "integer", "default 100, max 1000"}},
+      resp: ledger.ZoneTransactionPage{}},
+    {method: "GET", path: "/v1/zones/{zone_id}/balance-sheet", summary: "Credits, debits, net and cross-zone position of the zone's accounts over a time range", tag: "zones", handler: a.handleBalanceSheet,
+      query: []queryParam{{"from", "string", "RFC 3339 time (default 24h before to)"}, {"to", "string", "RFC 3339 time, exclusive (default now on the sim clock)"}, {"top", "integer", "accounts to list, 1-100 (default 10)"}},
+      resp: ledger.BalanceSheet{}},
+    {method: "GET", path: "/v1/zones/{zone_id}/days/{date}", summary: "A business day's totals: stored once the day closed, so far otherwise", tag: "zones", handler: a.handleGetBusinessDay,
+      resp: ledger.DailySummary{}},
+    {method: "GET", path: "/v1/flows", summary: "Value moved between each pair of zones over a window", tag: "zones", handler: a.handleFlows,
+      query: []queryParam{{"window", "string", "duration, 1m to 24h (default 1h)"}}, resp: ledger.FlowMatrix{}},
+    {method: "GET", path: "/v1/stats/timeseries", summary: "A metric per step from the per-minute rollups, for dashboards", tag: "zones", handler: a.handleStatsTimeSeries,
+      query: []queryParam{{"metric", "string", "transfers, amount_units, spooled or incidents"}, {"zone", "string", "one zone; empty sums every zone"},
+        {"step", "string", "whole minutes, 1m to 24h (default 1m)"}, {"from", "string", "RFC 3339 (default: an hour before to)"}, {"to", "string", "RFC 3339, exclusive (default: now on the sim clock)"}},
+      resp: ledger.StatsSeries{}},
+    {method: "GET", path: "/v1/zones/{zone_id}/ledger-proof", summary: "Verify the zone's transaction hash chain", tag: "zones", handler: a.handleLedgerProof,
+      resp: ledger.LedgerProof{}},
+    {method: "GET", path: "/v1/zones/{zone_id}/recommendations", summary: "Actions the advisor recommends for the zone's current state", tag: "zones", handler: a.handleListRecommendations,
+      resp: obj{"recommendations": []ledger.Recommendation{}}},
+    {method: "POST", path: "/v1/zones/{zone_id}/recommendations/{recommendation_id}/apply", summary: "Apply a recommendation through its control API", tag: "zones", handler: a.handleApplyRecommendation,
+      body: ApplyRecommendationRequest{}, resp: ledger.AppliedRecommendation{}, extra: pendingApprovalResp},
+    {method: "POST", path: "/v1/zones/{zone_id}/status", summary: "Set zone status", tag: "zones", handler: a.handleSetZoneStatus,
+      body: SetZoneStatusRequest{}, resp: ledger.Zone{}, extra: pendingApprovalResp},
+
+    // transfers + reads
+    {method: "POST", path: "/v1/transfers", summary: "Create a transfer (applied, or 202 when spooled)", tag: "transfers", handler: a.handleCreateTransfer,
+      body: CreateTransferRequest{}, resp: TransferAppliedResponse{}, extra: map[int]any{http.StatusAccepted: TransferSpooledResponse{}}},
+    {method: "POST", path: "/v1/transfers/estimate", summary: "Estimate whether a transfer would be applied, spooled or rejected, without recording it", tag: "transfers",
+      handler: a.handleEstimateTransfer, body: EstimateTransferRequest{}, resp: EstimateTransferResponse{}},
+    {method: "POST", path: "/v1/transfers/prepare", summary: "Prepare a two-phase transfer: run the gates and hold it until commit, abort or TTL", tag: "transfers",
+      handler: a.handlePrepareTransfer, body: PrepareTransferRequest{}, resp: ledger.PreparedTransfer{}},
+    {method: "POST", path: "/v1/transfers/commit", summary: "Commit a prepared transfer", tag: "transfers", handler: a.handleCommitPrepared,
+      body: ResolvePreparedRequest{}, resp: ledger.PreparedTransfer{}},
+    {method: "POST", path: "/v1/transfers/abort", summary: "Abort a prepared transfer", tag: "transfers", handler: a.handleAbortPrepared,
+      body: ResolvePreparedRequest{}, resp: ledger.PreparedTransfer{}},
+    {method: "GET", path: "/v1/transfers/prepared/{token}", summary: "Get a prepared transfer", tag: "transfers", handler: a.handleGetPrepared,
+      resp: ledger.PreparedTransfer{}},
+    {method: "POST", path: "/v1/transfers/from-template/{name}", summary: "Create a transfer from a template (applied, or 202 when spooled)", tag: "transfers",
+      handler: a.handleTransferFromTemplate, body: TemplateTransferRequest{}, resp: TransferAppliedResponse{}, extra: map[int]any{http.StatusAccepted: TransferSpooledResponse{}}},
+    {method: "GET", path: "/v1/transfer-templates", summary: "List transfer templates", tag: "transfers", handler: a.handleListTransferTemplates,
+      resp: obj{"templates": []ledger.TransferTemplate{}}},
+    {method: "POST", path: "/v1/transfer-templates", summary: "Create or replace a transfer template", tag: "transfers", handler: a.handleSaveTransferTemplate,
+      body: SaveTransferTemplateRequest{}, resp: ledger.TransferTemplate{}},
+    {method: "GET", path: "/v1/transfer-templates/{name}", summary: "Get a transfer template", tag: "transfers", handler: a.handleGetTransferTemplate,
+      resp: ledger.TransferTemplate{}},
+    {method: "DELETE", path: "/v1/transfer-templates/{name}", summary: "Delete a transfer template", tag: "transfers", handler: a.handleDeleteTransferTemplate,
+      query: []queryParam{{"actor", "string", "who is deleting it"}, {"reason", "string", ""}}, resp: ledger.TransferTemplate{}},
+    {method: "GET", path: "/v1/balances", summary: "List balances, a page at a time", tag: "transfers", handler: a.handleListBalances,
+      query: []queryParam{{"limit", "integer", "page size, 1-500 (default 100)"}, {"cursor", "string", "next_cursor (also the X-Next-Cursor header) of the previous page"},
+        {"order", "string", "updated (default, most recently changed first) or account"}, {"zone_id", "string", ""},
+        {"account_tag", "string", "only accounts carrying this tag"}, {"as_of", "string", "RFC 3339 time; balances rebuilt from postings up to it"}},
+      resp: ledger.BalancePage{}},
+    {method: "POST", path: "/v1/accounts", summary: "Create an account (required before use with STRICT_ACCOUNTS)", tag: "transfers", handler: a.handleCreateAccount,
+      body: CreateAccountRequest{}, status: http.StatusCreated, resp: ledger.Account{}},
+    {method: "GET", path: "/v1/transactions", summary: "List recent transactions", tag: "transfers", handler: a.handleListTransactions,
+      query: []queryParam{limitParam, {"tag", "string", "only transactions annotated with this tag"}, {"account_tag", "string", "only transactions from or to an account carrying this tag"},
+        {"type", "string", "only transactions of this type"}}, resp: obj{"transactions": []ledger.TransactionRow{}}},
+    {method: "GET", path: "/v1/transaction-types", summary: "The transaction type catalog", tag: "transfers", handler: a.handleListTransactionTypes,
+      resp: obj{"transaction_types": []ledger.TransactionType{}}},
+    {method: "GET", path: "/v1/cdc/transactions", summary: "Resumable change feed of recorded transactions", tag: "transfers", handler: a.handleTransactionChanges,
+      query: []queryParam{{"cursor", "string", "next_cursor or a change's cursor from an earlier page; empty starts from the beginning"}, {"limit", "integer", "default 100, max 1000"}},
+      resp: ledger.TransactionChangePage{}},
+    {method: "GET", path: "/v1/transactions/{transaction_id}", summary: "Get a transaction with postings and annotations", tag: "transfers", handler: a.handleGetTransaction,
+      resp: ledger.TransactionDetail{}},
+    {method: "GET", path: "/v1/transactions/{transaction_id}/related", summary: "Spool entry, outbox events, incidents and audit entries for a transaction", tag: "transfers", handler: a.handleTransactionRelated,
+      resp: ledger.TransactionRelated{}},
+    {method: "GET", path: "/v1/transactions/{transaction_id}/annotations", summary: "List a transaction's annotations", tag: "transfers", handler: a.handleListAnnotations,
+      resp: obj{"annotations": []ledger.Annotation{}}},
+    {method: "POST", path: "/v1/transactions/{transaction_id}/annotations", summary: "Annotate a transaction with a note and tags", tag: "transfers", handler: a.handleAnnotateTransaction,
+      body: AnnotateTransactionRequest{}, status: http.StatusCreated, resp: ledger.Annotation{}},
+
+    // incidents
+    {method: "GET", path: "/v1/zones/{zone_id}/incidents", summary: "List incidents for a zone", tag: "incidents", handler: a.handleListIncidentsByZone,
+      resp: obj{"incidents": []ledger.Incident{}}, extra: notModifiedResp},
+    {method: "GET", path: "/v1/incidents", summary: "List recent incidents", tag: "incidents", handler: a.handleListRecentIncidents,
+      query: []queryParam{limitParam, tagSelectorParam}, resp: obj{"incidents": []ledger.Incident{}}, extra: notModifiedResp},
+    {method: "GET", path: "/v1/incidents/{incident_id}", summary: "Get an incident", tag: "incidents", handler: a.handleGetIncident,
+      resp: ledger.Incident{}},
+    {method: "POST", path: "/v1/incidents/{incident_id}/action", summary: "Acknowledge, assign, note or resolve an incident", tag: "incidents", handler: a.handleIncidentAction,
+      body: IncidentActionRequest{}, resp: ledger.Incident{}},
+    {method: "GET", path: "/v1/incidents/{incident_id}/export", summary: "Postmortem bundle: timeline, transactions, control changes and audit around an incident", tag: "incidents", handler: a.handleExportIncident,
+      query: []queryParam{{"format", "string", "json (default) or markdown; otherwise the Accept header decides"}, {"lead_minutes", "integer", "how far before detection the window opens, default 15, max 1440"}},
+      resp: ledger.Postmortem{}},
+
+    // ops controls + spool + audit
+    {method: "GET", path: "/v1/zones/{zone_id}/controls", summary: "Get zone controls", tag: "controls", handler: a.handleGetZoneControls,
+      resp: ledger.ZoneControls{}, extra: notModifiedResp},
+    {method: "POST", path: "/v1/zones/{zone_id}/controls", summary: "Set zone controls", tag: "controls", handler: a.handleSetZoneControls,
+      body: SetZoneControlsRequest{}, resp: ledger.ZoneControls{}, extra: pendingApprovalResp},
+    {method: "POST", path: "/v1/zones/controls:batch", summary: "Change the controls of many zones at once", tag: "controls", handler: a.handleBatchZoneControls,
+      body: BatchZoneControlsRequest{}, resp: ledger.BatchZoneControlsResult{}, extra: pendingApprovalResp},
+    {method: "GET", path: "/v1/zones/{zone_id}/controls/history", summary: "Every version of a zone's controls, oldest first", tag: "controls", handler: a.handleZoneControlsHistory,
+      query: []queryParam{{"after_version", "integer", "only versions after this one"}, {"since", "string", "RFC 3339 time; versions changed at or after it"}, limitParam},
+      resp: obj{"history": []ledger.ZoneControlsChange{}}},
+    {method: "GET", path: "/v1/zones/{zone_id}/controls/scheduled", summary: "List scheduled controls changes", tag: "controls", handler: a.handleListScheduledControls,
+      query: []queryParam{{"all", "boolean", "include applied and cancelled changes"}}, resp: obj{"scheduled": []ledger.ScheduledControlChange{}}},
+    {method: "POST", path: "/v1/zones/{zone_id}/controls/scheduled", summary: "Schedule a controls change", tag: "controls", handler: a.handleScheduleZoneControls,
+      body: ScheduleZoneControlsRequest{}, status: http.StatusCreated, resp: ledger.ScheduledControlChange{}, extra: pendingApprovalResp},
+    {method: "POST", path: "/v1/scheduled-controls/{schedule_id}/cancel", summary: "Cancel a scheduled controls change", tag: "controls", handler: a.handleCancelScheduledControls,
+      body: CancelScheduledControlsRequest{}, resp: ledger.ScheduledControlChange{}},
+    {method: "GET", path: "/v1/zones/{zone_id}/controls/ramps", summary: "List throttle ramps", tag: "controls", handler: a.handleListThrottleRamps,
+      query: []queryParam{limitParam}, resp: obj{"ramps": []ledger.ThrottleRamp{}}},
+    {method: "POST", path: "/v1/zones/{zone_id}/controls/ramps", summary: "Ramp the throttle up to 100% over time", tag: "controls", handler: a.handleStartThrottleRamp,
+      body: StartThrottleRampRequest{}, status: http.StatusCreated, resp: ledger.ThrottleRamp{}},
+    {method: "GET", path: "/v1/throttle-ramps/{ramp_id}", summary: "Get a throttle ramp", tag: "controls", handler: a.handleGetThrottleRamp,
+      resp: ledger.ThrottleRamp{}},
+    {method: "POST", path: "/v1/throttle-ramps/{ramp_id}/cancel", summary: "Cancel a throttle ramp", tag: "controls", handler: a.handleCancelThrottleRamp,
+      body: CancelThrottleRampRequest{}, resp: ledger.ThrottleRamp{}},
+
+    {method: "GET", path: "/v1/zones/{zone_id}/spool", summary: "Spool stats", tag: "spool", handler: a.handleGetSpoolStats,
+      resp: ledger.SpoolStats{}},
+    {method: "GET", path: "/v1/zones/{zone_id}/spool/history", summary: "Sampled spool depth over a time range, oldest first", tag: "spool", handler: a.handleSpoolHistory,
+      query: []queryParam{{"from", "string", "RFC 3339 time (default 24h before to)"}, {"to", "string", "RFC 3339 time, inclusive (default now on the sim clock)"}, {"limit", "integer", "maximum number of samples, up to 10000 (default 1000)"}},
+      resp: ledger.SpoolDepthHistory{}},
+    {method: "POST", path: "/v1/zones/{zone_id}/spool/replay", summary: "Replay spooled transfers", tag: "spool", handler: a.handleReplaySpool,
+      body: ReplaySpoolRequest{}, resp: ledger.ReplayResult{}},
+    {method: "GET", path: "/v1/zones/{zone_id}/spool/replay-jobs", summary: "List spool replay jobs", tag: "spool", handler: a.handleListReplayJobs,
+      query: []queryParam{limitParam}, resp: obj{"jobs": []ledger.Job{}}},
+    {method: "POST", path: "/v1/zones/{zone_id}/spool/replay-jobs", summary: "Replay the spool in the background at a bounded rate", tag: "spool", handler: a.handleStartReplayJob,
+      body: StartReplayJobRequest{}, status: http.StatusAccepted, resp: ledger.Job{}},
+
+    {method: "GET", path: "/v1/jobs", summary: "List background jobs, newest first", tag: "jobs", handler: a.handleListJobs,
+      query: []queryParam{{"kind", "string", "job kind, e.g. REPLAY_SPOOL"}, {"target", "string", "what the job works on, e.g. a zone id"}, {"status", "string", "QUEUED, RUNNING, COMPLETED, CANCELLED or FAILED"}, limitParam},
+      resp: obj{"jobs": []ledger.Job{}}},
+    {method: "GET", path: "/v1/jobs/{job_id}", summary: "Job status and progress", tag: "jobs", handler: a.handleGetJob,
+      resp: ledger.Job{}},
+    {method: "POST", path: "/v1/jobs/{job_id}/cancel", summary: "Cancel a queued or running job", tag: "jobs", handler: a.handleCancelJob,
+      body: CancelJobRequest{}, resp: ledger.Job{}},
+    {method: "GET", path: "/v1/jobs/{job_id}/artifact", summary: "Download the file a finished job produced", tag: "jobs", admin: true, handler: a.handleDownloadArtifact},
+    {method: "GET", path: "/v1/exports", summary: "List export jobs, newest first", tag: "jobs", admin: true, handler: a.handleListExports,
+      query: []queryParam{limitParam}, resp: obj{"exports": []ledger.Job{}}},
+    {method: "POST", path: "/v1/exports", summary: "Export a dataset to a downloadable file in the background", tag: "jobs", admin: true, handler: a.handleStartExport,
+      body: StartExportRequest{}, status: http.StatusAccepted, resp: ledger.Job{}},
+
+    {method: "GET", path: "/v1/zones/{zone_id}/audit", summary: "Audit log for a zone", tag: "audit", handler: a.handleListAudit,
+      query: []queryParam{limitParam}, resp: obj{"audit": []ledger.AuditEntry{}}},
+    {method: "GET", path: "/v1/audit/api-calls", summary: "Recorded mutating API calls", tag: "audit", admin: true, handler: a.handleListAPICalls,
+      query: []queryParam{limitParam}, resp: obj{"calls": []ledger.AuditEntry{}}},
+    {method: "GET", path: "/v1/audit/verify", summary: "Verify audit entry signatures", tag: "audit", admin: true, handler: a.handleVerifyAudit,
+      query: []queryParam{sinceParam}, resp: ledger.AuditVerification{}},
+    {method: "GET", path: "/v1/audit/export", summary: "Export the signed audit trail (NDJSON)", tag: "audit", admin: true, handler: a.handleExportAudit,
+      query: []queryParam{sinceParam}, resp: ledger.SignedAuditEntry{}},
+
+    // two-person rule
+    {method: "GET", path: "/v1/approvals", summary: "List approvals", tag: "approvals", handler: a.handleListApprovals,
+      query: []queryParam{{"status", "string", "PENDING, APPROVED, REJECTED, FAILED or EXPIRED"}, limitParam}, resp: obj{"approvals": []ledger.Approval{}}},
+    {method: "GET", path: "/v1/approvals/{approval_id}", summary: "Get an approval", tag: "approvals", handler: a.handleGetApproval,
+      resp: ledger.Approval{}},
+    {method: "POST", path: "/v1/approvals/{approval_id}/approve", summary: "Approve a pending action and perform it (a restore needs the admin key)", tag: "approvals", handler: a.handleDecideApproval(true),
+      body: DecideApprovalRequest{}, resp: ledger.Approval{}},
+    {method: "POST", path: "/v1/approvals/{approval_id}/reject", summary: "Reject or withdraw a pending action", tag: "approvals", handler: a.handleDecideApproval(false),
+      body: DecideApprovalRequest{}, resp: ledger.Approval{}},
+
+    // actor directory
+    {method: "GET", path: "/v1/actors", summary: "List registered actors", tag: "audit", handler: a.handleListActors,
+      query: []queryParam{{"kind", "string", "HUMAN, SERVICE or SCENARIO"}, {"all", "boolean", "include disabled actors"}}, resp: obj{"actors": []ledger.Actor{}}},
+    {method: "POST", path: "/v1/actors", summary: "Register an actor", tag: "audit", admin: true, handler: a.handleRegisterActor,
+      body: RegisterActorRequest{}, status: http.StatusCreated, resp: ledger.Actor{}},
+    {method: "GET", path: "/v1/actors/{actor_id}", summary: "Get an actor", tag: "audit", handler: a.handleGetActor,
+      resp: ledger.Actor{}},
+    {method: "DELETE", path: "/v1/actors/{actor_id}", summary: "Disable an actor", tag: "audit", admin: true, handler: a.handleDisableActor,
+      query: []queryParam{{"actor", "string", "who is disabling it"}, {"reason", "string", ""}}, resp: ledger.Actor{}},
+    {method: "GET", path: "/v1/actors/{actor_id}/activity", summary: "What an actor did, by action and target", tag: "audit", handler: a.handleActorActivity,
+      query: []queryParam{{"since", "string", "RFC 3339 time (default 7 days ago)"}}, resp: ledger.ActorActivity{}},
+
+    // reason-code catalog
+    {method: "GET", path: "/v1/reason-codes", summary: "List reason codes", tag: "audit", handler: a.handleListReasonCodes,
+      query: []queryParam{{"action", "string", "SET_ZONE_STATUS, SET_ZONE_CONTROLS or REPLAY_SPOOL: codes usable for it"}, {"all", "boolean", "include retired codes"}}, resp: obj{"reason_codes": []ledger.ReasonCode{}}},
+    {method: "POST", path: "/v1/reason-codes", summary: "Add a reason code", tag: "audit", admin: true, handler: a.handleRegisterReasonCode,
+      body: RegisterReasonCodeRequest{}, status: http.StatusCreated, resp: ledger.ReasonCode{}},
+    {method: "GET", path: "/v1/reason-codes/usage", summary: "Zone actions by reason code", tag: "audit", handler: a.handleReasonCodeUsage,
+      query: []queryParam{{"since", "string", "RFC 3339 time (default 7 days ago)"}, {"zone_id", "string", ""}}, resp: ledger.ReasonCodeUsage{}},
+    {method: "DELETE", path: "/v1/reason-codes/{code}", summary: "Retire a reason code", tag: "audit", admin: true, handler: a.handleRetireReasonCode,
+      query: []queryParam{{"actor", "string", "who is retiring it"}, {"reason", "string", ""}}, resp: ledger.ReasonCode{}},
+
+    // sagas
+    {method: "GET", path: "/v1/sagas", summary: "List multi-zone transfer sagas, newest first", tag: "transfers", handler: a.handleListSagas,
+      query: []queryParam{{"status", "string", "RUNNING, COMPLETED, COMPENSATED or FAILED"}, {"limit", "integer", "default 100, max 500"}},
+      resp: obj{"sagas": []ledger.Saga{}}},
+    {method: "POST", path: "/v1/sagas", summary: "Start a multi-zone transfer saga (debit, credit, compensation on failure)", tag: "transfers",
+      handler: a.handleStartSaga, body: StartSagaRequest{}, status: http.StatusAccepted, resp: ledger.Saga{}},
+    {method: "GET", path: "/v1/sagas/{saga_id}", summary: "Get a saga with its step attempts", tag: "transfers", handler: a.handleGetSaga,
+      resp: ledger.Saga{}},
+
+    // recurring transfers
+    {method: "GET", path: "/v1/recurring-transfers", summary: "List recurring transfers", tag: "transfers", handler: a.handleListRecurringTransfers,
+      query: []queryParam{{"zone_id", "string", ""}, {"all", "boolean", "include cancelled definitions"}}, resp: obj{"recurring_transfers": []ledger.RecurringTransfer{}}},
+    {method: "POST", path: "/v1/recurring-transfers", summary: "Define a recurring transfer (cron or interval)", tag: "transfers", handler: a.handleCreateRecurringTransfer,
+      body: CreateRecurringTransferRequest{}, status: http.StatusCreated, resp: ledger.RecurringTransfer{}},
+    {method: "GET", path: "/v1/recurring-transfers/{recurring_id}", summary: "Get a recurring transfer", tag: "transfers", handler: a.handleGetRecurringTransfer,
+      resp: ledger.RecurringTransfer{}},
+    {method: "POST", path: "/v1/recurring-transfers/{recurring_id}/pause", summary: "Pause a recurring transfer", tag: "transfers",
+      handler: a.handleRecurringTransferAction((*ledger.Ledger).PauseRecurringTransfer), body: RecurringTransferActionRequest{}, resp: ledger.RecurringTransfer{}},
+    {method: "POST", path: "/v1/recurring-transfers/{recurring_id}/resume", summary: "Resume a paused recurring transfer", tag: "transfers",
+      handler: a.handleRecurringTransferAction((*ledger.Ledger).ResumeRecurringTransfer), body: RecurringTransferActionRequest{}, resp: ledger.RecurringTransfer{}},
+    {method: "DELETE", path: "/v1/recurring-transfers/{recurring_id}", summary: "Cancel a recurring transfer", tag: "transfers", handler: a.handleCancelRecurringTransfer,
+      query: []queryParam{{"actor", "string", "who is cancelling it"}, {"reason", "string", ""}}, resp: ledger.RecurringTransfer{}},
+
+    {method: "GET", path: "/v1/accounts/{account_id}/tags", summary: "Get an account's tags", tag: "transfers", handler: a.handleGetAccountTags,
+      resp: ledger.AccountTags{}},
+    {method: "POST", path: "/v1/accounts/{account_id}/tags", summary: "Add tags to an account", tag: "transfers", handler: a.handleTagAccount,
+      body: TagAccountRequest{}, resp: ledger.AccountTags{}},
+    {method: "DELETE", path: "/v1/accounts/{account_id}/tags/{tag}", summary: "Remove a tag from an account", tag: "transfers", handler: a.handleUntagAccount,
+      query: []queryParam{{"actor", "string", "who is removing it"}, {"reason", "string", ""}}, resp: ledger.AccountTags{}},
+    {method: "GET", path: "/v1/account-tags/stats", summary: "Accounts, balances and postings per account tag", tag: "transfers", handler: a.handleAccountTagStats,
+      query: []queryParam{{"since", "string", "RFC 3339 time (default 24h ago)"}}, resp: ledger.AccountTagStats{}},
+
+    {method: "POST", path: "/v1/balance-subscriptions", summary: "Subscribe to an account's balance changes or threshold crossings (webhook_url requires the admin key)", tag: "transfers",
+      handler: a.handleCreateBalanceSubscription, body: CreateBalanceSubscriptionRequest{}, status: http.StatusCreated, resp: ledger.NewBalanceSubscription{}},
+    {method: "GET", path: "/v1/balance-subscriptions", summary: "List balance subscriptions", tag: "transfers", handler: a.handleListBalanceSubscriptions,
+      query: []queryParam{{"account_id", "string", "only this account's"}}, resp: obj{"subscriptions": []ledger.BalanceSubscription{}}},
+    {method: "GET", path: "/v1/balance-subscriptions/{subscription_id}", summary: "Get a balance subscription", tag: "transfers", handler: a.handleGetBalanceSubscription,
+      resp: ledger.BalanceSubscription{}},
+    {method: "DELETE", path: "/v1/balance-subscriptions/{subscription_id}", summary: "Delete a balance subscription", tag: "transfers", handler: a.handleDeleteBalanceSubscription,
+      query: []queryParam{{"actor", "string", "who is deleting it"}, {"reason", "string", ""}}, resp: ledger.BalanceSubscription{}},
+    {method: "GET", path: "/v1/balance-subscriptions/{subscription_id}/stream", summary: "Stream a subscription's notifications as server-sent events", tag: "transfers",
+      handler: a.handleStreamBalanceSubscription, stream: true, resp: ledger.BalanceNotification{}},
+
+    {method: "GET", path: "/v1/accounts/{account_id}/controls", summary: "Get account controls", tag: "controls", handler: a.handleGetAccountControls,
+      resp: ledger.AccountControls{}},
+    {method: "POST", path: "/v1/accounts/{account_id}/controls", summary: "Set account controls", tag: "controls", handler: a.handleSetAccountControls,
+      body: SetAccountControlsRequest{}, resp: ledger.AccountControls{}},
+
+    // sim admin (snapshots)
+    {method: "POST", path: "/v1/sim/snapshot", summary: "Take a snapshot (JSON, NDJSON stream, or to object storage)", tag: "snapshots", admin: true, handler: a.handleSnapshot,
+      query: []queryParam{{"format", "string", "ndjson to stream"}, {"full", "boolean", "include transaction history"}, {"dest", "string", "s3://bucket/key to write to object storage"}},
+      resp: map[string]any{}},
+    {method: "POST", path: "/v1/sim/restore", summary: "Restore a snapshot", tag: "snapshots", admin: true, handler: a.handleRestore,
+      query: []queryParam{{"format", "string", "ndjson"}, {"src", "string", "s3://bucket/key to read from object storage"}, {"dry_run", "boolean", "validate only"}, {"scope", "string", "comma-separated: zones,controls,balances,incidents,spool,audit"},
+        {"actor", "string", "who is restoring (required while restores need approval)"}, {"reason", "string", ""}},
+      body: map[string]any{}, resp: ledger.RestoreReport{}, extra: pendingApprovalResp},
+    {method: "POST", path: "/v1/sim/snapshot/diff", summary: "Diff two snapshots, or one against current state", tag: "snapshots", admin: true, handler: a.handleSnapshotDiff,
+      body: SnapshotDiffRequest{}, resp: ledger.SnapshotDiff{}},
+
+    // sim admin (chaos scenarios)
+    {method: "POST", path: "/v1/sim/scenarios", summary: "Upload a scenario (JSON or YAML)", tag: "scenarios", admin: true, handler: a.handleUploadScenario,
+      body: ledger.Scenario{}, status: http.StatusCreated, resp: ledger.Scenario{}},
+    {method: "GET", path: "/v1/sim/scenarios", summary: "List scenarios", tag: "scenarios", handler: a.handleListScenarios,
+      resp: obj{"scenarios": []ledger.Scenario{}}},
+    {method: "GET", path: "/v1/sim/scenarios/{name}", summary: "Scenario and its latest run", tag: "scenarios", handler: a.handleScenarioStatus,
+      resp: obj{"scenario": ledger.Scenario{}, "running": false, "last_run": &ledger.ScenarioRun{}}},
+    {method: "POST", path: "/v1/sim/scenarios/{name}/run", summary: "Run a scenario", tag: "scenarios", admin: true, handler: a.handleRunScenario,
+      status: http.StatusAccepted, resp: ledger.ScenarioRun{}},
+    {method: "POST", path: "/v1/sim/scenarios/{name}/stop", summary: "Stop a running scenario", tag: "scenarios", admin: true, handler: a.handleStopScenario,
+      resp: ledger.ScenarioRun{}},
+
+    // sim admin (virtual clock + random seed)
+    {method: "GET", path: "/v1/sim/clock", summary: "Clock state", tag: "clock", handler: a.handleGetClock,
+      resp: ledger.SimClockState{}},
+    {method: "GET", path: "/v1/sim/clock/skew-report", summary: "Clock skew anomalies", tag: "clock", handler: a.handleClockSkewReport,
+      query: []queryParam{limitParam}, resp: ledger.ClockSkewReport{}},
+    {method: "POST", path: "/v1/sim/clock", summary: "Set the clock rate", tag: "clock", admin: true, handler: a.handleSetClockRate,
+      body: SetClockRateRequest{}, resp: ledger.SimClockState{}},
+    {method: "POST", path: "/v1/sim/clock/freeze", summary: "Freeze the clock", tag: "clock", admin: true, handler: a.handleAdjustClock(ledger.ClockOpFreeze),
+      body: AdjustClockRequest{}, resp: ledger.SimClockState{}},
+    {method: "POST", path: "/v1/sim/clock/resume", summary: "Resume the clock", tag: "clock", admin: true, handler: a.handleAdjustClock(ledger.ClockOpResume),
+      body: AdjustClockRequest{}, resp: ledger.SimClockState{}},
+    {method: "POST", path: "/v1/sim/clock/advance", summary: "Advance the clock", tag: "clock", admin: true, handler: a.handleAdjustClock(ledger.ClockOpAdvance),
+      body: AdjustClockRequest{}, resp: ledger.SimClockState{}},
+    {method: "POST", path: "/v1/sim/clock/reset", summary: "Reset the clock to wall time", tag: "clock", admin: true, handler: a.handleAdjustClock(ledger.ClockOpReset),
+      body: AdjustClockRequest{}, resp: ledger.SimClockState{}},
+    {method: "POST", path: "/v1/sim/random-seed", summary: "Reseed the sim random source", tag: "clock", admin: true, handler: a.handleReseed,
+      body: ReseedRequest{}, resp: obj{"seed": uint64(0)}},
+    {method: "GET", path: "/v1/sim/reconcile", summary: "Report inconsistencies between the spool, transactions, outbox and incidents", tag: "sim", handler: a.handleReconcile,
+      resp: ledger.ReconcileReport{}},
+    {method: "POST", path: "/v1/sim/reconcile", summary: "Apply the safe reconciliation fixes", tag: "sim", admin: true, handler: a.handleReconcileFix,
+      body: ReconcileFixRequest{}, resp: ledger.ReconcileReport{}},
+    {method: "GET", path: "/v1/sim/settlements", summary: "Inter-zone settlement run history, newest first", tag: "sim", handler: a.handleListSettlementRuns,
+      query: []queryParam{limitParam}, resp: obj{"settlement_runs": []ledger.SettlementRun{}}},
+    {method: "POST", path: "/v1/sim/settlements", summary: "Net unsettled cross-zone transactions into settlement transactions now", tag: "sim", admin: true,
+      handler: a.handleRunSettlement, body: RunSettlementRequest{}, status: http.StatusCreated, resp: ledger.SettlementRun{}},
+    {method: "GET", path: "/v1/sim/settlements/{run_id}", summary: "Get a settlement run with its per zone pair obligations", tag: "sim", handler: a.handleGetSettlementRun,
+      resp: ledger.SettlementRun{}},
+    {method: "POST", path: "/v1/sim/seed", summary: "Seed accounts and history", tag: "sim", admin: true, handler: a.handleSeed,
+      body: SeedRequest{}, status: http.StatusCreated, resp: ledger.SeedResult{}},
+    {method: "GET", path: "/v1/sim/accruals", summary: "List interest/decay accrual rules", tag: "sim", handler: a.handleListAccrualRules,
+      query: []queryParam{{"zone_id", "string", ""}, {"all", "boolean", "include disabled rules"}}, resp: obj{"accruals": []ledger.AccrualRule{}}},
+    {method: "POST", path: "/v1/sim/accruals", summary: "Add an interest/decay accrual rule", tag: "sim", admin: true, handler: a.handleCreateAccrualRule,
+      body: CreateAccrualRuleRequest{}, status: http.StatusCreated, resp: ledger.AccrualRule{}},
+    {method: "DELETE", path: "/v1/sim/accruals/{rule_id}", summary: "Disable an accrual rule", tag: "sim", admin: true, handler: a.handleDisableAccrualRule,
+      query: []queryParam{{"actor", "string", "who is disabling it"}, {"reason", "string", ""}}, resp: ledger.AccrualRule{}},
+
+    // sim runs (tagging + before/after summaries)
+    {method: "GET", path: "/v1/sim/runs", summary: "List sim runs", tag: "sim", handler: a.handleListSimRuns,
+      query: []queryParam{limitParam}, resp: obj{"runs": []ledger.SimRun{}}},
+    {method: "POST", path: "/v1/sim/runs", summary: "Start a sim run", tag: "sim", admin: true, handler: a.handleStartSimRun,
+      body: StartSimRunRequest{}, status: http.StatusCreated, resp: ledger.SimRun{}},
+    {method: "GET", path: "/v1/sim/runs/{run_id}", summary: "Get a sim run", tag: "sim", handler: a.handleGetSimRun,
+      resp: ledger.SimRun{}},
+    {method: "POST", path: "/v1/sim/runs/{run_id}/stop", summary: "Stop a sim run", tag: "sim", admin: true, handler: a.handleStopSimRun,
+      body: StopSimRunRequest{}, resp: ledger.SimRun{}},
+    {method: "GET", path: "/v1/sim/runs/{run_id}/summary", summary: "Sim run summary", tag: "sim", handler: a.handleSimRunSummary,
+      resp: ledger.SimRunSummary{}},
+    {method: "GET", path: "/v1/sim/runs/{run_id}/compare/{other_run_id}", summary: "Compare a sim run (before) with another (after)", tag: "sim",
+      handler: a.handleCompareSimRuns, resp: ledger.SimRunComparison{}},
+
+    // sim admin (training drills run by a game master)
+    {method: "POST", path: "/v1/sim/drills", summary: "Start a drill from a script (JSON or YAML)", tag: "drills", admin: true, handler: a.handleStartDrill,
+      body: ledger.StartDrillInput{}, status: http.StatusCreated, resp: ledger.Drill{}},
+    {method: "GET", path: "/v1/sim/drills", summary: "List drills, newest first", tag: "drills", admin: true, handler: a.handleListDrills,
+      query: []queryParam{limitParam}, resp: obj{"drills": []ledger.Drill{}}},
+    {method: "GET", path: "/v1/sim/drills/{drill_id}", summary: "Get a drill with its script and inject log", tag: "drills", admin: true, handler: a.handleGetDrill,
+      resp: ledger.Drill{}},
+    {method: "POST", path: "/v1/sim/drills/{drill_id}/injects", summary: "Make an ad hoc inject in a running drill", tag: "drills", admin: true, handler: a.handleDrillInject,
+      body: ledger.DrillInject{}, resp: ledger.Drill{}},
+    {method: "POST", path: "/v1/sim/drills/{drill_id}/end", summary: "End a drill, revealing its audit entries, and debrief it", tag: "drills", admin: true, handler: a.handleEndDrill,
+      body: EndDrillRequest{}, resp: ledger.DrillDebrief{}},
+    {method: "GET", path: "/v1/sim/drills/{drill_id}/debrief", summary: "Compare the trainees' actions with the drill script", tag: "drills", admin: true, handler: a.handleDrillDebrief,
+      resp: ledger.DrillDebrief{}},
+
+    // sim admin (tenants: independent simulations, scoped by X-Tenant-Key when MULTI_TENANT is on)
+    {method: "POST", path: "/v1/tenants", summary: "Create a tenant", tag: "tenants", admin: true, handler: a.handleCreateTenant,
+      body: CreateTenantRequest{}, status: http.StatusCreated, resp: ledger.Tenant{}},
+    {method: "GET", path: "/v1/tenants", summary: "List tenants", tag: "tenants", admin: true, handler: a.handleListTenants,
+      resp: obj{"tenants": []ledger.Tenant{}}},
+    {method: "POST", path: "/v1/tenants/{tenant_id}/keys", summary: "Issue a tenant API key; the key is only in this response", tag: "tenants", admin: true, handler: a.handleCreateTenantKey,
+      body: CreateTenantKeyRequest{}, status: http.StatusCreated, resp: ledger.NewTenantKey{}},
+    {method: "GET", path: "/v1/tenants/{tenant_id}/keys", summary: "List a tenant's API keys", tag: "tenants", admin: true, handler: a.handleListTenantKeys,
+      resp: obj{"keys": []ledger.TenantKey{}}},
+    {method: "DELETE", path: "/v1/tenants/{tenant_id}/keys/{key_id}", summary: "Revoke a tenant API key", tag: "tenants", admin: true, handler: a.handleRevokeTenantKey,
+      query: []queryParam{{"actor", "string", "who is revoking it"}, {"reason", "string", ""}}, resp: ledger.TenantKey{}},
+
+    // sim admin (drain before shutdown)
+    {method: "POST", path: drainPath, summary: "Stop accepting writes, finish in-flight ones and flush the outbox", tag: "sim", admin: true, handler: a.handleDrain,
+      resp: DrainReport{}},
+
+    // sim admin (runtime config)
+    {method: "GET", path: "/v1/sim/config", summary: "Tunables in effect", tag: "sim", admin: true, handler: a.handleGetConfig,
+      resp: map[string]any{}},
+    {method: "POST", path: reloadPath, summary: "Re-read the config file and environment and apply tunables", tag: "sim", admin: true, handler: a.handleReloadConfig,
+      resp: ReloadReport{}},
+
+    // sim admin (JetStream streams and consumers)
+    {method: "GET", path: "/v1/sim/streams", summary: "List JetStream streams with their state", tag: "sim", admin: true, handler: a.handleListStreams,
+      resp: obj{"streams": []messaging.StreamInfo{}}},
+    {method: "GET", path: "/v1/sim/streams/{stream}", summary: "Get a stream with its consumers", tag: "sim", admin: true, handler: a.handleGetStream,
+      resp: messaging.StreamDetail{}},
+    {method: "POST", path: "/v1/sim/streams/{stream}/purge", summary: "Purge a stream's messages, optionally one subject's, keeping the newest", tag: "sim", admin: true, handler: a.handlePurgeStream,
+      body: PurgeStreamRequest{}, resp: messaging.PurgeReport{}},
+    {method: "GET", path: "/v1/sim/streams/{stream}/consumers/{consumer}", summary: "Get a consumer's config, pending and ack floor", tag: "sim", admin: true, handler: a.handleGetConsumer,
+      resp: messaging.ConsumerInfo{}},
+    {method: "POST", path: "/v1/sim/streams/{stream}/consumers/{consumer}/reset", summary: "Recreate a durable consumer from a new starting point", tag: "sim", admin: true, handler: a.handleResetConsumer,
+      body: ResetConsumerRequest{}, resp: messaging.ConsumerInfo{}},
+
+    // sim admin (database latency)
+    {method: "GET", path: "/v1/sim/db/activity", summary: "Slow queries, long transactions and transactions open now", tag: "sim", admin: true, handler: a.handleDBActivity,
+      resp: DBActivity{}},
+
+    // sim admin (network partitions)
+    {method: "GET", path: "/v1/sim/partitions", summary: "List partitions", tag: "sim", handler: a.handleListPartitions,
+      resp: obj{"partitions": []ledger.Partition{}}},
+    {method: "POST", path: "/v1/sim/partitions", summary: "Partition zones", tag: "sim", admin: true, handler: a.handleCreatePartition,
+      body: PartitionRequest{}, status: http.StatusCreated, resp: obj{"partitions": []ledger.Partition{}}},
+    {method: "POST", path: "/v1/sim/partitions/heal", summary: "Heal a partition", tag: "sim", admin: true, handler: a.handleHealPartition,
+      body: PartitionRequest{}, resp: obj{"healed": int64(0)}},
+  }
+}
+
+func (a *API) RegisterRoutes(r chi.Router) {
+  for _, rt := range a.routes() {
+    h := rt.handler
+    if rt.admin { h = a.admin(h) }
+    r.Method(rt.method, rt.path, h)
+  }
+}
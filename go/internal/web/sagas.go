@@ -0,0 +1,57 @@
+package web
+
+import (
+  "encoding/json"
+  "net/http"
+  "strings"
+  "time"
+
+  "github.com/go-chi/chi/v5"
+
+  "time-ledger-sim/go/internal/ledger"
+  "time-ledger-sim/go/internal/util"
+)
+
+// --- sagas ---
+
+type StartSagaRequest struct {
+  RequestID string `json:"request_id" validate:"required"`
+  FromZone string `json:"from_zone" validate:"required,zone_id"`
+  ToZone string `json:"to_zone" validate:"required,zone_id"`
+  FromAccount string `json:"from_account" validate:"required"`
+  ToAccount string `json:"to_account" validate:"required"`
+  AmountUnits int64 `json:"amount_units" validate:"gt=0"`
+  Metadata map[string]any `json:"metadata"`
+  StepTimeout string `json:"step_timeout" validate:"omitempty,duration"` // how long a blocked step is retried; default 5m
+}
+
+func (a *API) handleStartSaga(w http.ResponseWriter, r *http.Request) {
+  var req StartSagaRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  if !validRequest(w, r, req) { return }
+  if req.Metadata == nil { req.Metadata = map[string]any{} }
+  var timeout time.Duration
+  if req.StepTimeout != "" { timeout, _ = time.ParseDuration(strings.TrimSpace(req.StepTimeout)) }
+
+  payloadHash, err := util.HashCanonicalJSON(req)
+  if err != nil { writeProblem(w, r, 500, CodeInternal, "hash error"); return }
+
+  s, err := a.led.StartSaga(r.Context(), ledger.StartSagaInput{
+    RequestID: req.RequestID, PayloadHash: payloadHash, FromZone: req.FromZone, ToZone: req.ToZone,
+    FromAccount: req.FromAccount, ToAccount: req.ToAccount, AmountUnits: req.AmountUnits, Metadata: req.Metadata, StepTimeout: timeout,
+  })
+  if err != nil { writeError(w, r, err, 400); return }
+  writeJSON(w, http.StatusAccepted, s)
+}
+
+func (a *API) handleListSagas(w http.ResponseWriter, r *http.Request) {
+  list, err := a.led.ListSagas(r.Context(), r.URL.Query().Get("status"), util.QueryInt(r, "limit", 100))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeList(w, r, "sagas", list)
+}
+
+func (a *API) handleGetSaga(w http.ResponseWriter, r *http.Request) {
+  s, err := a.led.GetSaga(r.Context(), chi.URLParam(r, "saga_id"))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, s)
+}
@@ -0,0 +1,68 @@
+package web
+
+import (
+  "io"
+  "net/http"
+  "strings"
+
+  "github.com/go-chi/chi/v5"
+
+  "time-ledger-sim/go/internal/ledger"
+)
+
+// --- chaos scenarios (admin) ---
+
+const maxScenarioBytes = 1 << 20
+
+func (a *API) handleUploadScenario(w http.ResponseWriter, r *http.Request) {
+  body, err := io.ReadAll(io.LimitReader(r.Body, maxScenarioBytes))
+  if err != nil { writeProblem(w, r, 400, CodeInvalidRequest, "bad body"); return }
+  ct := r.Header.Get("Content-Type")
+  isYAML := strings.Contains(ct, "yaml")
+  sc, err := ledger.ParseScenario(body, isYAML)
+  if err != nil { writeError(w, r, err, 400); return }
+  if err := a.led.SaveScenario(r.Context(), sc); err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, http.StatusCreated, sc)
+}
+
+func (a *API) handleListScenarios(w http.ResponseWriter, r *http.Request) {
+  list, err := a.led.ListScenarios(r.Context())
+  if err != nil { writeError(w, r, err, 500); return }
+  writeList(w, r, "scenarios", list)
+}
+
+func (a *API) handleRunScenario(w http.ResponseWriter, r *http.Request) {
+  name := chi.URLParam(r, "name")
+  run, err := a.scenarios.Start(r.Context(), name)
+  if err != nil {
+    writeError(w, r, err, 500)
+    return
+  }
+  writeJSON(w, http.StatusAccepted, run)
+}
+
+func (a *API) handleStopScenario(w http.ResponseWriter, r *http.Request) {
+  name := chi.URLParam(r, "name")
+  run, err := a.scenarios.Stop(r.Context(), name)
+  if err != nil {
+    writeError(w, r, err, 500)
+    return
+  }
+  writeJSON(w, 200, run)
+}
+
+func (a *API) handleScenarioStatus(w http.ResponseWriter, r *http.Request) {
+  name := chi.URLParam(r, "name")
+  sc, err := a.led.GetScenario(r.Context(), name)
+  if err != nil {
+    writeError(w, r, err, 500)
+    return
+  }
+  run, err := a.led.LatestScenarioRun(r.Context(), name)
+  if err != nil && !ledger.IsScenarioNotRunning(err) { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, map[string]any{
+    "scenario": sc,
+    "running": a.scenarios.IsRunning(name),
+    "last_run": run,
+  })
+}
@@ -0,0 +1,63 @@
+package web
+
+import (
+  "encoding/json"
+  "net/http"
+  "time"
+
+  "github.com/go-chi/chi/v5"
+)
+
+// --- scheduled controls changes ---
+
+type ScheduleZoneControlsRequest struct {
+  SetZoneControlsRequest
+  ApplyAt time.Time `json:"apply_at" validate:"required"`
+}
+
+func (a *API) handleScheduleZoneControls(w http.ResponseWriter, r *http.Request) {
+  zoneID := chi.URLParam(r, "zone_id")
+  var req ScheduleZoneControlsRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  req.Actor = actorFor(r, req.Actor)
+  if !validRequest(w, r, req) || !validBlockedActions(w, r, req.BlockedActions) { return }
+  if a.led.ZoneControlsNeedApproval(req.toInput()) {
+    ap, err := a.led.RequestZoneControlsApproval(r.Context(), zoneID, req.ApplyAt, req.toInput())
+    if err != nil { writeError(w, r, err, 400); return }
+    writeJSON(w, http.StatusAccepted, ap)
+    return
+  }
+  s, err := a.led.ScheduleZoneControls(r.Context(), zoneID, req.ApplyAt, req.toInput())
+  if err != nil {
+    writeError(w, r, err, 400)
+    return
+  }
+  writeJSON(w, http.StatusCreated, s)
+}
+
+func (a *API) handleListScheduledControls(w http.ResponseWriter, r *http.Request) {
+  zoneID := chi.URLParam(r, "zone_id")
+  all := r.URL.Query().Get("all") == "true"
+  list, err := a.led.ListScheduledControls(r.Context(), zoneID, all)
+  if err != nil { writeError(w, r, err, 500); return }
+  writeList(w, r, "scheduled", list)
+}
+
+type CancelScheduledControlsRequest struct {
+  Actor string `json:"actor" validate:"required"`
+  Reason string `json:"reason"`
+}
+
+func (a *API) handleCancelScheduledControls(w http.ResponseWriter, r *http.Request) {
+  id := chi.URLParam(r, "schedule_id")
+  var req CancelScheduledControlsRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  req.Actor = actorFor(r, req.Actor)
+  if !validRequest(w, r, req) { return }
+  s, err := a.led.CancelScheduledControls(r.Context(), id, req.Actor, req.Reason)
+  if err != nil {
+    writeError(w, r, err, 500)
+    return
+  }
+  writeJSON(w, 200, s)
+}
@@ -0,0 +1,47 @@
+package web
+
+import (
+  "encoding/json"
+  "net/http"
+  "strings"
+  "time"
+
+  "time-ledger-sim/go/internal/ledger"
+)
+
+type SeedRequest struct {
+  Seed uint64 `json:"seed"` // 0 = current sim seed
+  Zones []string `json:"zones"`
+  AccountsPerZone int `json:"accounts_per_zone" validate:"min=1"`
+  StartingBalanceUnits int64 `json:"starting_balance_units" validate:"min=0"`
+  HistoryTransactions int `json:"history_transactions" validate:"min=0"` // per zone
+  HistorySpan string `json:"history_span" validate:"omitempty,duration"` // default 24h
+  MaxAmountUnits int64 `json:"max_amount_units" validate:"min=0"`
+  Actor string `json:"actor" validate:"required"`
+  Reason string `json:"reason"`
+}
+
+func (a *API) handleSeed(w http.ResponseWriter, r *http.Request) {
+  var req SeedRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  req.Actor = actorFor(r, req.Actor)
+  if !validRequest(w, r, req) { return }
+  var span time.Duration
+  if req.HistorySpan != "" { span, _ = time.ParseDuration(strings.TrimSpace(req.HistorySpan)) }
+  res, err := a.led.SeedData(r.Context(), ledger.SeedInput{
+    Seed: req.Seed,
+    Zones: req.Zones,
+    AccountsPerZone: req.AccountsPerZone,
+    StartingBalanceUnits: req.StartingBalanceUnits,
+    HistoryTransactions: req.HistoryTransactions,
+    HistorySpan: span,
+    MaxAmountUnits: req.MaxAmountUnits,
+    Actor: req.Actor,
+    Reason: req.Reason,
+  })
+  if err != nil {
+    writeError(w, r, err, 400)
+    return
+  }
+  writeJSON(w, http.StatusCreated, res)
+}
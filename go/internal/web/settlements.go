@@ -0,0 +1,39 @@
+package web
+
+import (
+  "encoding/json"
+  "net/http"
+
+  "github.com/go-chi/chi/v5"
+
+  "time-ledger-sim/go/internal/util"
+)
+
+// --- inter-zone settlement ---
+
+type RunSettlementRequest struct {
+  Actor string `json:"actor" validate:"required"`
+  Reason string `json:"reason"`
+}
+
+func (a *API) handleRunSettlement(w http.ResponseWriter, r *http.Request) {
+  var req RunSettlementRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  req.Actor = actorFor(r, req.Actor)
+  if !validRequest(w, r, req) { return }
+  run, err := a.led.RunSettlement(r.Context(), req.Actor, req.Reason)
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, http.StatusCreated, run)
+}
+
+func (a *API) handleListSettlementRuns(w http.ResponseWriter, r *http.Request) {
+  list, err := a.led.ListSettlementRuns(r.Context(), util.QueryInt(r, "limit", 100))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeList(w, r, "settlement_runs", list)
+}
+
+func (a *API) handleGetSettlementRun(w http.ResponseWriter, r *http.Request) {
+  run, err := a.led.GetSettlementRun(r.Context(), chi.URLParam(r, "run_id"))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, run)
+}
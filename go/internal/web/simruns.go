@@ -0,0 +1,65 @@
+package web
+
+import (
+  "encoding/json"
+  "net/http"
+
+  "github.com/go-chi/chi/v5"
+
+  "time-ledger-sim/go/internal/util"
+)
+
+type StartSimRunRequest struct {
+  Name string `json:"name" validate:"required"`
+  Actor string `json:"actor" validate:"required"`
+  Reason string `json:"reason"`
+}
+
+type StopSimRunRequest struct {
+  Actor string `json:"actor" validate:"required"`
+  Reason string `json:"reason"`
+}
+
+func (a *API) handleListSimRuns(w http.ResponseWriter, r *http.Request) {
+  runs, err := a.led.ListSimRuns(r.Context(), util.QueryInt(r, "limit", 50))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeList(w, r, "runs", runs)
+}
+
+func (a *API) handleStartSimRun(w http.ResponseWriter, r *http.Request) {
+  var req StartSimRunRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  req.Actor = actorFor(r, req.Actor)
+  if !validRequest(w, r, req) { return }
+  run, err := a.led.StartSimRun(r.Context(), req.Name, req.Actor, req.Reason)
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, http.StatusCreated, run)
+}
+
+func (a *API) handleGetSimRun(w http.ResponseWriter, r *http.Request) {
+  run, err := a.led.GetSimRun(r.Context(), chi.URLParam(r, "run_id"))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, run)
+}
+
+func (a *API) handleStopSimRun(w http.ResponseWriter, r *http.Request) {
+  var req StopSimRunRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  req.Actor = actorFor(r, req.Actor)
+  if !validRequest(w, r, req) { return }
+  run, err := a.led.StopSimRun(r.Context(), chi.URLParam(r, "run_id"), req.Actor, req.Reason)
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, run)
+}
+
+func (a *API) handleSimRunSummary(w http.ResponseWriter, r *http.Request) {
+  s, err := a.led.SimRunSummary(r.Context(), chi.URLParam(r, "run_id"))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, s)
+}
+
+func (a *API) handleCompareSimRuns(w http.ResponseWriter, r *http.Request) {
+  c, err := a.led.CompareSimRuns(r.Context(), chi.URLParam(r, "run_id"), chi.URLParam(r, "other_run_id"))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, c)
+}
@@ -0,0 +1,50 @@
+package web
+
+import (
+  "net/http"
+  "slices"
+  "strings"
+  "time"
+
+  "time-ledger-sim/go/internal/ledger"
+)
+
+// defaultSeriesRange is how far back a time series starts without from.
+const defaultSeriesRange = time.Hour
+
+// handleStatsTimeSeries serves a rolled-up metric as zero-filled points,
+// e.g. for a Grafana JSON data source.
+func (a *API) handleStatsTimeSeries(w http.ResponseWriter, r *http.Request) {
+  q := r.URL.Query()
+  metric := q.Get("metric")
+  if !slices.Contains(ledger.StatsMetrics, metric) {
+    writeValidationProblem(w, r, FieldError{Field: "metric", Message: "must be one of " + strings.Join(ledger.StatsMetrics, ", ")})
+    return
+  }
+  step := time.Minute
+  if s := q.Get("step"); s != "" {
+    d, err := time.ParseDuration(s)
+    if err != nil || d < time.Minute || d > 24*time.Hour || d%time.Minute != 0 {
+      writeValidationProblem(w, r, FieldError{Field: "step", Message: "must be a whole number of minutes between 1m and 24h"})
+      return
+    }
+    step = d
+  }
+  from, ok := timeQuery(w, r, "from")
+  if !ok { return }
+  to, ok := timeQuery(w, r, "to")
+  if !ok { return }
+  if to.IsZero() { to = a.led.Now() }
+  if from.IsZero() { from = to.Add(-defaultSeriesRange) }
+  if !from.Before(to) {
+    writeValidationProblem(w, r, FieldError{Field: "from", Message: "must be before to"})
+    return
+  }
+  if to.Sub(from)/step > ledger.MaxStatsPoints {
+    writeValidationProblem(w, r, FieldError{Field: "step", Message: "too fine for the range: at most 1440 points"})
+    return
+  }
+  s, err := a.led.StatsTimeSeries(r.Context(), metric, q.Get("zone"), step, from, to)
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, s)
+}
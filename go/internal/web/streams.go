@@ -0,0 +1,97 @@
+package web
+
+import (
+  "context"
+  "encoding/json"
+  "net/http"
+  "time"
+
+  "github.com/go-chi/chi/v5"
+
+  "time-ledger-sim/go/internal/messaging"
+)
+
+// --- JetStream streams and consumers (what the nats CLI would show) ---
+
+// StreamManager inspects and manages JetStream streams and consumers
+// (messaging.StreamAdmin).
+type StreamManager interface {
+  Streams(ctx context.Context) ([]messaging.StreamInfo, error)
+  Stream(ctx context.Context, name string) (*messaging.StreamDetail, error)
+  Consumer(ctx context.Context, stream, name string) (*messaging.ConsumerInfo, error)
+  Purge(ctx context.Context, stream string, in messaging.PurgeInput) (*messaging.PurgeReport, error)
+  ResetConsumer(ctx context.Context, stream, name string, in messaging.ResetConsumerInput) (*messaging.ConsumerInfo, error)
+}
+
+type PurgeStreamRequest struct {
+  Subject string `json:"subject"` // only this subject (wildcards allowed); default all
+  Keep uint64 `json:"keep"` // newest matching messages to keep
+  Actor string `json:"actor" validate:"required"`
+  Reason string `json:"reason" validate:"required"`
+}
+
+type ResetConsumerRequest struct {
+  Deliver string `json:"deliver" validate:"omitempty,oneof=all new last by_start_sequence by_start_time"` // default all
+  StartSeq uint64 `json:"start_seq"` // by_start_sequence
+  StartTime *time.Time `json:"start_time"` // by_start_time
+  Actor string `json:"actor" validate:"required"`
+  Reason string `json:"reason" validate:"required"`
+}
+
+// Errors other than not found mean NATS could not answer: 503.
+
+func (a *API) handleListStreams(w http.ResponseWriter, r *http.Request) {
+  if a.streams == nil { writeProblem(w, r, http.StatusNotFound, CodeNotFound, "stream admin not available"); return }
+  streams, err := a.streams.Streams(r.Context())
+  if err != nil { writeError(w, r, err, http.StatusServiceUnavailable); return }
+  writeList(w, r, "streams", streams)
+}
+
+func (a *API) handleGetStream(w http.ResponseWriter, r *http.Request) {
+  if a.streams == nil { writeProblem(w, r, http.StatusNotFound, CodeNotFound, "stream admin not available"); return }
+  s, err := a.streams.Stream(r.Context(), chi.URLParam(r, "stream"))
+  if err != nil { writeError(w, r, err, http.StatusServiceUnavailable); return }
+  writeJSON(w, 200, s)
+}
+
+func (a *API) handlePurgeStream(w http.ResponseWriter, r *http.Request) {
+  if a.streams == nil { writeProblem(w, r, http.StatusNotFound, CodeNotFound, "stream admin not available"); return }
+  var req PurgeStreamRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  req.Actor = actorFor(r, req.Actor)
+  if !validRequest(w, r, req) { return }
+  rep, err := a.streams.Purge(r.Context(), chi.URLParam(r, "stream"), messaging.PurgeInput{Subject: req.Subject, Keep: req.Keep})
+  if err != nil { writeError(w, r, err, http.StatusServiceUnavailable); return }
+  writeJSON(w, 200, rep)
+}
+
+func (a *API) handleGetConsumer(w http.ResponseWriter, r *http.Request) {
+  if a.streams == nil { writeProblem(w, r, http.StatusNotFound, CodeNotFound, "stream admin not available"); return }
+  c, err := a.streams.Consumer(r.Context(), chi.URLParam(r, "stream"), chi.URLParam(r, "consumer"))
+  if err != nil { writeError(w, r, err, http.StatusServiceUnavailable); return }
+  writeJSON(w, 200, c)
+}
+
+// handleResetConsumer recreates a durable consumer from a new starting point;
+// what it had not acked is delivered again.
+func (a *API) handleResetConsumer(w http.ResponseWriter, r *http.Request) {
+  if a.streams == nil { writeProblem(w, r, http.StatusNotFound, CodeNotFound, "stream admin not available"); return }
+  var req ResetConsumerRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  req.Actor = actorFor(r, req.Actor)
+  if !validRequest(w, r, req) { return }
+  in := messaging.ResetConsumerInput{Deliver: req.Deliver, StartSeq: req.StartSeq}
+  switch {
+  case req.Deliver == "by_start_sequence" && req.StartSeq == 0:
+    writeValidationProblem(w, r, FieldError{Field: "start_seq", Message: "is required for by_start_sequence"})
+    return
+  case req.Deliver == "by_start_time" && req.StartTime == nil:
+    writeValidationProblem(w, r, FieldError{Field: "start_time", Message: "is required for by_start_time"})
+    return
+  case req.StartTime != nil:
+    in.StartTime = *req.StartTime
+  }
+  c, err := a.streams.ResetConsumer(r.Context(), chi.URLParam(r, "stream"), chi.URLParam(r, "consumer"), in)
+  if err != nil { writeError(w, r, err, http.StatusServiceUnavailable); return }
+  writeJSON(w, 200, c)
+}
@@ -0,0 +1,91 @@
+package web
+
+import (
+  "encoding/json"
+  "net/http"
+
+  "github.com/go-chi/chi/v5"
+
+  "time-ledger-sim/go/internal/ledger"
+)
+
+// --- transfer templates ---
+
+type SaveTransferTemplateRequest struct {
+  Name string `json:"name" validate:"required"` // [a-z0-9][a-z0-9_-]{0,63}
+  Description string `json:"description"`
+  ZoneID string `json:"zone_id" validate:"required,zone_id"`
+  FromAccount string `json:"from_account" validate:"required"`
+  ToAccount string `json:"to_account" validate:"required"`
+  AmountUnits int64 `json:"amount_units" validate:"gt=0"`
+  Metadata map[string]any `json:"metadata"`
+  Actor string `json:"actor" validate:"required"`
+  Reason string `json:"reason"`
+}
+
+func (a *API) handleSaveTransferTemplate(w http.ResponseWriter, r *http.Request) {
+  var req SaveTransferTemplateRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  req.Actor = actorFor(r, req.Actor)
+  if !validRequest(w, r, req) { return }
+  t, err := a.led.SaveTransferTemplate(r.Context(), ledger.SaveTransferTemplateInput{
+    Name: req.Name, Description: req.Description, ZoneID: req.ZoneID, FromAccount: req.FromAccount, ToAccount: req.ToAccount,
+    AmountUnits: req.AmountUnits, Metadata: req.Metadata, Actor: req.Actor, Reason: req.Reason,
+  })
+  if err != nil { writeError(w, r, err, 400); return }
+  writeJSON(w, 200, t)
+}
+
+func (a *API) handleListTransferTemplates(w http.ResponseWriter, r *http.Request) {
+  list, err := a.led.ListTransferTemplates(r.Context())
+  if err != nil { writeError(w, r, err, 500); return }
+  writeList(w, r, "templates", list)
+}
+
+func (a *API) handleGetTransferTemplate(w http.ResponseWriter, r *http.Request) {
+  t, err := a.led.GetTransferTemplate(r.Context(), chi.URLParam(r, "name"))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, t)
+}
+
+// handleDeleteTransferTemplate takes actor/reason as query params (DELETE has no body).
+func (a *API) handleDeleteTransferTemplate(w http.ResponseWriter, r *http.Request) {
+  q := r.URL.Query()
+  actor := actorFor(r, q.Get("actor"))
+  if actor == "" { writeValidationProblem(w, r, FieldError{Field: "actor", Message: "is required"}); return }
+  t, err := a.led.DeleteTransferTemplate(r.Context(), chi.URLParam(r, "name"), actor, q.Get("reason"))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, t)
+}
+
+// TemplateTransferRequest overrides a template's fields for one transfer; the
+// body may be empty. Without request_id every call is a new transfer.
+type TemplateTransferRequest struct {
+  RequestID string `json:"request_id"`
+  ZoneID string `json:"zone_id" validate:"omitempty,zone_id"`
+  FromAccount string `json:"from_account"`
+  ToAccount string `json:"to_account"`
+  AmountUnits int64 `json:"amount_units" validate:"min=0"`
+  Metadata map[string]any `json:"metadata"` // merged over the template's
+}
+
+func (a *API) handleTransferFromTemplate(w http.ResponseWriter, r *http.Request) {
+  var req TemplateTransferRequest
+  if r.ContentLength != 0 {
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  }
+  if !validRequest(w, r, req) { return }
+  in, err := a.led.TemplateTransfer(r.Context(), chi.URLParam(r, "name"), ledger.TemplateOverrides{
+    RequestID: req.RequestID, ZoneID: req.ZoneID, FromAccount: req.FromAccount, ToAccount: req.ToAccount,
+    AmountUnits: req.AmountUnits, Metadata: req.Metadata,
+  })
+  if err != nil { writeError(w, r, err, 400); return }
+
+  txn, spoolID, err := a.led.CreateTransfer(r.Context(), in)
+  if err != nil { writeError(w, r, err, 500); return }
+  if spoolID != nil {
+    writeJSON(w, http.StatusAccepted, TransferSpooledResponse{Status: "SPOOLED", SpoolID: *spoolID, RequestID: in.RequestID})
+    return
+  }
+  writeJSON(w, 200, TransferAppliedResponse{Status: "APPLIED", TransactionID: txn.ID, RequestID: txn.RequestID, CreatedAt: txn.CreatedAt, ZoneSeq: txn.ZoneSeq})
+}
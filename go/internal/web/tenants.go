@@ -0,0 +1,88 @@
+package web
+
+import (
+  "encoding/json"
+  "net/http"
+
+  "github.com/go-chi/chi/v5"
+
+  "time-ledger-sim/go/internal/ledger"
+  "time-ledger-sim/go/internal/store"
+)
+
+// --- tenants (independent simulations on one deployment) ---
+
+// TenantMiddleware scopes each request to a tenant when the ledger runs
+// multi-tenant: the tenant of its X-Tenant-Key, or the default tenant when it
+// has none. An unknown or revoked key is rejected. With tenancy off the
+// header is ignored.
+func (a *API) TenantMiddleware(next http.Handler) http.Handler {
+  return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+    if !a.led.MultiTenant() {
+      next.ServeHTTP(w, r)
+      return
+    }
+    tenant := store.DefaultTenant
+    if key := r.Header.Get("X-Tenant-Key"); key != "" {
+      t, err := a.led.TenantForKey(r.Context(), key)
+      if err != nil { writeError(w, r, err, http.StatusServiceUnavailable); return }
+      tenant = t
+    }
+    next.ServeHTTP(w, r.WithContext(store.WithTenant(r.Context(), tenant)))
+  })
+}
+
+type CreateTenantRequest struct {
+  ID string `json:"id" validate:"required"` // 2-32 of a-z, 0-9 and -
+  Name string `json:"name"` // default: the id
+  Actor string `json:"actor" validate:"required"`
+  Reason string `json:"reason"`
+}
+
+func (a *API) handleCreateTenant(w http.ResponseWriter, r *http.Request) {
+  var req CreateTenantRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  req.Actor = actorFor(r, req.Actor)
+  if !validRequest(w, r, req) { return }
+  t, err := a.led.CreateTenant(r.Context(), ledger.CreateTenantInput{ID: req.ID, Name: req.Name, Actor: req.Actor, Reason: req.Reason})
+  if err != nil { writeError(w, r, err, 400); return }
+  writeJSON(w, http.StatusCreated, t)
+}
+
+func (a *API) handleListTenants(w http.ResponseWriter, r *http.Request) {
+  ts, err := a.led.ListTenants(r.Context())
+  if err != nil { writeError(w, r, err, 500); return }
+  writeList(w, r, "tenants", ts)
+}
+
+type CreateTenantKeyRequest struct {
+  Label string `json:"label"` // e.g. the cohort or trainee it is for
+  Actor string `json:"actor" validate:"required"`
+  Reason string `json:"reason"`
+}
+
+func (a *API) handleCreateTenantKey(w http.ResponseWriter, r *http.Request) {
+  var req CreateTenantKeyRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  req.Actor = actorFor(r, req.Actor)
+  if !validRequest(w, r, req) { return }
+  k, err := a.led.CreateTenantKey(r.Context(), chi.URLParam(r, "tenant_id"), ledger.CreateTenantKeyInput{Label: req.Label, Actor: req.Actor, Reason: req.Reason})
+  if err != nil { writeError(w, r, err, 400); return }
+  writeJSON(w, http.StatusCreated, k)
+}
+
+func (a *API) handleListTenantKeys(w http.ResponseWriter, r *http.Request) {
+  ks, err := a.led.ListTenantKeys(r.Context(), chi.URLParam(r, "tenant_id"))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeList(w, r, "keys", ks)
+}
+
+// handleRevokeTenantKey takes actor/reason as query params (DELETE has no body).
+func (a *API) handleRevokeTenantKey(w http.ResponseWriter, r *http.Request) {
+  q := r.URL.Query()
+  actor := actorFor(r, q.Get("actor"))
+  if actor == "" { writeValidationProblem(w, r, FieldError{Field: "actor", Message: "is required"}); return }
+  k, err := a.led.RevokeTenantKey(r.Context(), chi.URLParam(r, "tenant_id"), chi.URLParam(r, "key_id"), actor, q.Get("reason"))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, k)
+}
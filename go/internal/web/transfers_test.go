@@ -0,0 +1,50 @@
+package web
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"time-ledger-sim/go/internal/ledger"
+	"time-ledger-sim/go/internal/ledger/ledgertest"
+)
+
+func TestCreateTransferHandler(t *testing.T) {
+	repo := ledgertest.NewMemRepo("zone-eu")
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	api := NewAPI("", ledger.NewWithRepo(repo, log), nil, nil, nil, nil, nil, nil, nil, log)
+	r := chi.NewRouter()
+	api.RegisterRoutes(r)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest("POST", "/v1/transfers", strings.NewReader(body)))
+		return rec
+	}
+	const body = `{"request_id":"req-1","from_account":"a","to_account":"b","amount_units":30,"zone_id":"zone-eu"}`
+
+	rec := post(body)
+	var applied TransferAppliedResponse
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &applied) != nil || applied.Status != "APPLIED" {
+		t.Fatalf("apply = %d %s", rec.Code, rec.Body)
+	}
+	if rec := post(strings.Replace(body, `"amount_units":30`, `"amount_units":31`, 1)); rec.Code != http.StatusConflict {
+		t.Fatalf("changed payload = %d %s, want 409", rec.Code, rec.Body)
+	}
+
+	repo.SetZoneStatus("zone-eu", "DOWN")
+	repo.SetZoneControls(ledger.ZoneControls{ZoneID: "zone-eu", CrossZoneThrottle: 100, SpoolEnabled: true})
+	rec = post(strings.Replace(body, "req-1", "req-2", 1))
+	if rec.Code != http.StatusAccepted || !strings.Contains(rec.Body.String(), `"SPOOLED"`) {
+		t.Fatalf("spool = %d %s", rec.Code, rec.Body)
+	}
+	if rec := post(`{"request_id":"req-3","from_account":"a","to_account":"b","amount_units":1,"zone_id":"zone-xx"}`); rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown zone = %d %s, want 404", rec.Code, rec.Body)
+	}
+}
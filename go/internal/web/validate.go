@@ -0,0 +1,138 @@
+package web
+
+import (
+  "fmt"
+  "net/http"
+  "reflect"
+  "strconv"
+  "strings"
+  "time"
+
+  "time-ledger-sim/go/internal/ledger"
+)
+
+// Request bodies declare their constraints in `validate` tags, checked after
+// decoding (and after actorFor, so an authenticated caller satisfies actor):
+//
+//   required      non-zero value
+//   omitempty     skip the remaining rules when the value is zero
+//   gt=N          number > N
+//   min=N, max=N  number within bounds
+//   oneof=A B C   one of the listed strings
+//   zone_id       matches ledger.ValidZoneID (zone-[a-z0-9-]+)
+//   duration      a Go duration string, e.g. "1h30m"
+//
+// Field names in errors are the json names. Embedded structs are flattened.
+
+// FieldError is one invalid field of a request body.
+type FieldError struct {
+  Field string `json:"field"`
+  Message string `json:"message"`
+}
+
+// ValidationProblem is the 400 body for a request that failed validation.
+type ValidationProblem struct {
+  Problem
+  Errors []FieldError `json:"errors"`
+}
+
+// validRequest validates v and, when it fails, writes a validation problem.
+func validRequest(w http.ResponseWriter, r *http.Request, v any) bool {
+  errs := validate(v)
+  if len(errs) == 0 { return true }
+  writeValidationProblem(w, r, errs...)
+  return false
+}
+
+func writeValidationProblem(w http.ResponseWriter, r *http.Request, errs ...FieldError) {
+  parts := make([]string, len(errs))
+  for i, e := range errs { parts[i] = e.Field + " " + e.Message }
+  p := newProblem(r, http.StatusBadRequest, CodeValidation, strings.Join(parts, "; "))
+  writeProblemBody(w, http.StatusBadRequest, ValidationProblem{Problem: p, Errors: errs})
+}
+
+func validate(v any) []FieldError {
+  rv := reflect.Indirect(reflect.ValueOf(v))
+  if rv.Kind() != reflect.Struct { return nil }
+  var errs []FieldError
+  validateStruct(rv, &errs)
+  return errs
+}
+
+func validateStruct(rv reflect.Value, errs *[]FieldError) {
+  t := rv.Type()
+  for i := 0; i < t.NumField(); i++ {
+    f := t.Field(i)
+    if f.Anonymous && f.Type.Kind() == reflect.Struct { validateStruct(rv.Field(i), errs); continue }
+    tag := f.Tag.Get("validate")
+    if tag == "" || !f.IsExported() { continue }
+    name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+    if name == "" { name = f.Name }
+    if msg := checkRules(rv.Field(i), tag); msg != "" {
+      *errs = append(*errs, FieldError{Field: name, Message: msg})
+    }
+  }
+}
+
+// checkRules returns the first failed rule's message, or "".
+func checkRules(fv reflect.Value, tag string) string {
+  for _, rule := range strings.Split(tag, ",") {
+    name, arg, _ := strings.Cut(rule, "=")
+    if name == "required" {
+      if fv.IsZero() { return "is required" }
+      continue
+    }
+    if fv.Kind() == reflect.Pointer {
+      if fv.IsNil() { return "" } // optional field
+      fv = fv.Elem()
+    }
+    if name == "omitempty" {
+      if fv.IsZero() { return "" }
+      continue
+    }
+    if msg := checkRule(fv, name, arg); msg != "" { return msg }
+  }
+  return ""
+}
+
+func checkRule(fv reflect.Value, name, arg string) string {
+  switch name {
+  case "gt":
+    if n, ok := number(fv); ok && n <= mustFloat(arg) { return "must be greater than " + arg }
+  case "min":
+    if n, ok := number(fv); ok && n < mustFloat(arg) { return "must be at least " + arg }
+  case "max":
+    if n, ok := number(fv); ok && n > mustFloat(arg) { return "must be at most " + arg }
+  case "oneof":
+    opts := strings.Fields(arg)
+    for _, o := range opts {
+      if fv.String() == o { return "" }
+    }
+    return "must be one of " + strings.Join(opts, ", ")
+  case "zone_id":
+    if !ledger.ValidZoneID(fv.String()) { return "must match zone-[a-z0-9-]+" }
+  case "duration":
+    if _, err := time.ParseDuration(strings.TrimSpace(fv.String())); err != nil { return `must be a duration like "1h30m"` }
+  default:
+    panic(fmt.Sprintf("validate: unknown rule %q", name))
+  }
+  return ""
+}
+
+func number(fv reflect.Value) (float64, bool) {
+  switch {
+  case fv.CanInt():
+    return float64(fv.Int()), true
+  case fv.CanUint():
+    return float64(fv.Uint()), true
+  case fv.CanFloat():
+    return fv.Float(), true
+  }
+  return 0, false
+}
+
+func mustFloat(s string) float64 {
+  n, err := strconv.ParseFloat(s, 64)
+  if err != nil { panic(fmt.Sprintf("validate: bad number %q", s)) }
+  return n
+}
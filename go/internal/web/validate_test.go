@@ -0,0 +1,91 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+func fieldErrors(errs []FieldError) map[string]string {
+	out := map[string]string{}
+	for _, e := range errs {
+		out[e.Field] = e.Message
+	}
+	return out
+}
+
+func TestValidateTransfer(t *testing.T) {
+	got := fieldErrors(validate(CreateTransferRequest{FromAccount: "a", ToAccount: "b", ZoneID: "eu-1"}))
+	want := map[string]string{
+		"request_id":   "is required",
+		"amount_units": "must be greater than 0",
+		"zone_id":      "must match zone-[a-z0-9-]+",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v", got)
+	}
+	for f, msg := range want {
+		if got[f] != msg {
+			t.Fatalf("%s: got %q, want %q", f, got[f], msg)
+		}
+	}
+	ok := CreateTransferRequest{RequestID: "r", FromAccount: "a", ToAccount: "b", AmountUnits: 1, ZoneID: "zone-eu"}
+	if errs := validate(ok); len(errs) != 0 {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+}
+
+func TestValidateEmbeddedAndOptional(t *testing.T) {
+	req := ScheduleZoneControlsRequest{SetZoneControlsRequest: SetZoneControlsRequest{ThrottleMode: "FAST", ErrorRatePercent: 101}}
+	got := fieldErrors(validate(req))
+	for _, f := range []string{"throttle_mode", "error_rate_percent", "actor", "apply_at"} {
+		if _, ok := got[f]; !ok {
+			t.Fatalf("expected error for %s, got %v", f, got)
+		}
+	}
+
+	if errs := validate(SetAccountControlsRequest{Actor: "ops"}); len(errs) != 0 {
+		t.Fatalf("nil throttle is optional, got %v", errs)
+	}
+	bad := 150
+	if got := fieldErrors(validate(SetAccountControlsRequest{Actor: "ops", Throttle: &bad})); got["throttle"] != "must be at most 100" {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestValidationProblemBody(t *testing.T) {
+	w := httptest.NewRecorder()
+	if validRequest(w, httptest.NewRequest("POST", "/v1/transfers", nil), StartSimRunRequest{}) {
+		t.Fatal("expected validation failure")
+	}
+	if w.Code != 400 || w.Header().Get("content-type") != "application/problem+json" {
+		t.Fatalf("status %d, content-type %q", w.Code, w.Header().Get("content-type"))
+	}
+	var p ValidationProblem
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Code != CodeValidation || len(p.Errors) != 2 || p.Errors[0].Field != "name" {
+		t.Fatalf("unexpected body %+v", p)
+	}
+}
+
+func TestOpenAPIDocumentsConstraints(t *testing.T) {
+	doc := buildOpenAPI((&API{}).routes())
+	schemas := doc["components"].(map[string]any)["schemas"].(map[string]any)
+	s := schemas["CreateTransferRequest"].(map[string]any)
+	props := s["properties"].(map[string]any)
+	if props["amount_units"].(map[string]any)["exclusiveMinimum"] != float64(0) {
+		t.Fatalf("amount_units: %v", props["amount_units"])
+	}
+	if props["zone_id"].(map[string]any)["pattern"] == nil {
+		t.Fatalf("zone_id: %v", props["zone_id"])
+	}
+	if req, _ := s["required"].([]string); len(req) != 4 {
+		t.Fatalf("required: %v", s["required"])
+	}
+	op := doc["paths"].(map[string]any)["/v1/transfers"].(map[string]any)["post"].(map[string]any)
+	if _, ok := op["responses"].(map[string]any)["400"]; !ok {
+		t.Fatal("validated bodies should document the 400 validation problem")
+	}
+}
@@ -0,0 +1,187 @@
+package web
+
+import (
+  "encoding/json"
+  "net/http"
+  "strconv"
+  "time"
+
+  "github.com/go-chi/chi/v5"
+
+  "time-ledger-sim/go/internal/ledger"
+  "time-ledger-sim/go/internal/metrics"
+  "time-ledger-sim/go/internal/util"
+)
+
+func (a *API) handleGetZoneHealth(w http.ResponseWriter, r *http.Request) {
+  zoneID := chi.URLParam(r, "zone_id")
+  h, err := a.led.GetZoneHealth(r.Context(), zoneID)
+  if err != nil {
+    writeError(w, r, err, 500)
+    return
+  }
+  writeJSON(w, 200, h)
+}
+
+func (a *API) handleListZoneTransactions(w http.ResponseWriter, r *http.Request) {
+  var after int64
+  if q := r.URL.Query().Get("after_seq"); q != "" {
+    n, err := strconv.ParseInt(q, 10, 64)
+    if err != nil || n < 0 { writeValidationProblem(w, r, FieldError{Field: "after_seq", Message: "must be a non-negative integer"}); return }
+    after = n
+  }
+  page, err := a.led.ListZoneTransactions(r.Context(), chi.URLParam(r, "zone_id"), after, util.QueryInt(r, "limit", 100))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, page)
+}
+
+const defaultStatsWindow = 5 * time.Minute
+
+func (a *API) handleGetZoneStats(w http.ResponseWriter, r *http.Request) {
+  zoneID := chi.URLParam(r, "zone_id")
+  window := defaultStatsWindow
+  if q := r.URL.Query().Get("window"); q != "" {
+    d, err := time.ParseDuration(q)
+    if err != nil || d < 10*time.Second || d > metrics.MaxWindow {
+      writeValidationProblem(w, r, FieldError{Field: "window", Message: "must be a duration between 10s and " + metrics.MaxWindow.String()})
+      return
+    }
+    window = d
+  }
+  st, err := a.led.GetZoneStats(r.Context(), zoneID, window)
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, st)
+}
+
+func (a *API) handleBalanceSheet(w http.ResponseWriter, r *http.Request) {
+  from, ok := timeQuery(w, r, "from")
+  if !ok { return }
+  to, ok := timeQuery(w, r, "to")
+  if !ok { return }
+  top := 0
+  if q := r.URL.Query().Get("top"); q != "" {
+    n, err := strconv.Atoi(q)
+    if err != nil || n < 1 || n > 100 { writeValidationProblem(w, r, FieldError{Field: "top", Message: "must be between 1 and 100"}); return }
+    top = n
+  }
+  bs, err := a.led.GetBalanceSheet(r.Context(), chi.URLParam(r, "zone_id"), from, to, top)
+  if err != nil { writeError(w, r, err, 400); return }
+  writeJSON(w, 200, bs)
+}
+
+func (a *API) handleGetBusinessDay(w http.ResponseWriter, r *http.Request) {
+  date, err := time.Parse("2006-01-02", chi.URLParam(r, "date"))
+  if err != nil { writeValidationProblem(w, r, FieldError{Field: "date", Message: "must be a date as YYYY-MM-DD"}); return }
+  s, err := a.led.GetBusinessDay(r.Context(), chi.URLParam(r, "zone_id"), date)
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, s)
+}
+
+const defaultFlowWindow = time.Hour
+
+func (a *API) handleFlows(w http.ResponseWriter, r *http.Request) {
+  window := defaultFlowWindow
+  if q := r.URL.Query().Get("window"); q != "" {
+    d, err := time.ParseDuration(q)
+    if err != nil || d < time.Minute || d > ledger.MaxFlowWindow {
+      writeValidationProblem(w, r, FieldError{Field: "window", Message: "must be a duration between 1m and " + ledger.MaxFlowWindow.String()})
+      return
+    }
+    window = d
+  }
+  m, err := a.led.GetFlowMatrix(r.Context(), window)
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, m)
+}
+
+func (a *API) handleLedgerProof(w http.ResponseWriter, r *http.Request) {
+  p, err := a.led.LedgerProof(r.Context(), chi.URLParam(r, "zone_id"))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, p)
+}
+
+// --- zone lifecycle (admin) ---
+
+type CreateZoneRequest struct {
+  ID string `json:"id" validate:"required,zone_id"`
+  Name string `json:"name" validate:"required"`
+  Tags map[string]string `json:"tags"` // e.g. {"region": "EMEA", "tier": "critical"}
+  Actor string `json:"actor" validate:"required"`
+  Reason string `json:"reason"`
+}
+
+func (a *API) handleCreateZone(w http.ResponseWriter, r *http.Request) {
+  var req CreateZoneRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  req.Actor = actorFor(r, req.Actor)
+  if !validRequest(w, r, req) { return }
+  z, err := a.led.CreateZone(r.Context(), ledger.CreateZoneInput{ID: req.ID, Name: req.Name, Tags: req.Tags, Actor: req.Actor, Reason: req.Reason})
+  if err != nil {
+    writeError(w, r, err, 400)
+    return
+  }
+  writeJSON(w, http.StatusCreated, z)
+}
+
+// handleRetireZone takes actor/reason/migrate_to as query params (DELETE has no body).
+func (a *API) handleRetireZone(w http.ResponseWriter, r *http.Request) {
+  zoneID := chi.URLParam(r, "zone_id")
+  q := r.URL.Query()
+  actor := actorFor(r, q.Get("actor"))
+  if actor == "" { writeValidationProblem(w, r, FieldError{Field: "actor", Message: "is required"}); return }
+  err := a.led.RetireZone(r.Context(), zoneID, ledger.RetireZoneInput{
+    MigrateAccountsTo: q.Get("migrate_to"),
+    Actor: actor,
+    Reason: q.Get("reason"),
+  })
+  if err != nil {
+    writeError(w, r, err, 500)
+    return
+  }
+  writeJSON(w, 200, map[string]any{"status": "retired", "zone_id": zoneID})
+}
+
+// --- zone tags ---
+
+// tagSelectorQuery reads the tag query params (key=value, repeated or
+// comma-separated; a zone must carry all of them). No params selects every
+// zone.
+func tagSelectorQuery(w http.ResponseWriter, r *http.Request) (map[string]string, bool) {
+  terms := r.URL.Query()["tag"]
+  if len(terms) == 0 { return nil, true }
+  tags, err := ledger.ParseTagSelector(terms)
+  if err != nil { writeValidationProblem(w, r, FieldError{Field: "tag", Message: err.Error()}); return nil, false }
+  return tags, true
+}
+
+func (a *API) handleGetZoneTags(w http.ResponseWriter, r *http.Request) {
+  t, err := a.led.GetZoneTags(r.Context(), chi.URLParam(r, "zone_id"))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, t)
+}
+
+type TagZoneRequest struct {
+  Tags map[string]string `json:"tags" validate:"required"` // keys lower-cased; existing keys take the new value
+  Actor string `json:"actor" validate:"required"`
+  Reason string `json:"reason"`
+}
+
+func (a *API) handleTagZone(w http.ResponseWriter, r *http.Request) {
+  var req TagZoneRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil { writeProblem(w, r, 400, CodeInvalidJSON, "bad json"); return }
+  req.Actor = actorFor(r, req.Actor)
+  if !validRequest(w, r, req) { return }
+  t, err := a.led.TagZone(r.Context(), chi.URLParam(r, "zone_id"), ledger.TagZoneInput{Tags: req.Tags, Actor: req.Actor, Reason: req.Reason})
+  if err != nil { writeError(w, r, err, 400); return }
+  writeJSON(w, 200, t)
+}
+
+// handleUntagZone takes actor/reason as query params (DELETE has no body).
+func (a *API) handleUntagZone(w http.ResponseWriter, r *http.Request) {
+  q := r.URL.Query()
+  actor := actorFor(r, q.Get("actor"))
+  if actor == "" { writeValidationProblem(w, r, FieldError{Field: "actor", Message: "is required"}); return }
+  t, err := a.led.UntagZone(r.Context(), chi.URLParam(r, "zone_id"), chi.URLParam(r, "key"), actor, q.Get("reason"))
+  if err != nil { writeError(w, r, err, 500); return }
+  writeJSON(w, 200, t)
+}
@@ -18,6 +18,20 @@ struct TransferPosted {
     transaction_id: Option<String>,
     zone_id: Option<String>,
     amount_units: Option<i64>,
+    spool_id: Option<String>,
+    saga_id: Option<String>,
+}
+
+impl TransferPosted {
+    /// Whether the event is a posted transfer. Publishers that predate
+    /// routing by event type sent spool and saga events from the shared
+    /// outbox to events.transfer_posted too, and the stream may still hold
+    /// them.
+    fn posted(&self) -> bool {
+        self.transaction_id.as_deref().is_some_and(|id| !id.is_empty())
+            && self.spool_id.is_none()
+            && self.saga_id.is_none()
+    }
 }
 
 impl FraudConsumer {
@@ -86,6 +100,9 @@ impl FraudConsumer {
         msg: &async_nats::jetstream::message::Message,
     ) -> Result<(), Box<dyn std::error::Error>> {
         let ev: TransferPosted = serde_json::from_slice(&msg.payload)?;
+        if !ev.posted() {
+            return Ok(());
+        }
 
         let event_id = ev
             .event_id
@@ -127,3 +144,25 @@ impl FraudConsumer {
         Ok(())
     }
 }
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn event(json: &str) -> TransferPosted {
+        serde_json::from_str(json).unwrap()
+    }
+
+    #[test]
+    fn only_posted_transfers_count() {
+        assert!(event(r#"{"transaction_id":"t1","amount_units":3600}"#).posted());
+        for json in [
+            r#"{"spool_id":"s1","amount_units":3600}"#,
+            r#"{"spool_id":"s1","transaction_id":"t1","amount_units":3600}"#,
+            r#"{"saga_id":"g1","step":"debit","amount_units":3600}"#,
+            r#"{"transaction_id":"","amount_units":3600}"#,
+        ] {
+            assert!(!event(json).posted(), "{json}");
+        }
+    }
+}
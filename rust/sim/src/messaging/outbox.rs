@@ -4,6 +4,14 @@ use std::time::Duration;
 use tokio_util::sync::CancellationToken;
 use tracing::warn;
 
+/// Maps an outbox event type to its JetStream subject, as the Go publisher
+/// does (TRANSFER_POSTED -> events.transfer_posted). The Go service shares
+/// the outbox table and writes other event types to it (spool, saga and
+/// partition events), so every row goes to its own type's subject.
+fn event_subject(event_type: &str) -> String {
+    format!("events.{}", event_type.to_lowercase())
+}
+
 pub struct OutboxPublisher {
     db: Pool,
     js: jetstream::Context,
@@ -43,6 +51,7 @@ impl OutboxPublisher {
 
         for row in &rows {
             let id: String = row.get("id");
+            let event_type: String = row.get("event_type");
             let payload: serde_json::Value = row.get("payload");
 
             // replace event_id if still placeholder
@@ -60,7 +69,7 @@ impl OutboxPublisher {
             headers.insert("Nats-Msg-Id", id.as_str());
 
             self.js
-                .publish_with_headers::<String>("events.transfer_posted".into(), headers, body.into())
+                .publish_with_headers(event_subject(&event_type), headers, body.into())
                 .await?
                 .await?;
 
@@ -72,3 +81,29 @@ impl OutboxPublisher {
         Ok(())
     }
 }
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn transfer_posted_subject() {
+        assert_eq!(event_subject("TRANSFER_POSTED"), "events.transfer_posted");
+    }
+
+    // Go-only event types must never reach the transfer consumers.
+    #[test]
+    fn other_events_keep_their_subject() {
+        for (event_type, subject) in [
+            ("TRANSFER_SPOOLED", "events.transfer_spooled"),
+            ("SPOOL_ITEM_APPLIED", "events.spool_item_applied"),
+            ("SAGA_STEP_DUE", "events.saga_step_due"),
+            ("ZONE_PARTITIONED", "events.zone_partitioned"),
+            ("ZONE_PARTITION_HEALED", "events.zone_partition_healed"),
+            ("DAY_CLOSED", "events.day_closed"),
+        ] {
+            assert_eq!(event_subject(event_type), subject);
+            assert_ne!(event_subject(event_type), "events.transfer_posted");
+        }
+    }
+}
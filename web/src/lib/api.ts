@@ -38,7 +38,7 @@ export async function api<T = any>(path: string, init?: ReqInit): Promise<T> {
 
   if (!res.ok) {
     const msg =
-      (json && (json.error || json.message)) ? (json.error || json.message) :
+      (json && (json.detail || json.error || json.message)) ? (json.detail || json.error || json.message) :
       (typeof json?.raw === "string" ? json.raw : "") ||
       `HTTP ${res.status}`;
     throw new Error(msg);